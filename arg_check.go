@@ -0,0 +1,225 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+// checkArgValue returns an error if the value of the placeholder can never be an argument of the query,
+// so that the mistake is reported with the placeholder instead of a driver error.
+func checkArgValue(placeholder string, value reflect.Value) error {
+	for value.Kind() == reflect.Interface && !value.IsNil() {
+		value = value.Elem()
+	}
+	switch kind := value.Kind(); kind {
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return fmt.Errorf("%w: %s is a %s", ErrInvalidArgument, placeholder, kind)
+	}
+	return nil
+}
+
+// placeholderSyntax is the lexical syntax of a dialect which matters to find its placeholders.
+type placeholderSyntax struct {
+	// marker is the prefix of the placeholders, ? for the positional ones, $ or : for the indexed ones.
+	marker byte
+	// backslashEscapes reports whether the quotes in the strings can be escaped by backslashes, like 'O\'Reilly'.
+	backslashEscapes bool
+	// hashComments reports whether # starts a comment to the end of the line.
+	hashComments bool
+	// dollarQuotes reports whether the strings can be quoted by $tag$, and escaped by backslashes if prefixed by E.
+	dollarQuotes bool
+}
+
+// placeholderSyntaxes are the syntaxes of the dialects whose placeholders are checked.
+var placeholderSyntaxes = map[string]placeholderSyntax{
+	"mysql":    {marker: '?', backslashEscapes: true, hashComments: true},
+	"sqlite3":  {marker: '?'},
+	"duckdb":   {marker: '?'},
+	"postgres": {marker: '$', dollarQuotes: true},
+	"oracle":   {marker: ':'},
+}
+
+// checkPlaceholders returns an error if the number of the placeholders of the query does not equal
+// the number of the arguments, which is usually caused by a literal placeholder written in the mapper
+// or substituted by ${}. The named placeholders and the unknown dialects are not checked.
+func checkPlaceholders(query string, args []any, translator driver.Translator) error {
+	if _, ok := translator.(driver.NamedTranslator); ok {
+		return nil
+	}
+	syntax, ok := placeholderSyntaxes[driver.DialectOf(translator)]
+	if !ok {
+		return nil
+	}
+	for _, arg := range args {
+		if _, ok := arg.(sql.NamedArg); ok {
+			return nil
+		}
+	}
+	if count := syntax.countPlaceholders(query); count != len(args) {
+		return fmt.Errorf("%w: %d placeholders, %d arguments", ErrPlaceholderMismatch, count, len(args))
+	}
+	return nil
+}
+
+// countPlaceholders returns the number of the placeholders of the query out of the strings, the quoted
+// identifiers and the comments. The marker ? counts every occurrence, and the markers $ and : count
+// the highest index of $1 or :1, since the same index may be used more than once.
+func (p placeholderSyntax) countPlaceholders(query string) (count int) {
+	for i := 0; i < len(query); i++ {
+		if end, ok := p.skipLiteral(query, i); ok {
+			i = end
+			continue
+		}
+		c := query[i]
+		switch {
+		case isIdentifierByte(c):
+			// the markers inside the identifiers, like the $ of a$1, are not placeholders.
+			i = identifierEnd(query, i)
+		case c != p.marker:
+		case c == '?':
+			count++
+		case i+1 < len(query) && '0' <= query[i+1] && query[i+1] <= '9':
+			index := 0
+			for i+1 < len(query) && '0' <= query[i+1] && query[i+1] <= '9' {
+				i++
+				index = index*10 + int(query[i]-'0')
+			}
+			count = max(count, index)
+		case i+1 < len(query) && query[i+1] == c:
+			// the casts like ::int are not placeholders.
+			i++
+		}
+	}
+	return count
+}
+
+// returningClause reports whether the query has a RETURNING clause out of the strings and the comments,
+// and returns the end of the last token of the query, which is before the trailing semicolons and comments.
+func (p placeholderSyntax) returningClause(query string) (found bool, end int) {
+	for i := 0; i < len(query); i++ {
+		literalEnd, ok := p.skipLiteral(query, i)
+		switch c := query[i]; {
+		case ok:
+			// the strings are the tokens of the query, while the comments are not.
+			if c == '\'' || c == '"' || c == '`' || c == '$' {
+				end = min(literalEnd+1, len(query))
+			}
+			i = literalEnd
+		case isIdentifierByte(c):
+			start := i
+			i = identifierEnd(query, i)
+			if strings.EqualFold(query[start:i+1], "returning") {
+				found = true
+			}
+			end = i + 1
+		case c == ';' || c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			end = i + 1
+		}
+	}
+	return found, end
+}
+
+// skipLiteral returns the index of the last byte of the string, the quoted identifier or the comment
+// starting at i, which is the last byte of the query if it is not closed.
+// It reports false if there is no such literal at i.
+func (p placeholderSyntax) skipLiteral(query string, i int) (int, bool) {
+	last := len(query) - 1
+	switch c := query[i]; {
+	case c == '\'' || c == '"' || c == '`':
+		escaped := p.backslashEscapes && c != '`'
+		// the E'' strings of PostgreSQL are escaped by backslashes.
+		if p.dollarQuotes && c == '\'' && i > 0 && (query[i-1] == 'E' || query[i-1] == 'e') && (i == 1 || !isIdentifierByte(query[i-2])) {
+			escaped = true
+		}
+		if end := closingQuote(query, i+1, c, escaped); end != -1 {
+			return end, true
+		}
+		return last, true
+	case c == '-' && i+1 < len(query) && query[i+1] == '-', c == '#' && p.hashComments:
+		if end := indexFrom(query, "\n", i); end != -1 {
+			return end, true
+		}
+		return last, true
+	case c == '/' && i+1 < len(query) && query[i+1] == '*':
+		if end := indexFrom(query, "*/", i+2); end != -1 {
+			return end + 1, true
+		}
+		return last, true
+	case c == '$' && p.dollarQuotes && i+1 < len(query) && !('0' <= query[i+1] && query[i+1] <= '9'):
+		// the dollar quoted strings, like $$ ... $$ or $body$ ... $body$.
+		tagEnd := i + 1
+		for tagEnd < len(query) && isIdentifierByte(query[tagEnd]) {
+			tagEnd++
+		}
+		if tagEnd == len(query) || query[tagEnd] != '$' {
+			return 0, false
+		}
+		tag := query[i : tagEnd+1]
+		if end := indexFrom(query, tag, tagEnd+1); end != -1 {
+			return end + len(tag) - 1, true
+		}
+		return last, true
+	}
+	return 0, false
+}
+
+// identifierEnd returns the index of the last byte of the unquoted identifier starting at i,
+// the $ of the identifiers like a$1 included.
+func identifierEnd(query string, i int) int {
+	for i+1 < len(query) && (isIdentifierByte(query[i+1]) || query[i+1] == '$') {
+		i++
+	}
+	return i
+}
+
+// closingQuote returns the index of the quote closing the string starting from the start, or -1 if not found.
+func closingQuote(query string, start int, quote byte, escaped bool) int {
+	for i := start; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if escaped {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// isIdentifierByte reports whether the byte can be a part of an unquoted identifier or a number.
+func isIdentifierByte(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c >= 0x80
+}
+
+// indexFrom returns the index of the first substr in s from the start, or -1 if not found.
+func indexFrom(s, substr string, start int) int {
+	if start > len(s) {
+		return -1
+	}
+	if index := strings.Index(s[start:], substr); index != -1 {
+		return start + index
+	}
+	return -1
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/eval"
+)
+
+func TestCheckArgValue(t *testing.T) {
+	node := NewTextNode("select * from user where id = #{id}")
+	param := eval.NewGenericParam(eval.H{"id": func() {}}, "")
+	_, _, err := node.Accept(driver.MySQLDriver{}.Translator(), param)
+	if !errors.Is(err, ErrInvalidArgument) {
+		t.Fatalf("expected ErrInvalidArgument, got %v", err)
+	}
+	if err.Error() != "invalid argument: #{id} is a func" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	param = eval.NewGenericParam(eval.H{"id": make(chan int)}, "")
+	if _, _, err = node.Accept(driver.MySQLDriver{}.Translator(), param); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestCheckPlaceholders(t *testing.T) {
+	tests := []struct {
+		name   string
+		driver driver.Driver
+		query  string
+		args   []any
+		err    bool
+	}{
+		{name: "mysql", driver: driver.MySQLDriver{}, query: "select * from user where id = ? and name = ?", args: []any{1, "eat"}},
+		{name: "mysql literal", driver: driver.MySQLDriver{}, query: "select * from user where id = ? and name = ?", args: []any{1}, err: true},
+		{name: "mysql quoted", driver: driver.MySQLDriver{}, query: "select '?', `a?`, \"b?\" from user -- why?\n where id = ? /* ? */", args: []any{1}},
+		{name: "postgres", driver: driver.PostgresDriver{}, query: "select $1::int, $2 from user where id = $1", args: []any{1, 2}},
+		{name: "postgres missing", driver: driver.PostgresDriver{}, query: "select $1, $3", args: []any{1, 2}, err: true},
+		{name: "oracle", driver: driver.OracleDriver{}, query: "select * from user where id = :1 and name = :2", args: []any{1, 2}},
+		{name: "mysql backslash escape", driver: driver.MySQLDriver{}, query: `select * from user where name = 'O\'Reilly' and note = "say \"?\"" and id = ?`, args: []any{1}},
+		{name: "mysql hash comment", driver: driver.MySQLDriver{}, query: "select * from user where id = ? # why?\n and age > ?", args: []any{1, 2}},
+		{name: "sqlite no backslash escape", driver: driver.SQLiteDriver{}, query: `select 'C:\' , ? from user`, args: []any{1}},
+		{name: "postgres escape string", driver: driver.PostgresDriver{}, query: `select E'it\'s $2', $1`, args: []any{1}},
+		{name: "postgres standard string", driver: driver.PostgresDriver{}, query: `select 'C:\', $1`, args: []any{1}},
+		{name: "postgres dollar quotes", driver: driver.PostgresDriver{}, query: "select $$ $2 $$, $body$ it's $3 $body$, $1", args: []any{1}},
+		{name: "postgres identifier", driver: driver.PostgresDriver{}, query: "select a$1, $1 from t$2", args: []any{1}},
+		{name: "postgres jsonb", driver: driver.PostgresDriver{}, query: "select * from t where data ? 'key' and id = $1", args: []any{1}},
+		{name: "oracle quoted", driver: driver.OracleDriver{}, query: "select ':2' from dual where id = :1 -- :3", args: []any{1}},
+		{name: "named", driver: driver.WithNamedPlaceholders(driver.MySQLDriver{}, "@"), query: "select ?", args: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPlaceholders(tt.query, tt.args, tt.driver.Translator())
+			if tt.err != errors.Is(err, ErrPlaceholderMismatch) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
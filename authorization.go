@@ -0,0 +1,123 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Authorizer checks whether the caller from the context meets the requirement declared by
+// the requires attribute of the statements.
+type Authorizer interface {
+	// Authorize returns a non-nil error to deny the statement.
+	Authorize(ctx context.Context, requirement string) error
+}
+
+// AuthorizerFunc is an adapter to allow the use of ordinary functions as Authorizer.
+type AuthorizerFunc func(ctx context.Context, requirement string) error
+
+// Authorize calls f(ctx, requirement).
+func (f AuthorizerFunc) Authorize(ctx context.Context, requirement string) error {
+	return f(ctx, requirement)
+}
+
+// AuthorizationError is the error that is returned when a requirement of the statement is not met.
+type AuthorizationError struct {
+	Statement   string
+	Requirement string
+	Err         error
+}
+
+// Error implements error.
+func (e *AuthorizationError) Error() string {
+	return fmt.Sprintf("statement %q requires %q: %v", e.Statement, e.Requirement, e.Err)
+}
+
+// Unwrap returns the error returned by the Authorizer.
+func (e *AuthorizationError) Unwrap() error {
+	return e.Err
+}
+
+// ensure AuthorizationMiddleware implements Middleware.
+var _ Middleware = (*AuthorizationMiddleware)(nil) // compile time check
+
+// AuthorizationMiddleware is a middleware that checks the requirements declared by the requires attribute
+// of the statements before execution. Multiple requirements are separated by commas, and all of them must be met.
+//
+//	<delete id="DeleteOrder" requires="orders:write">
+//	    delete from orders where id = #{id}
+//	</delete>
+//
+// It is usually registered by Engine.SetAuthorizer.
+type AuthorizationMiddleware struct {
+	Authorizer Authorizer
+}
+
+// QueryContext implements Middleware.
+func (m *AuthorizationMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryHandler {
+	requirements := m.requirements(stmt)
+	if len(requirements) == 0 {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		if err := m.authorize(ctx, stmt, requirements); err != nil {
+			return nil, err
+		}
+		return next(ctx, query, args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *AuthorizationMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHandler {
+	requirements := m.requirements(stmt)
+	if len(requirements) == 0 {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		if err := m.authorize(ctx, stmt, requirements); err != nil {
+			return nil, err
+		}
+		return next(ctx, query, args...)
+	}
+}
+
+// requirements returns the requirements of the statement.
+func (m *AuthorizationMiddleware) requirements(stmt Statement) []string {
+	if m.Authorizer == nil {
+		return nil
+	}
+	var requirements []string
+	for _, requirement := range strings.Split(stmt.Attribute("requires"), ",") {
+		if requirement = strings.TrimSpace(requirement); requirement != "" {
+			requirements = append(requirements, requirement)
+		}
+	}
+	return requirements
+}
+
+// authorize checks all the requirements.
+func (m *AuthorizationMiddleware) authorize(ctx context.Context, stmt Statement, requirements []string) error {
+	for _, requirement := range requirements {
+		if err := m.Authorizer.Authorize(ctx, requirement); err != nil {
+			return &AuthorizationError{Statement: stmt.Name(), Requirement: requirement, Err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// grantsKey is the context key of the granted requirements of the tests.
+type grantsKey struct{}
+
+func TestEngine_SetAuthorizer(t *testing.T) {
+	connector := newEchoConnector()
+	engine := newEchoEngine(t, connector)
+
+	var checked []string
+	errDenied := errors.New("denied")
+	engine.SetAuthorizer(AuthorizerFunc(func(ctx context.Context, requirement string) error {
+		checked = append(checked, requirement)
+		grants, _ := ctx.Value(grantsKey{}).([]string)
+		if !slices.Contains(grants, requirement) {
+			return errDenied
+		}
+		return nil
+	}))
+	query := func(ctx context.Context, id string) error {
+		_, err := NewGenericManager[[]string](engine).Object(id).QueryContext(ctx, H{"value": "a"})
+		return err
+	}
+
+	// the statements without requirements are not checked.
+	if err := query(context.Background(), "echo.Echo"); err != nil || len(checked) != 0 {
+		t.Fatalf("expected no check, got %v and %v", checked, err)
+	}
+
+	ctx := context.WithValue(context.Background(), grantsKey{}, []string{"secrets:read"})
+	err := query(ctx, "echo.EchoSecret")
+	var authorizationError *AuthorizationError
+	if !errors.As(err, &authorizationError) || !errors.Is(err, errDenied) {
+		t.Fatalf("expected the authorization error, got %v", err)
+	}
+	if authorizationError.Statement != "echo.EchoSecret" || authorizationError.Requirement != "audit" {
+		t.Fatalf("unexpected authorization error: %+v", authorizationError)
+	}
+	if connector.queries.Load() != 1 {
+		t.Fatalf("expected the denied statement not to be queried, got %d queries", connector.queries.Load())
+	}
+
+	checked = nil
+	ctx = context.WithValue(context.Background(), grantsKey{}, []string{"secrets:read", "audit"})
+	if err = query(ctx, "echo.EchoSecret"); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(checked, []string{"secrets:read", "audit"}) {
+		t.Fatalf("expected all the requirements to be checked, got %v", checked)
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/internal/ctxreducer"
+	"github.com/go-juicedev/juice/session"
+)
+
+// BackendExecutor is the Executor which runs the statements on a session.Backend,
+// for the backends beyond database/sql, like the native pgx pool, the ClickHouse native protocol
+// or an HTTP SQL gateway. The statements are built with the driver, executed through the middlewares,
+// and the rows are bound like GenericExecutor does.
+//
+// The rows of the backend are not the ones of database/sql, so the middlewares handling the rows,
+// like the ones wrapping or replacing them, must implement BackendQueryMiddleware. The other middlewares
+// are adapted from their QueryContext, whose next handler returns a nil *sql.Rows, and the query fails
+// with an error if they return their own *sql.Rows.
+//
+//	exe, err := juice.BackendExecutorOf[User](engine, UserRepository.FindByID, backend)
+//	if err != nil {
+//		return err
+//	}
+//	user, err := exe.QueryContext(ctx, param)
+type BackendExecutor[T any] struct {
+	statement   Statement
+	driver      driver.Driver
+	backend     session.Backend
+	middlewares MiddlewareGroup
+}
+
+// NewBackendExecutor returns a BackendExecutor of the statement, which is executed through the middlewares.
+func NewBackendExecutor[T any](statement Statement, driver driver.Driver, backend session.Backend, middlewares ...Middleware) *BackendExecutor[T] {
+	return &BackendExecutor[T]{statement: statement, driver: driver, backend: backend, middlewares: middlewares}
+}
+
+// BackendExecutorOf returns a BackendExecutor of the statement of v, which is built with the driver
+// of the engine and executed through the middlewares of the engine, like the logging and the timeout.
+func BackendExecutorOf[T any](engine *Engine, v any, backend session.Backend) (*BackendExecutor[T], error) {
+	statement, err := engine.StatementOf(v)
+	if err != nil {
+		return nil, err
+	}
+	return NewBackendExecutor[T](statement, engine.Driver(), backend, engine.middlewares...), nil
+}
+
+// errNoBackendRows is returned when a middleware returns without querying the backend.
+var errNoBackendRows = errors.New("no rows returned by the backend")
+
+// errBackendRowsReplaced is returned when a middleware without BackendQueryMiddleware replaces
+// the rows of the backend with its own *sql.Rows.
+var errBackendRowsReplaced = errors.New("the rows of the backend are replaced by *sql.Rows, implement BackendQueryMiddleware to handle them")
+
+// BackendQueryMiddleware is implemented by the middlewares which handle the rows of the queries
+// executed by BackendExecutor, whose rows are session.Rows instead of *sql.Rows.
+type BackendQueryMiddleware interface {
+	// BackendQueryContext wraps the query handler of the backend.
+	BackendQueryContext(stmt Statement, next Handler[session.Rows]) Handler[session.Rows]
+}
+
+// ensure MiddlewareGroup implements BackendQueryMiddleware.
+var _ BackendQueryMiddleware = MiddlewareGroup(nil) // compile time check
+
+// BackendQueryContext implements BackendQueryMiddleware.
+// The middlewares which do not implement BackendQueryMiddleware are adapted from their QueryContext.
+func (m MiddlewareGroup) BackendQueryContext(stmt Statement, next Handler[session.Rows]) Handler[session.Rows] {
+	for _, middleware := range m {
+		if backendMiddleware, ok := middleware.(BackendQueryMiddleware); ok {
+			next = backendMiddleware.BackendQueryContext(stmt, next)
+			continue
+		}
+		next = backendQueryAdapter(stmt, middleware, next)
+	}
+	return next
+}
+
+// backendQueryAdapter adapts the QueryContext of the middleware to the query handler of the backend,
+// the rows of the backend are passed around the middleware, which receives a nil *sql.Rows.
+func backendQueryAdapter(stmt Statement, middleware Middleware, next Handler[session.Rows]) Handler[session.Rows] {
+	return func(ctx context.Context, query string, args ...any) (session.Rows, error) {
+		var rows session.Rows
+		queryHandler := middleware.QueryContext(stmt, func(ctx context.Context, query string, args ...any) (_ *sql.Rows, err error) {
+			rows, err = next(ctx, query, args...)
+			return nil, err
+		})
+		sqlRows, err := queryHandler(ctx, query, args...)
+		if sqlRows != nil {
+			_ = sqlRows.Close()
+			if rows != nil {
+				_ = rows.Close()
+			}
+			return nil, fmt.Errorf("middleware %T: %w", middleware, errBackendRowsReplaced)
+		}
+		if err != nil {
+			if rows != nil {
+				_ = rows.Close()
+			}
+			return nil, err
+		}
+		if rows == nil {
+			return nil, errNoBackendRows
+		}
+		return rows, nil
+	}
+}
+
+// QueryContext builds the statement, queries the backend through the middlewares and binds the rows.
+func (e *BackendExecutor[T]) QueryContext(ctx context.Context, param Param) (result T, err error) {
+	retMap, err := e.statement.ResultMap()
+	// ErrResultMapNotSet means the result map is not set, use the default result map.
+	if err != nil {
+		if !errors.Is(err, ErrResultMapNotSet) {
+			return result, err
+		}
+		retMap = defaultResultMap[T](e.statement)
+	}
+	query, args, err := buildStatement(ctx, e.statement, e.driver.Translator(), param)
+	if err != nil {
+		return result, err
+	}
+	// the rows scanned are counted against the row quota of the statement, see QuotaMiddleware.
+	ctx, scanned := withScannedRows(e.context(ctx, param), e.statement)
+	// the backends receive the values of the redacted arguments, which are masked by the logs.
+	args, redacted := unredactArgs(args)
+	queryHandler := e.middlewares.BackendQueryContext(e.statement, e.backend.QueryContext)
+	rows, err := queryHandler(withRedactedArgs(ctx, redacted), query, args...)
+	if err != nil {
+		return result, err
+	}
+	if rows == nil {
+		return result, errNoBackendRows
+	}
+	defer func() { _ = rows.Close() }()
+	if result, err = BindWithResultMap[T](rows, retMap); err == nil {
+		recordResultRows(e.statement, result)
+		scanned.done(resultRows(result))
+	}
+	return result, err
+}
+
+// ExecContext builds the statement and executes it on the backend through the middlewares.
+func (e *BackendExecutor[_]) ExecContext(ctx context.Context, param Param) (sql.Result, error) {
+	query, args, err := buildStatement(ctx, e.statement, e.driver.Translator(), param)
+	if err != nil {
+		return nil, err
+	}
+	ctx = e.context(ctx, param)
+	// the inserts returning their generated keys are queried on the backend, see useGeneratedKeysMiddleware.
+	ctx = context.WithValue(ctx, returningQueryHandlerKey{}, Handler[session.Rows](e.backend.QueryContext))
+	execHandler := func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return e.backend.ExecContext(ctx, query, args...)
+	}
+	// the backends receive the values of the redacted arguments, which are masked by the logs.
+	args, redacted := unredactArgs(args)
+	return e.middlewares.ExecContext(e.statement, execHandler)(withRedactedArgs(ctx, redacted), query, args...)
+}
+
+// context returns the context of the middlewares with the param and the driver, like CompiledStatementHandler does.
+func (e *BackendExecutor[_]) context(ctx context.Context, param Param) context.Context {
+	contextReducer := ctxreducer.G{
+		ctxreducer.NewParamContextReducer(param),
+		ctxreducer.NewDriverContextReducer(e.driver),
+	}
+	return contextReducer.Reduce(ctx)
+}
+
+// Statement returns the statement of the executor.
+func (e *BackendExecutor[_]) Statement() Statement { return e.statement }
+
+// Driver returns the driver of the executor.
+func (e *BackendExecutor[_]) Driver() driver.Driver { return e.driver }
+
+// ensure BackendExecutor implements Executor.
+var _ Executor[any] = (*BackendExecutor[any])(nil)
@@ -0,0 +1,305 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/session"
+)
+
+// memoryRows is the rows of the memoryBackend, which are not *sql.Rows.
+type memoryRows struct {
+	columns []string
+	values  [][]any
+	index   int
+	closed  bool
+}
+
+func (r *memoryRows) Columns() ([]string, error) { return r.columns, nil }
+
+func (r *memoryRows) Next() bool {
+	r.index++
+	return r.index <= len(r.values)
+}
+
+func (r *memoryRows) Scan(dest ...any) error {
+	for i, value := range r.values[r.index-1] {
+		switch d := dest[i].(type) {
+		case *int64:
+			*d = value.(int64)
+		case *string:
+			*d = value.(string)
+		case *any:
+			*d = value
+		case sql.Scanner:
+			if err := d.Scan(value); err != nil {
+				return err
+			}
+		default:
+			return errors.New("unexpected destination")
+		}
+	}
+	return nil
+}
+
+func (r *memoryRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *memoryRows) Err() error { return nil }
+
+type memoryResult int64
+
+func (r memoryResult) LastInsertId() (int64, error) { return 0, nil }
+
+func (r memoryResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+// memoryBackend is a session.Backend without database/sql.
+type memoryBackend struct {
+	queries []string
+	args    [][]any
+	rows    *memoryRows
+}
+
+func (b *memoryBackend) QueryContext(_ context.Context, query string, args ...any) (session.Rows, error) {
+	b.queries, b.args = append(b.queries, query), append(b.args, args)
+	return b.rows, nil
+}
+
+func (b *memoryBackend) ExecContext(_ context.Context, query string, args ...any) (session.Result, error) {
+	b.queries, b.args = append(b.queries, query), append(b.args, args)
+	return memoryResult(1), nil
+}
+
+type backendUser struct {
+	ID   int64  `column:"id"`
+	Name string `column:"name"`
+}
+
+type backendScannedUser struct{}
+
+func (*backendScannedUser) ScanRows(*sql.Rows) error { return nil }
+
+func TestBackendExecutor(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="List">select id, name from user where id > #{id}</select>
+    <update id="Rename">update user set name = #{name} where id = #{id}</update>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	backend := &memoryBackend{rows: &memoryRows{
+		columns: []string{"id", "name"},
+		values:  [][]any{{int64(1), "eat"}, {int64(2), "apple"}},
+	}}
+	ctx := context.Background()
+
+	users, err := NewBackendExecutor[[]backendUser](m.statements["List"], driver.PostgresDriver{}, backend).
+		QueryContext(ctx, H{"id": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[1] != (backendUser{ID: 2, Name: "apple"}) {
+		t.Fatalf("unexpected users: %v", users)
+	}
+	if !backend.rows.closed {
+		t.Error("expected the rows to be closed")
+	}
+	if backend.queries[0] != "select id, name from user where id > $1" {
+		t.Errorf("unexpected query: %s", backend.queries[0])
+	}
+
+	result, err := NewBackendExecutor[any](m.statements["Rename"], driver.PostgresDriver{}, backend).
+		ExecContext(ctx, H{"id": 1, "name": "juice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 1 {
+		t.Errorf("unexpected rows affected: %d", affected)
+	}
+
+	backend.rows.index = 0
+	_, err = NewBackendExecutor[[]backendScannedUser](m.statements["List"], driver.PostgresDriver{}, backend).
+		QueryContext(ctx, H{"id": 0})
+	if !errors.Is(err, errRowScannerRequiresSQLRows) {
+		t.Errorf("expected errRowScannerRequiresSQLRows, got %v", err)
+	}
+}
+
+// recordingMiddleware records the queries passing through it.
+type recordingMiddleware struct{ queries []string }
+
+func (m *recordingMiddleware) QueryContext(_ Statement, next QueryHandler) QueryHandler {
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		m.queries = append(m.queries, query)
+		return next(ctx, query, args...)
+	}
+}
+
+func (m *recordingMiddleware) ExecContext(_ Statement, next ExecHandler) ExecHandler {
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		m.queries = append(m.queries, query)
+		return next(ctx, query, args...)
+	}
+}
+
+// failingBackend is the backend whose executions fail with the unique violations of SQLite.
+type failingBackend struct{ memoryBackend }
+
+func (b *failingBackend) ExecContext(context.Context, string, ...any) (session.Result, error) {
+	return nil, errors.New("UNIQUE constraint failed: user.name")
+}
+
+func TestBackendExecutorOf(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="List">select id, name from user</select>
+    <update id="Rename">update user set name = #{name} where id = #{id}</update>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mappers Mappers
+	if err = mappers.setMapper(m.namespace, m); err != nil {
+		t.Fatal(err)
+	}
+	engine := &Engine{configuration: Configuration{mappers: &mappers}, driver: driver.SQLiteDriver{}, rw: &NoOpRWMutex{}}
+	recorder := &recordingMiddleware{}
+	engine.Use(recorder)
+	engine.Use(&ErrorTranslatorMiddleware{})
+
+	backend := &failingBackend{memoryBackend{rows: &memoryRows{columns: []string{"id", "name"}, values: [][]any{{int64(1), "eat"}}}}}
+	list, err := BackendExecutorOf[[]backendUser](engine, "main.User.List", backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := list.QueryContext(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Name != "eat" {
+		t.Fatalf("unexpected users: %v", users)
+	}
+
+	rename, err := BackendExecutorOf[any](engine, "main.User.Rename", backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the error of the backend is translated by the middleware of the engine.
+	if _, err = rename.ExecContext(context.Background(), H{"id": 1, "name": "eat"}); !errors.As(err, new(ErrDuplicateKey)) {
+		t.Fatalf("expected ErrDuplicateKey, got %v", err)
+	}
+	if len(recorder.queries) != 2 || recorder.queries[1] != "update user set name = ? where id = ?" {
+		t.Fatalf("expected the queries to pass through the middlewares, got %q", recorder.queries)
+	}
+	if _, err = BackendExecutorOf[any](engine, "main.User.Missing", backend); err == nil {
+		t.Fatal("expected an error for the missing statement")
+	}
+}
+
+// upperRows upper cases the strings of the wrapped rows.
+type upperRows struct{ session.Rows }
+
+func (r upperRows) Scan(dest ...any) error {
+	if err := r.Rows.Scan(dest...); err != nil {
+		return err
+	}
+	for _, d := range dest {
+		if s, ok := d.(*string); ok {
+			*s = strings.ToUpper(*s)
+		}
+	}
+	return nil
+}
+
+// upperMiddleware wraps the rows of the backends with upperRows.
+type upperMiddleware struct{ recordingMiddleware }
+
+func (m *upperMiddleware) BackendQueryContext(_ Statement, next Handler[session.Rows]) Handler[session.Rows] {
+	return func(ctx context.Context, query string, args ...any) (session.Rows, error) {
+		rows, err := next(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		return upperRows{Rows: rows}, nil
+	}
+}
+
+// replacingMiddleware replaces the rows of the queries with the ones of its db.
+type replacingMiddleware struct {
+	recordingMiddleware
+	db *sql.DB
+}
+
+func (m *replacingMiddleware) QueryContext(_ Statement, next QueryHandler) QueryHandler {
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		if _, err := next(ctx, query, args...); err != nil {
+			return nil, err
+		}
+		return m.db.QueryContext(ctx, "select 1")
+	}
+}
+
+func TestBackendExecutor_RowsMiddlewares(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="List">select id, name from user</select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	backend := &memoryBackend{rows: &memoryRows{columns: []string{"id", "name"}, values: [][]any{{int64(1), "eat"}}}}
+	ctx := context.Background()
+
+	recorder, upper := &recordingMiddleware{}, &upperMiddleware{}
+	users, err := NewBackendExecutor[[]backendUser](m.statements["List"], driver.SQLiteDriver{}, backend, recorder, MiddlewareGroup{upper}).
+		QueryContext(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Name != "EAT" {
+		t.Fatalf("expected the rows wrapped by the middleware, got %v", users)
+	}
+	if len(recorder.queries) != 1 {
+		t.Errorf("expected the query to pass through the adapted middleware, got %q", recorder.queries)
+	}
+
+	db := sql.OpenDB(newEchoConnector())
+	defer func() { _ = db.Close() }()
+	backend.rows.index, backend.rows.closed = 0, false
+	_, err = NewBackendExecutor[[]backendUser](m.statements["List"], driver.SQLiteDriver{}, backend, &replacingMiddleware{db: db}).
+		QueryContext(ctx, nil)
+	if !errors.Is(err, errBackendRowsReplaced) {
+		t.Fatalf("expected errBackendRowsReplaced, got %v", err)
+	}
+	if !backend.rows.closed {
+		t.Error("expected the rows of the backend to be closed")
+	}
+}
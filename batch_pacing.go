@@ -0,0 +1,167 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrBatchCutoff is the cause of the BatchStoppedError when the cutoff of the BatchPacing is reached.
+var ErrBatchCutoff = errors.New("batch cutoff reached")
+
+// BatchPacing paces the batches of a batch insert, so that a long-running backfill can yield to the
+// production traffic and stop cleanly at a cutoff time. The context is also checked between the batches,
+// and the insert stops before the next batch once the context is done.
+//
+// It is set by ContextWithBatchPacing, or by the batchRowsPerSecond and batchInterval attributes of the
+// insert statement.
+//
+//	<insert id="Backfill" batchSize="500" batchRowsPerSecond="2000" batchInterval="50ms">
+//	    ...
+//	</insert>
+type BatchPacing struct {
+	// RowsPerSecond is the maximum number of rows inserted per second, zero means unlimited.
+	RowsPerSecond int
+
+	// Interval is the minimum sleep between two batches.
+	Interval time.Duration
+
+	// Cutoff is the time after which no batch is started, zero means no cutoff.
+	Cutoff time.Time
+}
+
+// batchPacingKey is the context key of the BatchPacing.
+type batchPacingKey struct{}
+
+// ContextWithBatchPacing returns a new context carrying the pacing of the batch inserts executed with it,
+// which takes precedence over the attributes of the statement.
+//
+//	ctx = juice.ContextWithBatchPacing(ctx, juice.BatchPacing{RowsPerSecond: 2000, Cutoff: sixAM})
+func ContextWithBatchPacing(ctx context.Context, pacing BatchPacing) context.Context {
+	return context.WithValue(ctx, batchPacingKey{}, pacing)
+}
+
+// BatchPacingFromContext returns the BatchPacing set by ContextWithBatchPacing.
+func BatchPacingFromContext(ctx context.Context) (BatchPacing, bool) {
+	pacing, ok := ctx.Value(batchPacingKey{}).(BatchPacing)
+	return pacing, ok
+}
+
+// BatchStoppedError is returned when a batch insert is stopped between the batches,
+// by the cutoff of the BatchPacing or by the context. The rows before Rows are inserted.
+type BatchStoppedError struct {
+	// Rows is the number of the rows inserted before the insert is stopped.
+	Rows int
+	// Total is the number of the rows of the insert.
+	Total int
+	err   error
+}
+
+// Error returns the error message.
+func (e *BatchStoppedError) Error() string {
+	return fmt.Sprintf("batch insert stopped after %d of %d rows: %v", e.Rows, e.Total, e.err)
+}
+
+// Unwrap returns the cause, ErrBatchCutoff or the error of the context.
+func (e *BatchStoppedError) Unwrap() error {
+	return e.err
+}
+
+// batchPacingOf returns the pacing of the statement executed with the context.
+func batchPacingOf(ctx context.Context, statement Statement) (BatchPacing, error) {
+	if pacing, ok := BatchPacingFromContext(ctx); ok {
+		return pacing, nil
+	}
+	var pacing BatchPacing
+	if value := statement.Attribute("batchRowsPerSecond"); value != "" {
+		rowsPerSecond, err := strconv.Atoi(value)
+		if err != nil || rowsPerSecond < 0 {
+			return pacing, fmt.Errorf("invalid batchRowsPerSecond %q", value)
+		}
+		pacing.RowsPerSecond = rowsPerSecond
+	}
+	if value := statement.Attribute("batchInterval"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < 0 {
+			return pacing, fmt.Errorf("invalid batchInterval %q", value)
+		}
+		pacing.Interval = interval
+	}
+	return pacing, nil
+}
+
+// batchPacer waits between the batches by the BatchPacing.
+type batchPacer struct {
+	pacing BatchPacing
+	start  time.Time
+	// now and sleep are for testing.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// newBatchPacer returns a batchPacer started now.
+func newBatchPacer(pacing BatchPacing) *batchPacer {
+	return &batchPacer{pacing: pacing, start: time.Now(), now: time.Now, sleep: sleepContext}
+}
+
+// wait waits until the batch after the given rows can be started, it returns a BatchStoppedError
+// instead of waiting past the cutoff or the deadline of the context.
+func (p *batchPacer) wait(ctx context.Context, rows, total int) error {
+	stopped := func(err error) error { return &BatchStoppedError{Rows: rows, Total: total, err: err} }
+	if err := ctx.Err(); err != nil {
+		return stopped(err)
+	}
+	now := p.now()
+	var delay time.Duration
+	if rows > 0 {
+		delay = p.pacing.Interval
+		if p.pacing.RowsPerSecond > 0 {
+			due := p.start.Add(time.Duration(rows) * time.Second / time.Duration(p.pacing.RowsPerSecond))
+			delay = max(delay, due.Sub(now))
+		}
+	}
+	resume := now.Add(delay)
+	if !p.pacing.Cutoff.IsZero() && !resume.Before(p.pacing.Cutoff) {
+		return stopped(ErrBatchCutoff)
+	}
+	if deadline, ok := ctx.Deadline(); ok && !resume.Before(deadline) {
+		return stopped(context.DeadlineExceeded)
+	}
+	if delay <= 0 {
+		return nil
+	}
+	if err := p.sleep(ctx, delay); err != nil {
+		return stopped(err)
+	}
+	return nil
+}
+
+// sleepContext sleeps for the duration, it returns the error of the context if the context is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
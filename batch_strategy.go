@@ -0,0 +1,215 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/session"
+)
+
+// BatchStrategy is the strategy of executing the batch inserts, which is declared by the batchStrategy
+// attribute of the insert statement, or the batchStrategy setting for all of them.
+//
+//	<insert id="BatchInsert" batchSize="100" batchStrategy="auto">
+//	    insert into user (name, age) values
+//	    <foreach collection="list" item="user" separator=",">
+//	        (#{user.name}, #{user.age})
+//	    </foreach>
+//	</insert>
+type BatchStrategy string
+
+const (
+	// BatchStrategyValues inserts every batch by a single statement with multiple rows of VALUES.
+	// It is the default strategy.
+	BatchStrategyValues BatchStrategy = "values"
+
+	// BatchStrategyExec inserts the rows one by one, by executing the single row statement
+	// repeatedly over one prepared statement.
+	BatchStrategyExec BatchStrategy = "exec"
+
+	// BatchStrategyBulk inserts all the rows by the bulk API of the driver, see driver.BulkExecer.
+	BatchStrategyBulk BatchStrategy = "bulk"
+
+	// BatchStrategyAuto picks the strategy by the capabilities of the driver and the number of rows.
+	BatchStrategyAuto BatchStrategy = "auto"
+)
+
+// errBulkNotSupported is returned when the bulk strategy is used by a driver that does not implement driver.BulkExecer.
+var errBulkNotSupported = errors.New("bulk batch strategy is not supported by the driver")
+
+// batchStrategyOf returns the batch strategy of the statement.
+func batchStrategyOf(statement Statement) (BatchStrategy, error) {
+	value := statement.Attribute("batchStrategy")
+	if value == "" {
+		if cfg := statement.Configuration(); cfg != nil {
+			value = cfg.Settings().Get("batchStrategy").String()
+		}
+	}
+	switch strategy := BatchStrategy(value); strategy {
+	case "":
+		return BatchStrategyValues, nil
+	case BatchStrategyValues, BatchStrategyExec, BatchStrategyBulk, BatchStrategyAuto:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("invalid batch strategy %q", value)
+	}
+}
+
+// resolve returns the strategy to execute the rows, the auto strategy is resolved as:
+//  1. bulk, if the driver implements driver.BulkExecer and the rows can not be inserted by one batch
+//  2. values, if the driver supports multiple rows of VALUES
+//  3. exec, otherwise
+func (b BatchStrategy) resolve(drv driver.Driver, rows int, batchSize int64) BatchStrategy {
+	if b != BatchStrategyAuto {
+		return b
+	}
+	if _, ok := drv.(driver.BulkExecer); ok && int64(rows) > batchSize {
+		return BatchStrategyBulk
+	}
+	if driver.SupportsMultiRowValues(drv) {
+		return BatchStrategyValues
+	}
+	return BatchStrategyExec
+}
+
+// batchExecutor executes the rows of a batch insert by the strategy.
+type batchExecutor struct {
+	driver      driver.Driver
+	middlewares MiddlewareGroup
+	session     session.Session
+	batchSize   int64
+}
+
+// execContext executes the rows in batches, the chunk returns the parameter of the rows from start to end.
+func (b batchExecutor) execContext(ctx context.Context, statement Statement, param Param, rows int, chunk func(start, end int) Param) (result sql.Result, err error) {
+	strategy, err := batchStrategyOf(statement)
+	if err != nil {
+		return nil, err
+	}
+	batchSize := int(b.batchSize)
+
+	pacing, err := batchPacingOf(ctx, statement)
+	if err != nil {
+		return nil, err
+	}
+	pacer := newBatchPacer(pacing)
+	if err = pacer.wait(ctx, 0, rows); err != nil {
+		return nil, err
+	}
+
+	switch strategy.resolve(b.driver, rows, b.batchSize) {
+	case BatchStrategyBulk:
+		return b.execBulk(ctx, statement, param, rows, chunk)
+	case BatchStrategyExec:
+		batchSize = 1
+	}
+
+	times := (rows + batchSize - 1) / batchSize
+
+	if times == 1 {
+		statementHandler := NewQueryBuildStatementHandler(b.driver, b.session, b.middlewares...)
+		return statementHandler.ExecContext(ctx, statement, param)
+	}
+
+	// Create a PreparedStatementHandler for batch processing.
+	// We use PreparedStatementHandler here because:
+	// 1. For batch inserts with size N, we only need at most 2 prepared statements:
+	//    - One for full batch (N rows), prepared once and executed by every full batch
+	//    - One for remaining rows (< N rows), prepared by the final short batch
+	// 2. These statements are kept by their queries until the handler is closed
+	// 3. This significantly reduces the overhead of preparing statements repeatedly
+	preparedStatementHandler := &PreparedStatementHandler{
+		driver:      b.driver,
+		middlewares: b.middlewares,
+		session:     b.session,
+	}
+
+	// Ensure all prepared statements are properly closed after use
+	defer func() { _ = preparedStatementHandler.Close() }()
+
+	// execute the statement in batches, paced between them.
+	for i := 0; i < times; i++ {
+		start := i * batchSize
+		end := min((i+1)*batchSize, rows)
+		if i > 0 {
+			if err = pacer.wait(ctx, start, rows); err != nil {
+				return nil, err
+			}
+		}
+		result, err = preparedStatementHandler.ExecContext(ctx, statement, chunk(start, end))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// execBulk builds the single row statement of every row, and executes all of them at once by the bulk API of the driver.
+// The middlewares are applied once, with the arguments of all the rows.
+func (b batchExecutor) execBulk(ctx context.Context, statement Statement, param Param, rows int, chunk func(start, end int) Param) (sql.Result, error) {
+	bulkExecer, ok := b.driver.(driver.BulkExecer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", errBulkNotSupported, b.driver)
+	}
+	var (
+		query string
+		args  []any
+	)
+	translator := b.driver.Translator()
+	for i := 0; i < rows; i++ {
+		rowQuery, rowArgs, err := buildStatement(ctx, statement, translator, chunk(i, i+1))
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			query = rowQuery
+		} else if rowQuery != query {
+			return nil, fmt.Errorf("bulk batch strategy requires the identical query for every row, got %q and %q", query, rowQuery)
+		}
+		args = append(args, rowArgs...)
+	}
+	execHandler := func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		if len(args)%rows != 0 {
+			return nil, fmt.Errorf("bulk batch strategy got %d arguments for %d rows", len(args), rows)
+		}
+		size := len(args) / rows
+		bulkRows := make([][]any, 0, rows)
+		for i := 0; i < rows; i++ {
+			bulkRows = append(bulkRows, args[i*size:(i+1)*size])
+		}
+		preparedStmt, err := b.session.PrepareContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("prepare statement failed: %w", err)
+		}
+		defer func() { _ = preparedStmt.Close() }()
+		return bulkExecer.BulkExecContext(ctx, preparedStmt, bulkRows)
+	}
+	statementHandler := CompiledStatementHandler{
+		query:       query,
+		args:        args,
+		middlewares: b.middlewares,
+		driver:      b.driver,
+		session:     b.session,
+		execHandler: execHandler,
+	}
+	return statementHandler.ExecContext(ctx, statement, param)
+}
@@ -0,0 +1,276 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+// batchCounter counts the prepared statements, the closed ones and the executions of the batchConn.
+type batchCounter struct {
+	prepares atomic.Int64
+	closes   atomic.Int64
+	execs    atomic.Int64
+}
+
+// batchConnector is a sql connector whose statements do nothing.
+type batchConnector struct{ counter *batchCounter }
+
+func (c batchConnector) Connect(context.Context) (sqldriver.Conn, error) { return batchConn(c), nil }
+
+func (c batchConnector) Driver() sqldriver.Driver { return nil }
+
+type batchConn batchConnector
+
+func (c batchConn) Prepare(string) (sqldriver.Stmt, error) {
+	c.counter.prepares.Add(1)
+	return batchStmt(c), nil
+}
+
+func (c batchConn) Close() error { return nil }
+
+func (c batchConn) Begin() (sqldriver.Tx, error) { return nil, sqldriver.ErrSkip }
+
+type batchStmt batchConn
+
+func (s batchStmt) Close() error {
+	s.counter.closes.Add(1)
+	return nil
+}
+
+func (s batchStmt) NumInput() int { return -1 }
+
+func (s batchStmt) Exec([]sqldriver.Value) (sqldriver.Result, error) {
+	s.counter.execs.Add(1)
+	return sqldriver.RowsAffected(1), nil
+}
+
+func (s batchStmt) Query([]sqldriver.Value) (sqldriver.Rows, error) { return nil, sqldriver.ErrSkip }
+
+// bulkDriver is a driver whose bulk API executes the rows one by one.
+type bulkDriver struct{ driver.MySQLDriver }
+
+func (bulkDriver) BulkExecContext(ctx context.Context, stmt *sql.Stmt, rows [][]any) (sql.Result, error) {
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return nil, err
+		}
+	}
+	return sqldriver.RowsAffected(len(rows)), nil
+}
+
+type batchUser struct {
+	Name string
+	Age  int
+}
+
+func newBatchStatementHandler(t testing.TB, counter *batchCounter) (IConfiguration, StatementHandler) {
+	cfg, err := NewXMLConfiguration("testdata/configuration/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(batchConnector{counter: counter})
+	t.Cleanup(func() { _ = db.Close() })
+	return cfg, NewBatchStatementHandler(bulkDriver{}, db)
+}
+
+func TestBatchStrategy(t *testing.T) {
+	users := H{"list": make([]batchUser, 250)}
+	for _, tc := range []struct {
+		id       string
+		prepares int64
+		execs    int64
+	}{
+		{id: "BatchInsertValues", prepares: 2, execs: 3},
+		{id: "BatchInsertExec", prepares: 1, execs: 250},
+		{id: "BatchInsertBulk", prepares: 1, execs: 250},
+	} {
+		t.Run(tc.id, func(t *testing.T) {
+			var counter batchCounter
+			cfg, handler := newBatchStatementHandler(t, &counter)
+			statement, err := cfg.GetStatement("main.Repository." + tc.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = handler.ExecContext(context.Background(), statement, users); err != nil {
+				t.Fatal(err)
+			}
+			if counter.prepares.Load() != tc.prepares || counter.execs.Load() != tc.execs {
+				t.Fatalf("expected %d prepares and %d execs, got %d and %d",
+					tc.prepares, tc.execs, counter.prepares.Load(), counter.execs.Load())
+			}
+		})
+	}
+}
+
+func BenchmarkBatchStrategy(b *testing.B) {
+	for _, rows := range []int{10, 1000} {
+		users := H{"list": make([]batchUser, rows)}
+		for _, id := range []string{"BatchInsertValues", "BatchInsertExec", "BatchInsertBulk"} {
+			b.Run(fmt.Sprintf("%s/%d", id, rows), func(b *testing.B) {
+				var counter batchCounter
+				cfg, handler := newBatchStatementHandler(b, &counter)
+				statement, err := cfg.GetStatement("main.Repository." + id)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if _, err = handler.ExecContext(context.Background(), statement, users); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestPreparedStatementHandler_Bounded(t *testing.T) {
+	counter := &batchCounter{}
+	db := sql.OpenDB(batchConnector{counter: counter})
+	defer func() { _ = db.Close() }()
+	handler := &PreparedStatementHandler{driver: driver.MySQLDriver{}, session: db}
+	for i := 0; i < maxHandlerPreparedStatements+2; i++ {
+		if _, err := handler.getOrPrepare(context.Background(), fmt.Sprintf("SELECT %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the most recently used one is reused.
+	if _, err := handler.getOrPrepare(context.Background(), "SELECT 9"); err != nil {
+		t.Fatal(err)
+	}
+	if prepares := counter.prepares.Load(); prepares != maxHandlerPreparedStatements+2 {
+		t.Fatalf("expected %d prepares, got %d", maxHandlerPreparedStatements+2, prepares)
+	}
+	if kept := handler.stmts.len(); kept != maxHandlerPreparedStatements {
+		t.Fatalf("expected %d kept statements, got %d", maxHandlerPreparedStatements, kept)
+	}
+	if closes := counter.closes.Load(); closes != 2 {
+		t.Fatalf("expected the 2 evicted statements to be closed, got %d", closes)
+	}
+	if err := handler.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if closes := counter.closes.Load(); closes != maxHandlerPreparedStatements+2 {
+		t.Fatalf("expected all the statements to be closed, got %d", closes)
+	}
+	if kept := handler.stmts.len(); kept != 0 {
+		t.Fatalf("expected no kept statements, got %d", kept)
+	}
+}
+
+func TestBatchPacing(t *testing.T) {
+	users := H{"list": make([]batchUser, 250)}
+	var counter batchCounter
+	cfg, handler := newBatchStatementHandler(t, &counter)
+	statement, err := cfg.GetStatement("main.Repository.BatchInsertValues")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	ctx := ContextWithBatchPacing(context.Background(), BatchPacing{RowsPerSecond: 10000})
+	if _, err = handler.ExecContext(ctx, statement, users); err != nil {
+		t.Fatal(err)
+	}
+	if spent := time.Since(start); spent < 20*time.Millisecond || counter.execs.Load() != 3 {
+		t.Fatalf("expected 3 batches paced in 20ms at least, got %d in %s", counter.execs.Load(), spent)
+	}
+
+	// the cutoff passed already, no batch is started.
+	counter.execs.Store(0)
+	pacing := BatchPacing{Cutoff: time.Now().Add(-time.Second)}
+	_, err = handler.ExecContext(ContextWithBatchPacing(context.Background(), pacing), statement, users)
+	var stopped *BatchStoppedError
+	if !errors.As(err, &stopped) || !errors.Is(err, ErrBatchCutoff) {
+		t.Fatalf("expected the batch to be stopped by the cutoff, got %v", err)
+	}
+	if stopped.Rows != 0 || stopped.Total != 250 || counter.execs.Load() != 0 {
+		t.Fatalf("expected no rows inserted, got %+v and %d", stopped, counter.execs.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = handler.ExecContext(ctx, statement, users); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the canceled context to stop the batch, got %v", err)
+	}
+}
+
+func TestBatchPacer(t *testing.T) {
+	start := time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)
+	newPacer := func(pacing BatchPacing) (*batchPacer, *[]time.Duration, *time.Time) {
+		now, slept := start, new([]time.Duration)
+		pacer := &batchPacer{
+			pacing: pacing,
+			start:  start,
+			now:    func() time.Time { return now },
+			sleep: func(_ context.Context, d time.Duration) error {
+				*slept = append(*slept, d)
+				now = now.Add(d)
+				return nil
+			},
+		}
+		return pacer, slept, &now
+	}
+	ctx := context.Background()
+
+	// the rows per second is paced since the start, counting the time spent by the batches.
+	pacer, slept, now := newPacer(BatchPacing{RowsPerSecond: 1000})
+	for _, rows := range []int{0, 100, 200} {
+		if err := pacer.wait(ctx, rows, 250); err != nil {
+			t.Fatal(err)
+		}
+		*now = now.Add(30 * time.Millisecond)
+	}
+	if expected := []time.Duration{70 * time.Millisecond, 70 * time.Millisecond}; !reflect.DeepEqual(*slept, expected) {
+		t.Fatalf("unexpected sleeps: %v", *slept)
+	}
+
+	// the interval is the minimum sleep, and no batch resumes past the cutoff.
+	pacer, slept, _ = newPacer(BatchPacing{RowsPerSecond: 10000, Interval: 50 * time.Millisecond, Cutoff: start.Add(75 * time.Millisecond)})
+	for _, rows := range []int{0, 100} {
+		if err := pacer.wait(ctx, rows, 250); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := pacer.wait(ctx, 200, 250)
+	var stopped *BatchStoppedError
+	if !errors.As(err, &stopped) || !errors.Is(err, ErrBatchCutoff) || stopped.Rows != 200 || stopped.Total != 250 {
+		t.Fatalf("expected the batch to be stopped by the cutoff after 200 rows, got %v", err)
+	}
+	if expected := []time.Duration{50 * time.Millisecond}; !reflect.DeepEqual(*slept, expected) {
+		t.Fatalf("unexpected sleeps: %v", *slept)
+	}
+
+	// the wait is stopped by the context.
+	pacer, _, _ = newPacer(BatchPacing{Interval: time.Second})
+	pacer.sleep = func(context.Context, time.Duration) error { return context.Canceled }
+	if err = pacer.wait(ctx, 100, 250); !errors.As(err, &stopped) || !errors.Is(err, context.Canceled) || stopped.Rows != 100 {
+		t.Fatalf("expected the wait to be stopped by the context, got %v", err)
+	}
+}
@@ -37,7 +37,7 @@ var (
 // It serves as the core binding function for all mapping operations in the package.
 //
 // Parameters:
-//   - rows: The source sql.Rows to map from. Must not be nil.
+//   - rows: The source rows to map from, like *sql.Rows. Must not be nil.
 //   - v: The destination value to map to. Must be a pointer and not nil.
 //   - resultMap: The mapping strategy to use. If nil, a default mapper will be selected
 //     based on the destination type (SingleRowResultMap for struct, MultiRowsResultMap for slice).
@@ -52,16 +52,16 @@ var (
 //   - The rows parameter is nil (ErrNilRows)
 //   - The destination is not a pointer (ErrPointerRequired)
 //   - Any error occurs during the mapping process
-func bindWithResultMap(rows *sql.Rows, v any, resultMap ResultMap) error {
+func bindWithResultMap(rows Rows, v any, resultMap ResultMap) error {
 	if v == nil {
 		return ErrNilDestination
 	}
-	if rows == nil {
+	if sqlRows, ok := rows.(*sql.Rows); rows == nil || ok && sqlRows == nil {
 		return ErrNilRows
 	}
 	// Try custom row scanning if the destination implements RowScanner
 	if rowScanner, ok := v.(RowScanner); ok {
-		return rowScanner.ScanRows(rows)
+		return scanRows(rowScanner, rows)
 	}
 	rv := reflect.ValueOf(v)
 
@@ -85,11 +85,12 @@ func bindWithResultMap(rows *sql.Rows, v any, resultMap ResultMap) error {
 // bind cover sql.Rows to given entity
 // dest can be a pointer to a struct, a pointer to a slice of struct, or a pointer to a slice of any type.
 // rows won't be closed when the function returns.
-func BindWithResultMap[T any](rows *sql.Rows, resultMap ResultMap) (result T, err error) {
+func BindWithResultMap[T any](rows Rows, resultMap ResultMap) (result T, err error) {
 	// ptr is the pointer of the result, it is the destination of the binding.
 	var ptr any = &result
 
-	if _type := reflect.TypeOf(result); _type.Kind() == reflect.Ptr {
+	// the type of an interface result is nil, which is bound through the pointer of it.
+	if _type := reflect.TypeOf(result); _type != nil && _type.Kind() == reflect.Ptr {
 		// if the result is a pointer, create a new instance of the element.
 		// you'd better not use a nil pointer as the result.
 		result = reflect.New(_type.Elem()).Interface().(T)
@@ -119,7 +120,7 @@ func BindWithResultMap[T any](rows *sql.Rows, resultMap ResultMap) (result T, er
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
-func Bind[T any](rows *sql.Rows) (result T, err error) {
+func Bind[T any](rows Rows) (result T, err error) {
 	return BindWithResultMap[T](rows, nil)
 }
 
@@ -150,7 +151,7 @@ func Bind[T any](rows *sql.Rows) (result T, err error) {
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
-func List[T any](rows *sql.Rows) (result []T, err error) {
+func List[T any](rows Rows) (result []T, err error) {
 	var multiRowsResultMap MultiRowsResultMap
 
 	element := reflect.TypeOf((*T)(nil)).Elem()
@@ -168,7 +169,7 @@ func List[T any](rows *sql.Rows) (result []T, err error) {
 // List2 converts database query results into a slice of pointers.
 // Unlike List function, List2 returns a slice of pointers []*T instead of a slice of values []T.
 // This is particularly useful when you need to modify slice elements or handle large structs.
-func List2[T any](rows *sql.Rows) ([]*T, error) {
+func List2[T any](rows Rows) ([]*T, error) {
 	items, err := List[T](rows)
 	if err != nil {
 		return nil, err
@@ -184,8 +185,8 @@ func List2[T any](rows *sql.Rows) ([]*T, error) {
 // It implements Go's built-in iter.Seq interface for type-safe iteration over database rows.
 // Type parameter T represents the type of values that will be yielded during iteration.
 type RowsIter[T any] struct {
-	rows *sql.Rows // The underlying sql.Rows to iterate over
-	err  error     // Stores any error that occurs during iteration
+	rows Rows  // The underlying rows to iterate over
+	err  error // Stores any error that occurs during iteration
 }
 
 // Err returns any error that occurred during iteration.
@@ -274,6 +275,6 @@ func (r *RowsIter[T]) Iter() iter.Seq[T] {
 // for closing the rows when iteration is complete. This design allows for more
 // flexible resource management, especially when using the iterator in different
 // contexts or when early termination is needed.
-func Iter[T any](rows *sql.Rows) *RowsIter[T] {
+func Iter[T any](rows Rows) *RowsIter[T] {
 	return &RowsIter[T]{rows: rows}
 }
@@ -0,0 +1,624 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/eval"
+	"github.com/go-juicedev/juice/internal/reflectlite"
+)
+
+// ErrTooManyBranches is an error that is returned when the branches of a statement exceed the limit.
+var ErrTooManyBranches = errors.New("too many branches")
+
+// BranchCondition is the decision of a condition of a branch.
+type BranchCondition struct {
+	// Test is the test expression of the if or when node. The implicit conditions of the set nodes
+	// are described as "name is not zero" for the assignments of ignoreZero, and as "column in mask"
+	// for the assignments of fieldMask.
+	Test string
+	// Matched is the forced result of the test.
+	Matched bool
+}
+
+// Branch is a distinct sql generated by a combination of the dynamic conditions of a statement.
+type Branch struct {
+	// Conditions are the decisions of the conditions which generate the sql, in the order of their evaluations.
+	// The conditions in the excluded nodes are not decided, so they are not reported.
+	Conditions []BranchCondition
+	// Query is the generated sql.
+	Query string
+	// Args are the arguments of the generated sql.
+	Args []any
+	// Param is a representative parameter set of the branch, which generates the same sql when the statement
+	// is built with it on top of the param given to EnumerateBranches. It is nil if no parameter set is found,
+	// since the values are derived from the literals of the test expressions and a few common values.
+	Param H
+	// Err is the error when generating the sql, like a collection of foreach not found in the param.
+	Err error
+}
+
+// EnumerateBranches generates the sql of every combination of the if and choose nodes and the conditional
+// assignments of the set nodes of the statement, without evaluating their conditions, so that the tests can
+// assert all the branches of complex mappers. The combinations generating the same sql are reported once.
+//
+// The param is used to render the placeholders and the foreach nodes, and the missing parameters are bound as nil.
+// The limit is the maximum number of the combinations, zero means 1024.
+//
+// Example usage:
+//
+//	branches, err := juice.EnumerateBranches(statement, driver.MySQLDriver{}, juice.H{"ids": []int{1}}, 0)
+//	for _, branch := range branches {
+//		t.Log(branch.Conditions, branch.Query, branch.Param)
+//	}
+func EnumerateBranches(statement Statement, drv driver.Driver, param Param, limit int) ([]Branch, error) {
+	xmlStatement, ok := statement.(*xmlSQLStatement)
+	if !ok {
+		return nil, fmt.Errorf("enumerate branches: unsupported statement type %T", statement)
+	}
+	if limit <= 0 {
+		limit = 1024
+	}
+	enumerator := &branchEnumerator{mapper: xmlStatement.mapper}
+	root, err := enumerator.rewrite(xmlStatement.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	total := 1
+	for _, slot := range enumerator.slots {
+		if total *= slot.options(); total > limit {
+			return nil, fmt.Errorf("%w: statement %s has more than %d branches", ErrTooManyBranches, statement.Name(), limit)
+		}
+	}
+	base := newGenericParam(param, xmlStatement.Attribute("paramName"))
+	enumerator.decisions = make([]int, len(enumerator.slots))
+	enumerator.visited = make([]bool, len(enumerator.slots))
+
+	var (
+		branches []Branch
+		seen     = make(map[string]struct{})
+	)
+	for i := 0; i < total; i++ {
+		// decode the index into the decisions of the slots.
+		index := i
+		for j, slot := range enumerator.slots {
+			enumerator.decisions[j] = index % slot.options()
+			index /= slot.options()
+		}
+		clear(enumerator.visited)
+		enumerator.order = enumerator.order[:0]
+		// every rendering uses its own translator, since the named translators number their arguments.
+		query, args, err := root.Accept(drv.Translator(), branchParameter{Parameter: base})
+		args, _ = unredactArgs(args)
+		key := query
+		if err != nil {
+			key = "error:" + err.Error()
+		}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		branch := Branch{Query: query, Args: args, Err: err}
+		decisions := enumerator.decided()
+		for _, decision := range decisions {
+			branch.Conditions = append(branch.Conditions, BranchCondition{Test: decision.test, Matched: decision.matched})
+		}
+		if err == nil {
+			branch.Param = solveBranch(xmlStatement.Nodes, drv, base, decisions, query)
+		}
+		branches = append(branches, branch)
+	}
+	return branches, nil
+}
+
+// branchSlot is a decision point of the statement, an if node, a choose node or a conditional assignment.
+type branchSlot struct {
+	// tests are the tests of the if node, or the when nodes of the choose node.
+	tests []string
+	// conditions are the if node, or the when nodes of the choose node.
+	conditions []*ConditionNode
+	// assignment is the ignoreZeroNode or the fieldMaskNode of a conditional assignment.
+	assignment Node
+	// choose reports whether the slot is a choose node.
+	choose bool
+}
+
+// options returns the number of the decisions of the slot.
+// The decisions of an if node are false and true, and the decisions of a choose node
+// are the when nodes and the otherwise node.
+func (s branchSlot) options() int {
+	if s.choose {
+		return len(s.tests) + 1
+	}
+	return 2
+}
+
+// branchDecision is the decision of a condition of the current branch.
+type branchDecision struct {
+	test string
+	// condition is the decided if or when node, nil for the conditional assignments.
+	condition *ConditionNode
+	// assignment is the decided conditional assignment, nil for the if and when nodes.
+	assignment Node
+	matched    bool
+}
+
+// branchEnumerator rewrites the nodes of a statement to the nodes using the forced decisions.
+type branchEnumerator struct {
+	mapper    *Mapper
+	slots     []branchSlot
+	decisions []int
+	// visited marks the slots evaluated by the current rendering, and order keeps them in the order of the evaluations.
+	visited []bool
+	order   []int
+}
+
+// visit marks the slot as evaluated and returns its decision.
+func (e *branchEnumerator) visit(slot int) int {
+	if !e.visited[slot] {
+		e.visited[slot] = true
+		e.order = append(e.order, slot)
+	}
+	return e.decisions[slot]
+}
+
+// decided returns the decisions of the slots evaluated by the current rendering.
+func (e *branchEnumerator) decided() []branchDecision {
+	var decisions []branchDecision
+	for _, index := range e.order {
+		slot, decision := e.slots[index], e.decisions[index]
+		if !slot.choose {
+			decisions = append(decisions, branchDecision{
+				test:       slot.tests[0],
+				condition:  slot.conditions[0],
+				assignment: slot.assignment,
+				matched:    decision == 1,
+			})
+			continue
+		}
+		for j, test := range slot.tests {
+			decisions = append(decisions, branchDecision{test: test, condition: slot.conditions[j], matched: j == decision})
+			if j == decision {
+				break
+			}
+		}
+	}
+	return decisions
+}
+
+// addSlot adds a slot and returns its index.
+func (e *branchEnumerator) addSlot(slot branchSlot) int {
+	e.slots = append(e.slots, slot)
+	return len(e.slots) - 1
+}
+
+// rewriteGroup rewrites the nodes in the group.
+func (e *branchEnumerator) rewriteGroup(nodes []Node) (NodeGroup, error) {
+	group := make(NodeGroup, 0, len(nodes))
+	for _, node := range nodes {
+		rewritten, err := e.rewrite(node)
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, rewritten)
+	}
+	return group, nil
+}
+
+// rewrite rewrites the node, the nodes without children are returned as they are.
+func (e *branchEnumerator) rewrite(node Node) (Node, error) {
+	switch node := node.(type) {
+	case NodeGroup:
+		return e.rewriteGroup(node)
+	case *ConditionNode:
+		nodes, err := e.rewriteGroup(node.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		slot := e.addSlot(branchSlot{tests: []string{node.test}, conditions: []*ConditionNode{node}})
+		return &forcedConditionNode{enumerator: e, slot: slot, Nodes: nodes}, nil
+	case ignoreZeroNode:
+		names := make([]string, len(node.node.placeholder))
+		for i, param := range node.node.placeholder {
+			names[i] = param[1]
+		}
+		test := strings.Join(names, " or ") + " is not zero"
+		slot := e.addSlot(branchSlot{tests: []string{test}, conditions: []*ConditionNode{nil}, assignment: node})
+		return &forcedConditionNode{enumerator: e, slot: slot, Nodes: NodeGroup{node.node}}, nil
+	case fieldMaskNode:
+		assignment, err := e.rewrite(node.node)
+		if err != nil {
+			return nil, err
+		}
+		test := node.column + " in " + node.mask
+		slot := e.addSlot(branchSlot{tests: []string{test}, conditions: []*ConditionNode{nil}, assignment: node})
+		return &forcedConditionNode{enumerator: e, slot: slot, Nodes: NodeGroup{assignment}}, nil
+	case *ChooseNode:
+		return e.rewriteChoose(*node)
+	case ChooseNode:
+		return e.rewriteChoose(node)
+	case *WhereNode:
+		return e.rewriteWhere(*node)
+	case WhereNode:
+		return e.rewriteWhere(node)
+	case *SetNode:
+		return e.rewriteSet(*node)
+	case SetNode:
+		return e.rewriteSet(node)
+	case *TrimNode:
+		return e.rewriteTrim(*node)
+	case TrimNode:
+		return e.rewriteTrim(node)
+	case *DialectNode:
+		return e.rewriteDialect(*node)
+	case DialectNode:
+		return e.rewriteDialect(node)
+	case *ForeachNode:
+		return e.rewriteForeach(*node)
+	case ForeachNode:
+		return e.rewriteForeach(node)
+	case *OtherwiseNode:
+		nodes, err := e.rewriteGroup(node.Nodes)
+		return &OtherwiseNode{Nodes: nodes}, err
+	case OtherwiseNode:
+		nodes, err := e.rewriteGroup(node.Nodes)
+		return &OtherwiseNode{Nodes: nodes}, err
+	case *SQLNode:
+		nodes, err := e.rewriteGroup(node.nodes)
+		return &SQLNode{id: node.id, nodes: nodes}, err
+	case SQLNode:
+		nodes, err := e.rewriteGroup(node.nodes)
+		return &SQLNode{id: node.id, nodes: nodes}, err
+	case *IncludeNode:
+		sqlNode := node.sqlNode
+		if sqlNode == nil {
+			var err error
+			if sqlNode, err = node.mapper.GetSQLNodeByID(node.refId); err != nil {
+				return nil, err
+			}
+		}
+		rewritten, err := e.rewrite(sqlNode)
+		if err != nil || len(node.properties) == 0 {
+			return rewritten, err
+		}
+		// the properties are kept for the rewritten fragment.
+		return &IncludeNode{sqlNode: rewritten, mapper: node.mapper, refId: node.refId, properties: node.properties}, nil
+	default:
+		return node, nil
+	}
+}
+
+// rewriteChoose rewrites the choose node to a forced choose node.
+func (e *branchEnumerator) rewriteChoose(node ChooseNode) (Node, error) {
+	forced := &forcedChooseNode{enumerator: e}
+	var (
+		tests      []string
+		conditions []*ConditionNode
+	)
+	for _, when := range node.WhenNodes {
+		condition, ok := when.(*ConditionNode)
+		if !ok {
+			return nil, fmt.Errorf("enumerate branches: unsupported when node type %T", when)
+		}
+		nodes, err := e.rewriteGroup(condition.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, condition.test)
+		conditions = append(conditions, condition)
+		forced.whens = append(forced.whens, nodes)
+	}
+	if node.OtherwiseNode != nil {
+		otherwise, err := e.rewrite(node.OtherwiseNode)
+		if err != nil {
+			return nil, err
+		}
+		forced.otherwise = otherwise
+	}
+	forced.slot = e.addSlot(branchSlot{tests: tests, conditions: conditions, choose: true})
+	return forced, nil
+}
+
+// rewriteTrim rewrites the children of the trim node.
+func (e *branchEnumerator) rewriteTrim(node TrimNode) (Node, error) {
+	nodes, err := e.rewriteGroup(node.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	node.Nodes = nodes
+	return &node, nil
+}
+
+// rewriteWhere rewrites the children of the where node and keeps its overrides.
+func (e *branchEnumerator) rewriteWhere(node WhereNode) (Node, error) {
+	nodes, err := e.rewriteGroup(node.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	node.Nodes = nodes
+	return &node, nil
+}
+
+// rewriteSet rewrites the children of the set node and keeps its overrides.
+func (e *branchEnumerator) rewriteSet(node SetNode) (Node, error) {
+	nodes, err := e.rewriteGroup(node.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	node.Nodes = nodes
+	return &node, nil
+}
+
+// rewriteDialect rewrites the children of every dialect body, only the one of the translator is enumerated.
+func (e *branchEnumerator) rewriteDialect(node DialectNode) (Node, error) {
+	cases := make([]DialectCase, len(node.Cases))
+	for i, body := range node.Cases {
+		nodes, err := e.rewriteGroup(body.Nodes)
+		if err != nil {
+			return nil, err
+		}
+		cases[i] = DialectCase{Drivers: body.Drivers, Nodes: nodes}
+	}
+	node.Cases = cases
+	return &node, nil
+}
+
+// rewriteForeach rewrites the children of the foreach node.
+func (e *branchEnumerator) rewriteForeach(node ForeachNode) (Node, error) {
+	nodes, err := e.rewriteGroup(node.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	node.Nodes = nodes
+	return &node, nil
+}
+
+// forcedConditionNode is an if node whose result is forced by the enumerator.
+type forcedConditionNode struct {
+	enumerator *branchEnumerator
+	slot       int
+	Nodes      NodeGroup
+}
+
+// Accept accepts parameters and returns query and arguments.
+func (f *forcedConditionNode) Accept(translator driver.Translator, p Parameter) (query string, args []any, err error) {
+	if f.enumerator.visit(f.slot) == 0 {
+		return "", nil, nil
+	}
+	return f.Nodes.Accept(translator, p)
+}
+
+// forcedChooseNode is a choose node whose branch is forced by the enumerator.
+type forcedChooseNode struct {
+	enumerator *branchEnumerator
+	slot       int
+	whens      []NodeGroup
+	otherwise  Node
+}
+
+// Accept accepts parameters and returns query and arguments.
+func (f *forcedChooseNode) Accept(translator driver.Translator, p Parameter) (query string, args []any, err error) {
+	decision := f.enumerator.visit(f.slot)
+	if decision < len(f.whens) {
+		return f.whens[decision].Accept(translator, p)
+	}
+	if f.otherwise != nil {
+		return f.otherwise.Accept(translator, p)
+	}
+	return "", nil, nil
+}
+
+// nilValue is the value of the missing parameters of the branches.
+var nilValue = reflect.Zero(reflect.TypeOf((*any)(nil)).Elem())
+
+// branchParameter binds the missing parameters as nil.
+type branchParameter struct {
+	Parameter
+	// missing records the names of the missing parameters if it is not nil.
+	missing *[]string
+}
+
+// Get implements Parameter.
+func (b branchParameter) Get(name string) (reflect.Value, bool) {
+	if value, exists := b.Parameter.Get(name); exists {
+		return value, true
+	}
+	if b.missing != nil {
+		*b.missing = append(*b.missing, name)
+	}
+	return nilValue, true
+}
+
+// maxBranchCandidates is the maximum number of the combinations of the candidates tried for a condition.
+const maxBranchCandidates = 4096
+
+// solveBranch returns the values of the parameters which make the conditions decide as the decisions,
+// or nil if they are not found or the statement built with them does not generate the query.
+func solveBranch(nodes Node, drv driver.Driver, base Parameter, decisions []branchDecision, query string) H {
+	solver := &branchSolver{base: base, values: make(map[string]any)}
+	for _, decision := range decisions {
+		var solved bool
+		switch assignment := decision.assignment.(type) {
+		case ignoreZeroNode:
+			solved = solver.decideNonZero(assignment, decision.matched)
+		case fieldMaskNode:
+			solved = solver.decideMasked(assignment, decision.matched)
+		default:
+			solved = solver.decideCondition(decision.condition, decision.matched)
+		}
+		if !solved {
+			return nil
+		}
+	}
+	// the conditions are decided one by one, the values are checked by building the statement with them.
+	var missing []string
+	built, _, err := nodes.Accept(drv.Translator(), branchParameter{Parameter: solver.parameter(), missing: &missing})
+	if err != nil || built != query {
+		return nil
+	}
+	// the other parameters of the branch, like the ones of the placeholders, are bound as nil.
+	for _, name := range missing {
+		if solver.free(name) {
+			solver.values[name] = nil
+		}
+	}
+	return solver.param()
+}
+
+// branchSolver derives the values of the parameters of the conditions, the parameters of the base are kept.
+type branchSolver struct {
+	base Parameter
+	// values are the derived values by their dotted names, like user.name.
+	values map[string]any
+}
+
+// param returns the derived values, the dotted names are nested, like H{"user": H{"name": value}}.
+func (s *branchSolver) param() H {
+	param := H{}
+	for name, value := range s.values {
+		current := param
+		parts := strings.Split(name, ".")
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := current[part].(H)
+			if !ok {
+				next = H{}
+				current[part] = next
+			}
+			current = next
+		}
+		// the value is replaced by the nested values of its fields.
+		if _, nested := current[parts[len(parts)-1]].(H); !nested {
+			current[parts[len(parts)-1]] = value
+		}
+	}
+	return param
+}
+
+// parameter returns the parameter of the derived values on top of the base.
+func (s *branchSolver) parameter() Parameter {
+	return eval.ParamGroup{s.param().AsParam(), s.base}
+}
+
+// free reports whether the value of the parameter can be derived, which is neither given nor derived.
+func (s *branchSolver) free(name string) bool {
+	if _, exists := s.base.Get(name); exists {
+		return false
+	}
+	if _, exists := s.values[name]; exists {
+		return false
+	}
+	for derived := range s.values {
+		if strings.HasPrefix(derived, name+".") {
+			return false
+		}
+	}
+	return true
+}
+
+// decideCondition derives the free parameters of the condition from the candidates, so that the condition
+// matches as the decision.
+func (s *branchSolver) decideCondition(condition *ConditionNode, matched bool) bool {
+	var free []string
+	for _, name := range eval.Identifiers(condition.expr) {
+		if s.free(name) {
+			free = append(free, name)
+		}
+	}
+	candidates := branchCandidates(eval.Literals(condition.expr))
+	total := 1
+	for range free {
+		if total *= len(candidates); total > maxBranchCandidates {
+			return false
+		}
+	}
+	for i := 0; i < total; i++ {
+		index := i
+		for _, name := range free {
+			s.values[name] = candidates[index%len(candidates)]
+			index /= len(candidates)
+		}
+		if result, err := condition.Match(s.parameter()); err == nil && result == matched {
+			return true
+		}
+	}
+	for _, name := range free {
+		delete(s.values, name)
+	}
+	return false
+}
+
+// decideNonZero derives the parameters of the assignment of ignoreZero, a non-zero one if it is written,
+// and zero ones if it is skipped.
+func (s *branchSolver) decideNonZero(assignment ignoreZeroNode, matched bool) bool {
+	parameter := s.parameter()
+	for _, param := range assignment.node.placeholder {
+		name := param[1]
+		if s.free(name) {
+			if matched {
+				s.values[name] = 1
+				return true
+			}
+			s.values[name] = nil
+			continue
+		}
+		value, _ := parameter.Get(name)
+		if nonZero := value.IsValid() && !reflectlite.Unpack(value).IsZero(); nonZero {
+			return matched
+		}
+	}
+	return !matched
+}
+
+// decideMasked derives the mask of the assignment of fieldMask, which contains the column if it is written.
+// The mask given by the base is not changed.
+func (s *branchSolver) decideMasked(assignment fieldMaskNode, matched bool) bool {
+	if value, given := s.base.Get(assignment.mask); given {
+		mask, err := toFieldMask(value)
+		return err == nil && assignment.masked(mask) == matched
+	}
+	mask, _ := s.values[assignment.mask].(FieldMask)
+	if !matched {
+		s.values[assignment.mask] = mask
+		return !assignment.masked(mask)
+	}
+	if !assignment.masked(mask) {
+		s.values[assignment.mask] = append(mask, assignment.column)
+	}
+	return true
+}
+
+// branchCandidates returns the candidate values of the parameters of a condition, the literals of the condition
+// and their neighbours first, then the common values like nil, zero and the empty ones.
+func branchCandidates(literals []any) []any {
+	var candidates []any
+	for _, literal := range literals {
+		switch literal := literal.(type) {
+		case int64:
+			candidates = append(candidates, literal, literal+1, literal-1)
+		case float64:
+			candidates = append(candidates, literal, literal+1, literal-1)
+		case string:
+			candidates = append(candidates, literal, literal+"x")
+		}
+	}
+	return append(candidates, nil, 0, 1, "", "x", true, false, []any{}, []any{1})
+}
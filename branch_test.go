@@ -0,0 +1,135 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+func TestEnumerateBranches(t *testing.T) {
+	cfg, err := NewXMLConfiguration("testdata/configuration/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement, err := cfg.GetStatement("main.Repository.Branches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	branches, err := EnumerateBranches(statement, driver.MySQLDriver{}, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"select * from user WHERE name = ?",
+		"select * from user WHERE id = ? and name = ?",
+		"select * from user WHERE status = 1",
+		"select * from user WHERE id = ? and status = 1",
+	}
+	if len(branches) != len(expected) {
+		t.Fatalf("expected %d branches, got %d", len(expected), len(branches))
+	}
+	for i, branch := range branches {
+		if branch.Err != nil {
+			t.Fatal(branch.Err)
+		}
+		if branch.Query != expected[i] {
+			t.Errorf("branch %d: expected %q, got %q", i, expected[i], branch.Query)
+		}
+	}
+}
+
+func TestEnumerateBranches_Param(t *testing.T) {
+	cfg, err := NewXMLConfiguration("testdata/configuration/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, expected := range map[string][]string{
+		"Branches": {
+			"select * from user WHERE name = ?",
+			"select * from user WHERE id = ? and name = ?",
+			"select * from user WHERE status = 1",
+			"select * from user WHERE id = ? and status = 1",
+		},
+		"PatchBranches": {
+			"update user where id = ?",
+			"update user SET name = ? where id = ?",
+			"update user SET age = ? where id = ?",
+			"update user SET name = ?, age = ? where id = ?",
+		},
+		"MaskBranches": {
+			"update user where id = ?",
+			"update user SET name = ? where id = ?",
+			"update user SET age = ? where id = ?",
+			"update user SET name = ?, age = ? where id = ?",
+		},
+	} {
+		statement, err := cfg.GetStatement("main.Repository." + id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		branches, err := EnumerateBranches(statement, driver.MySQLDriver{}, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(branches) != len(expected) {
+			t.Fatalf("%s: expected %d branches, got %d", id, len(expected), len(branches))
+		}
+		for i, branch := range branches {
+			if branch.Query != expected[i] {
+				t.Errorf("%s branch %d: expected %q, got %q", id, i, expected[i], branch.Query)
+			}
+			if branch.Param == nil {
+				t.Errorf("%s branch %d: no param", id, i)
+				continue
+			}
+			// the statement built with the param generates the sql of the branch.
+			query, _, err := statement.Build(driver.MySQLDriver{}.Translator(), branch.Param)
+			if err != nil {
+				t.Errorf("%s branch %d: %v", id, i, err)
+				continue
+			}
+			if query != branch.Query {
+				t.Errorf("%s branch %d: param %v builds %q", id, i, branch.Param, query)
+			}
+		}
+	}
+}
+
+func TestEnumerateBranches_Conditions(t *testing.T) {
+	cfg, err := NewXMLConfiguration("testdata/configuration/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement, err := cfg.GetStatement("main.Repository.Branches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	branches, err := EnumerateBranches(statement, driver.MySQLDriver{}, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []BranchCondition{{Test: "id != 0", Matched: false}, {Test: `name != ""`, Matched: true}}
+	if !reflect.DeepEqual(branches[0].Conditions, expected) {
+		t.Errorf("unexpected conditions: %v", branches[0].Conditions)
+	}
+	if !reflect.DeepEqual(branches[0].Param, H{"id": int64(0), "name": "x"}) {
+		t.Errorf("unexpected param: %#v", branches[0].Param)
+	}
+}
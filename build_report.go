@@ -0,0 +1,62 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+// BuildReport is the outcome of BuildWithReport.
+type BuildReport struct {
+	// Statement is the full name of the statement, like "main.UserRepository.Search".
+	Statement string
+	// Query is the built query.
+	Query string
+	// Args are the arguments of the query.
+	Args []any
+	// Branches are the test expressions evaluated by the build in order, like the ones of if and when,
+	// whose results tell which fragments are chosen.
+	Branches []ExprTraceEntry
+}
+
+// BuildWithReport builds the statement like Statement.Build, and reports the branches chosen by the build,
+// for the external tools which consume the built queries without an Engine, like the linters,
+// the analyzers and the load test generators. The context provides the options of the build,
+// like the schema set by ContextWithSchema.
+//
+//	cfg, err := juice.NewXMLConfiguration("config.xml")
+//	statement, err := cfg.GetStatement("main.UserRepository.Search")
+//	report, err := juice.BuildWithReport(ctx, statement, driver.MySQLDriver{}.Translator(), param)
+//	for _, branch := range report.Branches {
+//	    log.Printf("%s %s => %v", branch.Source, branch.Expr, branch.Result)
+//	}
+func BuildWithReport(ctx context.Context, statement Statement, translator driver.Translator, param Param) (*BuildReport, error) {
+	ctx = WithExprTrace(ctx)
+	query, args, err := buildStatement(ctx, statement, translator, param)
+	if err != nil {
+		return nil, err
+	}
+	args, _ = unredactArgs(args)
+	return &BuildReport{
+		Statement: statement.Name(),
+		Query:     query,
+		Args:      args,
+		Branches:  ExprTraceFromContext(ctx).Entries(),
+	}, nil
+}
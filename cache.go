@@ -0,0 +1,288 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+// ResultCache stores the results of the select statements.
+type ResultCache interface {
+	// Get returns the value stored with the key and the time when it was stored.
+	Get(key string) (value any, storedAt time.Time, ok bool)
+	// Set stores the value with the key.
+	Set(key string, value any)
+	// Delete removes the value stored with the key.
+	Delete(key string)
+}
+
+// cacheEntry is an entry of the MemoryResultCache.
+type cacheEntry struct {
+	value    any
+	storedAt time.Time
+}
+
+// MemoryResultCache is a ResultCache which stores the results in memory.
+// It is safe for concurrent use.
+type MemoryResultCache struct {
+	mu      sync.Mutex
+	entries lru[string, cacheEntry]
+}
+
+// NewMemoryResultCache returns a new MemoryResultCache without the limit of the entries.
+func NewMemoryResultCache() *MemoryResultCache {
+	return NewLRUResultCache(0)
+}
+
+// NewLRUResultCache returns a new MemoryResultCache which keeps at most maxEntries entries,
+// the least recently used entries are evicted when the budget is exceeded.
+// A maxEntries less than or equal to zero means no limit.
+func NewLRUResultCache(maxEntries int) *MemoryResultCache {
+	return &MemoryResultCache{entries: lru[string, cacheEntry]{max: max(maxEntries, 0)}}
+}
+
+// Get implements ResultCache.
+func (c *MemoryResultCache) Get(key string) (any, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries.get(key)
+	return entry.value, entry.storedAt, ok
+}
+
+// Set implements ResultCache.
+func (c *MemoryResultCache) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.set(key, cacheEntry{value: value, storedAt: time.Now()})
+}
+
+// Delete implements ResultCache.
+func (c *MemoryResultCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.delete(key)
+}
+
+// Len returns the number of the entries, it implements CacheSizer.
+func (c *MemoryResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.len()
+}
+
+// CacheInfo reports how the result of a query is served by the CachedExecutor.
+type CacheInfo struct {
+	// Hit reports whether the result is served from the cache.
+	Hit bool
+	// Stale reports whether the result is served from an expired entry because the database failed.
+	Stale bool
+	// Err is the error of the database when the stale result is served.
+	Err error
+}
+
+// cacheInfoKey is the context key of the CacheInfo.
+type cacheInfoKey struct{}
+
+// ContextWithCacheInfo returns a new context with an empty CacheInfo,
+// which is filled by the CachedExecutor when a query is executed with the context.
+//
+//	ctx, info := juice.ContextWithCacheInfo(ctx)
+//	users, err := executor.QueryContext(ctx, param)
+//	if info.Stale {
+//	    // tell the client the data may be outdated.
+//	}
+func ContextWithCacheInfo(ctx context.Context) (context.Context, *CacheInfo) {
+	info := &CacheInfo{}
+	return context.WithValue(ctx, cacheInfoKey{}, info), info
+}
+
+// cacheInfoFromContext returns the CacheInfo from the context, or nil if not set.
+func cacheInfoFromContext(ctx context.Context) *CacheInfo {
+	info, _ := ctx.Value(cacheInfoKey{}).(*CacheInfo)
+	return info
+}
+
+// CachedExecutor is an Executor which caches the results of the select statements.
+// The statements can opt out by setting the useCache attribute to false.
+// The cached results are not invalidated by the writes, since the affected results can not be known;
+// use a short TTL or delete them on the change events.
+//
+// The results are keyed by the built query and its arguments, and copied when they are stored and served,
+// so that the callers never share them. The queries in a transaction are not cached, since they may read
+// the uncommitted writes of the transaction.
+type CachedExecutor[T any] struct {
+	Executor[T]
+
+	// Cache stores the results.
+	Cache ResultCache
+
+	// TTL is how long a result is fresh, zero means forever.
+	TTL time.Duration
+
+	// ServeStaleOnError serves the expired result if the database fails,
+	// which improves the availability of the read-heavy pages during database outages.
+	ServeStaleOnError bool
+}
+
+// QueryContext returns the cached result if it is fresh, otherwise queries the database and caches the result.
+func (e *CachedExecutor[T]) QueryContext(ctx context.Context, param Param) (result T, err error) {
+	statement := e.Statement()
+	if statement == nil || statement.Action() != Select || statement.Attribute("useCache") == "false" || IsTxManager(ManagerFromContext(ctx)) {
+		return e.Executor.QueryContext(ctx, param)
+	}
+	key, ok := resultCacheKey(ctx, statement, e.Driver(), param)
+	if !ok {
+		return e.Executor.QueryContext(ctx, param)
+	}
+	info := cacheInfoFromContext(ctx)
+	cached, storedAt, found := e.Cache.Get(key)
+	value, typed := cached.(T)
+	found = found && typed
+	if found && (e.TTL <= 0 || time.Since(storedAt) < e.TTL) {
+		if info != nil {
+			info.Hit = true
+		}
+		return cloneResult(value), nil
+	}
+	result, err = e.Executor.QueryContext(ctx, param)
+	if err != nil {
+		if found && e.ServeStaleOnError && ctx.Err() == nil {
+			if info != nil {
+				info.Hit, info.Stale, info.Err = true, true, err
+			}
+			return cloneResult(value), nil
+		}
+		return result, err
+	}
+	e.Cache.Set(key, cloneResult(result))
+	return result, nil
+}
+
+// resultCacheKey returns the key of the result by the statement name, the built query and its arguments,
+// so that the params building the same query share the result, and the ones which can not be the arguments,
+// like the channels and the functions, fail to build. It reports false if the key can not be built.
+func resultCacheKey(ctx context.Context, statement Statement, drv driver.Driver, param Param) (string, bool) {
+	if drv == nil {
+		return "", false
+	}
+	query, args, err := buildStatement(ctx, statement, drv.Translator(), param)
+	if err != nil {
+		return "", false
+	}
+	args, _ = unredactArgs(args)
+	var key strings.Builder
+	key.WriteString(statement.Name())
+	key.WriteByte(0)
+	key.WriteString(query)
+	for _, arg := range args {
+		if named, ok := arg.(sql.NamedArg); ok {
+			key.WriteString("\x00@")
+			key.WriteString(named.Name)
+			arg = named.Value
+		}
+		value, err := sqldriver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return "", false
+		}
+		// the type is a part of the key, so that 1 and "1" get different keys.
+		switch value := value.(type) {
+		case time.Time:
+			_, _ = fmt.Fprintf(&key, "\x00%T:%s", value, value.UTC().Format(time.RFC3339Nano))
+		default:
+			_, _ = fmt.Fprintf(&key, "\x00%T:%#v", value, value)
+		}
+	}
+	return key.String(), true
+}
+
+// cloneResult returns the deep copy of the result, the pointers, the slices, the maps and the exported fields
+// of the structs are copied, so that the cached result is not modified by its callers.
+func cloneResult[T any](result T) T {
+	value := reflect.ValueOf(&result).Elem()
+	cloned := reflect.New(value.Type()).Elem()
+	cloned.Set(cloneValue(value))
+	return cloned.Interface().(T)
+}
+
+// cloneValue returns the deep copy of the value.
+func cloneValue(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return value
+		}
+		cloned := reflect.New(value.Type().Elem())
+		cloned.Elem().Set(cloneValue(value.Elem()))
+		return cloned
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		cloned := reflect.New(value.Type()).Elem()
+		cloned.Set(cloneValue(value.Elem()))
+		return cloned
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		cloned := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			cloned.Index(i).Set(cloneValue(value.Index(i)))
+		}
+		return cloned
+	case reflect.Array:
+		cloned := reflect.New(value.Type()).Elem()
+		for i := 0; i < value.Len(); i++ {
+			cloned.Index(i).Set(cloneValue(value.Index(i)))
+		}
+		return cloned
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		cloned := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			cloned.SetMapIndex(iter.Key(), cloneValue(iter.Value()))
+		}
+		return cloned
+	case reflect.Struct:
+		// the struct is copied by value, and its exported fields are copied deeply.
+		cloned := reflect.New(value.Type()).Elem()
+		cloned.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if field := cloned.Field(i); field.CanSet() {
+				field.Set(cloneValue(value.Field(i)))
+			}
+		}
+		return cloned
+	default:
+		return value
+	}
+}
+
+// ensure CachedExecutor implements Executor.
+var _ Executor[any] = (*CachedExecutor[any])(nil)
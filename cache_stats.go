@@ -0,0 +1,140 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"iter"
+	"maps"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-juicedev/juice/eval"
+)
+
+// CacheSizer is implemented by the caches which report their number of entries, like MemoryResultCache.
+type CacheSizer interface {
+	Len() int
+}
+
+// CacheStats reports the sizes of the caches, for the operators running many engines in one process.
+type CacheStats struct {
+	// FuncNames is the number of the method references resolved to the statement ids,
+	// which is shared by all the engines of the process.
+	FuncNames int
+
+	// Statements is the number of the statements parsed by the configuration.
+	Statements int
+
+	// Expressions is the number of the compiled expressions cached by the eval package,
+	// which is shared by all the engines of the process.
+	Expressions int
+
+	// ResultTypes is the number of the struct types whose column fields are resolved for mapping the rows,
+	// which is shared by all the engines of the process.
+	ResultTypes int
+
+	// PreparedStatements is the number of the statements prepared by Engine.Warmup.
+	PreparedStatements int
+
+	// Caches are the number of entries of the caches registered by Engine.RegisterCache, by their names.
+	Caches map[string]int
+}
+
+// CacheBudget is the budget of the internal caches, the least recently used entries are evicted
+// once a cache exceeds its budget. Zero means no limit.
+type CacheBudget struct {
+	// ResultTypes is the max number of the struct types whose column fields are cached,
+	// which is shared by all the engines of the process.
+	ResultTypes int
+
+	// PreparedStatements is the max number of the statements prepared by Engine.Warmup kept by each engine,
+	// it applies when the statements are prepared.
+	PreparedStatements int
+}
+
+// cacheBudget is the budget set by SetCacheBudget.
+var cacheBudget atomic.Pointer[CacheBudget]
+
+// SetCacheBudget sets the budget of the internal caches of the process, for the operators running many engines.
+// The results cached by the ResultCache are budgeted by themselves, see NewLRUResultCache.
+//
+//	juice.SetCacheBudget(juice.CacheBudget{ResultTypes: 1000, PreparedStatements: 100})
+func SetCacheBudget(budget CacheBudget) {
+	budget.ResultTypes = max(budget.ResultTypes, 0)
+	budget.PreparedStatements = max(budget.PreparedStatements, 0)
+	cacheBudget.Store(&budget)
+	resizeColumnFieldsCache(budget.ResultTypes)
+}
+
+// currentCacheBudget returns the budget set by SetCacheBudget.
+func currentCacheBudget() CacheBudget {
+	if budget := cacheBudget.Load(); budget != nil {
+		return *budget
+	}
+	return CacheBudget{}
+}
+
+// cacheRegistry is the caches registered to the engine, it is shared by the cloned engines.
+type cacheRegistry struct {
+	mu     sync.RWMutex
+	caches map[string]CacheSizer
+}
+
+// RegisterCache registers the cache with the name, whose size is reported by CacheStats.
+// The cache registered with the same name is replaced.
+// The engine must be created by New, whose caches are shared by the cloned engines.
+//
+//	cache := juice.NewLRUResultCache(10000)
+//	engine.RegisterCache("users", cache)
+func (e *Engine) RegisterCache(name string, cache CacheSizer) {
+	if e.caches == nil {
+		panic("juice: RegisterCache on an engine not created by New")
+	}
+	e.caches.mu.Lock()
+	defer e.caches.mu.Unlock()
+	if e.caches.caches == nil {
+		e.caches.caches = make(map[string]CacheSizer)
+	}
+	e.caches.caches[name] = cache
+}
+
+// CacheStats returns the sizes of the caches.
+func (e *Engine) CacheStats() CacheStats {
+	stats := CacheStats{
+		FuncNames:   int(funcNameCacheSize.Load()),
+		Expressions: eval.CompiledExpressions(),
+		ResultTypes: cachedColumnFields(),
+
+		PreparedStatements: e.prepared.Len(),
+	}
+	if provider, ok := e.GetConfiguration().(interface{ Statements() iter.Seq[Statement] }); ok {
+		for range provider.Statements() {
+			stats.Statements++
+		}
+	}
+	var caches map[string]CacheSizer
+	if e.caches != nil {
+		e.caches.mu.RLock()
+		caches = maps.Clone(e.caches.caches)
+		e.caches.mu.RUnlock()
+	}
+	stats.Caches = make(map[string]int, len(caches))
+	for name, cache := range caches {
+		stats.Caches[name] = cache.Len()
+	}
+	return stats
+}
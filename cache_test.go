@@ -0,0 +1,151 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+func TestLRUResultCache(t *testing.T) {
+	cache := NewLRUResultCache(2)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	// a is used recently, so b is evicted.
+	if _, _, ok := cache.Get("a"); !ok {
+		t.Fatal("expected a in the cache")
+	}
+	cache.Set("c", 3)
+	if _, _, ok := cache.Get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", cache.Len())
+	}
+}
+
+func TestSetCacheBudget(t *testing.T) {
+	SetCacheBudget(CacheBudget{ResultTypes: 1, PreparedStatements: 1})
+	t.Cleanup(func() { SetCacheBudget(CacheBudget{}) })
+
+	type first struct {
+		ID int64 `column:"id"`
+	}
+	type second struct {
+		Name string `column:"name"`
+	}
+	columnFieldsOf(reflect.TypeFor[first]())
+	columnFieldsOf(reflect.TypeFor[second]())
+	if size := cachedColumnFields(); size != 1 {
+		t.Fatalf("expected 1 cached result type, got %d", size)
+	}
+
+	engine := newWarmupEngine(t, &batchCounter{})
+	const id = "main.Repository.BatchInsertValues"
+	err := engine.Warmup(context.Background(), WarmupOptions{Statements: []WarmupStatement{
+		{Statement: id, Param: H{"list": []batchUser{{}}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement, err := engine.StatementOf(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, _, err := statement.Build(engine.Driver().Translator(), H{"list": []batchUser{{}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stmt, release, ok := engine.prepared.get(engine.DB(), query)
+	if !ok {
+		t.Fatal("expected the prepared statement")
+	}
+	// the statement in use is evicted by the next one, but it is closed after it is released.
+	err = engine.Warmup(context.Background(), WarmupOptions{Statements: []WarmupStatement{
+		{Statement: id, Param: H{"list": []batchUser{{}, {}}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := engine.CacheStats(); stats.PreparedStatements != 1 || stats.ResultTypes != 1 {
+		t.Fatalf("unexpected cache stats: %+v", stats)
+	}
+	if _, err = stmt.ExecContext(context.Background(), "", 0); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if _, err = stmt.ExecContext(context.Background(), "", 0); err == nil {
+		t.Fatal("expected the evicted statement to be closed")
+	}
+}
+
+type countingExecutor struct {
+	statement Statement
+	queries   int
+}
+
+func (e *countingExecutor) QueryContext(_ context.Context, param Param) ([]map[string]any, error) {
+	e.queries++
+	return []map[string]any{{"name": "eat", "queries": e.queries}}, nil
+}
+
+func (e *countingExecutor) ExecContext(context.Context, Param) (sql.Result, error) { return nil, nil }
+
+func (e *countingExecutor) Statement() Statement { return e.statement }
+
+func (e *countingExecutor) Driver() driver.Driver { return driver.MySQLDriver{} }
+
+func TestCachedExecutor(t *testing.T) {
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Select, name: "main.User.List",
+		Nodes: NodeGroup{NewTextNode("select * from user where id = #{id}")}}
+	inner := &countingExecutor{statement: stmt}
+	executor := &CachedExecutor[[]map[string]any]{Executor: inner, Cache: NewLRUResultCache(8)}
+	ctx := context.Background()
+
+	result, err := executor.QueryContext(ctx, H{"id": 1, "ignored": make(chan int)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result[0]["name"] = "changed"
+	// the unused param does not change the key, and the cached result is not changed by its caller.
+	result, err = executor.QueryContext(ctx, H{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inner.queries != 1 || result[0]["name"] != "eat" {
+		t.Fatalf("expected the cached copy, got %v after %d queries", result, inner.queries)
+	}
+	// the string argument gets a different key from the integer one.
+	if _, err = executor.QueryContext(ctx, H{"id": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if inner.queries != 2 {
+		t.Fatalf("expected the different key, got %d queries", inner.queries)
+	}
+	// the queries in a transaction are not cached.
+	txCtx := ContextWithManager(ctx, &BasicTxManager{})
+	if _, err = executor.QueryContext(txCtx, H{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if inner.queries != 3 {
+		t.Fatalf("expected the transaction to bypass the cache, got %d queries", inner.queries)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/go-juicedev/juice/session"
+)
+
+// ChangeEvent describes a successful write of a statement.
+type ChangeEvent struct {
+	// Statement is the name of the statement.
+	Statement string
+	// Table is the table which is written.
+	Table string
+	// Action is the action of the statement, one of insert, update and delete.
+	Action Action
+	// PrimaryKey is the value of the parameter named by the primaryKey attribute of the statement.
+	PrimaryKey any
+	// Columns is the columns written by the insert or update statement.
+	Columns []string
+	// RowsAffected is the number of rows affected by the statement, -1 if the driver can not report it.
+	RowsAffected int64
+	// Param is the parameter of the statement.
+	Param Param
+}
+
+// ChangePublisher publishes the change events, for example to bust the caches or to update the search index.
+type ChangePublisher interface {
+	Publish(ctx context.Context, event ChangeEvent)
+}
+
+// ChangePublisherFunc is an adapter to allow the use of ordinary functions as ChangePublisher.
+type ChangePublisherFunc func(ctx context.Context, event ChangeEvent)
+
+// Publish calls f(ctx, event).
+func (f ChangePublisherFunc) Publish(ctx context.Context, event ChangeEvent) {
+	f(ctx, event)
+}
+
+// ensure ChangeEventMiddleware implements Middleware.
+var _ Middleware = (*ChangeEventMiddleware)(nil) // compile time check
+
+// ChangeEventMiddleware is a middleware that publishes a ChangeEvent after an insert, update or delete
+// statement is executed successfully. When the statement is executed in a transaction,
+// the event is published after the transaction is committed, and discarded if it is rolled back.
+//
+// The table is read from the table attribute of the statement, or parsed from the query if not set.
+// It can be turned off for a statement by setting the changeEvent attribute to false.
+type ChangeEventMiddleware struct {
+	Publisher ChangePublisher
+}
+
+// QueryContext implements Middleware.
+func (m *ChangeEventMiddleware) QueryContext(_ Statement, next QueryHandler) QueryHandler {
+	return next
+}
+
+// ExecContext implements Middleware.
+func (m *ChangeEventMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHandler {
+	if m.Publisher == nil || !stmt.Action().ForWrite() || stmt.Attribute("changeEvent") == "false" {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		result, err := next(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		event := m.event(ctx, stmt, query)
+		// the write succeeded even if the driver can not report the rows affected.
+		if event.RowsAffected, err = result.RowsAffected(); err != nil {
+			event.RowsAffected = -1
+		}
+		publish := func() { m.Publisher.Publish(ctx, event) }
+		if sess, _ := session.FromContext(ctx); sess != nil {
+			if committer, ok := sess.(session.AfterCommitter); ok {
+				committer.AfterCommit(publish)
+				return result, nil
+			}
+		}
+		publish()
+		return result, nil
+	}
+}
+
+// event builds the ChangeEvent without the rows affected.
+func (m *ChangeEventMiddleware) event(ctx context.Context, stmt Statement, query string) ChangeEvent {
+	param := ParamFromContext(ctx)
+	event := ChangeEvent{
+		Statement: stmt.Name(),
+		Table:     stmt.Attribute("table"),
+		Action:    stmt.Action(),
+		Param:     param,
+	}
+	if event.Table == "" {
+		event.Table = parseWriteTable(query)
+	}
+	if primaryKey := stmt.Attribute("primaryKey"); primaryKey != "" {
+		value, exists := newGenericParam(param, stmt.Attribute("paramName")).Get(primaryKey)
+		if exists && value.IsValid() && value.CanInterface() {
+			event.PrimaryKey = value.Interface()
+		}
+	}
+	switch event.Action {
+	case Insert:
+		event.Columns = parseInsertColumns(query)
+	case Update:
+		event.Columns = parseUpdateColumns(query)
+	}
+	return event
+}
+
+var (
+	// writeTableRegexp matches the table of the insert, update and delete statements.
+	writeTableRegexp = regexp.MustCompile("(?is)^\\s*(?:/\\*.*?\\*/\\s*)*(?:insert\\s+(?:ignore\\s+)?into|replace\\s+into|update|delete\\s+from)\\s+([\\w.`\"\\[\\]]+)")
+
+	// insertColumnsRegexp matches the column list of the insert statement.
+	insertColumnsRegexp = regexp.MustCompile(`(?is)^[^(]*\(([^)]*)\)`)
+
+	// updateSetRegexp matches the SET clause of the update statement.
+	updateSetRegexp = regexp.MustCompile(`(?is)\bset\b(.*?)(?:\bwhere\b|\breturning\b|$)`)
+)
+
+// parseWriteTable returns the table of the write query.
+func parseWriteTable(query string) string {
+	matched := writeTableRegexp.FindStringSubmatch(query)
+	if len(matched) != 2 {
+		return ""
+	}
+	return unquoteIdentifier(matched[1])
+}
+
+// parseInsertColumns returns the columns of the insert query.
+func parseInsertColumns(query string) []string {
+	matched := insertColumnsRegexp.FindStringSubmatch(query)
+	if len(matched) != 2 {
+		return nil
+	}
+	var columns []string
+	for _, column := range strings.Split(matched[1], ",") {
+		if column = unquoteIdentifier(strings.TrimSpace(column)); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// parseUpdateColumns returns the columns of the SET clause of the update query.
+func parseUpdateColumns(query string) []string {
+	matched := updateSetRegexp.FindStringSubmatch(query)
+	if len(matched) != 2 {
+		return nil
+	}
+	var columns []string
+	for _, assignment := range splitAssignments(matched[1]) {
+		column, _, found := strings.Cut(assignment, "=")
+		if !found {
+			continue
+		}
+		columns = append(columns, unquoteIdentifier(strings.TrimSpace(column)))
+	}
+	return columns
+}
+
+// unquoteIdentifier removes the dialect quotes of the identifier.
+func unquoteIdentifier(identifier string) string {
+	return strings.NewReplacer("`", "", `"`, "", "[", "", "]", "").Replace(identifier)
+}
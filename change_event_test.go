@@ -0,0 +1,101 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-juicedev/juice/session"
+)
+
+// failingCommitTx is the transaction session whose commit fails.
+type failingCommitTx struct{ recordingTx }
+
+func (f *failingCommitTx) Commit() error { return errors.New("connection lost") }
+
+func TestChangeEventMiddleware(t *testing.T) {
+	var events []ChangeEvent
+	middleware := &ChangeEventMiddleware{Publisher: ChangePublisherFunc(func(_ context.Context, event ChangeEvent) {
+		events = append(events, event)
+	})}
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Update, name: "main.User.Update", attrs: map[string]string{"primaryKey": "id"}}
+	var result sql.Result = sqldriver.RowsAffected(2)
+	exec := middleware.ExecContext(stmt, func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return result, nil
+	})
+	query := "update `user` set name = ?, age = ? where id = ?"
+	ctx := CtxWithParam(context.Background(), H{"id": 7})
+
+	if _, err := exec(ctx, query, "eat", 18, 7); err != nil {
+		t.Fatal(err)
+	}
+	want := ChangeEvent{Statement: "main.User.Update", Table: "user", Action: Update, PrimaryKey: 7,
+		Columns: []string{"name", "age"}, RowsAffected: 2, Param: H{"id": 7}}
+	if len(events) != 1 || !reflect.DeepEqual(events[0], want) {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	// the write succeeds even if the driver can not report the rows affected.
+	result = noRowsAffectedResult{}
+	got, err := exec(ctx, query, "eat", 18, 7)
+	if err != nil || got != result {
+		t.Fatalf("expected the result of the statement, got %v %v", got, err)
+	}
+	if len(events) != 2 || events[1].RowsAffected != -1 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	// the events of a transaction are published after the commit and discarded by the rollback.
+	result = sqldriver.RowsAffected(1)
+	events = nil
+	for _, tc := range []struct {
+		tx      session.TransactionSession
+		commit  bool
+		publish bool
+	}{
+		{tx: session.WithAfterCommit(&recordingTx{}), commit: true, publish: true},
+		{tx: session.WithAfterCommit(&recordingTx{}), commit: false, publish: false},
+		{tx: session.WithAfterCommit(&failingCommitTx{}), commit: true, publish: false},
+	} {
+		events = nil
+		if _, err = exec(session.WithContext(ctx, tc.tx), query, "eat", 18, 7); err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 0 {
+			t.Fatalf("expected the event to wait for the transaction, got %+v", events)
+		}
+		if tc.commit {
+			_ = tc.tx.Commit()
+		}
+		// the rollback after a failed commit publishes nothing either.
+		_ = tc.tx.Rollback()
+		if published := len(events) == 1; published != tc.publish {
+			t.Errorf("expected published %v, got %+v", tc.publish, events)
+		}
+	}
+}
+
+// noRowsAffectedResult is the result whose rows affected are not supported.
+type noRowsAffectedResult struct{}
+
+func (noRowsAffectedResult) LastInsertId() (int64, error) { return 0, errors.New("not supported") }
+func (noRowsAffectedResult) RowsAffected() (int64, error) { return 0, errors.New("not supported") }
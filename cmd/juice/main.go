@@ -0,0 +1,125 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command juice provides the tools for the juice mappers.
+//
+// Usage:
+//
+//	juice cover -config juice.xml -profile juice.cover
+//	juice schema -config juice.xml -statement main.UserRepository.FindByID
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"iter"
+	"os"
+
+	"github.com/go-juicedev/juice"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "cover":
+		err = cover(os.Args[2:])
+	case "schema":
+		err = schema(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "juice:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	_, _ = fmt.Fprintln(os.Stderr, "usage: juice <command> [arguments]")
+	_, _ = fmt.Fprintln(os.Stderr, "")
+	_, _ = fmt.Fprintln(os.Stderr, "commands:")
+	_, _ = fmt.Fprintln(os.Stderr, "\tcover\treport the statements not executed by the tests")
+	_, _ = fmt.Fprintln(os.Stderr, "\tschema\tprint the JSON schema of the parameters of the statements")
+}
+
+// cover reports the statements which are not in the coverage profile.
+func cover(args []string) error {
+	flags := flag.NewFlagSet("cover", flag.ExitOnError)
+	config := flags.String("config", "juice.xml", "the configuration file")
+	profile := flags.String("profile", "juice.cover", "the coverage profile written by juice.CoverageMiddleware")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := juice.NewXMLConfigurationWithoutEnvironments(*config)
+	if err != nil {
+		return err
+	}
+	executed, err := juice.ReadCoverageProfile(*profile)
+	if err != nil {
+		return err
+	}
+	report, err := juice.NewCoverageReport(cfg, executed)
+	if err != nil {
+		return err
+	}
+	_, err = report.WriteTo(os.Stdout)
+	return err
+}
+
+// schema prints the JSON schemas of the parameters of the statements, keyed by the statement names.
+func schema(args []string) error {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	config := flags.String("config", "juice.xml", "the configuration file")
+	statementID := flags.String("statement", "", "the statement to print, all the statements if empty")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := juice.NewXMLConfigurationWithoutEnvironments(*config)
+	if err != nil {
+		return err
+	}
+	schemas := make(map[string]*juice.ParamSchema)
+	if *statementID != "" {
+		statement, err := cfg.GetStatement(*statementID)
+		if err != nil {
+			return err
+		}
+		if schemas[statement.Name()], err = juice.NewParamSchema(statement); err != nil {
+			return err
+		}
+	} else {
+		provider, ok := cfg.(interface {
+			Statements() iter.Seq[juice.Statement]
+		})
+		if !ok {
+			return fmt.Errorf("unsupported configuration type %T", cfg)
+		}
+		for statement := range provider.Statements() {
+			if schemas[statement.Name()], err = juice.NewParamSchema(statement); err != nil {
+				return err
+			}
+		}
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schemas)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// columnField is a field of the struct which is mapped to a column by its column tag.
+type columnField struct {
+	// column is the column name of the column tag.
+	column string
+
+	// index is the index sequence of the field in the struct, see reflect.Value.FieldByIndex.
+	index []int
+
+	// field is the struct field, whose Index is the index in its own struct.
+	field reflect.StructField
+
+	// nullValue is the NULL sentinel declared by the nullValue option of the column tag.
+	nullValue    string
+	hasNullValue bool
+}
+
+// columnFieldsCache caches the column fields by the struct types, which is shared by all the engines of the process.
+// Its budget is set by SetCacheBudget.
+var columnFieldsCache struct {
+	mu     sync.Mutex
+	fields lru[reflect.Type, []columnField]
+}
+
+// columnFieldsOf returns the column fields of the struct type in the order they are declared,
+// the embedded structs without the column tags are walked into.
+func columnFieldsOf(tp reflect.Type) []columnField {
+	columnFieldsCache.mu.Lock()
+	fields, ok := columnFieldsCache.fields.get(tp)
+	columnFieldsCache.mu.Unlock()
+	if ok {
+		return fields
+	}
+	fields = walkColumnFields(tp, nil, nil)
+	columnFieldsCache.mu.Lock()
+	columnFieldsCache.fields.set(tp, fields)
+	columnFieldsCache.mu.Unlock()
+	return fields
+}
+
+// cachedColumnFields returns the number of the struct types whose column fields are cached.
+func cachedColumnFields() int {
+	columnFieldsCache.mu.Lock()
+	defer columnFieldsCache.mu.Unlock()
+	return columnFieldsCache.fields.len()
+}
+
+// resizeColumnFieldsCache changes the budget of the struct types whose column fields are cached.
+func resizeColumnFieldsCache(max int) {
+	columnFieldsCache.mu.Lock()
+	defer columnFieldsCache.mu.Unlock()
+	columnFieldsCache.fields.resize(max)
+}
+
+// walkColumnFields appends the column fields of the struct type to fields, walk is the index sequence of the struct.
+func walkColumnFields(tp reflect.Type, walk []int, fields []columnField) []columnField {
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		column, nullValue, hasNullValue := parseColumnTag(field.Tag.Get("column"))
+		// if the tag is empty or "-", we can skip it.
+		if skip := column == "" && !field.Anonymous || column == "-"; skip {
+			continue
+		}
+		// if the field is anonymous and the type is struct, we can walk into it.
+		if deepScan := field.Anonymous && field.Type.Kind() == reflect.Struct && len(column) == 0; deepScan {
+			fields = walkColumnFields(field.Type, slices.Concat(walk, field.Index), fields)
+			continue
+		}
+		fields = append(fields, columnField{
+			column:       column,
+			index:        slices.Concat(walk, field.Index),
+			field:        field,
+			nullValue:    nullValue,
+			hasNullValue: hasNullValue,
+		})
+	}
+	return fields
+}
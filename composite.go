@@ -0,0 +1,360 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-juicedev/juice/eval"
+)
+
+// CompositeStep is a step of the composite statement, which executes the referenced statement.
+type CompositeStep struct {
+	// Ref is the id of the statement, which is in the namespace of the mapper if it has no dot.
+	Ref string
+
+	// As is the name of the output of the step, which is a parameter of the following steps.
+	// The output of a select statement is its first row as a map by the column names, nil if no rows.
+	// The output of the other statements is a map of rowsAffected and lastInsertId.
+	As string
+
+	// Compensate is the id of the statement which undoes the step, like Ref.
+	// The composite statement with compensations is executed as a saga, see CompositeStatement.
+	Compensate string
+
+	// Environment is the id of the environment which executes the step and its compensation,
+	// the environment of the engine by default. Only the steps of a saga can have it,
+	// since the other environments can not join the transaction.
+	Environment string
+}
+
+// CompositeStatement is a sequence of the existing statements, which are executed in order
+// within one transaction, for the simple workflows without writing the orchestration in Go.
+//
+//	<composite id="Transfer">
+//	    <step ref="GetAccount" as="account"/>
+//	    <step ref="Debit" as="debit"/>
+//	    <step ref="main.AuditMapper.Record"/>
+//	</composite>
+//
+// The outputs of the steps are piped to the following steps by their names, before the parameter
+// of the composite statement, like #{account.balance} above.
+//
+// For the composites across the data sources, where the transaction is unavailable, the steps can
+// have the compensations, which makes the composite a saga. The steps are executed without
+// a transaction, each one on its environment, and the compensations of the completed steps are
+// executed in reverse order when a step fails, with the same parameter as the steps.
+// A failed compensation is retried by the compensateRetries attribute, 3 by default, waiting
+// the compensateBackoff attribute before the first retry, 100ms by default, and twice as long
+// before each of the following ones.
+//
+//	<composite id="Book" compensateRetries="5" compensateBackoff="200ms">
+//	    <step ref="ReserveSeat" compensate="ReleaseSeat"/>
+//	    <step ref="main.PaymentMapper.Charge" compensate="main.PaymentMapper.Refund" environment="payment"/>
+//	</composite>
+//
+// The statements referenced by the steps and the environments are checked when the mappers are parsed.
+type CompositeStatement struct {
+	mapper *Mapper
+	id     string
+	steps  []CompositeStep
+	attrs  map[string]string
+}
+
+// ID returns the id of the composite statement.
+func (c *CompositeStatement) ID() string {
+	return c.id
+}
+
+// Name returns the full name of the composite statement, which is the namespace and the id.
+func (c *CompositeStatement) Name() string {
+	return c.mapper.namespace + "." + c.id
+}
+
+// Attribute returns the value of the attribute with the given key.
+func (c *CompositeStatement) Attribute(key string) string {
+	return c.attrs[key]
+}
+
+// Steps returns the steps of the composite statement.
+func (c *CompositeStatement) Steps() []CompositeStep {
+	return c.steps
+}
+
+// validate returns an error if a step references an unknown statement or environment.
+// The environments are not checked if they are not parsed.
+func (c *CompositeStatement) validate(mappers *Mappers, envs EnvironmentProvider) error {
+	for index, step := range c.steps {
+		if _, err := mappers.GetStatementByID(c.resolveID(step.Ref)); err != nil {
+			return fmt.Errorf("composite %s step %d: %w", c.Name(), index+1, err)
+		}
+		if step.Compensate != "" {
+			if _, err := mappers.GetStatementByID(c.resolveID(step.Compensate)); err != nil {
+				return fmt.Errorf("composite %s compensation of step %d: %w", c.Name(), index+1, err)
+			}
+		}
+		if step.Environment != "" && envs != nil {
+			if _, err := envs.Use(step.Environment); err != nil {
+				return fmt.Errorf("composite %s step %d: %w", c.Name(), index+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveID returns the full id of the statement referenced by the step.
+func (c *CompositeStatement) resolveID(ref string) string {
+	if strings.Contains(ref, ".") {
+		return ref
+	}
+	return c.mapper.namespace + "." + ref
+}
+
+func (m *Mapper) setComposite(composite *CompositeStatement) error {
+	if m.composites == nil {
+		m.composites = make(map[string]*CompositeStatement)
+	}
+	if _, exists := m.composites[composite.id]; exists {
+		return fmt.Errorf("duplicate composite id: %s", composite.id)
+	}
+	if _, exists := m.statements[composite.id]; exists {
+		return fmt.Errorf("duplicate xmlSQLStatement id: %s", composite.id)
+	}
+	m.composites[composite.id] = composite
+	return nil
+}
+
+// validateComposites validates the composite statements of all the mappers, see CompositeStatement.validate.
+func (m *Mappers) validateComposites(envs EnvironmentProvider) error {
+	if m.mappers == nil {
+		return nil
+	}
+	for _, mapper := range m.mappers.All() {
+		for _, composite := range mapper.Value.composites {
+			if err := composite.validate(m, envs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetCompositeByID returns the composite statement by the id in the format of "namespace.id".
+func (m *Mappers) GetCompositeByID(id string) (*CompositeStatement, error) {
+	if m == nil {
+		return nil, fmt.Errorf("%w: composite '%s' not found in mapper configuration", ErrNoStatementFound, id)
+	}
+	mapper, key, err := m.getMapperAndKey(id)
+	if err != nil {
+		return nil, err
+	}
+	composite, exists := mapper.composites[key]
+	if !exists {
+		return nil, fmt.Errorf("%w: composite '%s' not found in mapper %s", ErrNoStatementFound, key, mapper.namespace)
+	}
+	return composite, nil
+}
+
+// CompositeResult is the outputs of the steps of the composite statement by their names.
+type CompositeResult map[string]any
+
+// ExecComposite executes the composite statement of the id within one transaction, the transaction
+// of the ctx is used if any. The outputs of the steps are returned by their names.
+func (e *Engine) ExecComposite(ctx context.Context, id string, param Param) (CompositeResult, error) {
+	provider, ok := e.GetConfiguration().(interface{ Mappers() *Mappers })
+	if !ok {
+		return nil, fmt.Errorf("composite statements are not supported by %T", e.GetConfiguration())
+	}
+	composite, err := provider.Mappers().GetCompositeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if ManagerFromContext(ctx) == nil {
+		ctx = ContextWithManager(ctx, e)
+	}
+	result := make(CompositeResult, len(composite.steps))
+	// the outputs come before the parameter of the composite statement.
+	stepParam := eval.ParamGroup{
+		newGenericParam(eval.H(result), ""),
+		newGenericParam(param, composite.Attribute("paramName")),
+	}
+	if composite.saga() {
+		err = composite.runSaga(ctx, e, stepParam, result)
+	} else {
+		err = NestedTransaction(ctx, func(ctx context.Context) error {
+			_, err := composite.runSteps(ctx, e, stepParam, result)
+			return err
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// runSteps executes the steps in order, and returns the number of the completed steps.
+func (c *CompositeStatement) runSteps(ctx context.Context, engine *Engine, param Param, result CompositeResult) (int, error) {
+	for index, step := range c.steps {
+		manager, err := stepManager(ctx, engine, step)
+		if err != nil {
+			return index, fmt.Errorf("composite %s step %d %s: %w", c.Name(), index+1, step.Ref, err)
+		}
+		output, err := execCompositeStep(ctx, manager, c.resolveID(step.Ref), param)
+		if err != nil {
+			return index, fmt.Errorf("composite %s step %d %s: %w", c.Name(), index+1, step.Ref, err)
+		}
+		if step.As != "" {
+			result[step.As] = output
+		}
+	}
+	return len(c.steps), nil
+}
+
+// saga reports whether any step of the composite statement has a compensation.
+func (c *CompositeStatement) saga() bool {
+	for _, step := range c.steps {
+		if step.Compensate != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// stepManager returns the manager executing the step, which is the manager of the ctx,
+// or the engine of the environment of the step.
+func stepManager(ctx context.Context, engine *Engine, step CompositeStep) (Manager, error) {
+	if step.Environment == "" {
+		return ManagerFromContext(ctx), nil
+	}
+	return engine.With(step.Environment)
+}
+
+const (
+	// defaultCompensateRetries is the default number of the retries of a failed compensation.
+	defaultCompensateRetries = 3
+
+	// defaultCompensateBackoff is the default wait before the first retry of a failed compensation.
+	defaultCompensateBackoff = 100 * time.Millisecond
+)
+
+// runSaga executes the steps without a transaction, each step is committed by itself.
+// When a step fails, the compensations of the completed steps are executed in reverse order.
+// The compensations are executed even if the ctx is canceled, since the completed steps
+// are committed anyway. The steps executed in a transaction of the ctx are not compensated,
+// which are rolled back as a whole, unlike the ones on the other environments.
+func (c *CompositeStatement) runSaga(ctx context.Context, engine *Engine, param Param, result CompositeResult) error {
+	completed, err := c.runSteps(ctx, engine, param, result)
+	if err == nil {
+		return err
+	}
+	// the attributes are validated when they are parsed.
+	retries, backoff := defaultCompensateRetries, defaultCompensateBackoff
+	if value := c.Attribute("compensateRetries"); value != "" {
+		retries, _ = strconv.Atoi(value)
+	}
+	if value := c.Attribute("compensateBackoff"); value != "" {
+		backoff, _ = time.ParseDuration(value)
+	}
+	inTx := IsTxManager(ManagerFromContext(ctx))
+	ctx = context.WithoutCancel(ctx)
+	errs := []error{err}
+	for index := completed - 1; index >= 0; index-- {
+		step := c.steps[index]
+		if step.Compensate == "" || (inTx && step.Environment == "") {
+			continue
+		}
+		if err = c.compensate(ctx, engine, step, param, retries, backoff); err != nil {
+			errs = append(errs, fmt.Errorf("composite %s compensation %s of step %d: %w", c.Name(), step.Compensate, index+1, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compensate executes the compensation of the step, which is retried with the exponential backoff.
+func (c *CompositeStatement) compensate(ctx context.Context, engine *Engine, step CompositeStep, param Param, retries int, backoff time.Duration) error {
+	manager, err := stepManager(ctx, engine, step)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		if _, err = execCompositeStep(ctx, manager, c.resolveID(step.Compensate), param); err == nil || attempt == retries {
+			return err
+		}
+		time.Sleep(backoff << attempt)
+	}
+}
+
+// execCompositeStep executes the statement of the step, and returns its output.
+func execCompositeStep(ctx context.Context, manager Manager, id string, param Param) (any, error) {
+	executor := manager.Object(id)
+	statement := executor.Statement()
+	if statement == nil || statement.Action() != Select {
+		result, err := executor.ExecContext(ctx, param)
+		if err != nil {
+			return nil, err
+		}
+		output := eval.H{}
+		if rowsAffected, err := result.RowsAffected(); err == nil {
+			output["rowsAffected"] = rowsAffected
+		}
+		if lastInsertId, err := result.LastInsertId(); err == nil {
+			output["lastInsertId"] = lastInsertId
+		}
+		return output, nil
+	}
+	rows, err := executor.QueryContext(ctx, param)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	return scanFirstRow(rows)
+}
+
+// scanFirstRow scans the first row as a map by the column names, it returns nil if there are no rows.
+func scanFirstRow(rows *sql.Rows) (eval.H, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	row := make(eval.H, len(columns))
+	for i, column := range columns {
+		// the text columns may be scanned as bytes by the drivers.
+		if value, ok := values[i].([]byte); ok {
+			row[column] = string(value)
+			continue
+		}
+		row[column] = values[i]
+	}
+	return row, rows.Err()
+}
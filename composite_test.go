@@ -0,0 +1,315 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/eval"
+)
+
+// compositeConn is a sql connection which records the executed statements,
+// whose queries return one row of the id column. The statements containing fail
+// fail after calling cancel if any, and the ones containing flaky fail while flaky is positive.
+type compositeConn struct {
+	execs   *[]string
+	commits *int
+	queries *atomic.Int64
+	cancel  func()
+	flaky   *int
+}
+
+func (c compositeConn) Connect(context.Context) (sqldriver.Conn, error) { return c, nil }
+
+func (c compositeConn) Driver() sqldriver.Driver { return nil }
+
+func (c compositeConn) Prepare(query string) (sqldriver.Stmt, error) {
+	return compositeStmt{conn: c, query: query}, nil
+}
+
+func (c compositeConn) Close() error { return nil }
+
+func (c compositeConn) Begin() (sqldriver.Tx, error) { return c, nil }
+
+func (c compositeConn) Commit() error {
+	*c.commits++
+	return nil
+}
+
+func (c compositeConn) Rollback() error { return nil }
+
+type compositeStmt struct {
+	conn  compositeConn
+	query string
+}
+
+func (s compositeStmt) Close() error { return nil }
+
+func (s compositeStmt) NumInput() int { return -1 }
+
+func (s compositeStmt) Exec(args []sqldriver.Value) (sqldriver.Result, error) {
+	if strings.Contains(s.query, "fail") {
+		if s.conn.cancel != nil {
+			s.conn.cancel()
+		}
+		return nil, errors.New("exec failed")
+	}
+	if strings.Contains(s.query, "flaky") && s.conn.flaky != nil && *s.conn.flaky > 0 {
+		*s.conn.flaky--
+		return nil, errors.New("exec failed")
+	}
+	var values []string
+	for _, arg := range args {
+		values = append(values, arg.(string))
+	}
+	*s.conn.execs = append(*s.conn.execs, s.query+" "+strings.Join(values, ","))
+	return sqldriver.RowsAffected(1), nil
+}
+
+func (s compositeStmt) Query([]sqldriver.Value) (sqldriver.Rows, error) {
+	if s.conn.queries != nil {
+		s.conn.queries.Add(1)
+	}
+	return &compositeRows{}, nil
+}
+
+type compositeRows struct{ done bool }
+
+func (r *compositeRows) Columns() []string { return []string{"id"} }
+
+func (r *compositeRows) Close() error { return nil }
+
+func (r *compositeRows) Next(dest []sqldriver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = []byte("42")
+	return nil
+}
+
+func TestEngine_ExecComposite(t *testing.T) {
+	mapper := `<mapper namespace="order">
+    <select id="GetUser">select id from user where name = #{name}</select>
+    <update id="Touch">update user set updated = now() where id = #{user.id}</update>
+    <insert id="Create">insert into orders (user_id, name) values (#{user.id}, #{name})</insert>
+    <composite id="PlaceOrder">
+        <step ref="GetUser" as="user"/>
+        <step ref="Touch"/>
+        <step ref="order.Create" as="created"/>
+    </composite>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("order.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mappers Mappers
+	if err = mappers.setMapper(m.namespace, m); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		execs   []string
+		commits int
+	)
+	db := sql.OpenDB(compositeConn{execs: &execs, commits: &commits})
+	t.Cleanup(func() { _ = db.Close() })
+	engine := &Engine{configuration: Configuration{mappers: &mappers}, db: db, driver: driver.MySQLDriver{}, rw: &NoOpRWMutex{}}
+
+	result, err := engine.ExecComposite(context.Background(), "order.PlaceOrder", map[string]any{"name": "book"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"update user set updated = now() where id = ? 42",
+		"insert into orders (user_id, name) values (?, ?) 42,book",
+	}
+	if strings.Join(execs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected executions:\n%s", strings.Join(execs, "\n"))
+	}
+	if commits != 1 {
+		t.Fatalf("expected one commit, got %d", commits)
+	}
+	if len(result) != 2 || result["user"].(eval.H)["id"] != "42" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+	if _, err = engine.ExecComposite(context.Background(), "order.Missing", nil); err == nil {
+		t.Fatal("expected an error for the missing composite")
+	}
+}
+
+func TestEngine_ExecCompositeSaga(t *testing.T) {
+	mapper := `<mapper namespace="booking">
+    <update id="Reserve">update seat set reserved = 1 where id = #{id}</update>
+    <update id="Release">update seat set reserved = 0 where id = #{id}</update>
+    <update id="Charge">update account set balance = balance - 1 where id = #{id}</update>
+    <update id="Refund">update account set balance = balance + 1 where id = #{id}</update>
+    <update id="Ship">update fail set shipped = 1 where id = #{id}</update>
+    <composite id="Book">
+        <step ref="Reserve" compensate="Release"/>
+        <step ref="Charge" compensate="Refund"/>
+        <step ref="Ship"/>
+    </composite>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("booking.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mappers Mappers
+	if err = mappers.setMapper(m.namespace, m); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		execs   []string
+		commits int
+	)
+	db := sql.OpenDB(compositeConn{execs: &execs, commits: &commits})
+	t.Cleanup(func() { _ = db.Close() })
+	engine := &Engine{configuration: Configuration{mappers: &mappers}, db: db, driver: driver.MySQLDriver{}, rw: &NoOpRWMutex{}}
+
+	if _, err = engine.ExecComposite(context.Background(), "booking.Book", map[string]any{"id": "7"}); err == nil {
+		t.Fatal("expected the error of the failed step")
+	}
+	want := []string{
+		"update seat set reserved = 1 where id = ? 7",
+		"update account set balance = balance - 1 where id = ? 7",
+		"update account set balance = balance + 1 where id = ? 7",
+		"update seat set reserved = 0 where id = ? 7",
+	}
+	if strings.Join(execs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected executions:\n%s", strings.Join(execs, "\n"))
+	}
+	if commits != 0 {
+		t.Fatalf("expected no transaction, got %d commits", commits)
+	}
+}
+
+func TestEngine_ExecCompositeSagaEnvironments(t *testing.T) {
+	mapper := `<mapper namespace="booking">
+    <update id="Reserve">update seat set reserved = 1 where id = #{id}</update>
+    <update id="Release">update seat set reserved = 0 where id = #{id}</update>
+    <update id="Charge">update account set balance = balance - 1 where id = #{id}</update>
+    <update id="Refund">update flaky set balance = balance + 1 where id = #{id}</update>
+    <update id="Ship">update fail set shipped = 1 where id = #{id}</update>
+    <composite id="Book" compensateRetries="2" compensateBackoff="1ms">
+        <step ref="Reserve" compensate="Release"/>
+        <step ref="Charge" compensate="Refund" environment="payment"/>
+        <step ref="Ship"/>
+    </composite>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("booking.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mappers Mappers
+	if err = mappers.setMapper(m.namespace, m); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		execs, paymentExecs []string
+		commits             int
+		flaky               = 2
+	)
+	db := sql.OpenDB(compositeConn{execs: &execs, commits: &commits, cancel: cancel})
+	t.Cleanup(func() { _ = db.Close() })
+	paymentDB := sql.OpenDB(compositeConn{execs: &paymentExecs, commits: new(int), flaky: &flaky})
+	t.Cleanup(func() { _ = paymentDB.Close() })
+	manager := &DBManager{}
+	manager.conns.Store("payment", &conn{db: paymentDB, drv: driver.MySQLDriver{}})
+	engine := &Engine{configuration: Configuration{mappers: &mappers}, db: db, driver: driver.MySQLDriver{}, manager: manager, rw: &NoOpRWMutex{}}
+
+	// the failed step cancels the ctx, the compensations are executed anyway.
+	if _, err = engine.ExecComposite(ctx, "booking.Book", map[string]any{"id": "7"}); err == nil {
+		t.Fatal("expected the error of the failed step")
+	}
+	want := []string{"update seat set reserved = 1 where id = ? 7", "update seat set reserved = 0 where id = ? 7"}
+	if strings.Join(execs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected executions:\n%s", strings.Join(execs, "\n"))
+	}
+	// the refund is retried twice on the payment environment.
+	want = []string{"update account set balance = balance - 1 where id = ? 7", "update flaky set balance = balance + 1 where id = ? 7"}
+	if strings.Join(paymentExecs, "\n") != strings.Join(want, "\n") || flaky != 0 {
+		t.Fatalf("unexpected payment executions:\n%s", strings.Join(paymentExecs, "\n"))
+	}
+
+	// in a transaction, only the steps on the other environments are compensated.
+	execs, paymentExecs = nil, nil
+	err = Transaction(ContextWithManager(context.Background(), engine), func(ctx context.Context) error {
+		_, err := engine.ExecComposite(ctx, "booking.Book", map[string]any{"id": "7"})
+		return err
+	})
+	if err == nil {
+		t.Fatal("expected the error of the failed step")
+	}
+	if want = []string{"update seat set reserved = 1 where id = ? 7"}; strings.Join(execs, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected executions:\n%s", strings.Join(execs, "\n"))
+	}
+	if len(paymentExecs) != 2 {
+		t.Fatalf("expected the compensation on the payment environment, got:\n%s", strings.Join(paymentExecs, "\n"))
+	}
+}
+
+func TestNewXMLConfigurationWithFS_Composites(t *testing.T) {
+	configuration := `<configuration>
+    <environments default="prod">
+        <environment id="prod">
+            <dataSource>fake</dataSource>
+            <driver>fake</driver>
+        </environment>
+    </environments>
+    <mappers>
+        <mapper namespace="booking">
+            <update id="Reserve">update seat set reserved = 1</update>
+            <update id="Release">update seat set reserved = 0</update>
+            %s
+        </mapper>
+    </mappers>
+</configuration>`
+	for name, composite := range map[string]string{
+		"unknown step":                   `<composite id="Book"><step ref="Missing"/></composite>`,
+		"unknown compensation":           `<composite id="Book"><step ref="Reserve" compensate="main.Missing.Release"/></composite>`,
+		"unknown environment":            `<composite id="Book"><step ref="Reserve" compensate="Release" environment="payment"/></composite>`,
+		"environment in a transaction":   `<composite id="Book"><step ref="Reserve" environment="prod"/></composite>`,
+		"negative compensateRetries":     `<composite id="Book" compensateRetries="-1"><step ref="Reserve" compensate="Release"/></composite>`,
+		"invalid compensateBackoff":      `<composite id="Book" compensateBackoff="soon"><step ref="Reserve" compensate="Release"/></composite>`,
+		"non-positive compensateBackoff": `<composite id="Book" compensateBackoff="0s"><step ref="Reserve" compensate="Release"/></composite>`,
+	} {
+		fsys := fstest.MapFS{"juice.xml": {Data: []byte(fmt.Sprintf(configuration, composite))}}
+		if _, err := NewXMLConfigurationWithFS(fsys, "juice.xml"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	valid := `<composite id="Book" compensateRetries="0" compensateBackoff="1s"><step ref="Reserve" compensate="booking.Release" environment="prod"/></composite>`
+	fsys := fstest.MapFS{"juice.xml": {Data: []byte(fmt.Sprintf(configuration, valid))}}
+	if _, err := NewXMLConfigurationWithFS(fsys, "juice.xml"); err != nil {
+		t.Fatal(err)
+	}
+}
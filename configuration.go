@@ -18,6 +18,7 @@ package juice
 
 import (
 	"io/fs"
+	"iter"
 	"path"
 	"path/filepath"
 )
@@ -61,10 +62,27 @@ func (c Configuration) GetStatement(v any) (Statement, error) {
 	return c.mappers.GetStatement(v)
 }
 
+// Mappers returns the mappers of the configuration.
+func (c Configuration) Mappers() *Mappers {
+	return c.mappers
+}
+
+// Statements returns all the statements of the mappers.
+func (c Configuration) Statements() iter.Seq[Statement] {
+	return c.mappers.Statements()
+}
+
 func NewXMLConfiguration(filename string) (IConfiguration, error) {
 	return newLocalXMLConfiguration(filename, false)
 }
 
+// NewXMLConfigurationWithoutEnvironments creates a new Configuration from an XML file without parsing
+// the environments, for the tools which only need the mappers, like the coverage and the schema commands,
+// so that the data sources and their environment variables are not required.
+func NewXMLConfigurationWithoutEnvironments(filename string) (IConfiguration, error) {
+	return newLocalXMLConfiguration(filename, true)
+}
+
 // for go linkname
 func newLocalXMLConfiguration(filename string, ignoreEnv bool) (IConfiguration, error) {
 	baseDir := filepath.Dir(filename)
@@ -72,7 +90,15 @@ func newLocalXMLConfiguration(filename string, ignoreEnv bool) (IConfiguration,
 	return newXMLConfigurationParser(localFS{baseDir: baseDir}, filename, ignoreEnv)
 }
 
-// NewXMLConfigurationWithFS creates a new Configuration from an XML file.
+// NewXMLConfigurationWithFS creates a new Configuration from an XML file of the fs.FS.
+// The resources and the patterns of the mappers are resolved against the directory of the file
+// in the same fs.FS, so the configuration and the mappers can be embedded into the binary by go:embed,
+// without the files on the disk at runtime.
+//
+//	//go:embed config
+//	var configFS embed.FS
+//
+//	cfg, err := juice.NewXMLConfigurationWithFS(configFS, "config/config.xml")
 func NewXMLConfigurationWithFS(fs fs.FS, filename string) (IConfiguration, error) {
 	baseDir := path.Dir(filename)
 	filename = path.Base(filename)
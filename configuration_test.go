@@ -1,8 +1,18 @@
 package juice
 
 import (
+	"context"
+	"database/sql"
 	"embed"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/fstest"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/eval"
 )
 
 //go:embed testdata/configuration
@@ -15,9 +25,679 @@ func TestNewXMLConfigurationWithFS(t *testing.T) {
 	}
 }
 
+func TestNewXMLConfigurationWithFS_Resources(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/juice.xml": {Data: []byte(`<configuration>
+    <environments default="prod">
+        <environment id="prod">
+            <dataSource>fake</dataSource>
+            <driver>fake</driver>
+        </environment>
+    </environments>
+    <mappers pattern="mappers/*.xml">
+        <mapper resource="shared/tag.xml"/>
+    </mappers>
+</configuration>`)},
+		"config/mappers/user.xml": {Data: []byte(`<mapper namespace="main.User"><select id="List">select * from user</select></mapper>`)},
+		"config/shared/tag.xml":   {Data: []byte(`<mapper namespace="main.Tag"><select id="List">select * from tag</select></mapper>`)},
+	}
+	configuration, err := NewXMLConfigurationWithFS(fsys, "config/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"main.User.List", "main.Tag.List"} {
+		if _, err = configuration.GetStatement(id); err != nil {
+			t.Errorf("expected the statement %s to be loaded from the fs: %v", id, err)
+		}
+	}
+}
+
+func TestNewXMLConfigurationWithFS_OnConnect(t *testing.T) {
+	fsys := fstest.MapFS{"juice.xml": {Data: []byte(`<configuration>
+    <environments default="prod">
+        <environment id="prod">
+            <dataSource>fake</dataSource>
+            <driver>fake</driver>
+            <onConnect>SET time_zone = '+00:00'</onConnect>
+            <onConnect>  </onConnect>
+            <onConnect>
+                SET search_path = app
+            </onConnect>
+        </environment>
+    </environments>
+</configuration>`)}}
+	configuration, err := NewXMLConfigurationWithFS(fsys, "juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := configuration.Environments().Use("prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"SET time_zone = '+00:00'", "SET search_path = app"}
+	if !reflect.DeepEqual(env.OnConnect, expected) {
+		t.Fatalf("unexpected on connect queries: %q", env.OnConnect)
+	}
+}
+
 func TestNewXMLConfiguration(t *testing.T) {
 	_, err := NewXMLConfiguration("testdata/configuration/juice.xml")
 	if err != nil {
 		t.Fatal(err)
 	}
 }
+
+func TestNewXMLConfigurationWithoutEnvironments(t *testing.T) {
+	cfg, err := NewXMLConfigurationWithoutEnvironments("testdata/configuration/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cfg.Environments().Use("prod"); err == nil {
+		t.Fatal("expected the environments not to be parsed")
+	}
+	if _, err = cfg.GetStatement("main.Repository.BatchInsertExec"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseMapper_ErrorPosition(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="List">
+        select * from user where id in
+        <foreach collection="ids">#{id}</foreach>
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	_, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	if parseError.File != "user.xml" || parseError.Line != 4 {
+		t.Fatalf("unexpected position: %v", parseError)
+	}
+}
+
+func TestStatementBuild_ErrorSource(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="List">
+        select * from user
+        <if test="id > 1">where id = #{id}</if>
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	statement := m.statements["List"]
+	if source, ok := SourceOf(statement); !ok || source.String() != "user.xml:2" {
+		t.Fatalf("unexpected statement source: %v", source)
+	}
+	_, _, err = statement.Build(driver.MySQLDriver{}.Translator(), H{"id": "1"})
+	var sourceError *SourceError
+	if !errors.As(err, &sourceError) {
+		t.Fatalf("expected SourceError, got %v", err)
+	}
+	if sourceError.Source.String() != "user.xml:4" {
+		t.Fatalf("unexpected source: %v", sourceError)
+	}
+	var exprError *eval.ExprError
+	if !errors.As(err, &exprError) {
+		t.Fatalf("expected ExprError, got %v", err)
+	}
+	if exprError.Statement != statement.Name() || exprError.Span != "id > 1" {
+		t.Fatalf("unexpected expression error: %v", exprError)
+	}
+}
+
+func TestStatementBuild_Truthy(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Search" truthy="true">
+        select * from user
+        <where>
+            <if test="name">name = #{name}</if>
+            <if test="tags and not deleted">
+                and tag in <foreach collection="tags" item="tag" open="(" separator="," close=")"><if test="tag and true">#{tag}</if></foreach>
+            </if>
+        </where>
+    </select>
+    <select id="Strict">select * from user <if test="name">where name = #{name}</if></select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	translator := driver.MySQLDriver{}.Translator()
+	query, args, err := m.statements["Search"].Build(translator, H{"name": "", "tags": []int{1, 2}, "deleted": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "select * from user WHERE tag in (?,?)" || len(args) != 2 {
+		t.Fatalf("unexpected query: %s %v", query, args)
+	}
+	if _, _, err = m.statements["Strict"].Build(translator, H{"name": []string{}}); err == nil {
+		t.Fatal("expected error without truthy mode")
+	}
+	m.mappers.cfg = &Configuration{settings: keyValueSettingProvider{"truthy": "true"}}
+	query, _, err = m.statements["Strict"].Build(translator, H{"name": []string{}})
+	if err != nil || query != "select * from user" {
+		t.Fatalf("unexpected query: %s %v", query, err)
+	}
+}
+
+func TestStatementBuild_FoldParamKeys(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Search" foldParamKeys="true">
+        select * from user where name = #{userName}
+        <if test="tagIds != nil">
+            and tag in <foreach collection="tag_ids" item="tag" open="(" separator="," close=")">#{tag.tagId}</foreach>
+        </if>
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	param := map[string]any{"user_name": "juice", "TagIDs": []map[string]any{{"tag_id": 1}, {"TagId": 2}}}
+	query, args, err := m.statements["Search"].Build(driver.MySQLDriver{}.Translator(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "select * from user where name = ? and tag in (?,?)" || len(args) != 3 || args[0] != "juice" || args[2] != 2 {
+		t.Fatalf("unexpected query: %s %v", query, args)
+	}
+}
+
+func TestStatementBuild_ExpressionLimits(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Search">select * from user <if test='name != "" and len(name) > 1 and len(name) != 10'>where name = #{name}</if></select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{cfg: &Configuration{settings: keyValueSettingProvider{"exprMaxCalls": "1"}}}
+	translator := driver.MySQLDriver{}.Translator()
+	_, _, err = m.statements["Search"].Build(translator, H{"name": "juice"})
+	if !errors.Is(err, eval.ErrExpressionLimitExceeded) {
+		t.Fatalf("expected ErrExpressionLimitExceeded, got %v", err)
+	}
+	m.mappers.cfg = &Configuration{settings: keyValueSettingProvider{"exprMaxCalls": "2", "exprTimeout": "1s"}}
+	query, _, err := m.statements["Search"].Build(translator, H{"name": "juice"})
+	if err != nil || query != "select * from user where name = ?" {
+		t.Fatalf("unexpected query: %s %v", query, err)
+	}
+}
+
+func TestGetStatement_Suggestions(t *testing.T) {
+	cfg, err := NewXMLConfiguration("testdata/configuration/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cfg.GetStatement("main.Repositry.HelloWorld")
+	var notFound *ErrStatementNotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected ErrStatementNotFound, got %v", err)
+	}
+	var mapperNotFound ErrMapperNotFound
+	if !errors.As(err, &mapperNotFound) {
+		t.Fatalf("expected ErrMapperNotFound, got %v", err)
+	}
+	if len(notFound.Closest) != 1 || notFound.Closest[0] != "main.Repository.HelloWorld" {
+		t.Fatalf("unexpected suggestions: %v", notFound.Closest)
+	}
+	expected := `mapper "main.Repositry" not found, did you mean "main.Repository.HelloWorld"?`
+	if err.Error() != expected {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestStatementBuild_Dialect(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Get" dialect="postgres">select * from user where id = #{id}</select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, _, err := m.statements["Get"].Build(driver.MySQLDriver{}.Translator(), H{"id": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "select * from user where id = $1" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+}
+
+func TestNewParamSchema(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Search">
+        select * from user
+        <where>
+            <if test="user?.Profile.Age > 18 and len(ids) > 0">
+                id in
+                <foreach collection="ids" item="id" open="(" separator="," close=")">#{id}</foreach>
+            </if>
+            and name = #{user.Name}
+        </where>
+        order by id ${asc ? 'ASC' : 'DESC'}
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	schema, err := NewParamSchema(m.statements["Search"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := schema.Properties["user"]
+	if user == nil || user.Properties["Name"] == nil || user.Properties["Profile"].Properties["Age"] == nil {
+		t.Fatalf("unexpected user schema: %+v", user)
+	}
+	if ids := schema.Properties["ids"]; ids == nil || ids.Type != "array" || ids.Items == nil {
+		t.Fatalf("unexpected ids schema: %+v", ids)
+	}
+	if len(schema.Properties) != 3 || schema.Properties["asc"] == nil {
+		t.Fatalf("unexpected properties: %v", schema.Properties)
+	}
+}
+
+func TestBindNode(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Search">
+        <bind name="pattern" value="'%' || name || '%'"/>
+        select * from user where name like #{pattern}
+        <foreach collection="tags" item="tag" open="and tag in (" separator="," close=")">
+            <bind name="upper" value="tag + '!'"/>#{upper}
+        </foreach>
+    </select>
+    <select id="Invalid"><bind name="pattern"/>select 1</select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	_, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected ParseError, got %v", err)
+	}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(strings.Replace(mapper, `<bind name="pattern"/>`, "", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	query, args, err := m.statements["Search"].Build(driver.MySQLDriver{}.Translator(), H{"name": "juice", "tags": []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "select * from user where name like ? and tag in (?,?)" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+	if !reflect.DeepEqual(args, []any{"%juice%", "a!", "b!"}) {
+		t.Fatalf("unexpected args: %v", args)
+	}
+	schema, err := NewParamSchema(m.statements["Search"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Properties) != 2 || schema.Properties["name"] == nil || schema.Properties["tags"] == nil {
+		t.Fatalf("unexpected properties: %v", schema.Properties)
+	}
+}
+
+func TestParseMapper_TrimOverrides(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Search">
+        select * from user
+        <trim prefix="WHERE" prefixOverrides="AND |OR " suffixOverrides="AND |OR ">
+            OR id = #{id} <if test='name != ""'>and name = #{name} and</if>
+        </trim>
+        <where suffixOverrides="LIMIT">1 = 1 LIMIT</where>
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	query, _, err := m.statements["Search"].Build(driver.MySQLDriver{}.Translator(), H{"id": 1, "name": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "select * from user WHERE id = ? and name = ? WHERE 1 = 1" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+}
+
+func TestChooseNode(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Search">
+        select * from user
+        <where>
+            <choose>
+                <when test="id > 0"><if test='name != ""'>name = #{name}</if></when>
+                <when test="missing.Field > 0">never</when>
+                <otherwise>status = 'ACTIVE'<choose><when test="admin">and admin = 1</when></choose></otherwise>
+            </choose>
+        </where>
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	translator := driver.MySQLDriver{}.Translator()
+	for _, tc := range []struct {
+		param H
+		want  string
+	}{
+		// the matched when wins even if it renders nothing, and the next one is never evaluated.
+		{param: H{"id": 1, "name": ""}, want: "select * from user"},
+		{param: H{"id": 1, "name": "a"}, want: "select * from user WHERE name = ?"},
+		{param: H{"id": 0, "missing": H{"Field": 0}, "admin": true}, want: "select * from user WHERE status = 'ACTIVE' and admin = 1"},
+	} {
+		query, _, err := m.statements["Search"].Build(translator, tc.param)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != tc.want {
+			t.Fatalf("expected %q, got %q", tc.want, query)
+		}
+	}
+
+	for _, invalid := range []string{
+		`<choose><otherwise>1</otherwise><when test="id > 0">2</when></choose>`,
+		`<choose><if test="id > 0">1</if></choose>`,
+	} {
+		_, err = parser.parseMapperByReader("user.xml", strings.NewReader(`<mapper namespace="main.User"><select id="Search">`+invalid+`</select></mapper>`))
+		if err == nil {
+			t.Fatalf("expected error of %s", invalid)
+		}
+	}
+}
+
+func TestDialectNode(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Page">
+        <dialect driver="mysql, sqlite3">select * from user limit #{offset}, #{limit}</dialect>
+        <dialect driver="oracle">select * from user offset #{offset} rows fetch next #{limit} rows only</dialect>
+        <dialect>select * from user limit #{limit} offset #{offset}</dialect>
+    </select>
+    <select id="Count">
+        <dialect driver="mysql">select count(*) from user</dialect>
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	param := H{"offset": 10, "limit": 20}
+	for _, tc := range []struct {
+		translator driver.Translator
+		want       string
+	}{
+		{translator: driver.MySQLDriver{}.Translator(), want: "select * from user limit ?, ?"},
+		{translator: driver.OracleDriver{}.Translator(), want: "select * from user offset :1 rows fetch next :2 rows only"},
+		{translator: driver.WithNamedPlaceholders(driver.OracleDriver{}, ":").Translator(), want: "select * from user offset :offset rows fetch next :limit rows only"},
+		{translator: driver.PostgresDriver{}.Translator(), want: "select * from user limit $1 offset $2"},
+	} {
+		query, _, err := m.statements["Page"].Build(tc.translator, param)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != tc.want {
+			t.Fatalf("expected %q, got %q", tc.want, query)
+		}
+	}
+	_, _, err = m.statements["Count"].Build(driver.PostgresDriver{}.Translator(), param)
+	if !errors.Is(err, ErrDialectNotMatched) {
+		t.Fatalf("expected ErrDialectNotMatched, got %v", err)
+	}
+	_, err = parser.parseMapperByReader("user.xml", strings.NewReader(`<mapper namespace="main.User"><select id="Page"><dialect>1</dialect><dialect>2</dialect></select></mapper>`))
+	if err == nil {
+		t.Fatal("expected error of the duplicate default dialect")
+	}
+}
+
+func TestStatementSchema(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="List">select * from ${schema}.user where id = #{id}</select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prod, staging := &Environment{}, &Environment{}
+	prod.setAttr("schema", "sales")
+	staging.setAttr("schema", "sales_staging")
+	envs := &environments{envs: map[string]*Environment{"prod": prod, "staging": staging}}
+	envs.setAttr("default", "prod")
+	m.mappers = &Mappers{cfg: Configuration{environments: envs}}
+	statement := m.statements["List"]
+	translator := driver.MySQLDriver{}.Translator()
+	engine := &Engine{using: "staging", configuration: m.mappers.cfg, rw: &NoOpRWMutex{}}
+	// the handler of the engine builds the statement with the schema of its environment.
+	var built schemaBuildHandler
+	handler := environmentSchemaHandler{StatementHandler: &built, schema: environmentSchema(engine.GetConfiguration(), engine.EnvID())}
+
+	for _, tc := range []struct {
+		ctx     context.Context
+		handler StatementHandler
+		want    string
+	}{
+		{ctx: context.Background(), handler: &built, want: "select * from sales.user where id = ?"},
+		{ctx: context.Background(), handler: handler, want: "select * from sales_staging.user where id = ?"},
+		{ctx: ContextWithSchema(context.Background(), "tenant_42"), handler: handler, want: "select * from tenant_42.user where id = ?"},
+	} {
+		// the built-in schema takes precedence over the parameter.
+		if _, err = tc.handler.ExecContext(tc.ctx, statement, H{"id": 1, "schema": "public"}); err != nil {
+			t.Fatal(err)
+		}
+		if built.query != tc.want || len(built.args) != 1 {
+			t.Fatalf("expected %q, got %q %v", tc.want, built.query, built.args)
+		}
+	}
+	if _, _, err = buildStatement(ContextWithSchema(context.Background(), "x; drop table user"), statement, translator, H{"id": 1}); err == nil {
+		t.Fatal("expected error of the invalid schema")
+	}
+	// without any schema the parameter is used like before.
+	prod.setAttr("schema", "")
+	query, _, err := buildStatement(context.Background(), statement, translator, H{"id": 1, "schema": "public"})
+	if err != nil || query != "select * from public.user where id = ?" {
+		t.Fatalf("unexpected query: %q %v", query, err)
+	}
+}
+
+// schemaBuildHandler is the statement handler which records the built statements.
+type schemaBuildHandler struct {
+	query string
+	args  []any
+}
+
+func (h *schemaBuildHandler) QueryContext(context.Context, Statement, Param) (*sql.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (h *schemaBuildHandler) ExecContext(ctx context.Context, statement Statement, param Param) (sql.Result, error) {
+	var err error
+	h.query, h.args, err = buildStatement(ctx, statement, driver.MySQLDriver{}.Translator(), param)
+	return nil, err
+}
+
+func TestIncludeProperties(t *testing.T) {
+	const common = `<mapper namespace="common">
+    <sql id="columns">${alias}.id, ${alias}.name</sql>
+    <sql id="join">join ${table} ${alias} on ${alias}.user_id = u.id <include refid="filter"><property name="column" value="${alias}.deleted"/></include></sql>
+    <sql id="filter">and ${column} = #{deleted}</sql>
+</mapper>`
+	const user = `<mapper namespace="main.User">
+    <select id="List">
+        select <include refid="common.columns"><property name="alias" value="u"/></include>,
+        <include refid="common.columns"><property name="alias" value="o"/></include>
+        from user u
+        <include refid="common.join"><property name="table" value="orders"/><property name="alias" value="o"/></include>
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	var mappers Mappers
+	for name, mapper := range map[string]string{"common.xml": common, "user.xml": user} {
+		m, err := parser.parseMapperByReader(name, strings.NewReader(mapper))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mappers.setMapper(m.namespace, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	statement, err := mappers.GetStatementByID("main.User.List")
+	if err != nil {
+		t.Fatal(err)
+	}
+	query, args, err := statement.Build(driver.MySQLDriver{}.Translator(), H{"deleted": 0, "alias": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "select u.id, u.name , o.id, o.name from user u join orders o on o.user_id = u.id and o.deleted = ?"
+	if query != want || len(args) != 1 {
+		t.Fatalf("unexpected query: %s %v", query, args)
+	}
+	schema, err := NewParamSchema(statement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Properties) != 1 || schema.Properties["deleted"] == nil {
+		t.Fatalf("unexpected properties: %v", schema.Properties)
+	}
+
+	for _, invalid := range []string{
+		`<include refid="columns"><property value="u"/></include>`,
+		`<include refid="columns"><property name="alias"/></include>`,
+		`<include refid="columns"><property name="alias" value="u"/><property name="alias" value="o"/></include>`,
+		`<include refid="columns"><if test="true">1</if></include>`,
+	} {
+		_, err = parser.parseMapperByReader("user.xml", strings.NewReader(`<mapper namespace="main.User"><select id="List">`+invalid+`</select></mapper>`))
+		if err == nil {
+			t.Fatalf("expected error of %s", invalid)
+		}
+	}
+}
+
+func TestWithExprTrace(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Search">
+        select * from user where 1 = 1
+        <if test='name != ""'>and name = #{name}</if>
+        <foreach collection="ids" item="id" open="and id in (" separator="," close=")"><if test="id > 1">#{id}</if></foreach>
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	ctx := WithExprTrace(context.Background())
+	_, _, err = buildStatement(ctx, m.statements["Search"], driver.MySQLDriver{}.Translator(), H{"name": "", "ids": []int{2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := ExprTraceFromContext(ctx).Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Statement != "main.User.Search" || entries[0].Expr != `name != ""` || entries[0].Result || entries[0].Inputs["name"] != "" {
+		t.Fatalf("unexpected entry: %+v", entries[0])
+	}
+	if entries[1].Expr != "id > 1" || !entries[1].Result || entries[1].Inputs["id"] != 2 {
+		t.Fatalf("unexpected entry: %+v", entries[1])
+	}
+	if steps := entries[1].Steps; len(steps) != 3 || steps[2].Expr != "id > 1" || steps[2].Operands[0] != 2 {
+		t.Fatalf("unexpected steps: %+v", steps)
+	}
+	if ExprTraceFromContext(context.Background()) != nil {
+		t.Fatal("expected no trace")
+	}
+}
+
+func TestBuildWithReport(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="Search">
+        select * from user
+        <where>
+            <if test='name != ""'>and name = #{name}</if>
+            <choose>
+                <when test="age > 18">and adult = 1</when>
+                <otherwise>and adult = 0</otherwise>
+            </choose>
+        </where>
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	report, err := BuildWithReport(context.Background(), m.statements["Search"], driver.MySQLDriver{}.Translator(), H{"name": "eat", "age": 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Statement != "main.User.Search" || report.Query != "select * from user WHERE name = ? and adult = 1" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if len(report.Args) != 1 || report.Args[0] != "eat" {
+		t.Fatalf("unexpected args: %v", report.Args)
+	}
+	if len(report.Branches) != 2 || !report.Branches[0].Result || report.Branches[1].Expr != "age > 18" || !report.Branches[1].Result {
+		t.Fatalf("unexpected branches: %+v", report.Branches)
+	}
+	if _, err = BuildWithReport(context.Background(), m.statements["Search"], driver.MySQLDriver{}.Translator(), H{"age": 20}); err == nil {
+		t.Fatal("expected the error of the missing parameter")
+	}
+}
+
+func TestNewXMLConfigurationWithFS_ExpressionLimits(t *testing.T) {
+	configuration := `<configuration>
+    <settings>
+        <setting name="%s" value="%s"/>
+    </settings>
+    <mappers>
+        <mapper namespace="main.User">
+            <select id="List">select * from user <if test="((((id)))) > 0">where id = #{id}</if></select>
+        </mapper>
+    </mappers>
+</configuration>`
+	for _, setting := range [][2]string{
+		{"exprMaxDepth", "-1"},
+		{"exprMaxNodes", "many"},
+		{"exprMaxCalls", "1.5"},
+		{"exprTimeout", "50"},
+		{"exprTimeout", "-1s"},
+		// the expression is deeper than the limit.
+		{"exprMaxDepth", "3"},
+	} {
+		fsys := fstest.MapFS{"juice.xml": {Data: []byte(fmt.Sprintf(configuration, setting[0], setting[1]))}}
+		if _, err := NewXMLConfigurationWithFS(fsys, "juice.xml"); err == nil {
+			t.Errorf("%s=%s: expected an error", setting[0], setting[1])
+		}
+	}
+	fsys := fstest.MapFS{"juice.xml": {Data: []byte(fmt.Sprintf(configuration, "exprMaxDepth", "16"))}}
+	if _, err := NewXMLConfigurationWithFS(fsys, "juice.xml"); err != nil {
+		t.Fatal(err)
+	}
+}
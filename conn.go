@@ -0,0 +1,82 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ConnManager is a Manager which executes all the statements on a single connection,
+// without starting a transaction. It is created by Engine.WithConn.
+type ConnManager struct {
+	engine *Engine
+	conn   *sql.Conn
+}
+
+// Object implements the Manager interface
+func (c *ConnManager) Object(v any) SQLRowsExecutor {
+	statement, err := c.engine.GetConfiguration().GetStatement(v)
+	if err != nil {
+		return inValidExecutor(err)
+	}
+	return NewSQLRowsExecutor(statement, c.engine.statementHandler(c.conn), c.engine.Driver())
+}
+
+// Raw returns a Runner which executes the query on the pinned connection.
+func (c *ConnManager) Raw(query string) Runner {
+	return NewRunner(query, c.engine, c.conn)
+}
+
+// Conn returns the pinned connection.
+func (c *ConnManager) Conn() *sql.Conn {
+	return c.conn
+}
+
+// ContextTx returns a TxManager which begins the transaction on the pinned connection.
+func (c *ConnManager) ContextTx(ctx context.Context, opt *sql.TxOptions) *BasicTxManager {
+	tx := c.engine.ContextTx(ctx, opt)
+	tx.conn = c.conn
+	return tx
+}
+
+// WithConn pins all the statements executed in the handler to a single connection,
+// which is returned to the pool after the handler returns.
+// It is needed by the features which require the connection affinity, like temporary
+// tables, session variables and advisory locks.
+// The statements should be executed by the manager from the context given to the handler,
+// and the Transaction started with that context also begins on the pinned connection.
+// For example:
+//
+//	err := engine.WithConn(ctx, func(ctx context.Context) error {
+//		manager := juice.ManagerFromContext(ctx)
+//		if _, err := manager.Object("CreateTempTable").ExecContext(ctx, nil); err != nil {
+//			return err
+//		}
+//		// ... use the temporary table
+//		return nil
+//	})
+func (e *Engine) WithConn(ctx context.Context, handler func(ctx context.Context) error) (err error) {
+	conn, err := e.DB().Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, conn.Close()) }()
+	manager := &ConnManager{engine: e, conn: conn}
+	return handler(ContextWithManager(ctx, manager))
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+// connectCounter counts the connections opened by the echo connector.
+type connectCounter struct {
+	echoConnector
+	connects *atomic.Int64
+}
+
+func (c connectCounter) Connect(ctx context.Context) (sqldriver.Conn, error) {
+	c.connects.Add(1)
+	return c.echoConnector.Connect(ctx)
+}
+
+func TestEngine_WithConn(t *testing.T) {
+	connector := connectCounter{echoConnector: newEchoConnector(), connects: new(atomic.Int64)}
+	engine := newEchoEngine(t, connector)
+	engine.DB().SetMaxIdleConns(0)
+
+	ctx := context.Background()
+	err := engine.WithConn(ctx, func(ctx context.Context) error {
+		manager, ok := ManagerFromContext(ctx).(*ConnManager)
+		if !ok {
+			t.Fatalf("expected the ConnManager in the context, got %T", ManagerFromContext(ctx))
+		}
+		for _, value := range []string{"a", "b"} {
+			values, err := NewGenericManager[[]string](manager).Object("echo.Echo").QueryContext(ctx, H{"value": value})
+			if err != nil {
+				return err
+			}
+			if !reflect.DeepEqual(values, []string{value}) {
+				t.Fatalf("unexpected values: %v", values)
+			}
+		}
+		rows, err := manager.Raw("select #{value}").Select(ctx, H{"value": "c"})
+		if err != nil {
+			return err
+		}
+		_ = rows.Close()
+		// the transaction begins on the pinned connection.
+		err = Transaction(ctx, func(ctx context.Context) error {
+			_, err := NewGenericManager[[]string](ManagerFromContext(ctx)).Object("echo.Echo").QueryContext(ctx, H{"value": "d"})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if inUse := engine.DB().Stats().InUse; inUse != 1 {
+			t.Fatalf("expected the pinned connection to be in use, got %d", inUse)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the idle connections are closed, so every connection taken from the pool is a new one.
+	if connects := connector.connects.Load(); connects != 1 {
+		t.Fatalf("expected the statements to be executed on a single connection, got %d connections", connects)
+	}
+	if commits := connector.commits.Load(); commits != 1 {
+		t.Fatalf("expected 1 commit, got %d", commits)
+	}
+	if inUse := engine.DB().Stats().InUse; inUse != 0 {
+		t.Fatalf("expected the connection to be returned to the pool, got %d in use", inUse)
+	}
+}
+
+func TestEngine_WithConn_Error(t *testing.T) {
+	engine := newEchoEngine(t, newEchoConnector())
+	handlerErr := errors.New("handler failed")
+	err := engine.WithConn(context.Background(), func(context.Context) error { return handlerErr })
+	if !errors.Is(err, handlerErr) {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+	if inUse := engine.DB().Stats().InUse; inUse != 0 {
+		t.Fatalf("expected the connection to be returned to the pool, got %d in use", inUse)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	err = engine.WithConn(ctx, func(context.Context) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || called {
+		t.Fatalf("expected context canceled without calling the handler, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ensure CoverageMiddleware implements Middleware.
+var _ Middleware = (*CoverageMiddleware)(nil) // compile time check
+
+// CoverageMiddleware is a middleware that records the statements executed,
+// so that the statements never executed by the tests can be reported, like the code coverage.
+//
+// Example usage:
+//
+//	var coverage = juice.NewCoverageMiddleware()
+//
+//	func TestMain(m *testing.M) {
+//		// engine.Use(coverage) when the engine is created.
+//		code := m.Run()
+//		if err := coverage.WriteFile("juice.cover"); err != nil {
+//			log.Fatal(err)
+//		}
+//		os.Exit(code)
+//	}
+//
+// Then run `juice cover -config juice.xml -profile juice.cover` to report the unexecuted statements.
+type CoverageMiddleware struct {
+	mu       sync.Mutex
+	executed map[string]struct{}
+}
+
+// NewCoverageMiddleware returns a new CoverageMiddleware.
+func NewCoverageMiddleware() *CoverageMiddleware {
+	return &CoverageMiddleware{executed: make(map[string]struct{})}
+}
+
+// QueryContext implements Middleware.
+func (m *CoverageMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryHandler {
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		m.record(stmt)
+		return next(ctx, query, args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *CoverageMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHandler {
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		m.record(stmt)
+		return next(ctx, query, args...)
+	}
+}
+
+// record records the statement as executed.
+func (m *CoverageMiddleware) record(stmt Statement) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executed[stmt.Name()] = struct{}{}
+}
+
+// Executed returns the names of the executed statements in order.
+func (m *CoverageMiddleware) Executed() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := make([]string, 0, len(m.executed))
+	for name := range m.executed {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// WriteFile writes the names of the executed statements into the coverage profile, one name per line.
+// The names already in the profile are kept, so that the profile can be shared by multiple test packages.
+func (m *CoverageMiddleware) WriteFile(name string) error {
+	executed, err := ReadCoverageProfile(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	executed = append(executed, m.Executed()...)
+	slices.Sort(executed)
+	executed = slices.Compact(executed)
+	return os.WriteFile(name, []byte(strings.Join(executed, "\n")+"\n"), 0o644)
+}
+
+// ReadCoverageProfile reads the names of the executed statements from the coverage profile.
+func ReadCoverageProfile(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	var executed []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			executed = append(executed, line)
+		}
+	}
+	return executed, scanner.Err()
+}
+
+// NamespaceCoverage is the coverage of the statements of a mapper.
+type NamespaceCoverage struct {
+	Namespace  string
+	Total      int
+	Executed   int
+	Unexecuted []string
+}
+
+// CoverageReport is the coverage of the statements of a configuration.
+type CoverageReport struct {
+	Namespaces []NamespaceCoverage
+}
+
+// NewCoverageReport compares the statements of the configuration with the executed ones.
+// The configuration must provide its statements, like the one created by NewXMLConfiguration.
+func NewCoverageReport(cfg IConfiguration, executed []string) (*CoverageReport, error) {
+	provider, ok := cfg.(interface{ Statements() iter.Seq[Statement] })
+	if !ok {
+		return nil, fmt.Errorf("coverage: configuration %T does not provide its statements", cfg)
+	}
+	var (
+		report  CoverageReport
+		visited = make(map[string]struct{}, len(executed))
+	)
+	for _, name := range executed {
+		visited[name] = struct{}{}
+	}
+	for statement := range provider.Statements() {
+		name := statement.Name()
+		namespace := strings.TrimSuffix(name, "."+statement.ID())
+		if last := len(report.Namespaces) - 1; last < 0 || report.Namespaces[last].Namespace != namespace {
+			report.Namespaces = append(report.Namespaces, NamespaceCoverage{Namespace: namespace})
+		}
+		coverage := &report.Namespaces[len(report.Namespaces)-1]
+		coverage.Total++
+		if _, ok = visited[name]; ok {
+			coverage.Executed++
+		} else {
+			coverage.Unexecuted = append(coverage.Unexecuted, statement.ID())
+		}
+	}
+	return &report, nil
+}
+
+// Percent returns the percentage of the executed statements.
+func (c NamespaceCoverage) Percent() float64 {
+	if c.Total == 0 {
+		return 100
+	}
+	return float64(c.Executed) * 100 / float64(c.Total)
+}
+
+// WriteTo writes the report as text into the writer.
+func (r *CoverageReport) WriteTo(w io.Writer) (int64, error) {
+	var (
+		written  int64
+		total    int
+		executed int
+	)
+	write := func(format string, args ...any) error {
+		n, err := fmt.Fprintf(w, format, args...)
+		written += int64(n)
+		return err
+	}
+	for _, coverage := range r.Namespaces {
+		total += coverage.Total
+		executed += coverage.Executed
+		if err := write("%s\t%d/%d\t%.1f%%\n", coverage.Namespace, coverage.Executed, coverage.Total, coverage.Percent()); err != nil {
+			return written, err
+		}
+		for _, id := range coverage.Unexecuted {
+			if err := write("\tnot executed: %s\n", id); err != nil {
+				return written, err
+			}
+		}
+	}
+	all := NamespaceCoverage{Total: total, Executed: executed}
+	err := write("total\t%d/%d\t%.1f%%\n", executed, total, all.Percent())
+	return written, err
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCoverageMiddleware(t *testing.T) {
+	engine := newEchoEngine(t, newEchoConnector())
+	coverage := NewCoverageMiddleware()
+	engine.Use(coverage)
+
+	ctx := context.Background()
+	manager := NewGenericManager[[]string](engine)
+	for range 2 {
+		if _, err := manager.Object("echo.Echo").QueryContext(ctx, H{"value": "a"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := engine.Object("echo.EchoAll").ExecContext(ctx, H{"values": []string{"a", "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if executed, expected := coverage.Executed(), []string{"echo.Echo", "echo.EchoAll"}; !reflect.DeepEqual(executed, expected) {
+		t.Fatalf("unexpected executed statements: %v", executed)
+	}
+
+	// the names already in the profile are kept.
+	profile := filepath.Join(t.TempDir(), "juice.cover")
+	if err := os.WriteFile(profile, []byte("echo.Echo\nother.List\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := coverage.WriteFile(profile); err != nil {
+		t.Fatal(err)
+	}
+	executed, err := ReadCoverageProfile(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"echo.Echo", "echo.EchoAll", "other.List"}; !reflect.DeepEqual(executed, expected) {
+		t.Fatalf("unexpected profile: %v", executed)
+	}
+}
+
+func TestNewCoverageReport(t *testing.T) {
+	engine := newEchoEngine(t, newEchoConnector())
+	report, err := NewCoverageReport(engine.GetConfiguration(), []string{"echo.Echo", "echo.EchoAll", "other.List"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []NamespaceCoverage{{Namespace: "echo", Total: 3, Executed: 2, Unexecuted: []string{"EchoSecret"}}}
+	if !reflect.DeepEqual(report.Namespaces, expected) {
+		t.Fatalf("unexpected report: %+v", report.Namespaces)
+	}
+	var text strings.Builder
+	if _, err = report.WriteTo(&text); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "echo\t2/3\t66.7%\n\tnot executed: EchoSecret\ntotal\t2/3\t66.7%\n"; text.String() != expected {
+		t.Fatalf("unexpected report text: %q", text.String())
+	}
+
+	// the configuration without its statements can not be reported.
+	if _, err = NewCoverageReport(struct{ IConfiguration }{}, nil); err == nil {
+		t.Fatal("expected the configuration without statements to fail")
+	}
+}
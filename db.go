@@ -38,6 +38,8 @@ type Source struct {
 	MaxOpenConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+	OnConnect       []string
+	Telemetry       driver.Telemetry
 }
 
 // conn represents an active database connection along with its associated driver.
@@ -121,6 +123,8 @@ func (m *DBManager) connect(name string, source Source) (db *sql.DB, drv driver.
 			driver.ConnectWithMaxIdleConnNum(source.MaxIdleConns),
 			driver.ConnectWithMaxConnLifetime(source.ConnMaxLifetime),
 			driver.ConnectWithMaxIdleConnLifetime(source.ConnMaxIdleTime),
+			driver.ConnectWithOnConnect(source.OnConnect...),
+			driver.ConnectWithTelemetry(source.Telemetry),
 		)
 		if err != nil {
 			err = fmt.Errorf("failed to create connection: %w", err)
@@ -212,13 +216,19 @@ func newDBManagerFromConfiguration(cfg IConfiguration) (*DBManager, error) {
 	}
 
 	for name, env := range cfg.Environments().Iter() {
-		if err := m.Add(name, Source{
+		telemetry, err := env.telemetry()
+		if err != nil {
+			return nil, fmt.Errorf("failed to add source %s: %w", name, err)
+		}
+		if err = m.Add(name, Source{
 			Driver:          env.Driver,
 			DSN:             env.DataSource,
 			MaxOpenConns:    env.MaxOpenConnNum,
 			MaxIdleConns:    env.MaxIdleConnNum,
 			ConnMaxLifetime: time.Duration(env.MaxConnLifetime) * time.Second,
 			ConnMaxIdleTime: time.Duration(env.MaxIdleConnLifetime) * time.Second,
+			OnConnect:       env.OnConnect,
+			Telemetry:       telemetry,
 		}); err != nil {
 			return nil, fmt.Errorf("failed to add source %s: %w", name, err)
 		}
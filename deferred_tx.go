@@ -0,0 +1,128 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+// ErrDeferredTxDone is an error that is returned when the DeferredTx is already committed or rolled back.
+var ErrDeferredTxDone = errors.New("juice: deferred transaction is already done")
+
+// DeferredTx is a transaction whose commit or rollback is deferred to the caller,
+// unlike Transaction which ends the transaction when the handler returns.
+// It is useful when the transaction is begun in a middleware, passed through the handlers by the context,
+// and ended by an outer middleware according to the result of the request, like the status of the response.
+//
+// For example:
+//
+//	ctx, tx, err := juice.BeginDeferredTx(juice.ContextWithManager(r.Context(), engine))
+//	if err != nil {
+//		// handle error
+//	}
+//	defer func() { _ = tx.Rollback() }() // no-op if already committed
+//	next.ServeHTTP(w, r.WithContext(ctx))
+//	if status < 400 {
+//		err = tx.Commit()
+//	}
+type DeferredTx struct {
+	tx   *BasicTxManager
+	mu   sync.Mutex
+	done bool
+}
+
+// Manager returns the TxManager of the transaction.
+func (d *DeferredTx) Manager() TxManager {
+	return d.tx
+}
+
+// Commit commits the transaction.
+// It returns ErrDeferredTxDone if the transaction is already committed or rolled back.
+// The transaction is not done if the commit fails, so that the deferred Rollback can still release it.
+func (d *DeferredTx) Commit() error {
+	return d.end(d.tx.Commit)
+}
+
+// Rollback rollbacks the transaction.
+// It returns nil if the transaction is already committed or rolled back,
+// so it is safe to be deferred right after the transaction is begun.
+func (d *DeferredTx) Rollback() error {
+	err := d.end(d.tx.Rollback)
+	// the transaction may be ended by the failed commit already.
+	if errors.Is(err, ErrDeferredTxDone) || errors.Is(err, sql.ErrTxDone) {
+		return nil
+	}
+	return err
+}
+
+// End commits the transaction if the given error is nil, otherwise rollbacks it.
+func (d *DeferredTx) End(err error) error {
+	if err != nil {
+		return errors.Join(err, d.Rollback())
+	}
+	return d.Commit()
+}
+
+// Done reports whether the transaction is already committed or rolled back.
+func (d *DeferredTx) Done() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.done
+}
+
+// end ends the transaction with the given function, until one of them succeeds.
+func (d *DeferredTx) end(fn func() error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done {
+		return ErrDeferredTxDone
+	}
+	err := fn()
+	d.done = err == nil || errors.Is(err, sql.ErrTxDone)
+	return err
+}
+
+// deferredTxKey is the context key of the DeferredTx.
+type deferredTxKey struct{}
+
+// BeginDeferredTx begins a DeferredTx with the Engine from the context,
+// and returns a new context carrying the transaction as its Manager.
+// If the manager of the context is not an instance of Engine, it will return ErrInvalidManager.
+// The ctx must should be created by ContextWithManager.
+func BeginDeferredTx(ctx context.Context, opts ...TransactionOptionFunc) (context.Context, *DeferredTx, error) {
+	engine, ok := ManagerFromContext(ctx).(*Engine)
+	if !ok {
+		return nil, nil, ErrInvalidManager
+	}
+	tx := engine.ContextTx(ctx, newTxOptions(opts...))
+	if err := tx.Begin(); err != nil {
+		return nil, nil, err
+	}
+	deferred := &DeferredTx{tx: tx}
+	ctx = ContextWithManager(ctx, tx)
+	ctx = context.WithValue(ctx, deferredTxKey{}, deferred)
+	return ctx, deferred, nil
+}
+
+// DeferredTxFromContext returns the DeferredTx from the context.
+func DeferredTxFromContext(ctx context.Context) (*DeferredTx, bool) {
+	deferred, ok := ctx.Value(deferredTxKey{}).(*DeferredTx)
+	return deferred, ok
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-juicedev/juice/session"
+)
+
+func TestBeginDeferredTx(t *testing.T) {
+	connector := newEchoConnector()
+	engine := newEchoEngine(t, connector)
+
+	ctx, tx, err := BeginDeferredTx(ContextWithManager(context.Background(), engine))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deferred, ok := DeferredTxFromContext(ctx); !ok || deferred != tx {
+		t.Fatal("expected the deferred transaction in the context")
+	}
+	if ManagerFromContext(ctx) != tx.Manager() {
+		t.Fatal("expected the transaction to be the manager of the context")
+	}
+	// the statements executed with the context are in the transaction.
+	values, err := NewGenericManager[[]string](ManagerFromContext(ctx)).Object("echo.Echo").QueryContext(ctx, H{"value": "a"})
+	if err != nil || len(values) != 1 || values[0] != "a" {
+		t.Fatalf("unexpected query result: %v, %v", values, err)
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if !tx.Done() || connector.commits.Load() != 1 {
+		t.Fatalf("expected the transaction to be committed, got %d commits", connector.commits.Load())
+	}
+	// the deferred rollback after the commit is a no-op, the second commit is an error.
+	if err = tx.Rollback(); err != nil || connector.rollbacks.Load() != 0 {
+		t.Fatalf("expected no rollback, got %v and %d rollbacks", err, connector.rollbacks.Load())
+	}
+	if err = tx.Commit(); !errors.Is(err, ErrDeferredTxDone) {
+		t.Fatalf("expected ErrDeferredTxDone, got %v", err)
+	}
+}
+
+func TestDeferredTx_End(t *testing.T) {
+	connector := newEchoConnector()
+	engine := newEchoEngine(t, connector)
+	ctx := ContextWithManager(context.Background(), engine)
+
+	_, tx, err := BeginDeferredTx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlerErr := errors.New("handler failed")
+	if err = tx.End(handlerErr); !errors.Is(err, handlerErr) {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+	if connector.rollbacks.Load() != 1 || connector.commits.Load() != 0 {
+		t.Fatalf("expected a rollback, got %d rollbacks and %d commits", connector.rollbacks.Load(), connector.commits.Load())
+	}
+
+	if _, tx, err = BeginDeferredTx(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err = tx.End(nil); err != nil || connector.commits.Load() != 1 {
+		t.Fatalf("expected a commit, got %v and %d commits", err, connector.commits.Load())
+	}
+
+	if _, _, err = BeginDeferredTx(context.Background()); !errors.Is(err, ErrInvalidManager) {
+		t.Fatalf("expected ErrInvalidManager, got %v", err)
+	}
+}
+
+func TestDeferredTx_CommitFailure(t *testing.T) {
+	engine := newEchoEngine(t, newEchoConnector())
+	raw := &failingCommitTx{}
+	tx := &DeferredTx{tx: &BasicTxManager{engine: engine, tx: raw}}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("expected the commit to fail")
+	}
+	if tx.Done() {
+		t.Fatal("expected the transaction not to be done after the failed commit")
+	}
+	// the deferred rollback still reaches the transaction.
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if !tx.Done() || len(raw.queries) != 1 || raw.queries[0] != "ROLLBACK" {
+		t.Fatalf("expected the transaction to be rolled back, got %q", raw.queries)
+	}
+	if err := tx.Rollback(); err != nil || len(raw.queries) != 1 {
+		t.Fatalf("expected the second rollback to be a no-op, got %v and %q", err, raw.queries)
+	}
+
+	// the transaction ended by the driver on the failed commit is done by the deferred rollback.
+	connector := newEchoConnector()
+	_, tx, err := BeginDeferredTx(ContextWithManager(context.Background(), newEchoEngine(t, connector)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Manager().(*BasicTxManager).tx.(session.BeforeEnder).BeforeEnd(func() error { return errors.New("reset failed") })
+	if err = tx.Commit(); err == nil {
+		t.Fatal("expected the commit to fail")
+	}
+	if connector.rollbacks.Load() != 1 || connector.commits.Load() != 0 {
+		t.Fatalf("expected the failed commit to roll back, got %d rollbacks and %d commits", connector.rollbacks.Load(), connector.commits.Load())
+	}
+	if err = tx.Rollback(); err != nil || !tx.Done() {
+		t.Fatalf("expected the deferred rollback to end the transaction, got %v", err)
+	}
+}
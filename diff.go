@@ -0,0 +1,81 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/go-juicedev/juice/internal/reflectlite"
+)
+
+// errStructRequired is an error that is returned when the snapshots to diff are not structs.
+var errStructRequired = errors.New("diff: type must be a struct or a pointer to struct")
+
+// Diff compares two snapshots of an entity and returns the fields which are changed as a FieldMask,
+// so that only the changed columns are written by a set node declared with fieldMask.
+//
+// The field is named by its column tag, or by its param tag and field name if the column tag is not set.
+// The fields of the embedded structs are compared recursively.
+//
+// Example usage:
+//
+//	changed, err := juice.Diff(before, after)
+//	if err != nil {
+//	    return err
+//	}
+//	param := juice.H{"user": after, "mask": changed}
+func Diff[T any](before, after T) (FieldMask, error) {
+	beforeValue := reflectlite.Unwrap(reflect.ValueOf(before))
+	afterValue := reflectlite.Unwrap(reflect.ValueOf(after))
+	if beforeValue.Kind() != reflect.Struct || afterValue.Kind() != reflect.Struct {
+		return nil, errStructRequired
+	}
+	var mask FieldMask
+	diffStruct(beforeValue, afterValue, &mask)
+	return mask, nil
+}
+
+// diffStruct appends the names of the changed fields into the mask.
+func diffStruct(before, after reflect.Value, mask *FieldMask) {
+	tp := before.Type()
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		column, _, _ := parseColumnTag(field.Tag.Get("column"))
+		if column == "-" {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && column == "" {
+			diffStruct(before.Field(i), after.Field(i), mask)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if reflect.DeepEqual(before.Field(i).Interface(), after.Field(i).Interface()) {
+			continue
+		}
+		if column != "" {
+			*mask = append(*mask, column)
+			continue
+		}
+		if param := field.Tag.Get("param"); param != "" {
+			*mask = append(*mask, param)
+		}
+		*mask = append(*mask, field.Name)
+	}
+}
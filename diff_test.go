@@ -0,0 +1,62 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type Audit struct {
+		UpdatedBy string `column:"updated_by"`
+	}
+	type User struct {
+		Audit
+		ID      int64  `column:"id"`
+		Name    string `column:"name"`
+		Age     int    `param:"age"`
+		Email   string
+		Tags    []string `column:"tags"`
+		Ignored string   `column:"-"`
+		secret  string
+	}
+	before := User{ID: 1, Name: "eat", Age: 18, Email: "a@b", Tags: []string{"a"}, Ignored: "x", secret: "s"}
+	after := before
+	after.Name, after.Age, after.Email = "juice", 19, "c@d"
+	after.Tags = []string{"a", "b"}
+	after.UpdatedBy = "admin"
+	after.Ignored, after.secret = "y", "t"
+
+	mask, err := Diff(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := FieldMask{"updated_by", "name", "age", "Age", "Email", "tags"}
+	if !reflect.DeepEqual(mask, expected) {
+		t.Fatalf("expected %v, got %v", expected, mask)
+	}
+
+	// the pointers are compared by the structs they point to.
+	if mask, err = Diff(&before, &before); err != nil || len(mask) != 0 {
+		t.Fatalf("expected no changes, got %v, %v", mask, err)
+	}
+	if _, err = Diff(1, 2); !errors.Is(err, errStructRequired) {
+		t.Fatalf("expected errStructRequired, got %v", err)
+	}
+}
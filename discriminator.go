@@ -0,0 +1,222 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrUnknownDiscriminator is returned by DiscriminatorResultMap when the value of the discriminator
+// column matches no case and there is no default.
+var ErrUnknownDiscriminator = errors.New("juice: unknown discriminator value")
+
+// DiscriminatedUnion is implemented by the tagged union wrappers which DiscriminatorResultMap maps the rows into,
+// the wrapper keeps the concrete value and the key of its case.
+//
+//	type Animal struct {
+//		Kind  string
+//		Value any
+//	}
+//
+//	func (a *Animal) SetVariant(key string, value any) error {
+//		a.Kind, a.Value = key, value
+//		return nil
+//	}
+type DiscriminatedUnion interface {
+	// SetVariant sets the value scanned by the case of the key, which is a pointer of the concrete type.
+	SetVariant(key string, value any) error
+}
+
+// DiscriminatorResultMap is a ResultMap which selects the concrete type of each row by the value of
+// the discriminator column, for the single table inheritance schemas whose rows are of different types.
+// The destination is a pointer to a value, or a pointer to a slice, of an interface implemented by
+// the concrete types, or of a DiscriminatedUnion wrapper.
+//
+//	resultMap := juice.DiscriminatorResultMap{
+//		Column: "kind",
+//		Cases: map[string]func() any{
+//			"dog": func() any { return new(Dog) },
+//			"cat": func() any { return new(Cat) },
+//		},
+//	}
+//	animals, err := juice.BindWithResultMap[[]Animal](rows, resultMap)
+//
+// The rows are scanned twice, once for the discriminator and once for the concrete type,
+// so the Rows must support scanning a row more than once, like *sql.Rows.
+type DiscriminatorResultMap struct {
+	// Column is the discriminator column.
+	Column string
+	// Cases returns the new pointers of the concrete types by the values of the discriminator column.
+	Cases map[string]func() any
+	// Default returns the new pointer of the concrete type of the values matching no case.
+	// ErrUnknownDiscriminator is returned for them if it is nil.
+	Default func() any
+	// FoldColumnNames matches columns to `column` tags case-insensitively.
+	// See foldColumnName for the details.
+	FoldColumnNames bool
+}
+
+// MapTo implements ResultMap.
+func (m DiscriminatorResultMap) MapTo(rv reflect.Value, rows Rows) error {
+	if rv.Kind() != reflect.Pointer {
+		return ErrPointerRequired
+	}
+	target := rv.Elem()
+	columns, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("failed to get columns: %w", err)
+	}
+	mapper, err := m.newRowMapper(columns)
+	if err != nil {
+		return err
+	}
+	if target.Kind() != reflect.Slice {
+		if !rows.Next() {
+			if err = rows.Err(); err != nil {
+				return fmt.Errorf("error occurred while fetching row: %w", err)
+			}
+			return sql.ErrNoRows
+		}
+		if err = mapper.mapRow(rows, target); err != nil {
+			return err
+		}
+		if rows.Next() {
+			return ErrTooManyRows
+		}
+		return rows.Err()
+	}
+	values := reflect.MakeSlice(target.Type(), 0, defaultRowsCapacity)
+	element := reflect.New(target.Type().Elem()).Elem()
+	for rows.Next() {
+		element.SetZero()
+		if err = mapper.mapRow(rows, element); err != nil {
+			return err
+		}
+		values = reflect.Append(values, element)
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error occurred while iterating rows: %w", err)
+	}
+	target.Set(values)
+	return nil
+}
+
+// newRowMapper returns the mapper of the rows of the columns.
+func (m DiscriminatorResultMap) newRowMapper(columns []string) (*discriminatorRowMapper, error) {
+	mapper := &discriminatorRowMapper{
+		resultMap:    m,
+		columns:      columns,
+		column:       -1,
+		destinations: make(map[reflect.Type]*rowDestination),
+		probe:        make([]any, len(columns)),
+	}
+	for i, column := range columns {
+		if column == m.Column || m.FoldColumnNames && foldColumnName(column) == foldColumnName(m.Column) {
+			mapper.column = i
+		}
+		mapper.probe[i] = &sink
+	}
+	if mapper.column < 0 {
+		return nil, fmt.Errorf("discriminator column %s not found", m.Column)
+	}
+	mapper.probe[mapper.column] = &mapper.key
+	return mapper, nil
+}
+
+// discriminatorRowMapper maps the rows of a result set, the destinations of the concrete types are reused by the rows.
+type discriminatorRowMapper struct {
+	resultMap    DiscriminatorResultMap
+	columns      []string
+	column       int
+	destinations map[reflect.Type]*rowDestination
+	// probe scans the discriminator column only.
+	probe []any
+	key   any
+}
+
+// mapRow scans the current row into a new value of its concrete type, and sets it to the target.
+func (d *discriminatorRowMapper) mapRow(rows Rows, target reflect.Value) error {
+	d.key = nil
+	if err := rows.Scan(d.probe...); err != nil {
+		return fmt.Errorf("failed to scan discriminator: %w", err)
+	}
+	key := discriminatorKey(d.key)
+	newValue, ok := d.resultMap.Cases[key]
+	if !ok {
+		if newValue = d.resultMap.Default; newValue == nil {
+			return fmt.Errorf("%w: %s = %q", ErrUnknownDiscriminator, d.resultMap.Column, key)
+		}
+	}
+	value := reflect.ValueOf(newValue())
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return fmt.Errorf("discriminator case %q must return a non-nil pointer, got %s", key, value.Kind())
+	}
+	destination, exists := d.destinations[value.Type()]
+	if !exists {
+		destination = &rowDestination{foldColumns: d.resultMap.FoldColumnNames}
+		d.destinations[value.Type()] = destination
+	}
+	dest, err := destination.Destination(value.Elem(), d.columns)
+	if err != nil {
+		return fmt.Errorf("failed to create destination mapping: %w", err)
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return fmt.Errorf("failed to scan row: %w", err)
+	}
+	return setVariant(target, key, value)
+}
+
+// discriminatorKey returns the scanned value of the discriminator column as a string.
+func discriminatorKey(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []byte:
+		return string(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// setVariant sets the scanned pointer of the concrete type to the target, which is an interface
+// implemented by the pointer or the value, or a DiscriminatedUnion.
+func setVariant(target reflect.Value, key string, value reflect.Value) error {
+	targetType := target.Type()
+	switch {
+	case value.Type().AssignableTo(targetType):
+		target.Set(value)
+	case value.Elem().Type().AssignableTo(targetType):
+		target.Set(value.Elem())
+	case target.CanAddr() && reflect.PointerTo(targetType).Implements(discriminatedUnionType):
+		return target.Addr().Interface().(DiscriminatedUnion).SetVariant(key, value.Interface())
+	case targetType.Kind() == reflect.Pointer && targetType.Implements(discriminatedUnionType):
+		union := reflect.New(targetType.Elem())
+		target.Set(union)
+		return union.Interface().(DiscriminatedUnion).SetVariant(key, value.Interface())
+	default:
+		return fmt.Errorf("discriminator case %q of %s can not be set to %s", key, value.Type(), targetType)
+	}
+	return nil
+}
+
+// discriminatedUnionType is the type of the DiscriminatedUnion interface.
+var discriminatedUnionType = reflect.TypeOf((*DiscriminatedUnion)(nil)).Elem()
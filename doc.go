@@ -48,6 +48,13 @@ Basic Usage:
 	}
 	fmt.Println(result)
 
+The configuration and the mappers can also be embedded into the binary:
+
+	//go:embed config
+	var configFS embed.FS
+
+	cfg, err := juice.NewXMLConfigurationWithFS(configFS, "config/config.xml")
+
 Features:
 
   - XML-based SQL configuration
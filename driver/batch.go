@@ -0,0 +1,44 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+	"database/sql"
+)
+
+// BulkExecer is implemented by the drivers which can execute a prepared statement
+// with many rows of arguments at once, like the array binding of Oracle.
+// It is used by the bulk strategy of the batch inserts.
+type BulkExecer interface {
+	BulkExecContext(ctx context.Context, stmt *sql.Stmt, rows [][]any) (sql.Result, error)
+}
+
+// MultiRowValuesSupporter is implemented by the drivers to report
+// whether the insert statements accept multiple rows of VALUES.
+type MultiRowValuesSupporter interface {
+	SupportsMultiRowValues() bool
+}
+
+// SupportsMultiRowValues reports whether the driver accepts multiple rows of VALUES in one insert statement.
+// The drivers which do not implement MultiRowValuesSupporter are considered to support it.
+func SupportsMultiRowValues(driver Driver) bool {
+	if supporter, ok := driver.(MultiRowValuesSupporter); ok {
+		return supporter.SupportsMultiRowValues()
+	}
+	return true
+}
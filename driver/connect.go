@@ -27,6 +27,8 @@ type connectOption struct {
 	MaxOpenConnNum      int
 	MaxConnLifetime     time.Duration
 	MaxIdleConnLifetime time.Duration
+	OnConnect           []string
+	Telemetry           Telemetry
 }
 
 // ConnectOptionFunc is a function to set the connection option.
@@ -66,7 +68,7 @@ func Connect(driver string, datasource string, opts ...ConnectOptionFunc) (*sql.
 	for _, opt := range opts {
 		opt(&option)
 	}
-	db, err := sql.Open(driver, datasource)
+	db, err := open(driver, datasource, option)
 	if err != nil {
 		return nil, err
 	}
@@ -84,3 +86,22 @@ func Connect(driver string, datasource string, opts ...ConnectOptionFunc) (*sql.
 	}
 	return db, nil
 }
+
+// open opens the database with the connector wrappers required by the option.
+func open(driver string, datasource string, option connectOption) (*sql.DB, error) {
+	if len(option.OnConnect) == 0 && option.Telemetry == nil {
+		return sql.Open(driver, datasource)
+	}
+	connector, err := openConnector(driver, datasource)
+	if err != nil {
+		return nil, err
+	}
+	// the on connect queries are measured as well.
+	if option.Telemetry != nil {
+		connector = telemetryConnector{Connector: connector, telemetry: option.Telemetry}
+	}
+	if len(option.OnConnect) > 0 {
+		connector = onConnectConnector{Connector: connector, queries: option.OnConnect}
+	}
+	return sql.OpenDB(connector), nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"fmt"
+	"io"
+)
+
+// ConnectWithOnConnect sets the queries executed for every new connection of the pool,
+// like "SET time_zone = '+00:00'" or "SET search_path = app".
+func ConnectWithOnConnect(queries ...string) ConnectOptionFunc {
+	return func(option *connectOption) {
+		option.OnConnect = append(option.OnConnect, queries...)
+	}
+}
+
+// openConnector returns the connector of the registered database/sql driver with the datasource.
+func openConnector(driver string, datasource string) (sqldriver.Connector, error) {
+	// sql.Open does not connect to the database, it is used to look up the registered driver.
+	db, err := sql.Open(driver, datasource)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+	if drv, ok := db.Driver().(sqldriver.DriverContext); ok {
+		return drv.OpenConnector(datasource)
+	}
+	return dsnConnector{dsn: datasource, driver: db.Driver()}, nil
+}
+
+// dsnConnector is a connector of the drivers which do not implement driver.DriverContext.
+type dsnConnector struct {
+	dsn    string
+	driver sqldriver.Driver
+}
+
+// Connect implements driver.Connector.
+func (c dsnConnector) Connect(_ context.Context) (sqldriver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+// Driver implements driver.Connector.
+func (c dsnConnector) Driver() sqldriver.Driver {
+	return c.driver
+}
+
+// onConnectConnector executes the queries for every new connection.
+type onConnectConnector struct {
+	sqldriver.Connector
+	queries []string
+}
+
+// Connect implements driver.Connector.
+func (c onConnectConnector) Connect(ctx context.Context) (sqldriver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, query := range c.queries {
+		if err = execConn(ctx, conn, query); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("on connect %q: %w", query, err)
+		}
+	}
+	return conn, nil
+}
+
+// Close implements io.Closer, which is called by sql.DB.Close.
+func (c onConnectConnector) Close() error {
+	return closeConnector(c.Connector)
+}
+
+// closeConnector closes the connector if it implements io.Closer.
+func closeConnector(connector sqldriver.Connector) error {
+	if closer, ok := connector.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// execConn executes the query without arguments on the connection.
+func execConn(ctx context.Context, conn sqldriver.Conn, query string) error {
+	if execer, ok := conn.(sqldriver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if err != sqldriver.ErrSkip {
+			return err
+		}
+	}
+	var (
+		stmt sqldriver.Stmt
+		err  error
+	)
+	if preparer, ok := conn.(sqldriver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = conn.Prepare(query)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+	if execer, ok := stmt.(sqldriver.StmtExecContext); ok {
+		_, err = execer.ExecContext(ctx, nil)
+		return err
+	}
+	//nolint:staticcheck // fallback for the drivers which do not implement driver.StmtExecContext.
+	_, err = stmt.Exec(nil)
+	return err
+}
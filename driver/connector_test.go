@@ -0,0 +1,158 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordDriver is a database/sql driver without the optional interfaces,
+// which records the queries executed by the connections of each datasource.
+type recordDriver struct {
+	mu      sync.Mutex
+	queries map[string][]string
+}
+
+func (d *recordDriver) record(dsn, query string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.queries == nil {
+		d.queries = make(map[string][]string)
+	}
+	d.queries[dsn] = append(d.queries[dsn], query)
+}
+
+func (d *recordDriver) executed(dsn string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.queries[dsn]...)
+}
+
+func (d *recordDriver) Open(dsn string) (sqldriver.Conn, error) {
+	return &recordConn{dsn: dsn, driver: d}, nil
+}
+
+type recordConn struct {
+	dsn    string
+	driver *recordDriver
+}
+
+func (c *recordConn) Prepare(query string) (sqldriver.Stmt, error) {
+	return &recordStmt{conn: c, query: query}, nil
+}
+
+func (c *recordConn) Close() error { return nil }
+
+func (c *recordConn) Begin() (sqldriver.Tx, error) { return recordTx{}, nil }
+
+type recordStmt struct {
+	conn  *recordConn
+	query string
+}
+
+func (s *recordStmt) Close() error { return nil }
+
+func (s *recordStmt) NumInput() int { return -1 }
+
+func (s *recordStmt) Exec(_ []sqldriver.Value) (sqldriver.Result, error) {
+	s.conn.driver.record(s.conn.dsn, s.query)
+	if strings.Contains(s.query, "fail") {
+		return nil, errors.New("exec failed")
+	}
+	return sqldriver.RowsAffected(1), nil
+}
+
+func (s *recordStmt) Query(_ []sqldriver.Value) (sqldriver.Rows, error) {
+	s.conn.driver.record(s.conn.dsn, s.query)
+	if strings.Contains(s.query, "fail") {
+		return nil, errors.New("query failed")
+	}
+	return recordRows{}, nil
+}
+
+type recordRows struct{}
+
+func (recordRows) Columns() []string { return []string{"id"} }
+
+func (recordRows) Close() error { return nil }
+
+func (recordRows) Next(_ []sqldriver.Value) error { return io.EOF }
+
+type recordTx struct{}
+
+func (recordTx) Commit() error { return nil }
+
+func (recordTx) Rollback() error { return nil }
+
+var testRecordDriver = &recordDriver{}
+
+func init() {
+	sql.Register("juice-record", testRecordDriver)
+}
+
+func TestConnectWithOnConnect(t *testing.T) {
+	db, err := Connect("juice-record", "on-connect", ConnectWithOnConnect("SET a = 1", "SET b = 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	db.SetMaxOpenConns(2)
+	ctx := context.Background()
+	first, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = first.Close() }()
+	if _, err = first.ExecContext(ctx, "UPDATE user SET name = 'a'"); err != nil {
+		t.Fatal(err)
+	}
+	// the second connection runs the queries again.
+	second, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = second.Close() }()
+	expected := []string{"SET a = 1", "SET b = 2", "UPDATE user SET name = 'a'", "SET a = 1", "SET b = 2"}
+	executed := testRecordDriver.executed("on-connect")
+	if strings.Join(executed, ";") != strings.Join(expected, ";") {
+		t.Fatalf("unexpected queries: %q", executed)
+	}
+}
+
+func TestConnectWithOnConnect_Error(t *testing.T) {
+	db, err := Connect("juice-record", "on-connect-error", ConnectWithOnConnect("SET a = 1", "SET fail = 1", "SET b = 2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	err = db.PingContext(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `on connect "SET fail = 1"`) {
+		t.Fatalf("expected on connect error, got %v", err)
+	}
+	// the queries after the failed one are not executed.
+	executed := testRecordDriver.executed("on-connect-error")
+	for _, query := range executed {
+		if query == "SET b = 2" {
+			t.Fatalf("unexpected queries: %q", executed)
+		}
+	}
+}
+
+func TestConnect_WithoutOnConnect(t *testing.T) {
+	db, err := Connect("juice-record", "plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	if err = db.PingContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if executed := testRecordDriver.executed("plain"); len(executed) != 0 {
+		t.Fatalf("unexpected queries: %q", executed)
+	}
+}
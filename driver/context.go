@@ -0,0 +1,43 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoDriver is the error that no driver found in context.
+var ErrNoDriver = errors.New("no driver found in context")
+
+// driverKey is the key for the driver in the context.
+type driverKey struct{}
+
+// WithContext returns a new context with the driver.
+func WithContext(ctx context.Context, driver Driver) context.Context {
+	return context.WithValue(ctx, driverKey{}, driver)
+}
+
+// FromContext returns the driver from the context.
+// If no driver is found in the context, it returns ErrNoDriver.
+func FromContext(ctx context.Context) (Driver, error) {
+	driver, ok := ctx.Value(driverKey{}).(Driver)
+	if !ok {
+		return nil, ErrNoDriver
+	}
+	return driver, nil
+}
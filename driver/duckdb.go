@@ -0,0 +1,203 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DuckDBDriver is a driver of DuckDB.
+// DuckDB is an embedded analytical database, the same mappers can run against an in-memory
+// database or a local file for the tests and the reporting workloads,
+// like the environment with driver="duckdb" and dataSource="" for an in-memory database.
+// DuckDB has no row level locks, so the locking clauses are not supported.
+type DuckDBDriver struct{}
+
+// Translator returns a translator of SQL.
+func (d DuckDBDriver) Translator() Translator {
+	translator := TranslateFunc(func(matched string) string { return "?" })
+	return newDialectTranslator(d.String(), translator, DoubleQuoteQuoter)
+}
+
+func (d DuckDBDriver) String() string {
+	return "duckdb"
+}
+
+func init() {
+	Register("duckdb", &DuckDBDriver{})
+}
+
+// DuckDBList scans a LIST column of DuckDB, which is reported as []any by the driver,
+// into a slice of T. The elements are converted like DuckDBStruct does, so the nested
+// lists and structs are supported.
+//
+//	type Report struct {
+//		Tags driver.DuckDBList[string] `column:"tags"`
+//	}
+type DuckDBList[T any] []T
+
+// Scan implements sql.Scanner.
+func (l *DuckDBList[T]) Scan(src any) error {
+	var list []T
+	if err := convertDuckDBValue(src, reflect.ValueOf(&list).Elem()); err != nil {
+		return fmt.Errorf("duckdb: scan list: %w", err)
+	}
+	*l = list
+	return nil
+}
+
+// DuckDBStruct scans a STRUCT column of DuckDB, which is reported as map[string]any by the driver,
+// into T. The keys of the struct are matched with the fields of T by the column tag,
+// or by the field name case-insensitively.
+//
+//	type Address struct {
+//		City string `column:"city"`
+//		Zip  string `column:"zip"`
+//	}
+//
+//	type User struct {
+//		Address driver.DuckDBStruct[Address] `column:"address"`
+//	}
+type DuckDBStruct[T any] struct {
+	Value T
+	// Valid is false if the column is NULL.
+	Valid bool
+}
+
+// Scan implements sql.Scanner.
+func (s *DuckDBStruct[T]) Scan(src any) error {
+	var value T
+	if err := convertDuckDBValue(src, reflect.ValueOf(&value).Elem()); err != nil {
+		return fmt.Errorf("duckdb: scan struct: %w", err)
+	}
+	s.Value, s.Valid = value, src != nil
+	return nil
+}
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// convertDuckDBValue converts the value reported by the DuckDB driver to dst.
+func convertDuckDBValue(src any, dst reflect.Value) error {
+	if src == nil {
+		dst.SetZero()
+		return nil
+	}
+	if dst.CanAddr() && dst.Addr().Type().Implements(scannerType) {
+		return dst.Addr().Interface().(sql.Scanner).Scan(src)
+	}
+	if dst.Kind() == reflect.Pointer {
+		elem := reflect.New(dst.Type().Elem())
+		if err := convertDuckDBValue(src, elem.Elem()); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+	value := reflect.ValueOf(src)
+	switch {
+	case value.Type().AssignableTo(dst.Type()):
+		dst.Set(value)
+	case dst.Kind() == reflect.Slice && value.Kind() == reflect.Slice:
+		slice := reflect.MakeSlice(dst.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			if err := convertDuckDBValue(value.Index(i).Interface(), slice.Index(i)); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		dst.Set(slice)
+	case dst.Kind() == reflect.Map && value.Kind() == reflect.Map:
+		m := reflect.MakeMapWithSize(dst.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			key := reflect.New(dst.Type().Key()).Elem()
+			if err := convertDuckDBValue(iter.Key().Interface(), key); err != nil {
+				return fmt.Errorf("key %v: %w", iter.Key(), err)
+			}
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := convertDuckDBValue(iter.Value().Interface(), elem); err != nil {
+				return fmt.Errorf("key %v: %w", iter.Key(), err)
+			}
+			m.SetMapIndex(key, elem)
+		}
+		dst.Set(m)
+	case dst.Kind() == reflect.Struct && dst.Type() != timeType && value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String:
+		iter := value.MapRange()
+		for iter.Next() {
+			field, ok := duckDBStructField(dst, iter.Key().String())
+			if !ok {
+				continue
+			}
+			if err := convertDuckDBValue(iter.Value().Interface(), field); err != nil {
+				return fmt.Errorf("field %s: %w", iter.Key(), err)
+			}
+		}
+	case isDuckDBNumber(value.Kind()) && isDuckDBNumber(dst.Kind()):
+		dst.Set(value.Convert(dst.Type()))
+	case value.Kind() == reflect.String && dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8,
+		value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 && dst.Kind() == reflect.String:
+		dst.Set(value.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("can not convert %T to %s", src, dst.Type())
+	}
+	return nil
+}
+
+// duckDBStructField returns the exported field of the struct for the key of a DuckDB STRUCT.
+func duckDBStructField(rv reflect.Value, key string) (reflect.Value, bool) {
+	tp := rv.Type()
+	byName := -1
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		// the options after the comma are not part of the name, like `column:"age,nullValue=-1"`.
+		tag, _, _ := strings.Cut(field.Tag.Get("column"), ",")
+		if tag == "-" {
+			continue
+		}
+		if tag == key {
+			return rv.Field(i), true
+		}
+		if tag == "" && byName < 0 && strings.EqualFold(field.Name, key) {
+			byName = i
+		}
+	}
+	if byName < 0 {
+		return reflect.Value{}, false
+	}
+	return rv.Field(byName), true
+}
+
+// isDuckDBNumber reports whether the kind is a number which can be converted to another number.
+func isDuckDBNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,57 @@
+package driver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDuckDBDriver(t *testing.T) {
+	driver := DuckDBDriver{}
+	translator := driver.Translator()
+	if translator.Translate("foo") != "?" {
+		t.Fatal("failed to translate")
+	}
+	if _, err := LockClause(driver, LockOptions{Mode: LockForUpdate}); err == nil {
+		t.Fatal("expected lock error")
+	}
+}
+
+func TestDuckDBTypes(t *testing.T) {
+	type address struct {
+		City    string `column:"city"`
+		ZipCode string
+		Floors  DuckDBList[int]
+	}
+	var list DuckDBList[int64]
+	if err := list.Scan([]any{int32(1), int64(2), nil}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual([]int64(list), []int64{1, 2, 0}) {
+		t.Fatalf("unexpected list: %v", list)
+	}
+
+	var addresses DuckDBList[address]
+	err := addresses.Scan([]any{
+		map[string]any{"city": "Hangzhou", "zipcode": "310000", "floors": []any{int32(1), int32(2)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := address{City: "Hangzhou", ZipCode: "310000", Floors: DuckDBList[int]{1, 2}}
+	if len(addresses) != 1 || !reflect.DeepEqual(addresses[0], want) {
+		t.Fatalf("unexpected addresses: %v", addresses)
+	}
+
+	var value DuckDBStruct[map[string]string]
+	if err = value.Scan(map[string]any{"a": "b"}); err != nil || !value.Valid || value.Value["a"] != "b" {
+		t.Fatalf("unexpected struct: %v, %v", value, err)
+	}
+	if err = value.Scan(nil); err != nil || value.Valid {
+		t.Fatalf("expected NULL struct: %v, %v", value, err)
+	}
+
+	var tags DuckDBList[int]
+	if err = tags.Scan([]any{"a"}); err == nil {
+		t.Fatal("expected conversion error")
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import "strings"
+
+// IdentifierQuoter quotes identifiers like table names and column names,
+// so that reserved words like order or user can be used as identifiers.
+type IdentifierQuoter interface {
+	QuoteIdentifier(identifier string) string
+}
+
+// IdentifierQuoteFunc is a function to quote the identifier.
+type IdentifierQuoteFunc func(identifier string) string
+
+// QuoteIdentifier implements the IdentifierQuoter interface.
+func (f IdentifierQuoteFunc) QuoteIdentifier(identifier string) string {
+	return f(identifier)
+}
+
+// quoteWith returns an IdentifierQuoteFunc which quotes every part of a qualified identifier
+// with the given open and close characters.
+// The wildcard and the parts which are already quoted are kept as they are,
+// and the close character inside the identifier is escaped by doubling it.
+func quoteWith(open, close string) IdentifierQuoteFunc {
+	return func(identifier string) string {
+		identifier = strings.TrimSpace(identifier)
+		if identifier == "" {
+			return identifier
+		}
+		parts := strings.Split(identifier, ".")
+		for i, part := range parts {
+			if part == "*" || (strings.HasPrefix(part, open) && strings.HasSuffix(part, close) && len(part) > 1) {
+				continue
+			}
+			parts[i] = open + strings.ReplaceAll(part, close, close+close) + close
+		}
+		return strings.Join(parts, ".")
+	}
+}
+
+var (
+	// BacktickQuoter quotes the identifier with backticks, like `order`.
+	// It is used by MySQL.
+	BacktickQuoter = quoteWith("`", "`")
+
+	// DoubleQuoteQuoter quotes the identifier with double quotes, like "order".
+	// It is the standard sql style, used by PostgreSQL, Oracle and SQLite.
+	DoubleQuoteQuoter = quoteWith(`"`, `"`)
+
+	// BracketQuoter quotes the identifier with brackets, like [order].
+	// It is used by SQL Server.
+	BracketQuoter = quoteWith("[", "]")
+)
+
+// dialectTranslator is a Translator which also knows how to quote the identifiers.
+type dialectTranslator struct {
+	Translator
+	IdentifierQuoter
+	dialect string
+}
+
+// Dialect implements DialectNamer.
+func (d *dialectTranslator) Dialect() string {
+	return d.dialect
+}
+
+// NewDialectTranslator returns a Translator which translates the placeholders with the given translator
+// and quotes the identifiers with the given quoter.
+func NewDialectTranslator(translator Translator, quoter IdentifierQuoter) Translator {
+	return newDialectTranslator("", translator, quoter)
+}
+
+// newDialectTranslator returns the dialect translator of the built-in driver named dialect.
+func newDialectTranslator(dialect string, translator Translator, quoter IdentifierQuoter) Translator {
+	return &dialectTranslator{Translator: translator, IdentifierQuoter: quoter, dialect: dialect}
+}
+
+// DialectNamer is implemented by the translators which know the name of their dialect, like mysql or postgres.
+// The name selects the dialect bodies of the statements, see the dialect tag of the mapper.
+type DialectNamer interface {
+	Dialect() string
+}
+
+// DialectOf returns the name of the dialect of the translator, or empty if the translator
+// does not implement DialectNamer.
+func DialectOf(translator Translator) string {
+	if namer, ok := translator.(DialectNamer); ok {
+		return namer.Dialect()
+	}
+	return ""
+}
+
+// QuoteIdentifier quotes the identifier with the quoter of the translator.
+// If the translator does not implement IdentifierQuoter, the identifier is returned as it is.
+func QuoteIdentifier(translator Translator, identifier string) string {
+	if quoter, ok := translator.(IdentifierQuoter); ok {
+		return quoter.QuoteIdentifier(identifier)
+	}
+	return identifier
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// LimitClauseBuilder is implemented by the drivers which do not use the LIMIT clause
+// to restrict the number of rows returned by a select statement.
+type LimitClauseBuilder interface {
+	// LimitClause returns the clause which restricts the rows to the given limit.
+	LimitClause(limit int64) string
+}
+
+// LimitClause returns the limit clause of the driver.
+// It returns "LIMIT n" if the driver does not implement LimitClauseBuilder.
+func LimitClause(driver Driver, limit int64) string {
+	if builder, ok := driver.(LimitClauseBuilder); ok {
+		return builder.LimitClause(limit)
+	}
+	return "LIMIT " + strconv.FormatInt(limit, 10)
+}
+
+// ErrWithTiesNotSupported is the error that the driver does not support FETCH FIRST n ROWS WITH TIES.
+var ErrWithTiesNotSupported = errors.New("with ties is not supported by the driver")
+
+// PageOptions describes the row limiting clause of a page.
+type PageOptions struct {
+	Limit  int64
+	Offset int64
+	// WithTies also returns the rows which tie with the last row of the page in the order,
+	// it requires an ORDER BY clause.
+	WithTies bool
+}
+
+// PageClauseBuilder is implemented by the drivers whose page clause is not "LIMIT n OFFSET m".
+type PageClauseBuilder interface {
+	// PageClause returns the clause which restricts the rows to the page.
+	// It returns an error wrapping ErrWithTiesNotSupported if the options are not supported.
+	PageClause(options PageOptions) (string, error)
+}
+
+// PageClause returns the page clause of the driver.
+// It returns "LIMIT n OFFSET m" if the driver does not implement PageClauseBuilder,
+// which does not support WithTies.
+func PageClause(driver Driver, options PageOptions) (string, error) {
+	if builder, ok := driver.(PageClauseBuilder); ok {
+		return builder.PageClause(options)
+	}
+	if options.WithTies {
+		return "", fmt.Errorf("%w: %s", ErrWithTiesNotSupported, driver)
+	}
+	return limitOffsetClause(options), nil
+}
+
+// limitOffsetClause returns the clause like "LIMIT n OFFSET m".
+func limitOffsetClause(options PageOptions) string {
+	clause := "LIMIT " + strconv.FormatInt(options.Limit, 10)
+	if options.Offset > 0 {
+		clause += " OFFSET " + strconv.FormatInt(options.Offset, 10)
+	}
+	return clause
+}
+
+// fetchFirstClause returns the row limiting clause of the SQL standard,
+// like "OFFSET m ROWS FETCH FIRST n ROWS WITH TIES".
+func fetchFirstClause(options PageOptions) string {
+	var clause string
+	if options.Offset > 0 {
+		clause = "OFFSET " + strconv.FormatInt(options.Offset, 10) + " ROWS "
+	}
+	clause += "FETCH FIRST " + strconv.FormatInt(options.Limit, 10) + " ROWS "
+	if options.WithTies {
+		return clause + "WITH TIES"
+	}
+	return clause + "ONLY"
+}
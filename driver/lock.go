@@ -0,0 +1,114 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrLockNotSupported is the error that the driver does not support the requested lock.
+var ErrLockNotSupported = errors.New("lock is not supported by the driver")
+
+// LockMode is the mode of a row lock.
+type LockMode string
+
+const (
+	// LockForUpdate locks the selected rows exclusively.
+	LockForUpdate LockMode = "update"
+	// LockForShare locks the selected rows in shared mode.
+	LockForShare LockMode = "share"
+)
+
+// LockWait is the strategy when the rows are already locked by another transaction.
+type LockWait string
+
+const (
+	// LockWaitDefault waits until the lock is released.
+	LockWaitDefault LockWait = ""
+	// LockNoWait fails immediately if the rows are locked.
+	LockNoWait LockWait = "nowait"
+	// LockSkipLocked skips the rows which are locked.
+	LockSkipLocked LockWait = "skipLocked"
+)
+
+// LockOptions describes the locking clause appended to a select statement.
+type LockOptions struct {
+	Mode LockMode
+	Wait LockWait
+}
+
+// LockClauseBuilder is implemented by the drivers which support pessimistic locking.
+type LockClauseBuilder interface {
+	// LockClause returns the locking clause like "FOR UPDATE NOWAIT".
+	// It returns an error wrapping ErrLockNotSupported if the options are not supported.
+	LockClause(options LockOptions) (string, error)
+}
+
+// LockCapabilities describes which locks a dialect supports.
+type LockCapabilities struct {
+	ForUpdate  bool
+	ForShare   bool
+	NoWait     bool
+	SkipLocked bool
+}
+
+// LockClause builds the locking clause with the standard syntax,
+// after checking the options against the capabilities.
+func (c LockCapabilities) LockClause(options LockOptions) (string, error) {
+	var clause string
+	switch options.Mode {
+	case LockForUpdate:
+		if !c.ForUpdate {
+			return "", fmt.Errorf("%w: FOR UPDATE", ErrLockNotSupported)
+		}
+		clause = "FOR UPDATE"
+	case LockForShare:
+		if !c.ForShare {
+			return "", fmt.Errorf("%w: FOR SHARE", ErrLockNotSupported)
+		}
+		clause = "FOR SHARE"
+	default:
+		return "", fmt.Errorf("invalid lock mode: %q", options.Mode)
+	}
+	switch options.Wait {
+	case LockWaitDefault:
+	case LockNoWait:
+		if !c.NoWait {
+			return "", fmt.Errorf("%w: NOWAIT", ErrLockNotSupported)
+		}
+		clause += " NOWAIT"
+	case LockSkipLocked:
+		if !c.SkipLocked {
+			return "", fmt.Errorf("%w: SKIP LOCKED", ErrLockNotSupported)
+		}
+		clause += " SKIP LOCKED"
+	default:
+		return "", fmt.Errorf("invalid lock wait: %q", options.Wait)
+	}
+	return clause, nil
+}
+
+// LockClause returns the locking clause of the driver.
+// It returns an error wrapping ErrLockNotSupported if the driver does not implement LockClauseBuilder.
+func LockClause(driver Driver, options LockOptions) (string, error) {
+	builder, ok := driver.(LockClauseBuilder)
+	if !ok {
+		return "", fmt.Errorf("%w: %T", ErrLockNotSupported, driver)
+	}
+	return builder.LockClause(options)
+}
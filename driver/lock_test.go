@@ -0,0 +1,29 @@
+package driver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLockClause(t *testing.T) {
+	clause, err := LockClause(MySQLDriver{}, LockOptions{Mode: LockForUpdate, Wait: LockSkipLocked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clause != "FOR UPDATE SKIP LOCKED" {
+		t.Fatalf("unexpected clause: %s", clause)
+	}
+	clause, err = LockClause(PostgresDriver{}, LockOptions{Mode: LockForShare, Wait: LockNoWait})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clause != "FOR SHARE NOWAIT" {
+		t.Fatalf("unexpected clause: %s", clause)
+	}
+	if _, err = LockClause(OracleDriver{}, LockOptions{Mode: LockForShare}); !errors.Is(err, ErrLockNotSupported) {
+		t.Fatalf("expected ErrLockNotSupported, got %v", err)
+	}
+	if _, err = LockClause(SQLiteDriver{}, LockOptions{Mode: LockForUpdate}); !errors.Is(err, ErrLockNotSupported) {
+		t.Fatalf("expected ErrLockNotSupported, got %v", err)
+	}
+}
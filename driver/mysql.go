@@ -21,7 +21,31 @@ type MySQLDriver struct{}
 
 // Translator returns a translator of SQL.
 func (d MySQLDriver) Translator() Translator {
-	return TranslateFunc(func(matched string) string { return "?" })
+	translator := TranslateFunc(func(matched string) string { return "?" })
+	return newDialectTranslator(d.String(), translator, BacktickQuoter)
+}
+
+// LockClause implements LockClauseBuilder.
+func (d MySQLDriver) LockClause(options LockOptions) (string, error) {
+	return LockCapabilities{ForUpdate: true, ForShare: true, NoWait: true, SkipLocked: true}.LockClause(options)
+}
+
+// SessionVariable implements SessionVariableSetter.
+// The variable is set as a user-defined variable, which lives until the connection is closed.
+func (d MySQLDriver) SessionVariable(name, value string) (string, []any, error) {
+	if err := checkSessionVariableName(name); err != nil {
+		return "", nil, err
+	}
+	return "SET @`" + name + "` = ?", []any{value}, nil
+}
+
+// ResetSessionVariable implements SessionVariableResetter.
+// The user-defined variables outlive the transaction, so they are reset before the connection is released.
+func (d MySQLDriver) ResetSessionVariable(name string) (string, []any, error) {
+	if err := checkSessionVariableName(name); err != nil {
+		return "", nil, err
+	}
+	return "SET @`" + name + "` = NULL", nil, nil
 }
 
 func (d MySQLDriver) String() string {
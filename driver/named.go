@@ -0,0 +1,149 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// NamedTranslator is a Translator which translates the parameters into named placeholders,
+// like @name or :name, and the arguments of them are passed as sql.NamedArg.
+type NamedTranslator interface {
+	Translator
+	// TranslateNamed returns the placeholder and the argument of the parameter with its value.
+	// The parameter translated again with an equal value, like #{id} used twice, reuses the argument
+	// and reports it by reused, so that the argument is passed once. The parameter translated again
+	// with a different value, like the item of foreach, gets a different argument name every time.
+	// The value given as sql.NamedArg keeps its own name.
+	TranslateNamed(name string, value any) (placeholder string, arg sql.NamedArg, reused bool)
+}
+
+// namedTranslator is the NamedTranslator which prefixes the argument names.
+type namedTranslator struct {
+	prefix string
+	// used counts the argument names used by the placeholders.
+	used map[string]int
+	// args are the names of the arguments passed by their names and values.
+	args map[namedValue]string
+	IdentifierQuoter
+	dialect string
+}
+
+// Dialect implements DialectNamer.
+func (n *namedTranslator) Dialect() string {
+	return n.dialect
+}
+
+// namedValue is the key of the argument passed by the name with the value.
+type namedValue struct {
+	name  string
+	value any
+}
+
+// Translate implements Translator.
+func (n *namedTranslator) Translate(matched string) string {
+	return n.prefix + n.nextArgName(NamedArgName(matched))
+}
+
+// TranslateNamed implements NamedTranslator.
+func (n *namedTranslator) TranslateNamed(name string, value any) (string, sql.NamedArg, bool) {
+	argName := NamedArgName(name)
+	namedArg, given := value.(sql.NamedArg)
+	if given {
+		argName, value = namedArg.Name, namedArg.Value
+	}
+	// the values which can not be compared, like the slices, are never reused.
+	comparable := value == nil || reflect.ValueOf(value).Comparable()
+	key := namedValue{name: argName, value: value}
+	if comparable {
+		if reused, ok := n.args[key]; ok {
+			return n.prefix + reused, sql.Named(reused, value), true
+		}
+	}
+	if !given {
+		argName = n.nextArgName(argName)
+	}
+	if comparable {
+		if n.args == nil {
+			n.args = make(map[namedValue]string)
+		}
+		n.args[key] = argName
+	}
+	return n.prefix + argName, sql.Named(argName, value), false
+}
+
+// nextArgName returns the argument name which is not used yet, numbered from the second use of the name.
+func (n *namedTranslator) nextArgName(argName string) string {
+	if n.used == nil {
+		n.used = make(map[string]int)
+	}
+	n.used[argName]++
+	if count := n.used[argName]; count > 1 {
+		argName += "_" + strconv.Itoa(count)
+	}
+	return argName
+}
+
+// NamedArgName replaces the characters which can not be used by the argument name with underscores,
+// like the dots of user.name.
+func NamedArgName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// NewNamedTranslator returns a NamedTranslator which prefixes the argument names with the prefix,
+// like @ for SQL Server or : for Oracle, and quotes the identifiers with the given quoter.
+// The quoter can be nil if the identifiers do not need to be quoted.
+func NewNamedTranslator(prefix string, quoter IdentifierQuoter) NamedTranslator {
+	if quoter == nil {
+		quoter = IdentifierQuoteFunc(func(identifier string) string { return identifier })
+	}
+	return &namedTranslator{prefix: prefix, IdentifierQuoter: quoter}
+}
+
+// namedDriver is the Driver which translates the parameters into named placeholders.
+type namedDriver struct {
+	Driver
+	prefix string
+}
+
+// Translator implements Driver.
+func (n namedDriver) Translator() Translator {
+	translator := n.Driver.Translator()
+	quoter, _ := translator.(IdentifierQuoter)
+	named := NewNamedTranslator(n.prefix, quoter).(*namedTranslator)
+	named.dialect = DialectOf(translator)
+	return named
+}
+
+// WithNamedPlaceholders returns a Driver which translates the parameters into named placeholders
+// with the prefix instead of the positional ones of the driver.
+// Note that only the Translator of the driver is kept, the other capabilities like LockClauseBuilder are not.
+//
+// Example:
+//
+//	driver.Register("oracle", driver.WithNamedPlaceholders(driver.OracleDriver{}, ":"))
+func WithNamedPlaceholders(driver Driver, prefix string) Driver {
+	return namedDriver{Driver: driver, prefix: prefix}
+}
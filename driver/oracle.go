@@ -24,10 +24,34 @@ type OracleDriver struct{}
 // Translator is a function to translate a matched string.
 func (o OracleDriver) Translator() Translator {
 	var i int
-	return TranslateFunc(func(matched string) string {
+	translator := TranslateFunc(func(matched string) string {
 		i++
 		return ":" + strconv.Itoa(i)
 	})
+	return newDialectTranslator(o.String(), translator, DoubleQuoteQuoter)
+}
+
+// LockClause implements LockClauseBuilder.
+// Oracle does not support FOR SHARE.
+func (o OracleDriver) LockClause(options LockOptions) (string, error) {
+	return LockCapabilities{ForUpdate: true, NoWait: true, SkipLocked: true}.LockClause(options)
+}
+
+// LimitClause implements LimitClauseBuilder.
+// Oracle uses the row limiting clause of the SQL standard.
+func (o OracleDriver) LimitClause(limit int64) string {
+	return "FETCH FIRST " + strconv.FormatInt(limit, 10) + " ROWS ONLY"
+}
+
+// PageClause implements PageClauseBuilder.
+func (o OracleDriver) PageClause(options PageOptions) (string, error) {
+	return fetchFirstClause(options), nil
+}
+
+// SupportsMultiRowValues implements MultiRowValuesSupporter.
+// Oracle does not accept multiple rows of VALUES, the rows are inserted by INSERT ALL or one by one.
+func (o OracleDriver) SupportsMultiRowValues() bool {
+	return false
 }
 
 func (o OracleDriver) String() string {
@@ -16,7 +16,10 @@ limitations under the License.
 
 package driver
 
-import "strconv"
+import (
+	"strconv"
+	"strings"
+)
 
 // PostgresDriver is a driver of PostgreSQL.
 type PostgresDriver struct{}
@@ -24,10 +27,44 @@ type PostgresDriver struct{}
 // Translator is a function to translate a matched string.
 func (d PostgresDriver) Translator() Translator {
 	var i int
-	return TranslateFunc(func(matched string) string {
+	translator := TranslateFunc(func(matched string) string {
 		i++
 		return "$" + strconv.Itoa(i)
 	})
+	return newDialectTranslator(d.String(), translator, DoubleQuoteQuoter)
+}
+
+// LockClause implements LockClauseBuilder.
+func (d PostgresDriver) LockClause(options LockOptions) (string, error) {
+	return LockCapabilities{ForUpdate: true, ForShare: true, NoWait: true, SkipLocked: true}.LockClause(options)
+}
+
+// PageClause implements PageClauseBuilder.
+// The page is limited by LIMIT and OFFSET, except WITH TIES, which requires PostgreSQL 13 or later.
+func (d PostgresDriver) PageClause(options PageOptions) (string, error) {
+	if options.WithTies {
+		return fetchFirstClause(options), nil
+	}
+	return limitOffsetClause(options), nil
+}
+
+// SessionVariable implements SessionVariableSetter.
+// The variable is set by set_config with is_local, which lives until the end of the transaction,
+// and can be read by current_setting(name).
+func (d PostgresDriver) SessionVariable(name, value string) (string, []any, error) {
+	if err := checkSessionVariableName(name); err != nil {
+		return "", nil, err
+	}
+	return "SELECT set_config($1, $2, true)", []any{name, value}, nil
+}
+
+// ReturningClause implements ReturningClauseBuilder.
+func (d PostgresDriver) ReturningClause(columns ...string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = DoubleQuoteQuoter(column)
+	}
+	return " RETURNING " + strings.Join(quoted, ", ")
 }
 
 func (d PostgresDriver) String() string {
@@ -0,0 +1,25 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+// ReturningClauseBuilder is implemented by the drivers which can not report the last insert id,
+// like PostgreSQL, so the generated keys are returned by the RETURNING clause appended to the insert statements.
+// It is used by the useGeneratedKeys attribute of the insert statements.
+type ReturningClauseBuilder interface {
+	// ReturningClause returns the clause which returns the columns, with a leading space.
+	ReturningClause(columns ...string) string
+}
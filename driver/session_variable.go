@@ -0,0 +1,62 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrSessionVariableNotSupported is the error that the driver does not support session variables.
+var ErrSessionVariableNotSupported = errors.New("session variable is not supported by the driver")
+
+// SessionVariableSetter is implemented by the drivers which support session variables,
+// which can be read by the row level security policies and triggers of the database.
+type SessionVariableSetter interface {
+	// SessionVariable returns the query and its arguments to set the variable for the current transaction.
+	SessionVariable(name, value string) (query string, args []any, err error)
+}
+
+// SessionVariableResetter is implemented by the drivers whose session variables outlive the transaction,
+// like the user-defined variables of MySQL, which must be reset before the connection goes back to the pool.
+type SessionVariableResetter interface {
+	// ResetSessionVariable returns the query and its arguments to reset the variable.
+	ResetSessionVariable(name string) (query string, args []any, err error)
+}
+
+// sessionVariableNameRegexp matches the valid names of the session variables.
+var sessionVariableNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// checkSessionVariableName returns an error if the name is not a valid session variable name,
+// since some dialects can not bind the name as an argument.
+func checkSessionVariableName(name string) error {
+	if !sessionVariableNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid session variable name %q", name)
+	}
+	return nil
+}
+
+// SessionVariable returns the query to set the session variable of the driver.
+// It returns an error wrapping ErrSessionVariableNotSupported if the driver does not implement SessionVariableSetter.
+func SessionVariable(driver Driver, name, value string) (query string, args []any, err error) {
+	setter, ok := driver.(SessionVariableSetter)
+	if !ok {
+		return "", nil, fmt.Errorf("%w: %T", ErrSessionVariableNotSupported, driver)
+	}
+	return setter.SessionVariable(name, value)
+}
@@ -21,7 +21,8 @@ type SQLiteDriver struct{}
 
 // Translator returns a translator of SQL.
 func (d SQLiteDriver) Translator() Translator {
-	return TranslateFunc(func(matched string) string { return "?" })
+	translator := TranslateFunc(func(matched string) string { return "?" })
+	return newDialectTranslator(d.String(), translator, DoubleQuoteQuoter)
 }
 
 func (d SQLiteDriver) String() string {
@@ -0,0 +1,279 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"context"
+	sqldriver "database/sql/driver"
+	"errors"
+	"io"
+	"time"
+)
+
+// TelemetryEvent is the kind of the operation measured by Telemetry.
+type TelemetryEvent string
+
+const (
+	// TelemetryConnect is the event of opening a new connection.
+	TelemetryConnect TelemetryEvent = "connect"
+	// TelemetryAcquire is the event of acquiring an idle connection from the pool,
+	// measured by resetting the session of the connection before it is reused.
+	TelemetryAcquire TelemetryEvent = "acquire"
+	// TelemetryPrepare is the event of preparing a statement.
+	TelemetryPrepare TelemetryEvent = "prepare"
+	// TelemetryExec is the event of executing a statement without rows.
+	TelemetryExec TelemetryEvent = "exec"
+	// TelemetryQuery is the event of executing a query, until the first row is ready to be read.
+	TelemetryQuery TelemetryEvent = "query"
+	// TelemetryBegin is the event of beginning a transaction.
+	TelemetryBegin TelemetryEvent = "begin"
+)
+
+// Telemetry receives the timings measured at the database/sql driver level,
+// which do not include the time waiting for a connection from the pool, unlike the middlewares.
+// The connections are acquired by the connect events for the new ones and the acquire events for the idle ones,
+// compare them with the timings of the middlewares and sql.DBStats to tell pool saturation from slow sql.
+type Telemetry interface {
+	// Observe is called after an operation is done.
+	// The query is empty for the connect, acquire and begin events.
+	Observe(ctx context.Context, event TelemetryEvent, query string, duration time.Duration, err error)
+}
+
+// TelemetryFunc is an adapter to allow the use of ordinary functions as Telemetry.
+type TelemetryFunc func(ctx context.Context, event TelemetryEvent, query string, duration time.Duration, err error)
+
+// Observe calls f(ctx, event, query, duration, err).
+func (f TelemetryFunc) Observe(ctx context.Context, event TelemetryEvent, query string, duration time.Duration, err error) {
+	f(ctx, event, query, duration, err)
+}
+
+// ConnectWithTelemetry sets the telemetry which measures the operations of the connections.
+func ConnectWithTelemetry(telemetry Telemetry) ConnectOptionFunc {
+	return func(option *connectOption) {
+		option.Telemetry = telemetry
+	}
+}
+
+// observe calls the telemetry with the duration since the start, ignoring driver.ErrSkip.
+func observe(ctx context.Context, telemetry Telemetry, event TelemetryEvent, query string, start time.Time, err error) {
+	if errors.Is(err, sqldriver.ErrSkip) {
+		return
+	}
+	telemetry.Observe(ctx, event, query, time.Since(start), err)
+}
+
+// telemetryConnector measures the connect time of the connections, and wraps them with telemetryConn.
+type telemetryConnector struct {
+	sqldriver.Connector
+	telemetry Telemetry
+}
+
+// Connect implements driver.Connector.
+func (c telemetryConnector) Connect(ctx context.Context) (sqldriver.Conn, error) {
+	start := time.Now()
+	conn, err := c.Connector.Connect(ctx)
+	observe(ctx, c.telemetry, TelemetryConnect, "", start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &telemetryConn{Conn: conn, telemetry: c.telemetry}, nil
+}
+
+// Close implements io.Closer, which is called by sql.DB.Close.
+func (c telemetryConnector) Close() error {
+	return closeConnector(c.Connector)
+}
+
+// telemetryConn measures the operations of the connection.
+// The optional interfaces are forwarded to the wrapped connection, or fall back to the behaviors of database/sql.
+type telemetryConn struct {
+	sqldriver.Conn
+	telemetry Telemetry
+}
+
+// Prepare implements driver.Conn.
+func (c *telemetryConn) Prepare(query string) (sqldriver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *telemetryConn) PrepareContext(ctx context.Context, query string) (stmt sqldriver.Stmt, err error) {
+	start := time.Now()
+	if preparer, ok := c.Conn.(sqldriver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	observe(ctx, c.telemetry, TelemetryPrepare, query, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &telemetryStmt{Stmt: stmt, query: query, telemetry: c.telemetry}, nil
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *telemetryConn) ExecContext(ctx context.Context, query string, args []sqldriver.NamedValue) (sqldriver.Result, error) {
+	execer, ok := c.Conn.(sqldriver.ExecerContext)
+	if !ok {
+		// database/sql will prepare the query and execute the statement.
+		return nil, sqldriver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	observe(ctx, c.telemetry, TelemetryExec, query, start, err)
+	return result, err
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *telemetryConn) QueryContext(ctx context.Context, query string, args []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	queryer, ok := c.Conn.(sqldriver.QueryerContext)
+	if !ok {
+		// database/sql will prepare the query and query the statement.
+		return nil, sqldriver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	observe(ctx, c.telemetry, TelemetryQuery, query, start, err)
+	return rows, err
+}
+
+// BeginTx implements driver.ConnBeginTx.
+func (c *telemetryConn) BeginTx(ctx context.Context, opts sqldriver.TxOptions) (tx sqldriver.Tx, err error) {
+	start := time.Now()
+	if beginner, ok := c.Conn.(sqldriver.ConnBeginTx); ok {
+		tx, err = beginner.BeginTx(ctx, opts)
+	} else {
+		if opts.Isolation != 0 || opts.ReadOnly {
+			return nil, errors.New("driver: driver does not support non-default transaction options")
+		}
+		//nolint:staticcheck // fallback for the drivers which do not implement driver.ConnBeginTx.
+		tx, err = c.Conn.Begin()
+	}
+	observe(ctx, c.telemetry, TelemetryBegin, "", start, err)
+	return tx, err
+}
+
+// Ping implements driver.Pinger.
+func (c *telemetryConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(sqldriver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter.
+// It is called by database/sql before an idle connection is reused, which is observed as the acquire event.
+func (c *telemetryConn) ResetSession(ctx context.Context) (err error) {
+	start := time.Now()
+	if resetter, ok := c.Conn.(sqldriver.SessionResetter); ok {
+		err = resetter.ResetSession(ctx)
+	}
+	observe(ctx, c.telemetry, TelemetryAcquire, "", start, err)
+	return err
+}
+
+// IsValid implements driver.Validator.
+func (c *telemetryConn) IsValid() bool {
+	if validator, ok := c.Conn.(sqldriver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (c *telemetryConn) CheckNamedValue(value *sqldriver.NamedValue) error {
+	if checker, ok := c.Conn.(sqldriver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	// database/sql will use the default converter.
+	return sqldriver.ErrSkip
+}
+
+// telemetryStmt measures the executions of the prepared statement.
+type telemetryStmt struct {
+	sqldriver.Stmt
+	query     string
+	telemetry Telemetry
+}
+
+// ExecContext implements driver.StmtExecContext.
+func (s *telemetryStmt) ExecContext(ctx context.Context, args []sqldriver.NamedValue) (result sqldriver.Result, err error) {
+	start := time.Now()
+	if execer, ok := s.Stmt.(sqldriver.StmtExecContext); ok {
+		result, err = execer.ExecContext(ctx, args)
+	} else {
+		var values []sqldriver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			//nolint:staticcheck // fallback for the drivers which do not implement driver.StmtExecContext.
+			result, err = s.Stmt.Exec(values)
+		}
+	}
+	observe(ctx, s.telemetry, TelemetryExec, s.query, start, err)
+	return result, err
+}
+
+// QueryContext implements driver.StmtQueryContext.
+func (s *telemetryStmt) QueryContext(ctx context.Context, args []sqldriver.NamedValue) (rows sqldriver.Rows, err error) {
+	start := time.Now()
+	if queryer, ok := s.Stmt.(sqldriver.StmtQueryContext); ok {
+		rows, err = queryer.QueryContext(ctx, args)
+	} else {
+		var values []sqldriver.Value
+		if values, err = namedValuesToValues(args); err == nil {
+			//nolint:staticcheck // fallback for the drivers which do not implement driver.StmtQueryContext.
+			rows, err = s.Stmt.Query(values)
+		}
+	}
+	observe(ctx, s.telemetry, TelemetryQuery, s.query, start, err)
+	return rows, err
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (s *telemetryStmt) CheckNamedValue(value *sqldriver.NamedValue) error {
+	if checker, ok := s.Stmt.(sqldriver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	// database/sql will fall back to the checker of the connection.
+	return sqldriver.ErrSkip
+}
+
+// namedValuesToValues converts the named values to the values for the legacy drivers.
+func namedValuesToValues(named []sqldriver.NamedValue) ([]sqldriver.Value, error) {
+	values := make([]sqldriver.Value, len(named))
+	for i, arg := range named {
+		if arg.Name != "" {
+			return nil, errors.New("driver: driver does not support the use of named parameters")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+var (
+	_ io.Closer                    = telemetryConnector{}
+	_ sqldriver.ConnPrepareContext = (*telemetryConn)(nil)
+	_ sqldriver.ExecerContext      = (*telemetryConn)(nil)
+	_ sqldriver.QueryerContext     = (*telemetryConn)(nil)
+	_ sqldriver.ConnBeginTx        = (*telemetryConn)(nil)
+	_ sqldriver.Pinger             = (*telemetryConn)(nil)
+	_ sqldriver.SessionResetter    = (*telemetryConn)(nil)
+	_ sqldriver.Validator          = (*telemetryConn)(nil)
+	_ sqldriver.NamedValueChecker  = (*telemetryConn)(nil)
+	_ sqldriver.StmtExecContext    = (*telemetryStmt)(nil)
+	_ sqldriver.StmtQueryContext   = (*telemetryStmt)(nil)
+	_ sqldriver.NamedValueChecker  = (*telemetryStmt)(nil)
+)
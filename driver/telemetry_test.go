@@ -0,0 +1,151 @@
+package driver
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type observation struct {
+	event TelemetryEvent
+	query string
+	err   error
+}
+
+type recordTelemetry struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (r *recordTelemetry) Observe(_ context.Context, event TelemetryEvent, query string, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if duration < 0 {
+		panic("negative duration")
+	}
+	r.observations = append(r.observations, observation{event: event, query: query, err: err})
+}
+
+func (r *recordTelemetry) events() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]string, 0, len(r.observations))
+	for _, o := range r.observations {
+		event := string(o.event)
+		if o.query != "" {
+			event += " " + o.query
+		}
+		if o.err != nil {
+			event += " (" + o.err.Error() + ")"
+		}
+		events = append(events, event)
+	}
+	return strings.Join(events, "; ")
+}
+
+func TestConnectWithTelemetry(t *testing.T) {
+	telemetry := &recordTelemetry{}
+	db, err := Connect("juice-record", "telemetry", ConnectWithTelemetry(telemetry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+	if _, err = db.ExecContext(ctx, "UPDATE user SET name = 'a'"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.QueryContext(ctx, "SELECT id FROM user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rows.Close()
+	if _, err = db.ExecContext(ctx, "UPDATE fail"); err == nil {
+		t.Fatal("expected the exec to fail")
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = tx.Rollback()
+	// the driver.ErrSkip of the connection without ExecerContext and QueryerContext is not observed,
+	// and the idle connection is acquired again for every operation after the first one.
+	expected := "connect; " +
+		"prepare UPDATE user SET name = 'a'; exec UPDATE user SET name = 'a'; " +
+		"acquire; prepare SELECT id FROM user; query SELECT id FROM user; " +
+		"acquire; prepare UPDATE fail; exec UPDATE fail (exec failed); " +
+		"acquire; begin"
+	if events := telemetry.events(); events != expected {
+		t.Fatalf("unexpected events:\n%s\nexpected:\n%s", events, expected)
+	}
+}
+
+func TestConnectWithTelemetry_OnConnect(t *testing.T) {
+	telemetry := &recordTelemetry{}
+	db, err := Connect("juice-record", "telemetry-on-connect", ConnectWithTelemetry(telemetry), ConnectWithOnConnect("SET a = 1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	if err = db.PingContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// the on connect queries are measured as well.
+	if events, expected := telemetry.events(), "connect; prepare SET a = 1; exec SET a = 1"; events != expected {
+		t.Fatalf("unexpected events: %s", events)
+	}
+}
+
+// closingConnector is a connector which records whether it is closed.
+type closingConnector struct {
+	dsnConnector
+	closed bool
+	err    error
+}
+
+func (c *closingConnector) Connect(ctx context.Context) (sqldriver.Conn, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.dsnConnector.Connect(ctx)
+}
+
+func (c *closingConnector) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestTelemetryConnector(t *testing.T) {
+	telemetry := &recordTelemetry{}
+	connector := &closingConnector{dsnConnector: dsnConnector{dsn: "telemetry-connector", driver: testRecordDriver}, err: errors.New("connection refused")}
+	db := sql.OpenDB(onConnectConnector{Connector: telemetryConnector{Connector: connector, telemetry: telemetry}, queries: []string{"SET a = 1"}})
+	if err := db.PingContext(context.Background()); err == nil {
+		t.Fatal("expected the connect to fail")
+	}
+	if events, expected := telemetry.events(), "connect (connection refused)"; events != expected {
+		t.Fatalf("unexpected events: %s", events)
+	}
+	// the wrapped connectors are closed with the db.
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !connector.closed {
+		t.Fatal("expected the wrapped connector to be closed")
+	}
+}
+
+func TestTelemetryFunc(t *testing.T) {
+	var got TelemetryEvent
+	var telemetry Telemetry = TelemetryFunc(func(_ context.Context, event TelemetryEvent, _ string, _ time.Duration, _ error) {
+		got = event
+	})
+	telemetry.Observe(context.Background(), TelemetryBegin, "", time.Millisecond, nil)
+	if got != TelemetryBegin {
+		t.Fatalf("unexpected event: %s", got)
+	}
+}
@@ -19,7 +19,12 @@ package juice
 import (
 	"fmt"
 	"iter"
+	"maps"
 	"os"
+	"strings"
+	"sync"
+
+	"github.com/go-juicedev/juice/driver"
 )
 
 // Environment defines a environment.
@@ -43,6 +48,9 @@ type Environment struct {
 	// MaxIdleConnLifetime is a maximum lifetime of an idle connection.
 	MaxIdleConnLifetime int
 
+	// OnConnect is the queries executed for every new connection of the pool.
+	OnConnect []string
+
 	// attrs is a map of attributes.
 	attrs map[string]string
 }
@@ -65,6 +73,21 @@ func (e *Environment) ID() string {
 	return e.Attr("id")
 }
 
+// telemetry returns the telemetry registered with the name of the telemetry attribute.
+func (e *Environment) telemetry() (driver.Telemetry, error) {
+	name := e.Attr("telemetry")
+	if name == "" {
+		return nil, nil
+	}
+	telemetryLibrariesMu.RLock()
+	defer telemetryLibrariesMu.RUnlock()
+	telemetry, exists := telemetryLibraries[name]
+	if !exists {
+		return nil, fmt.Errorf("telemetry %s not registered", name)
+	}
+	return telemetry, nil
+}
+
 // provider is a environment value provider.
 // It provides a value of the environment variable.
 func (e *Environment) provider() EnvValueProvider {
@@ -101,14 +124,20 @@ func (e *environments) setAttr(key, value string) {
 
 // Attribute returns a value of the attribute.
 func (e *environments) Attribute(key string) string {
+	if e == nil {
+		return ""
+	}
 	return e.attr[key]
 }
 
 // Use returns the environment specified by the identifier.
 func (e *environments) Use(id string) (*Environment, error) {
+	if e == nil {
+		return nil, &ErrEnvironmentNotFound{ID: id}
+	}
 	env, exists := e.envs[id]
 	if !exists {
-		return nil, fmt.Errorf("environment %s not found", id)
+		return nil, &ErrEnvironmentNotFound{ID: id, Closest: closestNames(id, maps.Keys(e.envs))}
 	}
 	return env, nil
 }
@@ -148,7 +177,12 @@ type OsEnvValueProvider struct{}
 func (p OsEnvValueProvider) Get(key string) (string, error) {
 	var err error
 	key = formatRegexp.ReplaceAllStringFunc(key, func(find string) string {
-		value := os.Getenv(formatRegexp.FindStringSubmatch(find)[1])
+		matched := formatRegexp.FindStringSubmatch(find)
+		value := os.Getenv(matched[1])
+		// ${HOST ?? 'localhost'} falls back to the default text without the quotes.
+		if len(value) == 0 && matched[2] != "" {
+			value = strings.Trim(matched[2], `'"`)
+		}
 		if len(value) == 0 {
 			err = fmt.Errorf("environment variable %s not found", find)
 		}
@@ -182,6 +216,30 @@ func GetEnvValueProvider(key string) EnvValueProvider {
 	return defaultEnvValueProvider
 }
 
+var (
+	// telemetryLibraries is a map of the registered telemetries.
+	telemetryLibraries = map[string]driver.Telemetry{}
+
+	// telemetryLibrariesMu protects telemetryLibraries.
+	telemetryLibrariesMu sync.RWMutex
+)
+
+// RegisterTelemetry registers a telemetry which can be used by the telemetry attribute of the environments.
+// It must be called before the engine is created, since the connections are opened by then.
+//
+//	<environment id="prod" telemetry="metrics">
+func RegisterTelemetry(name string, telemetry driver.Telemetry) {
+	if len(name) == 0 {
+		panic("name is empty")
+	}
+	if telemetry == nil {
+		panic("juice: telemetry is nil")
+	}
+	telemetryLibrariesMu.Lock()
+	defer telemetryLibrariesMu.Unlock()
+	telemetryLibraries[name] = telemetry
+}
+
 func init() {
 	// Register the default environment value provider.
 	RegisterEnvValueProvider("env", &OsEnvValueProvider{})
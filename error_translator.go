@@ -0,0 +1,455 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+// ErrDuplicateKey is returned when a row violates a unique constraint or the primary key.
+// The fields are filled as far as the driver exposes them, so an API can tell which value conflicts:
+//
+//	var duplicateKey juice.ErrDuplicateKey
+//	if errors.As(err, &duplicateKey) && slices.Contains(duplicateKey.Columns, "email") {
+//	    return errors.New("email already registered")
+//	}
+type ErrDuplicateKey struct {
+	// Constraint is the name of the violated constraint, or the columns for SQLite, it may be empty.
+	Constraint string
+	// Table is the table of the violated constraint, it may be empty.
+	Table string
+	// Columns is the columns of the violated constraint, which are not reported by MySQL.
+	Columns []string
+	// Values is the conflicting values of the columns in order, as text.
+	// MySQL reports the values of a composite key joined by "-" as a single value.
+	Values []string
+	// Err is the error of the driver.
+	Err error
+}
+
+func (e ErrDuplicateKey) Error() string {
+	return constraintErrorMessage("duplicate key", e.Constraint, e.Err)
+}
+
+// Unwrap returns the error of the driver.
+func (e ErrDuplicateKey) Unwrap() error { return e.Err }
+
+// ErrForeignKeyViolation is returned when a row violates a foreign key constraint.
+type ErrForeignKeyViolation struct {
+	// Constraint is the name of the violated constraint, it may be empty.
+	Constraint string
+	// Err is the error of the driver.
+	Err error
+}
+
+func (e ErrForeignKeyViolation) Error() string {
+	return constraintErrorMessage("foreign key violation", e.Constraint, e.Err)
+}
+
+// Unwrap returns the error of the driver.
+func (e ErrForeignKeyViolation) Unwrap() error { return e.Err }
+
+// ErrCheckViolation is returned when a row violates a check constraint.
+type ErrCheckViolation struct {
+	// Constraint is the name of the violated constraint, it may be empty.
+	Constraint string
+	// Err is the error of the driver.
+	Err error
+}
+
+func (e ErrCheckViolation) Error() string {
+	return constraintErrorMessage("check violation", e.Constraint, e.Err)
+}
+
+// Unwrap returns the error of the driver.
+func (e ErrCheckViolation) Unwrap() error { return e.Err }
+
+// ErrSerializationFailure is returned when a transaction can not be serialized or is chosen as
+// the deadlock victim, the transaction is expected to be retried.
+type ErrSerializationFailure struct {
+	// Err is the error of the driver.
+	Err error
+}
+
+func (e ErrSerializationFailure) Error() string { return "serialization failure: " + e.Err.Error() }
+
+// Unwrap returns the error of the driver.
+func (e ErrSerializationFailure) Unwrap() error { return e.Err }
+
+func constraintErrorMessage(kind, constraint string, err error) string {
+	if constraint != "" {
+		kind += " " + strconv.Quote(constraint)
+	}
+	return kind + ": " + err.Error()
+}
+
+// ErrorTranslator translates the driver-specific errors into the domain errors,
+// like ErrDuplicateKey, so the application code does not parse the vendor error strings.
+type ErrorTranslator interface {
+	// TranslateError returns the translated error, or the err itself if it is not known.
+	TranslateError(err error) error
+}
+
+// ErrorTranslatorFunc is an adapter to allow the use of ordinary functions as ErrorTranslator.
+type ErrorTranslatorFunc func(err error) error
+
+// TranslateError implements ErrorTranslator.
+func (f ErrorTranslatorFunc) TranslateError(err error) error { return f(err) }
+
+var (
+	// MySQLErrorTranslator translates the errors of MySQL by the error numbers.
+	MySQLErrorTranslator ErrorTranslator = ErrorTranslatorFunc(translateMySQLError)
+
+	// PostgresErrorTranslator translates the errors of PostgreSQL by the SQLSTATE codes,
+	// or the messages if the driver error does not expose the code.
+	PostgresErrorTranslator ErrorTranslator = ErrorTranslatorFunc(translatePostgresError)
+
+	// SQLiteErrorTranslator translates the errors of SQLite by the messages.
+	SQLiteErrorTranslator ErrorTranslator = ErrorTranslatorFunc(translateSQLiteError)
+)
+
+// DefaultErrorTranslator returns the error translator of the driver,
+// or nil if the driver has no translator.
+func DefaultErrorTranslator(drv driver.Driver) ErrorTranslator {
+	switch drv.(type) {
+	case driver.MySQLDriver, *driver.MySQLDriver:
+		return MySQLErrorTranslator
+	case driver.PostgresDriver, *driver.PostgresDriver:
+		return PostgresErrorTranslator
+	case driver.SQLiteDriver, *driver.SQLiteDriver:
+		return SQLiteErrorTranslator
+	}
+	return nil
+}
+
+// isTranslatedError reports whether the error is already translated.
+func isTranslatedError(err error) bool {
+	var (
+		duplicateKey         ErrDuplicateKey
+		foreignKeyViolation  ErrForeignKeyViolation
+		checkViolation       ErrCheckViolation
+		serializationFailure ErrSerializationFailure
+	)
+	return errors.As(err, &duplicateKey) || errors.As(err, &foreignKeyViolation) ||
+		errors.As(err, &checkViolation) || errors.As(err, &serializationFailure)
+}
+
+var (
+	// mysqlErrorPattern matches the errors of go-sql-driver/mysql, like "Error 1062 (23000): Duplicate entry".
+	mysqlErrorPattern = regexp.MustCompile(`Error (\d+)(?: \(\w+\))?: `)
+
+	// mysqlDuplicateEntryPattern matches the value and the key of the duplicate entry,
+	// like "Duplicate entry 'a@b.c' for key 'users.uk_email'".
+	mysqlDuplicateEntryPattern = regexp.MustCompile("Duplicate entry '(.*)' for key '([^']+)'")
+
+	// mysqlConstraintPattern matches the constraint of the foreign key and the check violations.
+	mysqlConstraintPattern = regexp.MustCompile("(?i)constraint [`']([^`']+)[`']")
+)
+
+func translateMySQLError(err error) error {
+	if err == nil || isTranslatedError(err) {
+		return err
+	}
+	message := err.Error()
+	number, ok := mysqlErrorNumber(err)
+	if !ok {
+		// the drivers which do not expose the number, or the errors wrapped as text.
+		matched := mysqlErrorPattern.FindStringSubmatch(message)
+		if matched == nil {
+			return err
+		}
+		parsed, parseErr := strconv.ParseUint(matched[1], 10, 16)
+		if parseErr != nil {
+			return err
+		}
+		number = uint16(parsed)
+	}
+	switch number {
+	case 1062, 1586:
+		return mysqlDuplicateKey(message, err)
+	case 1451, 1452, 1216, 1217:
+		return ErrForeignKeyViolation{Constraint: submatch(mysqlConstraintPattern, message), Err: err}
+	case 3819:
+		return ErrCheckViolation{Constraint: submatch(mysqlConstraintPattern, message), Err: err}
+	case 1213, 1205:
+		// deadlock and lock wait timeout, which are retried like the serialization failures.
+		return ErrSerializationFailure{Err: err}
+	}
+	return err
+}
+
+// mysqlErrorNumber returns the error number of the first MySQL error in the chain,
+// by the Number field of the *mysql.MySQLError of go-sql-driver/mysql.
+func mysqlErrorNumber(err error) (uint16, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		value := reflect.ValueOf(err)
+		for value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			continue
+		}
+		if field := value.FieldByName("Number"); field.IsValid() && field.Kind() == reflect.Uint16 {
+			return uint16(field.Uint()), true
+		}
+	}
+	return 0, false
+}
+
+// mysqlDuplicateKey returns the ErrDuplicateKey of the message.
+// The key is qualified by the table since MySQL 8.0, like "users.uk_email".
+func mysqlDuplicateKey(message string, err error) ErrDuplicateKey {
+	duplicateKey := ErrDuplicateKey{Err: err}
+	matched := mysqlDuplicateEntryPattern.FindStringSubmatch(message)
+	if matched == nil {
+		return duplicateKey
+	}
+	duplicateKey.Constraint, duplicateKey.Values = matched[2], []string{matched[1]}
+	if table, _, ok := strings.Cut(matched[2], "."); ok {
+		duplicateKey.Table = table
+	}
+	return duplicateKey
+}
+
+// postgresMessagePattern matches the constraint of the messages of PostgreSQL,
+// like `duplicate key value violates unique constraint "users_email_key"`.
+var postgresMessagePattern = regexp.MustCompile(`violates (unique|foreign key|check) constraint "([^"]+)"`)
+
+func translatePostgresError(err error) error {
+	if err == nil || isTranslatedError(err) {
+		return err
+	}
+	fields := driverErrorFields(err)
+	state, constraint := fields.state, fields.constraint
+	if state == "" {
+		// the drivers which do not expose the fields, or the wrapped errors.
+		message := err.Error()
+		if matched := postgresMessagePattern.FindStringSubmatch(message); matched != nil {
+			state, constraint = map[string]string{"unique": "23505", "foreign key": "23503", "check": "23514"}[matched[1]], matched[2]
+		} else if strings.Contains(message, "could not serialize access") {
+			state = "40001"
+		} else if strings.Contains(message, "deadlock detected") {
+			state = "40P01"
+		}
+	}
+	switch state {
+	case "23505":
+		duplicateKey := ErrDuplicateKey{Constraint: constraint, Table: fields.table, Err: err}
+		// the detail is like "Key (email)=(a@b.c) already exists.", which is a part of the message for lib/pq.
+		detail := fields.detail
+		if detail == "" {
+			detail = err.Error()
+		}
+		duplicateKey.Columns, duplicateKey.Values = postgresKeyDetail(detail)
+		return duplicateKey
+	case "23503":
+		return ErrForeignKeyViolation{Constraint: constraint, Err: err}
+	case "23514":
+		return ErrCheckViolation{Constraint: constraint, Err: err}
+	case "40001", "40P01":
+		return ErrSerializationFailure{Err: err}
+	}
+	return err
+}
+
+// driverErrorInfo is the fields of a driver error.
+type driverErrorInfo struct {
+	state, constraint, table, detail string
+}
+
+// driverErrorFields returns the fields of the first driver error in the chain which reports the SQLSTATE code,
+// by the SQLState method or the Code, ConstraintName, TableName and Detail fields of pgx and lib/pq.
+func driverErrorFields(err error) driverErrorInfo {
+	for ; err != nil; err = errors.Unwrap(err) {
+		var info driverErrorInfo
+		if stater, ok := err.(interface{ SQLState() string }); ok {
+			info.state = stater.SQLState()
+		}
+		value := reflect.ValueOf(err)
+		for value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+		if value.Kind() != reflect.Struct {
+			continue
+		}
+		if info.state == "" {
+			info.state = stringField(value, "Code")
+		}
+		if info.constraint = stringField(value, "ConstraintName"); info.constraint == "" {
+			info.constraint = stringField(value, "Constraint")
+		}
+		if info.table = stringField(value, "TableName"); info.table == "" {
+			info.table = stringField(value, "Table")
+		}
+		info.detail = stringField(value, "Detail")
+		if info.state != "" {
+			return info
+		}
+	}
+	return driverErrorInfo{}
+}
+
+// postgresKeyDetailPattern matches the detail of the unique violations of PostgreSQL.
+var postgresKeyDetailPattern = regexp.MustCompile(`Key \((.+?)\)=\((.*)\) already exists`)
+
+// postgresKeyDetail returns the columns and the values of the detail like "Key (email)=(a@b.c) already exists.".
+// The values are split by ", " only if they match the columns, since a value may contain a comma.
+func postgresKeyDetail(detail string) (columns, values []string) {
+	matched := postgresKeyDetailPattern.FindStringSubmatch(detail)
+	if matched == nil {
+		return nil, nil
+	}
+	columns = strings.Split(matched[1], ", ")
+	if values = strings.Split(matched[2], ", "); len(values) != len(columns) {
+		values = []string{matched[2]}
+	}
+	return columns, values
+}
+
+// stringField returns the value of the named string field of the struct, or empty if not found.
+func stringField(value reflect.Value, name string) string {
+	field := value.FieldByName(name)
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return ""
+	}
+	return field.String()
+}
+
+// sqliteConstraintPattern matches the errors of SQLite, like "UNIQUE constraint failed: users.email".
+var sqliteConstraintPattern = regexp.MustCompile(`(UNIQUE|PRIMARY KEY|FOREIGN KEY|CHECK) constraint failed(?:: (.+))?`)
+
+func translateSQLiteError(err error) error {
+	if err == nil || isTranslatedError(err) {
+		return err
+	}
+	matched := sqliteConstraintPattern.FindStringSubmatch(err.Error())
+	if matched == nil {
+		return err
+	}
+	constraint := strings.TrimSpace(matched[2])
+	switch matched[1] {
+	case "UNIQUE", "PRIMARY KEY":
+		return sqliteDuplicateKey(constraint, err)
+	case "FOREIGN KEY":
+		return ErrForeignKeyViolation{Constraint: constraint, Err: err}
+	default:
+		return ErrCheckViolation{Constraint: constraint, Err: err}
+	}
+}
+
+// sqliteDuplicateKey returns the ErrDuplicateKey of the columns like "users.email, users.name".
+// SQLite does not report the name of the index nor the conflicting values.
+func sqliteDuplicateKey(constraint string, err error) ErrDuplicateKey {
+	duplicateKey := ErrDuplicateKey{Constraint: constraint, Err: err}
+	if constraint == "" {
+		return duplicateKey
+	}
+	for _, column := range strings.Split(constraint, ", ") {
+		table, name, ok := strings.Cut(column, ".")
+		if !ok {
+			table, name = "", column
+		}
+		duplicateKey.Table = table
+		duplicateKey.Columns = append(duplicateKey.Columns, name)
+	}
+	return duplicateKey
+}
+
+// submatch returns the first submatch of the pattern in the text, or empty if not matched.
+func submatch(pattern *regexp.Regexp, text string) string {
+	if matched := pattern.FindStringSubmatch(text); len(matched) > 1 {
+		return matched[1]
+	}
+	return ""
+}
+
+// ensure ErrorTranslatorMiddleware implements Middleware.
+var _ Middleware = (*ErrorTranslatorMiddleware)(nil) // compile time check
+
+// ErrorTranslatorMiddleware is a middleware that translates the errors of the driver into the
+// domain errors, like ErrDuplicateKey, ErrForeignKeyViolation, ErrCheckViolation and ErrSerializationFailure.
+//
+//	var duplicateKey juice.ErrDuplicateKey
+//	if errors.As(err, &duplicateKey) && duplicateKey.Constraint == "users_email_key" {
+//	    // the email is already registered.
+//	}
+//
+// The errors of the commits of the transactions of the engine are translated as well, since PostgreSQL
+// reports the serialization failures at the commit. The errors returned by the rows, like the ones of
+// sql.Rows.Err, are not translated.
+type ErrorTranslatorMiddleware struct {
+	// Translator translates the errors, the DefaultErrorTranslator of the driver is used if it is nil.
+	Translator ErrorTranslator
+}
+
+// QueryContext implements Middleware.
+func (m *ErrorTranslatorMiddleware) QueryContext(_ Statement, next QueryHandler) QueryHandler {
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		rows, err := next(ctx, query, args...)
+		if err != nil {
+			return rows, m.translate(ctx, err)
+		}
+		return rows, nil
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *ErrorTranslatorMiddleware) ExecContext(_ Statement, next ExecHandler) ExecHandler {
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		result, err := next(ctx, query, args...)
+		if err != nil {
+			return result, m.translate(ctx, err)
+		}
+		return result, nil
+	}
+}
+
+// translate translates the error by the translator, or the default translator of the driver in the context.
+func (m *ErrorTranslatorMiddleware) translate(ctx context.Context, err error) error {
+	drv, _ := driver.FromContext(ctx)
+	return m.translateWith(drv, err)
+}
+
+// translateWith translates the error by the translator, or the default translator of the driver.
+func (m *ErrorTranslatorMiddleware) translateWith(drv driver.Driver, err error) error {
+	translator := m.Translator
+	if translator == nil {
+		if translator = DefaultErrorTranslator(drv); translator == nil {
+			return err
+		}
+	}
+	return translator.TranslateError(err)
+}
+
+// translateError translates the error by the ErrorTranslatorMiddleware used by the engine, if any,
+// like the errors of the commits, which are not executed by the middlewares.
+func (e *Engine) translateError(err error) error {
+	for _, middleware := range e.middlewares {
+		if translator, ok := middleware.(*ErrorTranslatorMiddleware); ok {
+			return translator.translateWith(e.Driver(), err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+// pgError mimics the error of the PostgreSQL drivers, which expose the code and the constraint as fields.
+type pgError struct {
+	Code           string
+	ConstraintName string
+	TableName      string
+	Detail         string
+}
+
+func (e *pgError) Error() string { return "ERROR: violation (SQLSTATE " + e.Code + ")" }
+
+// mysqlError mimics the *mysql.MySQLError of go-sql-driver/mysql, whose message does not contain the number here.
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mysqlError) Error() string { return e.Message }
+
+func TestErrorTranslators(t *testing.T) {
+	tests := []struct {
+		translator ErrorTranslator
+		err        error
+		want       any
+		constraint string
+	}{
+		{MySQLErrorTranslator, errors.New("Error 1062 (23000): Duplicate entry 'a@b.c' for key 'users.uk_email'"), ErrDuplicateKey{}, "users.uk_email"},
+		{MySQLErrorTranslator, errors.New("Error 1452 (23000): Cannot add or update a child row: a foreign key constraint fails (`db`.`orders`, CONSTRAINT `fk_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))"), ErrForeignKeyViolation{}, "fk_user"},
+		{MySQLErrorTranslator, errors.New("Error 3819 (HY000): Check constraint 'chk_age' is violated."), ErrCheckViolation{}, "chk_age"},
+		{MySQLErrorTranslator, errors.New("Error 1213 (40001): Deadlock found when trying to get lock"), ErrSerializationFailure{}, ""},
+		{MySQLErrorTranslator, fmt.Errorf("insert: %w", &mysqlError{Number: 1062, Message: "Duplicate entry '7' for key 'users.PRIMARY'"}), ErrDuplicateKey{}, "users.PRIMARY"},
+		{MySQLErrorTranslator, &mysqlError{Number: 1205, Message: "Lock wait timeout exceeded"}, ErrSerializationFailure{}, ""},
+		{PostgresErrorTranslator, fmt.Errorf("insert: %w", &pgError{Code: "23505", ConstraintName: "users_email_key"}), ErrDuplicateKey{}, "users_email_key"},
+		{PostgresErrorTranslator, errors.New(`pq: insert or update on table "orders" violates foreign key constraint "orders_user_id_fkey"`), ErrForeignKeyViolation{}, "orders_user_id_fkey"},
+		{PostgresErrorTranslator, &pgError{Code: "40001"}, ErrSerializationFailure{}, ""},
+		{SQLiteErrorTranslator, errors.New("UNIQUE constraint failed: users.email"), ErrDuplicateKey{}, "users.email"},
+		{SQLiteErrorTranslator, errors.New("FOREIGN KEY constraint failed"), ErrForeignKeyViolation{}, ""},
+		{SQLiteErrorTranslator, errors.New("CHECK constraint failed: age > 0"), ErrCheckViolation{}, "age > 0"},
+	}
+	for _, tt := range tests {
+		err := tt.translator.TranslateError(tt.err)
+		var constraint string
+		switch tt.want.(type) {
+		case ErrDuplicateKey:
+			var target ErrDuplicateKey
+			if !errors.As(err, &target) {
+				t.Errorf("%v: expected ErrDuplicateKey, got %v", tt.err, err)
+			}
+			constraint = target.Constraint
+		case ErrForeignKeyViolation:
+			var target ErrForeignKeyViolation
+			if !errors.As(err, &target) {
+				t.Errorf("%v: expected ErrForeignKeyViolation, got %v", tt.err, err)
+			}
+			constraint = target.Constraint
+		case ErrCheckViolation:
+			var target ErrCheckViolation
+			if !errors.As(err, &target) {
+				t.Errorf("%v: expected ErrCheckViolation, got %v", tt.err, err)
+			}
+			constraint = target.Constraint
+		case ErrSerializationFailure:
+			var target ErrSerializationFailure
+			if !errors.As(err, &target) {
+				t.Errorf("%v: expected ErrSerializationFailure, got %v", tt.err, err)
+			}
+		}
+		if constraint != tt.constraint {
+			t.Errorf("%v: expected constraint %q, got %q", tt.err, tt.constraint, constraint)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%v: expected the translated error to wrap the driver error", tt.err)
+		}
+	}
+	unknown := errors.New("connection refused")
+	if err := MySQLErrorTranslator.TranslateError(unknown); err != unknown {
+		t.Errorf("expected the unknown error to be kept, got %v", err)
+	}
+}
+
+func TestErrDuplicateKeyDetails(t *testing.T) {
+	tests := []struct {
+		translator ErrorTranslator
+		err        error
+		want       ErrDuplicateKey
+	}{
+		{
+			MySQLErrorTranslator,
+			errors.New("Error 1062 (23000): Duplicate entry 'a@b.c' for key 'users.uk_email'"),
+			ErrDuplicateKey{Constraint: "users.uk_email", Table: "users", Values: []string{"a@b.c"}},
+		},
+		{
+			PostgresErrorTranslator,
+			&pgError{Code: "23505", ConstraintName: "users_email_key", TableName: "users", Detail: "Key (email)=(a@b.c) already exists."},
+			ErrDuplicateKey{Constraint: "users_email_key", Table: "users", Columns: []string{"email"}, Values: []string{"a@b.c"}},
+		},
+		{
+			PostgresErrorTranslator,
+			errors.New(`pq: duplicate key value violates unique constraint "orders_pkey" Key (tenant, id)=(1, 2) already exists.`),
+			ErrDuplicateKey{Constraint: "orders_pkey", Columns: []string{"tenant", "id"}, Values: []string{"1", "2"}},
+		},
+		{
+			SQLiteErrorTranslator,
+			errors.New("UNIQUE constraint failed: users.tenant, users.email"),
+			ErrDuplicateKey{Constraint: "users.tenant, users.email", Table: "users", Columns: []string{"tenant", "email"}},
+		},
+	}
+	for _, tt := range tests {
+		var got ErrDuplicateKey
+		if !errors.As(tt.translator.TranslateError(tt.err), &got) {
+			t.Errorf("%v: expected ErrDuplicateKey", tt.err)
+			continue
+		}
+		got.Err = nil
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: expected %+v, got %+v", tt.err, tt.want, got)
+		}
+	}
+}
+
+func TestErrorTranslatorMiddleware(t *testing.T) {
+	middleware := &ErrorTranslatorMiddleware{}
+	next := func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return nil, errors.New("UNIQUE constraint failed: users.email")
+	}
+	ctx := driver.WithContext(context.Background(), &driver.SQLiteDriver{})
+	_, err := middleware.ExecContext(nil, next)(ctx, "INSERT INTO users")
+	var duplicateKey ErrDuplicateKey
+	if !errors.As(err, &duplicateKey) || duplicateKey.Constraint != "users.email" {
+		t.Fatalf("expected ErrDuplicateKey, got %v", err)
+	}
+	// the custom translator takes precedence.
+	custom := errors.New("custom")
+	middleware.Translator = ErrorTranslatorFunc(func(error) error { return custom })
+	if _, err = middleware.ExecContext(nil, next)(ctx, "INSERT INTO users"); err != custom {
+		t.Fatalf("expected the custom error, got %v", err)
+	}
+}
+
+// serializationConn is the connection whose commits fail with the serialization failure of PostgreSQL.
+type serializationConn struct{ compositeConn }
+
+func (c serializationConn) Connect(context.Context) (sqldriver.Conn, error) { return c, nil }
+
+func (c serializationConn) Begin() (sqldriver.Tx, error) { return c, nil }
+
+func (c serializationConn) Commit() error { return &pgError{Code: "40001"} }
+
+func TestErrorTranslatorMiddleware_Commit(t *testing.T) {
+	db := sql.OpenDB(serializationConn{compositeConn{execs: new([]string), commits: new(int)}})
+	t.Cleanup(func() { _ = db.Close() })
+	engine := &Engine{db: db, driver: driver.PostgresDriver{}, rw: &NoOpRWMutex{}}
+
+	tx := engine.Tx()
+	if err := tx.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	var pgErr *pgError
+	if err := tx.Commit(); errors.As(err, new(ErrSerializationFailure)) || !errors.As(err, &pgErr) {
+		t.Fatalf("expected the untranslated error without the middleware, got %v", err)
+	}
+
+	engine.Use(&ErrorTranslatorMiddleware{})
+	tx = engine.Tx()
+	if err := tx.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); !errors.As(err, new(ErrSerializationFailure)) {
+		t.Fatalf("expected ErrSerializationFailure, got %v", err)
+	}
+}
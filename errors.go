@@ -46,6 +46,18 @@ var (
 
 	// ErrNoStatementFound is an error that is returned when the statement is not found.
 	ErrNoStatementFound = errors.New("no statement found")
+
+	// ErrDialectNotMatched is an error that is returned when none of the dialect bodies of the statement
+	// matches the driver, and the statement has no default body.
+	ErrDialectNotMatched = errors.New("no dialect matches the driver")
+
+	// ErrInvalidArgument is an error that is returned when the value of a parameter can not be passed
+	// to the database, like a func or a chan.
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrPlaceholderMismatch is an error that is returned when the number of the placeholders of the built query
+	// does not equal the number of the arguments.
+	ErrPlaceholderMismatch = errors.New("placeholders do not match the arguments")
 )
 
 // nodeUnclosedError is an error that is returned when the node is not closed.
@@ -81,6 +93,25 @@ func (e *nodeAttributeConflictError) Error() string {
 	return fmt.Sprintf("node %s has conflicting attribute %s", e.nodeName, e.attrName)
 }
 
+// ParseError is an error that is returned when a mapper file can not be parsed.
+// It reports the position in the file where the error occurred.
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+// Error returns the error message.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
 // unreachable is a function that is used to mark unreachable code.
 // nolint:deadcode,unused
 func unreachable() error {
@@ -99,8 +130,33 @@ func (e ErrMapperNotFound) Error() string { return fmt.Sprintf("mapper %q not fo
 type ErrStatementNotFound struct {
 	StatementName string
 	MapperName    string
+	// ID is the full id of the statement looked up, like "main.UserMapper.SelectUser".
+	ID string
+	// Closest is the ids of the registered statements closest to the ID, for the did-you-mean suggestions.
+	Closest []string
+	// err is the cause of the not found, like ErrMapperNotFound.
+	err error
 }
 
 func (e ErrStatementNotFound) Error() string {
-	return fmt.Sprintf("statement %q not found in mapper %q", e.StatementName, e.MapperName)
+	if e.err != nil {
+		return e.err.Error() + didYouMean(e.Closest)
+	}
+	return fmt.Sprintf("statement %q not found in mapper %q", e.StatementName, e.MapperName) + didYouMean(e.Closest)
+}
+
+// Unwrap returns the cause of the not found, like ErrMapperNotFound when the namespace is not registered.
+func (e ErrStatementNotFound) Unwrap() error {
+	return e.err
+}
+
+// ErrEnvironmentNotFound indicates that the environment was not found in the configuration
+type ErrEnvironmentNotFound struct {
+	ID string
+	// Closest is the ids of the environments closest to the ID, for the did-you-mean suggestions.
+	Closest []string
+}
+
+func (e ErrEnvironmentNotFound) Error() string {
+	return fmt.Sprintf("environment %s not found", e.ID) + didYouMean(e.Closest)
 }
@@ -0,0 +1,91 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eval
+
+import (
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// MaxCachedExpressions is the max number of the compiled expressions kept by the cache.
+// The expressions of the mappers are bounded, so the cache stops growing instead of
+// evicting the entries once it is full, the expressions beyond it are compiled every time.
+const MaxCachedExpressions = 4096
+
+// compiledExprCache caches the compiled expressions keyed by the expression text.
+// The compiled expressions are immutable, so they are safe to be shared by the goroutines.
+type compiledExprCache struct {
+	expressions sync.Map // map[string]Expression
+	size        atomic.Int64
+}
+
+// compile returns the cached expression or compiles it with the compiler.
+// The expressions failed to compile are not cached.
+func (c *compiledExprCache) compile(compiler ExprCompiler, expr string) (Expression, error) {
+	if cached, ok := c.expressions.Load(expr); ok {
+		return cached.(Expression), nil
+	}
+	expression, err := compiler.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if c.size.Load() < MaxCachedExpressions {
+		if _, loaded := c.expressions.LoadOrStore(expr, expression); !loaded {
+			c.size.Add(1)
+		}
+	}
+	return expression, nil
+}
+
+// exprCache is the process wide cache used by Compile and Eval.
+var exprCache = &compiledExprCache{}
+
+// CompiledExpressions returns the number of the compiled expressions in the cache.
+func CompiledExpressions() int {
+	return int(exprCache.size.Load())
+}
+
+// MaxCachedPatterns is the max number of the compiled patterns of the matches function kept by the cache.
+// Like MaxCachedExpressions, the cache stops growing once it is full.
+const MaxCachedPatterns = 1024
+
+// compiledPatternCache caches the compiled regular expressions keyed by the pattern.
+type compiledPatternCache struct {
+	patterns sync.Map // map[string]*regexp.Regexp
+	size     atomic.Int64
+}
+
+// compile returns the cached regular expression or compiles the pattern.
+func (c *compiledPatternCache) compile(pattern string) (*regexp.Regexp, error) {
+	if cached, ok := c.patterns.Load(pattern); ok {
+		return cached.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if c.size.Load() < MaxCachedPatterns {
+		if _, loaded := c.patterns.LoadOrStore(pattern, re); !loaded {
+			c.size.Add(1)
+		}
+	}
+	return re, nil
+}
+
+// patternCache is the process wide cache used by the matches function.
+var patternCache = &compiledPatternCache{}
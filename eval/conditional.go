@@ -0,0 +1,258 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"reflect"
+
+	"github.com/go-juicedev/juice/internal/reflectlite"
+)
+
+const (
+	// iifFuncName is the name of the conditional function iif(cond, a, b),
+	// which evaluates a if cond is true, otherwise b. A parameter named iif is called instead if it exists.
+	iifFuncName = "iif"
+
+	// ternaryFuncName is the name of the function the ternary cond ? a : b is rewritten to, which is iif
+	// without being shadowed by the parameters.
+	ternaryFuncName = "__ternary__"
+
+	// elvisFuncName is the name of the function the elvis a ?: b is rewritten to,
+	// which evaluates to a unless it is nil or zero, otherwise b.
+	elvisFuncName = "__elvis__"
+)
+
+// isConditionalFunc reports whether the call is a conditional function, whose arguments are evaluated lazily.
+// Like the other functions, iif is resolved from the parameters first.
+func isConditionalFunc(exp *ast.CallExpr, params Parameter) (string, bool) {
+	ident, ok := exp.Fun.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	switch ident.Name {
+	case ternaryFuncName, elvisFuncName:
+		return ident.Name, true
+	case iifFuncName:
+		if params == nil {
+			return ident.Name, true
+		}
+		if _, exists := params.Get(ident.Name); exists {
+			return "", false
+		}
+		return ident.Name, true
+	default:
+		return "", false
+	}
+}
+
+// evalConditionalCall evaluates the iif and elvis calls, only the chosen argument is evaluated.
+func evalConditionalCall(name string, exp *ast.CallExpr, params Parameter) (reflect.Value, error) {
+	switch name {
+	case iifFuncName, ternaryFuncName:
+		if len(exp.Args) != 3 {
+			return reflect.Value{}, fmt.Errorf("iif: invalid number of arguments: expected 3, got %d", len(exp.Args))
+		}
+		cond, err := eval(exp.Args[0], params)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		cond = reflectlite.Unwrap(cond)
+		if cond.Kind() != reflect.Bool {
+			return reflect.Value{}, fmt.Errorf("iif: expected bool condition, got %v", cond.Kind())
+		}
+		if cond.Bool() {
+			return eval(exp.Args[1], params)
+		}
+		return eval(exp.Args[2], params)
+	default:
+		if len(exp.Args) != 2 {
+			return reflect.Value{}, errors.New("invalid elvis expression")
+		}
+		value, err := eval(exp.Args[0], params)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if unwrapped := reflectlite.Unwrap(value); unwrapped.IsValid() && !unwrapped.IsZero() {
+			return value, nil
+		}
+		return eval(exp.Args[1], params)
+	}
+}
+
+// rewriteConditionals rewrites the ternary cond ? a : b to __ternary__(cond, a, b) and the elvis a ?: b to
+// __elvis__(a, b), which are not Go syntax. Like C, they have the lowest precedence and are right
+// associative, the parentheses and the arguments of the calls are rewritten separately.
+func rewriteConditionals(tokens []string) ([]string, error) {
+	var segment []string
+	for i := 0; i < len(tokens); i++ {
+		switch token := tokens[i]; token {
+		case "(", "[", "{":
+			end, err := closingBracket(tokens, i)
+			if err != nil {
+				return nil, err
+			}
+			inner, err := rewriteArguments(tokens[i+1 : end])
+			if err != nil {
+				return nil, err
+			}
+			segment = append(segment, token)
+			segment = append(segment, inner...)
+			segment = append(segment, tokens[end])
+			i = end
+		default:
+			segment = append(segment, token)
+		}
+	}
+	return rewriteTernary(segment)
+}
+
+// rewriteArguments rewrites the comma separated arguments in the brackets separately.
+func rewriteArguments(tokens []string) ([]string, error) {
+	var (
+		output []string
+		start  int
+		depth  int
+	)
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) {
+			switch tokens[i] {
+			case "(", "[", "{":
+				depth++
+				continue
+			case ")", "]", "}":
+				depth--
+				continue
+			case ",":
+				if depth != 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		argument, err := rewriteConditionals(tokens[start:i])
+		if err != nil {
+			return nil, err
+		}
+		output = append(output, argument...)
+		if i < len(tokens) {
+			output = append(output, ",")
+		}
+		start = i + 1
+	}
+	return output, nil
+}
+
+// closingBracket returns the index of the bracket closing the one at the start.
+func closingBracket(tokens []string, start int) (int, error) {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			if depth--; depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, errors.New("unbalanced brackets")
+}
+
+// rewriteTernary rewrites the ternary and elvis at the top level of the tokens,
+// whose brackets are already rewritten.
+func rewriteTernary(tokens []string) ([]string, error) {
+	question := -1
+	depth := 0
+	for i, token := range tokens {
+		switch token {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case "?":
+			if depth == 0 && question < 0 {
+				question = i
+			}
+		}
+	}
+	if question < 0 {
+		return tokens, nil
+	}
+	if question == 0 {
+		return nil, errors.New("missing condition before ?")
+	}
+	cond := tokens[:question]
+
+	// the elvis a ?: b
+	if question+1 < len(tokens) && tokens[question+1] == ":" {
+		right, err := rewriteTernary(tokens[question+2:])
+		if err != nil {
+			return nil, err
+		}
+		return wrapCall(elvisFuncName, cond, right), nil
+	}
+
+	// find the colon of the question, the nested ternaries are in the then branch.
+	nested := 0
+	depth = 0
+	for i := question + 1; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case "?":
+			if depth == 0 {
+				nested++
+			}
+		case ":":
+			if depth != 0 {
+				continue
+			}
+			if nested > 0 {
+				nested--
+				continue
+			}
+			then, err := rewriteTernary(tokens[question+1 : i])
+			if err != nil {
+				return nil, err
+			}
+			otherwise, err := rewriteTernary(tokens[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			return wrapCall(ternaryFuncName, cond, then, otherwise), nil
+		}
+	}
+	return nil, errors.New("missing : of ?")
+}
+
+// wrapCall returns the tokens calling the function with the arguments.
+func wrapCall(name string, arguments ...[]string) []string {
+	call := []string{name, "("}
+	for i, argument := range arguments {
+		if i > 0 {
+			call = append(call, ",")
+		}
+		call = append(call, argument...)
+	}
+	return append(call, ")")
+}
@@ -24,8 +24,12 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"math"
+	"math/big"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // SyntaxError represents a syntax error.
@@ -44,6 +48,84 @@ func (s *SyntaxError) Unwrap() error {
 	return s.err
 }
 
+// ExprError represents an error that occurs when evaluating the expression.
+// It reports which part of the expression failed, and the kinds of the operands
+// if the failed part is a binary expression, like "age + name" with the kinds int and string.
+type ExprError struct {
+	// Expr is the text of the failed expression.
+	Expr string
+	// Span is the source of the failed sub expression.
+	Span string
+	// Offset is the byte offset of Span in Expr, which starts from 0.
+	// It is -1 if the span can not be mapped back to Expr, like the spans in the ternary expressions
+	// which are rewritten to function calls, then Span is the rewritten source.
+	Offset int
+	// Statement is the full name of the statement which the expression belongs to, it is empty if unknown.
+	Statement string
+	// Left and Right are the runtime kinds of the operands of the failed binary expression,
+	// they are reflect.Invalid if the operand is nil or not evaluated.
+	Left, Right reflect.Kind
+	// Err is the underlying error.
+	Err error
+
+	pos, end token.Pos
+	binary   bool
+}
+
+// Error returns the error message, like
+// `eval "age + name" of statement main.User.Select at "age + name" (offset 0) with operands int and string: <error>`.
+func (e *ExprError) Error() string {
+	var builder strings.Builder
+	builder.WriteString("eval ")
+	builder.WriteString(strconv.Quote(e.Expr))
+	if e.Statement != "" {
+		builder.WriteString(" of statement ")
+		builder.WriteString(e.Statement)
+	}
+	if e.Span != "" {
+		builder.WriteString(" at ")
+		builder.WriteString(strconv.Quote(e.Span))
+		builder.WriteString(" (offset ")
+		builder.WriteString(strconv.Itoa(e.Offset))
+		builder.WriteString(")")
+	}
+	if e.binary {
+		builder.WriteString(" with operands ")
+		builder.WriteString(e.Left.String())
+		builder.WriteString(" and ")
+		builder.WriteString(e.Right.String())
+	}
+	builder.WriteString(": ")
+	builder.WriteString(e.Err.Error())
+	return builder.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *ExprError) Unwrap() error {
+	return e.Err
+}
+
+// withExprSource fills the expression text and the span of the failed sub expression.
+// The lexer maps the positions of the tokenized source back to the expression.
+func withExprSource(err error, lexer *Lexer, source string) error {
+	var exprError *ExprError
+	if !errors.As(err, &exprError) {
+		return err
+	}
+	exprError.Expr = lexer.input
+	if !exprError.pos.IsValid() {
+		return err
+	}
+	// the positions of parser.ParseExpr start from 1.
+	start, end := int(exprError.pos)-1, int(exprError.end)-1
+	if span, offset, ok := lexer.inputSpan(start, end); ok {
+		exprError.Span, exprError.Offset = span, offset
+	} else if start < end && end <= len(source) {
+		exprError.Span, exprError.Offset = source[start:end], -1
+	}
+	return err
+}
+
 // ExprCompiler is an evaluator of the expression.
 type ExprCompiler interface {
 	// Compile compiles the expression and returns the expression.
@@ -68,11 +150,11 @@ func (e *goExprCompiler) Compile(expr string) (Expression, error) {
 	// Create a new lexer and convert logical operators (and, or, not) to Go operators (&&, ||, !)
 	lexer := NewLexer(expr)
 	// Tokenize the expression, replacing operators while preserving other tokens
-	expr = lexer.Tokenize()
+	source := lexer.Tokenize()
 
 	// Parse the processed expression into an AST (Abstract Syntax Tree)
 	// This converts the string expression into a structured format that can be evaluated
-	exp, err := parser.ParseExpr(expr)
+	exp, err := parser.ParseExpr(source)
 	if err != nil {
 		return nil, &SyntaxError{err}
 	}
@@ -85,25 +167,69 @@ func (e *goExprCompiler) Compile(expr string) (Expression, error) {
 	optimizer := &StaticExprOptimizer{}
 	optimizedExp, err := optimizer.Optimize(exp, nil)
 	if err != nil {
-		return nil, err
+		return nil, withExprSource(err, lexer, source)
 	}
 
-	return &goExpression{Expr: optimizedExp}, nil
+	expression := &goExpression{Expr: optimizedExp, lexer: lexer, source: source, depth: exprDepth(exp)}
+	// the expressions composed only of literals, like 1 == 1, are folded into a constant,
+	// which is returned by Execute without evaluation.
+	switch folded := optimizedExp.(type) {
+	case *ast.BasicLit:
+		// the big numbers are pointers, which are not shared by the executions.
+		expression.constant, err = evalBasicLit(folded)
+		expression.isConstant = err == nil && expression.constant.Kind() != reflect.Pointer
+	case *ast.Ident:
+		if isConstantIdent(folded) {
+			expression.constant, expression.isConstant = builtins[folded.Name], true
+		}
+	}
+	return expression, nil
 }
 
 // goExpression is an expression who uses the go/ast package.
 type goExpression struct {
 	ast.Expr
+	// lexer maps the tokenized source, which the ast positions refer to, back to the expression.
+	lexer  *Lexer
+	source string
+	// constant is the folded result if isConstant is true.
+	constant   reflect.Value
+	isConstant bool
+	// depth is the depth of the parsed syntax tree, which is checked against Limits.MaxDepth.
+	depth int
 }
 
 // Execute evaluates the expression and returns the value.
 func (e *goExpression) Execute(params Parameter) (Value, error) {
-	return eval(e.Expr, params)
+	limits, hook := limitsOf(params), OptionsOf(params).TraceHook
+	if !limits.IsZero() || hook != nil {
+		if err := checkDepth(e.depth, limits); err != nil {
+			return reflect.Value{}, &ExprError{Expr: e.lexer.input, Offset: -1, Err: err}
+		}
+		b := newBudget(params, limits)
+		if hook != nil {
+			b.tracer = &tracer{hook: hook, expression: e}
+		}
+		params = b
+	}
+	if e.isConstant {
+		if b, ok := params.(*budget); ok && b.tracer != nil {
+			b.tracer.constant(e.constant)
+		}
+		return e.constant, nil
+	}
+	value, err := eval(e.Expr, params)
+	if err != nil {
+		return value, withExprSource(err, e.lexer, e.source)
+	}
+	return value, nil
 }
 
 // Compile compiles the expression and returns the expression.
+// The compiled expressions are cached by the expression text, so the same expression
+// is only parsed once no matter how many times it is compiled or evaluated.
 func Compile(expr string) (Expression, error) {
-	return new(goExprCompiler).Compile(expr)
+	return exprCache.compile(new(goExprCompiler), expr)
 }
 
 func Eval(expr string, params Parameter) (Value, error) {
@@ -114,7 +240,34 @@ func Eval(expr string, params Parameter) (Value, error) {
 	return expression.Execute(params)
 }
 
+// eval evaluates the expression, the error is wrapped as *ExprError with the position of
+// the innermost failed sub expression.
 func eval(exp ast.Expr, params Parameter) (reflect.Value, error) {
+	if b, ok := params.(*budget); ok {
+		if err := b.visit(); err != nil {
+			return reflect.Value{}, &ExprError{Err: err, pos: exp.Pos(), end: exp.End()}
+		}
+		if b.tracer != nil {
+			return b.tracer.eval(exp, params)
+		}
+	}
+	return evalNode(exp, params)
+}
+
+// evalNode evaluates the expression without the checks of the budget.
+func evalNode(exp ast.Expr, params Parameter) (reflect.Value, error) {
+	value, err := evalExpr(exp, params)
+	if err != nil {
+		var exprError *ExprError
+		if errors.As(err, &exprError) {
+			return reflect.Value{}, err
+		}
+		return reflect.Value{}, &ExprError{Err: err, pos: exp.Pos(), end: exp.End()}
+	}
+	return value, nil
+}
+
+func evalExpr(exp ast.Expr, params Parameter) (reflect.Value, error) {
 	switch exp := exp.(type) {
 	case *ast.BinaryExpr:
 		return evalBinaryExpr(exp, params)
@@ -184,31 +337,23 @@ func evalSliceExpr(exp *ast.SliceExpr, params Parameter) (reflect.Value, error)
 	return value.Slice3(low, high, sliceMax), nil
 }
 
-var errUnsupportedUnaryExpr = errors.New("unsupported unary expression")
-
+// evalUnaryExpr evaluates the unary expression like -offset, +offset, ^mask and !enabled.
 func evalUnaryExpr(exp *ast.UnaryExpr, params Parameter) (reflect.Value, error) {
+	executor, err := expr.FromUnaryToken(exp.Op)
+	if err != nil {
+		return reflect.Value{}, err
+	}
 	value, err := eval(exp.X, params)
 	if err != nil {
 		return reflect.Value{}, err
 	}
-	switch exp.Op {
-	case token.SUB:
-		return reflect.ValueOf(-value.Int()), nil
-	case token.ADD:
-		return reflect.ValueOf(+value.Int()), nil
-	case token.NOT:
-		return reflect.ValueOf(!value.Bool()), nil
-	case token.XOR:
-		return reflect.ValueOf(^value.Int()), nil
-	case token.AND:
-		return reflect.ValueOf(^value.Int()), nil
-	case token.MUL:
-		return reflect.ValueOf(value.Pointer()), nil
-	default:
-		return reflect.Value{}, errUnsupportedUnaryExpr
+	if exp.Op == token.NOT && IsTruthy(params) {
+		return reflect.ValueOf(!expr.Truthy(value)), nil
 	}
+	return executor.Exec(value)
 }
 
+// ErrIndexOutOfRange is returned when the index of a slice, array or string is out of range.
 var ErrIndexOutOfRange = errors.New("index out of range")
 
 func evalIndexExpr(exp *ast.IndexExpr, params Parameter) (reflect.Value, error) {
@@ -222,35 +367,59 @@ func evalIndexExpr(exp *ast.IndexExpr, params Parameter) (reflect.Value, error)
 	if err != nil {
 		return reflect.Value{}, err
 	}
+	index = reflectlite.Unwrap(index)
+
 	switch value.Kind() {
 	case reflect.Array, reflect.Slice, reflect.String:
-		i := index.Int()
-		if i >= int64(value.Len()) {
-			return reflect.Value{}, ErrIndexOutOfRange
+		var i int64
+		switch index.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			i = index.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			if index.Uint() > math.MaxInt64 {
+				return reflect.Value{}, fmt.Errorf("%w: index %d with length %d", ErrIndexOutOfRange, index.Uint(), value.Len())
+			}
+			i = int64(index.Uint())
+		default:
+			return reflect.Value{}, fmt.Errorf("invalid index of %v: %v, expected integer", value.Kind(), index.Kind())
+		}
+		if i < 0 || i >= int64(value.Len()) {
+			return reflect.Value{}, fmt.Errorf("%w: index %d with length %d", ErrIndexOutOfRange, i, value.Len())
 		}
 		return value.Index(int(i)), nil
 	case reflect.Map:
-		// in this case, index must be assignable to the map's key type
+		keyType := value.Type().Key()
+		switch {
+		case !index.IsValid():
+			return reflect.Value{}, fmt.Errorf("invalid map key: nil, expected %v", keyType)
+		case index.Type().AssignableTo(keyType):
+		// the literals are int64, float64 or string, convert them to the key type like map[int]string.
+		case index.Type().ConvertibleTo(keyType) && index.Kind() != reflect.String && keyType.Kind() != reflect.String:
+			index = index.Convert(keyType)
+		default:
+			return reflect.Value{}, fmt.Errorf("invalid map key: %v, expected %v", index.Type(), keyType)
+		}
 		// if value not exist, return the map's default value
 		v := value.MapIndex(index)
 		if v.IsValid() {
 			return v, nil
 		}
-		// get map default value
-		if v.Kind() == reflect.Interface {
-			v = v.Elem()
-		}
-		if v.Kind() == reflect.Invalid {
-			v = reflect.Zero(value.Type().Elem())
-		}
-		return v, nil
+		return reflect.Zero(value.Type().Elem()), nil
+	case reflect.Invalid:
+		return reflect.Value{}, errors.New("invalid index expression: index of nil")
 	default:
 		return reflect.Value{}, fmt.Errorf("invalid index expression: %v", value.Kind())
 	}
 }
 
 func evalCallExpr(exp *ast.CallExpr, params Parameter) (reflect.Value, error) {
-	fn, err := eval(exp.Fun, params)
+	if err := chargeCall(params); err != nil {
+		return reflect.Value{}, err
+	}
+	if name, ok := isConditionalFunc(exp, params); ok {
+		return evalConditionalCall(name, exp, params)
+	}
+	fn, err := evalCallee(exp.Fun, params)
 	if err != nil {
 		return reflect.Value{}, err
 	}
@@ -260,67 +429,55 @@ func evalCallExpr(exp *ast.CallExpr, params Parameter) (reflect.Value, error) {
 	if fn.Kind() != reflect.Func {
 		return reflect.Value{}, errors.New("unsupported call expression")
 	}
-	fnType := fn.Type()
-	if numIn := fnType.NumIn(); numIn != len(exp.Args) {
-		return reflect.Value{}, fmt.Errorf("invalid number of arguments: expected %d, got %d", numIn, len(exp.Args))
-	}
-	// TODO dot dot dot support
-	// ...type
-	if fnType.NumOut() != 2 {
-		return reflect.Value{}, fmt.Errorf("invalid number of return values: expected 2, got %d", fn.Type().NumOut())
-	}
 	// evaluate the arguments
 	args := make([]reflect.Value, 0, len(exp.Args))
-	for i, arg := range exp.Args {
+	for _, arg := range exp.Args {
 		value, err := eval(arg, params)
 		if err != nil {
 			return reflect.Value{}, err
 		}
-		value = reflectlite.Unwrap(value)
-		// type conversion for function arguments
-		in := fnType.In(i)
-		if in.Kind() != value.Kind() {
-			if !value.CanConvert(in) {
-				return reflect.Value{}, fmt.Errorf("cannot convert %s to %s", value.Type().Name(), in.Name())
-			}
-			value = value.Convert(in)
-		}
-		args = append(args, value)
+		args = append(args, reflectlite.Unwrap(value))
 	}
-	// call the function
-	rets := fn.Call(args)
-	// unreachable code.
-	// just for nil check
-	if len(rets) != 2 {
-		return reflect.Value{}, errors.New("invalid number of return values")
-	}
-	// check if the function returns an error
-	errRet := rets[1]
-	if !errRet.IsNil() {
-		// the second return value must be an error
+	// call the function, the arguments are converted to the parameter types by expr.CallFunc
+	return expr.CallFunc(fn, args)
+}
 
-		// we need to check if the second return value implements the error interface
+var errInvalidSelectorExpr = errors.New("invalid selector expression")
 
-		// try to convert the second return value to error
-		if ok := errRet.Type().Implements(errType); ok {
-			// I believe this is always true
-			return reflect.Value{}, errRet.Interface().(error)
+// nullSafeZero returns the zero value to select from if the value is nil,
+// which is what the null-safe selector x?.Name selects from.
+// The zero value is invalid if the type of the value is unknown, like a nil interface.
+func nullSafeZero(value reflect.Value) (zero reflect.Value, isNil bool) {
+	for {
+		switch value.Kind() {
+		case reflect.Invalid:
+			return reflect.Value{}, true
+		case reflect.Interface:
+			if value.IsNil() {
+				return reflect.Value{}, true
+			}
+			value = value.Elem()
+		case reflect.Ptr:
+			if value.IsNil() {
+				elem := value.Type().Elem()
+				for elem.Kind() == reflect.Ptr {
+					elem = elem.Elem()
+				}
+				return reflect.Zero(elem), true
+			}
+			value = value.Elem()
+		default:
+			return reflect.Value{}, false
 		}
-		// this should never happen, but just in case
-		// should I mark it unreachable?
-		return reflect.Value{}, errors.New("cannot convert return value to error")
 	}
-	return rets[0], nil
 }
 
-var errInvalidSelectorExpr = errors.New("invalid selector expression")
-
 func evalSelectorExpr(exp *ast.SelectorExpr, params Parameter) (reflect.Value, error) {
 	if exp.Sel == nil {
 		return reflect.Value{}, errInvalidSelectorExpr
 	}
 
-	fieldOrTagOrMethodName := exp.Sel.Name
+	fieldOrTagOrMethodName, nullSafe := strings.CutPrefix(exp.Sel.Name, nullSafePrefix)
 
 	if len(fieldOrTagOrMethodName) == 0 {
 		return reflect.Value{}, errInvalidSelectorExpr
@@ -331,6 +488,17 @@ func evalSelectorExpr(exp *ast.SelectorExpr, params Parameter) (reflect.Value, e
 		return reflect.Value{}, err
 	}
 
+	if nullSafe {
+		if zero, isNil := nullSafeZero(x); isNil {
+			if !zero.IsValid() {
+				// nothing is known about the type, the whole selector is nil.
+				return reflect.Value{}, nil
+			}
+			// select from the zero value, so the result is the zero value of the field.
+			x = zero
+		}
+	}
+
 	unwarned := reflectlite.Unwrap(x)
 
 	// check if the field name is exported
@@ -364,17 +532,23 @@ func evalSelectorExpr(exp *ast.SelectorExpr, params Parameter) (reflect.Value, e
 			}
 		}
 	case reflect.Map:
-		result = unwarned.MapIndex(reflect.ValueOf(fieldOrTagOrMethodName))
+		if unwarned.Type().Key().Kind() == reflect.String {
+			result, _ = mapParameter{Value: unwarned, foldKeys: IsFoldKeys(params)}.Get(fieldOrTagOrMethodName)
+		} else {
+			result = unwarned.MapIndex(reflect.ValueOf(fieldOrTagOrMethodName))
+		}
+		if !result.IsValid() && nullSafe {
+			result = reflect.Zero(unwarned.Type().Elem())
+		}
 		// select expression does not support get default value from map
 		// it might be ambiguous with calling a method
-	default:
-		return reflect.Value{}, fmt.Errorf("invalid selector expression: %s", fieldOrTagOrMethodName)
 	}
 
-	// try to find method from the type
-	if isExported && x.NumMethod() > 0 {
-		// use x directly, in case x is a pointer
-		result = x.MethodByName(fieldOrTagOrMethodName)
+	// try to find method from the type, like user.IsAdmin() or t.Before(now)
+	if isExported {
+		if method := methodByName(x, fieldOrTagOrMethodName); method.IsValid() {
+			result = method
+		}
 	}
 
 	// we failed to find the field
@@ -386,15 +560,91 @@ func evalSelectorExpr(exp *ast.SelectorExpr, params Parameter) (reflect.Value, e
 	return result, nil
 }
 
+// methodByName returns the exported method of the value with the given name, which can be called by the expressions,
+// see callableMethod. The methods of the pointer receiver are found for the values which are not addressable,
+// like the structs in a map, by calling them on a copy.
+func methodByName(x reflect.Value, name string) reflect.Value {
+	if method := findMethod(x, name); method.IsValid() && callableMethod(method.Type()) {
+		return method
+	}
+	return reflect.Value{}
+}
+
+// timeType is the type of time.Time.
+var timeType = reflect.TypeFor[time.Time]()
+
+// callableMethod reports whether the method can be called by the expressions, which returns a single value
+// optionally followed by an error, and takes no arguments or the simple ones, like the booleans, the numbers,
+// the strings and the times. So the methods taking the slices, the maps, the functions or the other objects
+// are not reachable.
+func callableMethod(method reflect.Type) bool {
+	switch {
+	case method.IsVariadic():
+		return false
+	case method.NumOut() == 2 && method.Out(1) == errType:
+	case method.NumOut() != 1:
+		return false
+	}
+	for i := 0; i < method.NumIn(); i++ {
+		switch in := method.In(i); in.Kind() {
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+		default:
+			if in != timeType {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// findMethod returns the exported method of the value with the given name.
+func findMethod(x reflect.Value, name string) reflect.Value {
+	for x.Kind() == reflect.Interface && !x.IsNil() {
+		x = x.Elem()
+	}
+	if !x.IsValid() || x.Kind() == reflect.Interface {
+		return reflect.Value{}
+	}
+	if method := x.MethodByName(name); method.IsValid() || x.Kind() == reflect.Pointer {
+		return method
+	}
+	if x.CanAddr() {
+		return x.Addr().MethodByName(name)
+	}
+	ptr := reflect.New(x.Type())
+	ptr.Elem().Set(x)
+	return ptr.MethodByName(name)
+}
+
+// evalCallee evaluates the function of a call expression, the name called is looked up
+// in the functions registered before the parameters, so len(items) calls len even if
+// there is a parameter named len.
+func evalCallee(exp ast.Expr, params Parameter) (reflect.Value, error) {
+	if ident, ok := exp.(*ast.Ident); ok {
+		if fn, ok := expr.LookupFunc(ident.Name); ok {
+			return fn, nil
+		}
+	}
+	return eval(exp, params)
+}
+
+// evalIdent evaluates an identifier, the parameters are looked up before the functions registered,
+// so a parameter named like a function, like str or int, is not shadowed by the function.
+// The builtin constants true, false and nil are reserved.
 func evalIdent(exp *ast.Ident, params Parameter) (reflect.Value, error) {
 	if fn, ok := builtins[exp.Name]; ok {
 		return fn, nil
 	}
-	value, ok := params.Get(exp.Name)
-	if !ok {
-		return reflect.Value{}, fmt.Errorf("undefined identifier: %s", exp.Name)
+	if value, ok := params.Get(exp.Name); ok {
+		return value, nil
 	}
-	return value, nil
+	if fn, ok := expr.LookupFunc(exp.Name); ok {
+		return fn, nil
+	}
+	return reflect.Value{}, fmt.Errorf("undefined identifier: %s", exp.Name)
 }
 
 var errUnsupportedBasicLiteral = errors.New("unsupported basic literal")
@@ -404,6 +654,10 @@ func evalBasicLit(exp *ast.BasicLit) (reflect.Value, error) {
 	case token.INT:
 		value, err := strconv.ParseInt(exp.Value, 10, 64)
 		if err != nil {
+			// the literal overflowing int64 is a big.Int.
+			if bigValue, ok := new(big.Int).SetString(exp.Value, 10); ok {
+				return reflect.ValueOf(bigValue), nil
+			}
 			return reflect.Value{}, err
 		}
 		return reflect.ValueOf(value), nil
@@ -414,7 +668,13 @@ func evalBasicLit(exp *ast.BasicLit) (reflect.Value, error) {
 		}
 		return reflect.ValueOf(value), nil
 	case token.STRING, token.CHAR:
-		return reflect.ValueOf(exp.Value[1 : len(exp.Value)-1]), nil
+		// the escapes like \n, \" and \u00e9 are decoded, the raw strings are kept as they are,
+		// and the rune literals like 'a' are strings too.
+		value, err := strconv.Unquote(exp.Value)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid string literal %s: %w", exp.Value, err)
+		}
+		return reflect.ValueOf(value), nil
 	default:
 		return reflect.Value{}, errUnsupportedBasicLiteral
 	}
@@ -446,6 +706,9 @@ func evalBinaryExpr(exp *ast.BinaryExpr, params Parameter) (reflect.Value, error
 	if lhs.Kind() == reflect.Func {
 		return evalFunc(lhs, exp, params), nil
 	}
+	if (exp.Op == token.LAND || exp.Op == token.LOR) && IsTruthy(params) {
+		return evalTruthyLogic(exp, lhs, params)
+	}
 	binaryExprExecutor, err := expr.FromToken(exp.Op)
 	if err != nil {
 		return reflect.Value{}, err
@@ -454,8 +717,69 @@ func evalBinaryExpr(exp *ast.BinaryExpr, params Parameter) (reflect.Value, error
 	x := func() (reflect.Value, error) { return lhs, nil }
 
 	// for lazy evaluation
-	y := func() (reflect.Value, error) { return eval(exp.Y, params) }
-	return binaryExprExecutor.Exec(x, y)
+	var rhs reflect.Value
+	y := func() (value reflect.Value, err error) {
+		rhs, err = eval(exp.Y, params)
+		return rhs, err
+	}
+	result, err := binaryExprExecutor.Exec(x, y)
+	if err != nil {
+		var exprError *ExprError
+		if errors.As(err, &exprError) {
+			return reflect.Value{}, err
+		}
+		return reflect.Value{}, &ExprError{
+			Err:    err,
+			Left:   operandKind(lhs),
+			Right:  operandKind(rhs),
+			pos:    exp.Pos(),
+			end:    exp.End(),
+			binary: true,
+		}
+	}
+	return result, nil
+}
+
+// evalTruthyLogic evaluates && and || in truthy mode, where the operands are coerced by expr.Truthy.
+// The right operand is only evaluated if the left one does not decide the result.
+// The || with a string on the left and a string literal on either side is still the concatenation,
+// like '%' || name || '%', while empty || age is the logical or.
+func evalTruthyLogic(exp *ast.BinaryExpr, lhs reflect.Value, params Parameter) (reflect.Value, error) {
+	if exp.Op == token.LOR && reflectlite.Unwrap(lhs).Kind() == reflect.String && (isStringLit(exp.X) || isStringLit(exp.Y)) {
+		x := func() (reflect.Value, error) { return lhs, nil }
+		y := func() (reflect.Value, error) { return eval(exp.Y, params) }
+		return expr.LORExprExecutor{}.Exec(x, y)
+	}
+	left := expr.Truthy(lhs)
+	if exp.Op == token.LAND && !left || exp.Op == token.LOR && left {
+		return reflect.ValueOf(left), nil
+	}
+	rhs, err := eval(exp.Y, params)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(expr.Truthy(rhs)), nil
+}
+
+// isStringLit reports whether the expression is a string literal, the parentheses are ignored.
+func isStringLit(exp ast.Expr) bool {
+	for {
+		paren, ok := exp.(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		exp = paren.X
+	}
+	lit, ok := exp.(*ast.BasicLit)
+	return ok && lit.Kind == token.STRING
+}
+
+// operandKind returns the kind of the operand, the interfaces are unwrapped to their dynamic values.
+func operandKind(v reflect.Value) reflect.Kind {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v.Kind()
 }
 
 // StaticExprOptimizer is used to optimize static expressions at compile time
@@ -466,6 +790,9 @@ func (s *StaticExprOptimizer) isStaticExpr(exp ast.Expr) bool {
 	switch exp := exp.(type) {
 	case *ast.BasicLit:
 		return true
+	case *ast.Ident:
+		// the builtin constants can not be shadowed by the parameters.
+		return isConstantIdent(exp)
 	case *ast.BinaryExpr:
 		return s.isStaticExpr(exp.X) && s.isStaticExpr(exp.Y)
 	case *ast.ParenExpr:
@@ -477,12 +804,71 @@ func (s *StaticExprOptimizer) isStaticExpr(exp ast.Expr) bool {
 	}
 }
 
-// Optimize optimizes static expressions by evaluating them at compile time
+// isConstantIdent reports whether the identifier is one of the builtin constants true, false and nil.
+func isConstantIdent(exp *ast.Ident) bool {
+	switch exp.Name {
+	case "true", "false", "nil":
+		return true
+	default:
+		return false
+	}
+}
+
+// Optimize optimizes static expressions by evaluating them at compile time.
+// The static sub expressions of a dynamic expression are folded as well,
+// like 1 == 1 in id > 0 && 1 == 1, unless they fail to evaluate, which are reported at runtime
+// if they are reached, like the right side of a short-circuited ||.
 func (s *StaticExprOptimizer) Optimize(exp ast.Expr, params Parameter) (ast.Expr, error) {
-	if !s.isStaticExpr(exp) {
-		return exp, nil
+	if s.isStaticExpr(exp) {
+		return s.fold(exp, params)
+	}
+	s.foldChildren(exp, params)
+	return exp, nil
+}
+
+// foldChildren folds the static sub expressions of the expression in place.
+func (s *StaticExprOptimizer) foldChildren(exp ast.Expr, params Parameter) {
+	optimize := func(exp ast.Expr) ast.Expr {
+		if !s.isStaticExpr(exp) {
+			s.foldChildren(exp, params)
+			return exp
+		}
+		folded, err := s.fold(exp, params)
+		if err != nil {
+			return exp
+		}
+		return folded
+	}
+	switch exp := exp.(type) {
+	case *ast.BinaryExpr:
+		exp.X, exp.Y = optimize(exp.X), optimize(exp.Y)
+	case *ast.ParenExpr:
+		exp.X = optimize(exp.X)
+	case *ast.UnaryExpr:
+		exp.X = optimize(exp.X)
+	case *ast.CallExpr:
+		for i, arg := range exp.Args {
+			exp.Args[i] = optimize(arg)
+		}
+	case *ast.IndexExpr:
+		exp.X, exp.Index = optimize(exp.X), optimize(exp.Index)
+	case *ast.SliceExpr:
+		exp.X = optimize(exp.X)
+		for _, index := range []*ast.Expr{&exp.Low, &exp.High, &exp.Max} {
+			if *index != nil {
+				*index = optimize(*index)
+			}
+		}
+	case *ast.SelectorExpr:
+		exp.X = optimize(exp.X)
+	case *ast.StarExpr:
+		exp.X = optimize(exp.X)
 	}
+}
 
+// fold evaluates the static expression and replaces it with the literal of the result.
+// The literal keeps the position of the expression for the error reports.
+func (s *StaticExprOptimizer) fold(exp ast.Expr, params Parameter) (ast.Expr, error) {
 	// Evaluate the static expression
 	value, err := eval(exp, params)
 	if err != nil {
@@ -492,26 +878,30 @@ func (s *StaticExprOptimizer) Optimize(exp ast.Expr, params Parameter) (ast.Expr
 	// Convert the evaluation result to the corresponding literal expression
 	switch value.Kind() {
 	case reflect.Bool:
-		return &ast.Ident{Name: strconv.FormatBool(value.Bool())}, nil
+		return &ast.Ident{NamePos: exp.Pos(), Name: strconv.FormatBool(value.Bool())}, nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return &ast.BasicLit{
-			Kind:  token.INT,
-			Value: strconv.FormatInt(value.Int(), 10),
+			ValuePos: exp.Pos(),
+			Kind:     token.INT,
+			Value:    strconv.FormatInt(value.Int(), 10),
 		}, nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return &ast.BasicLit{
-			Kind:  token.INT,
-			Value: strconv.FormatUint(value.Uint(), 10),
+			ValuePos: exp.Pos(),
+			Kind:     token.INT,
+			Value:    strconv.FormatUint(value.Uint(), 10),
 		}, nil
 	case reflect.Float32, reflect.Float64:
 		return &ast.BasicLit{
-			Kind:  token.FLOAT,
-			Value: strconv.FormatFloat(value.Float(), 'f', -1, 64),
+			ValuePos: exp.Pos(),
+			Kind:     token.FLOAT,
+			Value:    strconv.FormatFloat(value.Float(), 'f', -1, 64),
 		}, nil
 	case reflect.String:
 		return &ast.BasicLit{
-			Kind:  token.STRING,
-			Value: strconv.Quote(value.String()),
+			ValuePos: exp.Pos(),
+			Kind:     token.STRING,
+			Value:    strconv.Quote(value.String()),
 		}, nil
 	default:
 		return exp, nil
@@ -21,6 +21,10 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"unicode"
+
+	"github.com/go-juicedev/juice/eval/expr"
+	"github.com/go-juicedev/juice/internal/reflectlite"
 )
 
 // return the length of the string or array
@@ -87,7 +91,7 @@ func strJoin(v any, sep string) (string, error) {
 	return "", errors.New("join: invalid argument type")
 }
 
-// contains returns true if the value is in the array or string.
+// contains returns true if the value is in the array or string, or is a key of the map.
 func contains(s any, v any) (bool, error) {
 	switch t := s.(type) {
 	case string:
@@ -99,9 +103,16 @@ func contains(s any, v any) (bool, error) {
 	default:
 		rv := reflect.Indirect(reflect.ValueOf(s))
 		switch rv.Kind() {
-		case reflect.Array, reflect.Slice, reflect.Map:
+		case reflect.Array, reflect.Slice:
 			for i := 0; i < rv.Len(); i++ {
-				if rv.Index(i).Interface() == v {
+				if equalValue(rv.Index(i), v) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case reflect.Map:
+			for _, key := range rv.MapKeys() {
+				if equalValue(key, v) {
 					return true, nil
 				}
 			}
@@ -112,6 +123,66 @@ func contains(s any, v any) (bool, error) {
 	return false, errors.New("contains: invalid argument type")
 }
 
+// equalValue reports whether the element equals to the value.
+// The integers and floats are compared by their values, so that the literals
+// in the expressions match the elements of any numeric type.
+func equalValue(element reflect.Value, v any) bool {
+	element = reflectlite.Unwrap(element)
+	value := reflect.ValueOf(v)
+	if !element.IsValid() || !value.IsValid() {
+		return !element.IsValid() && !value.IsValid()
+	}
+	if result, err := (expr.GenericOperator{OperatorExpr: expr.Eq}).Operate(element, value); err == nil {
+		return result.Bool()
+	}
+	if element.Comparable() && value.Comparable() {
+		return element.Interface() == v
+	}
+	return false
+}
+
+// startsWith returns true if the string begins with the prefix.
+func startsWith(text, prefix string) (bool, error) {
+	return strings.HasPrefix(text, prefix), nil
+}
+
+// endsWith returns true if the string ends with the suffix.
+func endsWith(text, suffix string) (bool, error) {
+	return strings.HasSuffix(text, suffix), nil
+}
+
+// matches reports whether the string contains any match of the regular expression,
+// like matches(email, "^[^@]+@[^@]+$"). The nil value matches nothing.
+// The compiled patterns are cached.
+func matches(v any, pattern string) (bool, error) {
+	var text string
+	switch t := v.(type) {
+	case nil:
+		return false, nil
+	case string:
+		text = t
+	case []byte:
+		text = string(t)
+	case fmt.Stringer:
+		text = t.String()
+	default:
+		rv := reflectlite.Unwrap(reflect.ValueOf(v))
+		switch {
+		case !rv.IsValid():
+			return false, nil
+		case rv.Kind() == reflect.String:
+			text = rv.String()
+		default:
+			return false, fmt.Errorf("matches: unsupported type %T", v)
+		}
+	}
+	re, err := patternCache.compile(pattern)
+	if err != nil {
+		return false, fmt.Errorf("matches: %w", err)
+	}
+	return re.MatchString(text), nil
+}
+
 // slice returns a slice of the array or string.
 func slice(v any, start, count int) ([]any, error) {
 	rv := reflect.Indirect(reflect.ValueOf(v))
@@ -139,18 +210,30 @@ func upper(text string) (string, error) {
 }
 
 // trim returns a slice of the string s with all leading and trailing Unicode code points contained in cutset removed.
-func trim(text, cutest string) (string, error) {
-	return strings.Trim(text, cutest), nil
+// The white spaces are removed if the cutset is not given.
+func trim(text string, cutset ...string) (string, error) {
+	if len(cutset) == 0 {
+		return strings.TrimSpace(text), nil
+	}
+	return strings.Trim(text, strings.Join(cutset, "")), nil
 }
 
 // trimLeft returns a slice of the string s with all leading Unicode code points contained in cutset removed.
-func trimLeft(text, cutest string) (string, error) {
-	return strings.TrimLeft(text, cutest), nil
+// The white spaces are removed if the cutset is not given.
+func trimLeft(text string, cutset ...string) (string, error) {
+	if len(cutset) == 0 {
+		return strings.TrimLeftFunc(text, unicode.IsSpace), nil
+	}
+	return strings.TrimLeft(text, strings.Join(cutset, "")), nil
 }
 
 // trimRight returns a slice of the string s with all trailing Unicode code points contained in cutset removed.
-func trimRight(text, cutest string) (string, error) {
-	return strings.TrimRight(text, cutest), nil
+// The white spaces are removed if the cutset is not given.
+func trimRight(text string, cutset ...string) (string, error) {
+	if len(cutset) == 0 {
+		return strings.TrimRightFunc(text, unicode.IsSpace), nil
+	}
+	return strings.TrimRight(text, strings.Join(cutset, "")), nil
 }
 
 // replace returns a copy of the string s with the first n non-overlapping instances of old replaced by new.
@@ -182,9 +265,32 @@ func splitAfter(text, sep string) ([]string, error) {
 	return strings.SplitAfter(text, sep), nil
 }
 
+// str converts the value to a string, see expr.ToString, like '%' + str(code) + '%'.
+// It is registered as both str and string.
+func str(v any) (string, error) {
+	return expr.ToString(reflect.ValueOf(v))
+}
+
+// toInt converts the value to an int64, see expr.ToInt, like int("42").
+func toInt(v any) (int64, error) {
+	return expr.ToInt(reflect.ValueOf(v))
+}
+
+// toBool converts the value to a bool, see expr.ToBool, like bool("on").
+func toBool(v any) (bool, error) {
+	return expr.ToBool(reflect.ValueOf(v))
+}
+
+// toFloat converts the value to a float64, see expr.ToFloat, like float("1.5").
+func toFloat(v any) (float64, error) {
+	return expr.ToFloat(reflect.ValueOf(v))
+}
+
 // RegisterEvalFunc registers a function for eval.
-// The function must be a function with one return value.
+// The function must be a function with two return values, the last one is an error.
 // And Allowed to overwrite the built-in function.
+// It registers the function into the registry of the expr package, see expr.RegisterFunc
+// for the functions with one return value.
 func RegisterEvalFunc(name string, v any) error {
 	rv := reflect.Indirect(reflect.ValueOf(v))
 	if rv.Kind() != reflect.Func {
@@ -197,8 +303,7 @@ func RegisterEvalFunc(name string, v any) error {
 	if !rv.Type().Out(rv.Type().NumOut() - 1).Implements(errType) {
 		return errors.New("RegisterEvalFunc: v must be a function with an error return value")
 	}
-	builtins[name] = rv
-	return nil
+	return expr.RegisterFunc(name, rv.Interface())
 }
 
 // MustRegisterEvalFunc registers a function for eval.
@@ -213,7 +318,7 @@ func MustRegisterEvalFunc(name string, v any) {
 // errType is the reflect.Type of error.
 var errType = reflect.TypeOf((*error)(nil)).Elem()
 
-// builtins is a map of built-in functions.
+// builtins is a map of built-in identifiers.
 var builtins = map[string]reflect.Value{}
 
 var (
@@ -235,6 +340,9 @@ func init() {
 	MustRegisterEvalFunc("substr", strSub)
 	MustRegisterEvalFunc("join", strJoin)
 	MustRegisterEvalFunc("contains", contains)
+	MustRegisterEvalFunc("startsWith", startsWith)
+	MustRegisterEvalFunc("endsWith", endsWith)
+	MustRegisterEvalFunc("matches", matches)
 	MustRegisterEvalFunc("slice", slice)
 	MustRegisterEvalFunc("lower", lower)
 	MustRegisterEvalFunc("upper", upper)
@@ -246,4 +354,9 @@ func init() {
 	MustRegisterEvalFunc("split", split)
 	MustRegisterEvalFunc("splitN", splitN)
 	MustRegisterEvalFunc("splitAfter", splitAfter)
+	MustRegisterEvalFunc("str", str)
+	MustRegisterEvalFunc("string", str)
+	MustRegisterEvalFunc("bool", toBool)
+	MustRegisterEvalFunc("int", toInt)
+	MustRegisterEvalFunc("float", toFloat)
 }
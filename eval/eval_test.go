@@ -1,9 +1,18 @@
 package eval
 
 import (
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"go/ast"
 	"go/parser"
+	"math/big"
 	"reflect"
+	"slices"
 	"testing"
+	"time"
+
+	"github.com/go-juicedev/juice/eval/expr"
 )
 
 func testEval(expr string, v any) (result reflect.Value, err error) {
@@ -79,6 +88,40 @@ func BenchmarkEval(b *testing.B) {
 	// BenchmarkEval-8   	 1047154	      1111 ns/op
 }
 
+// BenchmarkEval_Uncached compiles the expression every time, like Eval did before the compiled expressions were cached.
+func BenchmarkEval_Uncached(b *testing.B) {
+	p := NewGenericParam(H{"id": 1, "age": 18, "name": "eatmoreapple"}, "")
+	compiler := new(goExprCompiler)
+	for i := 0; i < b.N; i++ {
+		expression, err := compiler.Compile(`id > 0 && id < 2 && name == "eatmoreapple"`)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err = expression.Execute(p); err != nil {
+			b.Fatal(err)
+		}
+	}
+	// BenchmarkEval            671869	      2192 ns/op	     928 B/op	      22 allocs/op
+	// BenchmarkEval_Uncached   253576	      4875 ns/op	    2440 B/op	      49 allocs/op
+}
+
+func TestCompile_Cached(t *testing.T) {
+	first, err := Compile(`id > 0 && name != ""`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Compile(`id > 0 && name != ""`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("expected the compiled expression to be cached")
+	}
+	if _, err = Compile(`id >`); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
+
 func BenchmarkEval2(b *testing.B) {
 	param := H{
 		"id":   1,
@@ -310,6 +353,216 @@ func TestUnaryExpr2(t *testing.T) {
 	}
 }
 
+func TestUnaryExprOperands(t *testing.T) {
+	param := H{"offset": 3, "price": 1.5, "size": uint8(2), "enabled": true, "big": big.NewInt(7)}
+	for expression, want := range map[string]bool{
+		"offset > -1":                 true,
+		"-offset == -3":               true,
+		"+offset == 3":                true,
+		"-price < 0":                  true,
+		"-size == -2":                 true,
+		"^offset == -4":               true,
+		"!enabled == false":           true,
+		"-big == -7":                  true,
+		"-(offset - 5) == 2":          true,
+		"offset > -1 and -1 < offset": true,
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Bool() != want {
+			t.Errorf("%s: expected %v", expression, want)
+		}
+	}
+	for _, expression := range []string{`-"a"`, "^price", "!offset", "-missing", "-nil"} {
+		if _, err := testEval(expression, H{"missing": nil}); err == nil {
+			t.Errorf("%s: expected error", expression)
+		}
+	}
+}
+
+func TestTruthyMode(t *testing.T) {
+	var none *int
+	one := 1
+	param := H{"name": "juice", "empty": "", "age": 0, "tags": []string{"a"}, "none": none, "one": &one}
+	for expression, want := range map[string]bool{
+		"name and tags":     true,
+		"name && age":       false,
+		"empty || age":      false,
+		"empty || one":      true,
+		"!none":             true,
+		"not empty":         true,
+		"one || missing()":  true,
+		"age and missing()": false,
+	} {
+		result, err := Eval(expression, WithTruthy(NewGenericParam(param, "")))
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Bool() != want {
+			t.Errorf("%s: expected %v", expression, want)
+		}
+	}
+	// the || with a string literal is the concatenation.
+	for expression, want := range map[string]string{
+		"'%' || name || '%'":   "%juice%",
+		"name || '%'":          "juice%",
+		"('%' || empty) + '_'": "%_",
+	} {
+		result, err := Eval(expression, WithTruthy(NewGenericParam(param, "")))
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Kind() != reflect.String || result.String() != want {
+			t.Errorf("%s: expected %q, got %v", expression, want, result)
+		}
+	}
+	// without truthy mode, the operands must be bool.
+	if _, err := testEval("name and tags", param); err == nil {
+		t.Error("expected error without truthy mode")
+	}
+	group := ParamGroup{H{"item": 1}.AsParam(), WithTruthy(H{}.AsParam())}
+	if !IsTruthy(group) {
+		t.Error("expected the group to inherit the truthy mode")
+	}
+}
+
+func TestExpressionLimits(t *testing.T) {
+	slow := func() int {
+		time.Sleep(5 * time.Millisecond)
+		return 1
+	}
+	param := H{"a": 1, "slow": slow}.AsParam()
+	for _, tc := range []struct {
+		expr   string
+		limits Limits
+	}{
+		{"((((a))))", Limits{MaxDepth: 3}},
+		{`len("a") + len("b") + len("c")`, Limits{MaxCalls: 2}},
+		{"slow() + slow() + a", Limits{Timeout: time.Millisecond}},
+		{"a + a + a + a", Limits{MaxNodes: 5}},
+	} {
+		_, err := Eval(tc.expr, WithLimits(param, tc.limits))
+		if !errors.Is(err, ErrExpressionLimitExceeded) {
+			t.Errorf("%s: expected ErrExpressionLimitExceeded, got %v", tc.expr, err)
+		}
+		if _, err = Eval(tc.expr, param); err != nil {
+			t.Errorf("%s: unexpected error without limits: %v", tc.expr, err)
+		}
+	}
+	result, err := Eval(`len("a") + a`, WithLimits(param, Limits{MaxDepth: 10, MaxCalls: 1, Timeout: time.Second}))
+	if err != nil || result.Int() != 2 {
+		t.Errorf("unexpected result: %v %v", result, err)
+	}
+	// the nested parameters and the truthy mode keep the limits.
+	group := ParamGroup{H{"item": 1}.AsParam(), WithTruthy(WithLimits(param, Limits{MaxCalls: 1}))}
+	if _, err = Eval(`len("a") + len("b")`, group); !errors.Is(err, ErrExpressionLimitExceeded) {
+		t.Errorf("expected the group to inherit the limits, got %v", err)
+	}
+	var limitError *LimitError
+	if _, err = Eval("((a))", WithLimits(param, Limits{MaxDepth: 2})); !errors.As(err, &limitError) || limitError.Limit != LimitMaxDepth || limitError.Actual != 3 {
+		t.Errorf("expected the depth limit error, got %v", err)
+	}
+	if _, err = Eval("a + a + a", WithLimits(param, Limits{MaxNodes: 4})); !errors.As(err, &limitError) || limitError.Limit != LimitMaxNodes {
+		t.Errorf("expected the nodes limit error, got %v", err)
+	}
+	SetDefaultLimits(Limits{MaxCalls: 1})
+	defer SetDefaultLimits(Limits{})
+	if _, err = Eval(`len("a") + len("b")`, param); !errors.Is(err, ErrExpressionLimitExceeded) {
+		t.Errorf("expected the default limits, got %v", err)
+	}
+}
+
+func TestWithOptions(t *testing.T) {
+	generic := &GenericParameter{Value: reflect.ValueOf(H{"user_name": "eat"}), FoldKeys: true}
+	hook := func(TraceStep) {}
+	param := WithTrace(WithLimits(WithTruthy(generic), Limits{MaxCalls: 1}), hook)
+	options := OptionsOf(param)
+	if !options.Truthy || !options.FoldKeys || options.Limits.MaxCalls != 1 || options.TraceHook == nil {
+		t.Fatalf("expected every option to be kept, got %+v", options)
+	}
+	if _, nested := param.(optionsParameter).Parameter.(optionsParameter); nested {
+		t.Error("expected the options to be attached once")
+	}
+	// the budget of the evaluation carries the options of its parameter.
+	if options = OptionsOf(newBudget(param, options.Limits)); options.Limits.MaxCalls != 1 || options.TraceHook == nil {
+		t.Errorf("expected the budget to carry the options, got %+v", options)
+	}
+}
+
+func TestStringLiteralEscapes(t *testing.T) {
+	param := H{"name": "it's", "quoted": `say "hi"`, "lines": "a\nb", "cafe": "café", "path": `C:\dir`, "code": "123"}
+	for _, expression := range []string{
+		`name == 'it\'s'`,
+		`name == "it's"`,
+		`quoted == 'say "hi"'`,
+		`quoted == "say \"hi\""`,
+		`lines == "a\nb"`,
+		`lines == 'a\nb'`,
+		`cafe == "caf\u00e9"`,
+		"path == `C:\\dir`",
+		`path == "C:\\dir"`,
+		`matches(code, "^\\d+$")`,
+		`'\'' + "s" == "'s"`,
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if !result.Bool() {
+			t.Errorf("%s: expected true", expression)
+		}
+	}
+	if _, err := testEval(`name == "\d"`, param); err == nil {
+		t.Error("expected error for the invalid escape")
+	}
+}
+
+func TestFoldKeys(t *testing.T) {
+	param := map[string]any{
+		"user_name": "juice",
+		"Age":       18,
+		"address":   map[string]any{"ZipCode": "10001"},
+		"id":        1,
+		"ID":        2,
+		"i_d":       3,
+	}
+	generic := &GenericParameter{Value: reflect.ValueOf(param), FoldKeys: true}
+	for expression, want := range map[string]bool{
+		`userName == "juice"`:         true,
+		`UserName == "juice"`:         true,
+		`age == 18`:                   true,
+		`address.zip_code == "10001"`: true,
+		// the exact keys take precedence.
+		`id == 1`: true,
+		`ID == 2`: true,
+	} {
+		result, err := Eval(expression, generic)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Bool() != want {
+			t.Errorf("%s: expected %v", expression, want)
+		}
+	}
+	// Id matches both id and ID case-insensitively, which is ambiguous.
+	if _, err := Eval("Id == 1", generic); err == nil {
+		t.Error("expected error for the ambiguous key")
+	}
+	if _, err := Eval("userName", NewGenericParam(param, "")); err == nil {
+		t.Error("expected error without folding the keys")
+	}
+	if !IsFoldKeys(ParamGroup{H{}.AsParam(), WithTruthy(generic)}) {
+		t.Error("expected the group to inherit the folding")
+	}
+}
+
 func TestIndexExprSlice(t *testing.T) {
 	param := H{
 		"a": []string{"eat", "more", "apple"},
@@ -925,6 +1178,49 @@ func TestStaticExprOptimizer(t *testing.T) {
 	}
 }
 
+func TestConstantFolding(t *testing.T) {
+	for expression, want := range map[string]bool{
+		"1 == 1":              true,
+		"not (1 > 2)":         true,
+		"true and 2 * 3 == 6": true,
+		"nil == nil":          true,
+	} {
+		compiled, err := new(goExprCompiler).Compile(expression)
+		if err != nil {
+			t.Fatalf("%s: %v", expression, err)
+		}
+		if !compiled.(*goExpression).isConstant {
+			t.Errorf("%s: expected a constant", expression)
+		}
+		// the constant is returned without the parameters.
+		result, err := compiled.Execute(nil)
+		if err != nil || result.Bool() != want {
+			t.Errorf("%s: unexpected result %v, %v", expression, result, err)
+		}
+	}
+
+	compiled, err := new(goExprCompiler).Compile("id > 0 and 1 + 1 == 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := compiled.(*goExpression)
+	if exp.isConstant {
+		t.Error("expected a dynamic expression")
+	}
+	if ident, ok := exp.Expr.(*ast.BinaryExpr).Y.(*ast.Ident); !ok || ident.Name != "true" {
+		t.Errorf("expected the static sub expression to be folded, got %T", exp.Expr.(*ast.BinaryExpr).Y)
+	}
+
+	// the failed sub expressions are kept to be reported at runtime if reached.
+	result, err := testEval(`id > 0 or 1 + "a" == 1`, H{"id": 1})
+	if err != nil || !result.Bool() {
+		t.Errorf("unexpected result %v, %v", result, err)
+	}
+	if _, err = testEval(`id > 1 or 1 + "a" == 1`, H{"id": 1}); err == nil {
+		t.Error("expected error of the reached sub expression")
+	}
+}
+
 func TestLexer_Tokenize(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -991,3 +1287,535 @@ func TestLexer_Tokenize(t *testing.T) {
 		})
 	}
 }
+
+func TestBitwise(t *testing.T) {
+	param := H{"flags": 6, "mask": uint8(3)}
+	for expr, want := range map[string]bool{
+		"flags & 4 != 0":           true,
+		"flags & 1 != 0":           false,
+		"(flags | 1) == 7":         true,
+		"(flags ^ 2) == 4":         true,
+		"(flags &^ 2) == 4":        true,
+		"1 << 3 == 8":              true,
+		"flags >> 1 == 3":          true,
+		"mask << 1 == mask + mask": true,
+	} {
+		result, err := testEval(expr, param)
+		if err != nil {
+			t.Errorf("%s: %v", expr, err)
+			continue
+		}
+		if result.Bool() != want {
+			t.Errorf("%s: expected %v", expr, want)
+		}
+	}
+}
+
+func TestRegisterFunc(t *testing.T) {
+	expr.MustRegisterFunc("isEmpty", func(v string) bool { return v == "" })
+	expr.MustRegisterFunc("inSet", func(v string, set ...string) bool { return slices.Contains(set, v) })
+	expr.MustRegisterFunc("double", func(v float64) (float64, error) { return v * 2, nil })
+
+	param := H{"name": "", "status": "B", "age": 9}
+	for expression, want := range map[string]bool{
+		"isEmpty(name)":             true,
+		"inSet(status, 'A', 'B')":   true,
+		"inSet(status)":             false,
+		"double(age) == 18.0":       true,
+		"!isEmpty(name) or age > 1": true,
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Bool() != want {
+			t.Errorf("%s: expected %v", expression, want)
+		}
+	}
+	if _, err := testEval("isEmpty(age)", param); err == nil {
+		t.Error("expected an error for the int argument")
+	}
+
+	expr.MustRegisterFunc("half", func(v int) int { return v / 2 })
+	if result, err := testEval("half(4.0)", param); err != nil || result.Int() != 2 {
+		t.Errorf("half(4.0): expected 2, got %v, %v", result, err)
+	}
+	if _, err := testEval("half(1.9)", param); err == nil {
+		t.Error("expected an error for the non-integral float argument")
+	}
+}
+
+func TestBuiltinHelpers(t *testing.T) {
+	param := H{"list": []int{1, 2, 3}, "name": "  juice  ", "tags": map[string]int{"a": 1}}
+	for expression, want := range map[string]bool{
+		"len(list) > 0":                     true,
+		"contains(list, 2)":                 true,
+		"contains(list, 4)":                 false,
+		"contains(tags, 'a')":               true,
+		`startsWith(trim(name), "ju")`:      true,
+		`endsWith(trim(name), "ice")`:       true,
+		`upper(trim(name)) == "JUICE"`:      true,
+		`lower("JUICE") == trim(name)`:      true,
+		`trim("--juice--", "-") == "juice"`: true,
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Bool() != want {
+			t.Errorf("%s: expected %v", expression, want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	param := H{"email": "juice@example.com", "code": []byte("A-42"), "none": nil}
+	for expression, want := range map[string]bool{
+		`matches(email, "^[^@]+@[^@]+$")`: true,
+		`matches(email, "^[0-9]+$")`:      false,
+		`matches(code, "^[A-Z]-[0-9]+$")`: true,
+		`matches(none, ".*")`:             false,
+		`!matches(email, "@test[.]com$")`: true,
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Bool() != want {
+			t.Errorf("%s: expected %v", expression, want)
+		}
+	}
+	if _, err := testEval(`matches(email, "[")`, param); err == nil {
+		t.Error("expected an error for the invalid pattern")
+	}
+	if _, err := testEval(`matches(1, ".*")`, param); err == nil {
+		t.Error("expected an error for the unsupported type")
+	}
+}
+
+func TestExprErrorDiagnostics(t *testing.T) {
+	param := H{"age": 18, "name": "juice", "list": []int{1}}
+	for expression, want := range map[string]ExprError{
+		`age > 1 and name + 1 > 0`: {Span: "name + 1", Offset: 12, Left: reflect.String, Right: reflect.Int64},
+		`age > 1 && list[2] == 1`:  {Span: "list[2]", Offset: 11},
+		`upper(missing) == ""`:     {Span: "missing", Offset: 6},
+		`age > 1 ? name + 1 : 0`:   {Span: "name + 1", Offset: -1, Left: reflect.String, Right: reflect.Int64},
+	} {
+		_, err := testEval(expression, param)
+		var exprError *ExprError
+		if !errors.As(err, &exprError) {
+			t.Errorf("%s: expected ExprError, got %v", expression, err)
+			continue
+		}
+		if exprError.Expr != expression || exprError.Span != want.Span || exprError.Offset != want.Offset {
+			t.Errorf("%s: unexpected position: %v", expression, exprError)
+		}
+		if exprError.Left != want.Left || exprError.Right != want.Right {
+			t.Errorf("%s: unexpected operands: %v", expression, exprError)
+		}
+	}
+	_, err := testEval(`age > 1 && list[2] == 1`, param)
+	if !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("expected ErrIndexOutOfRange, got %v", err)
+	}
+}
+
+func TestNullSafeSelector(t *testing.T) {
+	type Profile struct {
+		Age  int
+		Tags map[string]string
+	}
+	type User struct {
+		Profile *Profile
+	}
+	param := H{"user": User{}, "nilUser": (*User)(nil), "profile": Profile{}}
+	for expression, want := range map[string]bool{
+		"user?.Profile?.Age > 18":          false,
+		"user?.Profile?.Age == 0":          true,
+		"nilUser?.Profile == nil":          true,
+		"profile.Tags?.name == \"\"":       true,
+		"user.Profile?.Tags?.name == \"\"": true,
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Bool() != want {
+			t.Errorf("%s: expected %v", expression, want)
+		}
+	}
+	if _, err := testEval("user.Profile.Age > 18", param); err == nil {
+		t.Error("expected an error without the null-safe selector")
+	}
+	if _, err := testEval("user?", param); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
+
+func TestConditional(t *testing.T) {
+	type User struct {
+		Name string
+	}
+	param := H{"age": 20, "name": "", "nick": "eat", "user": (*User)(nil), "ids": []int{1, 2}}
+	for expression, want := range map[string]any{
+		`age > 18 ? "adult" : "minor"`:                    "adult",
+		`age > 30 ? "old" : age > 18 ? "adult" : "minor"`: "adult",
+		`(age < 18 ? 1 : 2) + 1`:                          int64(3),
+		`name ?: nick`:                                    "eat",
+		`nick ?: "anonymous"`:                             "eat",
+		`user != nil ? user.Name : "guest"`:               "guest",
+		`iif(len(ids) > 1, "many", "one")`:                "many",
+		`len(name ?: "ab")`:                               2,
+		`substr(name ?: "eat", 0, age > 18 ? 2 : 1)`:      "ea",
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if got := result.Interface(); got != want {
+			t.Errorf("%s: expected %v, got %v", expression, want, got)
+		}
+	}
+	for _, expression := range []string{`age ? 1 : 2`, `age > 1 ? 1`, `? 1 : 2`} {
+		if _, err := testEval(expression, param); err == nil {
+			t.Errorf("%s: expected an error", expression)
+		}
+	}
+
+	// the parameter named iif is called instead of the builtin, and the ternary is not affected by it.
+	param["iif"] = func(a, b, c int) int { return a + b + c }
+	for expression, want := range map[string]any{
+		`iif(1, 2, 3)`:         6,
+		`iif(true, 1, 2) > 0`:  nil,
+		`age > 18 ? "a" : "b"`: "a",
+	} {
+		result, err := testEval(expression, param)
+		if want == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error", expression)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if got := result.Interface(); got != want {
+			t.Errorf("%s: expected %v, got %v", expression, want, got)
+		}
+	}
+}
+
+func TestBigNumberLiteral(t *testing.T) {
+	balance, _ := new(big.Int).SetString("200000000000000000000", 10)
+	result, err := testEval("balance > 100000000000000000000 && 100000000000000000000 > 1", H{"balance": balance})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Bool() {
+		t.Error("expected true")
+	}
+}
+
+func TestIndexExprAccess(t *testing.T) {
+	type Item struct {
+		Price int
+	}
+	param := H{
+		"items": []Item{{Price: 10}},
+		"m":     map[string]*Item{"key": {Price: 1}},
+		"ids":   map[int]string{1: "one"},
+		"zero":  uint(0),
+	}
+	for expression, want := range map[string]bool{
+		"items[0].Price > 0":    true,
+		"m['key'] != nil":       true,
+		"m['missing'] == nil":   true,
+		`ids[1] == "one"`:       true,
+		`ids[2] == ""`:          true,
+		"items[zero].Price > 0": true,
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Bool() != want {
+			t.Errorf("%s: expected %v", expression, want)
+		}
+	}
+	for _, expression := range []string{"items[1].Price", "items[-1].Price", `items["0"]`, `ids["1"]`, "nothing[0]"} {
+		if _, err := testEval(expression, param); err == nil {
+			t.Errorf("%s: expected an error", expression)
+		}
+	}
+	if _, err := testEval("items[1]", param); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("expected ErrIndexOutOfRange, got %v", err)
+	}
+}
+
+type methodRole int
+
+func (r methodRole) IsAdmin() bool { return r == 1 }
+
+type methodUser struct {
+	Name string
+	Role methodRole
+}
+
+func (u methodUser) IsAdmin() bool { return u.Role.IsAdmin() }
+
+func (u *methodUser) HasName(name string) bool { return u.Name == name }
+
+func (u methodUser) Split() (string, string) { return u.Name, u.Name }
+
+func (u methodUser) HasAnyName(names []string) bool { return slices.Contains(names, u.Name) }
+
+func (u methodUser) HasNames(names ...string) bool { return slices.Contains(names, u.Name) }
+
+func (u *methodUser) Rename(name string) { u.Name = name }
+
+func TestMethodCall(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	param := H{
+		"user":    methodUser{Name: "eat", Role: 1},
+		"nilUser": (*methodUser)(nil),
+		"names":   []string{"eat"},
+		"t":       now.Add(-time.Hour),
+		"now":     now,
+	}
+	for expression, want := range map[string]bool{
+		"user.IsAdmin()":                  true,
+		"user.Role.IsAdmin()":             true,
+		`user.HasName("eat")`:             true,
+		`user.Name == "eat"`:              true,
+		"t.Before(now)":                   true,
+		"t.Add(3600000000000).Equal(now)": true,
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Bool() != want {
+			t.Errorf("%s: expected %v", expression, want)
+		}
+	}
+	for _, expression := range []string{
+		"nilUser.IsAdmin()", "user.Split()", "user.Missing()", "user.isAdmin()",
+		// the methods taking the other arguments or returning nothing can not be called.
+		"user.HasAnyName(names)", `user.HasNames("eat")`, `user.Rename("pillow")`,
+	} {
+		if _, err := testEval(expression, param); err == nil {
+			t.Errorf("%s: expected an error", expression)
+		}
+	}
+}
+
+type redactedParam struct {
+	Parameter
+	names []string
+}
+
+func (r redactedParam) Redacted(name string) bool { return slices.Contains(r.names, name) }
+
+func TestWithTrace(t *testing.T) {
+	var steps []TraceStep
+	param := WithTrace(redactedParam{Parameter: NewGenericParam(H{"age": 20, "password": "secret"}, ""), names: []string{"password"}}, func(step TraceStep) {
+		steps = append(steps, step)
+	})
+	value, err := Eval(`age >= 18 and password == ""`, param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.Bool() {
+		t.Fatal("expected false")
+	}
+	if len(steps) != 7 {
+		t.Fatalf("expected 7 steps, got %d: %+v", len(steps), steps)
+	}
+	last := steps[len(steps)-1]
+	if last.Expr != `age >= 18 and password == ""` || last.Depth != 0 || last.Result != false || len(last.Operands) != 2 {
+		t.Fatalf("unexpected last step: %+v", last)
+	}
+	if steps[0].Expr != "age" || steps[0].Depth != 2 || steps[0].Result != 20 {
+		t.Fatalf("unexpected first step: %+v", steps[0])
+	}
+	if steps[2].Expr != "age >= 18" || steps[2].Result != true || steps[2].Operands[0] != 20 {
+		t.Fatalf("unexpected step: %+v", steps[2])
+	}
+	if steps[3].Result != RedactedValue || steps[5].Operands[0] != RedactedValue {
+		t.Fatalf("expected the password to be redacted: %+v %+v", steps[3], steps[5])
+	}
+
+	steps = nil
+	if _, err = Eval("1 == 1", param); err != nil || len(steps) != 1 || steps[0].Result != true {
+		t.Fatalf("unexpected constant steps: %+v %v", steps, err)
+	}
+}
+
+func TestConversionFuncs(t *testing.T) {
+	param := H{"code": 42, "price": "19.90", "page": " 3 ", "ratio": 2.5, "flag": true, "name": "juice", "none": nil}
+	for expression, want := range map[string]any{
+		`'%' + str(code) + '%'`:      "%42%",
+		`'%' || code || '%'`:         "%42%",
+		`name || "-" || ratio`:       "juice-2.5",
+		`str(flag) + str(none)`:      "true",
+		`int(page) + 1`:              int64(4),
+		`int(ratio)`:                 int64(2),
+		`int(flag)`:                  int64(1),
+		`float(price) > 19.5`:        true,
+		`float(code) / 8`:            5.25,
+		`false || code == 42`:        true,
+		`int("9223372036854775807")`: int64(9223372036854775807),
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Interface() != want {
+			t.Errorf("%s: expected %v, got %v", expression, want, result.Interface())
+		}
+	}
+	for _, expression := range []string{`"a" + code`, `int(name)`, `int(none)`, `float("")`, `bool(name)`, `bool(none)`} {
+		if _, err := testEval(expression, param); err == nil {
+			t.Errorf("%s: expected error", expression)
+		}
+	}
+	if _, err := testEval(`int(name)`, param); !errors.Is(err, expr.ErrConversion) {
+		t.Errorf("expected ErrConversion, got %v", err)
+	}
+}
+
+func TestConversionFuncs_ShadowedByParams(t *testing.T) {
+	// the parameters are not shadowed by the functions of the same names,
+	// while the calls still call the functions.
+	param := H{"str": "x", "int": 7, "bool": false, "code": 42}
+	for expression, want := range map[string]any{
+		`str == "x"`:            true,
+		`int + 1`:               int64(8),
+		`!bool`:                 true,
+		`str(code) + str`:       "42x",
+		`int(str == "x") + int`: int64(8),
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if result.Interface() != want {
+			t.Errorf("%s: expected %v, got %v", expression, want, result.Interface())
+		}
+	}
+	expression, err := Compile(`str == "x" && int(code) > 0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := Identifiers(expression); !reflect.DeepEqual(names, []string{"str", "code"}) {
+		t.Errorf("unexpected identifiers: %v", names)
+	}
+	if literals := Literals(expression); !reflect.DeepEqual(literals, []any{"x", int64(0)}) {
+		t.Errorf("unexpected literals: %v", literals)
+	}
+	expression, err = Compile(`name == 'abc' or age > 1.5 or age == 0x10 or name == "abc"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if literals := Literals(expression); !reflect.DeepEqual(literals, []any{"abc", 1.5, int64(16)}) {
+		t.Errorf("unexpected literals: %v", literals)
+	}
+}
+
+func TestConversionFuncs_StringParams(t *testing.T) {
+	query := map[string]string{"page": "2", "size": "20", "active": "on", "deleted": "False", "price": "9.5"}
+	for _, expression := range []string{
+		`int(page) > 1`,
+		`int(page) * int(size) == 40`,
+		`bool(active) and !bool(deleted)`,
+		`float(price) < 10`,
+		`string(int(size) + 1) == "21"`,
+		`bool(1) and !bool(0.0)`,
+	} {
+		result, err := testEval(expression, query)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if !result.Bool() {
+			t.Errorf("%s: expected true", expression)
+		}
+	}
+}
+
+func TestItemParameter(t *testing.T) {
+	type user struct {
+		Name     string `param:"name"`
+		Password string `param:"password,redact"`
+	}
+	param := NewItemParameter("item", "i", true)
+	for i, item := range []any{user{Name: "a"}, map[string]any{"user_name": "b"}} {
+		param.Bind(reflect.ValueOf(item), reflect.ValueOf(i))
+		if index, ok := param.Get("i"); !ok || index.Int() != int64(i) {
+			t.Fatalf("unexpected index: %v", index)
+		}
+		if value, ok := param.Get("item"); !ok || !reflect.DeepEqual(value.Interface(), item) {
+			t.Fatalf("unexpected item: %v", value)
+		}
+	}
+	if value, ok := param.Get("item.userName"); !ok || value.Interface() != "b" {
+		t.Fatalf("expected the folded key, got %v", value)
+	}
+	param.Bind(reflect.ValueOf(user{Name: "a"}), reflect.ValueOf(0))
+	if value, ok := param.Get("item.name"); !ok || value.String() != "a" {
+		t.Fatalf("unexpected name: %v", value)
+	}
+	if !param.Redacted("item.password") || param.Redacted("item.name") || param.Redacted("password") {
+		t.Fatal("unexpected redaction")
+	}
+	if _, ok := param.Get("items"); ok {
+		t.Fatal("expected items not found")
+	}
+}
+
+type celsius struct{ degrees float64 }
+
+func (c *celsius) Value() (sqldriver.Value, error) { return c.degrees, nil }
+
+func TestValuerOperands(t *testing.T) {
+	param := H{
+		"name":    sql.NullString{String: "juice", Valid: true},
+		"age":     sql.NullInt64{Int64: 20, Valid: true},
+		"deleted": sql.NullBool{},
+		"nick":    sql.NullString{},
+		"score":   sql.Null[float64]{V: 9.5, Valid: true},
+		"temp":    &celsius{degrees: 36.6},
+	}
+	for _, expression := range []string{
+		`name == "juice"`,
+		`age > 18 and age + 1 == 21`,
+		`nick == nil and name != nil`,
+		`deleted == nil`,
+		`score * 2 == 19`,
+		`temp > 36`,
+		`len(name.String) == 5`,
+	} {
+		result, err := testEval(expression, param)
+		if err != nil {
+			t.Errorf("%s: %v", expression, err)
+			continue
+		}
+		if !result.Bool() {
+			t.Errorf("%s: expected true", expression)
+		}
+	}
+	result, err := Eval("name and !nick", WithTruthy(NewGenericParam(param, "")))
+	if err != nil || !result.Bool() {
+		t.Errorf("unexpected truthy result: %v %v", result, err)
+	}
+}
@@ -155,6 +155,8 @@ func (LANDExprExecutor) Exec(x, y func() (reflect.Value, error)) (reflect.Value,
 }
 
 // LORExprExecutor is the executor for ||
+// It concatenates the operands like SQL if the left one is a string, the right one is converted by ToString,
+// like '%' || code || '%'. Otherwise, it is the logical or.
 type LORExprExecutor struct{}
 
 // Exec execute the binary expression
@@ -165,6 +167,9 @@ func (LORExprExecutor) Exec(x, y func() (reflect.Value, error)) (reflect.Value,
 		return invalidValue, err
 	}
 	left = reflectlite.Unwrap(left)
+	if left.Kind() == reflect.String {
+		return concat(left, y)
+	}
 	if left.Kind() != reflect.Bool {
 		return invalidValue, fmt.Errorf("expected bool, got %v", left.Kind())
 	}
@@ -182,6 +187,19 @@ func (LORExprExecutor) Exec(x, y func() (reflect.Value, error)) (reflect.Value,
 	return right, nil
 }
 
+// concat concatenates the string with the right operand.
+func concat(left reflect.Value, y func() (reflect.Value, error)) (reflect.Value, error) {
+	right, err := y()
+	if err != nil {
+		return invalidValue, err
+	}
+	text, err := ToString(right)
+	if err != nil {
+		return invalidValue, err
+	}
+	return reflect.ValueOf(left.String() + text), nil
+}
+
 // ADDExprExecutor is the executor for +
 type ADDExprExecutor struct{}
 
@@ -279,23 +297,69 @@ func (NOTExprExecutor) Exec(_, y func() (reflect.Value, error)) (reflect.Value,
 	return reflect.ValueOf(!right.Bool()), nil
 }
 
-// ANDExprExecutor is the executor for &&
+// ANDExprExecutor is the executor for &
 type ANDExprExecutor struct{}
 
 // Exec execute the binary expression
 // implement BinaryExprExecutor interface
 func (ANDExprExecutor) Exec(x, y func() (reflect.Value, error)) (reflect.Value, error) {
-	executor := LANDExprExecutor{}
+	var operator = GenericOperator{OperatorExpr: And}
+	executor := OperatorExecutor{Operator: operator}
 	return executor.Exec(x, y)
 }
 
-// ORExprExecutor is the executor for ||
+// ORExprExecutor is the executor for |
 type ORExprExecutor struct{}
 
 // Exec execute the binary expression
 // implement BinaryExprExecutor interface
 func (ORExprExecutor) Exec(x, y func() (reflect.Value, error)) (reflect.Value, error) {
-	executor := LORExprExecutor{}
+	var operator = GenericOperator{OperatorExpr: Or}
+	executor := OperatorExecutor{Operator: operator}
+	return executor.Exec(x, y)
+}
+
+// XORExprExecutor is the executor for ^
+type XORExprExecutor struct{}
+
+// Exec execute the binary expression
+// implement BinaryExprExecutor interface
+func (XORExprExecutor) Exec(x, y func() (reflect.Value, error)) (reflect.Value, error) {
+	var operator = GenericOperator{OperatorExpr: Xor}
+	executor := OperatorExecutor{Operator: operator}
+	return executor.Exec(x, y)
+}
+
+// ANDNOTExprExecutor is the executor for &^
+type ANDNOTExprExecutor struct{}
+
+// Exec execute the binary expression
+// implement BinaryExprExecutor interface
+func (ANDNOTExprExecutor) Exec(x, y func() (reflect.Value, error)) (reflect.Value, error) {
+	var operator = GenericOperator{OperatorExpr: AndNot}
+	executor := OperatorExecutor{Operator: operator}
+	return executor.Exec(x, y)
+}
+
+// SHLExprExecutor is the executor for <<
+type SHLExprExecutor struct{}
+
+// Exec execute the binary expression
+// implement BinaryExprExecutor interface
+func (SHLExprExecutor) Exec(x, y func() (reflect.Value, error)) (reflect.Value, error) {
+	var operator = GenericOperator{OperatorExpr: Shl}
+	executor := OperatorExecutor{Operator: operator}
+	return executor.Exec(x, y)
+}
+
+// SHRExprExecutor is the executor for >>
+type SHRExprExecutor struct{}
+
+// Exec execute the binary expression
+// implement BinaryExprExecutor interface
+func (SHRExprExecutor) Exec(x, y func() (reflect.Value, error)) (reflect.Value, error) {
+	var operator = GenericOperator{OperatorExpr: Shr}
+	executor := OperatorExecutor{Operator: operator}
 	return executor.Exec(x, y)
 }
 
@@ -323,6 +387,10 @@ var binaryExprExecutors = map[token.Token]BinaryExprExecutor{
 	token.NOT:     NOTExprExecutor{},
 	token.AND:     ANDExprExecutor{},
 	token.OR:      ORExprExecutor{},
+	token.XOR:     XORExprExecutor{},
+	token.AND_NOT: ANDNOTExprExecutor{},
+	token.SHL:     SHLExprExecutor{},
+	token.SHR:     SHRExprExecutor{},
 }
 
 // FromToken returns the BinaryExprExecutor from the token
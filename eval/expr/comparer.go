@@ -0,0 +1,130 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expr
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Comparer compares the values of a custom type, like uuid.UUID or an enum, which can not be
+// compared by the kinds of the values. One of the values is of the registered type, the other
+// may be of any type, like a string literal, which the Comparer converts or rejects with an error.
+type Comparer interface {
+	// Compare returns a negative number if left is less than right, zero if they are equal,
+	// and a positive number if left is greater than right.
+	Compare(left, right reflect.Value) (int, error)
+}
+
+// ComparerFunc is an adapter to allow the use of ordinary functions as Comparer.
+type ComparerFunc func(left, right reflect.Value) (int, error)
+
+// Compare implements Comparer.
+func (f ComparerFunc) Compare(left, right reflect.Value) (int, error) { return f(left, right) }
+
+var comparers = struct {
+	mu sync.RWMutex
+	// types are the comparers of the concrete types.
+	types map[reflect.Type]Comparer
+	// interfaces are the comparers of the interface types, the latest registered first.
+	interfaces []interfaceComparer
+}{types: make(map[reflect.Type]Comparer)}
+
+// hasComparers reports whether any comparer is registered, so the comparisons of the programs
+// registering none do not take the lock.
+var hasComparers atomic.Bool
+
+// interfaceComparer is the comparer of the values implementing the interface type.
+type interfaceComparer struct {
+	typ      reflect.Type
+	comparer Comparer
+}
+
+// RegisterComparer registers the comparer of the type, which is consulted by ==, !=, <, <=, >, >=
+// before comparing the values by their kinds. The values are compared after the pointers are
+// unwrapped, so register the element type instead of the pointer type, like
+//
+//	expr.RegisterComparer(reflect.TypeOf(uuid.UUID{}), expr.ComparerFunc(compareUUID))
+//
+// If the type is an interface, the comparer is used for the values whose type or pointer type
+// implements it, the comparers of the concrete types take precedence.
+// The comparer registered for the same type is replaced.
+func RegisterComparer(typ reflect.Type, comparer Comparer) {
+	if typ == nil || comparer == nil {
+		panic("expr: comparer type or comparer is nil")
+	}
+	comparers.mu.Lock()
+	defer comparers.mu.Unlock()
+	hasComparers.Store(true)
+	if typ.Kind() != reflect.Interface {
+		comparers.types[typ] = comparer
+		return
+	}
+	interfaces := []interfaceComparer{{typ: typ, comparer: comparer}}
+	for _, registered := range comparers.interfaces {
+		if registered.typ != typ {
+			interfaces = append(interfaces, registered)
+		}
+	}
+	comparers.interfaces = interfaces
+}
+
+// lookupComparer returns the comparer of the type.
+func lookupComparer(typ reflect.Type) (Comparer, bool) {
+	comparers.mu.RLock()
+	defer comparers.mu.RUnlock()
+	if comparer, ok := comparers.types[typ]; ok {
+		return comparer, true
+	}
+	for _, registered := range comparers.interfaces {
+		if typ.Implements(registered.typ) || reflect.PointerTo(typ).Implements(registered.typ) {
+			return registered.comparer, true
+		}
+	}
+	return nil, false
+}
+
+// isComparison reports whether the operator is a comparison operator.
+func isComparison(operator OperatorExpr) bool {
+	switch operator {
+	case Eq, Ne, Lt, Le, Gt, Ge:
+		return true
+	default:
+		return false
+	}
+}
+
+// operateComparers compares the values by the comparer of the type of the left value,
+// or else the right value, it reports false if none of them has a comparer.
+func operateComparers(operator OperatorExpr, left, right reflect.Value) (reflect.Value, bool, error) {
+	if !hasComparers.Load() || !left.IsValid() || !right.IsValid() {
+		return invalidValue, false, nil
+	}
+	comparer, ok := lookupComparer(left.Type())
+	if !ok {
+		if comparer, ok = lookupComparer(right.Type()); !ok {
+			return invalidValue, false, nil
+		}
+	}
+	cmp, err := comparer.Compare(left, right)
+	if err != nil {
+		return invalidValue, true, err
+	}
+	result, err := compareResult(operator, cmp)
+	return result, true, err
+}
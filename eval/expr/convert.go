@@ -0,0 +1,202 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrConversion is returned when a value can not be converted by ToString, ToInt, ToFloat or ToBool.
+var ErrConversion = errors.New("invalid conversion")
+
+// ToString converts the value to a string:
+//   - nil is the empty string
+//   - the strings and the byte slices are themselves
+//   - the bools are "true" and "false"
+//   - the numbers are formatted in decimal, the floats without the trailing zeros
+//   - the other values are formatted by fmt, like the fmt.Stringer
+func ToString(value reflect.Value) (string, error) {
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer && value.Type().Elem().Kind() != reflect.Struct {
+		if value.IsNil() {
+			return "", nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Invalid:
+		return "", nil
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64), nil
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return string(value.Bytes()), nil
+		}
+	}
+	if !value.CanInterface() {
+		return "", fmt.Errorf("%w: %s to string", ErrConversion, value.Type())
+	}
+	return fmt.Sprint(value.Interface()), nil
+}
+
+// ToInt converts the value to an int64:
+//   - the bools are 1 and 0
+//   - the floats are truncated toward zero
+//   - the strings are parsed in decimal, the surrounding spaces are ignored
+//
+// The values out of the range of int64, nil and the other values can not be converted.
+func ToInt(value reflect.Value) (int64, error) {
+	value = unwrapConversion(value)
+	switch value.Kind() {
+	case reflect.Bool:
+		if value.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if value.Uint() <= math.MaxInt64 {
+			return int64(value.Uint()), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if f := math.Trunc(value.Float()); f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), nil
+		}
+	case reflect.String:
+		if i, err := strconv.ParseInt(strings.TrimSpace(value.String()), 10, 64); err == nil {
+			return i, nil
+		}
+	case reflect.Invalid:
+	default:
+		if i, ok := value.Interface().(*big.Int); ok && i != nil && i.IsInt64() {
+			return i.Int64(), nil
+		}
+	}
+	return 0, conversionError(value, "int")
+}
+
+// ToFloat converts the value to a float64:
+//   - the bools are 1 and 0
+//   - the strings are parsed, the surrounding spaces are ignored
+//
+// nil and the other values can not be converted.
+func ToFloat(value reflect.Value) (float64, error) {
+	value = unwrapConversion(value)
+	switch value.Kind() {
+	case reflect.Bool:
+		if value.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(value.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), nil
+	case reflect.String:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(value.String()), 64); err == nil {
+			return f, nil
+		}
+	case reflect.Invalid:
+	default:
+		switch number := value.Interface().(type) {
+		case *big.Int:
+			if number != nil {
+				f, _ := new(big.Float).SetInt(number).Float64()
+				return f, nil
+			}
+		case *big.Float:
+			if number != nil {
+				f, _ := number.Float64()
+				return f, nil
+			}
+		}
+	}
+	return 0, conversionError(value, "float")
+}
+
+// ToBool converts the value to a bool:
+//   - the numbers are true if non-zero
+//   - the strings are parsed case-insensitively, "1", "t", "true", "yes", "y" and "on" are true,
+//     "0", "f", "false", "no", "n" and "off" are false, the surrounding spaces are ignored
+//
+// nil and the other values can not be converted, see Truthy for the conversion of any value.
+func ToBool(value reflect.Value) (bool, error) {
+	value = unwrapConversion(value)
+	switch value.Kind() {
+	case reflect.Bool:
+		return value.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() != 0, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint() != 0, nil
+	case reflect.Float32, reflect.Float64:
+		return value.Float() != 0, nil
+	case reflect.String:
+		switch strings.ToLower(strings.TrimSpace(value.String())) {
+		case "1", "t", "true", "yes", "y", "on":
+			return true, nil
+		case "0", "f", "false", "no", "n", "off":
+			return false, nil
+		}
+	}
+	return false, conversionError(value, "bool")
+}
+
+// unwrapConversion unwraps the interfaces and the pointers to the basic values.
+// The pointers of the big numbers are kept, and the invalid value is returned for nil.
+func unwrapConversion(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer && value.Type().Elem().Kind() != reflect.Struct {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	if value.IsValid() && !value.CanInterface() {
+		return reflect.Value{}
+	}
+	return value
+}
+
+// conversionError returns the error of the value which can not be converted to the type.
+func conversionError(value reflect.Value, typ string) error {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return fmt.Errorf("%w: nil to %s", ErrConversion, typ)
+	case reflect.String:
+		return fmt.Errorf("%w: %q to %s", ErrConversion, value.String(), typ)
+	default:
+		return fmt.Errorf("%w: %s to %s", ErrConversion, value.Type(), typ)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+)
+
+// errType is the reflect.Type of error.
+var errType = reflect.TypeOf((*error)(nil)).Elem()
+
+// funcRegistry is the registry of the functions which can be called from the expressions.
+type funcRegistry struct {
+	mu    sync.RWMutex
+	funcs map[string]reflect.Value
+}
+
+// registry is the global function registry.
+var registry = &funcRegistry{funcs: make(map[string]reflect.Value)}
+
+// RegisterFunc registers a function which can be called from the expressions by the given name, like:
+//
+//	expr.RegisterFunc("inSet", func(v string, set ...string) bool { return slices.Contains(set, v) })
+//
+// and then used as `test="inSet(status, 'A', 'B')"`.
+//
+// The function must return one value, or one value and an error.
+// The arguments are converted to the parameter types of the function when they are convertible,
+// and the variadic parameters are supported.
+// Registering a function with an existing name overwrites the old one.
+// It is safe to call RegisterFunc concurrently.
+func RegisterFunc(name string, fn any) error {
+	if name == "" {
+		return errors.New("RegisterFunc: name must not be empty")
+	}
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func || rv.IsNil() {
+		return errors.New("RegisterFunc: fn must be a function")
+	}
+	switch rt := rv.Type(); {
+	case rt.NumOut() == 1:
+	case rt.NumOut() == 2 && rt.Out(1) == errType:
+	default:
+		return errors.New("RegisterFunc: fn must return one value, or one value and an error")
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.funcs[name] = rv
+	return nil
+}
+
+// MustRegisterFunc is like RegisterFunc but panics if an error occurs.
+func MustRegisterFunc(name string, fn any) {
+	if err := RegisterFunc(name, fn); err != nil {
+		panic(err)
+	}
+}
+
+// LookupFunc returns the function registered by the given name.
+func LookupFunc(name string) (reflect.Value, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	fn, ok := registry.funcs[name]
+	return fn, ok
+}
+
+// CallFunc calls the function with the given arguments.
+// The arguments are converted to the parameter types of the function,
+// and the error returned by the function is returned as the error.
+// The function must return a single value, or a value and an error.
+// The panic of the function, like calling a method on a nil pointer, is returned as the error.
+func CallFunc(fn reflect.Value, args []reflect.Value) (result reflect.Value, err error) {
+	fnType := fn.Type()
+	if numOut := fnType.NumOut(); numOut != 1 && (numOut != 2 || fnType.Out(1) != errType) {
+		return invalidValue, fmt.Errorf("invalid return values of %s: expected a value, or a value and an error", fnType)
+	}
+	numIn := fnType.NumIn()
+	if fnType.IsVariadic() {
+		if len(args) < numIn-1 {
+			return invalidValue, fmt.Errorf("invalid number of arguments: expected at least %d, got %d", numIn-1, len(args))
+		}
+	} else if len(args) != numIn {
+		return invalidValue, fmt.Errorf("invalid number of arguments: expected %d, got %d", numIn, len(args))
+	}
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var argType reflect.Type
+		if fnType.IsVariadic() && i >= numIn-1 {
+			argType = fnType.In(numIn - 1).Elem()
+		} else {
+			argType = fnType.In(i)
+		}
+		value, err := coerceArg(arg, argType)
+		if err != nil {
+			return invalidValue, fmt.Errorf("argument %d: %w", i, err)
+		}
+		in[i] = value
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = invalidValue, fmt.Errorf("call of %s panicked: %v", fnType, r)
+		}
+	}()
+	out := fn.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return invalidValue, out[1].Interface().(error)
+	}
+	return out[0], nil
+}
+
+// coerceArg converts the value to the given type.
+// Only the numeric values are converted between the kinds, to avoid the surprising
+// conversions like from an integer to a string.
+func coerceArg(value reflect.Value, to reflect.Type) (reflect.Value, error) {
+	if !value.IsValid() {
+		return reflect.Zero(to), nil
+	}
+	for value.Kind() == reflect.Interface && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Type().AssignableTo(to) {
+		return value, nil
+	}
+	if value.Kind() == reflect.Pointer && !value.IsNil() && value.Elem().Type().AssignableTo(to) {
+		return value.Elem(), nil
+	}
+	switch {
+	case isNumeric(value) && isNumericKind(to.Kind()):
+		if isFloat(value) && !isFloat(reflect.Zero(to)) {
+			// the floats are not truncated, like 1.9 to 1.
+			if f := value.Float(); f != math.Trunc(f) {
+				return invalidValue, fmt.Errorf("cannot convert %s %v to %s without truncation", value.Type(), f, to)
+			}
+		}
+		return value.Convert(to), nil
+	case value.Kind() == to.Kind() && value.CanConvert(to):
+		return value.Convert(to), nil
+	}
+	return invalidValue, fmt.Errorf("cannot convert %s to %s", value.Type(), to)
+}
@@ -0,0 +1,290 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expr
+
+import (
+	"encoding"
+	"errors"
+	"math/big"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// NumericAdapter lets the numeric types beyond the native kinds, like big.Int or the decimal types,
+// participate in the arithmetic and the comparisons of the expressions.
+type NumericAdapter interface {
+	// Operate performs the operation on the two values, it reports false if it does not handle them.
+	// The values are unwrapped, one of them is not of the native numeric kinds.
+	Operate(operator OperatorExpr, left, right reflect.Value) (result reflect.Value, ok bool, err error)
+}
+
+var numericAdapters = struct {
+	mu       sync.RWMutex
+	adapters []NumericAdapter
+}{adapters: []NumericAdapter{BigNumberAdapter{}, DecimalAdapter{}}}
+
+// RegisterNumericAdapter registers the numeric adapter, which is consulted before the registered ones.
+// The BigNumberAdapter and the DecimalAdapter are registered by default.
+func RegisterNumericAdapter(adapter NumericAdapter) {
+	if adapter == nil {
+		panic("expr: numeric adapter is nil")
+	}
+	numericAdapters.mu.Lock()
+	defer numericAdapters.mu.Unlock()
+	numericAdapters.adapters = append([]NumericAdapter{adapter}, numericAdapters.adapters...)
+}
+
+// operateNumericAdapters performs the operation with the first adapter handling the values.
+func operateNumericAdapters(operator OperatorExpr, left, right reflect.Value) (reflect.Value, bool, error) {
+	if !left.IsValid() || !right.IsValid() {
+		return invalidValue, false, nil
+	}
+	numericAdapters.mu.RLock()
+	adapters := numericAdapters.adapters
+	numericAdapters.mu.RUnlock()
+	for _, adapter := range adapters {
+		if result, ok, err := adapter.Operate(operator, left, right); ok {
+			return result, true, err
+		}
+	}
+	return invalidValue, false, nil
+}
+
+var (
+	bigIntType   = reflect.TypeOf(big.Int{})
+	bigFloatType = reflect.TypeOf(big.Float{})
+)
+
+// errDivisionByZero is returned when a big number is divided by zero.
+var errDivisionByZero = errors.New("division by zero")
+
+// BigNumberAdapter is the NumericAdapter of big.Int and big.Float, which can be operated with
+// each other and with the native numbers. The result is a *big.Int if both values are integers,
+// otherwise a *big.Float.
+type BigNumberAdapter struct{}
+
+// Operate implements NumericAdapter.
+func (BigNumberAdapter) Operate(operator OperatorExpr, left, right reflect.Value) (reflect.Value, bool, error) {
+	if !isBigNumber(left) && !isBigNumber(right) {
+		return invalidValue, false, nil
+	}
+	if x, ok := toBigInt(left); ok {
+		if y, ok := toBigInt(right); ok {
+			result, err := operateBigInt(operator, x, y)
+			return result, true, err
+		}
+	}
+	x, ok := toBigFloat(left)
+	if !ok {
+		return invalidValue, false, nil
+	}
+	y, ok := toBigFloat(right)
+	if !ok {
+		return invalidValue, false, nil
+	}
+	result, err := operateBigFloat(operator, x, y)
+	return result, true, err
+}
+
+func isBigNumber(value reflect.Value) bool {
+	return value.IsValid() && value.Type() == bigIntType || value.Type() == bigFloatType
+}
+
+// addressOf returns the pointer to the value, the value is copied if it is not addressable.
+func addressOf(value reflect.Value) any {
+	if !value.CanAddr() {
+		ptr := reflect.New(value.Type())
+		ptr.Elem().Set(value)
+		return ptr.Interface()
+	}
+	return value.Addr().Interface()
+}
+
+func toBigInt(value reflect.Value) (*big.Int, bool) {
+	switch {
+	case value.Type() == bigIntType:
+		return addressOf(value).(*big.Int), true
+	case isInt(value):
+		return big.NewInt(value.Int()), true
+	case isUint(value):
+		return new(big.Int).SetUint64(value.Uint()), true
+	default:
+		return nil, false
+	}
+}
+
+func toBigFloat(value reflect.Value) (*big.Float, bool) {
+	switch {
+	case value.Type() == bigFloatType:
+		return addressOf(value).(*big.Float), true
+	case value.Type() == bigIntType:
+		return new(big.Float).SetInt(addressOf(value).(*big.Int)), true
+	case isInt(value):
+		return new(big.Float).SetInt64(value.Int()), true
+	case isUint(value):
+		return new(big.Float).SetUint64(value.Uint()), true
+	case isFloat(value):
+		return big.NewFloat(value.Float()), true
+	default:
+		return nil, false
+	}
+}
+
+func operateBigInt(operator OperatorExpr, x, y *big.Int) (reflect.Value, error) {
+	switch operator {
+	case Add:
+		return reflect.ValueOf(new(big.Int).Add(x, y)), nil
+	case Sub:
+		return reflect.ValueOf(new(big.Int).Sub(x, y)), nil
+	case Mul:
+		return reflect.ValueOf(new(big.Int).Mul(x, y)), nil
+	case Quo, Rem:
+		if y.Sign() == 0 {
+			return invalidValue, errDivisionByZero
+		}
+		// like Go, the quotient is truncated toward zero.
+		if operator == Quo {
+			return reflect.ValueOf(new(big.Int).Quo(x, y)), nil
+		}
+		return reflect.ValueOf(new(big.Int).Rem(x, y)), nil
+	default:
+		return compareResult(operator, x.Cmp(y))
+	}
+}
+
+func operateBigFloat(operator OperatorExpr, x, y *big.Float) (reflect.Value, error) {
+	switch operator {
+	case Add:
+		return reflect.ValueOf(new(big.Float).Add(x, y)), nil
+	case Sub:
+		return reflect.ValueOf(new(big.Float).Sub(x, y)), nil
+	case Mul:
+		return reflect.ValueOf(new(big.Float).Mul(x, y)), nil
+	case Quo:
+		if y.Sign() == 0 {
+			return invalidValue, errDivisionByZero
+		}
+		return reflect.ValueOf(new(big.Float).Quo(x, y)), nil
+	default:
+		return compareResult(operator, x.Cmp(y))
+	}
+}
+
+// compareResult returns the result of the comparison operator by the result of Cmp.
+func compareResult(operator OperatorExpr, cmp int) (reflect.Value, error) {
+	switch operator {
+	case Eq:
+		return reflect.ValueOf(cmp == 0), nil
+	case Ne:
+		return reflect.ValueOf(cmp != 0), nil
+	case Lt:
+		return reflect.ValueOf(cmp < 0), nil
+	case Le:
+		return reflect.ValueOf(cmp <= 0), nil
+	case Gt:
+		return reflect.ValueOf(cmp > 0), nil
+	case Ge:
+		return reflect.ValueOf(cmp >= 0), nil
+	default:
+		return invalidValue, errors.New("unsupported operator " + operator.String())
+	}
+}
+
+// decimalMethods are the methods of the decimal types, which take and return the value of the type.
+var decimalMethods = map[OperatorExpr]string{Add: "Add", Sub: "Sub", Mul: "Mul", Quo: "Div"}
+
+// DecimalAdapter is the NumericAdapter of the decimal types, like shopspring/decimal.Decimal,
+// which have the Add, Sub, Mul and Div methods taking and returning the value of the type,
+// and the Cmp method returning an int. The other value is converted to the decimal type by
+// its UnmarshalText method, so the decimals can be operated with the native numbers and the
+// numeric strings.
+type DecimalAdapter struct{}
+
+// Operate implements NumericAdapter.
+func (DecimalAdapter) Operate(operator OperatorExpr, left, right reflect.Value) (reflect.Value, bool, error) {
+	var typ reflect.Type
+	switch {
+	case isDecimal(left.Type()):
+		typ = left.Type()
+	case isDecimal(right.Type()):
+		typ = right.Type()
+	default:
+		return invalidValue, false, nil
+	}
+	x, ok, err := toDecimal(typ, left)
+	if !ok || err != nil {
+		return invalidValue, ok, err
+	}
+	y, ok, err := toDecimal(typ, right)
+	if !ok || err != nil {
+		return invalidValue, ok, err
+	}
+	if name, ok := decimalMethods[operator]; ok {
+		if operator == Quo && y.MethodByName("IsZero").IsValid() && y.MethodByName("IsZero").Call(nil)[0].Bool() {
+			return invalidValue, true, errDivisionByZero
+		}
+		return x.MethodByName(name).Call([]reflect.Value{y})[0], true, nil
+	}
+	cmp := x.MethodByName("Cmp").Call([]reflect.Value{y})[0].Int()
+	result, err := compareResult(operator, int(cmp))
+	return result, true, err
+}
+
+// isDecimal reports whether the type has the methods of the decimal types.
+func isDecimal(typ reflect.Type) bool {
+	for _, name := range decimalMethods {
+		method, ok := typ.MethodByName(name)
+		if !ok || method.Type.NumIn() != 2 || method.Type.In(1) != typ ||
+			method.Type.NumOut() != 1 || method.Type.Out(0) != typ {
+			return false
+		}
+	}
+	method, ok := typ.MethodByName("Cmp")
+	return ok && method.Type.NumIn() == 2 && method.Type.In(1) == typ &&
+		method.Type.NumOut() == 1 && method.Type.Out(0).Kind() == reflect.Int
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// toDecimal converts the value to the decimal type, it reports false if the value is not a number.
+func toDecimal(typ reflect.Type, value reflect.Value) (reflect.Value, bool, error) {
+	if value.Type() == typ {
+		return value, true, nil
+	}
+	var text string
+	switch {
+	case isInt(value):
+		text = strconv.FormatInt(value.Int(), 10)
+	case isUint(value):
+		text = strconv.FormatUint(value.Uint(), 10)
+	case isFloat(value):
+		text = strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	case isString(value):
+		text = value.String()
+	default:
+		return invalidValue, false, nil
+	}
+	ptr := reflect.New(typ)
+	if !ptr.Type().Implements(textUnmarshalerType) {
+		return invalidValue, false, nil
+	}
+	if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text)); err != nil {
+		return invalidValue, true, err
+	}
+	return ptr.Elem(), true, nil
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package expr
 
 import (
+	"fmt"
+	"math"
 	"reflect"
+	"time"
 
 	"github.com/go-juicedev/juice/internal/reflectlite"
 )
@@ -27,21 +30,25 @@ import (
 type OperatorExpr int
 
 const (
-	Add  OperatorExpr = iota // +
-	Sub                      // -
-	Mul                      // *
-	Quo                      // /
-	Rem                      // %
-	And                      // &
-	Land                     // &&
-	Or                       // |
-	Lor                      // ||
-	Eq                       // ==
-	Ne                       // !=
-	Lt                       // <
-	Le                       // <=
-	Gt                       // >
-	Ge                       // >=
+	Add    OperatorExpr = iota // +
+	Sub                        // -
+	Mul                        // *
+	Quo                        // /
+	Rem                        // %
+	And                        // &
+	Land                       // &&
+	Or                         // |
+	Lor                        // ||
+	Eq                         // ==
+	Ne                         // !=
+	Lt                         // <
+	Le                         // <=
+	Gt                         // >
+	Ge                         // >=
+	Xor                        // ^
+	Shl                        // <<
+	Shr                        // >>
+	AndNot                     // &^
 )
 
 // String method returns the string representation of the operator.
@@ -77,6 +84,14 @@ func (e OperatorExpr) String() string {
 		return ">"
 	case Ge:
 		return ">="
+	case Xor:
+		return "^"
+	case Shl:
+		return "<<"
+	case Shr:
+		return ">>"
+	case AndNot:
+		return "&^"
 	default:
 		return ""
 	}
@@ -152,6 +167,10 @@ func (o IntOperator) Operate(left, right reflect.Value) (reflect.Value, error) {
 		return reflect.ValueOf(left.Int() > right.Int()), nil
 	case Ge:
 		return reflect.ValueOf(left.Int() >= right.Int()), nil
+	case Xor:
+		return reflect.ValueOf(left.Int() ^ right.Int()), nil
+	case AndNot:
+		return reflect.ValueOf(left.Int() &^ right.Int()), nil
 	default:
 		return invalidValue, NewOperationError(left, right, o.OperatorExpr.String())
 	}
@@ -201,6 +220,10 @@ func (o UintOperator) Operate(left, right reflect.Value) (reflect.Value, error)
 		return reflect.ValueOf(left.Uint() > right.Uint()), nil
 	case Ge:
 		return reflect.ValueOf(left.Uint() >= right.Uint()), nil
+	case Xor:
+		return reflect.ValueOf(left.Uint() ^ right.Uint()), nil
+	case AndNot:
+		return reflect.ValueOf(left.Uint() &^ right.Uint()), nil
 	default:
 		return invalidValue, NewOperationError(left, right, o.OperatorExpr.String())
 	}
@@ -382,6 +405,109 @@ func (o InvalidTypeOperator) Operate(left, right reflect.Value) (result reflect.
 	return invalidValue, NewOperationError(left, right, o.OperatorExpr.String())
 }
 
+// ShiftOperator represents a shift operator.
+// It embeds OperatorExpr to inherit its methods.
+type ShiftOperator struct {
+	OperatorExpr
+}
+
+// Operate method implements the Operator interface for ShiftOperator.
+// It shifts the integer value on the left by the count on the right.
+// Like Go, the left and right values are not required to have the same type,
+// but the count must not be negative.
+func (o ShiftOperator) Operate(left, right reflect.Value) (reflect.Value, error) {
+	left, right = reflectlite.Unwrap(left), reflectlite.Unwrap(right)
+	var count uint64
+	switch {
+	case isInt(right) && right.Int() >= 0:
+		count = uint64(right.Int())
+	case isUint(right):
+		count = right.Uint()
+	default:
+		return invalidValue, NewOperationError(left, right, o.OperatorExpr.String())
+	}
+	switch {
+	case isInt(left) && o.OperatorExpr == Shl:
+		return reflect.ValueOf(left.Int() << count), nil
+	case isInt(left) && o.OperatorExpr == Shr:
+		return reflect.ValueOf(left.Int() >> count), nil
+	case isUint(left) && o.OperatorExpr == Shl:
+		return reflect.ValueOf(left.Uint() << count), nil
+	case isUint(left) && o.OperatorExpr == Shr:
+		return reflect.ValueOf(left.Uint() >> count), nil
+	default:
+		return invalidValue, NewOperationError(left, right, o.OperatorExpr.String())
+	}
+}
+
+// TimeOperator represents a time operator.
+// It embeds OperatorExpr to inherit its methods.
+type TimeOperator struct {
+	OperatorExpr
+}
+
+// Operate method implements the Operator interface for TimeOperator.
+// It compares the two time.Time values, or the values of the types with the Before, After and Equal
+// methods, like time.Time. A string compared with a time.Time is parsed as a timestamp,
+// in RFC3339, "2006-01-02 15:04:05" or "2006-01-02" layouts, the latter two are in UTC.
+func (o TimeOperator) Operate(left, right reflect.Value) (reflect.Value, error) {
+	left, right = reflectlite.Unwrap(left), reflectlite.Unwrap(right)
+	if !left.IsValid() || !right.IsValid() {
+		return invalidValue, NewOperationError(left, right, o.OperatorExpr.String())
+	}
+	left, right, err := alignTime(left, right)
+	if err != nil || !isTimeComparable(left) || left.Type() != right.Type() {
+		return invalidValue, NewOperationError(left, right, o.OperatorExpr.String())
+	}
+	compare := func(method string) bool {
+		return left.MethodByName(method).Call([]reflect.Value{right})[0].Bool()
+	}
+	switch o.OperatorExpr {
+	case Eq:
+		return reflect.ValueOf(compare("Equal")), nil
+	case Ne:
+		return reflect.ValueOf(!compare("Equal")), nil
+	case Lt:
+		return reflect.ValueOf(compare("Before")), nil
+	case Le:
+		return reflect.ValueOf(!compare("After")), nil
+	case Gt:
+		return reflect.ValueOf(compare("After")), nil
+	case Ge:
+		return reflect.ValueOf(!compare("Before")), nil
+	default:
+		return invalidValue, NewOperationError(left, right, o.OperatorExpr.String())
+	}
+}
+
+// timeLayouts are the layouts of the string compared with a time.Time.
+var timeLayouts = []string{time.RFC3339Nano, time.DateTime, time.DateOnly}
+
+// alignTime parses the string compared with a time.Time.
+func alignTime(left, right reflect.Value) (reflect.Value, reflect.Value, error) {
+	var err error
+	switch {
+	case !left.IsValid() || !right.IsValid():
+	case left.Type() == timeType && isString(right):
+		right, err = parseTime(right.String())
+	case right.Type() == timeType && isString(left):
+		left, err = parseTime(left.String())
+	}
+	return left, right, err
+}
+
+// parseTime parses the string with the time layouts.
+func parseTime(value string) (reflect.Value, error) {
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return reflect.ValueOf(t), nil
+		}
+	}
+	return invalidValue, err
+}
+
 // GenericOperator represents a generic operator.
 // It embeds OperatorExpr to inherit its methods.
 type GenericOperator struct {
@@ -392,13 +518,40 @@ type GenericOperator struct {
 // It performs the operation represented by the operator on the two values, which can be of any type.
 func (o GenericOperator) Operate(left, right reflect.Value) (reflect.Value, error) {
 	var operator Operator
+	// the Valuer operands, like sql.NullString, are operated by their driver values.
+	left, err := unwrapValuer(left)
+	if err != nil {
+		return invalidValue, err
+	}
+	if right, err = unwrapValuer(right); err != nil {
+		return invalidValue, err
+	}
 	if !right.IsValid() || !left.IsValid() {
 		operator = InvalidTypeOperator(o)
 		return operator.Operate(left, right)
 	}
+	// the nil pointers are invalid after being unwrapped, they can not be operated by the adapters.
+	if unwrappedRight, unwrappedLeft := reflectlite.Unwrap(right), reflectlite.Unwrap(left); !unwrappedRight.IsValid() || !unwrappedLeft.IsValid() {
+		operator = InvalidTypeOperator(o)
+		return operator.Operate(left, right)
+	}
 	right, left = reflectlite.Unwrap(right), reflectlite.Unwrap(left)
 
+	// the registered comparers of the custom types take precedence over the kinds.
+	if isComparison(o.OperatorExpr) {
+		if result, ok, err := operateComparers(o.OperatorExpr, left, right); ok {
+			return result, err
+		}
+	}
+
+	// the shift count is not required to have the same type as the shifted value.
+	if o.OperatorExpr != Shl && o.OperatorExpr != Shr {
+		left, right = promoteNumeric(left, right)
+	}
+
 	switch {
+	case o.OperatorExpr == Shl || o.OperatorExpr == Shr:
+		operator = ShiftOperator(o)
 	case isAllInt(left, right):
 		operator = IntOperator(o)
 	case isAllUint(left, right):
@@ -411,8 +564,71 @@ func (o GenericOperator) Operate(left, right reflect.Value) (reflect.Value, erro
 		operator = BoolOperator(o)
 	case isAllComplex(left, right):
 		operator = ComplexOperator(o)
+	case isTimeComparable(left) || isTimeComparable(right):
+		operator = TimeOperator(o)
 	default:
+		// the numeric types beyond the native kinds, like big.Int.
+		if result, ok, err := operateNumericAdapters(o.OperatorExpr, left, right); ok {
+			return result, err
+		}
+		// + never converts the operands implicitly, the mixed strings and numbers are likely to be concatenated.
+		if o.OperatorExpr == Add && isString(left) != isString(right) {
+			return invalidValue, fmt.Errorf("%w, convert the operands explicitly by str(), int() or float()",
+				NewOperationError(left, right, o.OperatorExpr.String()))
+		}
 		return invalidValue, NewOperationError(left, right, o.OperatorExpr.String())
 	}
 	return operator.Operate(left, right)
 }
+
+// promoteNumeric converts the numeric values of the different kinds to a common kind,
+// so that the literals, which are parsed as int64 or float64, can be operated with the
+// values of any numeric type. The promotion ladder is:
+//
+//  1. if either value is a float, both are converted to float64;
+//  2. an int and an uint are converted to int64 if the uint fits in int64;
+//  3. otherwise they are converted to uint64 if the int is not negative;
+//  4. otherwise they are converted to float64, which keeps their order.
+//
+// The values are returned as they are if they are not both numeric, or are of the same kind.
+func promoteNumeric(left, right reflect.Value) (reflect.Value, reflect.Value) {
+	if !isNumeric(left) || !isNumeric(right) {
+		return left, right
+	}
+	switch {
+	case isAllInt(left, right), isAllUint(left, right), isAllFloat(left, right):
+		return left, right
+	case isFloat(left) || isFloat(right):
+		return reflect.ValueOf(toFloat64(left)), reflect.ValueOf(toFloat64(right))
+	}
+	// one is int, the other is uint.
+	signed, unsigned := left, right
+	if isUint(left) {
+		signed, unsigned = right, left
+	}
+	var promotedSigned, promotedUnsigned reflect.Value
+	switch {
+	case unsigned.Uint() <= math.MaxInt64:
+		promotedSigned, promotedUnsigned = reflect.ValueOf(signed.Int()), reflect.ValueOf(int64(unsigned.Uint()))
+	case signed.Int() >= 0:
+		promotedSigned, promotedUnsigned = reflect.ValueOf(uint64(signed.Int())), reflect.ValueOf(unsigned.Uint())
+	default:
+		promotedSigned, promotedUnsigned = reflect.ValueOf(float64(signed.Int())), reflect.ValueOf(float64(unsigned.Uint()))
+	}
+	if isUint(left) {
+		return promotedUnsigned, promotedSigned
+	}
+	return promotedSigned, promotedUnsigned
+}
+
+// toFloat64 returns the numeric value as float64.
+func toFloat64(value reflect.Value) float64 {
+	switch {
+	case isInt(value):
+		return float64(value.Int())
+	case isUint(value):
+		return float64(value.Uint())
+	default:
+		return value.Float()
+	}
+}
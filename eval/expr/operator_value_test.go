@@ -1,8 +1,14 @@
 package expr_test
 
 import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/go-juicedev/juice/eval/expr"
 )
@@ -210,3 +216,225 @@ func TestNilNe(t *testing.T) {
 		t.Errorf("Expected true, got %v", result.Bool())
 	}
 }
+
+func TestGenericOperator_MixedNumeric(t *testing.T) {
+	tests := []struct {
+		left, right any
+		operator    expr.OperatorExpr
+		want        any
+	}{
+		{left: 10.5, right: int64(10), operator: expr.Gt, want: true},
+		{left: int64(10), right: float32(10), operator: expr.Eq, want: true},
+		{left: uint8(3), right: int64(4), operator: expr.Add, want: int64(7)},
+		{left: int64(-1), right: uint64(1), operator: expr.Lt, want: true},
+		{left: uint64(1 << 63), right: int64(1), operator: expr.Gt, want: true},
+		{left: int64(-1), right: uint64(1 << 63), operator: expr.Lt, want: true},
+		{left: 1.5, right: uint(2), operator: expr.Mul, want: 3.0},
+	}
+	for _, tt := range tests {
+		operator := expr.GenericOperator{OperatorExpr: tt.operator}
+		result, err := operator.Operate(reflect.ValueOf(tt.left), reflect.ValueOf(tt.right))
+		if err != nil {
+			t.Errorf("%v %s %v: %v", tt.left, tt.operator, tt.right, err)
+			continue
+		}
+		if got := result.Interface(); got != tt.want {
+			t.Errorf("%v %s %v: expected %v, got %v", tt.left, tt.operator, tt.right, tt.want, got)
+		}
+	}
+}
+
+type version struct{ n int }
+
+func (v version) Before(other version) bool { return v.n < other.n }
+func (v version) After(other version) bool  { return v.n > other.n }
+func (v version) Equal(other version) bool  { return v.n == other.n }
+
+func TestGenericOperator_Time(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		left, right any
+		operator    expr.OperatorExpr
+		want        bool
+	}{
+		{left: now, right: now.Add(time.Hour), operator: expr.Lt, want: true},
+		{left: now, right: now.In(time.FixedZone("UTC+8", 8*3600)), operator: expr.Eq, want: true},
+		{left: &now, right: now, operator: expr.Ge, want: true},
+		{left: now, right: "2025-06-01T12:00:00Z", operator: expr.Eq, want: true},
+		{left: "2025-01-01", right: now, operator: expr.Lt, want: true},
+		{left: now, right: "2025-06-01 11:59:59", operator: expr.Gt, want: true},
+		{left: version{1}, right: version{2}, operator: expr.Le, want: true},
+	}
+	for _, tt := range tests {
+		operator := expr.GenericOperator{OperatorExpr: tt.operator}
+		result, err := operator.Operate(reflect.ValueOf(tt.left), reflect.ValueOf(tt.right))
+		if err != nil {
+			t.Errorf("%v %s %v: %v", tt.left, tt.operator, tt.right, err)
+			continue
+		}
+		if got := result.Bool(); got != tt.want {
+			t.Errorf("%v %s %v: expected %v, got %v", tt.left, tt.operator, tt.right, tt.want, got)
+		}
+	}
+	for _, right := range []any{"yesterday", 1} {
+		operator := expr.GenericOperator{OperatorExpr: expr.Lt}
+		if _, err := operator.Operate(reflect.ValueOf(now), reflect.ValueOf(right)); err == nil {
+			t.Errorf("expected an error comparing with %v", right)
+		}
+	}
+	// the nil pointers are reported as the operation errors, by the generic and the time operators.
+	var nilTime *time.Time
+	for _, operator := range []expr.Operator{expr.GenericOperator{OperatorExpr: expr.Lt}, expr.TimeOperator{OperatorExpr: expr.Lt}} {
+		if _, err := operator.Operate(reflect.ValueOf(nilTime), reflect.ValueOf(now)); err == nil {
+			t.Errorf("%T: expected an error comparing a nil time", operator)
+		}
+		if _, err := operator.Operate(reflect.ValueOf(now), reflect.ValueOf(nilTime)); err == nil {
+			t.Errorf("%T: expected an error comparing with a nil time", operator)
+		}
+	}
+}
+
+// cents is a decimal type with two decimal places, like shopspring/decimal.Decimal.
+type cents struct{ n int64 }
+
+func (c cents) Add(other cents) cents { return cents{c.n + other.n} }
+func (c cents) Sub(other cents) cents { return cents{c.n - other.n} }
+func (c cents) Mul(other cents) cents { return cents{c.n * other.n / 100} }
+func (c cents) Div(other cents) cents { return cents{c.n * 100 / other.n} }
+func (c cents) Cmp(other cents) int   { return cmp.Compare(c.n, other.n) }
+func (c cents) IsZero() bool          { return c.n == 0 }
+
+func (c *cents) UnmarshalText(text []byte) error {
+	value, err := strconv.ParseFloat(string(text), 64)
+	c.n = int64(math.Round(value * 100))
+	return err
+}
+
+func TestGenericOperator_NumericAdapter(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+	tests := []struct {
+		left, right any
+		operator    expr.OperatorExpr
+		want        string
+	}{
+		{left: huge, right: int64(1), operator: expr.Add, want: "100000000000000000001"},
+		{left: *huge, right: huge, operator: expr.Mul, want: "10000000000000000000000000000000000000000"},
+		{left: huge, right: uint8(3), operator: expr.Rem, want: "1"},
+		{left: huge, right: int64(math.MaxInt64), operator: expr.Gt, want: "true"},
+		{left: big.NewFloat(1.5), right: huge, operator: expr.Lt, want: "true"},
+		{left: 0.5, right: big.NewFloat(0.25), operator: expr.Add, want: "0.75"},
+		{left: cents{150}, right: cents{50}, operator: expr.Add, want: "{200}"},
+		{left: cents{150}, right: 1.5, operator: expr.Eq, want: "true"},
+		{left: "2.5", right: cents{150}, operator: expr.Sub, want: "{100}"},
+		{left: cents{300}, right: int64(2), operator: expr.Quo, want: "{150}"},
+	}
+	for _, tt := range tests {
+		operator := expr.GenericOperator{OperatorExpr: tt.operator}
+		result, err := operator.Operate(reflect.ValueOf(tt.left), reflect.ValueOf(tt.right))
+		if err != nil {
+			t.Errorf("%v %s %v: %v", tt.left, tt.operator, tt.right, err)
+			continue
+		}
+		got := fmt.Sprint(result.Interface())
+		if f, ok := result.Interface().(*big.Float); ok {
+			got = f.Text('f', -1)
+		}
+		if got != tt.want {
+			t.Errorf("%v %s %v: expected %v, got %v", tt.left, tt.operator, tt.right, tt.want, got)
+		}
+	}
+	for _, right := range []any{int64(0), cents{}} {
+		operator := expr.GenericOperator{OperatorExpr: expr.Quo}
+		left := any(huge)
+		if _, ok := right.(cents); ok {
+			left = cents{100}
+		}
+		if _, err := operator.Operate(reflect.ValueOf(left), reflect.ValueOf(right)); err == nil {
+			t.Errorf("expected division by zero error for %v", right)
+		}
+	}
+}
+
+func TestGenericOperator_NilPointer(t *testing.T) {
+	for _, operator := range []expr.OperatorExpr{expr.Eq, expr.Add, expr.Lt} {
+		_, err := (expr.GenericOperator{OperatorExpr: operator}).Operate(reflect.ValueOf((*int)(nil)), reflect.ValueOf(int64(1)))
+		if err == nil || err.Error() != "invalid operation "+operator.String()+" for invalid and int64" {
+			t.Errorf("expected the operation error of %s, got %v", operator, err)
+		}
+		_, err = (expr.GenericOperator{OperatorExpr: operator}).Operate(reflect.ValueOf(big.NewInt(1)), reflect.ValueOf((*big.Int)(nil)))
+		if err == nil {
+			t.Errorf("expected the operation error of %s with a nil big.Int", operator)
+		}
+	}
+}
+
+// testID is a custom ID type like uuid.UUID, which is an array.
+type testID [2]byte
+
+func (id testID) String() string { return fmt.Sprintf("%02x%02x", id[0], id[1]) }
+
+// ranked is implemented by the enums ordered by their ranks.
+type ranked interface{ Rank() int }
+
+type level string
+
+func (l level) Rank() int {
+	return map[level]int{"low": 1, "medium": 2, "high": 3}[l]
+}
+
+func TestGenericOperator_Comparer(t *testing.T) {
+	expr.RegisterComparer(reflect.TypeOf(testID{}), expr.ComparerFunc(func(left, right reflect.Value) (int, error) {
+		text := func(v reflect.Value) string {
+			if id, ok := v.Interface().(testID); ok {
+				return id.String()
+			}
+			return v.String()
+		}
+		return cmp.Compare(text(left), text(right)), nil
+	}))
+	expr.RegisterComparer(reflect.TypeOf((*ranked)(nil)).Elem(), expr.ComparerFunc(func(left, right reflect.Value) (int, error) {
+		l, lok := left.Interface().(ranked)
+		r, rok := right.Interface().(ranked)
+		if !lok || !rok {
+			return 0, fmt.Errorf("can not compare %v with %v", left, right)
+		}
+		return cmp.Compare(l.Rank(), r.Rank()), nil
+	}))
+	id := testID{0x0a, 0x0b}
+	tests := []struct {
+		left, right any
+		operator    expr.OperatorExpr
+		want        bool
+	}{
+		{left: id, right: testID{0x0a, 0x0b}, operator: expr.Eq, want: true},
+		{left: &id, right: "0a0b", operator: expr.Eq, want: true},
+		{left: "0a0c", right: id, operator: expr.Ne, want: true},
+		{left: id, right: testID{0x0b}, operator: expr.Lt, want: true},
+		{left: level("high"), right: level("medium"), operator: expr.Gt, want: true},
+		{left: level("low"), right: level("medium"), operator: expr.Ge, want: false},
+	}
+	for _, tt := range tests {
+		operator := expr.GenericOperator{OperatorExpr: tt.operator}
+		result, err := operator.Operate(reflect.ValueOf(tt.left), reflect.ValueOf(tt.right))
+		if err != nil {
+			t.Errorf("%v %s %v: %v", tt.left, tt.operator, tt.right, err)
+			continue
+		}
+		if result.Bool() != tt.want {
+			t.Errorf("%v %s %v: expected %v", tt.left, tt.operator, tt.right, tt.want)
+		}
+	}
+	// the comparer errors are returned, and the other operators are not affected.
+	if _, err := (expr.GenericOperator{OperatorExpr: expr.Eq}).Operate(reflect.ValueOf(level("low")), reflect.ValueOf(1)); err == nil {
+		t.Error("expected the error of the comparer")
+	}
+	result, err := (expr.GenericOperator{OperatorExpr: expr.Add}).Operate(reflect.ValueOf(level("low")), reflect.ValueOf(level("!")))
+	if err != nil || result.String() != "low!" {
+		t.Errorf("unexpected result: %v %v", result, err)
+	}
+	// the nil pointers are not handed to the comparers.
+	var nilID *testID
+	if _, err = (expr.GenericOperator{OperatorExpr: expr.Eq}).Operate(reflect.ValueOf(nilID), reflect.ValueOf(id)); err == nil {
+		t.Error("expected the operation error of a nil pointer")
+	}
+}
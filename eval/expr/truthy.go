@@ -0,0 +1,57 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expr
+
+import "reflect"
+
+// Truthy reports the truthiness of the value like OGNL does:
+//   - nil is false
+//   - bool is itself
+//   - the numbers are true if non-zero
+//   - the strings are true if non-empty
+//   - the slices, arrays and maps are true if non-empty
+//   - the pointers, channels and functions are true if non-nil
+//   - the driver.Valuer, like sql.NullString, is its driver value, NULL is false
+//   - the other values like structs are true
+func Truthy(value reflect.Value) bool {
+	if unwrapped, err := unwrapValuer(value); err == nil {
+		value = unwrapped
+	}
+	for value.Kind() == reflect.Interface {
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Invalid:
+		return false
+	case reflect.Bool:
+		return value.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int() != 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return value.Uint() != 0
+	case reflect.Float32, reflect.Float64:
+		return value.Float() != 0
+	case reflect.Complex64, reflect.Complex128:
+		return value.Complex() != 0
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len() > 0
+	case reflect.Pointer, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return !value.IsNil()
+	default:
+		return true
+	}
+}
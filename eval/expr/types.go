@@ -18,6 +18,7 @@ package expr
 
 import (
 	"reflect"
+	"time"
 
 	"github.com/go-juicedev/juice/internal/reflectlite"
 )
@@ -106,6 +107,23 @@ func isAllComplex(rs ...reflect.Value) bool {
 	return true
 }
 
+// isNumeric reports whether the value is an integer or a float.
+func isNumeric(value reflect.Value) bool {
+	return isNumericKind(value.Kind())
+}
+
+// isNumericKind reports whether the kind is an integer or a float.
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
 func isString(r reflect.Value) bool {
 	return r.Kind() == reflect.String
 }
@@ -166,3 +184,27 @@ func bothNil(left, right reflect.Value) bool {
 	}
 	return false
 }
+
+// timeType is the type of time.Time.
+var timeType = reflect.TypeOf(time.Time{})
+
+// isTimeComparable reports whether the value is a time.Time, or has the Before, After and Equal
+// methods comparing it with the values of its own type, like time.Time.
+func isTimeComparable(r reflect.Value) bool {
+	if !r.IsValid() {
+		return false
+	}
+	typ := r.Type()
+	if typ == timeType {
+		return true
+	}
+	for _, name := range []string{"Before", "After", "Equal"} {
+		method, ok := typ.MethodByName(name)
+		// the receiver is the first input of the method of the type.
+		if !ok || method.Type.NumIn() != 2 || method.Type.In(1) != typ ||
+			method.Type.NumOut() != 1 || method.Type.Out(0).Kind() != reflect.Bool {
+			return false
+		}
+	}
+	return true
+}
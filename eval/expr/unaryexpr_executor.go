@@ -0,0 +1,140 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/go-juicedev/juice/internal/reflectlite"
+)
+
+// UnaryExprExecutor is the interface for unary expression executor
+type UnaryExprExecutor interface {
+	// Exec execute the unary expression with the operand
+	// return the result of the unary expression
+	Exec(x reflect.Value) (reflect.Value, error)
+}
+
+// NEGExprExecutor is the executor for unary -
+// The signed integers are negated as int64, the unsigned integers as int64 if they fit,
+// the floats as float64, the complex numbers as complex128, and *big.Int, *big.Float as themselves.
+type NEGExprExecutor struct{}
+
+// Exec execute the unary expression
+// implement UnaryExprExecutor interface
+func (NEGExprExecutor) Exec(x reflect.Value) (reflect.Value, error) {
+	x = reflectlite.Unwrap(x)
+	switch {
+	case !x.IsValid():
+		return invalidValue, unaryOperandError(token.SUB, x)
+	case isInt(x):
+		return reflect.ValueOf(-x.Int()), nil
+	case isUint(x):
+		if x.Uint() > math.MaxInt64 {
+			return invalidValue, fmt.Errorf("unary -: %d overflows int64", x.Uint())
+		}
+		return reflect.ValueOf(-int64(x.Uint())), nil
+	case isFloat(x):
+		return reflect.ValueOf(-x.Float()), nil
+	case isComplex(x):
+		return reflect.ValueOf(-x.Complex()), nil
+	case x.Type() == bigIntType:
+		value := x.Interface().(big.Int)
+		return reflect.ValueOf(new(big.Int).Neg(&value)), nil
+	case x.Type() == bigFloatType:
+		value := x.Interface().(big.Float)
+		return reflect.ValueOf(new(big.Float).Neg(&value)), nil
+	default:
+		return invalidValue, unaryOperandError(token.SUB, x)
+	}
+}
+
+// POSExprExecutor is the executor for unary +
+type POSExprExecutor struct{}
+
+// Exec execute the unary expression
+// implement UnaryExprExecutor interface
+func (POSExprExecutor) Exec(x reflect.Value) (reflect.Value, error) {
+	x = reflectlite.Unwrap(x)
+	if !isNumeric(x) && (!x.IsValid() || x.Type() != bigIntType && x.Type() != bigFloatType) {
+		return invalidValue, unaryOperandError(token.ADD, x)
+	}
+	return x, nil
+}
+
+// COMPLExprExecutor is the executor for unary ^, the bitwise complement of the integers.
+type COMPLExprExecutor struct{}
+
+// Exec execute the unary expression
+// implement UnaryExprExecutor interface
+func (COMPLExprExecutor) Exec(x reflect.Value) (reflect.Value, error) {
+	x = reflectlite.Unwrap(x)
+	switch {
+	case isInt(x):
+		return reflect.ValueOf(^x.Int()), nil
+	case isUint(x):
+		return reflect.ValueOf(^x.Uint()), nil
+	default:
+		return invalidValue, unaryOperandError(token.XOR, x)
+	}
+}
+
+// LNOTExprExecutor is the executor for unary !
+type LNOTExprExecutor struct{}
+
+// Exec execute the unary expression
+// implement UnaryExprExecutor interface
+func (LNOTExprExecutor) Exec(x reflect.Value) (reflect.Value, error) {
+	x = reflectlite.Unwrap(x)
+	if !isBool(x) {
+		return invalidValue, unaryOperandError(token.NOT, x)
+	}
+	return reflect.ValueOf(!x.Bool()), nil
+}
+
+// unaryOperandError returns the error that the operand is not supported by the unary operator.
+func unaryOperandError(op token.Token, x reflect.Value) error {
+	if !x.IsValid() {
+		return fmt.Errorf("invalid operation: unary %s on nil", op)
+	}
+	return fmt.Errorf("invalid operation: unary %s on %s", op, x.Type())
+}
+
+// ErrUnsupportedUnaryExpr is the error that the unary expression is unsupported
+var ErrUnsupportedUnaryExpr = errors.New("unsupported unary expression")
+
+// unaryExprExecutors is a map from token to UnaryExprExecutor
+var unaryExprExecutors = map[token.Token]UnaryExprExecutor{
+	token.SUB: NEGExprExecutor{},
+	token.ADD: POSExprExecutor{},
+	token.XOR: COMPLExprExecutor{},
+	token.NOT: LNOTExprExecutor{},
+}
+
+// FromUnaryToken returns the UnaryExprExecutor from the token
+func FromUnaryToken(t token.Token) (UnaryExprExecutor, error) {
+	executor, ok := unaryExprExecutors[t]
+	if !ok {
+		return nil, ErrUnsupportedUnaryExpr
+	}
+	return executor, nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expr
+
+import (
+	"database/sql/driver"
+	"reflect"
+
+	"github.com/go-juicedev/juice/internal/reflectlite"
+)
+
+// valuerType is the type of the driver.Valuer interface.
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// unwrapValuer returns the driver value of the operand implementing driver.Valuer, like sql.NullString,
+// so that the Null types of database/sql and the custom Valuer types are operated by their values,
+// the invalid value is returned for NULL. The decimals and the types with the registered comparers
+// are kept, which are operated by themselves.
+func unwrapValuer(value reflect.Value) (reflect.Value, error) {
+	unwrapped := reflectlite.Unwrap(value)
+	if !unwrapped.IsValid() || !unwrapped.CanInterface() {
+		return value, nil
+	}
+	typ := unwrapped.Type()
+	// the fast path of the basic values.
+	if unwrapped.Kind() != reflect.Struct && typ.NumMethod() == 0 {
+		return value, nil
+	}
+	var valuer driver.Valuer
+	switch {
+	case typ.Implements(valuerType):
+		valuer = unwrapped.Interface().(driver.Valuer)
+	case reflect.PointerTo(typ).Implements(valuerType):
+		valuer = addressOf(unwrapped).(driver.Valuer)
+	default:
+		return value, nil
+	}
+	if isDecimal(typ) || isDecimal(reflect.PointerTo(typ)) {
+		return value, nil
+	}
+	if hasComparers.Load() {
+		if _, ok := lookupComparer(typ); ok {
+			return value, nil
+		}
+	}
+	driverValue, err := valuer.Value()
+	if err != nil {
+		return invalidValue, err
+	}
+	return reflect.ValueOf(driverValue), nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eval
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Identifiers returns the parameters referenced by the expression, in the order of their first
+// appearances. The selectors are reported as the dotted paths like "user.profile.age", and the
+// builtin identifiers and the functions registered are not parameters.
+// It returns nil if the expression is not compiled by this package.
+func Identifiers(expression Expression) []string {
+	compiled, ok := expression.(*goExpression)
+	if !ok {
+		return nil
+	}
+	var (
+		names []string
+		seen  = make(map[string]struct{})
+	)
+	add := func(name string) {
+		if _, exists := seen[name]; !exists {
+			seen[name] = struct{}{}
+			names = append(names, name)
+		}
+	}
+	var collect func(exp ast.Expr)
+	collect = func(exp ast.Expr) {
+		switch exp := exp.(type) {
+		case *ast.Ident:
+			if isParamIdent(exp.Name) {
+				add(exp.Name)
+			}
+		case *ast.SelectorExpr:
+			if path, ok := selectorPath(exp); ok {
+				if isParamIdent(strings.SplitN(path, ".", 2)[0]) {
+					add(path)
+				}
+				return
+			}
+			collect(exp.X)
+		case *ast.CallExpr:
+			switch fn := exp.Fun.(type) {
+			case *ast.Ident:
+				// the function itself is not a parameter.
+			case *ast.SelectorExpr:
+				// a method call, the receiver is the parameter.
+				collect(fn.X)
+			default:
+				collect(fn)
+			}
+			for _, arg := range exp.Args {
+				collect(arg)
+			}
+		case *ast.BinaryExpr:
+			collect(exp.X)
+			collect(exp.Y)
+		case *ast.UnaryExpr:
+			collect(exp.X)
+		case *ast.ParenExpr:
+			collect(exp.X)
+		case *ast.StarExpr:
+			collect(exp.X)
+		case *ast.IndexExpr:
+			collect(exp.X)
+			collect(exp.Index)
+		case *ast.SliceExpr:
+			collect(exp.X)
+			for _, index := range []ast.Expr{exp.Low, exp.High, exp.Max} {
+				if index != nil {
+					collect(index)
+				}
+			}
+		}
+	}
+	collect(compiled.Expr)
+	return names
+}
+
+// Literals returns the values of the number and string literals of the expression, in the order of
+// their first appearances. The integers are reported as int64, the floats as float64 and the strings
+// and characters as string, so that the tools can derive the parameters comparable with them.
+// It returns nil if the expression is not compiled by this package.
+func Literals(expression Expression) []any {
+	compiled, ok := expression.(*goExpression)
+	if !ok {
+		return nil
+	}
+	var literals []any
+	ast.Inspect(compiled.Expr, func(node ast.Node) bool {
+		literal, ok := node.(*ast.BasicLit)
+		if !ok {
+			return true
+		}
+		var (
+			value any
+			err   error
+		)
+		switch literal.Kind {
+		case token.INT:
+			value, err = strconv.ParseInt(literal.Value, 0, 64)
+		case token.FLOAT:
+			value, err = strconv.ParseFloat(literal.Value, 64)
+		case token.STRING, token.CHAR:
+			value, err = strconv.Unquote(literal.Value)
+		default:
+			return true
+		}
+		if err == nil && !slices.Contains(literals, value) {
+			literals = append(literals, value)
+		}
+		return true
+	})
+	return literals
+}
+
+// selectorPath returns the dotted path of the selector chain rooted at an identifier.
+func selectorPath(exp *ast.SelectorExpr) (string, bool) {
+	var parts []string
+	var current ast.Expr = exp
+	for {
+		switch node := current.(type) {
+		case *ast.SelectorExpr:
+			parts = append(parts, strings.TrimPrefix(node.Sel.Name, nullSafePrefix))
+			current = node.X
+		case *ast.Ident:
+			parts = append(parts, node.Name)
+			for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+				parts[i], parts[j] = parts[j], parts[i]
+			}
+			return strings.Join(parts, "."), true
+		default:
+			return "", false
+		}
+	}
+}
+
+// isParamIdent reports whether the identifier refers to a parameter.
+// The identifiers out of the call position are parameters unless they are the builtin constants,
+// since the parameters are looked up before the functions registered.
+func isParamIdent(name string) bool {
+	_, ok := builtins[name]
+	return !ok
+}
@@ -21,6 +21,8 @@ package eval
 import (
 	"go/scanner"
 	"go/token"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -44,40 +46,178 @@ func identReplacer(s string) string {
 	}
 }
 
+// nullSafePrefix marks the selector of the null-safe navigation x?.Name, which is not Go syntax.
+// The lexer rewrites x?.Name to x.__nullSafe__Name, and the evaluator selects the zero value
+// of the field instead of failing when x is nil.
+const nullSafePrefix = "__nullSafe__"
+
 // Lexer performs lexical analysis on input strings.
 // It uses Go's standard scanner to tokenize the input and processes
 // specific identifiers for logical operations.
 type Lexer struct {
 	scanner scanner.Scanner
+	input   string
+	file    *token.File
+	// spans maps the tokens of the last Tokenize call to the input.
+	spans []tokenSpan
+}
+
+// tokenSpan is the position of a token in the tokenized output and in the input.
+type tokenSpan struct {
+	offset, end           int // in the output
+	inputOffset, inputEnd int // in the input
+}
+
+// inputSpan returns the input text of the tokens within the output range [offset, end).
+// It returns false if the range covers no token, or the tokens are rewritten,
+// like the ternary expressions which are rewritten to function calls.
+func (l *Lexer) inputSpan(offset, end int) (span string, inputOffset int, ok bool) {
+	first, last := -1, -1
+	for i, s := range l.spans {
+		if s.offset >= offset && s.end <= end {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return "", 0, false
+	}
+	start, stop := l.spans[first].inputOffset, l.spans[last].inputEnd
+	if start > stop || stop > len(l.input) {
+		return "", 0, false
+	}
+	return l.input[start:stop], start, true
 }
 
 // Tokenize processes the input and returns a string with converted operators.
 // It scans through all tokens, replacing logical operators while preserving
 // other tokens and maintaining proper spacing.
+// The ternary cond ? a : b and the elvis a ?: b are rewritten to function calls.
 func (l *Lexer) Tokenize() string {
 	var tokens []string
+	// inputs are the input ranges of the tokens, which are used to report the errors.
+	var inputs [][2]int
+	emit := func(tok string, input [2]int) {
+		tokens = append(tokens, tok)
+		inputs = append(inputs, input)
+	}
+	// nullSafe is set by the "?." of the null-safe navigation, for the next selector.
+	var nullSafe, question bool
+	// questionAt is the input range of the pending "?".
+	var questionAt [2]int
 	for {
-		_, tok, lit := l.scanner.Scan()
+		pos, tok, lit := l.scanner.Scan()
 		if tok == token.EOF {
 			break
 		}
+		input := [2]int{l.file.Offset(pos), l.file.Offset(pos) + len(lit)}
+		if lit == "" || tok == token.SEMICOLON {
+			input[1] = input[0] + len(tok.String())
+		}
+		if question {
+			question = false
+			if tok == token.PERIOD {
+				nullSafe = true
+				emit(tok.String(), [2]int{questionAt[0], input[1]})
+				continue
+			}
+			// not a null-safe navigation, keep the "?" to report the syntax error.
+			emit("?", questionAt)
+		}
 
-		switch tok {
-		case token.IDENT:
+		switch {
+		case tok == token.ILLEGAL && lit == "?":
+			question = true
+			questionAt = input
+		case tok == token.IDENT && nullSafe:
+			nullSafe = false
+			emit(nullSafePrefix+lit, input)
+		case tok == token.IDENT:
 			replacement := identReplacer(lit)
-			tokens = append(tokens, replacement)
+			emit(replacement, input)
+		case tok == token.CHAR && isSingleQuotedString(lit):
+			// the single-quoted strings like m['key'] are strings, not rune literals.
+			emit(doubleQuoted(lit[1:len(lit)-1]), input)
 		default:
+			nullSafe = false
 			if lit != "" {
-				tokens = append(tokens, lit)
+				emit(lit, input)
 			} else {
-				tokens = append(tokens, tok.String())
+				emit(tok.String(), input)
 			}
 		}
 	}
+	if question {
+		emit("?", questionAt)
+	}
+
+	// the automatic semicolon is kept at the end, out of the ternary.
+	end := len(tokens)
+	for end > 0 && (tokens[end-1] == "\n" || tokens[end-1] == ";") {
+		end--
+	}
+	// on failure, the tokens are kept to report the syntax error.
+	if rewritten, err := rewriteConditionals(tokens[:end]); err == nil {
+		if !slices.Equal(rewritten, tokens[:end]) {
+			// the rewritten tokens do not map to the input anymore.
+			inputs = nil
+		}
+		tokens = append(rewritten, tokens[end:]...)
+	}
 
+	l.spans = l.spans[:0]
+	var offset int
+	for i, tok := range tokens {
+		if i < len(inputs) {
+			l.spans = append(l.spans, tokenSpan{
+				offset:      offset,
+				end:         offset + len(tok),
+				inputOffset: inputs[i][0],
+				inputEnd:    inputs[i][1],
+			})
+		}
+		offset += len(tok) + 1
+	}
 	return strings.Join(tokens, " ")
 }
 
+// isSingleQuotedString reports whether the single-quoted literal is a string, which is not
+// a valid rune literal like 'a' or '\n'. The unterminated literal is not a string.
+func isSingleQuotedString(lit string) bool {
+	if len(lit) < 2 || lit[len(lit)-1] != '\'' {
+		return false
+	}
+	_, err := strconv.Unquote(lit)
+	return err != nil
+}
+
+// doubleQuoted returns the double-quoted literal of the contents of a single-quoted string,
+// the escaped single quotes like 'it\'s' are unescaped and the double quotes are escaped.
+// The other escapes are kept, so the invalid ones are reported by the parser.
+func doubleQuoted(inner string) string {
+	var builder strings.Builder
+	builder.Grow(len(inner) + 2)
+	builder.WriteByte('"')
+	for i := 0; i < len(inner); i++ {
+		switch c := inner[i]; {
+		case c == '\\' && i+1 < len(inner):
+			i++
+			if inner[i] != '\'' {
+				builder.WriteByte('\\')
+			}
+			builder.WriteByte(inner[i])
+		case c == '"':
+			builder.WriteString(`\"`)
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
 // NewLexer creates a new Lexer instance with the given input string.
 // It initializes the internal scanner with the input and configures it
 // to scan comments as well.
@@ -88,5 +228,5 @@ func NewLexer(input string) *Lexer {
 
 	s.Init(file, []byte(input), nil, scanner.ScanComments)
 
-	return &Lexer{scanner: s}
+	return &Lexer{scanner: s, input: input, file: file}
 }
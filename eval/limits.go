@@ -0,0 +1,214 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"sync/atomic"
+	"time"
+)
+
+// ErrExpressionLimitExceeded is returned when the evaluation of an expression exceeds the Limits.
+// The returned error wraps a *LimitError, use errors.Is to check it, or errors.As for the exceeded limit.
+var ErrExpressionLimitExceeded = errors.New("expression limit exceeded")
+
+// The names of the limits reported by LimitError.
+const (
+	LimitMaxDepth = "MaxDepth"
+	LimitMaxNodes = "MaxNodes"
+	LimitMaxCalls = "MaxCalls"
+	LimitTimeout  = "Timeout"
+)
+
+// LimitError is the error of an evaluation which exceeds the Limits, it is ErrExpressionLimitExceeded.
+type LimitError struct {
+	// Limit is the name of the exceeded limit, like LimitMaxNodes.
+	Limit string
+	// Limits are the limits of the evaluation.
+	Limits Limits
+	// Actual is the depth of the expression, or the number of the nodes or the calls when the limit is exceeded.
+	// It is 0 for LimitTimeout.
+	Actual int
+}
+
+// Error returns the error message, like "expression limit exceeded: more than 2 function calls".
+func (e *LimitError) Error() string {
+	var detail string
+	switch e.Limit {
+	case LimitMaxDepth:
+		detail = fmt.Sprintf("expression depth %d exceeds %d", e.Actual, e.Limits.MaxDepth)
+	case LimitMaxNodes:
+		detail = fmt.Sprintf("more than %d evaluated nodes", e.Limits.MaxNodes)
+	case LimitMaxCalls:
+		detail = fmt.Sprintf("more than %d function calls", e.Limits.MaxCalls)
+	default:
+		detail = fmt.Sprintf("evaluation timeout %s", e.Limits.Timeout)
+	}
+	return ErrExpressionLimitExceeded.Error() + ": " + detail
+}
+
+// Is reports whether the target is ErrExpressionLimitExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrExpressionLimitExceeded
+}
+
+// Limits guards the evaluation of the expressions which incorporate the user-influenced parameters,
+// so that a pathological expression can not wedge the engine.
+// The zero value of each field means no limit.
+type Limits struct {
+	// MaxDepth is the max depth of the syntax tree of the expression.
+	MaxDepth int
+	// MaxNodes is the max number of the evaluated nodes of one evaluation.
+	MaxNodes int
+	// MaxCalls is the max number of the function calls of one evaluation.
+	MaxCalls int
+	// Timeout is the max duration of one evaluation.
+	// The deadline is checked between the evaluated nodes, a running function is not interrupted.
+	Timeout time.Duration
+}
+
+// IsZero reports whether there is no limit.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// defaultLimits are the limits of the parameters which do not carry their own, see SetDefaultLimits.
+var defaultLimits atomic.Pointer[Limits]
+
+// SetDefaultLimits sets the limits of the evaluations whose parameter carries no limits, see Options.
+// The zero Limits removes the default limits.
+func SetDefaultLimits(limits Limits) {
+	defaultLimits.Store(&limits)
+}
+
+// DefaultLimits returns the limits set by SetDefaultLimits.
+func DefaultLimits() Limits {
+	if limits := defaultLimits.Load(); limits != nil {
+		return *limits
+	}
+	return Limits{}
+}
+
+// WithLimits returns the parameter whose expressions are evaluated under the limits.
+func WithLimits(parameter Parameter, limits Limits) Parameter {
+	return WithOptions(parameter, func(options *Options) { options.Limits = limits })
+}
+
+// limitsOf returns the limits of the parameter, or the default limits if it carries none.
+func limitsOf(parameter Parameter) Limits {
+	if limits := OptionsOf(parameter).Limits; !limits.IsZero() {
+		return limits
+	}
+	return DefaultLimits()
+}
+
+// deadlineCheckInterval is the number of the evaluated nodes between the checks of the deadline,
+// so that the clock is not read for every node.
+const deadlineCheckInterval = 32
+
+// budget is the parameter of one evaluation under the limits or in trace mode,
+// it counts the calls and the evaluated nodes.
+type budget struct {
+	Parameter
+	limits   Limits
+	calls    int
+	nodes    int
+	deadline time.Time
+	// tracer is not nil if the evaluation is traced, see WithTrace.
+	tracer *tracer
+}
+
+func newBudget(parameter Parameter, limits Limits) *budget {
+	b := &budget{Parameter: parameter, limits: limits}
+	if limits.Timeout > 0 {
+		b.deadline = time.Now().Add(limits.Timeout)
+	}
+	return b
+}
+
+// Options implements OptionsParameter by the wrapped parameter,
+// so the expressions evaluated by the functions get the same options.
+func (b *budget) Options() Options { return OptionsOf(b.Parameter) }
+
+// Redacted implements RedactedParameter by the wrapped parameter.
+func (b *budget) Redacted(name string) bool { return IsRedacted(b.Parameter, name) }
+
+// visit is called before a node is evaluated.
+func (b *budget) visit() error {
+	b.nodes++
+	if b.limits.MaxNodes > 0 && b.nodes > b.limits.MaxNodes {
+		return &LimitError{Limit: LimitMaxNodes, Limits: b.limits, Actual: b.nodes}
+	}
+	if !b.deadline.IsZero() && b.nodes%deadlineCheckInterval == 0 && time.Now().After(b.deadline) {
+		return &LimitError{Limit: LimitTimeout, Limits: b.limits}
+	}
+	return nil
+}
+
+// call is called before a function is called.
+func (b *budget) call() error {
+	b.calls++
+	if b.limits.MaxCalls > 0 && b.calls > b.limits.MaxCalls {
+		return &LimitError{Limit: LimitMaxCalls, Limits: b.limits, Actual: b.calls}
+	}
+	if !b.deadline.IsZero() && time.Now().After(b.deadline) {
+		return &LimitError{Limit: LimitTimeout, Limits: b.limits}
+	}
+	return nil
+}
+
+// chargeCall charges a function call to the budget of the evaluation if there is one.
+func chargeCall(params Parameter) error {
+	if b, ok := params.(*budget); ok {
+		return b.call()
+	}
+	return nil
+}
+
+// CheckDepth returns a *LimitError if the depth of the compiled expression exceeds the MaxDepth of the limits.
+// The depth does not depend on the parameter, so the expressions can be checked once when they are compiled.
+func CheckDepth(expression Expression, limits Limits) error {
+	if e, ok := expression.(*goExpression); ok {
+		return checkDepth(e.depth, limits)
+	}
+	return nil
+}
+
+// checkDepth returns an error if the depth of the expression exceeds the limit.
+func checkDepth(depth int, limits Limits) error {
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return &LimitError{Limit: LimitMaxDepth, Limits: limits, Actual: depth}
+	}
+	return nil
+}
+
+// exprDepth returns the depth of the syntax tree of the expression.
+func exprDepth(exp ast.Expr) int {
+	var depth, maxDepth int
+	ast.Inspect(exp, func(node ast.Node) bool {
+		if node == nil {
+			depth--
+			return false
+		}
+		depth++
+		maxDepth = max(maxDepth, depth)
+		return true
+	})
+	return maxDepth
+}
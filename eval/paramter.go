@@ -97,6 +97,119 @@ func (g ParamGroup) Get(name string) (reflect.Value, bool) {
 	return reflect.Value{}, false
 }
 
+// RedactedParameter is implemented by the parameters which know whether the named parameter is sensitive,
+// the arguments of the sensitive parameters are masked in the logs.
+type RedactedParameter interface {
+	// Redacted reports whether the named parameter is sensitive.
+	Redacted(name string) bool
+}
+
+// Redacted implements RedactedParameter.
+// It reports true if any parameter of the group reports the named parameter is sensitive.
+func (g ParamGroup) Redacted(name string) bool {
+	for _, p := range g {
+		if IsRedacted(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRedacted reports whether the named parameter of the parameter is sensitive.
+func IsRedacted(parameter Parameter, name string) bool {
+	redacted, ok := parameter.(RedactedParameter)
+	return ok && redacted.Redacted(name)
+}
+
+// Options are the options of the evaluation of the expressions, which are carried by the parameters.
+type Options struct {
+	// Truthy enables the truthy mode, where the operands of &&, || and ! are not required to be bool,
+	// see expr.Truthy for the coercion.
+	Truthy bool
+
+	// FoldKeys resolves the names against the keys of the maps case-insensitively and by the naming
+	// conventions, see GenericParameter.FoldKeys.
+	FoldKeys bool
+
+	// Limits are the limits of the evaluation, the default limits are used if it is zero.
+	Limits Limits
+
+	// TraceHook enables the trace mode if it is not nil, see WithTrace.
+	TraceHook TraceHook
+}
+
+// OptionsParameter is implemented by the parameters which carry the options of the evaluation.
+// The parameters which wrap another one implement it by the wrapped one, so the options are
+// looked up by one method no matter how many options there are.
+type OptionsParameter interface {
+	// Options returns the options of the evaluation.
+	Options() Options
+}
+
+// Options implements OptionsParameter.
+// The modes are enabled if any parameter of the group enables them, and the first limits and hook
+// of the group are used, so the nested parameters like the items of foreach inherit them.
+func (g ParamGroup) Options() Options {
+	var options Options
+	for _, p := range g {
+		member := OptionsOf(p)
+		options.Truthy = options.Truthy || member.Truthy
+		options.FoldKeys = options.FoldKeys || member.FoldKeys
+		if options.Limits.IsZero() {
+			options.Limits = member.Limits
+		}
+		if options.TraceHook == nil {
+			options.TraceHook = member.TraceHook
+		}
+	}
+	return options
+}
+
+// OptionsOf returns the options of the parameter, which are zero if it carries none.
+func OptionsOf(parameter Parameter) Options {
+	if carrier, ok := parameter.(OptionsParameter); ok {
+		return carrier.Options()
+	}
+	return Options{}
+}
+
+// optionsParameter attaches the options to the wrapped parameter.
+type optionsParameter struct {
+	Parameter
+	options Options
+}
+
+// Options implements OptionsParameter.
+func (o optionsParameter) Options() Options { return o.options }
+
+// Redacted implements RedactedParameter by the wrapped parameter.
+func (o optionsParameter) Redacted(name string) bool { return IsRedacted(o.Parameter, name) }
+
+// WithOptions returns the parameter with the options of the parameter changed by the update.
+func WithOptions(parameter Parameter, update func(options *Options)) Parameter {
+	options := OptionsOf(parameter)
+	update(&options)
+	// the options are attached once, instead of being wrapped by each option.
+	if attached, ok := parameter.(optionsParameter); ok {
+		parameter = attached.Parameter
+	}
+	return optionsParameter{Parameter: parameter, options: options}
+}
+
+// WithTruthy returns the parameter which evaluates the expressions in truthy mode,
+// which matches the OGNL semantics, like "name and age" for a non-empty name and a non-zero age.
+func WithTruthy(parameter Parameter) Parameter {
+	if IsTruthy(parameter) {
+		return parameter
+	}
+	return WithOptions(parameter, func(options *Options) { options.Truthy = true })
+}
+
+// IsTruthy reports whether the parameter evaluates the expressions in truthy mode.
+func IsTruthy(parameter Parameter) bool {
+	return OptionsOf(parameter).Truthy
+}
+
 // make sure that structParameter implements Parameter.
 var _ Parameter = (*structParameter)(nil)
 
@@ -148,17 +261,67 @@ var _ Parameter = (*mapParameter)(nil)
 // mapParameter is a parameter that wraps a map.
 type mapParameter struct {
 	reflect.Value
+	// foldKeys resolves the names which do not match a key exactly, see foldKey.
+	foldKeys bool
 }
 
 // Get implements Parameter.
 func (p mapParameter) Get(name string) (reflect.Value, bool) {
-	value := p.MapIndex(reflect.ValueOf(name))
+	key := reflect.ValueOf(name)
+	if keyType := p.Type().Key(); keyType != key.Type() {
+		key = key.Convert(keyType)
+	}
+	value := p.MapIndex(key)
 	if !value.IsValid() {
+		if p.foldKeys {
+			return p.getFolded(name)
+		}
 		return reflect.Value{}, false
 	}
 	return value, true
 }
 
+// getFolded returns the value of the only key matching the name case-insensitively,
+// or else the only key matching it by foldKey, like user_name for userName.
+// The ambiguous names match nothing.
+func (p mapParameter) getFolded(name string) (reflect.Value, bool) {
+	folded := foldKey(name)
+	var caseMatched, foldMatched []reflect.Value
+	for iter := p.MapRange(); iter.Next(); {
+		key := iter.Key().String()
+		switch {
+		case strings.EqualFold(key, name):
+			caseMatched = append(caseMatched, iter.Value())
+		case foldKey(key) == folded:
+			foldMatched = append(foldMatched, iter.Value())
+		}
+	}
+	switch {
+	case len(caseMatched) == 1:
+		return caseMatched[0], true
+	case len(caseMatched) == 0 && len(foldMatched) == 1:
+		return foldMatched[0], true
+	default:
+		return reflect.Value{}, false
+	}
+}
+
+// foldKey returns the lower case of the key without the underscores and the hyphens,
+// so the snake_case, kebab-case, camelCase and PascalCase of a name are the same.
+func foldKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, key)
+}
+
+// IsFoldKeys reports whether the parameter folds the keys of the maps.
+func IsFoldKeys(parameter Parameter) bool {
+	return OptionsOf(parameter).FoldKeys
+}
+
 // make sure that sliceParameter implements Parameter.
 var _ Parameter = (*sliceParameter)(nil)
 
@@ -197,13 +360,18 @@ type GenericParameter struct {
 	// This three-level cache design ensures that field indexes are not mixed between different struct types,
 	// which is particularly important when dealing with slices of different struct types.
 	structFieldIndex map[int]map[reflect.Type]map[string][]int
+
+	// FoldKeys resolves the names which do not match the keys of the maps exactly, case-insensitively
+	// and by the naming conventions, like user_name for userName. The ambiguous names match nothing.
+	FoldKeys bool
 }
 
 func (g *GenericParameter) get(name string) (value reflect.Value, exists bool) {
 	value = g.Value
-	items := strings.Split(name, ".")
-	var param Parameter
-	for i, item := range items {
+	// the path is walked by strings.Cut instead of being split, so no slice is allocated.
+	for i, rest, more := 0, name, true; more; i++ {
+		var item string
+		item, rest, more = strings.Cut(rest, ".")
 
 		// only unwrap when the value need to call Get method
 		value = reflectlite.Unwrap(value)
@@ -216,7 +384,7 @@ func (g *GenericParameter) get(name string) (value reflect.Value, exists bool) {
 			if value.Type().Key().Kind() != reflect.String {
 				return reflect.Value{}, false
 			}
-			param = mapParameter{Value: value}
+			value, exists = mapParameter{Value: value, foldKeys: g.FoldKeys}.Get(item)
 		case reflect.Struct:
 			// Initialize the three-level cache if not exists:
 			// Level 1: path position -> to handle different levels in the path (e.g., user.address.street)
@@ -242,14 +410,14 @@ func (g *GenericParameter) get(name string) (value reflect.Value, exists bool) {
 			// Create a new structParameter with its field cache pointing to
 			// the cached indexes for its specific type, ensuring different
 			// struct types don't share the same field index cache
-			param = &structParameter{Value: value, fieldIndexes: structFieldIndex[valueType]}
+			value, exists = (&structParameter{Value: value, fieldIndexes: structFieldIndex[valueType]}).Get(item)
 		case reflect.Slice, reflect.Array:
-			param = sliceParameter{Value: value}
+			value, exists = sliceParameter{Value: value}.Get(item)
 		default:
 			// otherwise, return false
 			return reflect.Value{}, false
 		}
-		value, exists = param.Get(item)
+		// the parameters of the levels are not boxed into Parameter, so they are not allocated.
 		if !exists {
 			return reflect.Value{}, false
 		}
@@ -277,17 +445,127 @@ func (g *GenericParameter) Get(name string) (value reflect.Value, exists bool) {
 	return value, exists
 }
 
+// Redacted implements RedactedParameter.
+// The struct field is sensitive if its param tag has the redact option, like `param:"password,redact"`.
+func (g *GenericParameter) Redacted(name string) bool {
+	parent, fieldName := g.Value, name
+	if index := strings.LastIndexByte(name, '.'); index >= 0 {
+		value, exists := g.Get(name[:index])
+		if !exists {
+			return false
+		}
+		parent, fieldName = value, name[index+1:]
+	}
+	return fieldRedacted(parent, fieldName)
+}
+
+// fieldRedacted reports whether the named field of the struct is tagged with the redact option.
+func fieldRedacted(parent reflect.Value, fieldName string) bool {
+	parent = reflectlite.Unwrap(parent)
+	if parent.Kind() != reflect.Struct || len(fieldName) == 0 {
+		return false
+	}
+	var field reflect.StructField
+	if unicode.IsUpper(rune(fieldName[0])) {
+		var ok bool
+		if field, ok = parent.Type().FieldByName(fieldName); !ok {
+			return false
+		}
+	} else {
+		indexes, ok := reflectlite.TypeFrom(parent.Type()).GetFieldIndexesFromTag(defaultParamKey, fieldName)
+		if !ok {
+			return false
+		}
+		field = parent.Type().FieldByIndex(indexes)
+	}
+	_, options, _ := strings.Cut(field.Tag.Get(defaultParamKey), ",")
+	for _, option := range strings.Split(options, ",") {
+		if strings.TrimSpace(option) == "redact" {
+			return true
+		}
+	}
+	return false
+}
+
+// Options implements OptionsParameter, only the FoldKeys is carried.
+func (g *GenericParameter) Options() Options { return Options{FoldKeys: g.FoldKeys} }
+
 // Clear clears the cache of the parameter.
 func (g *GenericParameter) Clear() {
 	clear(g.cache)
 }
 
+// ItemParameter is the parameter of the current item of an iteration, like the foreach node.
+// It is created once for the iteration and bound to each item, the item and the paths under it,
+// like item.user.name, are resolved directly from the bound item without being cached, so that
+// no scope is recreated or cleared for the items. The field indexes of the struct items are
+// shared by the items.
+type ItemParameter struct {
+	item, index reflect.Value
+	itemName    string
+	indexName   string
+	// itemPrefix is the item name with the dot, like "item.".
+	itemPrefix string
+	// path resolves the paths under the item.
+	path GenericParameter
+}
+
+// NewItemParameter returns the parameter of the items named item, the index is named index
+// which is optional. The names under the item are resolved case-insensitively if foldKeys is true,
+// see GenericParameter.FoldKeys.
+func NewItemParameter(item, index string, foldKeys bool) *ItemParameter {
+	return &ItemParameter{itemName: item, indexName: index, itemPrefix: item + ".", path: GenericParameter{FoldKeys: foldKeys}}
+}
+
+// Bind binds the parameter to the item and its index.
+func (p *ItemParameter) Bind(item, index reflect.Value) {
+	p.item, p.index = item, index
+	p.path.Value = item
+}
+
+// Get implements Parameter.
+func (p *ItemParameter) Get(name string) (reflect.Value, bool) {
+	switch {
+	case name == p.itemName:
+		return p.item, true
+	case name == p.indexName && p.indexName != "":
+		return p.index, true
+	case strings.HasPrefix(name, p.itemPrefix):
+		return p.path.get(name[len(p.itemPrefix):])
+	default:
+		return reflect.Value{}, false
+	}
+}
+
+// Redacted implements RedactedParameter, the fields of the item are sensitive like the ones of GenericParameter.
+func (p *ItemParameter) Redacted(name string) bool {
+	if !strings.HasPrefix(name, p.itemPrefix) {
+		return false
+	}
+	parent, fieldName := p.item, name[len(p.itemPrefix):]
+	if index := strings.LastIndexByte(fieldName, '.'); index >= 0 {
+		value, exists := p.path.get(fieldName[:index])
+		if !exists {
+			return false
+		}
+		parent, fieldName = value, fieldName[index+1:]
+	}
+	return fieldRedacted(parent, fieldName)
+}
+
+// Options implements OptionsParameter, only the FoldKeys is carried.
+func (p *ItemParameter) Options() Options { return p.path.Options() }
+
 // NewGenericParam creates a generic parameter.
 // if the value is not a map, struct, slice or array, then wrap it as a map.
 func NewGenericParam(v any, wrapKey string) Parameter {
 	if v == nil {
 		return noOPParameter
 	}
+	// the parameter is used as it is, like the ParamGroup.
+	if parameter, ok := v.(Parameter); ok {
+		return parameter
+	}
 	value := reflect.ValueOf(v)
 
 	tp := reflectlite.IndirectType(value.Type())
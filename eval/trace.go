@@ -0,0 +1,128 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package eval
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+)
+
+// RedactedValue is the value recorded in the traces instead of the sensitive parameters,
+// see RedactedParameter.
+const RedactedValue = "***"
+
+// TraceStep is a sub expression evaluated in trace mode, see WithTrace.
+type TraceStep struct {
+	// Expr is the source of the sub expression, like "age >= 18" of "age >= 18 and name != nil".
+	Expr string
+	// Depth is the depth of the sub expression in the syntax tree, the whole expression is 0.
+	Depth int
+	// Operands are the results of the sub expressions evaluated directly by this one, in the order of
+	// evaluation. The operands which are not evaluated, like the right side of a short-circuited &&, are absent.
+	Operands []any
+	// Result is the result of the sub expression, it is nil if the evaluation failed.
+	Result any
+	// Err is the error of the evaluation.
+	Err error
+}
+
+// TraceHook is called with each sub expression once it is evaluated, the sub expressions
+// are reported before the expressions which contain them, so the whole expression is the last step.
+type TraceHook func(step TraceStep)
+
+// WithTrace returns the parameter whose expressions are evaluated in trace mode, the hook is called
+// with each evaluated sub expression, its operands and its result. It is meant for diagnosing
+// the expressions, like why an <if> branch is not taken, since the trace mode slows the evaluation down.
+//
+//	value, err := eval.Eval("age >= 18 and name != nil", eval.WithTrace(param, func(step eval.TraceStep) {
+//	    log.Printf("%*s%s %v => %v", step.Depth*2, "", step.Expr, step.Operands, step.Result)
+//	}))
+func WithTrace(parameter Parameter, hook TraceHook) Parameter {
+	if hook == nil {
+		return parameter
+	}
+	return WithOptions(parameter, func(options *Options) { options.TraceHook = hook })
+}
+
+// tracer records the steps of one evaluation.
+type tracer struct {
+	hook       TraceHook
+	expression *goExpression
+	// frames are the operands collected by the sub expressions being evaluated.
+	frames [][]any
+}
+
+// eval evaluates the sub expression and reports it to the hook.
+func (t *tracer) eval(exp ast.Expr, params Parameter) (reflect.Value, error) {
+	depth := len(t.frames)
+	t.frames = append(t.frames, nil)
+	value, err := evalNode(exp, params)
+	operands := t.frames[depth]
+	t.frames = t.frames[:depth]
+
+	step := TraceStep{Expr: t.span(exp), Depth: depth, Operands: operands, Err: err}
+	if err == nil {
+		step.Result = t.result(exp, value, params)
+	}
+	if depth > 0 {
+		t.frames[depth-1] = append(t.frames[depth-1], step.Result)
+	}
+	t.hook(step)
+	return value, err
+}
+
+// constant reports the expression folded into a constant as a single step.
+func (t *tracer) constant(value reflect.Value) {
+	t.hook(TraceStep{Expr: t.expression.lexer.input, Result: traceValue(value)})
+}
+
+// span returns the source of the sub expression in the expression.
+func (t *tracer) span(exp ast.Expr) string {
+	// the positions of parser.ParseExpr start from 1.
+	start, end := int(exp.Pos())-1, int(exp.End())-1
+	if span, _, ok := t.expression.lexer.inputSpan(start, end); ok {
+		return span
+	}
+	// the folded or rewritten sub expressions are printed.
+	return types.ExprString(exp)
+}
+
+// result returns the recorded result of the sub expression, the sensitive parameters are masked.
+func (t *tracer) result(exp ast.Expr, value reflect.Value, params Parameter) any {
+	if redacted, ok := params.(RedactedParameter); ok {
+		var name string
+		switch exp := exp.(type) {
+		case *ast.Ident:
+			name = exp.Name
+		case *ast.SelectorExpr:
+			name, _ = selectorPath(exp)
+		}
+		if name != "" && redacted.Redacted(name) {
+			return RedactedValue
+		}
+	}
+	return traceValue(value)
+}
+
+// traceValue returns the value as any, nil if it is invalid or can not be accessed.
+func traceValue(value reflect.Value) any {
+	if !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
+}
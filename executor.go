@@ -142,16 +142,29 @@ func (e *GenericExecutor[T]) QueryContext(ctx context.Context, p Param) (result
 		if !errors.Is(err, ErrResultMapNotSet) {
 			return result, err
 		}
+		retMap = defaultResultMap[T](statement)
 	}
 
+	// the rows scanned are counted against the row quota of the statement, see QuotaMiddleware.
+	ctx, scanned := withScannedRows(ctx, statement)
+
 	// try to query the database.
 	rows, err := e.SQLRowsExecutor.QueryContext(ctx, p)
 	if err != nil {
+		// serve the default result of the fallback if any.
+		result, _, err = queryFallback[T](ctx, e.SQLRowsExecutor, p, err)
 		return result, err
 	}
 	defer func() { _ = rows.Close() }()
 
-	return BindWithResultMap[T](rows, retMap)
+	if result, err = BindWithResultMap[T](rows, retMap); err == nil {
+		recordResultRows(statement, result)
+		scanned.done(resultRows(result))
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		// serve the default result of the fallback for the scanning errors as well.
+		result, _, err = queryFallback[T](ctx, e.SQLRowsExecutor, p, err)
+	}
+	return result, err
 }
 
 // ExecContext executes the query and returns the result.
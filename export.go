@@ -0,0 +1,254 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportFormat is the format of the rows written by Engine.Export.
+type ExportFormat string
+
+const (
+	// ExportCSV writes the rows as CSV, with the column names as the header.
+	ExportCSV ExportFormat = "csv"
+
+	// ExportJSONLines writes every row as a JSON object in a line, keyed by the column names.
+	ExportJSONLines ExportFormat = "jsonl"
+)
+
+// exportOptions are the options of Engine.Export.
+type exportOptions struct {
+	noHeader   bool
+	header     map[string]string
+	timeFormat string
+	nullValue  string
+}
+
+// ExportOption is a function to set the options of Engine.Export.
+type ExportOption func(options *exportOptions)
+
+// WithoutExportHeader skips the header line of the CSV.
+func WithoutExportHeader() ExportOption {
+	return func(options *exportOptions) {
+		options.noHeader = true
+	}
+}
+
+// WithExportHeader renames the columns in the CSV header or the JSON keys,
+// the columns not in the map keep their names.
+func WithExportHeader(header map[string]string) ExportOption {
+	return func(options *exportOptions) {
+		options.header = header
+	}
+}
+
+// WithExportTimeFormat sets the layout of the time values, time.RFC3339Nano by default.
+func WithExportTimeFormat(layout string) ExportOption {
+	return func(options *exportOptions) {
+		options.timeFormat = layout
+	}
+}
+
+// WithExportNullValue sets the text of the NULL values in the CSV, it is empty by default.
+// NULL is always written as null in JSON Lines.
+func WithExportNullValue(value string) ExportOption {
+	return func(options *exportOptions) {
+		options.nullValue = value
+	}
+}
+
+// Export executes the select statement and streams the rows to the writer in the given format,
+// without scanning them into the Go structs, which is ideal for the report downloads.
+//
+//	err := engine.Export(ctx, "main.ReportRepository.Orders", param, w, juice.ExportCSV,
+//		juice.WithExportHeader(map[string]string{"created_at": "Created At"}),
+//	)
+//
+// The statement is executed by the manager of the ctx if there is one, like the transaction of juice.Transaction.
+func (e *Engine) Export(ctx context.Context, v any, param Param, w io.Writer, format ExportFormat, opts ...ExportOption) error {
+	options := exportOptions{timeFormat: time.RFC3339Nano}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	var writer rowsWriter
+	switch format {
+	case ExportCSV:
+		writer = &csvRowsWriter{options: options, writer: csv.NewWriter(w)}
+	case ExportJSONLines:
+		writer = &jsonLinesRowsWriter{options: options, writer: bufio.NewWriter(w)}
+	default:
+		return fmt.Errorf("juice: unsupported export format: %q", format)
+	}
+	rows, err := e.contextObject(ctx, v).QueryContext(ctx, param)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if err = writer.Header(columns); err != nil {
+		return err
+	}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return err
+		}
+		if err = writer.Row(values); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// rowsWriter writes the scanned rows in an export format.
+type rowsWriter interface {
+	Header(columns []string) error
+	Row(values []any) error
+	Flush() error
+}
+
+// headerName returns the name of the column in the export.
+func (o exportOptions) headerName(column string) string {
+	if name, ok := o.header[column]; ok {
+		return name
+	}
+	return column
+}
+
+// csvRowsWriter writes the rows as CSV.
+type csvRowsWriter struct {
+	options exportOptions
+	writer  *csv.Writer
+	record  []string
+}
+
+// Header implements rowsWriter.
+func (c *csvRowsWriter) Header(columns []string) error {
+	c.record = make([]string, len(columns))
+	if c.options.noHeader {
+		return nil
+	}
+	for i, column := range columns {
+		c.record[i] = c.options.headerName(column)
+	}
+	return c.writer.Write(c.record)
+}
+
+// Row implements rowsWriter.
+func (c *csvRowsWriter) Row(values []any) error {
+	for i, value := range values {
+		c.record[i] = c.format(value)
+	}
+	return c.writer.Write(c.record)
+}
+
+// format returns the text of the value scanned from the driver.
+func (c *csvRowsWriter) format(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return c.options.nullValue
+	case []byte:
+		return string(value)
+	case string:
+		return value
+	case time.Time:
+		return value.Format(c.options.timeFormat)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Flush implements rowsWriter.
+func (c *csvRowsWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// jsonLinesRowsWriter writes the rows as JSON Lines, the keys keep the order of the columns.
+type jsonLinesRowsWriter struct {
+	options exportOptions
+	writer  *bufio.Writer
+	keys    [][]byte
+}
+
+// Header implements rowsWriter.
+func (j *jsonLinesRowsWriter) Header(columns []string) error {
+	j.keys = make([][]byte, len(columns))
+	for i, column := range columns {
+		key, err := json.Marshal(j.options.headerName(column))
+		if err != nil {
+			return err
+		}
+		j.keys[i] = key
+	}
+	return nil
+}
+
+// Row implements rowsWriter.
+func (j *jsonLinesRowsWriter) Row(values []any) error {
+	_ = j.writer.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			_ = j.writer.WriteByte(',')
+		}
+		_, _ = j.writer.Write(j.keys[i])
+		_ = j.writer.WriteByte(':')
+		switch v := value.(type) {
+		case []byte:
+			value = string(v)
+		case time.Time:
+			value = v.Format(j.options.timeFormat)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, _ = j.writer.Write(encoded)
+	}
+	_, err := j.writer.WriteString("}\n")
+	return err
+}
+
+// Flush implements rowsWriter.
+func (j *jsonLinesRowsWriter) Flush() error {
+	return j.writer.Flush()
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportRowsWriter(t *testing.T) {
+	options := exportOptions{timeFormat: time.DateOnly, nullValue: "NULL", header: map[string]string{"name": "Name"}}
+	columns := []string{"id", "name", "created_at"}
+	row := []any{int64(1), []byte("eat,more"), time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)}
+
+	var output strings.Builder
+	tests := []struct {
+		writer rowsWriter
+		want   string
+	}{
+		{
+			writer: &csvRowsWriter{options: options, writer: csv.NewWriter(&output)},
+			want:   "id,Name,created_at\n1,\"eat,more\",2025-01-02\nNULL,NULL,NULL\n",
+		},
+		{
+			writer: &jsonLinesRowsWriter{options: options, writer: bufio.NewWriter(&output)},
+			want:   `{"id":1,"Name":"eat,more","created_at":"2025-01-02"}` + "\n" + `{"id":null,"Name":null,"created_at":null}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		writer, want := tt.writer, tt.want
+		output.Reset()
+		if err := writer.Header(columns); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Row(row); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Row(make([]any, 3)); err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if got := output.String(); got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+// failingManager is the manager whose executors fail with the error.
+type failingManager struct{ err error }
+
+func (m failingManager) Object(any) SQLRowsExecutor { return inValidExecutor(m.err) }
+
+func TestEngine_Export_ContextManager(t *testing.T) {
+	// the statement is queried by the manager of the context, like a transaction.
+	errFromTx := errors.New("queried by the transaction")
+	ctx := ContextWithManager(context.Background(), failingManager{err: errFromTx})
+	engine := &Engine{rw: &NoOpRWMutex{}}
+	if err := engine.Export(ctx, "main.ReportRepository.Orders", nil, io.Discard, ExportCSV); !errors.Is(err, errFromTx) {
+		t.Fatalf("expected the error of the manager of the context, got %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-juicedev/juice/eval"
+)
+
+// ExprTraceEntry is a test expression evaluated when a statement is built.
+type ExprTraceEntry struct {
+	// Statement is the full name of the statement, like "main.UserRepository.Search".
+	Statement string
+	// Expr is the test expression.
+	Expr string
+	// Source is the position of the node of the expression in the mapper file.
+	Source SourcePosition
+	// Inputs is the parameters referenced by the expression with their values,
+	// the missing parameters are absent and the sensitive ones are redacted.
+	Inputs map[string]any
+	// Result is the outcome of the expression, false if it failed.
+	Result bool
+	// Err is the error of the evaluation.
+	Err error
+	// Steps are the evaluated sub expressions in the order of their completions, the whole expression is the last one.
+	Steps []eval.TraceStep
+}
+
+// ExprTrace records the test expressions evaluated by the statements built with the context,
+// see WithExprTrace. It is safe for concurrent use.
+type ExprTrace struct {
+	mu      sync.Mutex
+	entries []ExprTraceEntry
+}
+
+// Entries returns the evaluated expressions in order.
+func (t *ExprTrace) Entries() []ExprTraceEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ExprTraceEntry(nil), t.entries...)
+}
+
+func (t *ExprTrace) add(entry ExprTraceEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, entry)
+}
+
+// exprTraceKey is the context key of the ExprTrace.
+type exprTraceKey struct{}
+
+// WithExprTrace returns a new context with an empty ExprTrace, which records each test expression
+// evaluated by the statements built with the context, to find out why a fragment (dis)appears.
+//
+//	ctx = juice.WithExprTrace(ctx)
+//	users, err := executor.QueryContext(ctx, param)
+//	for _, entry := range juice.ExprTraceFromContext(ctx).Entries() {
+//	    log.Printf("%s: %s %v => %v", entry.Statement, entry.Expr, entry.Inputs, entry.Result)
+//	}
+func WithExprTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, exprTraceKey{}, &ExprTrace{})
+}
+
+// ExprTraceFromContext returns the ExprTrace of the context, or nil if the context is not traced.
+func ExprTraceFromContext(ctx context.Context) *ExprTrace {
+	trace, _ := ctx.Value(exprTraceKey{}).(*ExprTrace)
+	return trace
+}
+
+// exprTraceParameter is the parameter of a traced build of the statement.
+type exprTraceParameter struct {
+	Parameter
+	trace     *ExprTrace
+	statement string
+}
+
+// Options implements eval.OptionsParameter by the wrapped parameter.
+func (t exprTraceParameter) Options() eval.Options { return eval.OptionsOf(t.Parameter) }
+
+// Redacted implements eval.RedactedParameter by the wrapped parameter.
+func (t exprTraceParameter) Redacted(name string) bool { return eval.IsRedacted(t.Parameter, name) }
+
+// exprTraceOf returns the traced parameter of the build, which may be a member of the groups of foreach.
+func exprTraceOf(p Parameter) (exprTraceParameter, bool) {
+	switch p := p.(type) {
+	case exprTraceParameter:
+		return p, true
+	case eval.ParamGroup:
+		for _, member := range p {
+			if traced, ok := exprTraceOf(member); ok {
+				return traced, true
+			}
+		}
+	}
+	return exprTraceParameter{}, false
+}
+
+// match evaluates the condition in trace mode and records it.
+func (t exprTraceParameter) match(c *ConditionNode, p Parameter) (bool, error) {
+	var steps []eval.TraceStep
+	result, err := c.match(eval.WithTrace(p, func(step eval.TraceStep) { steps = append(steps, step) }))
+	entry := ExprTraceEntry{Statement: t.statement, Expr: c.test, Source: c.source, Result: result, Err: err, Steps: steps}
+	for _, name := range eval.Identifiers(c.expr) {
+		if value, exists := p.Get(name); exists && value.IsValid() {
+			if entry.Inputs == nil {
+				entry.Inputs = make(map[string]any)
+			}
+			entry.Inputs[name] = redactArg(p, name, value.Interface())
+		}
+	}
+	t.trace.add(entry)
+	return result, err
+}
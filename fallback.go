@@ -0,0 +1,104 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// fallbackRegistry is the fallbacks registered to the engine, it is shared by the cloned engines.
+type fallbackRegistry struct {
+	mu        sync.RWMutex
+	fallbacks map[string]any
+}
+
+func (r *fallbackRegistry) get(name string) (any, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fallback, ok := r.fallbacks[name]
+	return fallback, ok
+}
+
+// FallbackFunc provides the default result of the failed query of a statement.
+type FallbackFunc[T any] func(ctx context.Context, param Param) (T, error)
+
+// Fallback registers the fallback of the statement of v to the engine, which provides the default result
+// when the query or the scanning of the statement fails, so the read paths can serve sensible defaults
+// instead of errors. The fallback is only called for the executors whose result type is T.
+//
+//	juice.Fallback(engine, "main.UserRepository.HotItems", func(ctx context.Context, param juice.Param) ([]Item, error) {
+//	    return defaultItems, nil
+//	})
+//
+// The fallback is called with the error of the query, which is returned by FallbackErrorFromContext.
+// It is not called when the context is done, the statement is executed in a transaction,
+// or no rows are found for a single result. The fallback registered for the same statement is replaced.
+// The engine must be created by New, whose fallbacks are shared by the cloned engines.
+func Fallback[T any](engine *Engine, v any, fn FallbackFunc[T]) error {
+	if fn == nil {
+		return errors.New("fallback is nil")
+	}
+	if engine.fallbacks == nil {
+		panic("juice: Fallback on an engine not created by New")
+	}
+	statement, err := engine.GetConfiguration().GetStatement(v)
+	if err != nil {
+		return err
+	}
+	engine.fallbacks.mu.Lock()
+	defer engine.fallbacks.mu.Unlock()
+	if engine.fallbacks.fallbacks == nil {
+		engine.fallbacks.fallbacks = make(map[string]any)
+	}
+	engine.fallbacks.fallbacks[statement.Name()] = fn
+	return nil
+}
+
+// fallbackExecutor is the SQLRowsExecutor of the statement which has a fallback.
+type fallbackExecutor struct {
+	SQLRowsExecutor
+	fallback any
+}
+
+// queryFallback calls the fallback of the executor for the failed query,
+// it reports false if there is no fallback of the type T.
+func queryFallback[T any](ctx context.Context, executor SQLRowsExecutor, param Param, queryErr error) (result T, ok bool, err error) {
+	exe, ok := executor.(*fallbackExecutor)
+	if !ok || ctx.Err() != nil {
+		return result, false, queryErr
+	}
+	fallback, ok := exe.fallback.(FallbackFunc[T])
+	if !ok {
+		logger.Printf("ignored the fallback %T of the statement %s for the result type %T", exe.fallback, exe.Statement().Name(), result)
+		return result, false, queryErr
+	}
+	result, err = fallback(context.WithValue(ctx, fallbackErrorKey{}, queryErr), param)
+	return result, true, err
+}
+
+type fallbackErrorKey struct{}
+
+// FallbackErrorFromContext returns the error of the failed query in the fallback.
+func FallbackErrorFromContext(ctx context.Context) error {
+	err, _ := ctx.Value(fallbackErrorKey{}).(error)
+	return err
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+func TestEngine_Fallback(t *testing.T) {
+	cfg, err := NewXMLConfiguration("testdata/configuration/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the fake connection fails all the queries.
+	db := sql.OpenDB(batchConnector{counter: &batchCounter{}})
+	t.Cleanup(func() { _ = db.Close() })
+	engine := &Engine{configuration: cfg, db: db, driver: bulkDriver{}, rw: &NoOpRWMutex{}, fallbacks: &fallbackRegistry{}}
+
+	const id = "main.Repository.HelloWorld"
+	if err = Fallback[string](engine, id, nil); err == nil {
+		t.Fatal("expected an error for the nil fallback")
+	}
+	if _, err = NewGenericManager[string](engine).Object(id).QueryContext(context.Background(), nil); err == nil {
+		t.Fatal("expected the query to fail without the fallback")
+	}
+
+	var queryErr error
+	err = Fallback(engine, id, func(ctx context.Context, param Param) (string, error) {
+		queryErr = FallbackErrorFromContext(ctx)
+		return "hello fallback", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewGenericManager[string](engine).Object(id).QueryContext(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "hello fallback" || queryErr == nil {
+		t.Fatalf("unexpected fallback result %q, error %v", result, queryErr)
+	}
+	// the fallback of the other result type is ignored.
+	if _, err = NewGenericManager[int](engine).Object(id).QueryContext(context.Background(), nil); err == nil {
+		t.Fatal("expected the query to fail for the other result type")
+	}
+}
+
+func TestEngine_FallbackOfScanError(t *testing.T) {
+	mapper := `<mapper namespace="user">
+    <select id="LastLogin">select id from user</select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mappers Mappers
+	if err = mappers.setMapper(m.namespace, m); err != nil {
+		t.Fatal(err)
+	}
+	// the fake connection returns the id 42, which can not be scanned as a time.
+	db := sql.OpenDB(compositeConn{execs: new([]string), commits: new(int)})
+	t.Cleanup(func() { _ = db.Close() })
+	engine := &Engine{configuration: Configuration{mappers: &mappers}, db: db, driver: driver.MySQLDriver{}, rw: &NoOpRWMutex{}, fallbacks: &fallbackRegistry{}}
+
+	epoch := time.Unix(0, 0)
+	var scanErr error
+	err = Fallback(engine, "user.LastLogin", func(ctx context.Context, param Param) (time.Time, error) {
+		scanErr = FallbackErrorFromContext(ctx)
+		return epoch, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewGenericManager[time.Time](engine).Object("user.LastLogin").QueryContext(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Equal(epoch) || scanErr == nil {
+		t.Fatalf("unexpected fallback result %v, error %v", result, scanErr)
+	}
+}
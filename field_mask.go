@@ -0,0 +1,149 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/internal/reflectlite"
+)
+
+// FieldMask is a set of the fields which should be written by a partial update.
+// A field can be either a column name or a parameter name of the assignment.
+//
+// Example XML:
+//
+//	<update id="patchUser">
+//	  UPDATE users
+//	  <set fieldMask="mask">
+//	    name = #{user.name}, age = #{user.age}, email = #{user.email}
+//	  </set>
+//	  WHERE id = #{user.id}
+//	</update>
+//
+// Example usage:
+//
+//	param := juice.H{"user": user, "mask": juice.NewFieldMask("name", "email")}
+//	// UPDATE users SET name = ?, email = ? WHERE id = ?
+//
+// Besides FieldMask, the mask parameter can also be a []string or a map whose keys are the fields,
+// like the decoded body of a JSON merge patch request.
+type FieldMask []string
+
+// NewFieldMask returns a FieldMask with the given fields.
+func NewFieldMask(fields ...string) FieldMask {
+	return fields
+}
+
+// FieldMaskFromMap returns a FieldMask with the keys of the given map.
+// It is useful for the JSON merge patch, which only contains the changed fields.
+func FieldMaskFromMap[V any](fields map[string]V) FieldMask {
+	mask := make(FieldMask, 0, len(fields))
+	for field := range fields {
+		mask = append(mask, field)
+	}
+	slices.Sort(mask)
+	return mask
+}
+
+// Contains reports whether the field is in the mask.
+func (f FieldMask) Contains(field string) bool {
+	return slices.Contains(f, field)
+}
+
+// toFieldMask converts the value of the mask parameter to a FieldMask.
+func toFieldMask(value reflect.Value) (FieldMask, error) {
+	value = reflectlite.Unwrap(value)
+	if !value.IsValid() {
+		return nil, nil
+	}
+	if mask, ok := value.Interface().(FieldMask); ok {
+		return mask, nil
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		mask := make(FieldMask, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			field := reflectlite.Unwrap(value.Index(i))
+			if field.Kind() != reflect.String {
+				return nil, fmt.Errorf("invalid field mask element type %s", field.Type())
+			}
+			mask = append(mask, field.String())
+		}
+		return mask, nil
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("invalid field mask key type %s", value.Type().Key())
+		}
+		mask := make(FieldMask, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			mask = append(mask, key.String())
+		}
+		return mask, nil
+	default:
+		return nil, fmt.Errorf("invalid field mask type %s", value.Type())
+	}
+}
+
+// fieldMaskNode wraps an assignment of the SET clause which is declared with fieldMask,
+// it renders nothing if neither the column nor the parameters of the assignment are in the mask.
+type fieldMaskNode struct {
+	node        Node
+	column      string
+	placeholder [][]string
+	mask        string
+}
+
+// Accept accepts parameters and returns query and arguments.
+func (f fieldMaskNode) Accept(translator driver.Translator, p Parameter) (query string, args []any, err error) {
+	value, exists := p.Get(f.mask)
+	if !exists {
+		return "", nil, fmt.Errorf("field mask %s not found", f.mask)
+	}
+	mask, err := toFieldMask(value)
+	if err != nil {
+		return "", nil, err
+	}
+	if !f.masked(mask) {
+		return "", nil, nil
+	}
+	return f.node.Accept(translator, p)
+}
+
+// masked reports whether the assignment is selected by the mask.
+func (f fieldMaskNode) masked(mask FieldMask) bool {
+	if mask.Contains(f.column) {
+		return true
+	}
+	for _, param := range f.placeholder {
+		name := param[1]
+		if mask.Contains(name) {
+			return true
+		}
+		// #{user.name} is also selected by name.
+		if index := strings.LastIndex(name, "."); index != -1 && mask.Contains(name[index+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ Node = (*fieldMaskNode)(nil)
@@ -17,10 +17,13 @@ limitations under the License.
 package juice
 
 import (
+	"errors"
 	"os"
+	"reflect"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // replacer defines the replacer of function name
@@ -34,6 +37,9 @@ func runtimeFuncName(addr uintptr) string {
 	return strings.TrimSuffix(name, "-fm")
 }
 
+// funcNameCacheSize is the number of the function names cached by cachedRuntimeFuncName.
+var funcNameCacheSize atomic.Int64
+
 // _cachedRuntimeFuncName initializes a cached version of runtimeFuncName
 // The function is unexported to avoid polluting the package namespace,
 // while its returned function is assigned to an exported variable.
@@ -52,7 +58,9 @@ func _cachedRuntimeFuncName() func(addr uintptr) string {
 				return name.(string)
 			}
 			name := runtimeFuncName(addr)
-			cache.Store(addr, name)
+			if _, loaded := cache.LoadOrStore(addr, name); !loaded {
+				funcNameCacheSize.Add(1)
+			}
 			return name
 		}
 	}
@@ -62,3 +70,16 @@ func _cachedRuntimeFuncName() func(addr uintptr) string {
 // cachedRuntimeFuncName is a cached version of runtimeFuncName
 // It stores function names in memory to avoid repeated processing
 var cachedRuntimeFuncName = _cachedRuntimeFuncName()
+
+// StatementIDOf returns the statement id of the function, which is resolved from its runtime name.
+// The function can be a method value like repo.FindByID, or a method expression like UserRepository.FindByID,
+// both of them are resolved as "package.UserRepository.FindByID", the namespace of the mapper and the id of the statement.
+//
+// Resolving the statements from the methods keeps the call sites refactor-safe without the generated constants.
+func StatementIDOf(fn any) (string, error) {
+	value := reflect.Indirect(reflect.ValueOf(fn))
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return "", errors.New("statement id requires a non-nil function")
+	}
+	return cachedRuntimeFuncName(value.Pointer()), nil
+}
@@ -191,3 +191,22 @@ func BenchmarkFuncNameUnderMemoryPressure(b *testing.B) {
 		}
 	})
 }
+
+func TestStatementIDOf(t *testing.T) {
+	var s testStruct
+	for _, fn := range []any{testStruct.testMethod, s.testMethod} {
+		id, err := StatementIDOf(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != "github.com.go-juicedev.juice.testStruct.testMethod" {
+			t.Fatalf("unexpected id: %s", id)
+		}
+	}
+	if id, _ := StatementIDOf((*testStruct).pointerMethod); id != "github.com.go-juicedev.juice.testStruct.pointerMethod" {
+		t.Fatalf("unexpected id: %s", id)
+	}
+	if _, err := StatementIDOf("main.Repository.HelloWorld"); err == nil {
+		t.Fatal("expected error for non function")
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// HealthStatus is the status of the engine or its component.
+type HealthStatus string
+
+const (
+	// HealthUp means the component works.
+	HealthUp HealthStatus = "up"
+
+	// HealthDegraded means the component works with problems, like a lagging replica.
+	HealthDegraded HealthStatus = "degraded"
+
+	// HealthDown means the component does not work.
+	HealthDown HealthStatus = "down"
+)
+
+// HealthChecker is implemented by the components which can check their health,
+// like the caches backed by redis registered by Engine.RegisterCache.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ComponentHealth is the health of a component of the engine.
+type ComponentHealth struct {
+	// Name is the id of the environment or the name of the cache.
+	Name string `json:"name"`
+
+	// Kind is the kind of the component, environment or cache.
+	Kind string `json:"kind"`
+
+	Status HealthStatus `json:"status"`
+
+	// Latency is how long the check takes.
+	Latency time.Duration `json:"latency"`
+
+	// ReplicaLag is the lag of the replica reported by the replicaLagQuery of the environment.
+	ReplicaLag time.Duration `json:"replicaLag,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// HealthReport is the health of the engine reported by Engine.HealthCheck.
+type HealthReport struct {
+	// Status is down if the default environment is down, degraded if any other component
+	// is not up, otherwise up.
+	Status HealthStatus `json:"status"`
+
+	Components []ComponentHealth `json:"components"`
+
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Ready reports whether the engine can serve the requests, which is for the readiness probes.
+func (r HealthReport) Ready() bool {
+	return r.Status != HealthDown
+}
+
+// HealthCheck checks the health of the engine, the components are checked concurrently:
+//
+//   - every environment is pinged, the connection is opened if it is not yet.
+//   - the replica lag is checked by the replicaLagQuery attribute of the environment.
+//   - the caches registered by RegisterCache are checked if they implement HealthChecker.
+//
+// The replicaLagQuery returns the lag in seconds, the environment is degraded if the lag
+// exceeds its maxReplicaLag attribute:
+//
+//	<environment id="replica" replicaLagQuery="SELECT EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())" maxReplicaLag="10s">
+//
+// Use a ctx with a timeout, the slow components are reported as down when it is done.
+func (e *Engine) HealthCheck(ctx context.Context) HealthReport {
+	report := HealthReport{Status: HealthUp, CheckedAt: time.Now()}
+
+	envs := []string{e.EnvID()}
+	if e.manager != nil {
+		envs = e.manager.Registered()
+	}
+	var caches map[string]CacheSizer
+	if e.caches != nil {
+		e.caches.mu.RLock()
+		caches = maps.Clone(e.caches.caches)
+		e.caches.mu.RUnlock()
+	}
+	cacheNames := slices.Sorted(maps.Keys(caches))
+
+	report.Components = make([]ComponentHealth, len(envs)+len(cacheNames))
+	var wg sync.WaitGroup
+	for i, name := range envs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Components[i] = e.checkEnvironment(ctx, name)
+		}()
+	}
+	for i, name := range cacheNames {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Components[len(envs)+i] = checkCache(ctx, name, caches[name])
+		}()
+	}
+	wg.Wait()
+
+	for _, component := range report.Components {
+		switch {
+		case component.Status == HealthUp:
+		case component.Kind == "environment" && component.Name == e.EnvID() && component.Status == HealthDown:
+			report.Status = HealthDown
+		case report.Status == HealthUp:
+			report.Status = HealthDegraded
+		}
+	}
+	return report
+}
+
+// checkEnvironment pings the environment and checks its replica lag.
+func (e *Engine) checkEnvironment(ctx context.Context, name string) ComponentHealth {
+	health := ComponentHealth{Name: name, Kind: "environment", Status: HealthUp}
+	start := time.Now()
+	defer func() { health.Latency = time.Since(start) }()
+
+	db := e.DB()
+	if e.manager != nil {
+		var err error
+		if db, _, err = e.manager.Get(name); err != nil {
+			health.Status, health.Error = HealthDown, err.Error()
+			return health
+		}
+	}
+	if db == nil {
+		health.Status, health.Error = HealthDown, "no database connection"
+		return health
+	}
+	if err := db.PingContext(ctx); err != nil {
+		health.Status, health.Error = HealthDown, err.Error()
+		return health
+	}
+
+	var env *Environment
+	if cfg := e.GetConfiguration(); cfg != nil && cfg.Environments() != nil {
+		env, _ = cfg.Environments().Use(name)
+	}
+	if env == nil || env.Attr("replicaLagQuery") == "" {
+		return health
+	}
+	var seconds sql.NullFloat64
+	if err := db.QueryRowContext(ctx, env.Attr("replicaLagQuery")).Scan(&seconds); err != nil {
+		health.Status, health.Error = HealthDegraded, fmt.Sprintf("replica lag: %v", err)
+		return health
+	}
+	if !seconds.Valid {
+		health.Status, health.Error = HealthDegraded, "replica lag: replication is not running"
+		return health
+	}
+	health.ReplicaLag = time.Duration(seconds.Float64 * float64(time.Second))
+	if value := env.Attr("maxReplicaLag"); value != "" {
+		maxLag, err := time.ParseDuration(value)
+		if err != nil {
+			health.Status, health.Error = HealthDegraded, fmt.Sprintf("invalid maxReplicaLag %q", value)
+		} else if health.ReplicaLag > maxLag {
+			health.Status, health.Error = HealthDegraded, fmt.Sprintf("replica lag %s exceeds %s", health.ReplicaLag, maxLag)
+		}
+	}
+	return health
+}
+
+// checkCache checks the cache if it implements HealthChecker.
+func checkCache(ctx context.Context, name string, cache CacheSizer) ComponentHealth {
+	health := ComponentHealth{Name: name, Kind: "cache", Status: HealthUp}
+	checker, ok := cache.(HealthChecker)
+	if !ok {
+		return health
+	}
+	start := time.Now()
+	if err := checker.HealthCheck(ctx); err != nil {
+		health.Status, health.Error = HealthDown, err.Error()
+	}
+	health.Latency = time.Since(start)
+	return health
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+type unreachableCache struct{ MemoryResultCache }
+
+func (c *unreachableCache) HealthCheck(context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestEngine_HealthCheck(t *testing.T) {
+	replica := &Environment{}
+	replica.setAttr("id", "replica")
+	// the fake connection returns 42 for all the queries.
+	replica.setAttr("replicaLagQuery", "SELECT lag")
+	replica.setAttr("maxReplicaLag", "10s")
+	cfg := Configuration{environments: &environments{envs: map[string]*Environment{"replica": replica}}}
+
+	db := sql.OpenDB(compositeConn{execs: new([]string), commits: new(int)})
+	t.Cleanup(func() { _ = db.Close() })
+	engine := &Engine{configuration: cfg, db: db, driver: driver.MySQLDriver{}, rw: &NoOpRWMutex{}, using: "replica", caches: &cacheRegistry{}}
+
+	report := engine.HealthCheck(context.Background())
+	if report.Status != HealthDegraded || !report.Ready() {
+		t.Fatalf("expected the lagging replica degraded, got %+v", report)
+	}
+	if lag := report.Components[0].ReplicaLag.Seconds(); lag != 42 {
+		t.Fatalf("expected the replica lag of 42s, got %v", lag)
+	}
+
+	replica.setAttr("maxReplicaLag", "1m")
+	engine.RegisterCache("users", &unreachableCache{})
+	report = engine.HealthCheck(context.Background())
+	if report.Status != HealthDegraded || len(report.Components) != 2 || report.Components[1].Status != HealthDown {
+		t.Fatalf("expected the unreachable cache down, got %+v", report)
+	}
+
+	_ = db.Close()
+	if report = engine.HealthCheck(context.Background()); report.Ready() {
+		t.Fatalf("expected the closed database down, got %+v", report)
+	}
+}
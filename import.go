@@ -0,0 +1,193 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// BatchOptions are the options of Engine.Import.
+type BatchOptions struct {
+	// BatchSize is the number of the rows passed to the insert statement at once, 1000 by default.
+	// The rows are split again by the batchSize attribute of the statement.
+	BatchSize int
+
+	// Collection is the parameter name of the rows, which is referred by the collection of the foreach.
+	// The rows are passed as the parameter itself if it is empty.
+	Collection string
+
+	// Columns are the parameter names of the CSV fields by their positions.
+	// The header line of the CSV is used if it is empty.
+	// For JSON Lines, the keys of the objects are used as the parameter names,
+	// and Columns only picks the keys to import.
+	Columns []string
+
+	// NoHeader means the CSV has no header line, Columns is required then.
+	NoHeader bool
+
+	// NullValue is the text of the CSV fields imported as NULL, none of them is NULL if it is empty.
+	NullValue string
+
+	// Progress is called with the number of the rows imported so far after every batch.
+	Progress func(imported int64)
+}
+
+// Import parses the rows from the reader in the given format, maps their columns to the parameters
+// and feeds them to the batch insert statement. It returns the number of the rows imported.
+//
+//	<insert id="ImportUsers" batchSize="500">
+//	    insert into user (name, age) values
+//	    <foreach collection="list" item="user" separator=",">
+//	        (#{user.name}, #{user.age})
+//	    </foreach>
+//	</insert>
+//
+//	imported, err := engine.Import(ctx, "main.UserRepository.ImportUsers", file, juice.ExportCSV, juice.BatchOptions{
+//		Collection: "list",
+//	})
+//
+// The rows imported before an error are not rolled back unless the ctx is in a transaction,
+// the statement is executed by the manager of the ctx if there is one.
+func (e *Engine) Import(ctx context.Context, v any, r io.Reader, format ExportFormat, options BatchOptions) (int64, error) {
+	var reader rowsReader
+	switch format {
+	case ExportCSV:
+		reader = &csvRowsReader{options: options, reader: csv.NewReader(r)}
+	case ExportJSONLines:
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+		reader = &jsonLinesRowsReader{options: options, decoder: decoder}
+	default:
+		return 0, fmt.Errorf("juice: unsupported import format: %q", format)
+	}
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	var imported int64
+	rows := make([]map[string]any, 0, batchSize)
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		var param Param = rows
+		if options.Collection != "" {
+			param = H{options.Collection: rows}
+		}
+		if _, err := e.contextObject(ctx, v).ExecContext(ctx, param); err != nil {
+			return fmt.Errorf("juice: import rows %d-%d: %w", imported+1, imported+int64(len(rows)), err)
+		}
+		imported += int64(len(rows))
+		if options.Progress != nil {
+			options.Progress(imported)
+		}
+		rows = make([]map[string]any, 0, batchSize)
+		return nil
+	}
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imported, err
+		}
+		rows = append(rows, row)
+		if len(rows) == batchSize {
+			if err = flush(); err != nil {
+				return imported, err
+			}
+		}
+	}
+	return imported, flush()
+}
+
+// rowsReader reads the rows of an import format.
+type rowsReader interface {
+	// Read returns the next row, or io.EOF if there is no more row.
+	Read() (map[string]any, error)
+}
+
+// csvRowsReader reads the rows from CSV.
+type csvRowsReader struct {
+	options BatchOptions
+	reader  *csv.Reader
+	columns []string
+}
+
+// Read implements rowsReader.
+func (c *csvRowsReader) Read() (map[string]any, error) {
+	if c.columns == nil {
+		c.columns = c.options.Columns
+		if !c.options.NoHeader {
+			header, err := c.reader.Read()
+			if err != nil {
+				return nil, err
+			}
+			if c.columns == nil {
+				c.columns = header
+			}
+		}
+		if len(c.columns) == 0 {
+			return nil, errors.New("juice: import csv without header requires the columns")
+		}
+	}
+	record, err := c.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make(map[string]any, len(c.columns))
+	for i, column := range c.columns {
+		if i >= len(record) {
+			break
+		}
+		if c.options.NullValue != "" && record[i] == c.options.NullValue {
+			row[column] = nil
+		} else {
+			row[column] = record[i]
+		}
+	}
+	return row, nil
+}
+
+// jsonLinesRowsReader reads the rows from JSON Lines.
+// The numbers are decoded as json.Number to keep their precision.
+type jsonLinesRowsReader struct {
+	options BatchOptions
+	decoder *json.Decoder
+}
+
+// Read implements rowsReader.
+func (j *jsonLinesRowsReader) Read() (map[string]any, error) {
+	var object map[string]any
+	if err := j.decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	if len(j.options.Columns) == 0 {
+		return object, nil
+	}
+	row := make(map[string]any, len(j.options.Columns))
+	for _, column := range j.options.Columns {
+		row[column] = object[column]
+	}
+	return row, nil
+}
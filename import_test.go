@@ -0,0 +1,92 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestEngine_Import(t *testing.T) {
+	cfg, err := NewXMLConfiguration("testdata/configuration/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var counter batchCounter
+	db := sql.OpenDB(batchConnector{counter: &counter})
+	t.Cleanup(func() { _ = db.Close() })
+	engine := &Engine{configuration: cfg, db: db, driver: bulkDriver{}, rw: &NoOpRWMutex{}}
+
+	var lines strings.Builder
+	lines.WriteString("Name,Age\n")
+	for range 250 {
+		lines.WriteString("eatmoreapple,18\n")
+	}
+	var progress []int64
+	imported, err := engine.Import(context.Background(), "main.Repository.BatchInsertExec", strings.NewReader(lines.String()), ExportCSV, BatchOptions{
+		BatchSize:  100,
+		Collection: "list",
+		Progress:   func(imported int64) { progress = append(progress, imported) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 250 || counter.execs.Load() != 250 {
+		t.Fatalf("expected 250 rows imported, got %d with %d execs", imported, counter.execs.Load())
+	}
+	if len(progress) != 3 || progress[2] != 250 {
+		t.Fatalf("unexpected progress: %v", progress)
+	}
+}
+
+// countingManager is the manager which counts the executors it returns.
+type countingManager struct {
+	Manager
+	objects int
+}
+
+func (m *countingManager) Object(v any) SQLRowsExecutor {
+	m.objects++
+	return m.Manager.Object(v)
+}
+
+func TestEngine_Import_ContextManager(t *testing.T) {
+	cfg, err := NewXMLConfiguration("testdata/configuration/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var counter batchCounter
+	db := sql.OpenDB(batchConnector{counter: &counter})
+	t.Cleanup(func() { _ = db.Close() })
+	engine := &Engine{configuration: cfg, db: db, driver: bulkDriver{}, rw: &NoOpRWMutex{}}
+
+	// the rows are imported by the manager of the context, like a transaction.
+	manager := &countingManager{Manager: engine}
+	ctx := ContextWithManager(context.Background(), manager)
+	imported, err := engine.Import(ctx, "main.Repository.BatchInsertExec", strings.NewReader("Name,Age\neat,18\nmore,19\n"), ExportCSV, BatchOptions{
+		BatchSize:  1,
+		Collection: "list",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != 2 || manager.objects != 2 {
+		t.Fatalf("expected 2 rows imported by the manager of the context, got %d by %d executors", imported, manager.objects)
+	}
+}
@@ -17,13 +17,16 @@ limitations under the License.
 package juice
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"unicode"
 
+	"github.com/go-juicedev/juice/driver"
 	"github.com/go-juicedev/juice/internal/reflectlite"
+	"github.com/go-juicedev/juice/session"
 )
 
 // BatchInsertIDGenerateStrategy is an interface that defines a method for generating batch insert IDs.
@@ -100,6 +103,36 @@ func (in DecrementalBatchInsertIDStrategy) BatchInsertID(v reflect.Value) error
 	return nil
 }
 
+// ReturnedBatchInsertIDStrategy sets the keys returned by the RETURNING clause to the elements in order.
+type ReturnedBatchInsertIDStrategy struct {
+	indexes     []int
+	keyProperty string
+	IDs         []int64
+	isPtr       bool
+}
+
+func (in ReturnedBatchInsertIDStrategy) BatchInsertID(v reflect.Value) error {
+	length := v.Len()
+	if len(in.IDs) != length {
+		return fmt.Errorf("got %d generated keys for %d rows", len(in.IDs), length)
+	}
+	for i := 0; i < length; i++ {
+		value := v.Index(i)
+		if in.isPtr {
+			value = value.Elem()
+		}
+		value = value.FieldByIndex(in.indexes)
+		if !value.IsValid() {
+			return fmt.Errorf("invalid field %s", in.keyProperty)
+		}
+		if !value.CanInt() {
+			return fmt.Errorf("can not convert %s to int", in.keyProperty)
+		}
+		value.SetInt(in.IDs[i])
+	}
+	return nil
+}
+
 const (
 	_INCREMENTAL = "INCREMENTAL"
 	_DECREMENTAL = "DECREMENTAL"
@@ -188,6 +221,8 @@ type batchKeyGenerator struct {
 	batchInsertIDGenerateStrategy string
 	id                            int64
 	keyIncrement                  int64
+	// ids are the keys returned by the RETURNING clause, which are set to the elements in order.
+	ids []int64
 }
 
 // GenerateKeyTo generates keys for each element in the given reflect.Value slice based on the key property and sets them to the id.
@@ -223,6 +258,11 @@ func (s batchKeyGenerator) GenerateKeyTo(v reflect.Value) error {
 		return nil
 	}
 
+	// the returned keys are exact, no strategy is needed to guess them.
+	if s.ids != nil {
+		return ReturnedBatchInsertIDStrategy{IDs: s.ids, indexes: indexes, keyProperty: s.keyProperty, isPtr: isPrt}.BatchInsertID(v)
+	}
+
 	// determine the batch insert id strategy
 	if s.batchInsertIDGenerateStrategy == "" {
 		s.batchInsertIDGenerateStrategy = _DECREMENTAL
@@ -252,3 +292,151 @@ func (s batchKeyGenerator) GenerateKeyTo(v reflect.Value) error {
 	}
 	return batchInsertIDGenerateStrategy.BatchInsertID(v)
 }
+
+// returningQueryHandlerKey is the context key of the QueryHandler of the statement handler,
+// or the Handler[session.Rows] of the BackendExecutor, which queries the inserts returning their generated keys.
+type returningQueryHandlerKey struct{}
+
+// withReturningClause returns the insert query with the RETURNING clause of the key column,
+// unless the query has one out of its strings and comments. The clause is appended before
+// the trailing semicolons and comments, like insert into user (name) values (?) RETURNING "id"; -- new user.
+func withReturningClause(query string, translator driver.Translator, clause string) string {
+	syntax := placeholderSyntaxes[driver.DialectOf(translator)]
+	found, end := syntax.returningClause(query)
+	if found {
+		return query
+	}
+	return query[:end] + clause + query[end:]
+}
+
+// keyColumnsOf returns the columns of the generated keys returned by the RETURNING clause,
+// which are the comma separated keyColumn attribute of the statement, defaults to id.
+func keyColumnsOf(statement Statement) []string {
+	if columns := splitKeyAttribute(statement.Attribute("keyColumn")); len(columns) > 0 {
+		return columns
+	}
+	return []string{"id"}
+}
+
+// splitKeyAttribute splits the comma separated keyColumn or keyProperty attribute
+// of the composite keys, like keyProperty="TenantID,ID".
+func splitKeyAttribute(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// compositeKeyGenerator sets the composite keys returned by the RETURNING clause to the key properties,
+// the keys of a row are in the order of the key columns, which are the ones of the key properties.
+type compositeKeyGenerator struct {
+	keyProperties []string
+	// keys are the generated keys of every row.
+	keys [][]int64
+}
+
+// GenerateKeyTo sets the keys of the row to the struct, or the keys of every row to the elements in order.
+func (c compositeKeyGenerator) GenerateKeyTo(v reflect.Value) error {
+	switch reflect.Indirect(v).Kind() {
+	case reflect.Struct:
+		if v.Kind() != reflect.Ptr {
+			return ErrPointerRequired
+		}
+		if len(c.keys) != 1 {
+			return fmt.Errorf("got %d generated keys for 1 row", len(c.keys))
+		}
+		return c.setKeys(v.Elem(), c.keys[0])
+	case reflect.Slice, reflect.Array:
+		v = reflect.Indirect(v)
+		if len(c.keys) != v.Len() {
+			return fmt.Errorf("got %d generated keys for %d rows", len(c.keys), v.Len())
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := c.setKeys(reflect.Indirect(v.Index(i)), c.keys[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errStructPointerOrSliceArrayRequired
+	}
+}
+
+// setKeys sets the keys to the key properties of the struct.
+func (c compositeKeyGenerator) setKeys(v reflect.Value, keys []int64) error {
+	if v.Kind() != reflect.Struct {
+		return errors.New("the param is not a struct")
+	}
+	for i, keyProperty := range c.keyProperties {
+		indexes, ok := findFieldIndexesFromProperties(v.Type(), strings.Split(keyProperty, ".")...)
+		if !ok {
+			return fmt.Errorf("the keyProperty %s is not found in %s", keyProperty, v.Type())
+		}
+		field := v.FieldByIndex(indexes)
+		if !field.CanInt() {
+			return fmt.Errorf("the keyProperty %s is not a int", keyProperty)
+		}
+		field.SetInt(keys[i])
+	}
+	return nil
+}
+
+// returningResult is the sql.Result of the insert statement whose generated keys are returned by the RETURNING clause.
+type returningResult struct {
+	ids []int64
+}
+
+// LastInsertId returns the last generated key.
+func (r returningResult) LastInsertId() (int64, error) {
+	if len(r.ids) == 0 {
+		return 0, errors.New("no generated keys returned")
+	}
+	return r.ids[len(r.ids)-1], nil
+}
+
+// RowsAffected returns the number of the generated keys.
+func (r returningResult) RowsAffected() (int64, error) {
+	return int64(len(r.ids)), nil
+}
+
+// queryGeneratedKeys executes the insert statement with the RETURNING clause by the query handler
+// of the statement handler, or the session of the context, and returns the keys of the given columns of every row in order.
+func queryGeneratedKeys(ctx context.Context, query string, args []any, columns int) ([][]int64, error) {
+	var rows session.Rows
+	switch queryHandler := ctx.Value(returningQueryHandlerKey{}).(type) {
+	case Handler[session.Rows]:
+		// the backends beyond database/sql, see BackendExecutor.
+		backendRows, err := queryHandler(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		rows = backendRows
+	default:
+		sqlQueryHandler, ok := queryHandler.(QueryHandler)
+		if !ok {
+			sqlQueryHandler = SessionQueryHandler
+		}
+		sqlRows, err := sqlQueryHandler(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		rows = sqlRows
+	}
+	defer func() { _ = rows.Close() }()
+	var keys [][]int64
+	for rows.Next() {
+		key := make([]int64, columns)
+		dest := make([]any, columns)
+		for i := range key {
+			dest[i] = &key[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2023 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/session"
+)
+
+// returningConn is a sql connection whose queries return the ids from 7, one for every two arguments,
+// like the RETURNING clause of a multi-row insert. The ids of the second column are from 107.
+type returningConn struct {
+	queries *[]string
+	columns int
+}
+
+func (c returningConn) Connect(context.Context) (sqldriver.Conn, error) { return c, nil }
+
+func (c returningConn) Driver() sqldriver.Driver { return nil }
+
+func (c returningConn) Prepare(query string) (sqldriver.Stmt, error) {
+	*c.queries = append(*c.queries, query)
+	return returningStmt{columns: max(c.columns, 1)}, nil
+}
+
+func (c returningConn) Close() error { return nil }
+
+func (c returningConn) Begin() (sqldriver.Tx, error) { return nil, sqldriver.ErrSkip }
+
+type returningStmt struct{ columns int }
+
+func (returningStmt) Close() error { return nil }
+
+func (returningStmt) NumInput() int { return -1 }
+
+func (returningStmt) Exec([]sqldriver.Value) (sqldriver.Result, error) { return nil, sqldriver.ErrSkip }
+
+func (s returningStmt) Query(args []sqldriver.Value) (sqldriver.Rows, error) {
+	return &returningRows{rows: len(args) / 2, columns: s.columns}, nil
+}
+
+type returningRows struct{ rows, columns, next int }
+
+func (r *returningRows) Columns() []string { return []string{"id", "seq"}[:r.columns] }
+
+func (r *returningRows) Close() error { return nil }
+
+func (r *returningRows) Next(dest []sqldriver.Value) error {
+	if r.next == r.rows {
+		return io.EOF
+	}
+	for i := range dest {
+		dest[i] = []byte(strconv.Itoa(7 + 100*i + r.next))
+	}
+	r.next++
+	return nil
+}
+
+func TestUseGeneratedKeysMiddleware_Returning(t *testing.T) {
+	var queries []string
+	db := sql.OpenDB(returningConn{queries: &queries})
+	t.Cleanup(func() { _ = db.Close() })
+
+	type user struct {
+		ID   int64  `column:"user_id" autoincr:"true"`
+		Name string `column:"name"`
+	}
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Insert, attrs: map[string]string{"useGeneratedKeys": "true", "keyColumn": "user_id"}}
+	exec := (&useGeneratedKeysMiddleware{}).ExecContext(stmt, func(context.Context, string, ...any) (sql.Result, error) {
+		t.Fatal("expected the insert to be executed with the RETURNING clause")
+		return nil, nil
+	})
+	ctx := driver.WithContext(session.WithContext(context.Background(), db), driver.PostgresDriver{})
+
+	single := &user{Name: "eat"}
+	result, err := exec(CtxWithParam(ctx, single), "insert into users (name, age) values ($1, $2)", "eat", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if single.ID != 7 {
+		t.Errorf("expected the id to be 7, got %d", single.ID)
+	}
+	if id, _ := result.LastInsertId(); id != 7 {
+		t.Errorf("expected the last insert id to be 7, got %d", id)
+	}
+	if queries[0] != `insert into users (name, age) values ($1, $2) RETURNING "user_id"` {
+		t.Errorf("unexpected query: %s", queries[0])
+	}
+
+	users := []*user{{Name: "eat"}, {Name: "more"}, {Name: "apple"}}
+	result, err = exec(CtxWithParam(ctx, users), "insert into users (name, age) values ($1, $2), ($3, $4), ($5, $6) returning user_id", "eat", 1, "more", 2, "apple", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users[0].ID != 7 || users[1].ID != 8 || users[2].ID != 9 {
+		t.Errorf("expected the ids to be 7, 8 and 9, got %d, %d and %d", users[0].ID, users[1].ID, users[2].ID)
+	}
+	if rows, _ := result.RowsAffected(); rows != 3 {
+		t.Errorf("expected 3 rows affected, got %d", rows)
+	}
+	if queries[1] != "insert into users (name, age) values ($1, $2), ($3, $4), ($5, $6) returning user_id" {
+		t.Errorf("expected the written RETURNING clause to be kept, got %s", queries[1])
+	}
+}
+
+func TestWithReturningClause(t *testing.T) {
+	translator := driver.PostgresDriver{}.Translator()
+	clause := driver.PostgresDriver{}.ReturningClause("id")
+	for query, want := range map[string]string{
+		"insert into users (name) values ($1)":                     `insert into users (name) values ($1) RETURNING "id"`,
+		"insert into users (name) values ($1);\n":                  "insert into users (name) values ($1) RETURNING \"id\";\n",
+		"insert into users (name) values ($1) -- new user":         `insert into users (name) values ($1) RETURNING "id" -- new user`,
+		"insert into users (name) values ('returning')":            `insert into users (name) values ('returning') RETURNING "id"`,
+		"insert into users (name) values ($1) /* returning */":     `insert into users (name) values ($1) RETURNING "id" /* returning */`,
+		"insert into users (name) values ($$ returning $$)":        `insert into users (name) values ($$ returning $$) RETURNING "id"`,
+		"insert into users (name) values ($1) returning id;":       "insert into users (name) values ($1) returning id;",
+		`insert into users ("returning") values ($1) RETURNING id`: `insert into users ("returning") values ($1) RETURNING id`,
+	} {
+		if got := withReturningClause(query, translator, clause); got != want {
+			t.Errorf("%q: expected %q, got %q", query, want, got)
+		}
+	}
+}
+
+func TestUseGeneratedKeysMiddleware_ReturningQueryHandler(t *testing.T) {
+	var queries []string
+	db := sql.OpenDB(returningConn{queries: &queries})
+	t.Cleanup(func() { _ = db.Close() })
+
+	type user struct {
+		ID   int64  `column:"id" autoincr:"true"`
+		Name string `column:"name"`
+	}
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Insert, attrs: map[string]string{"useGeneratedKeys": "true"}}
+	// the insert is queried by the query handler of the statement handler, like the prepared statements.
+	var handled []string
+	handler := CompiledStatementHandler{
+		query:       "insert into users (name, age) values ($1, $2);",
+		args:        []any{"eat", 1},
+		middlewares: MiddlewareGroup{&useGeneratedKeysMiddleware{}},
+		driver:      driver.PostgresDriver{},
+		session:     db,
+		queryHandler: func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+			handled = append(handled, query)
+			return db.QueryContext(ctx, query, args...)
+		},
+		execHandler: func(context.Context, string, ...any) (sql.Result, error) {
+			t.Fatal("expected the insert to be queried with the RETURNING clause")
+			return nil, nil
+		},
+	}
+	single := &user{Name: "eat"}
+	if _, err := handler.ExecContext(context.Background(), stmt, single); err != nil {
+		t.Fatal(err)
+	}
+	if single.ID != 7 || len(handled) != 1 || handled[0] != `insert into users (name, age) values ($1, $2) RETURNING "id";` {
+		t.Fatalf("unexpected id %d of the queries %q", single.ID, handled)
+	}
+}
+
+func TestUseGeneratedKeysMiddleware_CompositeKeys(t *testing.T) {
+	var queries []string
+	db := sql.OpenDB(returningConn{queries: &queries, columns: 2})
+	t.Cleanup(func() { _ = db.Close() })
+
+	type line struct {
+		OrderID int64 `column:"order_id"`
+		Seq     int64 `column:"seq"`
+		Name    string
+	}
+	attrs := map[string]string{"useGeneratedKeys": "true", "keyColumn": "order_id, seq", "keyProperty": "OrderID, seq"}
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Insert, name: "main.Line.Insert", attrs: attrs}
+	exec := (&useGeneratedKeysMiddleware{}).ExecContext(stmt, func(context.Context, string, ...any) (sql.Result, error) {
+		t.Fatal("expected the insert to be executed with the RETURNING clause")
+		return nil, nil
+	})
+	ctx := driver.WithContext(session.WithContext(context.Background(), db), driver.PostgresDriver{})
+
+	single := &line{Name: "eat"}
+	if _, err := exec(CtxWithParam(ctx, single), "insert into lines (name, qty) values ($1, $2)", "eat", 1); err != nil {
+		t.Fatal(err)
+	}
+	if single.OrderID != 7 || single.Seq != 107 {
+		t.Errorf("expected the keys to be 7 and 107, got %d and %d", single.OrderID, single.Seq)
+	}
+	if queries[0] != `insert into lines (name, qty) values ($1, $2) RETURNING "order_id", "seq"` {
+		t.Errorf("unexpected query: %s", queries[0])
+	}
+
+	lines := []line{{Name: "eat"}, {Name: "more"}}
+	result, err := exec(CtxWithParam(ctx, &lines), "insert into lines (name, qty) values ($1, $2), ($3, $4)", "eat", 1, "more", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines[0].OrderID != 7 || lines[0].Seq != 107 || lines[1].OrderID != 8 || lines[1].Seq != 108 {
+		t.Errorf("unexpected keys: %+v", lines)
+	}
+	if id, _ := result.LastInsertId(); id != 8 {
+		t.Errorf("expected the last insert id to be the last key of the first column, got %d", id)
+	}
+
+	// the last insert id can not report the composite keys.
+	exec = (&useGeneratedKeysMiddleware{}).ExecContext(stmt, func(context.Context, string, ...any) (sql.Result, error) {
+		t.Fatal("expected the composite keys to be rejected")
+		return nil, nil
+	})
+	mysql := driver.WithContext(session.WithContext(context.Background(), db), driver.MySQLDriver{})
+	if _, err = exec(CtxWithParam(mysql, &line{}), "insert into lines (name) values (?)", "eat"); err == nil || !strings.Contains(err.Error(), "requires the driver returning") {
+		t.Errorf("expected the composite keys to require RETURNING, got %v", err)
+	}
+
+	// the key properties must match the key columns.
+	stmt = &xmlSQLStatement{mapper: &Mapper{}, action: Insert, name: "main.Line.Insert", attrs: map[string]string{"useGeneratedKeys": "true", "keyProperty": "OrderID,Seq"}}
+	exec = (&useGeneratedKeysMiddleware{}).ExecContext(stmt, nil)
+	if _, err = exec(CtxWithParam(ctx, &line{}), "insert into lines (name) values ($1)", "eat"); err == nil || !strings.Contains(err.Error(), "2 keyProperty for 1 keyColumn") {
+		t.Errorf("expected the mismatched keys to be rejected, got %v", err)
+	}
+}
@@ -225,6 +225,13 @@ func (t *Trie[T]) collectValues(node *TrieNode[T], prefix string, result *[]KeyV
 	}
 }
 
+// All returns all key-value pairs in the trie
+func (t *Trie[T]) All() []KeyValue[T] {
+	result := make([]KeyValue[T], 0, t.size)
+	t.collectValues(t.root, "", &result)
+	return result
+}
+
 // GetByPrefix returns all key-value pairs with the given prefix
 // Time complexity: O(k * log n + m) where k is the number of parts in the prefix,
 // n is the average number of children per node, and m is the number of matching nodes
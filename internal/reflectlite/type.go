@@ -2,6 +2,7 @@ package reflectlite
 
 import (
 	"reflect"
+	"strings"
 )
 
 // IndirectType returns the type of the element if the type is a pointer type.
@@ -76,7 +77,8 @@ func getFieldIndexesFromTag(value reflect.Type, tagName, tagValue string) ([]int
 				continue
 			}
 		}
-		if tag := field.Tag.Get(tagName); tag == tagValue {
+		// the options after the comma are not part of the name, like `param:"password,redact"`.
+		if tag, _, _ := strings.Cut(field.Tag.Get(tagName), ","); tag == tagValue {
 			return field.Index[:], true
 		}
 	}
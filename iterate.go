@@ -0,0 +1,97 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"iter"
+)
+
+// Iterate executes the select statement and yields every row as T, the rows are closed
+// when the iteration is done, either the rows are exhausted or the loop is broken early.
+// The error of the query or the scanning is yielded as the last element with the zero value of T.
+//
+// Example:
+//
+//	for user, err := range juice.Iterate[User](ctx, engine, "main.UserRepository.All", nil) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(user.Name)
+//	}
+func Iterate[T any](ctx context.Context, manager Manager, v any, param Param) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		executor := manager.Object(v)
+		// the rows scanned are counted against the row quota of the statement, see QuotaMiddleware.
+		ctx, scanned := withScannedRows(ctx, executor.Statement())
+		rows, err := executor.QueryContext(ctx, param)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer func() { _ = rows.Close() }()
+		var count int64
+		defer func() { scanned.done(count) }()
+		rowsIter := Iter[T](rows)
+		// the sequence is nil if the columns of the rows can not be read.
+		if seq := rowsIter.Iter(); seq != nil {
+			for value := range seq {
+				count++
+				if !yield(value, nil) {
+					return
+				}
+			}
+		}
+		if err = rowsIter.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// QueryToChannel executes the select statement with the manager from the context, and sends every row
+// as T to the channel as soon as it is scanned, so a slow receiver holds back the scanning.
+// The channel is closed when QueryToChannel returns, either the rows are exhausted, an error occurs,
+// or the context is done. The ctx must be created by ContextWithManager.
+//
+// Example:
+//
+//	ch := make(chan User, 100)
+//	go func() {
+//		errCh <- juice.QueryToChannel(ctx, "main.UserRepository.All", nil, ch)
+//	}()
+//	for user := range ch {
+//		// export the user
+//	}
+func QueryToChannel[T any](ctx context.Context, v any, param Param, ch chan<- T) error {
+	defer close(ch)
+	manager := ManagerFromContext(ctx)
+	if manager == nil {
+		return ErrInvalidManager
+	}
+	for value, err := range Iterate[T](ctx, manager, v, param) {
+		if err != nil {
+			return err
+		}
+		select {
+		case ch <- value:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
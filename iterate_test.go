@@ -0,0 +1,143 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIterate(t *testing.T) {
+	engine := newEchoEngine(t, newEchoConnector())
+	ctx := context.Background()
+	param := H{"values": []string{"a", "b", "c"}}
+
+	var values []string
+	for value, err := range Iterate[string](ctx, engine, "echo.EchoAll", param) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+	}
+	if expected := []string{"a", "b", "c"}; !reflect.DeepEqual(values, expected) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+
+	// the loop can be broken early.
+	values = values[:0]
+	for value, err := range Iterate[string](ctx, engine, "echo.EchoAll", param) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value)
+		break
+	}
+	if expected := []string{"a"}; !reflect.DeepEqual(values, expected) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+}
+
+func TestIterate_Error(t *testing.T) {
+	engine := newEchoEngine(t, newEchoConnector())
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		id   string
+		err  string
+	}{
+		{name: "query", id: "echo.EchoAll", err: "query failed"},
+		{name: "statement", id: "echo.Missing", err: "Missing"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var count int
+			for value, err := range Iterate[string](ctx, engine, c.id, H{"values": []string{"a", "fail"}}) {
+				count++
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected error %q, got %v", c.err, err)
+				}
+				if value != "" {
+					t.Fatalf("expected the zero value with the error, got %q", value)
+				}
+			}
+			if count != 1 {
+				t.Fatalf("expected the error to be yielded once, got %d elements", count)
+			}
+		})
+	}
+}
+
+func TestQueryToChannel(t *testing.T) {
+	engine := newEchoEngine(t, newEchoConnector())
+	ctx := ContextWithManager(context.Background(), engine)
+
+	ch := make(chan string)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- QueryToChannel(ctx, "echo.EchoAll", H{"values": []string{"a", "b", "c"}}, ch)
+	}()
+	var values []string
+	for value := range ch {
+		values = append(values, value)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"a", "b", "c"}; !reflect.DeepEqual(values, expected) {
+		t.Fatalf("unexpected values: %v", values)
+	}
+}
+
+func TestQueryToChannel_Error(t *testing.T) {
+	engine := newEchoEngine(t, newEchoConnector())
+
+	ch := make(chan string, 2)
+	err := QueryToChannel(ContextWithManager(context.Background(), engine), "echo.EchoAll", H{"values": []string{"a", "fail"}}, ch)
+	if err == nil || !strings.Contains(err.Error(), "query failed") {
+		t.Fatalf("expected the query error, got %v", err)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("expected the channel to be closed")
+	}
+
+	ch = make(chan string)
+	if err = QueryToChannel(context.Background(), "echo.EchoAll", nil, ch); !errors.Is(err, ErrInvalidManager) {
+		t.Fatalf("expected ErrInvalidManager, got %v", err)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("expected the channel to be closed")
+	}
+
+	// the sending stops when the context is done while nobody receives.
+	ctx, cancel := context.WithCancel(ContextWithManager(context.Background(), engine))
+	ch = make(chan string)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- QueryToChannel(ctx, "echo.EchoAll", H{"values": []string{"a", "b"}}, ch)
+	}()
+	if value := <-ch; value != "a" {
+		t.Fatalf("unexpected value: %s", value)
+	}
+	cancel()
+	if err = <-errCh; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-juicedev/juice/session"
+)
+
+// JournalOutcome is the outcome of a mutation recorded by the MutationJournalMiddleware.
+type JournalOutcome string
+
+const (
+	// JournalAutocommit is the outcome of the mutations executed out of a transaction.
+	JournalAutocommit JournalOutcome = "autocommit"
+
+	// JournalCommitted is the outcome of the mutations whose transaction is committed.
+	JournalCommitted JournalOutcome = "committed"
+
+	// JournalRolledBack is the outcome of the mutations whose transaction is rolled back.
+	JournalRolledBack JournalOutcome = "rolledBack"
+
+	// JournalFailed is the outcome of the mutations which failed to execute, or whose transactions failed to commit.
+	JournalFailed JournalOutcome = "failed"
+)
+
+// JournalEntry is a mutation recorded by the MutationJournalMiddleware.
+type JournalEntry struct {
+	// Time is the time when the statement is executed.
+	Time time.Time `json:"time"`
+	// Statement is the name of the statement.
+	Statement string `json:"statement"`
+	// Action is the action of the statement, one of insert, update and delete.
+	Action Action `json:"action"`
+	// Query is the built query.
+	Query string `json:"query"`
+	// Args are the arguments of the query, the sensitive ones are masked.
+	Args []any `json:"args"`
+	// RowsAffected is the number of rows affected by the statement, -1 if the driver can not report it.
+	RowsAffected int64 `json:"rowsAffected"`
+	// Duration is the execution time of the statement.
+	Duration time.Duration `json:"duration"`
+	// Error is the error returned by the statement.
+	Error string `json:"error,omitempty"`
+	// Outcome is the outcome of the statement and its transaction.
+	Outcome JournalOutcome `json:"outcome"`
+}
+
+// Journal records the mutations, it must be safe for concurrent use.
+type Journal interface {
+	Append(entry JournalEntry)
+}
+
+// JournalFunc is an adapter to allow the use of ordinary functions as Journal.
+type JournalFunc func(entry JournalEntry)
+
+// Append calls f(entry).
+func (f JournalFunc) Append(entry JournalEntry) {
+	f(entry)
+}
+
+// journalWriter is the Journal which writes the entries to an io.Writer.
+type journalWriter struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+// Append implements Journal.
+func (j *journalWriter) Append(entry JournalEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_ = j.encoder.Encode(entry)
+}
+
+// NewJournalWriter returns a Journal which writes the entries to w as json lines.
+// The errors of the writer are ignored, so that a broken journal never fails the statements.
+func NewJournalWriter(w io.Writer) Journal {
+	return &journalWriter{encoder: json.NewEncoder(w)}
+}
+
+// JournalRing is the Journal which keeps the latest entries in memory.
+type JournalRing struct {
+	mu      sync.RWMutex
+	entries []JournalEntry
+	next    int
+	full    bool
+}
+
+// NewJournalRing returns a JournalRing which keeps the latest size entries.
+// The size is set to 1 if it is not positive.
+func NewJournalRing(size int) *JournalRing {
+	return &JournalRing{entries: make([]JournalEntry, max(size, 1))}
+}
+
+// Append implements Journal, the oldest entry is dropped if the ring is full.
+func (r *JournalRing) Append(entry JournalEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Entries returns the entries in the ring, the oldest one first.
+func (r *JournalRing) Entries() []JournalEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.full {
+		return append([]JournalEntry(nil), r.entries[:r.next]...)
+	}
+	entries := make([]JournalEntry, 0, len(r.entries))
+	entries = append(entries, r.entries[r.next:]...)
+	return append(entries, r.entries[:r.next]...)
+}
+
+// ensure MutationJournalMiddleware implements Middleware.
+var _ Middleware = (*MutationJournalMiddleware)(nil) // compile time check
+
+// MutationJournalMiddleware is a middleware that appends the insert, update and delete statements
+// to the Journal, for the forensics after an incident. The arguments which are redacted are masked.
+//
+// The mutations executed in a transaction are appended after the transaction is committed or rolled back,
+// with the outcome of the transaction. The failed ones are appended at once.
+// It can be turned off for a statement by setting the journal attribute to false.
+//
+//	ring := juice.NewJournalRing(1000)
+//	engine.Use(&juice.MutationJournalMiddleware{Journal: ring})
+type MutationJournalMiddleware struct {
+	Journal Journal
+}
+
+// QueryContext implements Middleware.
+func (m *MutationJournalMiddleware) QueryContext(_ Statement, next QueryHandler) QueryHandler {
+	return next
+}
+
+// ExecContext implements Middleware.
+func (m *MutationJournalMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHandler {
+	if m.Journal == nil || !stmt.Action().ForWrite() || stmt.Attribute("journal") == "false" {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		entry := JournalEntry{
+			Time:      time.Now(),
+			Statement: stmt.Name(),
+			Action:    stmt.Action(),
+			Query:     query,
+			Args:      MaskArgs(ctx, args),
+		}
+		result, err := next(ctx, query, args...)
+		entry.Duration = time.Since(entry.Time)
+		if err != nil {
+			entry.Error = err.Error()
+			entry.Outcome = JournalFailed
+			m.Journal.Append(entry)
+			return nil, err
+		}
+		// the write succeeded even if the driver can not report the rows affected.
+		if entry.RowsAffected, err = result.RowsAffected(); err != nil {
+			entry.RowsAffected = -1
+		}
+		if sess, _ := session.FromContext(ctx); sess != nil {
+			hooks, ok := sess.(interface {
+				session.AfterCommitter
+				session.AfterRollbacker
+			})
+			if ok {
+				hooks.AfterCommit(func() { m.appendWithOutcome(entry, JournalCommitted) })
+				hooks.AfterRollback(func() { m.appendWithOutcome(entry, JournalRolledBack) })
+				if failer, ok := sess.(session.AfterCommitFailer); ok {
+					failer.AfterCommitFailure(func(err error) {
+						entry.Error = err.Error()
+						m.appendWithOutcome(entry, JournalFailed)
+					})
+				}
+				return result, nil
+			}
+		}
+		m.appendWithOutcome(entry, JournalAutocommit)
+		return result, nil
+	}
+}
+
+// appendWithOutcome appends the entry with the outcome to the journal.
+func (m *MutationJournalMiddleware) appendWithOutcome(entry JournalEntry, outcome JournalOutcome) {
+	entry.Outcome = outcome
+	m.Journal.Append(entry)
+}
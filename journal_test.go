@@ -0,0 +1,126 @@
+/*
+Copyright 2023 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-juicedev/juice/session"
+)
+
+type journalTx struct{ session.Session }
+
+func (journalTx) Commit() error   { return nil }
+func (journalTx) Rollback() error { return nil }
+
+func TestMutationJournalMiddleware(t *testing.T) {
+	ring := NewJournalRing(2)
+	middleware := &MutationJournalMiddleware{Journal: ring}
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Update, name: "main.User.Update"}
+	exec := middleware.ExecContext(stmt, func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		if query == "fail" {
+			return nil, errors.New("deadlock")
+		}
+		return sqldriver.RowsAffected(1), nil
+	})
+
+	ctx := context.Background()
+	if _, err := exec(ctx, "update user set password = ? where id = ?", Redact("secret"), 1); err != nil {
+		t.Fatal(err)
+	}
+	tx := session.WithAfterCommit(journalTx{})
+	txCtx := session.WithContext(ctx, tx)
+	if _, err := exec(txCtx, "update user set name = ? where id = ?", sql.Named("name", Redact("eat")), 2); err != nil {
+		t.Fatal(err)
+	}
+	if entries := ring.Entries(); len(entries) != 1 {
+		t.Fatalf("expected the mutation in the transaction to wait for its outcome, got %+v", entries)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := exec(ctx, "fail"); err == nil {
+		t.Fatal("expected the error of the statement")
+	}
+
+	entries := ring.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected the ring to keep the latest 2 entries, got %+v", entries)
+	}
+	if entries[0].Outcome != JournalRolledBack || entries[0].RowsAffected != 1 {
+		t.Errorf("unexpected entry: %+v", entries[0])
+	}
+	if named, ok := entries[0].Args[0].(sql.NamedArg); !ok || named.Value != redactedMask {
+		t.Errorf("expected the named argument to be masked, got %v", entries[0].Args[0])
+	}
+	if entries[1].Outcome != JournalFailed || entries[1].Error != "deadlock" {
+		t.Errorf("unexpected entry: %+v", entries[1])
+	}
+
+	var buf bytes.Buffer
+	middleware.Journal = NewJournalWriter(&buf)
+	exec = middleware.ExecContext(stmt, func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return sqldriver.RowsAffected(3), nil
+	})
+	if _, err := exec(ctx, "update user set password = ?", Redact("secret")); err != nil {
+		t.Fatal(err)
+	}
+	line := buf.String()
+	if strings.Contains(line, "secret") || !strings.Contains(line, `"args":["***"]`) || !strings.Contains(line, `"outcome":"autocommit"`) {
+		t.Errorf("unexpected journal line: %s", line)
+	}
+
+	stmt.attrs = map[string]string{"journal": "false"}
+	buf.Reset()
+	exec = middleware.ExecContext(stmt, func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return sqldriver.RowsAffected(1), nil
+	})
+	if _, err := exec(ctx, "delete from user"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected the statement to be skipped, got %s", buf.String())
+	}
+}
+
+func TestMutationJournalMiddleware_CommitFailure(t *testing.T) {
+	ring := NewJournalRing(10)
+	middleware := &MutationJournalMiddleware{Journal: ring}
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Update, name: "main.User.Update"}
+	exec := middleware.ExecContext(stmt, func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return noRowsAffectedResult{}, nil
+	})
+	tx := session.WithAfterCommit(&failingCommitTx{})
+	if _, err := exec(session.WithContext(context.Background(), tx), "update user set name = ?", "eat"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("expected the error of the commit")
+	}
+	// the rollback after the failed commit records nothing more.
+	_ = tx.Rollback()
+	entries := ring.Entries()
+	if len(entries) != 1 || entries[0].Outcome != JournalFailed || entries[0].Error != "connection lost" || entries[0].RowsAffected != -1 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+}
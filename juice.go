@@ -19,8 +19,10 @@ package juice
 import (
 	"context"
 	"database/sql"
+	"errors"
 
 	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/session"
 )
 
 // Engine is the implementation of Manager interface and the core of juice.
@@ -52,6 +54,18 @@ type Engine struct {
 	// It is used to intercept the execution of the statements
 	// like logging, tracing, etc.
 	middlewares MiddlewareGroup
+
+	// caches is the caches registered by RegisterCache
+	// It is shared by the cloned engines
+	caches *cacheRegistry
+
+	// fallbacks is the fallbacks registered by Fallback
+	// It is shared by the cloned engines
+	fallbacks *fallbackRegistry
+
+	// prepared is the statements prepared by Warmup
+	// It is shared by the cloned engines
+	prepared *preparedStatements
 }
 
 // sqlRowsExecutor represents a mapper sqlRowsExecutor with the given parameters
@@ -60,8 +74,11 @@ func (e *Engine) executor(v any) (SQLRowsExecutor, error) {
 	if err != nil {
 		return nil, err
 	}
-	statementHandler := NewBatchStatementHandler(e.Driver(), e.DB(), e.middlewares...)
-	return NewSQLRowsExecutor(statement, statementHandler, e.Driver()), nil
+	exe := NewSQLRowsExecutor(statement, e.statementHandler(e.session()), e.Driver())
+	if fallback, ok := e.fallbacks.get(statement.Name()); ok {
+		return &fallbackExecutor{SQLRowsExecutor: exe, fallback: fallback}, nil
+	}
+	return exe, nil
 }
 
 // Object implements the Manager interface
@@ -73,6 +90,14 @@ func (e *Engine) Object(v any) SQLRowsExecutor {
 	return exe
 }
 
+// StatementOf returns the statement of the given value, like the string id,
+// or the method reference which is resolved by StatementIDOf.
+//
+//	statement, err := engine.StatementOf(UserRepository.FindByID)
+func (e *Engine) StatementOf(v any) (Statement, error) {
+	return e.GetConfiguration().GetStatement(v)
+}
+
 // Tx returns a TxManager
 func (e *Engine) Tx() *BasicTxManager {
 	return e.ContextTx(context.Background(), nil)
@@ -106,12 +131,20 @@ func (e *Engine) Use(middleware Middleware) {
 	e.middlewares = append(e.middlewares, middleware)
 }
 
+// SetAuthorizer registers the authorizer which checks the requires attribute of the statements.
+func (e *Engine) SetAuthorizer(authorizer Authorizer) {
+	e.Use(&AuthorizationMiddleware{Authorizer: authorizer})
+}
+
 func (e *Engine) clone() *Engine {
 	return &Engine{
 		configuration: e.configuration,
 		manager:       e.manager,
 		rw:            e.rw,
 		middlewares:   e.middlewares,
+		caches:        e.caches,
+		fallbacks:     e.fallbacks,
+		prepared:      e.prepared,
 	}
 }
 
@@ -133,6 +166,22 @@ func (e *Engine) With(name string) (*Engine, error) {
 	return engine, nil
 }
 
+// statementHandler returns the statement handler which executes the statements on the session,
+// and builds them with the schema of the environment of the engine.
+func (e *Engine) statementHandler(sess session.Session) StatementHandler {
+	handler := NewBatchStatementHandler(e.Driver(), sess, e.middlewares...)
+	return environmentSchemaHandler{StatementHandler: handler, schema: environmentSchema(e.GetConfiguration(), e.EnvID())}
+}
+
+// session returns the session of the database connection,
+// which executes the queries prepared by Warmup with their prepared statements.
+func (e *Engine) session() session.Session {
+	if e.prepared.Len() == 0 {
+		return e.DB()
+	}
+	return preparedSession{DB: e.DB(), prepared: e.prepared}
+}
+
 // EnvID returns the identifier of the currently active database environment.
 func (e *Engine) EnvID() string {
 	return e.using
@@ -151,7 +200,7 @@ func (e *Engine) Driver() driver.Driver {
 // Close gracefully shuts down all managed database connections
 // all cloned engines share the same DBManager
 func (e *Engine) Close() error {
-	return e.manager.Close()
+	return errors.Join(e.prepared.Close(), e.manager.Close())
 }
 
 // SetLocker sets the locker of the engine
@@ -180,15 +229,19 @@ func (e *Engine) Raw(query string) Runner {
 
 // New is the alias of NewEngine
 func New(configuration IConfiguration) (*Engine, error) {
-	engine := &Engine{}
+	engine := &Engine{caches: &cacheRegistry{}, fallbacks: &fallbackRegistry{}, prepared: &preparedStatements{}}
 	// for performance, use the no-op locker by default
 	engine.SetLocker(&NoOpRWMutex{})
 	engine.SetConfiguration(configuration)
 	if err := engine.init(); err != nil {
 		return nil, err
 	}
+	engine.checkStatementManifest()
 	// add the default middlewares
 	engine.Use(&useGeneratedKeysMiddleware{})
+	engine.Use(&LockMiddleware{})
+	engine.Use(&MaxAffectedRowsMiddleware{})
+	engine.Use(&OutputParamsMiddleware{})
 	return engine, nil
 }
 
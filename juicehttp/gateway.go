@@ -0,0 +1,398 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juicehttp
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/go-juicedev/juice/session"
+)
+
+// GatewayRequest is the json body sent to the sql gateway.
+// The []byte arguments are sent as the base64 strings of the standard encoding,
+// which the gateway has to decode before binding them.
+type GatewayRequest struct {
+	SQL  string `json:"sql"`
+	Args []any  `json:"args"`
+}
+
+// GatewayResponse is the json body replied by the sql gateway.
+// The queries reply the columns and the rows, and the other statements reply rowsAffected and lastInsertId.
+type GatewayResponse struct {
+	Columns      []string `json:"columns"`
+	Rows         [][]any  `json:"rows"`
+	RowsAffected int64    `json:"rowsAffected"`
+	LastInsertID int64    `json:"lastInsertId"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// GatewayError is the error replied by the sql gateway.
+type GatewayError struct {
+	// StatusCode is the http status code of the response.
+	StatusCode int
+	// Message is the error of the response, or the body if it is not a GatewayResponse.
+	Message string
+}
+
+// Error returns the error message.
+func (e *GatewayError) Error() string {
+	return fmt.Sprintf("juicehttp: gateway error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// Gateway is the session.Backend which sends the built sql and the arguments over http as json,
+// for the serverless deployments without the tcp database drivers, like the sql over http APIs
+// of Cloudflare D1, Turso or PlanetScale, which can be adapted to the GatewayRequest and GatewayResponse
+// by a thin proxy. The placeholders are the ones of the driver of the engine.
+//
+// Example usage:
+//
+//	gateway := &juicehttp.Gateway{URL: "https://sql.example.com/query", Header: http.Header{"Authorization": {"Bearer " + token}}}
+//	exe, err := juice.BackendExecutorOf[[]User](engine, "main.UserRepository.List", gateway)
+type Gateway struct {
+	// URL is the endpoint which the requests are posted to.
+	URL string
+	// Client sends the requests, http.DefaultClient is used if nil.
+	Client *http.Client
+	// Header is added to every request, like the Authorization header.
+	Header http.Header
+	// MaxResponseSize is the maximum size in bytes of the response body,
+	// DefaultGatewayMaxResponseSize is used if zero.
+	MaxResponseSize int64
+}
+
+// DefaultGatewayMaxResponseSize is the default maximum size of the response body of the sql gateway.
+const DefaultGatewayMaxResponseSize = 32 << 20
+
+// QueryContext implements session.Backend.
+func (g *Gateway) QueryContext(ctx context.Context, query string, args ...any) (session.Rows, error) {
+	response, err := g.do(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayRows{columns: response.Columns, rows: response.Rows}, nil
+}
+
+// ExecContext implements session.Backend.
+func (g *Gateway) ExecContext(ctx context.Context, query string, args ...any) (session.Result, error) {
+	response, err := g.do(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return gatewayResult{rowsAffected: response.RowsAffected, lastInsertID: response.LastInsertID}, nil
+}
+
+// do posts the query and decodes the response.
+func (g *Gateway) do(ctx context.Context, query string, args []any) (*GatewayResponse, error) {
+	values, err := gatewayArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(GatewayRequest{SQL: query, Args: values})
+	if err != nil {
+		return nil, fmt.Errorf("juicehttp: encode gateway request: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, g.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range g.Header {
+		request.Header[key] = values
+	}
+	request.Header.Set("Content-Type", "application/json")
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	limit := g.MaxResponseSize
+	if limit <= 0 {
+		limit = DefaultGatewayMaxResponseSize
+	}
+	// read one more byte to tell the response at the limit from the larger one.
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("juicehttp: gateway response exceeds %d bytes", limit)
+	}
+	var response GatewayResponse
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keep the numbers as they are, the big integers do not fit in float64.
+	decoder.UseNumber()
+	if err = decoder.Decode(&response); err != nil {
+		if resp.StatusCode >= http.StatusBadRequest {
+			return nil, &GatewayError{StatusCode: resp.StatusCode, Message: string(bytes.TrimSpace(data))}
+		}
+		return nil, fmt.Errorf("juicehttp: decode gateway response: %w", err)
+	}
+	if response.Error != "" || resp.StatusCode >= http.StatusBadRequest {
+		message := response.Error
+		if message == "" {
+			message = http.StatusText(resp.StatusCode)
+		}
+		return nil, &GatewayError{StatusCode: resp.StatusCode, Message: message}
+	}
+	return &response, nil
+}
+
+// gatewayArgs converts the arguments to the json values,
+// the driver.Valuer arguments are replaced by their values, the times are formatted as RFC3339
+// and the bytes are encoded as base64.
+func gatewayArgs(args []any) ([]any, error) {
+	values := make([]any, len(args))
+	for i, arg := range args {
+		if valuer, ok := arg.(driver.Valuer); ok {
+			value, err := valuer.Value()
+			if err != nil {
+				return nil, fmt.Errorf("juicehttp: argument %d: %w", i, err)
+			}
+			arg = value
+		}
+		switch v := arg.(type) {
+		case time.Time:
+			arg = v.Format(time.RFC3339Nano)
+		case []byte:
+			arg = base64.StdEncoding.EncodeToString(v)
+		}
+		values[i] = arg
+	}
+	return values, nil
+}
+
+// gatewayResult implements session.Result.
+type gatewayResult struct {
+	rowsAffected int64
+	lastInsertID int64
+}
+
+// LastInsertId implements session.Result.
+func (r gatewayResult) LastInsertId() (int64, error) { return r.lastInsertID, nil }
+
+// RowsAffected implements session.Result.
+func (r gatewayResult) RowsAffected() (int64, error) { return r.rowsAffected, nil }
+
+// gatewayRows adapts the rows of the GatewayResponse to session.Rows.
+type gatewayRows struct {
+	columns []string
+	rows    [][]any
+	index   int
+	closed  bool
+}
+
+// Columns implements session.Rows.
+func (r *gatewayRows) Columns() ([]string, error) {
+	if r.closed {
+		return nil, errRowsClosed
+	}
+	return r.columns, nil
+}
+
+// Next implements session.Rows.
+func (r *gatewayRows) Next() bool {
+	if r.closed || r.index >= len(r.rows) {
+		return false
+	}
+	r.index++
+	return true
+}
+
+var errRowsClosed = errors.New("juicehttp: rows are closed")
+
+// Scan implements session.Rows.
+func (r *gatewayRows) Scan(dest ...any) error {
+	if r.closed {
+		return errRowsClosed
+	}
+	if r.index == 0 {
+		return errors.New("juicehttp: Scan called without calling Next")
+	}
+	row := r.rows[r.index-1]
+	if len(dest) != len(row) {
+		return fmt.Errorf("juicehttp: expected %d destination arguments in Scan, not %d", len(row), len(dest))
+	}
+	for i, value := range row {
+		if err := assignGatewayValue(dest[i], value); err != nil {
+			return fmt.Errorf("juicehttp: scan column %d %q: %w", i, r.columnName(i), err)
+		}
+	}
+	return nil
+}
+
+// columnName returns the name of the column, which is empty if the response has no columns.
+func (r *gatewayRows) columnName(i int) string {
+	if i < len(r.columns) {
+		return r.columns[i]
+	}
+	return ""
+}
+
+// Close implements session.Rows.
+func (r *gatewayRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+// Err implements session.Rows.
+func (r *gatewayRows) Err() error { return nil }
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// gatewayValue returns the value passed to the sql.Scanner and the any destinations,
+// the json numbers are converted to int64 or float64.
+func gatewayValue(value any) any {
+	number, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if i, err := number.Int64(); err == nil {
+		return i
+	}
+	if f, err := number.Float64(); err == nil {
+		return f
+	}
+	return number.String()
+}
+
+// assignGatewayValue copies the json value to the scan destination like database/sql does.
+func assignGatewayValue(dest, value any) error {
+	switch d := dest.(type) {
+	case sql.Scanner:
+		return d.Scan(gatewayValue(value))
+	case *any:
+		*d = gatewayValue(value)
+		return nil
+	}
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("destination not a pointer")
+	}
+	return assignGatewayReflectValue(rv.Elem(), value)
+}
+
+func assignGatewayReflectValue(dst reflect.Value, value any) error {
+	if value == nil {
+		switch dst.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			dst.SetZero()
+			return nil
+		default:
+			return fmt.Errorf("converting NULL to %s is unsupported", dst.Type())
+		}
+	}
+	if dst.Addr().Type().Implements(scannerType) {
+		return dst.Addr().Interface().(sql.Scanner).Scan(gatewayValue(value))
+	}
+	if dst.Kind() == reflect.Pointer {
+		elem := reflect.New(dst.Type().Elem())
+		if err := assignGatewayReflectValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	}
+	text, isText := value.(string)
+	if number, ok := value.(json.Number); ok {
+		text, isText = number.String(), true
+	}
+	switch kind := dst.Kind(); {
+	case kind == reflect.String && isText:
+		dst.SetString(text)
+	case kind == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8 && isText:
+		dst.SetBytes([]byte(text))
+	case kind == reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			dst.SetBool(v)
+		default:
+			if !isText {
+				return fmt.Errorf("converting %T to bool is unsupported", value)
+			}
+			b, err := strconv.ParseBool(text)
+			if err != nil {
+				return err
+			}
+			dst.SetBool(b)
+		}
+	case kind >= reflect.Int && kind <= reflect.Int64 && isText:
+		i, err := strconv.ParseInt(text, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(i)
+	case kind >= reflect.Uint && kind <= reflect.Uint64 && isText:
+		u, err := strconv.ParseUint(text, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(u)
+	case (kind == reflect.Float32 || kind == reflect.Float64) && isText:
+		f, err := strconv.ParseFloat(text, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	case dst.Type() == timeType && isText:
+		t, err := parseGatewayTime(text)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(t))
+	default:
+		// the json columns like arrays and objects are decoded into the destination.
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(data, dst.Addr().Interface()); err != nil {
+			return fmt.Errorf("converting %T to %s: %w", value, dst.Type(), err)
+		}
+	}
+	return nil
+}
+
+// parseGatewayTime parses the time replied by the gateway.
+func parseGatewayTime(text string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, time.DateTime, time.DateOnly} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can not parse %q as time", text)
+}
+
+// ensure Gateway implements session.Backend.
+var _ session.Backend = (*Gateway)(nil)
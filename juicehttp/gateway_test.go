@@ -0,0 +1,111 @@
+package juicehttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-juicedev/juice"
+)
+
+func TestGateway(t *testing.T) {
+	var requests []GatewayRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("unauthorized"))
+			return
+		}
+		var request GatewayRequest
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		switch request.SQL {
+		case "select":
+			_, _ = w.Write([]byte(`{"columns":["id","name","created_at","tags","deleted_at"],"rows":[
+				[9007199254740993,"eat","2025-01-02 03:04:05",["a","b"],null],
+				[2,"apple","2025-01-02T03:04:05Z",[],"2025-02-01"]]}`))
+		case "update":
+			_, _ = w.Write([]byte(`{"rowsAffected":2,"lastInsertId":7}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"syntax error"}`))
+		}
+	}))
+	defer server.Close()
+
+	type user struct {
+		ID        int64      `column:"id"`
+		Name      string     `column:"name"`
+		CreatedAt time.Time  `column:"created_at"`
+		Tags      []string   `column:"tags"`
+		DeletedAt *time.Time `column:"deleted_at"`
+	}
+	ctx := context.Background()
+	gateway := &Gateway{URL: server.URL, Header: http.Header{"Authorization": {"Bearer token"}}}
+	rows, err := gateway.QueryContext(ctx, "select", 1, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	users, err := juice.List[user](rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].ID != 9007199254740993 || users[0].Name != "eat" || len(users[0].Tags) != 2 {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+	if users[0].DeletedAt != nil || users[1].DeletedAt == nil || users[1].CreatedAt.Hour() != 3 {
+		t.Fatalf("unexpected times: %+v", users)
+	}
+	if args := requests[0].Args; len(args) != 2 || args[1] != "2025-01-01T00:00:00Z" {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	result, err := gateway.ExecContext(ctx, "update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if affected, _ := result.RowsAffected(); affected != 2 {
+		t.Errorf("unexpected rows affected: %d", affected)
+	}
+	if id, _ := result.LastInsertId(); id != 7 {
+		t.Errorf("unexpected last insert id: %d", id)
+	}
+
+	var gatewayError *GatewayError
+	if _, err = gateway.ExecContext(ctx, "drop"); !errors.As(err, &gatewayError) || gatewayError.Message != "syntax error" {
+		t.Errorf("expected gateway error, got %v", err)
+	}
+	gateway.Header = nil
+	if _, err = gateway.QueryContext(ctx, "select"); !errors.As(err, &gatewayError) || gatewayError.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized, got %v", err)
+	}
+}
+
+func TestGateway_MaxResponseSize(t *testing.T) {
+	var request GatewayRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		_, _ = w.Write([]byte(`{"rowsAffected":1}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	gateway := &Gateway{URL: server.URL, MaxResponseSize: 18}
+	if _, err := gateway.ExecContext(ctx, "update", []byte("juice")); err != nil {
+		t.Fatal(err)
+	}
+	// the bytes are sent as base64.
+	if args := request.Args; len(args) != 1 || args[0] != "anVpY2U=" {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	gateway.MaxResponseSize = 17
+	if _, err := gateway.ExecContext(ctx, "update"); err == nil || !strings.Contains(err.Error(), "exceeds 17 bytes") {
+		t.Errorf("expected the response to exceed the limit, got %v", err)
+	}
+}
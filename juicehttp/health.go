@@ -0,0 +1,62 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juicehttp
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-juicedev/juice"
+)
+
+// logger logs the details which are not replied to the clients.
+var logger = log.New(log.Writer(), "[juicehttp] ", log.Flags())
+
+// HealthHandler returns a handler which reports the juice.HealthReport of the engine as json,
+// for the readiness probes. The status is 200 if the engine is ready, otherwise 503.
+// The check is canceled after the timeout if it is positive.
+// The errors of the components are logged instead of replied, they may expose the addresses and the users
+// of the databases to the callers of the probe.
+//
+// Example usage:
+//
+//	http.Handle("/readyz", juicehttp.HealthHandler(engine, 3*time.Second))
+func HealthHandler(engine *juice.Engine, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		report := engine.HealthCheck(ctx)
+		for i, component := range report.Components {
+			if component.Error != "" {
+				logger.Printf("health check: %s %s is %s: %s", component.Kind, component.Name, component.Status, component.Error)
+				report.Components[i].Error = ""
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !report.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
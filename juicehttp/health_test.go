@@ -0,0 +1,67 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juicehttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-juicedev/juice"
+)
+
+func TestHealthHandler(t *testing.T) {
+	var logs bytes.Buffer
+	logger.SetOutput(&logs)
+	t.Cleanup(func() { logger.SetOutput(log.Writer()) })
+
+	for _, c := range []struct {
+		dsn    string
+		code   int
+		status juice.HealthStatus
+	}{
+		{dsn: "primary", code: http.StatusOK, status: juice.HealthUp},
+		{dsn: "down", code: http.StatusServiceUnavailable, status: juice.HealthDown},
+	} {
+		t.Run(c.dsn, func(t *testing.T) {
+			engine := newTxEngine(t, c.dsn)
+			recorder := httptest.NewRecorder()
+			HealthHandler(engine, 0).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if recorder.Code != c.code {
+				t.Fatalf("expected status %d, got %d", c.code, recorder.Code)
+			}
+			// the error of the driver is logged, not replied.
+			if body := recorder.Body.String(); strings.Contains(body, "10.0.0.1") || strings.Contains(body, `"error"`) {
+				t.Fatalf("expected the error not to be replied, got %s", body)
+			}
+			var report juice.HealthReport
+			if err := json.NewDecoder(recorder.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			if report.Status != c.status || len(report.Components) != 1 || report.Components[0].Status != c.status {
+				t.Fatalf("unexpected report: %+v", report)
+			}
+		})
+	}
+	if !strings.Contains(logs.String(), "environment primary is down: dial tcp 10.0.0.1:3306") {
+		t.Fatalf("expected the error to be logged, got %q", logs.String())
+	}
+}
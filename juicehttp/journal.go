@@ -0,0 +1,69 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juicehttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-juicedev/juice"
+)
+
+// JournalHandler returns a handler which replies the entries of the juice.JournalRing as json, the latest one first,
+// for the forensics after an incident. The entries can be filtered by the query parameters:
+//
+//   - statement: the name of the statement.
+//   - outcome: the outcome of the entry, like committed or rolledBack.
+//   - limit: the maximum number of the entries.
+//
+// The journal contains the executed sql, so the handler should be served behind the authentication.
+//
+// Example usage:
+//
+//	ring := juice.NewJournalRing(1000)
+//	engine.Use(&juice.MutationJournalMiddleware{Journal: ring})
+//	http.Handle("/admin/journal", juicehttp.JournalHandler(ring))
+func JournalHandler(ring *juice.JournalRing) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limit := -1
+		if value := query.Get("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+				http.Error(w, "invalid limit: "+value, http.StatusBadRequest)
+				return
+			}
+		}
+		statement, outcome := query.Get("statement"), juice.JournalOutcome(query.Get("outcome"))
+
+		entries := ring.Entries()
+		matched := make([]juice.JournalEntry, 0, len(entries))
+		for i := len(entries) - 1; i >= 0 && limit != len(matched); i-- {
+			entry := entries[i]
+			if statement != "" && entry.Statement != statement {
+				continue
+			}
+			if outcome != "" && entry.Outcome != outcome {
+				continue
+			}
+			matched = append(matched, entry)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(matched)
+	})
+}
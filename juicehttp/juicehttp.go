@@ -0,0 +1,128 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package juicehttp provides the net/http middlewares which inject the juice manager into the request context.
+package juicehttp
+
+import (
+	"net/http"
+
+	"github.com/go-juicedev/juice"
+)
+
+// Middleware returns a middleware which injects the manager into the context of every request.
+// The manager can be fetched in the handlers by Manager, and used by juice.Transaction.
+//
+// Example usage:
+//
+//	http.Handle("/", juicehttp.Middleware(engine)(handler))
+func Middleware(manager juice.Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := juice.ContextWithManager(r.Context(), manager)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Manager returns the manager injected by the middlewares of this package.
+// It returns nil if no manager is injected.
+func Manager(r *http.Request) juice.Manager {
+	return juice.ManagerFromContext(r.Context())
+}
+
+// TxConfig configures the TxMiddleware.
+type TxConfig struct {
+	// Options are the options of the transaction.
+	Options []juice.TransactionOptionFunc
+
+	// Commit reports whether the transaction should be committed by the status of the response.
+	// Defaults to commit when the status is less than 400.
+	Commit func(status int) bool
+
+	// ErrorHandler is called when the transaction failed to begin, commit or rollback.
+	// If it fails to begin, the request is not served, and the default handler replies 500.
+	// Otherwise, the response is already written, so the default handler does nothing.
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// TxMiddleware returns a middleware which begins a transaction for every request,
+// injects it into the request context, and commits or rollbacks it by the status of the response.
+// The transaction is also rolled back if the handler panics.
+//
+// Note that the transaction is ended after the handler returns, and the response may be already sent
+// to the client at that time. Use it for the routes which need it only, like the mutating ones.
+func TxMiddleware(engine *juice.Engine, config TxConfig) func(http.Handler) http.Handler {
+	commit := config.Commit
+	if commit == nil {
+		commit = func(status int) bool { return status < http.StatusBadRequest }
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := juice.ContextWithManager(r.Context(), engine)
+			ctx, tx, err := juice.BeginDeferredTx(ctx, config.Options...)
+			if err != nil {
+				if config.ErrorHandler != nil {
+					config.ErrorHandler(w, r, err)
+					return
+				}
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			// make sure to roll back the transaction if the handler panics.
+			defer func() { _ = tx.Rollback() }()
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			if commit(recorder.status) {
+				err = tx.Commit()
+			} else {
+				err = tx.Rollback()
+			}
+			if err != nil && config.ErrorHandler != nil {
+				config.ErrorHandler(w, r, err)
+			}
+		})
+	}
+}
+
+// statusRecorder records the status of the response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status and writes it.
+func (s *statusRecorder) WriteHeader(status int) {
+	if !s.wroteHeader {
+		s.status = status
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the data with the status 200 if the header is not written.
+func (s *statusRecorder) Write(data []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(data)
+}
+
+// Unwrap returns the original http.ResponseWriter for http.ResponseController.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juicehttp
+
+import (
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-juicedev/juice"
+	"github.com/go-juicedev/juice/driver"
+)
+
+// txEvents records the transactions of the fake database.
+var txEvents = struct {
+	sync.Mutex
+	events []string
+}{}
+
+func recordTx(event string) {
+	txEvents.Lock()
+	defer txEvents.Unlock()
+	txEvents.events = append(txEvents.events, event)
+}
+
+func recordedTx() []string {
+	txEvents.Lock()
+	defer txEvents.Unlock()
+	events := txEvents.events
+	txEvents.events = nil
+	return events
+}
+
+// txDriver is the database/sql driver which only records the transactions.
+// The transactions of the data source broken fail to begin, and the data source down fails to connect.
+type txDriver struct{}
+
+func (txDriver) Open(dsn string) (sqldriver.Conn, error) {
+	if dsn == "down" {
+		return nil, errors.New("dial tcp 10.0.0.1:3306: connect: connection refused")
+	}
+	return txConn{dsn: dsn}, nil
+}
+
+type txConn struct{ dsn string }
+
+func (c txConn) Prepare(string) (sqldriver.Stmt, error) { return nil, errors.New("not supported") }
+
+func (c txConn) Close() error { return nil }
+
+func (c txConn) Begin() (sqldriver.Tx, error) {
+	if c.dsn == "broken" {
+		return nil, errors.New("begin failed")
+	}
+	recordTx("begin")
+	return txConn{dsn: c.dsn}, nil
+}
+
+func (c txConn) Commit() error {
+	recordTx("commit")
+	return nil
+}
+
+func (c txConn) Rollback() error {
+	recordTx("rollback")
+	return nil
+}
+
+func init() {
+	sql.Register("juicehttp", txDriver{})
+	driver.Register("juicehttp", driver.MySQLDriver{})
+}
+
+func newTxEngine(t *testing.T, dsn string) *juice.Engine {
+	t.Helper()
+	fsys := fstest.MapFS{"juice.xml": {Data: []byte(`<configuration>
+    <environments default="primary">
+        <environment id="primary">
+            <dataSource>` + dsn + `</dataSource>
+            <driver>juicehttp</driver>
+        </environment>
+    </environments>
+</configuration>`)}}
+	cfg, err := juice.NewXMLConfigurationWithFS(fsys, "juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := juice.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = engine.Close() })
+	return engine
+}
+
+func TestMiddleware(t *testing.T) {
+	engine := newTxEngine(t, "primary")
+	var manager juice.Manager
+	handler := Middleware(engine)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		manager = Manager(r)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if manager != engine {
+		t.Fatalf("expected the engine to be injected, got %v", manager)
+	}
+	if Manager(httptest.NewRequest(http.MethodGet, "/", nil)) != nil {
+		t.Fatal("expected no manager without the middleware")
+	}
+}
+
+func TestTxMiddleware(t *testing.T) {
+	engine := newTxEngine(t, "primary")
+	for _, c := range []struct {
+		name   string
+		config TxConfig
+		status int
+		events []string
+	}{
+		{"ok", TxConfig{}, http.StatusOK, []string{"begin", "commit"}},
+		{"created", TxConfig{}, http.StatusCreated, []string{"begin", "commit"}},
+		{"bad request", TxConfig{}, http.StatusBadRequest, []string{"begin", "rollback"}},
+		{"custom commit", TxConfig{Commit: func(status int) bool { return status == http.StatusConflict }}, http.StatusConflict, []string{"begin", "commit"}},
+	} {
+		var inTx bool
+		handler := TxMiddleware(engine, c.config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, inTx = Manager(r).(juice.TxManager)
+			w.WriteHeader(c.status)
+			// the status written later is not recorded.
+			w.WriteHeader(http.StatusOK)
+		}))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+		if !inTx {
+			t.Errorf("%s: expected the transaction to be the manager of the request", c.name)
+		}
+		if recorder.Code != c.status {
+			t.Errorf("%s: expected status %d, got %d", c.name, c.status, recorder.Code)
+		}
+		if events := recordedTx(); !slices.Equal(events, c.events) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.events, events)
+		}
+	}
+}
+
+func TestTxMiddleware_Panic(t *testing.T) {
+	engine := newTxEngine(t, "primary")
+	handler := TxMiddleware(engine, TxConfig{})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected the panic to be propagated")
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	}()
+	if events := recordedTx(); !slices.Equal(events, []string{"begin", "rollback"}) {
+		t.Fatalf("expected the transaction to be rolled back, got %v", events)
+	}
+}
+
+func TestTxMiddleware_BeginError(t *testing.T) {
+	engine := newTxEngine(t, "broken")
+	served := false
+	next := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { served = true })
+
+	recorder := httptest.NewRecorder()
+	TxMiddleware(engine, TxConfig{})(next).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+	if served || recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 without serving, got %d and served %v", recorder.Code, served)
+	}
+
+	var handled error
+	config := TxConfig{ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+		handled = err
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}}
+	recorder = httptest.NewRecorder()
+	TxMiddleware(engine, config)(next).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", nil))
+	if served || handled == nil || recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected the error handler, got %d and %v", recorder.Code, handled)
+	}
+}
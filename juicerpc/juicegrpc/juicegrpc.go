@@ -0,0 +1,69 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package juicegrpc provides the gRPC server interceptors which wire the juice engine
+// into the context of every call by juicerpc.Scope.
+//
+//	scope := &juicerpc.Scope{
+//		Engine:   engine,
+//		Mutating: func(method string) bool { return strings.Contains(method, "/Create") },
+//	}
+//	server := grpc.NewServer(
+//		grpc.UnaryInterceptor(juicegrpc.UnaryServerInterceptor(scope)),
+//		grpc.StreamInterceptor(juicegrpc.StreamServerInterceptor(scope)),
+//	)
+//
+// It is a separate module, so that the users of juice without gRPC do not depend on it.
+package juicegrpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"github.com/go-juicedev/juice/juicerpc"
+)
+
+// UnaryServerInterceptor returns the unary server interceptor which calls the handler by the scope.
+func UnaryServerInterceptor(scope *juicerpc.Scope) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return scope.Do(ctx, info.FullMethod, func(ctx context.Context) (any, error) {
+			return handler(ctx, req)
+		})
+	}
+}
+
+// StreamServerInterceptor returns the stream server interceptor which calls the handler by the scope,
+// the context of the stream is the one carrying the manager.
+func StreamServerInterceptor(scope *juicerpc.Scope) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		_, err := scope.Do(stream.Context(), info.FullMethod, func(ctx context.Context) (any, error) {
+			return nil, handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+		})
+		return err
+	}
+}
+
+// serverStream is the grpc.ServerStream whose context is replaced.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context carrying the manager.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
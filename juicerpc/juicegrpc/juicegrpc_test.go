@@ -0,0 +1,117 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juicegrpc
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"google.golang.org/grpc"
+
+	"github.com/go-juicedev/juice"
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/juicerpc"
+)
+
+// commits counts the transactions committed by the fake database.
+var commits atomic.Int64
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (sqldriver.Conn, error) { return fakeConn{}, nil }
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(string) (sqldriver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                           { return nil }
+func (fakeConn) Begin() (sqldriver.Tx, error)           { return fakeConn{}, nil }
+func (fakeConn) Commit() error                          { commits.Add(1); return nil }
+func (fakeConn) Rollback() error                        { return nil }
+
+func init() {
+	sql.Register("juicegrpc", fakeDriver{})
+	driver.Register("juicegrpc", driver.MySQLDriver{})
+}
+
+func newScope(t *testing.T) *juicerpc.Scope {
+	t.Helper()
+	fsys := fstest.MapFS{"juice.xml": {Data: []byte(`<configuration>
+    <environments default="primary">
+        <environment id="primary">
+            <dataSource>primary</dataSource>
+            <driver>juicegrpc</driver>
+        </environment>
+    </environments>
+</configuration>`)}}
+	cfg, err := juice.NewXMLConfigurationWithFS(fsys, "juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := juice.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = engine.Close() })
+	return &juicerpc.Scope{Engine: engine, Mutating: func(method string) bool { return method == "/user.UserService/Create" }}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor(newScope(t))
+	before := commits.Load()
+	info := &grpc.UnaryServerInfo{FullMethod: "/user.UserService/Create"}
+	resp, err := interceptor(context.Background(), "req", info, func(ctx context.Context, req any) (any, error) {
+		if !juice.IsTxManager(juice.ManagerFromContext(ctx)) {
+			t.Error("expected the handler to run in a transaction")
+		}
+		return req, nil
+	})
+	if err != nil || resp != "req" {
+		t.Fatalf("unexpected response: %v %v", resp, err)
+	}
+	if commits.Load() != before+1 {
+		t.Fatal("expected the transaction to be committed")
+	}
+}
+
+// fakeServerStream is the grpc.ServerStream which only has the context.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeServerStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor(t *testing.T) {
+	scope := newScope(t)
+	interceptor := StreamServerInterceptor(scope)
+	info := &grpc.StreamServerInfo{FullMethod: "/user.UserService/List", IsServerStream: true}
+	errDone := errors.New("done")
+	err := interceptor(nil, fakeServerStream{ctx: context.Background()}, info, func(srv any, stream grpc.ServerStream) error {
+		if juice.ManagerFromContext(stream.Context()) != scope.Engine {
+			t.Error("expected the stream context to carry the engine")
+		}
+		return errDone
+	})
+	if !errors.Is(err, errDone) {
+		t.Fatalf("expected the error of the handler, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package juicerpc provides the transport independent core of the rpc server interceptors,
+// which wires the juice engine into the context of every call.
+//
+// It does not depend on any rpc framework, the gRPC server interceptors are provided by the juicegrpc module:
+//
+//	scope := &juicerpc.Scope{Engine: engine, Mutating: func(method string) bool { ... }}
+//	server := grpc.NewServer(
+//		grpc.UnaryInterceptor(juicegrpc.UnaryServerInterceptor(scope)),
+//		grpc.StreamInterceptor(juicegrpc.StreamServerInterceptor(scope)),
+//	)
+package juicerpc
+
+import (
+	"context"
+
+	"github.com/go-juicedev/juice"
+)
+
+// Scope wires the engine into the context of the rpc calls.
+type Scope struct {
+	// Engine is the engine injected into the context.
+	Engine *juice.Engine
+
+	// Mutating reports whether the method mutates data.
+	// The handlers of the mutating methods are executed in a transaction,
+	// which is committed if the handler returns no error, otherwise rolled back.
+	// Defaults to no method is mutating.
+	Mutating func(method string) bool
+
+	// ReadOnly reports whether the call is read only, for example by a flag of the request metadata.
+	// The read only calls are routed to ReadOnlySource if set, and marked by juice.ContextWithReadOnly,
+	// so that the writes can be denied by juice.ReadOnlyPolicy.
+	ReadOnly func(ctx context.Context, method string) bool
+
+	// ReadOnlySource is the environment id of the data source for the read only calls.
+	ReadOnlySource string
+
+	// TxOptions are the options of the transactions of the mutating methods.
+	TxOptions []juice.TransactionOptionFunc
+}
+
+// Do calls the handler with a context carrying the manager.
+func (s *Scope) Do(ctx context.Context, method string, handler func(ctx context.Context) (any, error)) (any, error) {
+	if s.Engine == nil {
+		return nil, juice.ErrInvalidManager
+	}
+	if s.ReadOnly != nil && s.ReadOnly(ctx, method) {
+		engine := s.Engine
+		if s.ReadOnlySource != "" {
+			var err error
+			if engine, err = engine.With(s.ReadOnlySource); err != nil {
+				return nil, err
+			}
+		}
+		ctx = juice.ContextWithReadOnly(juice.ContextWithManager(ctx, engine))
+		return handler(ctx)
+	}
+	ctx = juice.ContextWithManager(ctx, s.Engine)
+	if s.Mutating == nil || !s.Mutating(method) {
+		return handler(ctx)
+	}
+	ctx, tx, err := juice.BeginDeferredTx(ctx, s.TxOptions...)
+	if err != nil {
+		return nil, err
+	}
+	// make sure to roll back the transaction if the handler panics.
+	defer func() { _ = tx.Rollback() }()
+	resp, err := handler(ctx)
+	if err != nil {
+		// the error of the handler is returned as it is, like the status errors of gRPC,
+		// the error of the rollback is dropped, since the transaction is abandoned anyway.
+		_ = tx.Rollback()
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juicerpc
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-juicedev/juice"
+	"github.com/go-juicedev/juice/driver"
+)
+
+// events records the transactions of the fake databases by their data sources.
+var events = struct {
+	sync.Mutex
+	byDSN      map[string][]string
+	failCommit bool
+}{byDSN: make(map[string][]string)}
+
+func record(dsn, event string) {
+	events.Lock()
+	defer events.Unlock()
+	events.byDSN[dsn] = append(events.byDSN[dsn], event)
+}
+
+func recorded(dsn string) []string {
+	events.Lock()
+	defer events.Unlock()
+	recorded := events.byDSN[dsn]
+	delete(events.byDSN, dsn)
+	return recorded
+}
+
+// fakeDriver is the database/sql driver which only records the transactions.
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (sqldriver.Conn, error) { return fakeConn{dsn: dsn}, nil }
+
+type fakeConn struct{ dsn string }
+
+func (c fakeConn) Prepare(string) (sqldriver.Stmt, error) { return nil, errors.New("not supported") }
+
+func (c fakeConn) Close() error { return nil }
+
+func (c fakeConn) Begin() (sqldriver.Tx, error) {
+	record(c.dsn, "begin")
+	return fakeTx(c), nil
+}
+
+type fakeTx struct{ dsn string }
+
+func (t fakeTx) Commit() error {
+	events.Lock()
+	fail := events.failCommit
+	events.Unlock()
+	if fail {
+		return errors.New("commit failed")
+	}
+	record(t.dsn, "commit")
+	return nil
+}
+
+func (t fakeTx) Rollback() error {
+	record(t.dsn, "rollback")
+	return nil
+}
+
+func init() {
+	sql.Register("juicerpc", fakeDriver{})
+	driver.Register("juicerpc", driver.MySQLDriver{})
+}
+
+func newScope(t *testing.T) *Scope {
+	t.Helper()
+	fsys := fstest.MapFS{"juice.xml": {Data: []byte(`<configuration>
+    <environments default="primary">
+        <environment id="primary">
+            <dataSource>primary</dataSource>
+            <driver>juicerpc</driver>
+        </environment>
+        <environment id="replica">
+            <dataSource>replica</dataSource>
+            <driver>juicerpc</driver>
+        </environment>
+    </environments>
+</configuration>`)}}
+	cfg, err := juice.NewXMLConfigurationWithFS(fsys, "juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := juice.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = engine.Close() })
+	return &Scope{
+		Engine:         engine,
+		Mutating:       func(method string) bool { return method == "/user.UserService/Create" },
+		ReadOnly:       func(_ context.Context, method string) bool { return method == "/user.UserService/Report" },
+		ReadOnlySource: "replica",
+	}
+}
+
+func TestScope_Commit(t *testing.T) {
+	scope := newScope(t)
+	resp, err := scope.Do(context.Background(), "/user.UserService/Create", func(ctx context.Context) (any, error) {
+		if !juice.IsTxManager(juice.ManagerFromContext(ctx)) {
+			t.Error("expected the mutating method to run in a transaction")
+		}
+		return "created", nil
+	})
+	if err != nil || resp != "created" {
+		t.Fatalf("unexpected response: %v %v", resp, err)
+	}
+	if got := recorded("primary"); len(got) != 2 || got[0] != "begin" || got[1] != "commit" {
+		t.Fatalf("expected the transaction to be committed, got %v", got)
+	}
+}
+
+func TestScope_Rollback(t *testing.T) {
+	scope := newScope(t)
+	errNotFound := errors.New("user not found")
+	_, err := scope.Do(context.Background(), "/user.UserService/Create", func(ctx context.Context) (any, error) {
+		return nil, errNotFound
+	})
+	// the error of the handler is returned as it is.
+	if err != errNotFound {
+		t.Fatalf("expected the error of the handler, got %v", err)
+	}
+	if got := recorded("primary"); len(got) != 2 || got[0] != "begin" || got[1] != "rollback" {
+		t.Fatalf("expected the transaction to be rolled back, got %v", got)
+	}
+}
+
+func TestScope_CommitError(t *testing.T) {
+	scope := newScope(t)
+	events.Lock()
+	events.failCommit = true
+	events.Unlock()
+	t.Cleanup(func() {
+		events.Lock()
+		events.failCommit = false
+		events.Unlock()
+	})
+	_, err := scope.Do(context.Background(), "/user.UserService/Create", func(ctx context.Context) (any, error) {
+		return "created", nil
+	})
+	if err == nil || err.Error() != "commit failed" {
+		t.Fatalf("expected the error of the commit, got %v", err)
+	}
+	recorded("primary")
+}
+
+func TestScope_ReadOnly(t *testing.T) {
+	scope := newScope(t)
+	_, err := scope.Do(context.Background(), "/user.UserService/Report", func(ctx context.Context) (any, error) {
+		engine, ok := juice.ManagerFromContext(ctx).(*juice.Engine)
+		if !ok || engine.EnvID() != "replica" {
+			t.Errorf("expected the read only call to use the replica, got %v", juice.ManagerFromContext(ctx))
+		}
+		if !juice.IsReadOnlyContext(ctx) {
+			t.Error("expected the context to be read only")
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = scope.Do(context.Background(), "/user.UserService/Get", func(ctx context.Context) (any, error) {
+		if engine, ok := juice.ManagerFromContext(ctx).(*juice.Engine); !ok || engine != scope.Engine || juice.IsReadOnlyContext(ctx) {
+			t.Errorf("expected the engine of the scope, got %v", juice.ManagerFromContext(ctx))
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := append(recorded("primary"), recorded("replica")...); len(got) != 0 {
+		t.Fatalf("expected no transaction, got %v", got)
+	}
+}
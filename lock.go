@@ -0,0 +1,102 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/session"
+)
+
+// ErrLockOutsideTransaction is an error that is returned when a locking select is executed outside a transaction.
+var ErrLockOutsideTransaction = errors.New("locking select must be executed inside a transaction")
+
+// lockOptionsKey is the context key of the lock options.
+type lockOptionsKey struct{}
+
+// ContextWithLock returns a new context carrying the lock options,
+// which overrides the lock and lockWait attributes of the select statements executed with it.
+func ContextWithLock(ctx context.Context, options driver.LockOptions) context.Context {
+	return context.WithValue(ctx, lockOptionsKey{}, options)
+}
+
+// LockFromContext returns the lock options from the context.
+func LockFromContext(ctx context.Context) (driver.LockOptions, bool) {
+	options, ok := ctx.Value(lockOptionsKey{}).(driver.LockOptions)
+	return options, ok
+}
+
+// ensure LockMiddleware implements Middleware.
+var _ Middleware = (*LockMiddleware)(nil) // compile time check
+
+// LockMiddleware is a middleware that appends the locking clause of the driver to the select statements.
+//
+// The lock can be declared on the statement:
+//
+//	<select id="GetForUpdate" lock="update" lockWait="skipLocked">
+//	    select * from user where id = #{id}
+//	</select>
+//
+// or passed by ContextWithLock.
+// The lock attribute accepts update and share, the lockWait attribute accepts nowait and skipLocked.
+// An error is returned if the driver does not support the lock or the statement is not executed in a transaction.
+type LockMiddleware struct{}
+
+// QueryContext implements Middleware.
+func (m *LockMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryHandler {
+	if stmt.Action() != Select {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		options, ok := LockFromContext(ctx)
+		if !ok {
+			options = driver.LockOptions{
+				Mode: driver.LockMode(stmt.Attribute("lock")),
+				Wait: driver.LockWait(stmt.Attribute("lockWait")),
+			}
+		}
+		if options.Mode == "" {
+			return next(ctx, query, args...)
+		}
+		sess, err := session.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok = sess.(session.TransactionSession); !ok {
+			return nil, ErrLockOutsideTransaction
+		}
+		drv, err := driver.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		clause, err := driver.LockClause(drv, options)
+		if err != nil {
+			return nil, err
+		}
+		query = strings.TrimRight(query, "; \t\r\n") + " " + clause
+		return next(ctx, query, args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *LockMiddleware) ExecContext(_ Statement, next ExecHandler) ExecHandler {
+	return next
+}
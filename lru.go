@@ -0,0 +1,104 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import "container/list"
+
+// lruEntry is an entry of the lru.
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// lru keeps the entries by their keys, and evicts the least recently used ones when it exceeds the max entries.
+// It is not safe for concurrent use, the callers guard it with their own locks.
+type lru[K comparable, V any] struct {
+	// entries indexes the elements of the recency list by their keys.
+	entries map[K]*list.Element
+	// recency orders the entries from the most recently used to the least.
+	recency list.List
+	// max is the budget of the entries, zero means no limit.
+	max int
+	// onEvict is called with the entries evicted by the budget, it is optional.
+	onEvict func(key K, value V)
+}
+
+// get returns the value of the key, and marks it as the most recently used.
+func (l *lru[K, V]) get(key K) (value V, ok bool) {
+	element, ok := l.entries[key]
+	if !ok {
+		return value, false
+	}
+	l.recency.MoveToFront(element)
+	return element.Value.(*lruEntry[K, V]).value, true
+}
+
+// set stores the value of the key as the most recently used, and evicts the entries beyond the budget.
+func (l *lru[K, V]) set(key K, value V) {
+	if element, ok := l.entries[key]; ok {
+		element.Value.(*lruEntry[K, V]).value = value
+		l.recency.MoveToFront(element)
+		return
+	}
+	if l.entries == nil {
+		l.entries = make(map[K]*list.Element)
+	}
+	l.entries[key] = l.recency.PushFront(&lruEntry[K, V]{key: key, value: value})
+	l.evict()
+}
+
+// delete removes the entry of the key.
+func (l *lru[K, V]) delete(key K) {
+	if element, ok := l.entries[key]; ok {
+		l.recency.Remove(element)
+		delete(l.entries, key)
+	}
+}
+
+// resize changes the budget of the entries, and evicts the entries beyond it.
+func (l *lru[K, V]) resize(max int) {
+	l.max = max
+	l.evict()
+}
+
+// evict removes the least recently used entries until the entries are within the budget.
+func (l *lru[K, V]) evict() {
+	for l.max > 0 && l.recency.Len() > l.max {
+		entry := l.recency.Remove(l.recency.Back()).(*lruEntry[K, V])
+		delete(l.entries, entry.key)
+		if l.onEvict != nil {
+			l.onEvict(entry.key, entry.value)
+		}
+	}
+}
+
+// clear removes all the entries, and returns their values from the most recently used to the least.
+// The removed entries are not passed to onEvict.
+func (l *lru[K, V]) clear() []V {
+	values := make([]V, 0, l.recency.Len())
+	for element := l.recency.Front(); element != nil; element = element.Next() {
+		values = append(values, element.Value.(*lruEntry[K, V]).value)
+	}
+	l.recency.Init()
+	clear(l.entries)
+	return values
+}
+
+// len returns the number of the entries.
+func (l *lru[K, V]) len() int {
+	return l.recency.Len()
+}
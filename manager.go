@@ -80,6 +80,10 @@ type BasicTxManager struct {
 	// It's nil if no transaction is active
 	tx  session.TransactionSession
 	ctx context.Context
+
+	// conn is the connection pinned by Engine.WithConn
+	// The transaction is begun on it if it's not nil
+	conn *sql.Conn
 }
 
 // Object implements the Manager interface
@@ -91,9 +95,7 @@ func (t *BasicTxManager) Object(v any) SQLRowsExecutor {
 	if err != nil {
 		return inValidExecutor(err)
 	}
-	drv := t.engine.Driver()
-	statementHandler := NewBatchStatementHandler(drv, t.tx, t.engine.middlewares...)
-	return NewSQLRowsExecutor(statement, statementHandler, drv)
+	return NewSQLRowsExecutor(statement, t.engine.statementHandler(t.tx), t.engine.Driver())
 }
 
 // Begin begins the transaction
@@ -102,11 +104,19 @@ func (t *BasicTxManager) Begin() error {
 	if t.tx != nil {
 		return session.ErrTransactionAlreadyBegun
 	}
-	tx, err := t.engine.DB().BeginTx(t.ctx, t.txOptions)
+	var (
+		tx  *sql.Tx
+		err error
+	)
+	if t.conn != nil {
+		tx, err = t.conn.BeginTx(t.ctx, t.txOptions)
+	} else {
+		tx, err = t.engine.DB().BeginTx(t.ctx, t.txOptions)
+	}
 	if err != nil {
 		return err
 	}
-	t.tx = tx
+	t.tx = session.WithAfterCommit(tx)
 	return nil
 }
 
@@ -116,7 +126,10 @@ func (t *BasicTxManager) Commit() error {
 	if t.tx == nil {
 		return session.ErrTransactionNotBegun
 	}
-	return t.tx.Commit()
+	if err := t.tx.Commit(); err != nil {
+		return t.engine.translateError(err)
+	}
+	return nil
 }
 
 // Rollback rollbacks the transaction
@@ -143,6 +156,15 @@ func managerFromContext(ctx context.Context) (Manager, bool) {
 	return manager, ok
 }
 
+// contextObject returns the executor of the manager of the context, like the transaction of juice.Transaction,
+// or the one of the engine if the context has no manager.
+func (e *Engine) contextObject(ctx context.Context, v any) SQLRowsExecutor {
+	if manager, ok := managerFromContext(ctx); ok && manager != nil {
+		return manager.Object(v)
+	}
+	return e.Object(v)
+}
+
 // ManagerFromContext returns the Manager from the context.
 func ManagerFromContext(ctx context.Context) Manager {
 	manager, _ := managerFromContext(ctx)
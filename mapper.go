@@ -3,7 +3,9 @@ package juice
 import (
 	"errors"
 	"fmt"
+	"iter"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/go-juicedev/juice/internal/container"
@@ -15,6 +17,7 @@ type Mapper struct {
 	mappers    *Mappers
 	statements map[string]*xmlSQLStatement
 	sqlNodes   map[string]*SQLNode
+	composites map[string]*CompositeStatement
 	attrs      map[string]string
 }
 
@@ -56,6 +59,22 @@ func (m *Mapper) Prefix() string {
 	return m.Attribute("prefix")
 }
 
+// Statements returns the statements of the mapper sorted by their ids.
+func (m *Mapper) Statements() iter.Seq[Statement] {
+	ids := make([]string, 0, len(m.statements))
+	for id := range m.statements {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return func(yield func(Statement) bool) {
+		for _, id := range ids {
+			if !yield(m.statements[id]) {
+				return
+			}
+		}
+	}
+}
+
 func (m *Mapper) GetSQLNodeByID(id string) (Node, error) {
 	// if the id is not cross-namespace
 	isCrossNamespace := strings.Contains(id, ".")
@@ -135,17 +154,35 @@ func (m *Mappers) GetStatementByID(id string) (Statement, error) {
 	}
 	mapper, key, err := m.getMapperAndKey(id)
 	if err != nil {
+		var mapperNotFound ErrMapperNotFound
+		if errors.As(err, &mapperNotFound) {
+			return nil, &ErrStatementNotFound{
+				StatementName: key,
+				MapperName:    string(mapperNotFound),
+				ID:            id,
+				Closest:       closestNames(id, m.statementNames()),
+				err:           err,
+			}
+		}
 		return nil, err
 	}
 
 	stmt, exists := mapper.statements[key]
 	if !exists {
-		return nil, &ErrStatementNotFound{StatementName: key, MapperName: mapper.namespace}
+		return nil, &ErrStatementNotFound{
+			StatementName: key,
+			MapperName:    mapper.namespace,
+			ID:            id,
+			Closest:       closestNames(id, m.statementNames()),
+		}
 	}
 	return stmt, nil
 }
 
 func (m *Mappers) GetSQLNodeByID(id string) (Node, error) {
+	if m == nil {
+		return nil, &ErrSQLNodeNotFound{NodeName: id}
+	}
 	mapper, key, err := m.getMapperAndKey(id)
 	if err != nil {
 		return nil, err
@@ -187,8 +224,43 @@ func (m *Mappers) GetStatement(v any) (Statement, error) {
 	return m.GetStatementByID(id)
 }
 
+// Statements returns the statements of all the mappers sorted by their namespaces and ids.
+func (m *Mappers) Statements() iter.Seq[Statement] {
+	return func(yield func(Statement) bool) {
+		if m == nil || m.mappers == nil {
+			return
+		}
+		mappers := m.mappers.All()
+		slices.SortFunc(mappers, func(a, b container.KeyValue[*Mapper]) int {
+			return strings.Compare(a.Key, b.Key)
+		})
+		for _, mapper := range mappers {
+			for statement := range mapper.Value.Statements() {
+				if !yield(statement) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// statementNames returns the names of the statements of all the mappers.
+func (m *Mappers) statementNames() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for statement := range m.Statements() {
+			if !yield(statement.Name()) {
+				return
+			}
+		}
+	}
+}
+
 // Configuration represents a configuration of juice.
+// It returns nil if the Mappers is nil, like the mappers which are parsed alone.
 func (m *Mappers) Configuration() IConfiguration {
+	if m == nil {
+		return nil
+	}
 	return m.cfg
 }
 
@@ -0,0 +1,70 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrTooManyRowsAffected is an error that is returned when the rows affected by a statement
+// exceed the maxAffectedRows attribute of the statement.
+var ErrTooManyRowsAffected = errors.New("too many rows affected")
+
+// ensure MaxAffectedRowsMiddleware implements Middleware.
+var _ Middleware = (*MaxAffectedRowsMiddleware)(nil) // compile time check
+
+// MaxAffectedRowsMiddleware is a middleware that checks the rows affected by the statement
+// against its maxAffectedRows attribute, to protect against accidentally broad predicates.
+//
+//	<delete id="DeleteByID" maxAffectedRows="1">
+//	    delete from user where id = #{id}
+//	</delete>
+//
+// If the rows affected exceed the cap, ErrTooManyRowsAffected is returned, and the transaction of the statement
+// is rolled back by its owner, like juice.Transaction which rolls back on the error.
+// Note that the rows are already modified when the statement is not executed in a transaction.
+// The cap is not checked if the driver can not report the rows affected.
+// The attribute must be a positive integer, which is validated when the mapper is parsed.
+type MaxAffectedRowsMiddleware struct{}
+
+// QueryContext implements Middleware.
+func (m *MaxAffectedRowsMiddleware) QueryContext(_ Statement, next QueryHandler) QueryHandler {
+	return next
+}
+
+// ExecContext implements Middleware.
+func (m *MaxAffectedRowsMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHandler {
+	maxAffectedRows, err := strconv.ParseInt(stmt.Attribute("maxAffectedRows"), 10, 64)
+	if err != nil || maxAffectedRows <= 0 {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		result, err := next(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil || rowsAffected <= maxAffectedRows {
+			return result, nil
+		}
+		return nil, fmt.Errorf("%w: statement %s affected %d rows, max %d", ErrTooManyRowsAffected, stmt.Name(), rowsAffected, maxAffectedRows)
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-juicedev/juice/session"
+)
+
+func TestMaxAffectedRowsMiddleware(t *testing.T) {
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Delete, name: "main.User.DeleteByID", attrs: map[string]string{"maxAffectedRows": "1"}}
+	var result sql.Result
+	exec := (&MaxAffectedRowsMiddleware{}).ExecContext(stmt, func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return result, nil
+	})
+	raw := &recordingTx{}
+	ctx := session.WithContext(context.Background(), session.WithAfterCommit(raw))
+
+	result = sqldriver.RowsAffected(1)
+	if _, err := exec(ctx, "delete from user where id = ?", 1); err != nil {
+		t.Fatal(err)
+	}
+	result = sqldriver.RowsAffected(2)
+	if _, err := exec(ctx, "delete from user where id = ?", 1); !errors.Is(err, ErrTooManyRowsAffected) {
+		t.Fatalf("expected ErrTooManyRowsAffected, got %v", err)
+	}
+	// the transaction is left to its owner.
+	if len(raw.queries) != 0 {
+		t.Fatalf("unexpected queries: %q", raw.queries)
+	}
+	// the cap is not checked if the driver can not report the rows affected.
+	result = noRowsAffectedResult{}
+	if got, err := exec(ctx, "delete from user where id = ?", 1); err != nil || got != result {
+		t.Fatalf("expected the result of the statement, got %v %v", got, err)
+	}
+}
+
+func TestMaxAffectedRows_InvalidAttribute(t *testing.T) {
+	for _, value := range []string{"0", "-1", "one"} {
+		mapper := `<mapper namespace="main.User">
+    <delete id="DeleteByID" maxAffectedRows="` + value + `">delete from user where id = #{id}</delete>
+</mapper>`
+		parser := &XMLMappersElementParser{parser: &XMLParser{}}
+		_, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+		if err == nil || !strings.Contains(err.Error(), "invalid maxAffectedRows attribute of statement DeleteByID") {
+			t.Errorf("%s: expected the error of the invalid attribute, got %v", value, err)
+		}
+	}
+}
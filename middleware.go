@@ -21,6 +21,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/go-juicedev/juice/driver"
 	"github.com/go-juicedev/juice/internal/reflectlite"
 	"github.com/go-juicedev/juice/session"
 	"log"
@@ -89,7 +90,7 @@ func (m *DebugMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryH
 		start := time.Now()
 		rows, err := next(ctx, query, args...)
 		spent := time.Since(start)
-		logger.Printf("\x1b[33m[%s]\x1b[0m \x1b[32m %s\x1b[0m \x1b[38m %v\x1b[0m \x1b[31m %v\x1b[0m\n", stmt.Name(), query, args, spent)
+		logger.Printf("\x1b[33m[%s]\x1b[0m \x1b[32m %s\x1b[0m \x1b[38m %v\x1b[0m \x1b[31m %v\x1b[0m\n", m.label(stmt), query, MaskArgs(ctx, args), spent)
 		return rows, err
 	}
 }
@@ -105,11 +106,19 @@ func (m *DebugMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHand
 		start := time.Now()
 		rows, err := next(ctx, query, args...)
 		spent := time.Since(start)
-		logger.Printf("\x1b[33m[%s]\x1b[0m \x1b[32m %s\x1b[0m \x1b[38m %v\x1b[0m \x1b[31m %v\x1b[0m\n", stmt.Name(), query, args, spent)
+		logger.Printf("\x1b[33m[%s]\x1b[0m \x1b[32m %s\x1b[0m \x1b[38m %v\x1b[0m \x1b[31m %v\x1b[0m\n", m.label(stmt), query, MaskArgs(ctx, args), spent)
 		return rows, err
 	}
 }
 
+// label returns the name of the statement with its position in the mapper file if it is recorded.
+func (m *DebugMiddleware) label(stmt Statement) string {
+	if source, ok := SourceOf(stmt); ok {
+		return stmt.Name() + " " + source.String()
+	}
+	return stmt.Name()
+}
+
 // isDeBugMode returns true if the debug mode is on.
 // Default debug mode is on.
 // You can turn off the debug mode by setting the debug tag to false in the mapper xmlSQLStatement attribute or the configuration.
@@ -202,24 +211,59 @@ func (m *useGeneratedKeysMiddleware) ExecContext(stmt Statement, next ExecHandle
 	if !useGeneratedKeys {
 		return next
 	}
-	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
-		result, err := next(ctx, query, args...)
-		if err != nil {
-			return nil, err
-		}
-
-		id, err := result.LastInsertId()
-		if err != nil {
-			return nil, err
+	keyColumns := keyColumnsOf(stmt)
+	keyProperties := splitKeyAttribute(stmt.Attribute("keyProperty"))
+	if len(keyProperties) > 1 && len(keyProperties) != len(keyColumns) {
+		return func(context.Context, string, ...any) (sql.Result, error) {
+			return nil, fmt.Errorf("useGeneratedKeys: %d keyProperty for %d keyColumn of statement %s", len(keyProperties), len(keyColumns), stmt.Name())
 		}
-		rowsAffected, err := result.RowsAffected()
-		if err != nil {
-			return nil, err
+	}
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		var (
+			result sql.Result
+			keys   [][]int64
+			ids    []int64
+			id     int64
+			err    error
+		)
+		// the databases which can not report the last insert id return the generated keys by the RETURNING clause.
+		if drv, _ := driver.FromContext(ctx); drv != nil {
+			if returning, ok := drv.(driver.ReturningClauseBuilder); ok {
+				query = withReturningClause(query, drv.Translator(), returning.ReturningClause(keyColumns...))
+				if keys, err = queryGeneratedKeys(ctx, query, args, len(keyColumns)); err != nil {
+					return nil, err
+				}
+				ids = make([]int64, len(keys))
+				for i, key := range keys {
+					ids[i] = key[0]
+				}
+				result = returningResult{ids: ids}
+				if len(ids) == 0 {
+					return result, nil
+				}
+				id = ids[len(ids)-1]
+			}
 		}
-		// on most databases, the last insert ID is the first row affected.
-		// calculate the last insert ID by the number of rows affected.
-		if rowsAffected > 1 {
-			id = id + rowsAffected - 1
+		if result == nil {
+			// only one key is reported by the last insert id.
+			if len(keyProperties) > 1 {
+				return nil, fmt.Errorf("useGeneratedKeys: the composite keyProperty of statement %s requires the driver returning the generated keys", stmt.Name())
+			}
+			if result, err = next(ctx, query, args...); err != nil {
+				return nil, err
+			}
+			if id, err = result.LastInsertId(); err != nil {
+				return nil, err
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return nil, err
+			}
+			// on most databases, the last insert ID is the first row affected.
+			// calculate the last insert ID by the number of rows affected.
+			if rowsAffected > 1 {
+				id = id + rowsAffected - 1
+			}
 		}
 		// try to get param from context
 		// ParamCtxInjectorExecutor is already set in middlewares, so the param should be in the context.
@@ -254,13 +298,15 @@ func (m *useGeneratedKeysMiddleware) ExecContext(stmt Statement, next ExecHandle
 
 		var keyGenerator selectKeyGenerator
 
-		switch reflectlite.Unwrap(rv).Kind() {
-		case reflect.Struct:
+		switch kind := reflectlite.Unwrap(rv).Kind(); {
+		case len(keyProperties) > 1:
+			keyGenerator = compositeKeyGenerator{keyProperties: keyProperties, keys: keys}
+		case kind == reflect.Struct:
 			keyGenerator = &singleKeyGenerator{
 				keyProperty: keyProperty,
 				id:          id,
 			}
-		case reflect.Array, reflect.Slice:
+		case kind == reflect.Array, kind == reflect.Slice:
 			// try to get the keyIncrement from the xmlSQLStatement
 			// if the keyIncrement is not set or invalid, use the default value 1
 			keyIncrementValue := stmt.Attribute("keyIncrement")
@@ -275,6 +321,7 @@ func (m *useGeneratedKeysMiddleware) ExecContext(stmt Statement, next ExecHandle
 				id:                            id,
 				keyIncrement:                  keyIncrement,
 				batchInsertIDGenerateStrategy: batchInsertIDStrategy,
+				ids:                           ids,
 			}
 		default:
 			return nil, errStructPointerOrSliceArrayRequired
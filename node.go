@@ -17,14 +17,18 @@ limitations under the License.
 package juice
 
 import (
+	"cmp"
 	"fmt"
 	"github.com/go-juicedev/juice/internal/reflectlite"
+	"iter"
 	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/go-juicedev/juice/eval"
+	"github.com/go-juicedev/juice/eval/expr"
 
 	"github.com/go-juicedev/juice/driver"
 )
@@ -37,7 +41,9 @@ var (
 	//   - #{  age  }    -> matches (whitespace is ignored)
 	//   - #{}           -> doesn't match (requires identifier)
 	//   - #{123}        -> matches
-	paramRegex = regexp.MustCompile(`#{\s*(\w+(?:\.\w+)*)\s*}`)
+	//   - #{name, default='anonymous'} -> matches with the default of the missing or nil name
+	//   - #{age ?? 18}  -> matches with the default, same as #{age, default=18}
+	paramRegex = regexp.MustCompile(`#{\s*(\w+(?:\.\w+)*)\s*` + defaultPattern + `}`)
 
 	// formatRegexp matches string interpolation placeholders using ${...} syntax.
 	// Unlike paramRegex, these are replaced directly in the SQL string.
@@ -48,9 +54,58 @@ var (
 	//   - ${  field  }  -> matches (whitespace is ignored)
 	//   - ${}           -> doesn't match (requires identifier)
 	//   - ${123}        -> matches
-	formatRegexp = regexp.MustCompile(`\${\s*(\w+(?:\.\w+)*)\s*}`)
+	//   - ${value ?? 0} -> matches with the default of the missing or nil value
+	formatRegexp = regexp.MustCompile(`\${\s*(\w+(?:\.\w+)*)\s*` + defaultPattern + `}`)
+
+	// textSubstitutionRegexp matches the ${...} of formatRegexp, and the conditional expressions in ${...}
+	// whose results are substituted, like ${asc ? 'ASC' : 'DESC'} or ${sort ?: 'id'}.
+	// The expression is the third group, the name and the default are empty for it.
+	textSubstitutionRegexp = regexp.MustCompile(`\${\s*(?:(\w+(?:\.\w+)*)\s*` + defaultPattern + `|([^{}]*\?[^{}]*:[^{}]*?))\s*}`)
 )
 
+// defaultPattern matches the optional default of the #{} and ${} placeholders, like ", default='anonymous'"
+// or "?? 0". The default is an expression evaluated with the parameters, usually a literal.
+const defaultPattern = `(?:(?:,\s*default\s*=|\?\?)\s*([^}]*?)\s*)?`
+
+// placeholderValue returns the value of the named parameter of the placeholder,
+// the default expression is evaluated if the parameter is missing or nil.
+func placeholderValue(p Parameter, placeholder []string) (reflect.Value, error) {
+	name := placeholder[1]
+	value, exists := p.Get(name)
+	if len(placeholder) < 3 || placeholder[2] == "" || (exists && !isNilValue(value)) {
+		if !exists {
+			return reflect.Value{}, fmt.Errorf("parameter %s not found", name)
+		}
+		return value, nil
+	}
+	value, err := eval.Eval(placeholder[2], p)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("default of parameter %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// substitutionValue returns the value of the ${} substitution, which is the result of the conditional
+// expression if it is, otherwise the value of the named parameter like placeholderValue.
+func substitutionValue(p Parameter, substitution []string) (reflect.Value, error) {
+	if len(substitution) < 4 || substitution[3] == "" {
+		return placeholderValue(p, substitution)
+	}
+	value, err := eval.Eval(substitution[3], p)
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("substitution %s: %w", substitution[0], err)
+	}
+	return value, nil
+}
+
+// isNilValue reports whether the value is invalid or a nil pointer, interface, map or slice.
+func isNilValue(value reflect.Value) bool {
+	for value.Kind() == reflect.Interface && !value.IsNil() {
+		value = value.Elem()
+	}
+	return !value.IsValid() || (reflectlite.NilAble(value) && value.IsNil())
+}
+
 // Node is the fundamental interface for all SQL generation components.
 // It defines the contract for converting dynamic SQL structures into
 // concrete SQL queries with their corresponding parameters.
@@ -76,6 +131,8 @@ var (
 //   - ForeachNode: Collection iteration
 //   - TrimNode: String manipulation
 //   - IncludeNode: SQL fragment reuse
+//   - BindNode: Computed variables
+//   - DialectNode: Dialect-specific statement bodies
 //
 // Example usage:
 //
@@ -122,6 +179,13 @@ func (g NodeGroup) Accept(translator driver.Translator, p Parameter) (query stri
 
 	// Process each node in the group
 	for i, node := range g {
+		// the variables of the bind nodes are visible to the following nodes.
+		if bind, ok := node.(*BindNode); ok {
+			if p, err = bind.scope(p); err != nil {
+				return "", nil, err
+			}
+			continue
+		}
 		q, a, err := node.Accept(translator, p)
 		if err != nil {
 			return "", nil, err
@@ -203,14 +267,17 @@ func (c *TextNode) replaceHolder(query string, args []any, translator driver.Tra
 	newArgs = append(newArgs, args...)
 
 	for _, param := range c.placeholder {
-		if len(param) != 2 {
+		if len(param) < 2 {
 			return "", nil, fmt.Errorf("invalid parameter %v", param)
 		}
 		matched, name := param[0], param[1]
 
-		value, exists := p.Get(name)
-		if !exists {
-			return "", nil, fmt.Errorf("parameter %s not found", name)
+		value, err := placeholderValue(p, param)
+		if err != nil {
+			return "", nil, err
+		}
+		if err = checkArgValue(matched, value); err != nil {
+			return "", nil, err
 		}
 
 		pos := strings.Index(query[lastIndex:], matched)
@@ -220,10 +287,23 @@ func (c *TextNode) replaceHolder(query string, args []any, translator driver.Tra
 		pos += lastIndex
 
 		builder.WriteString(query[lastIndex:pos])
-		builder.WriteString(translator.Translate(name))
 		lastIndex = pos + len(matched)
 
-		newArgs = append(newArgs, value.Interface())
+		arg := redactArg(p, name, value.Interface())
+
+		// named placeholders take the arguments as sql.NamedArg.
+		if named, ok := translator.(driver.NamedTranslator); ok {
+			placeholder, namedArg, reused := named.TranslateNamed(name, arg)
+			builder.WriteString(placeholder)
+			// the repeated parameter with an equal value shares the argument passed before.
+			if !reused {
+				newArgs = append(newArgs, namedArg)
+			}
+			continue
+		}
+
+		builder.WriteString(translator.Translate(name))
+		newArgs = append(newArgs, arg)
 	}
 
 	builder.WriteString(query[lastIndex:])
@@ -242,14 +322,14 @@ func (c *TextNode) replaceTextSubstitution(query string, p Parameter) (string, e
 
 	lastIndex := 0
 	for _, sub := range c.textSubstitution {
-		if len(sub) != 2 {
+		if len(sub) < 2 {
 			return "", fmt.Errorf("invalid text substitution %v", sub)
 		}
-		matched, name := sub[0], sub[1]
+		matched := sub[0]
 
-		value, exists := p.Get(name)
-		if !exists {
-			return "", fmt.Errorf("parameter %s not found", name)
+		value, err := substitutionValue(p, sub)
+		if err != nil {
+			return "", err
 		}
 
 		pos := strings.Index(query[lastIndex:], matched)
@@ -273,7 +353,7 @@ func (c *TextNode) replaceTextSubstitution(query string, p Parameter) (string, e
 func NewTextNode(str string) Node {
 	placeholder := paramRegex.FindAllStringSubmatch(str, -1)
 
-	textSubstitution := formatRegexp.FindAllStringSubmatch(str, -1)
+	textSubstitution := textSubstitutionRegexp.FindAllStringSubmatch(str, -1)
 
 	if len(placeholder) == 0 && len(textSubstitution) == 0 {
 		return pureTextNode(str)
@@ -284,16 +364,25 @@ func NewTextNode(str string) Node {
 // ConditionNode represents a conditional SQL fragment with its evaluation expression and child nodes.
 // It is used to conditionally include or exclude SQL fragments based on runtime parameters.
 type ConditionNode struct {
-	expr  eval.Expression
-	Nodes NodeGroup
+	expr   eval.Expression
+	test   string
+	source SourcePosition
+	Nodes  NodeGroup
 }
 
 // Parse compiles the given expression string into an evaluable expression.
 // The expression syntax supports various operations like:
 //   - Comparison: ==, !=, >, <, >=, <=
 //   - Logical: &&, ||, !
+//   - Unary: -offset, +offset, ^mask on the numbers, like offset > -1
 //   - Null checks: != null, == null
 //   - Property access: user.age, order.status
+//   - Null-safe property access: user?.profile?.age, which is the zero value of age if any of them is nil
+//   - Conditional expressions: age >= 18 ? "adult" : "minor", iif(age >= 18, "adult", "minor"), and nickname ?: name, which is name if nickname is nil or zero
+//   - Method calls: user.IsAdmin(), createdAt.Before(now), the methods must take no arguments or the simple ones (booleans, numbers, strings, times),
+//     and return a value, or a value and an error
+//   - String literals: "say \"hi\"", 'it\'s', "caf\u00e9" with the Go escapes, and the raw strings like `C:\dir`
+//   - Conversions: int(page) > 1, float(price), bool(active), str(code) or string(code), and '%' || code || '%'
 //
 // Examples:
 //
@@ -303,9 +392,15 @@ type ConditionNode struct {
 //	"user.role == "ADMIN""    // Property access
 func (c *ConditionNode) Parse(test string) (err error) {
 	c.expr, err = eval.Compile(test)
+	c.test = test
 	return err
 }
 
+// Test returns the expression string of the condition.
+func (c *ConditionNode) Test() string {
+	return c.test
+}
+
 // Accept accepts parameters and returns query and arguments.
 // Accept implements Node interface.
 func (c *ConditionNode) Accept(translator driver.Translator, p Parameter) (query string, args []any, err error) {
@@ -325,10 +420,28 @@ func (c *ConditionNode) Accept(translator driver.Translator, p Parameter) (query
 //   - Integers (signed/unsigned): returns true if non-zero
 //   - Floats: returns true if non-zero
 //   - String: returns true if non-empty
+//
+// In truthy mode, see eval.WithTruthy, the value is coerced by expr.Truthy instead,
+// like the non-nil pointers and the non-empty slices are true.
 func (c *ConditionNode) Match(p Parameter) (bool, error) {
+	if traced, ok := exprTraceOf(p); ok {
+		return traced.match(c, p)
+	}
+	return c.match(p)
+}
+
+// match evaluates the expression and converts the result to bool.
+func (c *ConditionNode) match(p Parameter) (bool, error) {
 	value, err := c.expr.Execute(p)
 	if err != nil {
-		return false, err
+		return false, withSource(err, c.source)
+	}
+	if eval.IsTruthy(p) {
+		return expr.Truthy(value), nil
+	}
+	// the values of the map parameters are boxed, like the bool of H{"admin": true}.
+	for value.Kind() == reflect.Interface {
+		value = value.Elem()
 	}
 	switch value.Kind() {
 	case reflect.Bool:
@@ -342,7 +455,7 @@ func (c *ConditionNode) Match(p Parameter) (bool, error) {
 	case reflect.String:
 		return value.String() != "", nil
 	default:
-		return false, fmt.Errorf("unsupported type %s", value.Kind())
+		return false, withSource(fmt.Errorf("unsupported type %s", value.Kind()), c.source)
 	}
 }
 
@@ -367,15 +480,104 @@ type IfNode = ConditionNode
 
 var _ Node = (*IfNode)(nil)
 
+// BindNode binds the value of an expression to a variable, which is visible to the following nodes
+// of its parent, like MyBatis does. The variables shadow the parameters of the same names.
+//
+// Example XML:
+//
+//	<bind name="pattern" value="'%' + name + '%'"/>
+//	select * from user where name like #{pattern}
+type BindNode struct {
+	Name   string
+	expr   eval.Expression
+	value  string
+	source SourcePosition
+}
+
+// Parse compiles the value expression of the variable.
+func (b *BindNode) Parse(value string) (err error) {
+	b.expr, err = eval.Compile(value)
+	b.value = value
+	return err
+}
+
+// Accept implements Node, the bind node produces nothing by itself, the parent nodes evaluate it by scope.
+func (b *BindNode) Accept(_ driver.Translator, _ Parameter) (query string, args []any, err error) {
+	return "", nil, nil
+}
+
+// scope evaluates the value and returns the parameter of the following nodes, which has the variable.
+func (b *BindNode) scope(p Parameter) (Parameter, error) {
+	value, err := b.expr.Execute(p)
+	if err != nil {
+		return nil, withSource(fmt.Errorf("bind %s: %w", b.Name, err), b.source)
+	}
+	variable := eval.NewItemParameter(b.Name, "", eval.IsFoldKeys(p))
+	variable.Bind(value, reflect.Value{})
+	return eval.ParamGroup{variable, p}, nil
+}
+
+var _ Node = (*BindNode)(nil)
+
+// DialectNode selects the body of the statement by the dialect of the translator, see driver.DialectOf,
+// so that one statement holds the SQL of every database it runs on.
+//
+// Example XML:
+//
+//	<select id="Page">
+//	  <dialect driver="mysql,sqlite3">select * from user limit #{offset}, #{limit}</dialect>
+//	  <dialect driver="oracle">select * from user offset #{offset} rows fetch next #{limit} rows only</dialect>
+//	  <dialect>select * from user limit #{limit} offset #{offset}</dialect>
+//	</select>
+//
+// The consecutive dialect tags of the statement form one DialectNode. The dialect tag without
+// the driver attribute is the default body, which is used when none of the drivers matches.
+type DialectNode struct {
+	Cases  []DialectCase
+	source SourcePosition
+}
+
+// DialectCase is a body of the DialectNode, it is the default body if Drivers is empty.
+type DialectCase struct {
+	Drivers []string
+	Nodes   NodeGroup
+}
+
+// Accept accepts parameters and returns query and arguments of the body matched by the dialect.
+func (d DialectNode) Accept(translator driver.Translator, p Parameter) (query string, args []any, err error) {
+	dialect := driver.DialectOf(translator)
+	var fallback *DialectCase
+	for i, body := range d.Cases {
+		if len(body.Drivers) == 0 {
+			fallback = &d.Cases[i]
+			continue
+		}
+		if dialect != "" && slices.ContainsFunc(body.Drivers, func(name string) bool { return strings.EqualFold(name, dialect) }) {
+			return body.Nodes.Accept(translator, p)
+		}
+	}
+	if fallback == nil {
+		return "", nil, withSource(fmt.Errorf("%w: %q", ErrDialectNotMatched, dialect), d.source)
+	}
+	return fallback.Nodes.Accept(translator, p)
+}
+
+var _ Node = (*DialectNode)(nil)
+
 // WhereNode represents a SQL WHERE clause and its conditions.
 // It manages a group of condition nodes that form the complete WHERE clause.
+//
+// PrefixOverrides and SuffixOverrides default to "AND|OR" when empty,
+// they can be declared on the where tag like the trim tag does.
 type WhereNode struct {
-	Nodes NodeGroup
+	Nodes           NodeGroup
+	PrefixOverrides []string
+	SuffixOverrides []string
 }
 
 // Accept processes the WHERE clause and its conditions.
 // It handles several special cases:
-//  1. Removes leading "AND" or "OR" from the first condition
+//  1. Removes leading and trailing "AND" or "OR" of the conditions
 //  2. Ensures the clause starts with "WHERE" if not already present
 //  3. Properly handles spacing between conditions
 //
@@ -383,6 +585,7 @@ type WhereNode struct {
 //
 //	Input:  "AND id = ?"        -> Output: "WHERE id = ?"
 //	Input:  "OR name = ?"       -> Output: "WHERE name = ?"
+//	Input:  "id = ? AND"        -> Output: "WHERE id = ?"
 //	Input:  "WHERE age > ?"     -> Output: "WHERE age > ?"
 //	Input:  "status = ?"        -> Output: "WHERE status = ?"
 func (w WhereNode) Accept(translator driver.Translator, p Parameter) (query string, args []any, err error) {
@@ -394,19 +597,16 @@ func (w WhereNode) Accept(translator driver.Translator, p Parameter) (query stri
 	if query == "" {
 		return "", args, nil
 	}
-	// A space is required at the end; otherwise, it is meaningless.
-	switch {
-	case strings.HasPrefix(query, "and ") || strings.HasPrefix(query, "AND "):
-		query = query[4:]
-	case strings.HasPrefix(query, "or ") || strings.HasPrefix(query, "OR "):
-		query = query[3:]
-	}
+	query = trimPrefixOverrides(query, overridesOr(w.PrefixOverrides, defaultWherePrefixOverrides))
+	query = trimSuffixOverrides(query, overridesOr(w.SuffixOverrides, defaultWhereSuffixOverrides))
 
-	// A space is required at the end; otherwise, it is meaningless.
-	if !(strings.HasPrefix(query, "where ") || strings.HasPrefix(query, "WHERE ")) {
-		query = "WHERE " + query
+	if hasPrefixWord(query, "where") {
+		query = trimPrefixOverrides(query[len("where"):], overridesOr(w.PrefixOverrides, defaultWherePrefixOverrides))
 	}
-	return
+	if query == "" {
+		return "", args, nil
+	}
+	return "WHERE " + query, args, nil
 }
 
 var _ Node = (*WhereNode)(nil)
@@ -418,7 +618,7 @@ var _ Node = (*WhereNode)(nil)
 // Fields:
 //   - Nodes: Group of child nodes containing the SQL fragments
 //   - Prefix: String to prepend to the result if content exists
-//   - PrefixOverrides: Strings to remove if found at the start
+//   - PrefixOverrides: Strings to remove if found at the start, matched case-insensitively as whole words
 //   - Suffix: String to append to the result if content exists
 //   - SuffixOverrides: Strings to remove if found at the end
 //
@@ -429,7 +629,7 @@ var _ Node = (*WhereNode)(nil)
 //
 // Example XML:
 //
-//	<trim prefix="WHERE" prefixOverrides="AND|OR">
+//	<trim prefix="WHERE" prefixOverrides="AND |OR ">
 //	  <if test="id > 0">
 //	    AND id = #{id}
 //	  </if>
@@ -457,45 +657,14 @@ func (t TrimNode) Accept(translator driver.Translator, p Parameter) (query strin
 		return "", nil, err
 	}
 
-	if len(query) == 0 {
-		return "", nil, nil
-	}
-
-	// Handle prefix overrides before adding prefix
-	if len(t.PrefixOverrides) > 0 {
-		for _, prefix := range t.PrefixOverrides {
-			if strings.HasPrefix(query, prefix) {
-				query = query[len(prefix):]
-				break
-			}
-		}
-	}
-
-	// Handle suffix overrides before adding suffix
-	if len(t.SuffixOverrides) > 0 {
-		for _, suffix := range t.SuffixOverrides {
-			if strings.HasSuffix(query, suffix) {
-				query = query[:len(query)-len(suffix)]
-				break
-			}
-		}
-	}
+	// Handle overrides before adding prefix and suffix
+	query = trimPrefixOverrides(query, t.PrefixOverrides)
+	query = trimSuffixOverrides(query, t.SuffixOverrides)
 
-	// Build final query with prefix and suffix
-	var builder = getStringBuilder()
-	defer putStringBuilder(builder)
-
-	builder.Grow(len(t.Prefix) + len(query) + len(t.Suffix))
-
-	if t.Prefix != "" {
-		builder.WriteString(t.Prefix)
-	}
-	builder.WriteString(query)
-	if t.Suffix != "" {
-		builder.WriteString(t.Suffix)
+	if len(query) == 0 {
+		return "", args, nil
 	}
-
-	return builder.String(), args, nil
+	return joinTrimmed(t.Prefix, query, t.Suffix), args, nil
 }
 
 var _ Node = (*TrimNode)(nil)
@@ -505,10 +674,10 @@ var _ Node = (*TrimNode)(nil)
 // iteration over a collection of values in SQL generation.
 //
 // Fields:
-//   - Collection: Expression to get the collection to iterate over
+//   - Collection: Expression to get the slice, array, map or channel to iterate over
 //   - Nodes: SQL fragments to be repeated for each item
 //   - Item: Variable name for the current item in iteration
-//   - Index: Variable name for the current index (optional)
+//   - Index: Variable name for the current index, or the key of the map (optional)
 //   - Open: String to prepend before the iteration results
 //   - Close: String to append after the iteration results
 //   - Separator: String to insert between iterations
@@ -535,6 +704,13 @@ var _ Node = (*TrimNode)(nil)
 //     id = #{id}
 //     </foreach>
 //
+//  4. Nested iterations, the variables of the nested foreach must not shadow the outer ones:
+//     <foreach collection="rows" item="row" separator=",">
+//     (<foreach collection="row.values" item="value" index="column" separator=",">#{value}</foreach>)
+//     </foreach>
+//
+// A channel is received until it is closed, so it must be closed by the sender.
+//
 // Example results:
 //
 //	Input collection: [1, 2, 3]
@@ -548,15 +724,32 @@ type ForeachNode struct {
 	Open       string
 	Close      string
 	Separator  string
+	source     SourcePosition
+	// Deterministic makes the map collection iterated in the order of its keys,
+	// so that the identical inputs always produce the identical query.
+	Deterministic bool
 }
 
 // Accept accepts parameters and returns query and arguments.
 func (f ForeachNode) Accept(translator driver.Translator, p Parameter) (query string, args []any, err error) {
+	query, args, err = f.accept(translator, p)
+	if err != nil {
+		return "", nil, withSource(err, f.source)
+	}
+	return query, args, nil
+}
+
+func (f ForeachNode) accept(translator driver.Translator, p Parameter) (query string, args []any, err error) {
 
-	// if item already exists
+	// the variables of the nested foreach nodes must be distinct, otherwise the outer ones are shadowed.
 	if _, exists := p.Get(f.Item); exists {
 		return "", nil, fmt.Errorf("item %s already exists", f.Item)
 	}
+	if f.Index != "" {
+		if _, exists := p.Get(f.Index); exists || f.Index == f.Item {
+			return "", nil, fmt.Errorf("index %s already exists", f.Index)
+		}
+	}
 
 	// one collection from parameter
 	value, exists := p.Get(f.Collection)
@@ -576,123 +769,99 @@ func (f ForeachNode) Accept(translator driver.Translator, p Parameter) (query st
 
 	switch value.Kind() {
 	case reflect.Array, reflect.Slice:
-		return f.acceptSlice(value, translator, p)
+		return f.acceptItems(f.sliceItems(value), value.Len(), translator, p)
 	case reflect.Map:
-		return f.acceptMap(value, translator, p)
+		return f.acceptItems(f.mapItems(value), value.Len(), translator, p)
+	case reflect.Chan:
+		if value.Type().ChanDir()&reflect.RecvDir == 0 {
+			return "", nil, fmt.Errorf("collection %s is a send-only channel", f.Collection)
+		}
+		return f.acceptItems(chanItems(value), 0, translator, p)
 	default:
-		return "", nil, fmt.Errorf("collection %s is not a slice or map", f.Collection)
+		return "", nil, fmt.Errorf("collection %s is not a slice, map or channel", f.Collection)
 	}
 }
 
-func (f ForeachNode) acceptSlice(value reflect.Value, translator driver.Translator, p Parameter) (query string, args []any, err error) {
-	sliceLength := value.Len()
-
-	if sliceLength == 0 {
-		return "", nil, nil
-	}
-
-	// Pre-allocate args slice capacity to avoid multiple growths
-	// Estimate: number of slice elements * number of nodes
-	estimatedArgsLen := sliceLength * len(f.Nodes)
-
-	args = make([]any, 0, estimatedArgsLen)
-
-	// Pre-allocate string builder capacity to minimize buffer reallocations
-	// Capacity = open + items + separators + close
-	estimatedBuilderCap := len(f.Open) + (2 * sliceLength) + (len(f.Separator) * (sliceLength - 1)) + len(f.Close)
-
-	var builder = getStringBuilder()
-	defer putStringBuilder(builder)
-
-	builder.Grow(estimatedBuilderCap)
-
-	builder.WriteString(f.Open)
-
-	end := sliceLength - 1
-
-	h := make(eval.H, 2)
-
-	// Create and reuse GenericParameter outside the loop to avoid allocations per iteration
-	genericParameter := &eval.GenericParameter{Value: reflect.ValueOf(h)}
-
-	group := eval.ParamGroup{genericParameter, p}
-
-	for i := 0; i < sliceLength; i++ {
-
-		item := value.Index(i).Interface()
-
-		h[f.Item] = item
-		h[f.Index] = i
-
-		for _, node := range f.Nodes {
-			q, a, err := node.Accept(translator, group)
-			if err != nil {
-				return "", nil, err
-			}
-			if len(q) > 0 {
-				builder.WriteString(q)
-			}
-			if len(a) > 0 {
-				args = append(args, a...)
+// sliceItems yields the indexes and the items of the slice or array.
+func (f ForeachNode) sliceItems(value reflect.Value) iter.Seq2[reflect.Value, reflect.Value] {
+	return func(yield func(reflect.Value, reflect.Value) bool) {
+		for i := 0; i < value.Len(); i++ {
+			if !yield(reflect.ValueOf(i), value.Index(i)) {
+				return
 			}
 		}
+	}
+}
 
-		if i < end {
-			builder.WriteString(f.Separator)
+// mapItems yields the keys and the values of the map, in the order of the keys if Deterministic is set.
+func (f ForeachNode) mapItems(value reflect.Value) iter.Seq2[reflect.Value, reflect.Value] {
+	return func(yield func(reflect.Value, reflect.Value) bool) {
+		keys := value.MapKeys()
+		if f.Deterministic {
+			sortMapKeys(keys)
+		}
+		for _, key := range keys {
+			if !yield(key, value.MapIndex(key)) {
+				return
+			}
 		}
-		genericParameter.Clear()
 	}
-
-	// if sliceLength is not zero, add close
-	builder.WriteString(f.Close)
-
-	return builder.String(), args, nil
 }
 
-func (f ForeachNode) acceptMap(value reflect.Value, translator driver.Translator, p Parameter) (query string, args []any, err error) {
-	keys := value.MapKeys()
-
-	if len(keys) == 0 {
-		return "", nil, nil
+// chanItems yields the received values of the channel and their indexes until the channel is closed,
+// the channel must be closed by the sender, or the build blocks.
+func chanItems(value reflect.Value) iter.Seq2[reflect.Value, reflect.Value] {
+	return func(yield func(reflect.Value, reflect.Value) bool) {
+		for i := 0; ; i++ {
+			item, ok := value.Recv()
+			if !ok || !yield(reflect.ValueOf(i), item) {
+				return
+			}
+		}
 	}
+}
 
+// acceptItems renders the nodes for each item, size is the estimated number of the items.
+func (f ForeachNode) acceptItems(items iter.Seq2[reflect.Value, reflect.Value], size int, translator driver.Translator, p Parameter) (query string, args []any, err error) {
 	// Pre-allocate args slice capacity to avoid multiple growths
-	// Estimate: number of slice elements * number of nodes
-	estimatedArgsLen := len(keys) * len(f.Nodes)
-
-	args = make([]any, 0, estimatedArgsLen)
-
-	// Pre-allocate string builder capacity to minimize buffer reallocations
-	// Capacity = open + items + separators + close
-	estimatedBuilderCap := len(f.Open) + (2 * len(keys)) + (len(f.Separator) * (len(keys) - 1)) + len(f.Close)
+	// Estimate: number of items * number of nodes
+	args = make([]any, 0, size*len(f.Nodes))
 
 	var builder = getStringBuilder()
 	defer putStringBuilder(builder)
 
-	builder.Grow(estimatedBuilderCap)
-
-	builder.WriteString(f.Open)
-
-	end := len(keys) - 1
-
-	var index int
+	// Pre-allocate string builder capacity to minimize buffer reallocations
+	// Capacity = open + items + separators + close
+	builder.Grow(len(f.Open) + (2 * size) + (len(f.Separator) * max(size-1, 0)) + len(f.Close))
 
-	h := make(eval.H, 2)
+	// the item parameter is created once and bound to each item, so the expressions of the nodes
+	// are evaluated against the items without recreating or clearing the scope.
+	itemParameter := eval.NewItemParameter(f.Item, f.Index, eval.IsFoldKeys(p))
 
-	// Create and reuse GenericParameter outside the loop to avoid allocations per iteration
-	genericParameter := &eval.GenericParameter{Value: reflect.ValueOf(h)}
+	group := eval.ParamGroup{itemParameter, p}
 
-	group := eval.ParamGroup{genericParameter, p}
+	var count int
 
-	for _, key := range keys {
+	for index, item := range items {
 
-		item := value.MapIndex(key).Interface()
+		if count == 0 {
+			builder.WriteString(f.Open)
+		} else {
+			builder.WriteString(f.Separator)
+		}
+		count++
 
-		h[f.Item] = item
-		h[f.Index] = key.Interface()
+		itemParameter.Bind(item, index)
 
+		scope := Parameter(group)
 		for _, node := range f.Nodes {
-			q, a, err := node.Accept(translator, group)
+			if bind, ok := node.(*BindNode); ok {
+				if scope, err = bind.scope(scope); err != nil {
+					return "", nil, err
+				}
+				continue
+			}
+			q, a, err := node.Accept(translator, scope)
 			if err != nil {
 				return "", nil, err
 			}
@@ -703,14 +872,11 @@ func (f ForeachNode) acceptMap(value reflect.Value, translator driver.Translator
 				args = append(args, a...)
 			}
 		}
+	}
 
-		if index < end {
-			builder.WriteString(f.Separator)
-		}
-
-		genericParameter.Clear()
-
-		index++
+	// the empty collection renders nothing, even the open and close.
+	if count == 0 {
+		return "", nil, nil
 	}
 
 	builder.WriteString(f.Close)
@@ -718,6 +884,29 @@ func (f ForeachNode) acceptMap(value reflect.Value, translator driver.Translator
 	return builder.String(), args, nil
 }
 
+// sortMapKeys sorts the keys of a map, the keys of the same kind are compared by their values,
+// and the others are compared by their formatted strings.
+func sortMapKeys(keys []reflect.Value) {
+	slices.SortStableFunc(keys, func(x, y reflect.Value) int {
+		a, b := reflectlite.Unwrap(x), reflectlite.Unwrap(y)
+		if a.Kind() == b.Kind() {
+			switch a.Kind() {
+			case reflect.String:
+				return cmp.Compare(a.String(), b.String())
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				return cmp.Compare(a.Int(), b.Int())
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				return cmp.Compare(a.Uint(), b.Uint())
+			case reflect.Float32, reflect.Float64:
+				return cmp.Compare(a.Float(), b.Float())
+			case reflect.Bool:
+				return cmp.Compare(strconv.FormatBool(a.Bool()), strconv.FormatBool(b.Bool()))
+			}
+		}
+		return cmp.Compare(fmt.Sprint(x.Interface()), fmt.Sprint(y.Interface()))
+	})
+}
+
 var _ Node = (*ForeachNode)(nil)
 
 // SetNode represents an SQL SET clause for UPDATE statements.
@@ -755,11 +944,14 @@ var _ Node = (*ForeachNode)(nil)
 //	Case 2 (only status set):
 //	  UPDATE users SET status = ? WHERE id = ?
 //
-// Note: The node automatically handles trailing commas and ensures
+// Note: The node automatically handles leading and trailing commas and ensures
 // proper formatting of the SET clause regardless of which fields
-// are included dynamically.
+// are included dynamically. PrefixOverrides and SuffixOverrides default to ","
+// when empty.
 type SetNode struct {
-	Nodes NodeGroup
+	Nodes           NodeGroup
+	PrefixOverrides []string
+	SuffixOverrides []string
 }
 
 // Accept accepts parameters and returns query and arguments.
@@ -771,18 +963,151 @@ func (s SetNode) Accept(translator driver.Translator, p Parameter) (query string
 	if len(query) == 0 {
 		return "", args, nil
 	}
-	// Remove trailing comma
-	query = strings.TrimSuffix(query, ",")
+	if hasPrefixWord(query, "set") {
+		query = query[len("set"):]
+	}
+	// Remove dangling commas
+	query = trimPrefixOverrides(query, overridesOr(s.PrefixOverrides, defaultSetOverrides))
+	query = trimSuffixOverrides(query, overridesOr(s.SuffixOverrides, defaultSetOverrides))
+	if len(query) == 0 {
+		return "", args, nil
+	}
+	return "SET " + query, args, nil
+}
 
-	// Ensure SET prefix if not present
-	if !(strings.HasPrefix(query, "set ") || strings.HasPrefix(query, "SET ")) {
-		query = "SET " + query
+var _ Node = (*SetNode)(nil)
+
+// ignoreZeroNode wraps an assignment of the SET clause which is declared with ignoreZero="true",
+// it renders nothing if all the parameters of the assignment are zero values.
+//
+// Example XML:
+//
+//	<update id="updateUser">
+//	  UPDATE users
+//	  <set ignoreZero="true" includeZero="status">
+//	    name = #{name}, age = #{age}, status = #{status}
+//	  </set>
+//	  WHERE id = #{id}
+//	</update>
+//
+// If age is zero, the result is:
+//
+//	UPDATE users SET name = ?, status = ? WHERE id = ?
+//
+// Note that a non-nil pointer is not a zero value, so it can be used to write zero intentionally,
+// and a parameter which is not found is an error like the other placeholders.
+type ignoreZeroNode struct {
+	node *TextNode
+}
+
+// Accept accepts parameters and returns query and arguments.
+func (i ignoreZeroNode) Accept(translator driver.Translator, p Parameter) (query string, args []any, err error) {
+	for _, param := range i.node.placeholder {
+		value, err := placeholderValue(p, param)
+		if err != nil {
+			return "", nil, err
+		}
+		if value = reflectlite.Unpack(value); value.IsValid() && !value.IsZero() {
+			return i.node.Accept(translator, p)
+		}
 	}
+	return "", nil, nil
+}
 
-	return query, args, nil
+var _ Node = (*ignoreZeroNode)(nil)
+
+// setAssignmentOptions controls how the assignments of a SET clause are rendered.
+type setAssignmentOptions struct {
+	// ignoreZero skips the assignments whose parameters are all zero values.
+	ignoreZero bool
+	// includeZero is the columns which are written even if their parameters are zero values.
+	includeZero []string
+	// fieldMask is the name of the parameter which holds the FieldMask.
+	fieldMask string
 }
 
-var _ Node = (*SetNode)(nil)
+// newSetAssignmentNodes splits the text of a SET clause into assignments,
+// and wraps every assignment with ignoreZeroNode or fieldMaskNode by the options.
+func newSetAssignmentNodes(text string, options setAssignmentOptions) NodeGroup {
+	var nodes NodeGroup
+	for _, assignment := range splitAssignments(text) {
+		node := NewTextNode(assignment + ",")
+		textNode, ok := node.(*TextNode)
+		if !ok || len(textNode.placeholder) == 0 {
+			nodes = append(nodes, node)
+			continue
+		}
+		column, _, _ := strings.Cut(assignment, "=")
+		column = strings.TrimSpace(column)
+		if options.ignoreZero && !slices.Contains(options.includeZero, column) {
+			node = ignoreZeroNode{node: textNode}
+		}
+		if options.fieldMask != "" {
+			node = fieldMaskNode{node: node, column: column, placeholder: textNode.placeholder, mask: options.fieldMask}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// wrapSetAssignments wraps the assignments of the text nodes nested in the conditions of a SET clause,
+// like the ones in <if> and <choose>, with newSetAssignmentNodes.
+func wrapSetAssignments(node Node, options setAssignmentOptions) Node {
+	switch node := node.(type) {
+	case *TextNode:
+		return newSetAssignmentNodes(node.value, options)
+	case NodeGroup:
+		for i, child := range node {
+			node[i] = wrapSetAssignments(child, options)
+		}
+	case *ConditionNode:
+		node.Nodes = wrapSetAssignments(node.Nodes, options).(NodeGroup)
+	case *ChooseNode:
+		for i, when := range node.WhenNodes {
+			node.WhenNodes[i] = wrapSetAssignments(when, options)
+		}
+		if node.OtherwiseNode != nil {
+			node.OtherwiseNode = wrapSetAssignments(node.OtherwiseNode, options)
+		}
+	case *OtherwiseNode:
+		node.Nodes = wrapSetAssignments(node.Nodes, options).(NodeGroup)
+	}
+	return node
+}
+
+// splitAssignments splits the text by the commas which are not in the parentheses or quotes.
+func splitAssignments(text string) []string {
+	var (
+		assignments []string
+		depth       int
+		quote       rune
+		start       int
+	)
+	appendAssignment := func(assignment string) {
+		if assignment = strings.TrimSpace(assignment); assignment != "" {
+			assignments = append(assignments, assignment)
+		}
+	}
+	for index, char := range text {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+		case char == '(':
+			depth++
+		case char == ')':
+			depth--
+		case char == ',' && depth == 0:
+			appendAssignment(text[start:index])
+			start = index + 1
+		}
+	}
+	appendAssignment(text[start:])
+	return assignments
+}
 
 // SQLNode represents a complete SQL statement with its metadata and child nodes.
 // It serves as the root node for a single SQL operation (SELECT, INSERT, UPDATE, DELETE)
@@ -823,6 +1148,10 @@ var _ Node = (*SetNode)(nil)
 type SQLNode struct {
 	id    string    // Unique identifier for the SQL statement
 	nodes NodeGroup // Child nodes forming the SQL statement
+
+	// hash and includes are the content hash and the include nodes of the fragment, see hashIncludes.
+	hash     string
+	includes []*IncludeNode
 }
 
 // ID returns the id of the node.
@@ -876,10 +1205,27 @@ var _ Node = (*SQLNode)(nil)
 // Note: The refId must reference an existing SQL fragment defined with
 // the <sql> tag. The reference can be within the same mapper or from
 // another mapper if properly configured.
+//
+// The fragment can be parameterized by the properties of the include, which are
+// substituted for the ${} of the fragment and shadow the parameters of the same names.
+// The values of the properties can reference the properties of the enclosing include:
+//
+//	<sql id="columns">${alias}.id, ${alias}.name</sql>
+//
+//	<include refid="common.columns">
+//	  <property name="alias" value="u"/>
+//	</include>
 type IncludeNode struct {
-	sqlNode Node
-	mapper  *Mapper
-	refId   string
+	sqlNode    Node
+	mapper     *Mapper
+	refId      string
+	properties []IncludeProperty
+}
+
+// IncludeProperty is a property of the IncludeNode.
+type IncludeProperty struct {
+	Name  string
+	Value string
 }
 
 // Accept accepts parameters and returns query and arguments.
@@ -893,7 +1239,31 @@ func (i *IncludeNode) Accept(translator driver.Translator, p Parameter) (query s
 		}
 		i.sqlNode = sqlNode
 	}
-	return i.sqlNode.Accept(translator, p)
+	if len(i.properties) == 0 {
+		return i.sqlNode.Accept(translator, p)
+	}
+	scope, err := i.scope(p)
+	if err != nil {
+		return "", nil, err
+	}
+	return i.sqlNode.Accept(translator, scope)
+}
+
+// scope returns the parameter of the fragment, where the properties shadow the parameters.
+func (i *IncludeNode) scope(p Parameter) (Parameter, error) {
+	properties := make(eval.H, len(i.properties))
+	for _, property := range i.properties {
+		value := property.Value
+		if textSubstitution := textSubstitutionRegexp.FindAllStringSubmatch(value, -1); len(textSubstitution) > 0 {
+			var err error
+			text := &TextNode{value: value, textSubstitution: textSubstitution}
+			if value, err = text.replaceTextSubstitution(value, p); err != nil {
+				return nil, fmt.Errorf("property %s of include %s: %w", property.Name, i.refId, err)
+			}
+		}
+		properties[property.Name] = value
+	}
+	return eval.ParamGroup{eval.NewGenericParam(properties, ""), p}, nil
 }
 
 var _ Node = (*IncludeNode)(nil)
@@ -949,13 +1319,27 @@ type ChooseNode struct {
 }
 
 // Accept accepts parameters and returns query and arguments.
+//
+// The when conditions are evaluated lazily in order, the ones after the first matched
+// are never evaluated. A matched when node wins even if its content renders nothing,
+// like a when node whose nested if nodes are all false.
 func (c ChooseNode) Accept(translator driver.Translator, p Parameter) (query string, args []any, err error) {
 	for _, node := range c.WhenNodes {
+		if when, ok := node.(*WhenNode); ok {
+			matched, err := when.Match(p)
+			if err != nil {
+				return "", nil, err
+			}
+			if matched {
+				return when.Nodes.Accept(translator, p)
+			}
+			continue
+		}
 		q, a, err := node.Accept(translator, p)
 		if err != nil {
 			return "", nil, err
 		}
-		// if one of when nodes is true, return query and arguments
+		// the custom when nodes are matched by their non-empty results
 		if len(q) > 0 {
 			return q, a, nil
 		}
@@ -1062,6 +1446,9 @@ var _ Node = (*OtherwiseNode)(nil)
 type valueItem struct {
 	column string
 	value  string
+
+	// quote reports whether the column should be quoted by the dialect of the translator.
+	quote bool
 }
 
 // ValuesNode is a node of values.
@@ -1076,7 +1463,7 @@ func (v ValuesNode) Accept(translator driver.Translator, param Parameter) (query
 	builder := getStringBuilder()
 	defer putStringBuilder(builder)
 	builder.WriteString("(")
-	builder.WriteString(v.columns())
+	builder.WriteString(v.columns(translator))
 	builder.WriteString(") VALUES (")
 	builder.WriteString(v.values())
 	builder.WriteString(")")
@@ -1085,10 +1472,14 @@ func (v ValuesNode) Accept(translator driver.Translator, param Parameter) (query
 }
 
 // columns returns columns of values.
-func (v ValuesNode) columns() string {
+func (v ValuesNode) columns(translator driver.Translator) string {
 	columns := make([]string, 0, len(v))
 	for _, item := range v {
-		columns = append(columns, item.column)
+		column := item.column
+		if item.quote {
+			column = driver.QuoteIdentifier(translator, column)
+		}
+		columns = append(columns, column)
 	}
 	return strings.Join(columns, ", ")
 }
@@ -1106,21 +1497,28 @@ func (v ValuesNode) values() string {
 type selectFieldAliasItem struct {
 	column string
 	alias  string
+
+	// quote reports whether the column and the alias should be quoted by the dialect of the translator.
+	quote bool
 }
 
 // SelectFieldAliasNode is a node of select field alias.
 type SelectFieldAliasNode []*selectFieldAliasItem
 
 // Accept accepts parameters and returns query and arguments.
-func (s SelectFieldAliasNode) Accept(_ driver.Translator, _ Parameter) (query string, args []any, err error) {
+func (s SelectFieldAliasNode) Accept(translator driver.Translator, _ Parameter) (query string, args []any, err error) {
 	if len(s) == 0 {
 		return "", nil, nil
 	}
 	fields := make([]string, 0, len(s))
 	for _, item := range s {
-		field := item.column
+		field, alias := item.column, item.alias
+		if item.quote {
+			field = driver.QuoteIdentifier(translator, field)
+			alias = driver.QuoteIdentifier(translator, alias)
+		}
 		if item.alias != "" && item.alias != item.column {
-			field = field + " AS " + item.alias
+			field = field + " AS " + alias
 		}
 		fields = append(fields, field)
 	}
@@ -17,9 +17,17 @@ limitations under the License.
 package juice
 
 import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/eval"
 )
 
 func TestForeachNode_Accept(t *testing.T) {
@@ -54,6 +62,79 @@ func TestForeachNode_Accept(t *testing.T) {
 	}
 }
 
+func TestForeachNode_Nested(t *testing.T) {
+	drv := driver.MySQLDriver{}
+	inner := &ForeachNode{
+		Nodes:         []Node{NewTextNode("#{i}-#{column}=#{value}")},
+		Item:          "value",
+		Index:         "column",
+		Collection:    "row.values",
+		Separator:     ",",
+		Deterministic: true,
+	}
+	node := ForeachNode{
+		Nodes:      []Node{inner},
+		Item:       "row",
+		Index:      "i",
+		Collection: "rows",
+		Open:       "(",
+		Separator:  "),(",
+		Close:      ")",
+	}
+	params := H{"rows": []H{
+		{"values": map[string]int{"b": 2, "a": 1}},
+		{"values": map[string]int{"c": 3}},
+	}}
+	query, args, err := node.Accept(drv.Translator(), params.AsParam())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "(?-?=?,?-?=?),(?-?=?)" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+	if want := []any{0, "a", 1, 0, "b", 2, 1, "c", 3}; !reflect.DeepEqual(args, want) {
+		t.Fatalf("unexpected args: %v", args)
+	}
+
+	// the nested variables must not shadow the outer ones.
+	inner.Index = "i"
+	if _, _, err = node.Accept(drv.Translator(), params.AsParam()); err == nil {
+		t.Fatal("expected error of the shadowed index")
+	}
+}
+
+func TestForeachNode_Channel(t *testing.T) {
+	drv := driver.MySQLDriver{}
+	node := ForeachNode{
+		Nodes:      []Node{NewTextNode("#{id}")},
+		Item:       "id",
+		Index:      "i",
+		Collection: "ids",
+		Open:       "(",
+		Separator:  ",",
+		Close:      ")",
+	}
+	ids := make(chan int, 3)
+	ids <- 1
+	ids <- 2
+	close(ids)
+	query, args, err := node.Accept(drv.Translator(), H{"ids": ids}.AsParam())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "(?,?)" || !reflect.DeepEqual(args, []any{1, 2}) {
+		t.Fatalf("unexpected query: %s %v", query, args)
+	}
+	empty := make(chan int)
+	close(empty)
+	if query, _, err = node.Accept(drv.Translator(), H{"ids": empty}.AsParam()); err != nil || query != "" {
+		t.Fatalf("unexpected query of the empty channel: %q %v", query, err)
+	}
+	if _, _, err = node.Accept(drv.Translator(), H{"ids": (chan<- int)(make(chan int))}.AsParam()); err == nil {
+		t.Fatal("expected error of the send-only channel")
+	}
+}
+
 func TestForeachMapNode_Accept(t *testing.T) {
 	drv := driver.MySQLDriver{}
 	textNode := NewTextNode("(#{item}, #{index})")
@@ -99,6 +180,31 @@ func TestForeachMapNode_Accept(t *testing.T) {
 	}
 }
 
+func TestForeachMapNode_Deterministic(t *testing.T) {
+	drv := driver.MySQLDriver{}
+	node := ForeachNode{
+		Nodes:         []Node{NewTextNode("#{index} = #{item}")},
+		Item:          "item",
+		Index:         "index",
+		Collection:    "map",
+		Separator:     ", ",
+		Deterministic: true,
+	}
+	params := H{"map": map[string]any{"c": 3, "a": 1, "b": 2, "d": 4}}
+	for i := 0; i < 10; i++ {
+		query, args, err := node.Accept(drv.Translator(), params.AsParam())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if query != "? = ?, ? = ?, ? = ?, ? = ?" {
+			t.Fatalf("unexpected query: %s", query)
+		}
+		if !reflect.DeepEqual(args, []any{"a", 1, "b", 2, "c", 3, "d", 4}) {
+			t.Fatalf("unexpected args: %v", args)
+		}
+	}
+}
+
 func TestIfNode_Accept(t *testing.T) {
 	drv := driver.MySQLDriver{}
 	node1 := NewTextNode("select * from user where id = #{id}")
@@ -155,6 +261,55 @@ func TestTextNode_Accept(t *testing.T) {
 	}
 }
 
+func TestTextNode_AcceptDefault(t *testing.T) {
+	drv := driver.MySQLDriver{}
+	node := NewTextNode("select * from ${table ?? 'user'} where name = #{name, default='anonymous'} and age > #{age ?? 18} and nick = #{nick ?? name}")
+	var nick *string
+	query, args, err := node.Accept(drv.Translator(), newGenericParam(H{"age": nil, "nick": nick, "name": "juice"}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "select * from user where name = ? and age > ? and nick = ?" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+	if len(args) != 3 || args[0] != "juice" || args[1] != int64(18) || args[2] != "juice" {
+		t.Fatalf("unexpected args: %v", args)
+	}
+	query, args, err = node.Accept(drv.Translator(), newGenericParam(H{"table": "admin", "age": 20, "nick": "j"}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "select * from admin where name = ? and age > ? and nick = ?" || args[0] != "anonymous" || args[1] != 20 || args[2] != "j" {
+		t.Fatalf("unexpected query: %s %v", query, args)
+	}
+	// the parameters without the default are still required.
+	if _, _, err = NewTextNode("#{id}").Accept(drv.Translator(), newGenericParam(H{}, "")); err == nil {
+		t.Fatal("expected error for the missing parameter")
+	}
+}
+
+func TestTextNode_AcceptConditionalSubstitution(t *testing.T) {
+	drv := driver.MySQLDriver{}
+	node := NewTextNode("select * from user order by ${sort ?: 'id'} ${asc ? 'ASC' : 'DESC'}, ${table} limit #{limit}")
+	query, args, err := node.Accept(drv.Translator(), newGenericParam(H{"asc": false, "sort": "", "table": "t", "limit": 1}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "select * from user order by id DESC, t limit ?" || len(args) != 1 {
+		t.Fatalf("unexpected query: %s %v", query, args)
+	}
+	query, _, err = node.Accept(drv.Translator(), newGenericParam(H{"asc": true, "sort": "name", "table": "t", "limit": 1}, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "select * from user order by name ASC, t limit ?" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+	if _, _, err = node.Accept(drv.Translator(), newGenericParam(H{"asc": 1, "table": "t", "limit": 1}, "")); err == nil {
+		t.Fatal("expected error for the non-bool condition")
+	}
+}
+
 func TestWhereNode_Accept(t *testing.T) {
 	drv := driver.MySQLDriver{}
 	node1 := NewTextNode("AND id = #{id}")
@@ -238,6 +393,72 @@ func TestTrimNode_Accept(t *testing.T) {
 
 }
 
+func TestTrimNode_Overrides(t *testing.T) {
+	drv := driver.MySQLDriver{}
+	params := H{"id": 1, "name": "a"}.AsParam()
+	for _, tc := range []struct {
+		name string
+		node Node
+		want string
+	}{
+		{
+			name: "multi-token prefix",
+			node: &TrimNode{Nodes: []Node{NewTextNode("or id = #{id}")}, Prefix: "WHERE", PrefixOverrides: parseOverrides("AND |OR ")},
+			want: "WHERE id = ?",
+		},
+		{
+			name: "whole word only",
+			node: &TrimNode{Nodes: []Node{NewTextNode("ORDER BY id")}, Prefix: "WHERE", PrefixOverrides: parseOverrides("AND |OR ")},
+			want: "WHERE ORDER BY id",
+		},
+		{
+			name: "suffix",
+			node: &TrimNode{Nodes: []Node{NewTextNode("id = #{id} AND\n")}, Prefix: "WHERE", SuffixOverrides: parseOverrides("AND|OR")},
+			want: "WHERE id = ?",
+		},
+		{
+			name: "overridden to empty",
+			node: &TrimNode{Nodes: []Node{NewTextNode(",")}, Prefix: "(", Suffix: ")", SuffixOverrides: []string{","}},
+			want: "",
+		},
+		{
+			name: "where trailing separator",
+			node: &WhereNode{Nodes: []Node{NewTextNode("and id = #{id} Or")}},
+			want: "WHERE id = ?",
+		},
+		{
+			name: "where custom overrides",
+			node: &WhereNode{Nodes: []Node{NewTextNode("XOR id = #{id}")}, PrefixOverrides: []string{"XOR"}},
+			want: "WHERE id = ?",
+		},
+		{
+			name: "where keyword",
+			node: &WhereNode{Nodes: []Node{NewTextNode("where AND id = #{id}")}},
+			want: "WHERE id = ?",
+		},
+		{
+			name: "set leading comma",
+			node: &SetNode{Nodes: []Node{NewTextNode(", name = #{name},")}},
+			want: "SET name = ?",
+		},
+		{
+			name: "set custom overrides",
+			node: &SetNode{Nodes: []Node{NewTextNode("name = #{name};")}, SuffixOverrides: []string{";"}},
+			want: "SET name = ?",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			query, _, err := tc.node.Accept(drv.Translator(), params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if query != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, query)
+			}
+		})
+	}
+}
+
 func TestSetNode_Accept(t *testing.T) {
 	drv := driver.MySQLDriver{}
 	node1 := NewTextNode("id = #{id},")
@@ -269,3 +490,298 @@ func TestSetNode_Accept(t *testing.T) {
 		return
 	}
 }
+
+func TestSetNode_IgnoreZero(t *testing.T) {
+	drv := driver.MySQLDriver{}
+	node := SetNode{
+		Nodes: newSetAssignmentNodes("name = #{name}, age = #{age}, score = coalesce(#{score}, 0), status = #{status}", setAssignmentOptions{
+			ignoreZero:  true,
+			includeZero: []string{"status"},
+		}),
+	}
+	params := H{
+		"name":   "a",
+		"age":    0,
+		"score":  nil,
+		"status": 0,
+	}
+	query, args, err := node.Accept(drv.Translator(), newGenericParam(params, ""))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if query != "SET name = ?, status = ?" {
+		t.Errorf("query error: %s", query)
+		return
+	}
+	if len(args) != 2 {
+		t.Error("args error")
+		return
+	}
+	if args[0] != "a" || args[1] != 0 {
+		t.Error("args error")
+		return
+	}
+}
+
+func TestSetNode_IgnoreZeroNested(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <update id="Update">
+        update user
+        <set ignoreZero="true">
+            name = #{name},
+            <if test="admin">role = #{role}, level = #{level},</if>
+            <choose>
+                <when test="verified">email = #{email},</when>
+                <otherwise>phone = #{phone}</otherwise>
+            </choose>
+        </set>
+        where id = #{id}
+    </update>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	translator := driver.MySQLDriver{}.Translator()
+	params := H{"id": 1, "name": "a", "admin": true, "role": "", "level": 2, "verified": true, "email": "", "phone": "1"}
+	query, args, err := m.statements["Update"].Build(translator, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the zero assignments in the conditions are skipped as well.
+	if query != "update user SET name = ?, level = ? where id = ?" || len(args) != 3 {
+		t.Fatalf("unexpected query: %s %v", query, args)
+	}
+
+	// the missing parameter is an error instead of a zero value.
+	delete(params, "level")
+	if _, _, err = m.statements["Update"].Build(translator, params); err == nil || !strings.Contains(err.Error(), "parameter level not found") {
+		t.Fatalf("expected the missing parameter error, got %v", err)
+	}
+	delete(params, "name")
+	params["admin"] = false
+	if _, _, err = m.statements["Update"].Build(translator, params); err == nil || !strings.Contains(err.Error(), "parameter name not found") {
+		t.Fatalf("expected the missing parameter error, got %v", err)
+	}
+}
+
+func TestSetNode_FieldMask(t *testing.T) {
+	drv := driver.MySQLDriver{}
+	node := SetNode{
+		Nodes: newSetAssignmentNodes("name = #{user.name}, age = #{user.age}, email = #{user.email}", setAssignmentOptions{
+			fieldMask: "mask",
+		}),
+	}
+	params := H{
+		"user": H{"name": "a", "age": 18, "email": ""},
+		"mask": map[string]any{"name": "a", "email": nil},
+	}
+	query, args, err := node.Accept(drv.Translator(), newGenericParam(params, ""))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if query != "SET name = ?, email = ?" {
+		t.Errorf("query error: %s", query)
+		return
+	}
+	if len(args) != 2 {
+		t.Error("args error")
+		return
+	}
+}
+
+func TestValuesNode_QuoteIdentifier(t *testing.T) {
+	node := ValuesNode{
+		{column: "order", value: "#{order}", quote: true},
+		{column: "user.name", value: "#{name}", quote: true},
+	}
+	params := H{"order": 1, "name": "a"}
+	query, args, err := node.Accept(driver.MySQLDriver{}.Translator(), params.AsParam())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if query != "(`order`, `user`.`name`) VALUES (?, ?)" {
+		t.Errorf("query error: %s", query)
+		return
+	}
+	if len(args) != 2 {
+		t.Error("args error")
+		return
+	}
+	query, _, err = node.Accept(driver.PostgresDriver{}.Translator(), params.AsParam())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if query != `("order", "user"."name") VALUES ($1, $2)` {
+		t.Errorf("query error: %s", query)
+		return
+	}
+}
+
+func TestSelectFieldAliasNode_QuoteIdentifier(t *testing.T) {
+	node := SelectFieldAliasNode{
+		{column: "user.order", alias: "order", quote: true},
+		{column: "name", alias: "name", quote: true},
+		{column: "created_at", alias: "created"},
+	}
+	query, _, err := node.Accept(driver.MySQLDriver{}.Translator(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "`user`.`order` AS `order`, `name`, created_at AS created" {
+		t.Errorf("query error: %s", query)
+	}
+	query, _, err = node.Accept(driver.PostgresDriver{}.Translator(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != `"user"."order" AS "order", "name", created_at AS created` {
+		t.Errorf("query error: %s", query)
+	}
+}
+
+func TestTextNode_NamedPlaceholder(t *testing.T) {
+	translator := driver.NewNamedTranslator("@", nil)
+	node := ForeachNode{
+		Nodes:      []Node{NewTextNode("(#{item}, #{owner})")},
+		Item:       "item",
+		Collection: "list",
+		Separator:  ", ",
+	}
+	params := H{"list": []int{1, 2}, "owner": sql.Named("uid", 3)}
+	query, args, err := node.Accept(translator, params.AsParam())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "(@item, @uid), (@item_2, @uid)" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+	expected := []any{sql.Named("item", 1), sql.Named("uid", 3), sql.Named("item_2", 2)}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("unexpected args: %v", args)
+	}
+}
+
+func TestTextNode_NamedPlaceholderRepeated(t *testing.T) {
+	translator := driver.NewNamedTranslator("@", nil)
+	node := NewTextNode("id = #{id} OR parent_id = #{id} OR tags = #{tags} OR tags = #{tags}")
+	params := H{"id": 1, "tags": []string{"a"}}
+	query, args, err := node.Accept(translator, params.AsParam())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "id = @id OR parent_id = @id OR tags = @tags OR tags = @tags_2" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+	expected := []any{sql.Named("id", 1), sql.Named("tags", []string{"a"}), sql.Named("tags_2", []string{"a"})}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("unexpected args: %v", args)
+	}
+}
+
+func TestTextNode_Redact(t *testing.T) {
+	type user struct {
+		Name     string `param:"name"`
+		Password string `param:"password,redact"`
+		Token    string `param:"token"`
+	}
+	drv := driver.MySQLDriver{}
+	node := NewTextNode("#{name}, #{password}, #{token}")
+	param := withRedactAttribute(newGenericParam(user{Name: "eat", Password: "secret", Token: "abc"}, ""), "token")
+	query, args, err := node.Accept(drv.Translator(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "?, ?, ?" {
+		t.Fatalf("unexpected query: %s", query)
+	}
+	if printed := fmt.Sprintf("%v", args); printed != "[eat *** ***]" {
+		t.Fatalf("unexpected printed args: %s", printed)
+	}
+	value, err := args[1].(sqldriver.Valuer).Value()
+	if err != nil || value != "secret" {
+		t.Fatalf("unexpected value: %v, %v", value, err)
+	}
+}
+
+// maskRecorder records the arguments masked by MaskArgs.
+type maskRecorder struct{ masked []any }
+
+func (m *maskRecorder) QueryContext(_ Statement, next QueryHandler) QueryHandler { return next }
+
+func (m *maskRecorder) ExecContext(_ Statement, next ExecHandler) ExecHandler {
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		m.masked = MaskArgs(ctx, args)
+		return next(ctx, query, args...)
+	}
+}
+
+func TestCompiledStatementHandler_Redact(t *testing.T) {
+	type user struct {
+		Name     string    `param:"name"`
+		Password []byte    `param:"password,redact"`
+		Birthday time.Time `param:"birthday,redact"`
+	}
+	birthday := time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC)
+	param := user{Name: "eat", Password: []byte("secret"), Birthday: birthday}
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Update, name: "main.User.Update",
+		Nodes: NodeGroup{NewTextNode("update user set password = #{password}, birthday = #{birthday} where name = #{name}")}}
+	query, args, err := buildStatement(context.Background(), stmt, driver.MySQLDriver{}.Translator(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received []any
+	recorder := &maskRecorder{}
+	handler := CompiledStatementHandler{
+		query:       query,
+		args:        args,
+		middlewares: MiddlewareGroup{recorder},
+		driver:      driver.MySQLDriver{},
+		execHandler: func(_ context.Context, _ string, args ...any) (sql.Result, error) {
+			received = args
+			return sqldriver.RowsAffected(1), nil
+		},
+	}
+	if _, err = handler.ExecContext(context.Background(), stmt, param); err != nil {
+		t.Fatal(err)
+	}
+	// the driver receives the values as they are, while the logs mask them.
+	if !reflect.DeepEqual(received, []any{[]byte("secret"), birthday, "eat"}) {
+		t.Errorf("unexpected args: %v", received)
+	}
+	if !reflect.DeepEqual(recorder.masked, []any{redactedMask, redactedMask, "eat"}) {
+		t.Errorf("unexpected masked args: %v", recorder.masked)
+	}
+	if _, args, err = stmt.Build(driver.MySQLDriver{}.Translator(), param); err != nil || !reflect.DeepEqual(args, received) {
+		t.Errorf("unexpected built args: %v, %v", args, err)
+	}
+}
+
+func BenchmarkForeachNode(b *testing.B) {
+	items := make([]map[string]any, 10000)
+	for i := range items {
+		items[i] = map[string]any{"id": i + 1, "name": "n"}
+	}
+	cond := &ConditionNode{Nodes: NodeGroup{NewTextNode("(#{item.id}, #{item.name})")}}
+	if err := cond.Parse("item.id > 0"); err != nil {
+		b.Fatal(err)
+	}
+	node := ForeachNode{Collection: "items", Item: "item", Separator: ",", Nodes: []Node{
+		cond,
+	}}
+	param := eval.NewGenericParam(eval.H{"items": items}, "")
+	translator := driver.MySQLDriver{}.Translator()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := node.Accept(translator, param); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// nullValueOption is the option of the column tag which declares the NULL sentinel of the field.
+const nullValueOption = "nullValue="
+
+// nullSentinels are the named NULL sentinels, see RegisterNullSentinel.
+var nullSentinels sync.Map
+
+// RegisterNullSentinel registers the named NULL sentinel, which is referenced by the column tags
+// with an @ prefix, for the sentinels which can not be written as the text, like the zero time of a legacy schema.
+//
+//	juice.RegisterNullSentinel("epoch", time.Unix(0, 0).UTC())
+//
+//	type User struct {
+//		DeletedAt time.Time `column:"deleted_at,nullValue=@epoch"`
+//	}
+func RegisterNullSentinel(name string, value any) {
+	nullSentinels.Store(name, value)
+}
+
+// parseColumnTag returns the column name of the column tag, and the NULL sentinel declared by the
+// nullValue option, like `column:"age,nullValue=-1"`.
+func parseColumnTag(tag string) (column string, nullValue string, hasNullValue bool) {
+	column, options, _ := strings.Cut(tag, ",")
+	for options != "" {
+		var option string
+		option, options, _ = strings.Cut(options, ",")
+		if value, ok := strings.CutPrefix(strings.TrimSpace(option), nullValueOption); ok {
+			return column, value, true
+		}
+	}
+	return column, "", false
+}
+
+// parseNullSentinel returns the NULL sentinel of the type from the text of the nullValue option.
+// The text with an @ prefix is the name of a sentinel registered by RegisterNullSentinel.
+func parseNullSentinel(typ reflect.Type, text string) (reflect.Value, error) {
+	if name, ok := strings.CutPrefix(text, "@"); ok {
+		value, exists := nullSentinels.Load(name)
+		if !exists {
+			return reflect.Value{}, fmt.Errorf("null sentinel %s is not registered", name)
+		}
+		sentinel := reflect.ValueOf(value)
+		if !sentinel.Type().ConvertibleTo(typ) {
+			return reflect.Value{}, fmt.Errorf("null sentinel %s of %s can not be set to %s", name, sentinel.Type(), typ)
+		}
+		return sentinel.Convert(typ), nil
+	}
+	sentinel := reflect.New(typ).Elem()
+	if err := assignText(sentinel, text); err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid null sentinel %q of %s: %w", text, typ, err)
+	}
+	return sentinel, nil
+}
+
+// nullSentinelScanner scans the column into the field, the sentinel is set for NULL.
+type nullSentinelScanner struct {
+	field    reflect.Value
+	sentinel reflect.Value
+}
+
+// Scan implements sql.Scanner.
+func (s *nullSentinelScanner) Scan(src any) error {
+	if src == nil {
+		s.field.Set(s.sentinel)
+		return nil
+	}
+	if scanner, ok := s.field.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+	return assignDriverValue(s.field, src)
+}
+
+// assignDriverValue sets the value scanned from the database to the field, like database/sql does.
+func assignDriverValue(field reflect.Value, src any) error {
+	switch src := src.(type) {
+	case []byte:
+		if field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Uint8 {
+			field.SetBytes(append([]byte(nil), src...))
+			return nil
+		}
+		return assignText(field, string(src))
+	case string:
+		return assignText(field, src)
+	}
+	value := reflect.ValueOf(src)
+	switch {
+	case value.Type().AssignableTo(field.Type()):
+		field.Set(value)
+	case value.Type().ConvertibleTo(field.Type()) && (isNumberKind(value.Kind()) && isNumberKind(field.Kind()) || value.Kind() == field.Kind()):
+		field.Set(value.Convert(field.Type()))
+	case field.Kind() == reflect.String:
+		field.SetString(fmt.Sprint(src))
+	default:
+		return fmt.Errorf("unsupported scan, storing %T into %s", src, field.Type())
+	}
+	return nil
+}
+
+// assignText parses the text into the field by its kind.
+func assignText(field reflect.Value, text string) error {
+	if field.Type() == timeType {
+		value, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(value))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Bool:
+		value, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		field.SetBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value, err := strconv.ParseInt(text, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value, err := strconv.ParseUint(text, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(value)
+	case reflect.Float32, reflect.Float64:
+		value, err := strconv.ParseFloat(text, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(value)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		field.SetBytes([]byte(text))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// isNumberKind reports whether the kind is an integer or a float.
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+// ensure OutputParamsMiddleware implements Middleware.
+var _ Middleware = (*OutputParamsMiddleware)(nil) // compile time check
+
+// OutputParamsMiddleware is a middleware that binds the output parameters of the stored procedures
+// back to the fields of the parameter, which are declared by the outputParams attribute of the statement.
+//
+//	<update id="CountOrders" outputParams="total">
+//	    EXEC count_orders @user_id = #{userID}, @total = #{total} OUTPUT
+//	</update>
+//
+// The arguments of the output parameters are passed as sql.Named with sql.Out,
+// whose destinations are the fields of the parameter, so the parameter must be a pointer to struct or a map of pointers.
+// It requires the named placeholders, which are provided by driver.WithNamedPlaceholders.
+type OutputParamsMiddleware struct{}
+
+// QueryContext implements Middleware.
+func (m *OutputParamsMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryHandler {
+	names := outputParams(stmt)
+	if len(names) == 0 {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		if err := bindOutputParams(ctx, stmt, names, args); err != nil {
+			return nil, err
+		}
+		return next(ctx, query, args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *OutputParamsMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHandler {
+	names := outputParams(stmt)
+	if len(names) == 0 {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		if err := bindOutputParams(ctx, stmt, names, args); err != nil {
+			return nil, err
+		}
+		return next(ctx, query, args...)
+	}
+}
+
+// outputParams returns the names of the output parameters of the statement.
+func outputParams(stmt Statement) []string {
+	var names []string
+	for _, name := range strings.Split(stmt.Attribute("outputParams"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// bindOutputParams replaces the named arguments of the output parameters with sql.Out,
+// whose destinations are the values of the parameter.
+func bindOutputParams(ctx context.Context, stmt Statement, names []string, args []any) error {
+	parameter := newGenericParam(ParamFromContext(ctx), stmt.Attribute("paramName"))
+	for _, name := range names {
+		value, exists := parameter.Get(name)
+		if !exists {
+			return fmt.Errorf("output parameter %s not found", name)
+		}
+		for value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+		var dest any
+		switch {
+		case value.CanAddr():
+			dest = value.Addr().Interface()
+		case value.Kind() == reflect.Pointer && !value.IsNil():
+			dest = value.Interface()
+		default:
+			return fmt.Errorf("output parameter %s can not be set, a pointer is required", name)
+		}
+		argName := driver.NamedArgName(name)
+		index := -1
+		for i, arg := range args {
+			if named, ok := arg.(sql.NamedArg); ok && named.Name == argName {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return fmt.Errorf("output parameter %s requires the named placeholders of the driver", name)
+		}
+		args[index] = sql.Named(argName, sql.Out{Dest: dest})
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// parseTestStatement returns the statement of the given id parsed from the mapper.
+func parseTestStatement(t *testing.T, mapper, id string) Statement {
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("test.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	statement, ok := m.statements[id]
+	if !ok {
+		t.Fatalf("statement %s not found", id)
+	}
+	return statement
+}
+
+func TestOutputParamsMiddleware(t *testing.T) {
+	statement := parseTestStatement(t, `<mapper namespace="order">
+    <update id="CountOrders" outputParams="total, status">
+        EXEC count_orders @user_id = #{userID}, @total = #{total} OUTPUT, @status = #{status} OUTPUT
+    </update>
+</mapper>`, "CountOrders")
+	type countOrders struct {
+		UserID int64  `param:"userID"`
+		Total  int64  `param:"total"`
+		Status string `param:"status"`
+	}
+	param := &countOrders{UserID: 1}
+	ctx := CtxWithParam(context.Background(), param)
+	args := []any{sql.Named("userID", int64(1)), sql.Named("total", int64(0)), sql.Named("status", "")}
+
+	next := func(_ context.Context, _ string, args ...any) (sql.Result, error) {
+		// the driver writes the output parameters into the destinations.
+		for _, arg := range args {
+			named := arg.(sql.NamedArg)
+			switch out := named.Value.(type) {
+			case sql.Out:
+				switch dest := out.Dest.(type) {
+				case *int64:
+					*dest = 42
+				case *string:
+					*dest = "done"
+				}
+			default:
+				if named.Name != "userID" {
+					t.Errorf("expected %s to be an output parameter", named.Name)
+				}
+			}
+		}
+		return nil, nil
+	}
+	handler := (&OutputParamsMiddleware{}).ExecContext(statement, next)
+	if _, err := handler(ctx, "", args...); err != nil {
+		t.Fatal(err)
+	}
+	if param.Total != 42 || param.Status != "done" {
+		t.Fatalf("expected the output parameters to be bound, got %+v", param)
+	}
+
+	// the map of pointers is supported as well.
+	var total int64
+	var status string
+	ctx = CtxWithParam(context.Background(), H{"userID": int64(1), "total": &total, "status": &status})
+	args = []any{sql.Named("userID", int64(1)), sql.Named("total", int64(0)), sql.Named("status", "")}
+	if _, err := handler(ctx, "", args...); err != nil {
+		t.Fatal(err)
+	}
+	if total != 42 || status != "done" {
+		t.Fatalf("expected the output parameters to be bound, got %d, %s", total, status)
+	}
+}
+
+func TestOutputParamsMiddleware_Error(t *testing.T) {
+	statement := parseTestStatement(t, `<mapper namespace="order">
+    <update id="CountOrders" outputParams="total">
+        EXEC count_orders @total = #{total} OUTPUT
+    </update>
+</mapper>`, "CountOrders")
+	next := func(context.Context, string, ...any) (sql.Result, error) { return nil, nil }
+	handler := (&OutputParamsMiddleware{}).ExecContext(statement, next)
+
+	cases := []struct {
+		name  string
+		param Param
+		args  []any
+		err   string
+	}{
+		{name: "not found", param: H{}, args: []any{sql.Named("total", 0)}, err: "output parameter total not found"},
+		{name: "not addressable", param: H{"total": int64(0)}, args: []any{sql.Named("total", 0)}, err: "a pointer is required"},
+		{name: "positional", param: H{"total": new(int64)}, args: []any{int64(0)}, err: "requires the named placeholders"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := handler(CtxWithParam(context.Background(), c.param), "", c.args...)
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatalf("expected error %q, got %v", c.err, err)
+			}
+		})
+	}
+
+	// the statements without output parameters are not wrapped.
+	statement = parseTestStatement(t, `<mapper namespace="order"><update id="Cancel">UPDATE orders</update></mapper>`, "Cancel")
+	called := false
+	handler = (&OutputParamsMiddleware{}).ExecContext(statement, func(context.Context, string, ...any) (sql.Result, error) {
+		called = true
+		return nil, nil
+	})
+	if _, err := handler(context.Background(), "", int64(0)); err != nil || !called {
+		t.Fatalf("expected the next handler to be called, got %v", err)
+	}
+}
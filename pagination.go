@@ -0,0 +1,156 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+// DefaultPageTotalColumn is the column of the total rows counted by COUNT(*) OVER().
+const DefaultPageTotalColumn = "juice_total"
+
+// ErrPageConflict is returned when a paginated select statement already restricts its rows.
+var ErrPageConflict = errors.New("juice: the paginated statement already has a row limit")
+
+// Page describes the page of the select statements executed with the context, see ContextWithPage.
+type Page struct {
+	// Number is the page number, which starts from 1.
+	Number int64
+	// Size is the number of the rows of the page.
+	Size int64
+	// WithTies fetches the page with FETCH FIRST n ROWS WITH TIES, so the rows which tie
+	// with the last row of the page in the order are returned too.
+	// The statement must have an ORDER BY clause, and the dialect must support it.
+	WithTies bool
+	// CountTotal adds the COUNT(*) OVER() column to the select list, so the page and the total
+	// rows are returned by a single round trip. Map the column to a field to read it, like
+	//
+	//	Total int64 `column:"juice_total"`
+	//
+	// The total is counted before DISTINCT, it is not meant for the DISTINCT or UNION statements.
+	CountTotal bool
+}
+
+// offset returns the number of the rows before the page.
+func (p Page) offset() int64 {
+	if p.Number <= 1 {
+		return 0
+	}
+	return (p.Number - 1) * p.Size
+}
+
+// pageKey is the context key of the Page.
+type pageKey struct{}
+
+// ContextWithPage returns a new context with the page, which is applied by the PaginationMiddleware
+// to the select statements executed with the context.
+//
+//	ctx = juice.ContextWithPage(ctx, juice.Page{Number: 2, Size: 20, CountTotal: true})
+//	users, err := executor.QueryContext(ctx, param)
+func ContextWithPage(ctx context.Context, page Page) context.Context {
+	return context.WithValue(ctx, pageKey{}, page)
+}
+
+// pageFromContext returns the page from the context.
+func pageFromContext(ctx context.Context) (Page, bool) {
+	page, ok := ctx.Value(pageKey{}).(Page)
+	return page, ok && page.Size > 0
+}
+
+// ensure PaginationMiddleware implements Middleware.
+var _ Middleware = (*PaginationMiddleware)(nil) // compile time check
+
+// PaginationMiddleware is a middleware that pages the select statements executed with a context
+// carrying a Page, the page clause is built by the driver, see driver.PageClause.
+type PaginationMiddleware struct {
+	// TotalColumn is the column of the total rows, DefaultPageTotalColumn is used if it is empty.
+	TotalColumn string
+}
+
+// QueryContext implements Middleware.
+func (m *PaginationMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryHandler {
+	if stmt.Action() != Select {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		page, ok := pageFromContext(ctx)
+		if !ok {
+			return next(ctx, query, args...)
+		}
+		drv, err := driver.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		query, err = m.paginate(drv, query, page)
+		if err != nil {
+			return nil, fmt.Errorf("paginate statement %s: %w", stmt.Name(), err)
+		}
+		return next(ctx, query, args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *PaginationMiddleware) ExecContext(_ Statement, next ExecHandler) ExecHandler {
+	return next
+}
+
+// paginate returns the query restricted to the page.
+func (m *PaginationMiddleware) paginate(drv driver.Driver, query string, page Page) (string, error) {
+	if hasRowLimit(query) {
+		return "", ErrPageConflict
+	}
+	clause, err := driver.PageClause(drv, driver.PageOptions{Limit: page.Size, Offset: page.offset(), WithTies: page.WithTies})
+	if err != nil {
+		return "", err
+	}
+	if page.CountTotal {
+		column := m.TotalColumn
+		if column == "" {
+			column = DefaultPageTotalColumn
+		}
+		if query, err = appendWindowCount(query, column); err != nil {
+			return "", err
+		}
+	}
+	return appendLimitClause(query, clause), nil
+}
+
+// errNoTopLevelFrom is returned when the window count can not be added to the select list.
+var errNoTopLevelFrom = errors.New("juice: can not count the total rows of the statement without a top level FROM")
+
+// appendWindowCount appends COUNT(*) OVER() to the select list of the query,
+// which is evaluated before the page clause, so it counts the rows of all the pages.
+func appendWindowCount(query, column string) (string, error) {
+	position := -1
+	walkTopLevel(query, func(index int) bool {
+		if matchToken(query, index, "FROM") {
+			position = index
+			return false
+		}
+		return true
+	})
+	if position < 0 {
+		return "", errNoTopLevelFrom
+	}
+	return strings.TrimRight(query[:position], " \t\r\n") + ", COUNT(*) OVER() AS " + column + " " + query[position:], nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+func TestPaginationMiddleware_Paginate(t *testing.T) {
+	middleware := &PaginationMiddleware{}
+	tests := []struct {
+		driver driver.Driver
+		query  string
+		page   Page
+		want   string
+	}{
+		{driver.MySQLDriver{}, "SELECT * FROM user ORDER BY id", Page{Number: 1, Size: 10}, "SELECT * FROM user ORDER BY id LIMIT 10"},
+		{driver.MySQLDriver{}, "SELECT id, (SELECT 1 FROM t) FROM user", Page{Number: 3, Size: 10, CountTotal: true}, "SELECT id, (SELECT 1 FROM t), COUNT(*) OVER() AS juice_total FROM user LIMIT 10 OFFSET 20"},
+		{driver.PostgresDriver{}, "SELECT * FROM user ORDER BY score FOR UPDATE", Page{Number: 2, Size: 5, WithTies: true}, "SELECT * FROM user ORDER BY score OFFSET 5 ROWS FETCH FIRST 5 ROWS WITH TIES FOR UPDATE"},
+		{driver.OracleDriver{}, "SELECT * FROM users ORDER BY id", Page{Number: 1, Size: 5}, "SELECT * FROM users ORDER BY id FETCH FIRST 5 ROWS ONLY"},
+	}
+	for _, tt := range tests {
+		got, err := middleware.paginate(tt.driver, tt.query, tt.page)
+		if err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expected %q, got %q", tt.want, got)
+		}
+	}
+	if _, err := middleware.paginate(driver.MySQLDriver{}, "SELECT * FROM user ORDER BY id", Page{Size: 5, WithTies: true}); !errors.Is(err, driver.ErrWithTiesNotSupported) {
+		t.Errorf("expected ErrWithTiesNotSupported, got %v", err)
+	}
+	if _, err := middleware.paginate(driver.MySQLDriver{}, "SELECT * FROM user LIMIT 1", Page{Size: 5}); !errors.Is(err, ErrPageConflict) {
+		t.Errorf("expected ErrPageConflict, got %v", err)
+	}
+	if _, err := middleware.paginate(driver.MySQLDriver{}, "SELECT 1", Page{Size: 5, CountTotal: true}); err == nil {
+		t.Error("expected error without a top level FROM")
+	}
+}
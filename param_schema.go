@@ -0,0 +1,253 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-juicedev/juice/eval"
+)
+
+// ParamSchema is the JSON schema of the parameters referenced by a statement, so that the admin
+// tools can build the parameter forms automatically. The types of the leaf parameters are unknown,
+// only the objects and the arrays iterated by the foreach nodes are typed.
+type ParamSchema struct {
+	Schema     string                  `json:"$schema,omitempty"`
+	Title      string                  `json:"title,omitempty"`
+	Type       string                  `json:"type,omitempty"`
+	Properties map[string]*ParamSchema `json:"properties,omitempty"`
+	Items      *ParamSchema            `json:"items,omitempty"`
+}
+
+// property returns the property schema with the name, which is added if it does not exist.
+func (s *ParamSchema) property(name string) *ParamSchema {
+	if s.Properties == nil {
+		s.Properties = make(map[string]*ParamSchema)
+	}
+	if s.Type == "" {
+		s.Type = "object"
+	}
+	property, ok := s.Properties[name]
+	if !ok {
+		property = &ParamSchema{}
+		s.Properties[name] = property
+	}
+	return property
+}
+
+// NewParamSchema returns the schema of the parameters referenced by the statement,
+// from its placeholders, text substitutions, test expressions and foreach collections.
+//
+//	schema, err := juice.NewParamSchema(statement)
+//	data, err := json.MarshalIndent(schema, "", "  ")
+func NewParamSchema(statement Statement) (*ParamSchema, error) {
+	xmlStatement, ok := statement.(*xmlSQLStatement)
+	if !ok {
+		return nil, fmt.Errorf("param schema: unsupported statement type %T", statement)
+	}
+	collector := &paramSchemaCollector{
+		root: &ParamSchema{
+			Schema: "https://json-schema.org/draft/2020-12/schema",
+			Title:  statement.Name(),
+			Type:   "object",
+		},
+	}
+	if err := collector.collect(xmlStatement.Nodes, nil); err != nil {
+		return nil, err
+	}
+	return collector.root, nil
+}
+
+// paramSchemaCollector collects the parameters referenced by the nodes into the schema.
+type paramSchemaCollector struct {
+	root *ParamSchema
+}
+
+// add adds the dotted parameter path to the schema.
+// The path starting with a foreach item is added to the items of its collection.
+func (c *paramSchemaCollector) add(path string, scope map[string]*ParamSchema) *ParamSchema {
+	parts := strings.Split(path, ".")
+	schema, ok := scope[parts[0]]
+	if ok {
+		parts = parts[1:]
+	} else {
+		schema = c.root
+	}
+	for _, part := range parts {
+		schema = schema.property(part)
+	}
+	return schema
+}
+
+// addPlaceholders adds the parameters of the #{} and ${} of the text node.
+func (c *paramSchemaCollector) addPlaceholders(node *TextNode, scope map[string]*ParamSchema) {
+	for _, placeholders := range [][][]string{node.placeholder, node.textSubstitution} {
+		for _, placeholder := range placeholders {
+			// the conditional expression of ${} references its identifiers.
+			if len(placeholder) >= 4 && placeholder[3] != "" {
+				if expression, err := eval.Compile(placeholder[3]); err == nil {
+					for _, name := range eval.Identifiers(expression) {
+						c.add(name, scope)
+					}
+				}
+				continue
+			}
+			if len(placeholder) >= 2 {
+				c.add(strings.TrimSpace(placeholder[1]), scope)
+			}
+		}
+	}
+}
+
+// collect collects the parameters referenced by the node.
+func (c *paramSchemaCollector) collect(node Node, scope map[string]*ParamSchema) error {
+	switch node := node.(type) {
+	case NodeGroup:
+		return c.collectGroup(node, scope)
+	case *TextNode:
+		c.addPlaceholders(node, scope)
+	case ignoreZeroNode:
+		c.addPlaceholders(node.node, scope)
+	case fieldMaskNode:
+		c.add(node.mask, scope)
+		return c.collect(node.node, scope)
+	case ValuesNode:
+		for _, item := range node {
+			if err := c.collect(NewTextNode(item.value), scope); err != nil {
+				return err
+			}
+		}
+	case *ConditionNode:
+		for _, name := range eval.Identifiers(node.expr) {
+			c.add(name, scope)
+		}
+		return c.collectGroup(node.Nodes, scope)
+	case *ChooseNode:
+		return c.collectChoose(*node, scope)
+	case ChooseNode:
+		return c.collectChoose(node, scope)
+	case *WhereNode:
+		return c.collectGroup(node.Nodes, scope)
+	case WhereNode:
+		return c.collectGroup(node.Nodes, scope)
+	case *SetNode:
+		return c.collectGroup(node.Nodes, scope)
+	case SetNode:
+		return c.collectGroup(node.Nodes, scope)
+	case *TrimNode:
+		return c.collectGroup(node.Nodes, scope)
+	case TrimNode:
+		return c.collectGroup(node.Nodes, scope)
+	case *OtherwiseNode:
+		return c.collectGroup(node.Nodes, scope)
+	case OtherwiseNode:
+		return c.collectGroup(node.Nodes, scope)
+	case *DialectNode:
+		// the parameters of all the dialect bodies are collected, whichever the driver is.
+		for _, body := range node.Cases {
+			if err := c.collectGroup(body.Nodes, scope); err != nil {
+				return err
+			}
+		}
+	case *ForeachNode:
+		return c.collectForeach(*node, scope)
+	case ForeachNode:
+		return c.collectForeach(node, scope)
+	case *SQLNode:
+		return c.collectGroup(node.nodes, scope)
+	case SQLNode:
+		return c.collectGroup(node.nodes, scope)
+	case *IncludeNode:
+		sqlNode := node.sqlNode
+		if sqlNode == nil {
+			var err error
+			if sqlNode, err = node.mapper.GetSQLNodeByID(node.refId); err != nil {
+				return err
+			}
+		}
+		if len(node.properties) == 0 {
+			return c.collect(sqlNode, scope)
+		}
+		// the properties are not parameters, but their values may reference the parameters.
+		inner := make(map[string]*ParamSchema, len(scope)+len(node.properties))
+		for name, schema := range scope {
+			inner[name] = schema
+		}
+		for _, property := range node.properties {
+			if err := c.collect(NewTextNode(property.Value), scope); err != nil {
+				return err
+			}
+			inner[property.Name] = &ParamSchema{}
+		}
+		return c.collect(sqlNode, inner)
+	}
+	return nil
+}
+
+// collectGroup collects the parameters referenced by the nodes.
+func (c *paramSchemaCollector) collectGroup(nodes []Node, scope map[string]*ParamSchema) error {
+	for _, node := range nodes {
+		// the variable of the bind node is not a parameter, the following nodes reference it by a discarded schema.
+		if bind, ok := node.(*BindNode); ok {
+			for _, name := range eval.Identifiers(bind.expr) {
+				c.add(name, scope)
+			}
+			inner := make(map[string]*ParamSchema, len(scope)+1)
+			for name, schema := range scope {
+				inner[name] = schema
+			}
+			inner[bind.Name] = &ParamSchema{}
+			scope = inner
+			continue
+		}
+		if err := c.collect(node, scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectChoose collects the parameters referenced by the when and otherwise nodes.
+func (c *paramSchemaCollector) collectChoose(node ChooseNode, scope map[string]*ParamSchema) error {
+	if err := c.collectGroup(node.WhenNodes, scope); err != nil {
+		return err
+	}
+	if node.OtherwiseNode != nil {
+		return c.collect(node.OtherwiseNode, scope)
+	}
+	return nil
+}
+
+// collectForeach adds the collection as an array, whose items are referenced by the item of the foreach.
+func (c *paramSchemaCollector) collectForeach(node ForeachNode, scope map[string]*ParamSchema) error {
+	collection := c.add(node.Collection, scope)
+	collection.Type = "array"
+	if collection.Items == nil {
+		collection.Items = &ParamSchema{}
+	}
+	inner := make(map[string]*ParamSchema, len(scope)+1)
+	for name, schema := range scope {
+		inner[name] = schema
+	}
+	inner[node.Item] = collection.Items
+	if node.Index != "" {
+		// the index is not a parameter, it points to an unused schema.
+		inner[node.Index] = &ParamSchema{}
+	}
+	return c.collectGroup(node.Nodes, inner)
+}
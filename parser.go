@@ -25,8 +25,10 @@ import (
 	"io/fs"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-juicedev/juice/eval"
 )
@@ -43,6 +45,16 @@ type XMLParser struct {
 	FS            fs.FS
 	ignoreEnv     bool
 	parsers       []XMLElementParser
+	// expressions are the compiled expressions of the mappers, whose depth is checked
+	// against the settings once the configuration is parsed.
+	expressions []parsedExpression
+}
+
+// parsedExpression is a compiled expression of the mappers.
+type parsedExpression struct {
+	expr   eval.Expression
+	text   string
+	source SourcePosition
 }
 
 // Parse implements ConfigurationParser.
@@ -67,9 +79,27 @@ func (p *XMLParser) Parse(reader io.Reader) (IConfiguration, error) {
 			}
 		}
 	}
+	if err := p.checkExpressionDepth(); err != nil {
+		return nil, err
+	}
 	return &p.configuration, nil
 }
 
+// checkExpressionDepth checks the depth of the expressions against the exprMaxDepth setting,
+// so the too deep expressions are reported once at startup instead of by each evaluation.
+func (p *XMLParser) checkExpressionDepth() error {
+	if p.configuration.settings == nil {
+		return nil
+	}
+	limits := settingsExpressionLimits(p.configuration.settings)
+	for _, expression := range p.expressions {
+		if err := eval.CheckDepth(expression.expr, limits); err != nil {
+			return withSource(fmt.Errorf("expression %q: %w", expression.text, err), expression.source)
+		}
+	}
+	return nil
+}
+
 func (p *XMLParser) AddXMLElementParser(parsers ...XMLElementParser) {
 	p.parsers = append(p.parsers, parsers...)
 }
@@ -167,6 +197,14 @@ func (p *XMLEnvironmentsElementParser) parseEnvironment(decoder *xml.Decoder, to
 				if err != nil {
 					return nil, err
 				}
+			case "onConnect":
+				query, err := parseString(tokenName, decoder, provider)
+				if err != nil {
+					return nil, err
+				}
+				if query = strings.TrimSpace(query); query != "" {
+					env.OnConnect = append(env.OnConnect, query)
+				}
 			}
 		case xml.EndElement:
 			if token.Name.Local == "environment" {
@@ -228,10 +266,28 @@ func (p *XMLSettingsElementParser) ParseElement(parser *XMLParser, decoder *xml.
 	if err != nil {
 		return err
 	}
+	for name, validate := range settingValidators {
+		if value := settings.Get(name).String(); value != "" {
+			if err = validate(value); err != nil {
+				return fmt.Errorf("invalid %s setting: %w", name, err)
+			}
+		}
+	}
 	parser.configuration.settings = settings
 	return nil
 }
 
+// settingValidators validate the settings when they are parsed.
+var settingValidators = map[string]func(value string) error{
+	"exprMaxDepth": validateNonNegativeIntAttribute,
+	"exprMaxNodes": validateNonNegativeIntAttribute,
+	"exprMaxCalls": validateNonNegativeIntAttribute,
+	"exprTimeout":  validatePositiveDurationAttribute,
+	"quota":        validateQuotaAttribute,
+	"rowQuota":     validateQuotaAttribute,
+	"quotaAction":  validateQuotaActionAttribute,
+}
+
 func (p *XMLSettingsElementParser) parseSettings(decoder *xml.Decoder) (keyValueSettingProvider, error) {
 	var settings = make(keyValueSettingProvider)
 
@@ -269,6 +325,48 @@ func (p *XMLSettingsElementParser) parseSettings(decoder *xml.Decoder) (keyValue
 
 type XMLMappersElementParser struct {
 	parser *XMLParser
+	// file is the name of the mapper file being parsed, which is recorded by the source positions.
+	file string
+	// source is the reader of the mapper file read by the decoder,
+	// which hashes the statements by their bodies, see statementHash.
+	source *hashingReader
+	// includes records the include nodes of the statement or the fragment being parsed,
+	// whose contents are hashed along with it.
+	includes []*IncludeNode
+}
+
+// hashBody starts hashing the body of the statement after its start element read by the decoder,
+// the returned function stops it and returns the hash, which is empty if the body is not read from the source.
+func (p *XMLMappersElementParser) hashBody(decoder *xml.Decoder, action Action, attrs map[string]string) func() string {
+	source := p.source
+	if source == nil || source.offset != decoder.InputOffset() {
+		return func() string { return "" }
+	}
+	hash := newStatementHash(action, attrs)
+	source.hash = hash
+	return func() string {
+		if source.hash != hash {
+			return ""
+		}
+		source.hash = nil
+		if source.offset != decoder.InputOffset() {
+			return ""
+		}
+		return hash.Sum()
+	}
+}
+
+// compiled records the compiled expression, see XMLParser.checkExpressionDepth.
+func (p *XMLMappersElementParser) compiled(expr eval.Expression, text string, source SourcePosition) {
+	if p.parser != nil {
+		p.parser.expressions = append(p.parser.expressions, parsedExpression{expr: expr, text: text, source: source})
+	}
+}
+
+// sourceOf returns the source position of the token just read by the decoder.
+func (p *XMLMappersElementParser) sourceOf(decoder *xml.Decoder) SourcePosition {
+	line, _ := decoder.InputPos()
+	return SourcePosition{File: p.file, Line: line}
 }
 
 func (p *XMLMappersElementParser) MatchElement(token xml.StartElement) bool {
@@ -283,7 +381,12 @@ func (p *XMLMappersElementParser) ParseElement(parser *XMLParser, decoder *xml.D
 	}
 	mappers.cfg = parser.configuration
 	parser.configuration.mappers = mappers
-	return nil
+	// the composites may reference the statements of the mappers parsed after them.
+	var envs EnvironmentProvider
+	if parser.configuration.environments != nil {
+		envs = parser.configuration.environments
+	}
+	return mappers.validateComposites(envs)
 }
 
 func (p *XMLMappersElementParser) parseMappers(start xml.StartElement, decoder *xml.Decoder) (*Mappers, error) {
@@ -402,6 +505,14 @@ func (p *XMLMappersElementParser) parseMapper(decoder *xml.Decoder, token xml.St
 				if err = mapper.setSqlNode(sqlNode); err != nil {
 					return nil, err
 				}
+			case "composite":
+				composite, err := p.parseComposite(mapper, decoder, token)
+				if err != nil {
+					return nil, err
+				}
+				if err = mapper.setComposite(composite); err != nil {
+					return nil, err
+				}
 			}
 		case xml.EndElement:
 			if token.Name.Local == "mapper" {
@@ -412,21 +523,35 @@ func (p *XMLMappersElementParser) parseMapper(decoder *xml.Decoder, token xml.St
 	return mapper, nil
 }
 
-func (p *XMLMappersElementParser) parseMapperByReader(reader io.Reader) (mapper *Mapper, err error) {
-	decoder := xml.NewDecoder(reader)
+// parseMapperByReader parses the mapper token by token from the reader,
+// the errors are reported with the position in the file of the given name.
+func (p *XMLMappersElementParser) parseMapperByReader(name string, reader io.Reader) (mapper *Mapper, err error) {
+	// restore the file of the parent, the mapper file may be referenced by the resource of another one.
+	defer func(file string, source *hashingReader) { p.file, p.source = file, source }(p.file, p.source)
+	p.file, p.source = name, newHashingReader(reader)
+	decoder := xml.NewDecoder(p.source)
+	positionError := func(err error) error {
+		// the error of the nested mapper file already has its own position.
+		var parseError *ParseError
+		if errors.As(err, &parseError) {
+			return err
+		}
+		line, column := decoder.InputPos()
+		return &ParseError{File: name, Line: line, Column: column, Err: err}
+	}
 	for {
 		token, err := decoder.Token()
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			return nil, err
+			return nil, positionError(err)
 		}
 		switch token := token.(type) {
 		case xml.StartElement:
 			if token.Name.Local == "mapper" {
 				if mapper, err = p.parseMapper(decoder, token); err != nil {
-					return nil, err
+					return nil, positionError(err)
 				}
 				break
 			}
@@ -445,7 +570,7 @@ func (p *XMLMappersElementParser) parseMapperByResource(resource string) (*Mappe
 		return nil, err
 	}
 	defer func() { _ = reader.Close() }()
-	return p.parseMapperByReader(reader)
+	return p.parseMapperByReader(resource, reader)
 }
 
 func (p *XMLMappersElementParser) parseMapperByHttpResponse(url string) (*Mapper, error) {
@@ -454,7 +579,7 @@ func (p *XMLMappersElementParser) parseMapperByHttpResponse(url string) (*Mapper
 		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
-	return p.parseMapperByReader(resp.Body)
+	return p.parseMapperByReader(url, resp.Body)
 }
 
 func (p *XMLMappersElementParser) parseMapperByURL(path string) (*Mapper, error) {
@@ -495,9 +620,9 @@ func (p *XMLMappersElementParser) parseMapperByPattern(pattern string) ([]*Mappe
 		defer func() { _ = file.Close() }()
 
 		// Parse mapper from file content
-		mapper, err := p.parseMapperByReader(file)
+		mapper, err := p.parseMapperByReader(match, file)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse mapper %q: %w", match, err)
+			return nil, fmt.Errorf("failed to parse mapper: %w", err)
 		}
 
 		return mapper, nil
@@ -521,14 +646,23 @@ func (p *XMLMappersElementParser) parseMapperByPattern(pattern string) ([]*Mappe
 }
 
 func (p *XMLMappersElementParser) parseStatement(stmt *xmlSQLStatement, decoder *xml.Decoder, token xml.StartElement) error {
+	stmt.source = p.sourceOf(decoder)
+	defer func(includes []*IncludeNode) { p.includes = includes }(p.includes)
+	p.includes = nil
 	for _, attr := range token.Attr {
 		stmt.setAttribute(attr.Name.Local, attr.Value)
 	}
+	// the body of the statement starts after the start element.
+	hashBody := p.hashBody(decoder, stmt.action, stmt.attrs)
+	defer hashBody()
 	if id := stmt.Attribute("id"); id == "" {
 		return fmt.Errorf("%s xmlSQLStatement id is required", stmt.Action())
 	} else {
 		stmt.id = id
 	}
+	if err := validateStatementAttributes(stmt); err != nil {
+		return err
+	}
 	for {
 		token, err := decoder.Token()
 		if err != nil {
@@ -544,7 +678,7 @@ func (p *XMLMappersElementParser) parseStatement(stmt *xmlSQLStatement, decoder
 				if stmt.action != Insert {
 					return fmt.Errorf("values node only support insert xmlSQLStatement")
 				}
-				node, err := p.parseValuesNode(decoder)
+				node, err := p.parseValuesNode(decoder, stmt.Attribute("quoteIdentifier") == "true")
 				if err != nil {
 					return err
 				}
@@ -553,11 +687,27 @@ func (p *XMLMappersElementParser) parseStatement(stmt *xmlSQLStatement, decoder
 				if stmt.action != Select {
 					return fmt.Errorf("alias node only support select xmlSQLStatement")
 				}
-				node, err := p.parseAliasNode(decoder)
+				node, err := p.parseAliasNode(decoder, stmt.Attribute("quoteIdentifier") == "true")
 				if err != nil {
 					return err
 				}
 				stmt.Nodes = append(stmt.Nodes, node)
+			case "dialect":
+				source := p.sourceOf(decoder)
+				body, err := p.parseDialect(stmt.mapper, decoder, token)
+				if err != nil {
+					return err
+				}
+				// the consecutive dialect tags are the bodies of the same node.
+				node, ok := lastNode(stmt.Nodes).(*DialectNode)
+				if !ok {
+					node = &DialectNode{source: source}
+					stmt.Nodes = append(stmt.Nodes, node)
+				}
+				if len(body.Drivers) == 0 && slices.ContainsFunc(node.Cases, func(body DialectCase) bool { return len(body.Drivers) == 0 }) {
+					return errors.New("default dialect is only once")
+				}
+				node.Cases = append(node.Cases, body)
 			default:
 				node, err := p.parseTags(stmt.mapper, decoder, token)
 				if err != nil {
@@ -574,6 +724,8 @@ func (p *XMLMappersElementParser) parseStatement(stmt *xmlSQLStatement, decoder
 		case xml.EndElement:
 			switch token.Name.Local {
 			case stmt.action.String():
+				stmt.hash = hashBody()
+				stmt.includes = p.includes
 				return nil
 			default:
 				return fmt.Errorf("unexpected end element: %s", token.Name.Local)
@@ -583,26 +735,158 @@ func (p *XMLMappersElementParser) parseStatement(stmt *xmlSQLStatement, decoder
 	return nil
 }
 
+// statementAttributeValidators validate the attributes of the statements when they are parsed,
+// so that the invalid values are reported at startup instead of being ignored at runtime.
+var statementAttributeValidators = map[string]func(value string) error{
+	"maxAffectedRows": validatePositiveIntAttribute,
+	"resultCapacity":  validatePositiveIntAttribute,
+	"quota":           validateQuotaAttribute,
+	"rowQuota":        validateQuotaAttribute,
+	"quotaAction":     validateQuotaActionAttribute,
+}
+
+// validateStatementAttributes validates the attributes of the statement by statementAttributeValidators.
+func validateStatementAttributes(stmt *xmlSQLStatement) error {
+	for name, validate := range statementAttributeValidators {
+		value := stmt.Attribute(name)
+		if value == "" {
+			continue
+		}
+		if err := validate(value); err != nil {
+			return fmt.Errorf("invalid %s attribute of statement %s: %w", name, stmt.id, err)
+		}
+	}
+	return nil
+}
+
+// compositeAttributeValidators validate the attributes of the composite statements when they are parsed.
+var compositeAttributeValidators = map[string]func(value string) error{
+	"compensateRetries": validateNonNegativeIntAttribute,
+	"compensateBackoff": validatePositiveDurationAttribute,
+}
+
+// validateNonNegativeIntAttribute returns an error if the value is not a non-negative integer.
+func validateNonNegativeIntAttribute(value string) error {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	if number < 0 {
+		return fmt.Errorf("%d is negative", number)
+	}
+	return nil
+}
+
+// validatePositiveDurationAttribute returns an error if the value is not a positive duration.
+func validatePositiveDurationAttribute(value string) error {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if duration <= 0 {
+		return fmt.Errorf("%s is not positive", duration)
+	}
+	return nil
+}
+
+// validatePositiveIntAttribute returns an error if the value is not a positive integer.
+func validatePositiveIntAttribute(value string) error {
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	if number <= 0 {
+		return fmt.Errorf("%d is not positive", number)
+	}
+	return nil
+}
+
 func (p *XMLMappersElementParser) parseTags(mapper *Mapper, decoder *xml.Decoder, token xml.StartElement) (Node, error) {
 	switch token.Name.Local {
 	case "if":
 		return p.parseIf(mapper, decoder, token)
 	case "where":
-		return p.parseWhere(mapper, decoder)
+		return p.parseWhere(mapper, decoder, token)
 	case "trim":
 		return p.parseTrim(mapper, decoder, token)
 	case "foreach":
 		return p.parseForeach(mapper, decoder, token)
 	case "set":
-		return p.parseSet(mapper, decoder)
+		return p.parseSet(mapper, decoder, token)
 	case "include":
 		return p.parseInclude(mapper, decoder, token)
+	case "bind":
+		return p.parseBind(decoder, token)
 	case "choose":
 		return p.parseChoose(mapper, decoder)
 	}
 	return nil, fmt.Errorf("unknown tag: %s", token.Name.Local)
 }
 
+func (p *XMLMappersElementParser) parseComposite(mapper *Mapper, decoder *xml.Decoder, token xml.StartElement) (*CompositeStatement, error) {
+	composite := &CompositeStatement{mapper: mapper, attrs: make(map[string]string)}
+	for _, attr := range token.Attr {
+		composite.attrs[attr.Name.Local] = attr.Value
+	}
+	if composite.id = composite.attrs["id"]; composite.id == "" {
+		return nil, &nodeAttributeRequiredError{nodeName: "composite", attrName: "id"}
+	}
+	for name, validate := range compositeAttributeValidators {
+		if value := composite.attrs[name]; value != "" {
+			if err := validate(value); err != nil {
+				return nil, fmt.Errorf("invalid %s attribute of composite %s: %w", name, composite.id, err)
+			}
+		}
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local != "step" {
+				return nil, fmt.Errorf("unexpected element %s in composite %s", token.Name.Local, composite.id)
+			}
+			var step CompositeStep
+			for _, attr := range token.Attr {
+				switch attr.Name.Local {
+				case "ref":
+					step.Ref = attr.Value
+				case "as":
+					step.As = attr.Value
+				case "compensate":
+					step.Compensate = attr.Value
+				case "environment":
+					step.Environment = attr.Value
+				}
+			}
+			if step.Ref == "" {
+				return nil, &nodeAttributeRequiredError{nodeName: "step", attrName: "ref"}
+			}
+			composite.steps = append(composite.steps, step)
+		case xml.EndElement:
+			if token.Name.Local == "composite" {
+				if len(composite.steps) == 0 {
+					return nil, fmt.Errorf("composite %s has no steps", composite.id)
+				}
+				if !composite.saga() {
+					for _, step := range composite.steps {
+						if step.Environment != "" {
+							return nil, fmt.Errorf("step %s of composite %s has an environment without the compensations", step.Ref, composite.id)
+						}
+					}
+				}
+				return composite, nil
+			}
+		}
+	}
+	return nil, &nodeUnclosedError{nodeName: "composite"}
+}
+
 func (p *XMLMappersElementParser) parseInclude(mapper *Mapper, decoder *xml.Decoder, token xml.StartElement) (Node, error) {
 	var ref string
 	for _, attr := range token.Attr {
@@ -631,9 +915,21 @@ func (p *XMLMappersElementParser) parseInclude(mapper *Mapper, decoder *xml.Deco
 			return nil, err
 		}
 		switch token := token.(type) {
-		// TODO: PARSE PROPERTIES HERE
+		case xml.StartElement:
+			if token.Name.Local != "property" {
+				return nil, fmt.Errorf("unknown tag in include: %s", token.Name.Local)
+			}
+			property, err := p.parseIncludeProperty(decoder, token)
+			if err != nil {
+				return nil, err
+			}
+			if slices.ContainsFunc(includeNode.properties, func(item IncludeProperty) bool { return item.Name == property.Name }) {
+				return nil, fmt.Errorf("property %s of include %s is duplicated", property.Name, ref)
+			}
+			includeNode.properties = append(includeNode.properties, property)
 		case xml.EndElement:
 			if token.Name.Local == "include" {
+				p.includes = append(p.includes, includeNode)
 				return includeNode, nil
 			}
 		}
@@ -641,8 +937,146 @@ func (p *XMLMappersElementParser) parseInclude(mapper *Mapper, decoder *xml.Deco
 	return nil, &nodeUnclosedError{nodeName: "include"}
 }
 
-func (p *XMLMappersElementParser) parseSet(mapper *Mapper, decoder *xml.Decoder) (Node, error) {
+func (p *XMLMappersElementParser) parseIncludeProperty(decoder *xml.Decoder, token xml.StartElement) (IncludeProperty, error) {
+	var (
+		property IncludeProperty
+		hasValue bool
+	)
+	for _, attr := range token.Attr {
+		switch attr.Name.Local {
+		case "name":
+			property.Name = attr.Value
+		case "value":
+			property.Value, hasValue = attr.Value, true
+		}
+	}
+	if property.Name == "" {
+		return property, &nodeAttributeRequiredError{nodeName: "property", attrName: "name"}
+	}
+	// the empty value is allowed, like an empty alias.
+	if !hasValue {
+		return property, &nodeAttributeRequiredError{nodeName: "property", attrName: "value"}
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return property, err
+		}
+		if end, ok := token.(xml.EndElement); ok && end.Name.Local == "property" {
+			return property, nil
+		}
+	}
+	return property, &nodeUnclosedError{nodeName: "property"}
+}
+
+func (p *XMLMappersElementParser) parseBind(decoder *xml.Decoder, token xml.StartElement) (Node, error) {
+	bindNode := &BindNode{source: p.sourceOf(decoder)}
+	for _, attr := range token.Attr {
+		switch attr.Name.Local {
+		case "name":
+			bindNode.Name = attr.Value
+		case "value":
+			bindNode.value = attr.Value
+		}
+	}
+	if bindNode.Name == "" {
+		return nil, &nodeAttributeRequiredError{nodeName: "bind", attrName: "name"}
+	}
+	if bindNode.value == "" {
+		return nil, &nodeAttributeRequiredError{nodeName: "bind", attrName: "value"}
+	}
+	if err := bindNode.Parse(bindNode.value); err != nil {
+		return nil, err
+	}
+	p.compiled(bindNode.expr, bindNode.value, bindNode.source)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if end, ok := token.(xml.EndElement); ok && end.Name.Local == "bind" {
+			return bindNode, nil
+		}
+	}
+	return nil, &nodeUnclosedError{nodeName: "bind"}
+}
+
+func (p *XMLMappersElementParser) parseDialect(mapper *Mapper, decoder *xml.Decoder, token xml.StartElement) (DialectCase, error) {
+	var body DialectCase
+	for _, attr := range token.Attr {
+		if attr.Name.Local == "driver" {
+			for _, name := range strings.Split(attr.Value, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					body.Drivers = append(body.Drivers, name)
+				}
+			}
+		}
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return body, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			node, err := p.parseTags(mapper, decoder, token)
+			if err != nil {
+				return body, err
+			}
+			body.Nodes = append(body.Nodes, node)
+		case xml.CharData:
+			text := string(token)
+			if char := strings.TrimSpace(text); char != "" {
+				node := NewTextNode(char)
+				body.Nodes = append(body.Nodes, node)
+			}
+		case xml.EndElement:
+			if token.Name.Local == "dialect" {
+				return body, nil
+			}
+		}
+	}
+	return body, &nodeUnclosedError{nodeName: "dialect"}
+}
+
+// lastNode returns the last node of the group, or nil if the group is empty.
+func lastNode(nodes NodeGroup) Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[len(nodes)-1]
+}
+
+func (p *XMLMappersElementParser) parseSet(mapper *Mapper, decoder *xml.Decoder, token xml.StartElement) (Node, error) {
 	setNode := &SetNode{}
+	var options setAssignmentOptions
+	for _, attr := range token.Attr {
+		switch attr.Name.Local {
+		case "ignoreZero":
+			options.ignoreZero = attr.Value == "true"
+		case "includeZero":
+			for _, column := range strings.Split(attr.Value, ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					options.includeZero = append(options.includeZero, column)
+				}
+			}
+		case "fieldMask":
+			options.fieldMask = attr.Value
+		case "prefixOverrides":
+			setNode.PrefixOverrides = parseOverrides(attr.Value)
+		case "suffixOverrides":
+			setNode.SuffixOverrides = parseOverrides(attr.Value)
+		}
+	}
 	for {
 		token, err := decoder.Token()
 		if err != nil {
@@ -657,10 +1091,17 @@ func (p *XMLMappersElementParser) parseSet(mapper *Mapper, decoder *xml.Decoder)
 			if err != nil {
 				return nil, err
 			}
+			if options.ignoreZero || options.fieldMask != "" {
+				node = wrapSetAssignments(node, options)
+			}
 			setNode.Nodes = append(setNode.Nodes, node)
 		case xml.CharData:
 			text := string(token)
 			if char := strings.TrimSpace(text); char != "" {
+				if options.ignoreZero || options.fieldMask != "" {
+					setNode.Nodes = append(setNode.Nodes, newSetAssignmentNodes(char, options)...)
+					continue
+				}
 				node := NewTextNode(char)
 				setNode.Nodes = append(setNode.Nodes, node)
 			}
@@ -674,7 +1115,7 @@ func (p *XMLMappersElementParser) parseSet(mapper *Mapper, decoder *xml.Decoder)
 }
 
 func (p *XMLMappersElementParser) parseIf(mapper *Mapper, decoder *xml.Decoder, token xml.StartElement) (Node, error) {
-	ifNode := &IfNode{}
+	ifNode := &IfNode{source: p.sourceOf(decoder)}
 	var test string
 	for _, attr := range token.Attr {
 		if attr.Name.Local == "test" {
@@ -690,6 +1131,7 @@ func (p *XMLMappersElementParser) parseIf(mapper *Mapper, decoder *xml.Decoder,
 	if err := ifNode.Parse(test); err != nil {
 		return nil, err
 	}
+	p.compiled(ifNode.expr, test, ifNode.source)
 	for {
 		token, err := decoder.Token()
 		if err != nil {
@@ -720,8 +1162,16 @@ func (p *XMLMappersElementParser) parseIf(mapper *Mapper, decoder *xml.Decoder,
 	return nil, &nodeUnclosedError{nodeName: "if"}
 }
 
-func (p *XMLMappersElementParser) parseWhere(mapper *Mapper, decoder *xml.Decoder) (Node, error) {
+func (p *XMLMappersElementParser) parseWhere(mapper *Mapper, decoder *xml.Decoder, token xml.StartElement) (Node, error) {
 	whereNode := &WhereNode{}
+	for _, attr := range token.Attr {
+		switch attr.Name.Local {
+		case "prefixOverrides":
+			whereNode.PrefixOverrides = parseOverrides(attr.Value)
+		case "suffixOverrides":
+			whereNode.SuffixOverrides = parseOverrides(attr.Value)
+		}
+	}
 	for {
 		token, err := decoder.Token()
 		if err != nil {
@@ -759,19 +1209,11 @@ func (p *XMLMappersElementParser) parseTrim(mapper *Mapper, decoder *xml.Decoder
 		case "prefix":
 			trimNode.Prefix = attr.Value
 		case "prefixOverrides":
-			prefixOverrides := strings.Split(attr.Value, "|")
-			for i := range prefixOverrides {
-				prefixOverrides[i] = strings.TrimSpace(prefixOverrides[i])
-			}
-			trimNode.PrefixOverrides = prefixOverrides
+			trimNode.PrefixOverrides = parseOverrides(attr.Value)
 		case "suffix":
 			trimNode.Suffix = attr.Value
 		case "suffixOverrides":
-			suffixOverrides := strings.Split(attr.Value, "|")
-			for i := range suffixOverrides {
-				suffixOverrides[i] = strings.TrimSpace(suffixOverrides[i])
-			}
-			trimNode.SuffixOverrides = suffixOverrides
+			trimNode.SuffixOverrides = parseOverrides(attr.Value)
 		}
 	}
 	for {
@@ -789,6 +1231,12 @@ func (p *XMLMappersElementParser) parseTrim(mapper *Mapper, decoder *xml.Decoder
 				return nil, err
 			}
 			trimNode.Nodes = append(trimNode.Nodes, node)
+		case xml.CharData:
+			text := string(token)
+			if char := strings.TrimSpace(text); char != "" {
+				node := NewTextNode(char)
+				trimNode.Nodes = append(trimNode.Nodes, node)
+			}
 		case xml.EndElement:
 			if token.Name.Local == "trim" {
 				return trimNode, nil
@@ -799,7 +1247,9 @@ func (p *XMLMappersElementParser) parseTrim(mapper *Mapper, decoder *xml.Decoder
 }
 
 func (p *XMLMappersElementParser) parseForeach(mapper *Mapper, decoder *xml.Decoder, token xml.StartElement) (Node, error) {
-	foreachNode := &ForeachNode{}
+	foreachNode := &ForeachNode{source: p.sourceOf(decoder)}
+	// settings must be declared before the mappers to take effect.
+	foreachNode.Deterministic = p.parser.configuration.settings.Get("deterministic").Bool()
 	for _, attr := range token.Attr {
 		switch attr.Name.Local {
 		case "collection":
@@ -814,6 +1264,8 @@ func (p *XMLMappersElementParser) parseForeach(mapper *Mapper, decoder *xml.Deco
 			foreachNode.Separator = attr.Value
 		case "close":
 			foreachNode.Close = attr.Value
+		case "deterministic":
+			foreachNode.Deterministic = StringValue(attr.Value).Bool()
 		}
 	}
 
@@ -868,6 +1320,10 @@ func (p *XMLMappersElementParser) parseChoose(mapper *Mapper, decoder *xml.Decod
 		case xml.StartElement:
 			switch token.Name.Local {
 			case "when":
+				// the when branches after otherwise would never be evaluated.
+				if chooseNode.OtherwiseNode != nil {
+					return nil, errors.New("when must be declared before otherwise")
+				}
 				node, err := p.parseWhen(mapper, decoder, token)
 				if err != nil {
 					return nil, err
@@ -882,6 +1338,8 @@ func (p *XMLMappersElementParser) parseChoose(mapper *Mapper, decoder *xml.Decod
 					return nil, err
 				}
 				chooseNode.OtherwiseNode = node
+			default:
+				return nil, fmt.Errorf("unknown tag in choose: %s", token.Name.Local)
 			}
 
 		case xml.EndElement:
@@ -907,6 +1365,10 @@ func (p *XMLMappersElementParser) parseSQLNode(mapper *Mapper, decoder *xml.Deco
 	if strings.Contains(sqlNode.id, ".") {
 		return nil, fmt.Errorf("sql id can not contain '.' %s", sqlNode.id)
 	}
+	hashBody := p.hashBody(decoder, "sql", nil)
+	defer hashBody()
+	defer func(includes []*IncludeNode) { p.includes = includes }(p.includes)
+	p.includes = nil
 	for {
 		token, err := decoder.Token()
 		if err != nil {
@@ -930,6 +1392,8 @@ func (p *XMLMappersElementParser) parseSQLNode(mapper *Mapper, decoder *xml.Deco
 			}
 		case xml.EndElement:
 			if token.Name.Local == "sql" {
+				sqlNode.hash = hashBody()
+				sqlNode.includes = p.includes
 				return sqlNode, nil
 			}
 		}
@@ -938,7 +1402,7 @@ func (p *XMLMappersElementParser) parseSQLNode(mapper *Mapper, decoder *xml.Deco
 }
 
 func (p *XMLMappersElementParser) parseWhen(mapper *Mapper, decoder *xml.Decoder, token xml.StartElement) (Node, error) {
-	whenNode := &WhenNode{}
+	whenNode := &WhenNode{source: p.sourceOf(decoder)}
 	var test string
 	for _, attr := range token.Attr {
 		if attr.Name.Local == "test" {
@@ -954,6 +1418,7 @@ func (p *XMLMappersElementParser) parseWhen(mapper *Mapper, decoder *xml.Decoder
 	if err := whenNode.Parse(test); err != nil {
 		return nil, err
 	}
+	p.compiled(whenNode.expr, test, whenNode.source)
 	for {
 		token, err := decoder.Token()
 		if err != nil {
@@ -1016,7 +1481,9 @@ func (p *XMLMappersElementParser) parseOtherwise(mapper *Mapper, decoder *xml.De
 	return nil, &nodeUnclosedError{nodeName: "otherwise"}
 }
 
-func (p *XMLMappersElementParser) parseValuesNode(decoder *xml.Decoder) (Node, error) {
+// parseValuesNode parses the values node.
+// If quote is true, the columns will be quoted by the dialect of the driver.
+func (p *XMLMappersElementParser) parseValuesNode(decoder *xml.Decoder, quote bool) (Node, error) {
 	var node = make(ValuesNode, 0)
 	for {
 		token, err := decoder.Token()
@@ -1034,6 +1501,7 @@ func (p *XMLMappersElementParser) parseValuesNode(decoder *xml.Decoder) (Node, e
 				if err != nil {
 					return nil, err
 				}
+				value.quote = quote
 				node = append(node, value)
 			}
 		case xml.EndElement:
@@ -1082,7 +1550,8 @@ func (p *XMLMappersElementParser) parseValueNode(token xml.StartElement, decoder
 }
 
 // parseAliasNode parses the alias node
-func (p *XMLMappersElementParser) parseAliasNode(decoder *xml.Decoder) (Node, error) {
+// If quote is true, the field names and their aliases will be quoted by the dialect of the driver.
+func (p *XMLMappersElementParser) parseAliasNode(decoder *xml.Decoder, quote bool) (Node, error) {
 	var node = make(SelectFieldAliasNode, 0)
 	for {
 		token, err := decoder.Token()
@@ -1100,6 +1569,7 @@ func (p *XMLMappersElementParser) parseAliasNode(decoder *xml.Decoder) (Node, er
 				if err != nil {
 					return nil, err
 				}
+				item.quote = quote
 				node = append(node, item)
 			}
 		case xml.EndElement:
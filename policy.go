@@ -0,0 +1,227 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// ErrPolicyDenied is the error that a statement is denied by a policy.
+// All the errors returned by the PolicyMiddleware can be matched with errors.Is(err, ErrPolicyDenied).
+var ErrPolicyDenied = errors.New("statement denied by policy")
+
+// PolicyError is the error which describes why a statement is denied.
+type PolicyError struct {
+	// Policy is the name of the policy which denies the statement.
+	Policy string
+	// Statement is the name of the statement.
+	Statement string
+	// Reason is the reason why the statement is denied.
+	Reason string
+}
+
+// Error implements error.
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s: statement %q denied by %s: %s", ErrPolicyDenied, e.Statement, e.Policy, e.Reason)
+}
+
+// Is reports whether the target is ErrPolicyDenied.
+func (e *PolicyError) Is(target error) bool {
+	return target == ErrPolicyDenied
+}
+
+// Policy decides whether a statement is allowed to be executed.
+type Policy interface {
+	// Check returns a non-nil error to deny the statement.
+	// The error will be wrapped into a PolicyError if it is not one.
+	Check(ctx context.Context, stmt Statement, query string, args []any) error
+}
+
+// PolicyFunc is an adapter to allow the use of ordinary functions as Policy.
+type PolicyFunc func(ctx context.Context, stmt Statement, query string, args []any) error
+
+// Check calls f(ctx, stmt, query, args).
+func (f PolicyFunc) Check(ctx context.Context, stmt Statement, query string, args []any) error {
+	return f(ctx, stmt, query, args)
+}
+
+// ensure PolicyMiddleware implements Middleware.
+var _ Middleware = (*PolicyMiddleware)(nil) // compile time check
+
+// PolicyMiddleware is a middleware that evaluates the policies in order before the statement is executed,
+// and denies the statement once a policy returns an error.
+//
+// Example usage:
+//
+//	engine.Use(&juice.PolicyMiddleware{
+//	    Policies: []juice.Policy{juice.WhereRequiredPolicy{}, juice.ReadOnlyPolicy{}},
+//	})
+type PolicyMiddleware struct {
+	Policies []Policy
+}
+
+// QueryContext implements Middleware.
+func (m *PolicyMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryHandler {
+	if len(m.Policies) == 0 {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		if err := m.check(ctx, stmt, query, args); err != nil {
+			return nil, err
+		}
+		return next(ctx, query, args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *PolicyMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHandler {
+	if len(m.Policies) == 0 {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		if err := m.check(ctx, stmt, query, args); err != nil {
+			return nil, err
+		}
+		return next(ctx, query, args...)
+	}
+}
+
+// check evaluates the policies.
+func (m *PolicyMiddleware) check(ctx context.Context, stmt Statement, query string, args []any) error {
+	for _, policy := range m.Policies {
+		err := policy.Check(ctx, stmt, query, args)
+		if err == nil {
+			continue
+		}
+		var policyError *PolicyError
+		if errors.As(err, &policyError) {
+			return err
+		}
+		return &PolicyError{Policy: fmt.Sprintf("%T", policy), Statement: stmt.Name(), Reason: err.Error()}
+	}
+	return nil
+}
+
+// whereClauseRegexp matches the where clause of a query.
+var whereClauseRegexp = regexp.MustCompile(`(?i)\bwhere\b`)
+
+// WhereRequiredPolicy denies the update and delete statements without a WHERE clause,
+// which are likely to modify the whole table by accident.
+// It can be turned off for a statement by setting the allowFullTable attribute to true.
+type WhereRequiredPolicy struct{}
+
+// Check implements Policy.
+func (WhereRequiredPolicy) Check(_ context.Context, stmt Statement, query string, _ []any) error {
+	action := stmt.Action()
+	if action != Update && action != Delete {
+		return nil
+	}
+	if stmt.Attribute("allowFullTable") == "true" || whereClauseRegexp.MatchString(query) {
+		return nil
+	}
+	return &PolicyError{
+		Policy:    "WhereRequiredPolicy",
+		Statement: stmt.Name(),
+		Reason:    fmt.Sprintf("%s without WHERE clause", action),
+	}
+}
+
+// readOnlyKey is the context key of the read only flag.
+type readOnlyKey struct{}
+
+// ContextWithReadOnly returns a new context which marks the statements executed with it as read only,
+// for example, the requests authenticated by a read only api key.
+func ContextWithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// IsReadOnlyContext reports whether the context is marked as read only.
+func IsReadOnlyContext(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
+
+// ReadOnlyPolicy denies the write statements executed with a context created by ContextWithReadOnly.
+type ReadOnlyPolicy struct{}
+
+// Check implements Policy.
+func (ReadOnlyPolicy) Check(ctx context.Context, stmt Statement, _ string, _ []any) error {
+	if !stmt.Action().ForWrite() || !IsReadOnlyContext(ctx) {
+		return nil
+	}
+	return &PolicyError{
+		Policy:    "ReadOnlyPolicy",
+		Statement: stmt.Name(),
+		Reason:    "write statement in read only context",
+	}
+}
+
+// StatementListPolicy allows or denies the statements by their names.
+// The patterns are matched with path.Match, and the dots of the statement names are treated as separators,
+// for example, "main.UserRepository.*" matches all the statements of the mapper.
+// A statement is denied if it matches any of Deny, or Allow is not empty and it matches none of Allow.
+type StatementListPolicy struct {
+	Allow []string
+	Deny  []string
+}
+
+// Check implements Policy.
+func (p StatementListPolicy) Check(_ context.Context, stmt Statement, _ string, _ []any) error {
+	name := stmt.Name()
+	if pattern, ok := matchStatementPattern(p.Deny, name); ok {
+		return &PolicyError{
+			Policy:    "StatementListPolicy",
+			Statement: name,
+			Reason:    fmt.Sprintf("matched deny pattern %q", pattern),
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	if _, ok := matchStatementPattern(p.Allow, name); ok {
+		return nil
+	}
+	return &PolicyError{
+		Policy:    "StatementListPolicy",
+		Statement: name,
+		Reason:    "not in allow list",
+	}
+}
+
+// matchStatementPattern returns the first pattern which matches the statement name.
+func matchStatementPattern(patterns []string, name string) (string, bool) {
+	for _, pattern := range patterns {
+		if pattern == name {
+			return pattern, true
+		}
+		if matched, _ := path.Match(dotsToSlashes(pattern), dotsToSlashes(name)); matched {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// dotsToSlashes replaces the dots of the statement name with slashes, so that * does not match across dots.
+func dotsToSlashes(name string) string {
+	return strings.ReplaceAll(name, ".", "/")
+}
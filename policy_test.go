@@ -0,0 +1,143 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+func TestPolicyMiddleware(t *testing.T) {
+	mapper := `<mapper namespace="main.UserRepository">
+    <select id="Get">select * from user where id = #{id}</select>
+    <update id="Touch">update user set updated = now() where id = #{id}</update>
+    <update id="TouchAll">update user set updated = now()</update>
+    <delete id="Purge" allowFullTable="true">delete from user</delete>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+
+	var executed []string
+	exec := func(_ context.Context, query string, _ ...any) (sql.Result, error) {
+		executed = append(executed, query)
+		return nil, nil
+	}
+	query := func(_ context.Context, query string, _ ...any) (*sql.Rows, error) {
+		executed = append(executed, query)
+		return nil, nil
+	}
+	middleware := &PolicyMiddleware{Policies: []Policy{
+		WhereRequiredPolicy{},
+		ReadOnlyPolicy{},
+		StatementListPolicy{Deny: []string{"main.*.Purge"}},
+	}}
+	run := func(ctx context.Context, id string) error {
+		statement := m.statements[id]
+		built, _, err := statement.Build(driver.MySQLDriver{}.Translator(), H{"id": 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if statement.Action() == Select {
+			_, err = middleware.QueryContext(statement, query)(ctx, built)
+			return err
+		}
+		_, err = middleware.ExecContext(statement, exec)(ctx, built)
+		return err
+	}
+	for id, policy := range map[string]string{
+		"Get":      "",
+		"Touch":    "",
+		"TouchAll": "WhereRequiredPolicy",
+		"Purge":    "StatementListPolicy",
+	} {
+		err := run(context.Background(), id)
+		if policy == "" {
+			if err != nil {
+				t.Errorf("%s: %v", id, err)
+			}
+			continue
+		}
+		var policyError *PolicyError
+		if !errors.Is(err, ErrPolicyDenied) || !errors.As(err, &policyError) || policyError.Policy != policy {
+			t.Errorf("%s: expected denied by %s, got %v", id, policy, err)
+		}
+	}
+	if len(executed) != 2 {
+		t.Fatalf("expected the allowed statements to be executed, got %v", executed)
+	}
+
+	// the write statements are denied in the read only context, the selects are not.
+	ctx := ContextWithReadOnly(context.Background())
+	if err = run(ctx, "Get"); err != nil {
+		t.Fatal(err)
+	}
+	if err = run(ctx, "Touch"); !errors.Is(err, ErrPolicyDenied) {
+		t.Fatalf("expected the write to be denied, got %v", err)
+	}
+}
+
+func TestPolicyMiddleware_PolicyFunc(t *testing.T) {
+	mapper := `<mapper namespace="main.UserRepository">
+    <select id="Get">select * from user</select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	middleware := &PolicyMiddleware{Policies: []Policy{
+		PolicyFunc(func(context.Context, Statement, string, []any) error { return errors.New("maintenance") }),
+	}}
+	next := func(context.Context, string, ...any) (*sql.Rows, error) {
+		t.Fatal("the denied statement is executed")
+		return nil, nil
+	}
+	_, err = middleware.QueryContext(m.statements["Get"], next)(context.Background(), "select * from user")
+	var policyError *PolicyError
+	if !errors.As(err, &policyError) || policyError.Reason != "maintenance" || policyError.Statement != "main.UserRepository.Get" {
+		t.Fatalf("expected the wrapped policy error, got %v", err)
+	}
+}
+
+func TestStatementListPolicy(t *testing.T) {
+	for _, c := range []struct {
+		policy  StatementListPolicy
+		name    string
+		allowed bool
+	}{
+		{StatementListPolicy{}, "main.UserRepository.Get", true},
+		{StatementListPolicy{Allow: []string{"main.UserRepository.*"}}, "main.UserRepository.Get", true},
+		{StatementListPolicy{Allow: []string{"main.*"}}, "main.UserRepository.Get", false},
+		{StatementListPolicy{Allow: []string{"main.UserRepository.Get"}}, "main.UserRepository.GetAll", false},
+		{StatementListPolicy{Allow: []string{"main.*.*"}, Deny: []string{"*.*.Delete*"}}, "main.UserRepository.DeleteAll", false},
+	} {
+		statement := &xmlSQLStatement{name: c.name}
+		if err := c.policy.Check(context.Background(), statement, "", nil); (err == nil) != c.allowed {
+			t.Errorf("%+v %s: expected allowed %v, got %v", c.policy, c.name, c.allowed, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"sync"
+)
+
+// QueryMany executes the same select statement with every param concurrently,
+// and returns the results aligned with the order of the params.
+// At most concurrency queries are running at the same time,
+// a concurrency less than or equal to zero means no limit.
+// The first error cancels the remaining queries and is returned.
+//
+// Example:
+//
+//	users, err := juice.QueryMany[[]User](ctx, engine, "main.UserRepository.FindByTenant", params, 4)
+func QueryMany[T any](ctx context.Context, manager Manager, v any, params []Param, concurrency int) ([]T, error) {
+	results := make([]T, len(params))
+	if len(params) == 0 {
+		return results, nil
+	}
+	if concurrency <= 0 || concurrency > len(params) {
+		concurrency = len(params)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		tokens   = make(chan struct{}, concurrency)
+	)
+	var scheduleErr error
+	for i, param := range params {
+		select {
+		case tokens <- struct{}{}:
+		case <-ctx.Done():
+		}
+		// stop scheduling the remaining queries once failed or canceled.
+		if scheduleErr = ctx.Err(); scheduleErr != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, param Param) {
+			defer func() {
+				<-tokens
+				wg.Done()
+			}()
+			// every goroutine gets its own executor, since the statement handlers are not goroutine safe.
+			executor := NewGenericManager[T](manager).Object(v)
+			result, err := executor.QueryContext(ctx, param)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = result
+		}(i, param)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	// the parent context may be canceled before all the queries are scheduled.
+	if scheduleErr != nil {
+		return nil, scheduleErr
+	}
+	return results, nil
+}
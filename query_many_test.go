@@ -0,0 +1,198 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+// echoConnector is a sql connector whose queries return their arguments as the rows of the value column.
+// The queries with the argument fail fail, and the queries wait for the gate to be closed if it is not nil.
+type echoConnector struct {
+	queries   *atomic.Int64
+	commits   *atomic.Int64
+	rollbacks *atomic.Int64
+	active    *atomic.Int64
+	peak      *atomic.Int64
+	entered   chan struct{}
+	gate      chan struct{}
+}
+
+func newEchoConnector() echoConnector {
+	return echoConnector{
+		queries:   new(atomic.Int64),
+		commits:   new(atomic.Int64),
+		rollbacks: new(atomic.Int64),
+		active:    new(atomic.Int64),
+		peak:      new(atomic.Int64),
+	}
+}
+
+func (c echoConnector) Connect(context.Context) (sqldriver.Conn, error) { return c, nil }
+
+func (c echoConnector) Driver() sqldriver.Driver { return nil }
+
+func (c echoConnector) Prepare(string) (sqldriver.Stmt, error) { return echoStmt(c), nil }
+
+func (c echoConnector) Close() error { return nil }
+
+func (c echoConnector) Begin() (sqldriver.Tx, error) { return c, nil }
+
+func (c echoConnector) Commit() error {
+	c.commits.Add(1)
+	return nil
+}
+
+func (c echoConnector) Rollback() error {
+	c.rollbacks.Add(1)
+	return nil
+}
+
+type echoStmt echoConnector
+
+func (s echoStmt) Close() error { return nil }
+
+func (s echoStmt) NumInput() int { return -1 }
+
+func (s echoStmt) Exec([]sqldriver.Value) (sqldriver.Result, error) {
+	return sqldriver.RowsAffected(1), nil
+}
+
+func (s echoStmt) Query(args []sqldriver.Value) (sqldriver.Rows, error) {
+	s.queries.Add(1)
+	active := s.active.Add(1)
+	defer s.active.Add(-1)
+	for peak := s.peak.Load(); active > peak && !s.peak.CompareAndSwap(peak, active); peak = s.peak.Load() {
+	}
+	if s.entered != nil {
+		s.entered <- struct{}{}
+	}
+	if s.gate != nil {
+		<-s.gate
+	}
+	for _, arg := range args {
+		if arg == "fail" {
+			return nil, errors.New("query failed")
+		}
+	}
+	return &echoRows{values: args}, nil
+}
+
+type echoRows struct{ values []sqldriver.Value }
+
+func (r *echoRows) Columns() []string { return []string{"value"} }
+
+func (r *echoRows) Close() error { return nil }
+
+func (r *echoRows) Next(dest []sqldriver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+// newEchoEngine returns an engine of the echo mapper querying the connector.
+func newEchoEngine(t *testing.T, connector sqldriver.Connector) *Engine {
+	mapper := `<mapper namespace="echo">
+    <select id="Echo">select #{value}</select>
+    <select id="EchoSecret" requires="secrets:read, audit">select #{value}</select>
+    <select id="EchoAll">select <foreach collection="values" item="value" separator=",">#{value}</foreach></select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("echo.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mappers Mappers
+	if err = mappers.setMapper(m.namespace, m); err != nil {
+		t.Fatal(err)
+	}
+	// the names of the statements are computed lazily, before they are queried concurrently.
+	for statement := range mappers.Statements() {
+		_ = statement.Name()
+	}
+	db := sql.OpenDB(connector)
+	t.Cleanup(func() { _ = db.Close() })
+	return &Engine{configuration: Configuration{mappers: &mappers}, db: db, driver: driver.MySQLDriver{}, rw: &NoOpRWMutex{}}
+}
+
+func TestQueryMany(t *testing.T) {
+	connector := newEchoConnector()
+	connector.entered, connector.gate = make(chan struct{}), make(chan struct{})
+	engine := newEchoEngine(t, connector)
+
+	params := []Param{H{"value": "a"}, H{"value": "b"}, H{"value": "c"}, H{"value": "d"}}
+	go func() {
+		// the queries are released after two of them are running.
+		<-connector.entered
+		<-connector.entered
+		close(connector.gate)
+		for range params[2:] {
+			<-connector.entered
+		}
+	}()
+	results, err := QueryMany[[]string](context.Background(), engine, "echo.Echo", params, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := [][]string{{"a"}, {"b"}, {"c"}, {"d"}}; !reflect.DeepEqual(results, expected) {
+		t.Fatalf("expected the results in the order of the params, got %v", results)
+	}
+	if peak := connector.peak.Load(); peak != 2 {
+		t.Fatalf("expected at most 2 concurrent queries, got %d", peak)
+	}
+}
+
+func TestQueryMany_Error(t *testing.T) {
+	connector := newEchoConnector()
+	engine := newEchoEngine(t, connector)
+
+	params := []Param{H{"value": "a"}, H{"value": "fail"}, H{"value": "c"}}
+	results, err := QueryMany[[]string](context.Background(), engine, "echo.Echo", params, 1)
+	if err == nil || !strings.Contains(err.Error(), "query failed") {
+		t.Fatalf("expected the query error, got %v", err)
+	}
+	if results != nil {
+		t.Fatalf("expected no results, got %v", results)
+	}
+	// the queries after the failed one are not scheduled.
+	if queries := connector.queries.Load(); queries != 2 {
+		t.Fatalf("expected 2 queries, got %d", queries)
+	}
+
+	results, err = QueryMany[[]string](context.Background(), engine, "echo.Echo", nil, 0)
+	if err != nil || len(results) != 0 {
+		t.Fatalf("expected empty results, got %v, %v", results, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = QueryMany[[]string](ctx, engine, "echo.Echo", params, 0); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
@@ -0,0 +1,372 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is an error that is returned when a statement exceeds its quota.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaStore counts the executions and the rows of the statements in the quota windows.
+// MemoryQuotaStore counts them in the process, implement it with an external store like redis
+// to share the quotas between the instances of a service.
+type QuotaStore interface {
+	// Add adds n to the counter of the key in the current window of the given length,
+	// and returns the count of the window. The counter starts over in every window.
+	Add(ctx context.Context, key string, window time.Duration, n int64) (int64, error)
+}
+
+// ensure MemoryQuotaStore implements QuotaStore.
+var _ QuotaStore = (*MemoryQuotaStore)(nil) // compile time check
+
+// MemoryQuotaStore is a QuotaStore counting in the memory of the process.
+// The counters of the ended windows are evicted, so that it does not grow with the keys used once.
+// The zero value is ready to use.
+type MemoryQuotaStore struct {
+	mu       sync.Mutex
+	counters map[string]*quotaCounter
+	// swept is when the counters of the ended windows are evicted last time.
+	swept time.Time
+	// now is for testing, time.Now is used if it is nil.
+	now func() time.Time
+}
+
+type quotaCounter struct {
+	start  time.Time
+	window time.Duration
+	count  int64
+}
+
+// quotaSweepInterval is how often the MemoryQuotaStore evicts the counters of the ended windows.
+const quotaSweepInterval = time.Minute
+
+// Add implements QuotaStore.
+func (s *MemoryQuotaStore) Add(_ context.Context, key string, window time.Duration, n int64) (int64, error) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	current := now()
+	start := current.Truncate(window)
+	key = key + "@" + window.String()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counters == nil {
+		s.counters = make(map[string]*quotaCounter)
+	}
+	if current.Sub(s.swept) >= quotaSweepInterval {
+		s.sweep(current)
+	}
+	counter, ok := s.counters[key]
+	if !ok || !counter.start.Equal(start) {
+		counter = &quotaCounter{start: start, window: window}
+		s.counters[key] = counter
+	}
+	counter.count += n
+	return counter.count, nil
+}
+
+// sweep evicts the counters whose windows are ended.
+func (s *MemoryQuotaStore) sweep(now time.Time) {
+	for key, counter := range s.counters {
+		if !now.Before(counter.start.Add(counter.window)) {
+			delete(s.counters, key)
+		}
+	}
+	s.swept = now
+}
+
+// Len returns the number of the counters.
+func (s *MemoryQuotaStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.counters)
+}
+
+// Quota is the limit of a statement in a window, like 1000/h.
+type Quota struct {
+	Limit  int64
+	Window time.Duration
+}
+
+// String returns the quota in the form of limit/window.
+func (q Quota) String() string {
+	return fmt.Sprintf("%d/%s", q.Limit, q.Window)
+}
+
+// quotaWindows are the abbreviations of the windows of the quotas.
+var quotaWindows = map[string]time.Duration{
+	"s":      time.Second,
+	"second": time.Second,
+	"m":      time.Minute,
+	"minute": time.Minute,
+	"h":      time.Hour,
+	"hour":   time.Hour,
+	"d":      24 * time.Hour,
+	"day":    24 * time.Hour,
+}
+
+// ParseQuota parses the quota in the form of limit/window, the window is one of
+// s, m, h, d (second, minute, hour, day) or a duration like 30m.
+//
+//	1000/h
+//	10000/day
+//	100/15m
+func ParseQuota(s string) (Quota, error) {
+	limit, window, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Quota{}, fmt.Errorf("invalid quota %q: missing window", s)
+	}
+	var quota Quota
+	var err error
+	if quota.Limit, err = strconv.ParseInt(strings.TrimSpace(limit), 10, 64); err != nil || quota.Limit < 0 {
+		return Quota{}, fmt.Errorf("invalid quota %q: invalid limit", s)
+	}
+	window = strings.ToLower(strings.TrimSpace(window))
+	if quota.Window = quotaWindows[window]; quota.Window == 0 {
+		if quota.Window, err = time.ParseDuration(window); err != nil || quota.Window <= 0 {
+			return Quota{}, fmt.Errorf("invalid quota %q: invalid window", s)
+		}
+	}
+	return quota, nil
+}
+
+// ensure QuotaMiddleware implements Middleware.
+var _ Middleware = (*QuotaMiddleware)(nil) // compile time check
+
+// QuotaMiddleware is a middleware that enforces the execution and row quotas of the statements,
+// to contain the costly statements, like the analytics ones, in the shared services.
+//
+//	<select id="Report" quota="100/h">
+//	    select ...
+//	</select>
+//
+//	<delete id="Purge" quota="24/d" rowQuota="100000/d">
+//	    delete from ...
+//	</delete>
+//
+// The quota attribute limits the executions of the statement in the window, and the rowQuota
+// attribute limits the rows affected by it, or the rows scanned from its results for the selects. They can be set for all the statements by the
+// quota and rowQuota settings, which are overridden by the attributes.
+//
+// The statement exceeded its quota is blocked with ErrQuotaExceeded, set the quotaAction
+// attribute or setting to warn to log it and let it run.
+type QuotaMiddleware struct {
+	// Store counts the executions and rows, a MemoryQuotaStore is used if it is nil.
+	Store QuotaStore
+
+	// OnExceeded is called with the statement and the error wrapping ErrQuotaExceeded,
+	// when a statement exceeds its quota, for alerting.
+	OnExceeded func(ctx context.Context, stmt Statement, err error)
+
+	once  sync.Once
+	store QuotaStore
+}
+
+// QueryContext implements Middleware.
+func (m *QuotaMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryHandler {
+	quota, hasQuota := m.quota(stmt, "quota")
+	rowQuota, hasRowQuota := m.quota(stmt, "rowQuota")
+	if !hasQuota && !hasRowQuota {
+		return next
+	}
+	rowsKey := stmt.Name() + ":rows"
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		if hasQuota {
+			if err := m.check(ctx, stmt, stmt.Name(), quota, 1); err != nil {
+				return nil, err
+			}
+		}
+		// the rows are known after they are scanned, block it if the rows are used up already.
+		if hasRowQuota {
+			if err := m.check(ctx, stmt, rowsKey, rowQuota, 0); err != nil {
+				return nil, err
+			}
+			onRowsScanned(ctx, func(rows int64) { m.addRows(ctx, stmt, rowsKey, rowQuota, rows) })
+		}
+		return next(ctx, query, args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *QuotaMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHandler {
+	quota, hasQuota := m.quota(stmt, "quota")
+	rowQuota, hasRowQuota := m.quota(stmt, "rowQuota")
+	if !hasQuota && !hasRowQuota {
+		return next
+	}
+	rowsKey := stmt.Name() + ":rows"
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		if hasQuota {
+			if err := m.check(ctx, stmt, stmt.Name(), quota, 1); err != nil {
+				return nil, err
+			}
+		}
+		// the rows are known after the execution, block it if the rows are used up already.
+		if hasRowQuota {
+			if err := m.check(ctx, stmt, rowsKey, rowQuota, 0); err != nil {
+				return nil, err
+			}
+		}
+		result, err := next(ctx, query, args...)
+		if err != nil || !hasRowQuota {
+			return result, err
+		}
+		if rowsAffected, err := result.RowsAffected(); err == nil {
+			m.addRows(ctx, stmt, rowsKey, rowQuota, rowsAffected)
+		}
+		return result, nil
+	}
+}
+
+// addRows counts the rows of the statement against its row quota.
+func (m *QuotaMiddleware) addRows(ctx context.Context, stmt Statement, rowsKey string, rowQuota Quota, rows int64) {
+	if rows <= 0 {
+		return
+	}
+	if _, err := m.getStore().Add(ctx, rowsKey, rowQuota.Window, rows); err != nil {
+		logger.Printf("failed to count the rows of the statement %s: %v", stmt.Name(), err)
+	}
+}
+
+// check counts n against the quota of the key, the statement is blocked when it is used up.
+func (m *QuotaMiddleware) check(ctx context.Context, stmt Statement, key string, quota Quota, n int64) error {
+	count, err := m.getStore().Add(ctx, key, quota.Window, n)
+	if err != nil {
+		return err
+	}
+	// the count before this one
+	if count-n < quota.Limit {
+		return nil
+	}
+	err = fmt.Errorf("%w: statement %s exceeded its quota %s", ErrQuotaExceeded, key, quota)
+	if m.OnExceeded != nil {
+		m.OnExceeded(ctx, stmt, err)
+	}
+	if quotaAction(stmt) == "warn" {
+		logger.Println(err)
+		return nil
+	}
+	return err
+}
+
+// quota returns the quota of the statement from the attribute or the setting with the name.
+// The quotas of the mappers are validated when they are parsed, see validateQuotaAttribute.
+func (m *QuotaMiddleware) quota(stmt Statement, name string) (Quota, bool) {
+	value := quotaValue(stmt, name)
+	if value == "" {
+		return Quota{}, false
+	}
+	quota, err := ParseQuota(value)
+	if err != nil {
+		logger.Printf("ignored the %s of the statement %s: %v", name, stmt.Name(), err)
+		return Quota{}, false
+	}
+	return quota, true
+}
+
+// quotaValue returns the quota of the statement from the attribute or the setting with the name, without parsing it.
+func quotaValue(stmt Statement, name string) string {
+	if value := stmt.Attribute(name); value != "" {
+		return value
+	}
+	if cfg := stmt.Configuration(); cfg != nil {
+		return cfg.Settings().Get(name).String()
+	}
+	return ""
+}
+
+func (m *QuotaMiddleware) getStore() QuotaStore {
+	m.once.Do(func() {
+		m.store = m.Store
+		if m.store == nil {
+			m.store = &MemoryQuotaStore{}
+		}
+	})
+	return m.store
+}
+
+// quotaAction returns the action of the statement exceeded its quota, block or warn.
+func quotaAction(stmt Statement) string {
+	if action := stmt.Attribute("quotaAction"); action != "" {
+		return action
+	}
+	if cfg := stmt.Configuration(); cfg != nil {
+		return cfg.Settings().Get("quotaAction").String()
+	}
+	return ""
+}
+
+// validateQuotaAttribute returns an error if the value is not a quota, see ParseQuota.
+func validateQuotaAttribute(value string) error {
+	_, err := ParseQuota(value)
+	return err
+}
+
+// validateQuotaActionAttribute returns an error if the value is neither block nor warn.
+func validateQuotaActionAttribute(value string) error {
+	if value != "block" && value != "warn" {
+		return fmt.Errorf("%q is neither block nor warn", value)
+	}
+	return nil
+}
+
+// scannedRowsKey is the context key of the scannedRows.
+type scannedRowsKey struct{}
+
+// scannedRows collects the callbacks which count the rows scanned from the results of a select,
+// since the rows are scanned after the middlewares return.
+type scannedRows struct {
+	callbacks []func(rows int64)
+}
+
+// withScannedRows returns a new context with the scannedRows if the statement has a row quota,
+// otherwise the context is returned with nil.
+func withScannedRows(ctx context.Context, stmt Statement) (context.Context, *scannedRows) {
+	if stmt == nil || quotaValue(stmt, "rowQuota") == "" {
+		return ctx, nil
+	}
+	scanned := &scannedRows{}
+	return context.WithValue(ctx, scannedRowsKey{}, scanned), scanned
+}
+
+// onRowsScanned registers the callback called with the rows scanned from the results of the select.
+func onRowsScanned(ctx context.Context, callback func(rows int64)) {
+	if scanned, ok := ctx.Value(scannedRowsKey{}).(*scannedRows); ok {
+		scanned.callbacks = append(scanned.callbacks, callback)
+	}
+}
+
+// done calls the callbacks with the rows scanned, it does nothing if the scannedRows is nil.
+func (s *scannedRows) done(rows int64) {
+	if s == nil {
+		return
+	}
+	for _, callback := range s.callbacks {
+		callback(rows)
+	}
+}
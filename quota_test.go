@@ -0,0 +1,187 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+func TestParseQuota(t *testing.T) {
+	tests := []struct {
+		quota string
+		want  Quota
+	}{
+		{"1000/h", Quota{Limit: 1000, Window: time.Hour}},
+		{"10 / day", Quota{Limit: 10, Window: 24 * time.Hour}},
+		{"5/15m", Quota{Limit: 5, Window: 15 * time.Minute}},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuota(tt.quota)
+		if err != nil {
+			t.Errorf("%s: %v", tt.quota, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.quota, tt.want, got)
+		}
+	}
+	for _, quota := range []string{"1000", "x/h", "10/week", "-1/h"} {
+		if _, err := ParseQuota(quota); err == nil {
+			t.Errorf("%s: expected an error", quota)
+		}
+	}
+}
+
+func TestMemoryQuotaStore(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC)
+	store := &MemoryQuotaStore{now: func() time.Time { return now }}
+	ctx := context.Background()
+	if count, _ := store.Add(ctx, "a", time.Hour, 2); count != 2 {
+		t.Fatalf("expected 2, got %d", count)
+	}
+	if count, _ := store.Add(ctx, "a", time.Hour, 1); count != 3 {
+		t.Fatalf("expected 3, got %d", count)
+	}
+	now = now.Add(time.Hour)
+	if count, _ := store.Add(ctx, "a", time.Hour, 1); count != 1 {
+		t.Fatalf("expected the counter to start over, got %d", count)
+	}
+	// the counter of the ended window is evicted.
+	_, _ = store.Add(ctx, "b", time.Second, 1)
+	now = now.Add(2 * time.Minute)
+	_, _ = store.Add(ctx, "a", time.Hour, 1)
+	if size := store.Len(); size != 1 {
+		t.Fatalf("expected the ended counter to be evicted, got %d counters", size)
+	}
+}
+
+func TestQuotaMiddleware(t *testing.T) {
+	mapper := `<mapper namespace="user">
+    <delete id="Purge" quota="2/h" rowQuota="5/h">
+        delete from user
+    </delete>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	stmt := m.statements["Purge"]
+
+	var exceeded int
+	middleware := &QuotaMiddleware{OnExceeded: func(context.Context, Statement, error) { exceeded++ }}
+	rows := int64(3)
+	handler := middleware.ExecContext(stmt, func(context.Context, string, ...any) (sql.Result, error) {
+		return sqldriver.RowsAffected(rows), nil
+	})
+	ctx := context.Background()
+	if _, err = handler(ctx, "delete from user"); err != nil {
+		t.Fatal(err)
+	}
+	// 3 rows of 5 are used, the rows of this one are counted after it.
+	if _, err = handler(ctx, "delete from user"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = handler(ctx, "delete from user"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if exceeded != 1 {
+		t.Fatalf("expected OnExceeded to be called once, got %d", exceeded)
+	}
+}
+
+func TestQuotaMiddleware_RowQuotaOfSelect(t *testing.T) {
+	mapper := `<mapper namespace="user">
+    <select id="Report" rowQuota="2/h">
+        select id from user
+    </select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mappers Mappers
+	if err = mappers.setMapper(m.namespace, m); err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(compositeConn{execs: new([]string), commits: new(int)})
+	t.Cleanup(func() { _ = db.Close() })
+	engine := &Engine{configuration: Configuration{mappers: &mappers}, db: db, driver: driver.MySQLDriver{}, rw: &NoOpRWMutex{}}
+	store := &MemoryQuotaStore{}
+	engine.Use(&QuotaMiddleware{Store: store})
+
+	ctx := context.Background()
+	// every query scans one row.
+	if _, err = NewGenericManager[[]int64](engine).Object("user.Report").QueryContext(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, err = range Iterate[int64](ctx, engine, "user.Report", nil) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count, _ := store.Add(ctx, "user.Report:rows", time.Hour, 0); count != 2 {
+		t.Fatalf("expected 2 rows scanned, got %d", count)
+	}
+	if _, err = NewGenericManager[[]int64](engine).Object("user.Report").QueryContext(ctx, nil); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+}
+
+func TestNewXMLConfigurationWithFS_Quotas(t *testing.T) {
+	configuration := `<configuration>
+    <environments default="prod">
+        <environment id="prod">
+            <dataSource>fake</dataSource>
+            <driver>fake</driver>
+        </environment>
+    </environments>
+    <settings>%s</settings>
+    <mappers>
+        <mapper namespace="user">%s</mapper>
+    </mappers>
+</configuration>`
+	for name, tc := range map[string][2]string{
+		"invalid quota":          {"", `<select id="Report" quota="100">select 1</select>`},
+		"invalid rowQuota":       {"", `<select id="Report" rowQuota="x/h">select 1</select>`},
+		"invalid quotaAction":    {"", `<select id="Report" quota="100/h" quotaAction="alert">select 1</select>`},
+		"invalid quota setting":  {`<setting name="quota" value="100/week"/>`, ""},
+		"invalid action setting": {`<setting name="quotaAction" value="ignore"/>`, ""},
+	} {
+		fsys := fstest.MapFS{"juice.xml": {Data: []byte(fmt.Sprintf(configuration, tc[0], tc[1]))}}
+		if _, err := NewXMLConfigurationWithFS(fsys, "juice.xml"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	valid := fmt.Sprintf(configuration, `<setting name="quotaAction" value="warn"/>`,
+		`<select id="Report" quota="100/h" rowQuota="10000/d" quotaAction="block">select 1</select>`)
+	if _, err := NewXMLConfigurationWithFS(fstest.MapFS{"juice.xml": {Data: []byte(valid)}}, "juice.xml"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-juicedev/juice/eval"
+)
+
+// redactedMask is the text printed instead of the redacted arguments.
+const redactedMask = "***"
+
+// RedactedArg is an argument whose value is masked when it is printed, like by the debug logs,
+// the traces or the recorders, but is passed to the database as it is.
+// The statement handlers unwrap the redacted arguments before the middlewares, so that the drivers
+// receive the values themselves, like the driver.Valuer, the []byte and the time.Time ones,
+// and the middlewares which log the arguments mask them by MaskArgs.
+//
+// The arguments of the parameters are redacted automatically when the struct field is tagged
+// with the redact option, or the parameter is listed in the redact attribute of the statement.
+//
+//	type User struct {
+//		Password string `param:"password,redact"`
+//	}
+//
+//	<insert id="CreateUser" redact="user.token">
+//	    insert into user (password, token) values (#{password}, #{user.token})
+//	</insert>
+type RedactedArg struct {
+	value any
+}
+
+// Redact returns the argument masked in the logs, it can be used as the parameter directly.
+func Redact(value any) RedactedArg {
+	return RedactedArg{value: value}
+}
+
+// Value implements driver.Valuer, it converts the value by the default parameter converter of database/sql.
+func (r RedactedArg) Value() (sqldriver.Value, error) {
+	return sqldriver.DefaultParameterConverter.ConvertValue(r.value)
+}
+
+// Unwrap returns the value of the argument.
+func (r RedactedArg) Unwrap() any {
+	return r.value
+}
+
+// String implements fmt.Stringer.
+func (r RedactedArg) String() string {
+	return redactedMask
+}
+
+// Format implements fmt.Formatter, the value is masked with every verb.
+func (r RedactedArg) Format(f fmt.State, _ rune) {
+	_, _ = f.Write([]byte(redactedMask))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r RedactedArg) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redactedMask + `"`), nil
+}
+
+// redactParameter is the parameter which reports the parameters listed in the redact attribute
+// of the statement are sensitive.
+type redactParameter struct {
+	Parameter
+	names []string
+}
+
+// Redacted implements eval.RedactedParameter.
+func (r redactParameter) Redacted(name string) bool {
+	return slices.Contains(r.names, name) || eval.IsRedacted(r.Parameter, name)
+}
+
+// Options implements eval.OptionsParameter by the wrapped parameter.
+func (r redactParameter) Options() eval.Options { return eval.OptionsOf(r.Parameter) }
+
+// withRedactAttribute wraps the parameter with the names of the redact attribute of the statement.
+func withRedactAttribute(parameter Parameter, attribute string) Parameter {
+	if attribute == "" {
+		return parameter
+	}
+	var names []string
+	for _, name := range strings.Split(attribute, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return redactParameter{Parameter: parameter, names: names}
+}
+
+// redactArg returns the argument of the named parameter, which is redacted if the parameter is sensitive.
+func redactArg(p Parameter, name string, arg any) any {
+	if eval.IsRedacted(p, name) {
+		if _, ok := arg.(RedactedArg); !ok {
+			return Redact(arg)
+		}
+	}
+	return arg
+}
+
+// redactedArgsKey is the context key of the positions of the redacted arguments.
+type redactedArgsKey struct{}
+
+// unredactArgs returns the arguments where the redacted ones are replaced by their values,
+// and the positions of the redacted ones. The arguments are returned as they are if none is redacted.
+func unredactArgs(args []any) ([]any, []int) {
+	var (
+		values   []any
+		redacted []int
+	)
+	for i, arg := range args {
+		value, ok := unredactArg(arg)
+		if !ok {
+			continue
+		}
+		if values == nil {
+			values = slices.Clone(args)
+		}
+		values[i] = value
+		redacted = append(redacted, i)
+	}
+	if values == nil {
+		return args, nil
+	}
+	return values, redacted
+}
+
+// unredactArg returns the value of the redacted argument, the named one included.
+func unredactArg(arg any) (any, bool) {
+	switch value := arg.(type) {
+	case RedactedArg:
+		return value.value, true
+	case sql.NamedArg:
+		if redacted, ok := value.Value.(RedactedArg); ok {
+			value.Value = redacted.value
+			return value, true
+		}
+	}
+	return arg, false
+}
+
+// withRedactedArgs returns a new context carrying the positions of the redacted arguments.
+func withRedactedArgs(ctx context.Context, redacted []int) context.Context {
+	if len(redacted) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, redactedArgsKey{}, redacted)
+}
+
+// MaskArgs returns the copy of the arguments to be printed, where the redacted ones are replaced by the mask,
+// they are the arguments redacted by the statement handler of the context and the RedactedArg ones.
+// The middlewares which log the arguments use it, like DebugMiddleware and MutationJournalMiddleware.
+func MaskArgs(ctx context.Context, args []any) []any {
+	redacted, _ := ctx.Value(redactedArgsKey{}).([]int)
+	masked := make([]any, len(args))
+	for i, arg := range args {
+		if _, ok := unredactArg(arg); ok || slices.Contains(redacted, i) {
+			arg = maskArg(arg)
+		}
+		masked[i] = arg
+	}
+	return masked
+}
+
+// maskArg returns the mask of the argument, the named one keeps its name.
+func maskArg(arg any) any {
+	if named, ok := arg.(sql.NamedArg); ok {
+		named.Value = redactedMask
+		return named
+	}
+	return redactedMask
+}
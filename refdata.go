@@ -0,0 +1,228 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-juicedev/juice/internal/reflectlite"
+)
+
+// ErrRefDataKeyNotSet is returned by RefData.Get when the refdataKey attribute of the statement is not set.
+var ErrRefDataKeyNotSet = errors.New("refdataKey attribute of the statement is not set")
+
+// RefData is a refresh-ahead cache of a small reference table, like the countries or the currencies.
+// The full result set of the statement is cached in memory, and it is refreshed in the background
+// when it is older than the refdata attribute of the statement, without blocking the lookups.
+//
+//	<select id="Currencies" refdata="5m" refdataKey="code">
+//	    select code, name, symbol from currency
+//	</select>
+//
+//	currencies, err := juice.NewRefData[Currency](engine, "main.CurrencyMapper.Currencies")
+//	usd, ok, err := currencies.Get(ctx, "USD")
+//
+// The rows are indexed by the refdataKey column, which is matched with the column tag of the struct
+// fields, or the keys of the map rows. It is safe for concurrent use.
+type RefData[T any] struct {
+	manager   Manager
+	statement Statement
+	ttl       time.Duration
+	key       string
+
+	mu       sync.RWMutex
+	loaded   bool
+	rows     []T
+	index    map[any]int
+	loadedAt time.Time
+
+	// loading is the lock of the first load, refreshing is set while the background refresh is running.
+	loading    sync.Mutex
+	refreshing atomic.Bool
+
+	// now and refreshed are for testing, refreshed is called after the background refresh is done.
+	now       func() time.Time
+	refreshed func()
+}
+
+// NewRefData returns the RefData of the statement of v, whose refdata attribute is the
+// duration of the refresh, like 5m. The rows are loaded on the first use.
+func NewRefData[T any](manager Manager, v any) (*RefData[T], error) {
+	statement := manager.Object(v).Statement()
+	if statement == nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoStatementFound, v)
+	}
+	if statement.Action() != Select {
+		return nil, fmt.Errorf("refdata of statement %s: not a select statement", statement.Name())
+	}
+	ttl, err := time.ParseDuration(statement.Attribute("refdata"))
+	if err != nil || ttl <= 0 {
+		return nil, fmt.Errorf("refdata of statement %s: invalid refdata attribute %q", statement.Name(), statement.Attribute("refdata"))
+	}
+	return &RefData[T]{
+		manager:   manager,
+		statement: statement,
+		ttl:       ttl,
+		key:       statement.Attribute("refdataKey"),
+		now:       time.Now,
+	}, nil
+}
+
+// All returns all the cached rows, which must not be modified.
+func (r *RefData[T]) All(ctx context.Context) ([]T, error) {
+	if err := r.ensure(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rows, nil
+}
+
+// Get returns the row whose refdataKey column equals the key.
+// The numeric keys are matched regardless of their types, like int and int64.
+func (r *RefData[T]) Get(ctx context.Context, key any) (value T, ok bool, err error) {
+	if r.key == "" {
+		return value, false, ErrRefDataKeyNotSet
+	}
+	if err = r.ensure(ctx); err != nil {
+		return value, false, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	index, ok := r.index[refDataKeyOf(reflect.ValueOf(key))]
+	if !ok {
+		return value, false, nil
+	}
+	return r.rows[index], true, nil
+}
+
+// Refresh reloads the rows from the database.
+func (r *RefData[T]) Refresh(ctx context.Context) error {
+	rows, err := NewGenericManager[[]T](r.manager).Object(r.statement.Name()).QueryContext(ctx, nil)
+	if err != nil {
+		return err
+	}
+	index := make(map[any]int, len(rows))
+	if r.key != "" {
+		for i := range rows {
+			key, ok := refDataColumnOf(reflect.ValueOf(&rows[i]).Elem(), r.key)
+			if !ok {
+				return fmt.Errorf("refdata of statement %s: column %s not found in %T", r.statement.Name(), r.key, rows[i])
+			}
+			// the first row wins on the duplicate keys.
+			if _, exists := index[key]; !exists {
+				index[key] = i
+			}
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rows, r.index, r.loadedAt, r.loaded = rows, index, r.now(), true
+	return nil
+}
+
+// ensure loads the rows on the first use, and refreshes them in the background when they are expired.
+func (r *RefData[T]) ensure(ctx context.Context) error {
+	r.mu.RLock()
+	loaded, loadedAt := r.loaded, r.loadedAt
+	r.mu.RUnlock()
+	if !loaded {
+		r.loading.Lock()
+		defer r.loading.Unlock()
+		r.mu.RLock()
+		loaded = r.loaded
+		r.mu.RUnlock()
+		if loaded {
+			return nil
+		}
+		return r.Refresh(ctx)
+	}
+	if r.now().Sub(loadedAt) >= r.ttl && r.refreshing.CompareAndSwap(false, true) {
+		// the refresh outlives the request which triggers it.
+		ctx = context.WithoutCancel(ctx)
+		go func() {
+			if r.refreshed != nil {
+				defer r.refreshed()
+			}
+			defer r.refreshing.Store(false)
+			if err := r.Refresh(ctx); err != nil {
+				logger.Printf("failed to refresh the refdata of statement %s: %v", r.statement.Name(), err)
+			}
+		}()
+	}
+	return nil
+}
+
+// refDataColumnOf returns the key of the column of the row, which is a struct or a map.
+func refDataColumnOf(row reflect.Value, column string) (any, bool) {
+	row = reflectlite.Unwrap(row)
+	switch row.Kind() {
+	case reflect.Struct:
+		indexes, ok := reflectlite.TypeFrom(row.Type()).GetFieldIndexesFromTag("column", column)
+		if !ok {
+			// the field without the column tag is matched by its name.
+			field, ok := row.Type().FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, column) })
+			if !ok {
+				return nil, false
+			}
+			indexes = field.Index
+		}
+		return refDataKeyOf(row.FieldByIndex(indexes)), true
+	case reflect.Map:
+		if row.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		value := row.MapIndex(reflect.ValueOf(column).Convert(row.Type().Key()))
+		if !value.IsValid() {
+			return nil, false
+		}
+		return refDataKeyOf(value), true
+	default:
+		return nil, false
+	}
+}
+
+// refDataKeyOf normalizes the key, the integers are int64 or uint64, and the bytes are string.
+func refDataKeyOf(value reflect.Value) any {
+	value = reflectlite.Unwrap(value)
+	switch {
+	case !value.IsValid():
+		return nil
+	case value.CanInt():
+		return value.Int()
+	case value.CanUint():
+		if value.Uint() <= 1<<63-1 {
+			return int64(value.Uint())
+		}
+		return value.Uint()
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+		return string(value.Bytes())
+	case value.Kind() == reflect.String:
+		return value.String()
+	case value.Comparable():
+		return value.Interface()
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+func TestRefData(t *testing.T) {
+	mapper := `<mapper namespace="ref">
+    <select id="Users" refdata="50ms" refdataKey="id">select id from user</select>
+    <select id="Plain">select id from user</select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("ref.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mappers Mappers
+	if err = mappers.setMapper(m.namespace, m); err != nil {
+		t.Fatal(err)
+	}
+	var queries atomic.Int64
+	db := sql.OpenDB(compositeConn{execs: new([]string), commits: new(int), queries: &queries})
+	t.Cleanup(func() { _ = db.Close() })
+	engine := &Engine{configuration: Configuration{mappers: &mappers}, db: db, driver: driver.MySQLDriver{}, rw: &NoOpRWMutex{}}
+
+	if _, err = NewRefData[map[string]any](engine, "ref.Plain"); err == nil {
+		t.Fatal("expected an error without the refdata attribute")
+	}
+	type user struct {
+		ID int64 `column:"id"`
+	}
+	users, err := NewRefData[user](engine, "ref.Users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	refreshed := make(chan struct{})
+	users.now = func() time.Time { return now }
+	users.refreshed = func() { refreshed <- struct{}{} }
+	ctx := context.Background()
+	for range 3 {
+		value, ok, err := users.Get(ctx, 42)
+		if err != nil || !ok || value.ID != 42 {
+			t.Fatalf("unexpected lookup: %v %v %v", value, ok, err)
+		}
+	}
+	if _, ok, _ := users.Get(ctx, "missing"); ok {
+		t.Fatal("expected the missing key not found")
+	}
+	if queries.Load() != 1 {
+		t.Fatalf("expected one query, got %d", queries.Load())
+	}
+
+	// the rows are not refreshed before they are expired.
+	now = now.Add(49 * time.Millisecond)
+	if _, ok, _ := users.Get(ctx, 42); !ok || users.refreshing.Load() {
+		t.Fatal("expected the rows to be served without a refresh")
+	}
+
+	// the expired rows are served while they are refreshed in the background.
+	now = now.Add(time.Millisecond)
+	if _, ok, _ := users.Get(ctx, int32(42)); !ok {
+		t.Fatal("expected the expired rows to be served")
+	}
+	<-refreshed
+	if queries.Load() != 2 {
+		t.Fatalf("expected the background refresh, got %d queries", queries.Load())
+	}
+	// the refreshed rows are fresh again.
+	if _, ok, _ := users.Get(ctx, 42); !ok || users.refreshing.Load() || queries.Load() != 2 {
+		t.Fatalf("expected the refreshed rows to be served, got %d queries", queries.Load())
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/go-juicedev/juice/internal/reflectlite"
+)
+
+// MaxResultCapacityHint caps the capacity hints of the slice results,
+// so that an outlier does not make the later results allocate too much.
+const MaxResultCapacityHint = 4096
+
+// rowsAverageWeight is the weight of the latest rows in the moving average.
+const rowsAverageWeight = 0.2
+
+// rowsAverage is the exponentially weighted moving average of the rows returned by a statement.
+type rowsAverage struct {
+	bits atomic.Uint64 // math.Float64bits of the average, 0 if nothing recorded
+}
+
+// add adds the rows of an execution to the average.
+func (a *rowsAverage) add(rows int) {
+	for {
+		old := a.bits.Load()
+		average := float64(rows)
+		if old != 0 {
+			previous := math.Float64frombits(old)
+			average = previous + rowsAverageWeight*(average-previous)
+		}
+		// 0 is reserved for nothing recorded, the empty results keep a tiny average instead.
+		bits := max(math.Float64bits(average), 1)
+		if a.bits.CompareAndSwap(old, bits) {
+			return
+		}
+	}
+}
+
+// value returns the average.
+func (a *rowsAverage) value() float64 {
+	return math.Float64frombits(a.bits.Load())
+}
+
+// resultCapacity returns the capacity hint of the slice results of the statement, 0 means no hint.
+// The statement attribute "resultCapacity" takes precedence over the moving average of the rows
+// returned by the previous executions, which is turned off by the setting resultCapacityHints="false".
+func resultCapacity(statement Statement) int {
+	// the attribute is validated when it is parsed.
+	if attr := statement.Attribute("resultCapacity"); attr != "" {
+		capacity, _ := strconv.Atoi(attr)
+		return min(capacity, MaxResultCapacityHint)
+	}
+	average := statementRowsAverage(statement)
+	if average == nil {
+		return 0
+	}
+	return min(int(math.Ceil(average.value())), MaxResultCapacityHint)
+}
+
+// recordResultRows records the length of the slice result into the moving average of the statement.
+func recordResultRows(statement Statement, result any) {
+	value := reflectlite.Unwrap(reflect.ValueOf(result))
+	if value.Kind() != reflect.Slice {
+		return
+	}
+	if average := statementRowsAverage(statement); average != nil {
+		average.add(value.Len())
+	}
+}
+
+// resultRows returns the number of the rows mapped to the result, which is the length of the slices,
+// or one for the other results.
+func resultRows(result any) int64 {
+	if value := reflectlite.Unwrap(reflect.ValueOf(result)); value.Kind() == reflect.Slice {
+		return int64(value.Len())
+	}
+	return 1
+}
+
+// statementRowsAverage returns the moving average of the statement,
+// or nil if the hints are turned off or the statement does not keep it.
+func statementRowsAverage(statement Statement) *rowsAverage {
+	xmlStatement, ok := statement.(*xmlSQLStatement)
+	if !ok {
+		return nil
+	}
+	if cfg := statement.Configuration(); cfg != nil {
+		if value := cfg.Settings().Get("resultCapacityHints"); value != "" && !value.Bool() {
+			return nil
+		}
+	}
+	return &xmlStatement.rows
+}
+
+// defaultResultMap returns the ResultMap of the statements without a result map,
+// nil means bindWithResultMap picks the default one.
+func defaultResultMap[T any](statement Statement) ResultMap {
+	fold := foldColumnNamesEnabled(statement)
+	if reflectlite.IndirectType(reflect.TypeFor[T]()).Kind() == reflect.Slice {
+		if capacity := resultCapacity(statement); capacity > 0 || fold {
+			return MultiRowsResultMap{FoldColumnNames: fold, Capacity: capacity}
+		}
+	}
+	if fold {
+		return foldColumnsResultMap{}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+func TestResultCapacityHints(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="List">select id, name from user</select>
+    <select id="Fixed" resultCapacity="100">select id, name from user</select>
+</mapper>`
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.mappers = &Mappers{}
+	statement := m.statements["List"]
+	if resultCapacity(statement) != 0 {
+		t.Fatal("expected no hint before the first execution")
+	}
+	backend := &memoryBackend{rows: &memoryRows{
+		columns: []string{"id", "name"},
+		values:  [][]any{{int64(1), "eat"}, {int64(2), "more"}, {int64(3), "apple"}},
+	}}
+	executor := NewBackendExecutor[[]backendUser](statement, driver.MySQLDriver{}, backend)
+	for range 3 {
+		backend.rows.index = 0
+		if _, err = executor.QueryContext(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if capacity := resultCapacity(statement); capacity != 3 {
+		t.Fatalf("expected the capacity 3, got %d", capacity)
+	}
+	if resultMap, ok := defaultResultMap[[]backendUser](statement).(MultiRowsResultMap); !ok || resultMap.Capacity != 3 {
+		t.Fatalf("unexpected result map: %#v", resultMap)
+	}
+	// the single row results are not hinted.
+	if resultMap := defaultResultMap[backendUser](statement); resultMap != nil {
+		t.Fatalf("unexpected result map: %#v", resultMap)
+	}
+	// the empty results lower the average.
+	statement.rows.add(0)
+	if capacity := resultCapacity(statement); capacity != 3 {
+		t.Fatalf("expected the capacity 3, got %d", capacity)
+	}
+
+	if capacity := resultCapacity(m.statements["Fixed"]); capacity != 100 {
+		t.Fatalf("expected the attribute capacity, got %d", capacity)
+	}
+	m.mappers.cfg = &Configuration{settings: keyValueSettingProvider{"resultCapacityHints": "false"}}
+	if capacity := resultCapacity(statement); capacity != 0 {
+		t.Fatalf("expected no hint when it is turned off, got %d", capacity)
+	}
+}
+
+func TestResultCapacity_InvalidAttribute(t *testing.T) {
+	for _, value := range []string{"0", "-1", "many"} {
+		mapper := `<mapper namespace="main.User"><select id="List" resultCapacity="` + value + `">select id from user</select></mapper>`
+		parser := &XMLMappersElementParser{parser: &XMLParser{}}
+		if _, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper)); err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+}
+
+func BenchmarkMultiRowsResultMap_Capacity(b *testing.B) {
+	rows := &memoryRows{columns: []string{"id", "name"}, values: make([][]any, 1000)}
+	for i := range rows.values {
+		rows.values[i] = []any{int64(i), "eat"}
+	}
+	for _, capacity := range []int{0, len(rows.values)} {
+		b.Run(fmt.Sprintf("capacity=%d", capacity), func(b *testing.B) {
+			resultMap := MultiRowsResultMap{Capacity: capacity}
+			b.ReportAllocs()
+			for range b.N {
+				rows.index = 0
+				var users []backendUser
+				if err := resultMap.MapTo(reflect.ValueOf(&users), rows); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
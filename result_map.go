@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"reflect"
 	"slices"
+	"strings"
 )
 
 // ErrTooManyRows is returned when the result set has too many rows but excepted only one row.
@@ -31,16 +32,20 @@ var ErrTooManyRows = errors.New("juice: too many rows in result set")
 // ResultMap is an interface that defines a method for mapping database query results to Go data structures.
 type ResultMap interface {
 	// MapTo maps the data from the SQL row to the provided reflect.Value.
-	MapTo(rv reflect.Value, row *sql.Rows) error
+	MapTo(rv reflect.Value, row Rows) error
 }
 
 // SingleRowResultMap is a ResultMap that maps a rowDestination to a non-slice type.
-type SingleRowResultMap struct{}
+type SingleRowResultMap struct {
+	// FoldColumnNames matches columns to `column` tags case-insensitively.
+	// See foldColumnName for the details.
+	FoldColumnNames bool
+}
 
 // MapTo implements ResultMapper interface.
 // It maps the data from the SQL row to the provided reflect.Value.
 // If more than one row is returned from the query, it returns an ErrTooManyRows error.
-func (SingleRowResultMap) MapTo(rv reflect.Value, rows *sql.Rows) error {
+func (m SingleRowResultMap) MapTo(rv reflect.Value, rows Rows) error {
 	// Validate input is a pointer
 	if rv.Kind() != reflect.Ptr {
 		return ErrPointerRequired
@@ -64,7 +69,7 @@ func (SingleRowResultMap) MapTo(rv reflect.Value, rows *sql.Rows) error {
 	targetValue := reflect.Indirect(rv)
 
 	// Create destination mapper
-	columnDest := &rowDestination{}
+	columnDest := &rowDestination{foldColumns: m.FoldColumnNames}
 
 	// Map columns to struct fields and create scan destinations
 	dest, err := columnDest.Destination(targetValue, columns)
@@ -93,13 +98,32 @@ func (SingleRowResultMap) MapTo(rv reflect.Value, rows *sql.Rows) error {
 // MultiRowsResultMap is a ResultMap that maps a rowDestination to a slice type.
 type MultiRowsResultMap struct {
 	New func() reflect.Value
+
+	// FoldColumnNames matches columns to `column` tags case-insensitively.
+	// See foldColumnName for the details.
+	FoldColumnNames bool
+
+	// Capacity is the expected number of the rows, which pre-allocates the result.
+	// The default capacity is used if it is not positive.
+	Capacity int
+}
+
+// defaultRowsCapacity is the initial capacity of the rows without a Capacity.
+const defaultRowsCapacity = 8
+
+// capacity returns the initial capacity of the rows.
+func (m MultiRowsResultMap) capacity() int {
+	if m.Capacity > 0 {
+		return m.Capacity
+	}
+	return defaultRowsCapacity
 }
 
 // MapTo implements ResultMapper interface.
 // It maps the data from the SQL rows to the provided reflect.Value.
 // The reflect.Value must be a pointer to a slice.
 // Each row will be mapped to a new element in the slice.
-func (m MultiRowsResultMap) MapTo(rv reflect.Value, rows *sql.Rows) error {
+func (m MultiRowsResultMap) MapTo(rv reflect.Value, rows Rows) error {
 	if err := m.validateInput(rv); err != nil {
 		return err
 	}
@@ -116,18 +140,17 @@ func (m MultiRowsResultMap) MapTo(rv reflect.Value, rows *sql.Rows) error {
 		m.New = func() reflect.Value { return reflect.New(targetElementType) }
 	}
 
-	// map the rows to values
-	values, err := m.mapRows(rows, isPointer, isElementImplementsScanner)
+	target := rv.Elem()
+	// map the rows to a new slice of the capacity, which is the result if the target is empty.
+	values, err := m.mapRows(rows, reflect.MakeSlice(target.Type(), 0, m.capacity()), isPointer, isElementImplementsScanner)
 	if err != nil {
 		return err
 	}
-	target := rv.Elem()
-
-	// Since we've already verified the type compatibility above,
-	// we can safely grow the slice without additional type checks.
-	target.Grow(len(values))
-
-	target.Set(reflect.Append(target, values...))
+	if target.Len() == 0 {
+		target.Set(values)
+		return nil
+	}
+	target.Set(reflect.AppendSlice(target, values))
 	return nil
 }
 
@@ -152,50 +175,46 @@ func (m MultiRowsResultMap) resolveTypes(elementType reflect.Type) (bool, bool)
 	return isPointer, pointerType.Implements(rowScannerType)
 }
 
-// mapRows maps the rows to a slice of reflect.Values
-func (m MultiRowsResultMap) mapRows(rows *sql.Rows, isPointer bool, useScanner bool) ([]reflect.Value, error) {
+// mapRows maps the rows by appending them to the values, which is a slice of the elements,
+// and returns the appended slice.
+func (m MultiRowsResultMap) mapRows(rows Rows, values reflect.Value, isPointer bool, useScanner bool) (reflect.Value, error) {
 	if useScanner {
-		return m.mapWithRowScanner(rows, isPointer)
+		return m.mapWithRowScanner(rows, values, isPointer)
 	}
-	return m.mapWithColumnDestination(rows, isPointer)
+	return m.mapWithColumnDestination(rows, values, isPointer)
 }
 
 // mapWithRowScanner maps rows using the RowScanner interface
-func (m MultiRowsResultMap) mapWithRowScanner(rows *sql.Rows, isPointer bool) ([]reflect.Value, error) {
-	// Pre-allocate slice with an initial capacity
-	values := make([]reflect.Value, 0, 8)
-
+func (m MultiRowsResultMap) mapWithRowScanner(rows Rows, values reflect.Value, isPointer bool) (reflect.Value, error) {
 	for rows.Next() {
 		// Create a new instance. Since RowScanner is implemented with pointer receiver,
 		// we always create a pointer type and use it directly for scanning
 		newValue := m.New()
-		if err := newValue.Interface().(RowScanner).ScanRows(rows); err != nil {
-			return nil, fmt.Errorf("failed to scan row using RowScanner: %w", err)
+		if err := scanRows(newValue.Interface().(RowScanner), rows); err != nil {
+			return reflect.Value{}, fmt.Errorf("failed to scan row using RowScanner: %w", err)
 		}
 
 		if isPointer {
-			values = append(values, newValue)
+			values = reflect.Append(values, newValue)
 		} else {
-			values = append(values, newValue.Elem())
+			values = reflect.Append(values, newValue.Elem())
 		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error occurred while iterating rows: %w", err)
+		return reflect.Value{}, fmt.Errorf("error occurred while iterating rows: %w", err)
 	}
 
 	return values, nil
 }
 
 // mapWithColumnDestination maps rows using column destination
-func (m MultiRowsResultMap) mapWithColumnDestination(rows *sql.Rows, isPointer bool) ([]reflect.Value, error) {
+func (m MultiRowsResultMap) mapWithColumnDestination(rows Rows, values reflect.Value, isPointer bool) (reflect.Value, error) {
 	columns, err := rows.Columns()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get columns: %w", err)
+		return reflect.Value{}, fmt.Errorf("failed to get columns: %w", err)
 	}
-	columnDest := &rowDestination{}
-	// Pre-allocate slice with an initial capacity
-	values := make([]reflect.Value, 0, 8)
+	columnDest := &rowDestination{foldColumns: m.FoldColumnNames}
 
 	for rows.Next() {
 		// Create a new instance and get its underlying value for column mapping
@@ -205,24 +224,24 @@ func (m MultiRowsResultMap) mapWithColumnDestination(rows *sql.Rows, isPointer b
 		// Map database columns to struct fields and create scan destinations
 		dest, err := columnDest.Destination(elementValue, columns)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get destination: %w", err)
+			return reflect.Value{}, fmt.Errorf("failed to get destination: %w", err)
 		}
 
 		// Scan the current row into the destinations
 		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return reflect.Value{}, fmt.Errorf("failed to scan row: %w", err)
 		}
 
 		// Append either the pointer or the value based on the target type
 		if isPointer {
-			values = append(values, newValue)
+			values = reflect.Append(values, newValue)
 		} else {
-			values = append(values, elementValue)
+			values = reflect.Append(values, elementValue)
 		}
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error occurred while iterating rows: %w", err)
+		return reflect.Value{}, fmt.Errorf("error occurred while iterating rows: %w", err)
 	}
 
 	return values, nil
@@ -270,6 +289,19 @@ type rowDestination struct {
 	// Before each use, it is reset (e.g., using clear or manually setting elements to nil)
 	// to ensure no stale pointers are left from previous scans.
 	dest []any
+
+	// foldColumns makes column matching case-insensitive, see foldColumnName.
+	foldColumns bool
+
+	// sentinels are the NULL sentinels of the columns declared by the nullValue option of the column tags,
+	// the columns without the sentinels are the invalid values.
+	sentinels []reflect.Value
+
+	// scanners scan the columns with the sentinels, they are reused across the rows.
+	scanners []nullSentinelScanner
+
+	// err is the error of the sentinels.
+	err error
 }
 
 func (s *rowDestination) resetDest() {
@@ -319,15 +351,22 @@ func (s *rowDestination) destinationForStruct(rv reflect.Value, columns []string
 	if len(s.indexes) == 0 {
 		s.setIndexes(rv, columns)
 	}
+	if s.err != nil {
+		return nil, s.err
+	}
 	if s.dest == nil {
 		s.dest = make([]any, len(columns))
 	} else {
 		s.resetDest()
 	}
 	for i, indexes := range s.indexes {
-		if len(indexes) == 0 {
+		switch {
+		case len(indexes) == 0:
 			s.dest[i] = &sink
-		} else {
+		case s.sentinels != nil && s.sentinels[i].IsValid():
+			s.scanners[i] = nullSentinelScanner{field: rv.FieldByIndex(indexes), sentinel: s.sentinels[i]}
+			s.dest[i] = &s.scanners[i]
+		default:
 			s.dest[i] = rv.FieldByIndex(indexes).Addr().Interface()
 		}
 	}
@@ -342,46 +381,101 @@ func (s *rowDestination) setIndexes(rv reflect.Value, columns []string) {
 	// columnIndex is a map to store the index of the column.
 	columnIndex := make(map[string]int, len(columns))
 	for i, column := range columns {
-		columnIndex[column] = i
+		if s.foldColumns {
+			column = foldColumnName(column)
+		}
+		// keep the first one when the folded names collide.
+		if _, exists := columnIndex[column]; !exists {
+			columnIndex[column] = i
+		}
 	}
 
 	// walk into the struct
-	s.findFromStruct(tp, columns, columnIndex, nil)
+	s.findFromStruct(tp, columnIndex)
 }
 
-// findFromStruct finds the index from the given struct type.
-func (s *rowDestination) findFromStruct(tp reflect.Type, columns []string, columnIndex map[string]int, walk []int) {
+// findFromStruct finds the indexes of the columns from the column fields of the given struct type.
+func (s *rowDestination) findFromStruct(tp reflect.Type, columnIndex map[string]int) {
 
 	// finished is a helper function to check if the indexes completed or not.
 	finished := func() bool {
 		return slices.IndexFunc(s.indexes, func(v []int) bool { return len(v) == 0 }) == -1
 	}
 
-	// walk into the struct
-	for i := 0; i < tp.NumField(); i++ {
+	for _, field := range columnFieldsOf(tp) {
 		// if we find all the columns destination, we can stop.
 		if finished() {
 			break
 		}
-		field := tp.Field(i)
-		tag := field.Tag.Get("column")
-		// if the tag is empty or "-", we can skip it.
-		if skip := tag == "" && !field.Anonymous || tag == "-"; skip {
-			continue
-		}
-		// if the field is anonymous and the type is struct, we can walk into it.
-		if deepScan := field.Anonymous && field.Type.Kind() == reflect.Struct && len(tag) == 0; deepScan {
-			s.findFromStruct(field.Type, columns, columnIndex, append(walk, i))
-			continue
+		column := field.column
+		if s.foldColumns {
+			column = foldColumnName(column)
 		}
 		// find the index of the column
-		index, ok := columnIndex[tag]
+		index, ok := columnIndex[column]
 		if !ok {
 			continue
 		}
 		// set the index
-		s.indexes[index] = append(walk, field.Index...)
+		s.indexes[index] = field.index
+		if field.hasNullValue {
+			s.setSentinel(index, field.field, field.nullValue)
+		}
+	}
+}
+
+// setSentinel sets the NULL sentinel of the column from the nullValue option of the field.
+func (s *rowDestination) setSentinel(index int, field reflect.StructField, nullValue string) {
+	sentinel, err := parseNullSentinel(field.Type, nullValue)
+	if err != nil {
+		s.err = fmt.Errorf("field %s: %w", field.Name, err)
+		return
+	}
+	if s.sentinels == nil {
+		s.sentinels = make([]reflect.Value, len(s.indexes))
+		s.scanners = make([]nullSentinelScanner, len(s.indexes))
+	}
+	s.sentinels[index] = sentinel
+}
+
+// foldColumnName normalizes a column name for case-insensitive matching.
+// Databases fold unquoted identifiers differently: Postgres reports them in
+// lower case while Oracle reports them in upper case, so the same mapper and
+// struct only work across both if the case is ignored. Quoting characters
+// ("name", `name` and [name]) are stripped before folding, as a quoted
+// identifier names the same column once the dialect's quoting is removed.
+func foldColumnName(name string) string {
+	if n := len(name); n >= 2 {
+		switch first, last := name[0], name[n-1]; {
+		case first == '"' && last == '"', first == '`' && last == '`', first == '[' && last == ']':
+			name = name[1 : n-1]
+		}
+	}
+	return strings.ToLower(name)
+}
+
+// foldColumnsResultMap is the default ResultMap used when the foldColumnNames
+// option is enabled. Like bindWithResultMap, it picks MultiRowsResultMap for
+// slices and SingleRowResultMap for everything else.
+type foldColumnsResultMap struct{}
+
+// MapTo implements ResultMap interface.
+func (foldColumnsResultMap) MapTo(rv reflect.Value, rows Rows) error {
+	if kd := reflect.Indirect(rv).Kind(); kd == reflect.Slice {
+		return MultiRowsResultMap{FoldColumnNames: true}.MapTo(rv, rows)
+	}
+	return SingleRowResultMap{FoldColumnNames: true}.MapTo(rv, rows)
+}
+
+// foldColumnNamesEnabled reports whether the statement matches result columns
+// case-insensitively. The statement attribute "foldColumnNames" takes precedence
+// over the setting with the same name.
+func foldColumnNamesEnabled(statement Statement) bool {
+	if attr := statement.Attribute("foldColumnNames"); attr != "" {
+		return StringValue(attr).Bool()
 	}
+	cfg := statement.Configuration()
+	return cfg != nil && cfg.Settings().Get("foldColumnNames").Bool()
 }
 
 var errRawBytesScan = errors.New("sql: RawBytes isn't allowed on scan")
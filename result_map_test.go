@@ -0,0 +1,154 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRowDestination_FoldColumns(t *testing.T) {
+	type User struct {
+		ID   int64  `column:"id"`
+		Name string `column:"user_name"`
+	}
+	columns := []string{"ID", `"USER_NAME"`}
+
+	var user User
+	dest, err := (&rowDestination{}).Destination(reflect.ValueOf(&user).Elem(), columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dest[0] != &sink || dest[1] != &sink {
+		t.Fatal("expected columns to be unmapped without folding")
+	}
+
+	dest, err = (&rowDestination{foldColumns: true}).Destination(reflect.ValueOf(&user).Elem(), columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dest[0] != &user.ID || dest[1] != &user.Name {
+		t.Fatal("expected folded columns to be mapped")
+	}
+}
+
+type discriminatedAnimal interface{ Sound() string }
+
+type discriminatedDog struct {
+	ID   int64  `column:"id"`
+	Name string `column:"name"`
+}
+
+func (d *discriminatedDog) Sound() string { return "woof" }
+
+type discriminatedCat struct {
+	ID int64 `column:"id"`
+}
+
+func (c *discriminatedCat) Sound() string { return "meow" }
+
+type discriminatedUnion struct {
+	Kind  string
+	Value any
+}
+
+func (u *discriminatedUnion) SetVariant(key string, value any) error {
+	u.Kind, u.Value = key, value
+	return nil
+}
+
+func TestDiscriminatorResultMap(t *testing.T) {
+	newRows := func() *memoryRows {
+		return &memoryRows{
+			columns: []string{"id", "KIND", "name"},
+			values:  [][]any{{int64(1), "dog", "rex"}, {int64(2), "cat", "tom"}},
+		}
+	}
+	resultMap := DiscriminatorResultMap{
+		Column: "kind",
+		Cases: map[string]func() any{
+			"dog": func() any { return new(discriminatedDog) },
+			"cat": func() any { return new(discriminatedCat) },
+		},
+		FoldColumnNames: true,
+	}
+	animals, err := BindWithResultMap[[]discriminatedAnimal](newRows(), resultMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animals) != 2 || animals[0].(*discriminatedDog).Name != "rex" || animals[1].(*discriminatedCat).ID != 2 {
+		t.Fatalf("unexpected animals: %+v", animals)
+	}
+
+	unions, err := BindWithResultMap[[]discriminatedUnion](newRows(), resultMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unions) != 2 || unions[0].Kind != "dog" || unions[1].Value.(*discriminatedCat).ID != 2 {
+		t.Fatalf("unexpected unions: %+v", unions)
+	}
+
+	delete(resultMap.Cases, "cat")
+	if _, err = BindWithResultMap[[]discriminatedAnimal](newRows(), resultMap); !errors.Is(err, ErrUnknownDiscriminator) {
+		t.Fatalf("expected ErrUnknownDiscriminator, got %v", err)
+	}
+	resultMap.Default = func() any { return new(discriminatedCat) }
+	rows := newRows()
+	rows.values = rows.values[1:]
+	animal, err := BindWithResultMap[discriminatedAnimal](rows, resultMap)
+	if err != nil || animal.Sound() != "meow" {
+		t.Fatalf("unexpected animal: %v %v", animal, err)
+	}
+}
+
+func TestRowDestination_NullSentinel(t *testing.T) {
+	RegisterNullSentinel("epoch", time.Unix(0, 0).UTC())
+	type User struct {
+		ID        int64     `column:"id"`
+		Age       int       `column:"age,nullValue=-1"`
+		Name      string    `column:"name,nullValue=unknown"`
+		DeletedAt time.Time `column:"deleted_at,nullValue=@epoch"`
+	}
+	deletedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := &memoryRows{
+		columns: []string{"id", "age", "name", "deleted_at"},
+		values: [][]any{
+			{int64(1), nil, nil, nil},
+			{int64(2), int64(30), []byte("juice"), deletedAt},
+		},
+	}
+	users, err := BindWithResultMap[[]User](rows, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users[0].Age != -1 || users[0].Name != "unknown" || !users[0].DeletedAt.Equal(time.Unix(0, 0)) {
+		t.Fatalf("expected the sentinels, got %+v", users[0])
+	}
+	if users[1].Age != 30 || users[1].Name != "juice" || !users[1].DeletedAt.Equal(deletedAt) {
+		t.Fatalf("unexpected user: %+v", users[1])
+	}
+
+	type Invalid struct {
+		Age int `column:"age,nullValue=none"`
+	}
+	rows = &memoryRows{columns: []string{"id", "age"}, values: [][]any{{int64(1), nil}}}
+	if _, err = BindWithResultMap[[]Invalid](rows, nil); err == nil {
+		t.Fatal("expected the invalid sentinel error")
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RewriteRule rewrites the sql built by a statement before it is sent to the database.
+type RewriteRule struct {
+	// Name identifies the rule, it is used by ContextWithRewriteRules to enable the rule.
+	Name string
+
+	// Rewrite returns the rewritten query.
+	Rewrite func(query string) string
+
+	// Always applies the rule to every query, no matter it is enabled by the context or not.
+	Always bool
+}
+
+// RegexpRewriteRule returns a RewriteRule which replaces the matches of the pattern with the replacement.
+// The replacement supports the same expansion as regexp.Regexp.ReplaceAllString, for example:
+//
+//	// force the parallel hint for the batch jobs.
+//	RegexpRewriteRule("parallel", regexp.MustCompile(`(?i)^\s*SELECT\b`), "SELECT /*+ parallel */")
+func RegexpRewriteRule(name string, pattern *regexp.Regexp, replacement string) RewriteRule {
+	return RewriteRule{
+		Name:    name,
+		Rewrite: func(query string) string { return pattern.ReplaceAllString(query, replacement) },
+	}
+}
+
+// TokenRewriteRule returns a RewriteRule which replaces the old token with the new one.
+// Unlike a plain text replacement, the token is matched case-insensitively on word boundaries,
+// and never inside string literals, quoted identifiers or comments, for example:
+//
+//	// use a fixed timestamp in tests.
+//	TokenRewriteRule("fixedNow", "now()", "'2025-01-01 00:00:00'")
+func TokenRewriteRule(name, old, new string) RewriteRule {
+	return RewriteRule{
+		Name:    name,
+		Rewrite: func(query string) string { return replaceToken(query, old, new) },
+	}
+}
+
+// replaceToken replaces the old token with the new one outside the quotes and comments.
+func replaceToken(query, old, new string) string {
+	if old == "" {
+		return query
+	}
+	builder := getStringBuilder()
+	defer putStringBuilder(builder)
+	for index := 0; index < len(query); {
+		// skip the quotes and comments as a whole.
+		if end := skipQuotedOrComment(query, index); end > index {
+			builder.WriteString(query[index:end])
+			index = end
+			continue
+		}
+		if matchToken(query, index, old) {
+			builder.WriteString(new)
+			index += len(old)
+			continue
+		}
+		builder.WriteByte(query[index])
+		index++
+	}
+	return builder.String()
+}
+
+// skipQuotedOrComment returns the end of the quote or comment starting at the index,
+// or the index itself if there is none.
+func skipQuotedOrComment(query string, index int) int {
+	switch rest := query[index:]; {
+	case rest[0] == '\'' || rest[0] == '"' || rest[0] == '`':
+		// doubled quotes are the escapes of the quote, which are covered by scanning
+		// them as two adjacent quoted sections.
+		if end := strings.IndexByte(rest[1:], rest[0]); end >= 0 {
+			return index + end + 2
+		}
+		return len(query)
+	case strings.HasPrefix(rest, "--"):
+		if end := strings.IndexByte(rest, '\n'); end >= 0 {
+			return index + end
+		}
+		return len(query)
+	case strings.HasPrefix(rest, "/*"):
+		if end := strings.Index(rest[2:], "*/"); end >= 0 {
+			return index + end + 4
+		}
+		return len(query)
+	}
+	return index
+}
+
+// matchToken reports whether the token appears at the index on the word boundaries.
+func matchToken(query string, index int, token string) bool {
+	if len(query)-index < len(token) || !strings.EqualFold(query[index:index+len(token)], token) {
+		return false
+	}
+	isWord := func(r rune) bool { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+	if first, _ := utf8.DecodeRuneInString(token); isWord(first) && index > 0 {
+		if before, _ := utf8.DecodeLastRuneInString(query[:index]); isWord(before) {
+			return false
+		}
+	}
+	if last, _ := utf8.DecodeLastRuneInString(token); isWord(last) && index+len(token) < len(query) {
+		if after, _ := utf8.DecodeRuneInString(query[index+len(token):]); isWord(after) {
+			return false
+		}
+	}
+	return true
+}
+
+// rewriteRulesKey is the context key of the enabled rewrite rules.
+type rewriteRulesKey struct{}
+
+// ContextWithRewriteRules returns a new context which enables the rewrite rules with the given names.
+// Rules already enabled in the context stay enabled.
+func ContextWithRewriteRules(ctx context.Context, names ...string) context.Context {
+	enabled := slices.Concat(RewriteRulesFromContext(ctx), names)
+	return context.WithValue(ctx, rewriteRulesKey{}, enabled)
+}
+
+// RewriteRulesFromContext returns the names of the rewrite rules enabled in the context.
+func RewriteRulesFromContext(ctx context.Context) []string {
+	names, _ := ctx.Value(rewriteRulesKey{}).([]string)
+	return names
+}
+
+// ensure RewriteMiddleware implements Middleware.
+var _ Middleware = (*RewriteMiddleware)(nil) // compile time check
+
+// RewriteMiddleware is a middleware that applies the rewrite rules to the built sql.
+// The rules are applied in order when they are marked as Always, or enabled by
+// ContextWithRewriteRules for the current query.
+//
+// It can be turned off for a statement by setting the rewrite attribute to false.
+type RewriteMiddleware struct {
+	Rules []RewriteRule
+}
+
+// Register appends the rules to the middleware.
+func (m *RewriteMiddleware) Register(rules ...RewriteRule) {
+	m.Rules = append(m.Rules, rules...)
+}
+
+// QueryContext implements Middleware.
+func (m *RewriteMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryHandler {
+	if !m.enabled(stmt) {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		return next(ctx, m.rewrite(ctx, query), args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *RewriteMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHandler {
+	if !m.enabled(stmt) {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return next(ctx, m.rewrite(ctx, query), args...)
+	}
+}
+
+// enabled returns false if there is no rule or the rewrite is disabled by the statement.
+func (m *RewriteMiddleware) enabled(stmt Statement) bool {
+	return len(m.Rules) > 0 && stmt.Attribute("rewrite") != "false"
+}
+
+// rewrite applies the rules enabled for the context to the query.
+func (m *RewriteMiddleware) rewrite(ctx context.Context, query string) string {
+	enabled := RewriteRulesFromContext(ctx)
+	for _, rule := range m.Rules {
+		if rule.Rewrite == nil {
+			continue
+		}
+		if rule.Always || slices.Contains(enabled, rule.Name) {
+			query = rule.Rewrite(query)
+		}
+	}
+	return query
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRewriteMiddleware_Rewrite(t *testing.T) {
+	middleware := &RewriteMiddleware{}
+	middleware.Register(TokenRewriteRule("fixedNow", "now()", "'2025-01-01'"))
+
+	query := "SELECT NOW(), 'now()' /* now() */, snow() FROM t WHERE a = now()"
+
+	if got := middleware.rewrite(context.Background(), query); got != query {
+		t.Fatalf("expected the rule to be disabled, got %s", got)
+	}
+	ctx := ContextWithRewriteRules(context.Background(), "fixedNow")
+	want := "SELECT '2025-01-01', 'now()' /* now() */, snow() FROM t WHERE a = '2025-01-01'"
+	if got := middleware.rewrite(ctx, query); got != want {
+		t.Fatalf("expected %s, got %s", want, got)
+	}
+}
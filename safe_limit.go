@@ -0,0 +1,151 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/go-juicedev/juice/driver"
+)
+
+// ensure SafeLimitMiddleware implements Middleware.
+var _ Middleware = (*SafeLimitMiddleware)(nil) // compile time check
+
+// SafeLimitMiddleware is a middleware that appends a limit clause to the select statements
+// which have no limit, to protect the shared staging databases from runaway queries.
+// Every select statement got the limit is logged with its statement id, so they can be
+// fixed before going to production.
+//
+// It is meant for the non-production environments only, it is turned on by setting the
+// middleware's Limit or the safeLimit setting to a positive number:
+//
+//	<settings>
+//	    <setting name="safeLimit" value="1000"/>
+//	</settings>
+//
+// The safeLimit attribute of a statement overrides the limit, setting it to 0 turns off the
+// middleware for the statement, for example the statements which are expected to be unbounded.
+type SafeLimitMiddleware struct {
+	// Limit is the limit appended to the unbounded select statements.
+	// The safeLimit setting is used if it is not positive.
+	Limit int64
+}
+
+// QueryContext implements Middleware.
+func (m *SafeLimitMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryHandler {
+	if stmt.Action() != Select {
+		return next
+	}
+	limit := m.limit(stmt)
+	if limit <= 0 {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		if hasRowLimit(query) {
+			return next(ctx, query, args...)
+		}
+		drv, err := driver.FromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		query = appendLimitClause(query, driver.LimitClause(drv, limit))
+		logger.Printf("safe limit %d applied to the unbounded statement %s", limit, stmt.Name())
+		return next(ctx, query, args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *SafeLimitMiddleware) ExecContext(_ Statement, next ExecHandler) ExecHandler {
+	return next
+}
+
+// limit returns the limit for the statement, the attribute takes precedence.
+func (m *SafeLimitMiddleware) limit(stmt Statement) int64 {
+	if attr := stmt.Attribute("safeLimit"); attr != "" {
+		limit, _ := strconv.ParseInt(attr, 10, 64)
+		return limit
+	}
+	if m.Limit > 0 {
+		return m.Limit
+	}
+	if cfg := stmt.Configuration(); cfg != nil {
+		return cfg.Settings().Get("safeLimit").Int64()
+	}
+	return 0
+}
+
+// rowLimitKeywords are the keywords restricting the rows of a select statement.
+var rowLimitKeywords = []string{"LIMIT", "FETCH", "TOP", "ROWNUM"}
+
+// hasRowLimit reports whether the query restricts its rows at the top level.
+// The keywords inside the quotes, the comments and the sub queries are ignored.
+func hasRowLimit(query string) bool {
+	found := false
+	walkTopLevel(query, func(index int) bool {
+		for _, keyword := range rowLimitKeywords {
+			if matchToken(query, index, keyword) {
+				found = true
+				return false
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// appendLimitClause appends the limit clause to the query, before the locking clause if any.
+func appendLimitClause(query, clause string) string {
+	query = strings.TrimRight(query, "; \t\r\n")
+	position := len(query)
+	walkTopLevel(query, func(index int) bool {
+		if matchToken(query, index, "FOR") {
+			position = index
+			return false
+		}
+		return true
+	})
+	if position == len(query) {
+		return query + " " + clause
+	}
+	return strings.TrimRight(query[:position], " \t\r\n") + " " + clause + " " + query[position:]
+}
+
+// walkTopLevel calls fn with the index of each byte of the query which is not in the quotes,
+// the comments or the parentheses, until fn returns false.
+func walkTopLevel(query string, fn func(index int) bool) {
+	var depth int
+	for index := 0; index < len(query); {
+		if end := skipQuotedOrComment(query, index); end > index {
+			index = end
+			continue
+		}
+		switch query[index] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && !fn(index) {
+				return
+			}
+		}
+		index++
+	}
+}
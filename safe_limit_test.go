@@ -0,0 +1,40 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import "testing"
+
+func TestAppendLimitClause(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT * FROM user;", "SELECT * FROM user LIMIT 10"},
+		{"SELECT * FROM user WHERE id IN (SELECT id FROM t LIMIT 1)", "SELECT * FROM user WHERE id IN (SELECT id FROM t LIMIT 1) LIMIT 10"},
+		{"SELECT * FROM user WHERE name = 'limit' FOR UPDATE", "SELECT * FROM user WHERE name = 'limit' LIMIT 10 FOR UPDATE"},
+		{"SELECT * FROM user LIMIT 5", "SELECT * FROM user LIMIT 5"},
+	}
+	for _, tt := range tests {
+		got := tt.query
+		if !hasRowLimit(got) {
+			got = appendLimitClause(got, "LIMIT 10")
+		}
+		if got != tt.want {
+			t.Errorf("expected %q, got %q", tt.want, got)
+		}
+	}
+}
@@ -18,9 +18,16 @@ package juice
 
 import (
 	"database/sql"
+	"errors"
 	"reflect"
+
+	"github.com/go-juicedev/juice/session"
 )
 
+// Rows is the result set consumed by the binder, *sql.Rows implements it.
+// See session.Rows for the backends beyond database/sql.
+type Rows = session.Rows
+
 // RowScanner is an interface that provides a custom mechanism for mapping database rows
 // to Go structures. It serves as an extension point in the data binding system,
 // allowing implementers to override the default reflection-based mapping behavior.
@@ -44,9 +51,22 @@ import (
 //
 // The implementation must ensure proper handling of NULL values and return
 // appropriate errors if the scanning process fails.
+// RowScanner only works with *sql.Rows, binding it with the rows of the other backends fails.
 type RowScanner interface {
 	ScanRows(rows *sql.Rows) error
 }
 
+// errRowScannerRequiresSQLRows is returned when a RowScanner is bound to the rows which are not *sql.Rows.
+var errRowScannerRequiresSQLRows = errors.New("juice: RowScanner requires *sql.Rows")
+
+// scanRows delegates the scanning to the RowScanner, which only accepts *sql.Rows.
+func scanRows(scanner RowScanner, rows Rows) error {
+	sqlRows, ok := rows.(*sql.Rows)
+	if !ok {
+		return errRowScannerRequiresSQLRows
+	}
+	return scanner.ScanRows(sqlRows)
+}
+
 // rowScannerType is the type of the RowScanner interface
 var rowScannerType = reflect.TypeOf((*RowScanner)(nil)).Elem()
@@ -0,0 +1,124 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/go-juicedev/juice/eval"
+)
+
+// SchemaParamName is the name of the built-in substitution of the schema, like ${schema}.
+const SchemaParamName = "schema"
+
+// schemaRegexp matches the schema names, which may be qualified by the catalog, like sales or tenant_1.sales.
+var schemaRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// schemaKey is the context key of the schema.
+type schemaKey struct{}
+
+// ContextWithSchema returns a new context carrying the schema substituted for ${schema},
+// like the schema of the tenant of the request.
+//
+//	<select id="List">select * from ${schema}.user</select>
+//
+//	ctx = juice.ContextWithSchema(ctx, "tenant_42")
+//	// select * from tenant_42.user
+//
+// The schema of the context takes precedence over the schema attribute of the environment,
+// and both of them take precedence over the parameter named schema.
+func ContextWithSchema(ctx context.Context, schema string) context.Context {
+	return context.WithValue(ctx, schemaKey{}, schema)
+}
+
+// SchemaFromContext returns the schema set by ContextWithSchema.
+func SchemaFromContext(ctx context.Context) (string, bool) {
+	schema, ok := ctx.Value(schemaKey{}).(string)
+	return schema, ok
+}
+
+// environmentSchemaKey is the context key of the schema of the environment executing the statement.
+type environmentSchemaKey struct{}
+
+// statementSchema returns the schema of the statement built with the context, it is the schema of
+// the context, or the schema attribute of the environment in use, like <environment id="prod" schema="sales">.
+// The environment in use is the one of the engine executing the statement, see environmentSchemaHandler,
+// or the default environment if the statement is built without an engine, like by BuildWithReport.
+func statementSchema(ctx context.Context, statement Statement) string {
+	if schema, ok := SchemaFromContext(ctx); ok {
+		return schema
+	}
+	if schema, ok := ctx.Value(environmentSchemaKey{}).(string); ok {
+		return schema
+	}
+	cfg := statement.Configuration()
+	if cfg == nil {
+		return ""
+	}
+	return environmentSchema(cfg, "")
+}
+
+// environmentSchema returns the schema attribute of the environment, the empty id means the default one.
+func environmentSchema(cfg IConfiguration, id string) string {
+	envs := cfg.Environments()
+	if envs == nil {
+		return ""
+	}
+	if id == "" {
+		id = envs.Attribute("default")
+	}
+	env, err := envs.Use(id)
+	if err != nil {
+		return ""
+	}
+	return env.Attr("schema")
+}
+
+// environmentSchemaHandler is the statement handler which builds the statements with the schema
+// of the environment of the engine executing them.
+type environmentSchemaHandler struct {
+	StatementHandler
+	schema string
+}
+
+// QueryContext implements StatementHandler.
+func (h environmentSchemaHandler) QueryContext(ctx context.Context, statement Statement, param Param) (*sql.Rows, error) {
+	return h.StatementHandler.QueryContext(context.WithValue(ctx, environmentSchemaKey{}, h.schema), statement, param)
+}
+
+// ExecContext implements StatementHandler.
+func (h environmentSchemaHandler) ExecContext(ctx context.Context, statement Statement, param Param) (sql.Result, error) {
+	return h.StatementHandler.ExecContext(context.WithValue(ctx, environmentSchemaKey{}, h.schema), statement, param)
+}
+
+// withSchema returns the parameter whose ${schema} is the schema, unless the schema is empty.
+// The schema is substituted into the query as it is, so only the identifiers are allowed.
+func withSchema(param eval.Parameter, schema string) (eval.Parameter, error) {
+	if schema == "" {
+		return param, nil
+	}
+	if !schemaRegexp.MatchString(schema) {
+		return nil, fmt.Errorf("invalid schema %q", schema)
+	}
+	variable := eval.NewItemParameter(SchemaParamName, "", false)
+	variable.Bind(reflect.ValueOf(schema), reflect.Value{})
+	return eval.ParamGroup{variable, param}, nil
+}
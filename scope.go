@@ -46,8 +46,21 @@ func WithReadOnly(readOnly bool) TransactionOptionFunc {
 	}
 }
 
+// newTxOptions returns the transaction options set by the given functions.
+// It returns nil if no function is given, which means the default options of the database.
+func newTxOptions(opts ...TransactionOptionFunc) *sql.TxOptions {
+	if len(opts) == 0 {
+		return nil
+	}
+	options := new(sql.TxOptions)
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
 // Transaction executes a transaction with the given handler.
-// If the manager is not an instance of Engine, it will return ErrInvalidManager.
+// If the manager is not an instance of Engine or ConnManager, it will return ErrInvalidManager.
 // If the handler returns an error, the transaction will be rolled back.
 // Otherwise, the transaction will be committed.
 // The ctx must should be created by ContextWithManager.
@@ -63,21 +76,17 @@ func WithReadOnly(readOnly bool) TransactionOptionFunc {
 //			// handle error
 //		}
 func Transaction(ctx context.Context, handler func(ctx context.Context) error, opts ...TransactionOptionFunc) (err error) {
-	manager := ManagerFromContext(ctx)
-	engine, ok := manager.(*Engine)
-	if !ok {
-		return ErrInvalidManager
-	}
+	var tx *BasicTxManager
 
-	var options *sql.TxOptions
-	if len(opts) > 0 {
-		options = new(sql.TxOptions)
-		for _, opt := range opts {
-			opt(options)
-		}
-	}
 	// create a new transaction
-	tx := engine.ContextTx(ctx, options)
+	switch manager := ManagerFromContext(ctx).(type) {
+	case *Engine:
+		tx = manager.ContextTx(ctx, newTxOptions(opts...))
+	case *ConnManager:
+		tx = manager.ContextTx(ctx, newTxOptions(opts...))
+	default:
+		return ErrInvalidManager
+	}
 
 	if err = tx.Begin(); err != nil {
 		return err
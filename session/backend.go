@@ -0,0 +1,80 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import "context"
+
+// Rows is the minimal result set of a query, which is consumed by the binder.
+// *sql.Rows implements it, and the backends beyond database/sql can implement it
+// for their native result sets. The values passed to Scan are the same destinations
+// as database/sql uses, like the pointers to the basic types and the sql.Scanner implementations.
+type Rows interface {
+	// Columns returns the column names.
+	Columns() ([]string, error)
+	// Next prepares the next row for reading with Scan, it returns false if there is no more row.
+	Next() bool
+	// Scan copies the columns of the current row into dest.
+	Scan(dest ...any) error
+	// Close closes the rows.
+	Close() error
+	// Err returns the error encountered during the iteration.
+	Err() error
+}
+
+// Result is the result of an executed statement, sql.Result implements it.
+type Result interface {
+	// LastInsertId returns the id generated by the database in response to the statement.
+	LastInsertId() (int64, error)
+	// RowsAffected returns the number of rows affected by the statement.
+	RowsAffected() (int64, error)
+}
+
+// Backend executes the queries without database/sql, like the native pgx pool,
+// the ClickHouse native protocol or an HTTP SQL gateway.
+// The queries are built by the driver of the engine, so the placeholders of the
+// driver must be understood by the backend.
+type Backend interface {
+	// QueryContext executes the query and returns the rows.
+	QueryContext(ctx context.Context, query string, args ...any) (Rows, error)
+	// ExecContext executes a query without returning any rows.
+	ExecContext(ctx context.Context, query string, args ...any) (Result, error)
+}
+
+// sessionBackend adapts the Session to Backend.
+type sessionBackend struct {
+	session Session
+}
+
+// QueryContext implements Backend.
+func (s sessionBackend) QueryContext(ctx context.Context, query string, args ...any) (Rows, error) {
+	rows, err := s.session.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ExecContext implements Backend.
+func (s sessionBackend) ExecContext(ctx context.Context, query string, args ...any) (Result, error) {
+	return s.session.ExecContext(ctx, query, args...)
+}
+
+// AsBackend returns the Backend which executes the queries by the Session,
+// like the *sql.DB or the *sql.Tx.
+func AsBackend(session Session) Backend {
+	return sessionBackend{session: session}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package session
+
+import (
+	"errors"
+	"sync"
+)
+
+// AfterCommitter is implemented by the transaction sessions
+// which can run functions after the transaction is committed successfully.
+type AfterCommitter interface {
+	// AfterCommit registers a function which will be called after the transaction is committed.
+	// The registered functions are discarded if the transaction is rolled back.
+	AfterCommit(fn func())
+}
+
+// AfterRollbacker is implemented by the transaction sessions
+// which can run functions after the transaction is rolled back.
+type AfterRollbacker interface {
+	// AfterRollback registers a function which will be called after the transaction is rolled back.
+	// The registered functions are discarded if the transaction is committed.
+	AfterRollback(fn func())
+}
+
+// AfterCommitFailer is implemented by the transaction sessions
+// which can run functions after the commit of the transaction fails.
+type AfterCommitFailer interface {
+	// AfterCommitFailure registers a function which will be called with the error after the commit fails.
+	// The registered functions are discarded if the transaction is committed or rolled back.
+	AfterCommitFailure(fn func(err error))
+}
+
+// BeforeEnder is implemented by the transaction sessions which can run functions before the transaction
+// is committed or rolled back, while its connection is still held, like resetting the variables of the connection.
+type BeforeEnder interface {
+	// BeforeEnd registers a function which will be called before the transaction is committed or rolled back.
+	// The transaction is rolled back instead of committed if the function returns an error.
+	BeforeEnd(fn func() error)
+}
+
+// hookedTransactionSession is a TransactionSession which implements AfterCommitter, AfterRollbacker,
+// AfterCommitFailer and BeforeEnder.
+type hookedTransactionSession struct {
+	TransactionSession
+	mu            sync.Mutex
+	hooks         []func()
+	rollbackHooks []func()
+	failureHooks  []func(err error)
+	endHooks      []func() error
+}
+
+// AfterCommitFailure implements AfterCommitFailer.
+func (t *hookedTransactionSession) AfterCommitFailure(fn func(err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failureHooks = append(t.failureHooks, fn)
+}
+
+// BeforeEnd implements BeforeEnder.
+func (t *hookedTransactionSession) BeforeEnd(fn func() error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.endHooks = append(t.endHooks, fn)
+}
+
+// runEndHooks calls the functions registered by BeforeEnd in order and resets them,
+// it returns the first error of them.
+func (t *hookedTransactionSession) runEndHooks() error {
+	t.mu.Lock()
+	endHooks := t.endHooks
+	t.endHooks = nil
+	t.mu.Unlock()
+	var firstErr error
+	for _, hook := range endHooks {
+		if err := hook(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// AfterCommit implements AfterCommitter.
+func (t *hookedTransactionSession) AfterCommit(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.hooks = append(t.hooks, fn)
+}
+
+// AfterRollback implements AfterRollbacker.
+func (t *hookedTransactionSession) AfterRollback(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollbackHooks = append(t.rollbackHooks, fn)
+}
+
+// Commit commits the transaction and calls the registered functions in order.
+// The transaction is rolled back instead if a function registered by BeforeEnd fails,
+// and the functions registered by AfterRollback are called.
+// If the commit fails, the functions registered by AfterCommitFailure are called with the error,
+// and the other ones are discarded, since Rollback is not required after the failed commit.
+func (t *hookedTransactionSession) Commit() error {
+	if err := t.runEndHooks(); err != nil {
+		// release the connection of the transaction, the end hooks have been run already.
+		return errors.Join(err, t.Rollback())
+	}
+	if err := t.TransactionSession.Commit(); err != nil {
+		_, _, failureHooks := t.takeHooks()
+		for _, hook := range failureHooks {
+			hook(err)
+		}
+		return err
+	}
+	hooks, _, _ := t.takeHooks()
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+// Rollback rollbacks the transaction, discards the functions registered by AfterCommit
+// and calls the ones registered by AfterRollback in order.
+func (t *hookedTransactionSession) Rollback() error {
+	// the transaction is rolled back even if the functions registered by BeforeEnd fail.
+	_ = t.runEndHooks()
+	_, rollbackHooks, _ := t.takeHooks()
+	err := t.TransactionSession.Rollback()
+	for _, hook := range rollbackHooks {
+		hook()
+	}
+	return err
+}
+
+// takeHooks returns the registered functions and resets them.
+func (t *hookedTransactionSession) takeHooks() (hooks, rollbackHooks []func(), failureHooks []func(err error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	hooks, rollbackHooks, failureHooks = t.hooks, t.rollbackHooks, t.failureHooks
+	t.hooks, t.rollbackHooks, t.failureHooks = nil, nil, nil
+	return hooks, rollbackHooks, failureHooks
+}
+
+// WithAfterCommit wraps the TransactionSession to make it implement AfterCommitter, AfterRollbacker,
+// AfterCommitFailer and BeforeEnder.
+func WithAfterCommit(tx TransactionSession) TransactionSession {
+	if _, ok := tx.(AfterCommitter); ok {
+		return tx
+	}
+	return &hookedTransactionSession{TransactionSession: tx}
+}
@@ -39,4 +39,7 @@ var (
 
 	// ensure that the sql.Tx implements the Session interface.
 	_ Session = (*sql.Tx)(nil)
+
+	// ensure that the sql.Conn implements the Session interface.
+	_ Session = (*sql.Conn)(nil)
 )
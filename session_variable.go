@@ -0,0 +1,202 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/session"
+)
+
+// sessionVariablesKey is the context key of the session variables.
+type sessionVariablesKey struct{}
+
+// ContextWithSessionVariables returns a new context carrying the session variables,
+// like the id of the current user. Variables already in the context are kept unless overwritten.
+func ContextWithSessionVariables(ctx context.Context, variables map[string]string) context.Context {
+	merged := make(map[string]string, len(variables))
+	for name, value := range SessionVariablesFromContext(ctx) {
+		merged[name] = value
+	}
+	for name, value := range variables {
+		merged[name] = value
+	}
+	return context.WithValue(ctx, sessionVariablesKey{}, merged)
+}
+
+// SessionVariablesFromContext returns the session variables from the context.
+func SessionVariablesFromContext(ctx context.Context) map[string]string {
+	variables, _ := ctx.Value(sessionVariablesKey{}).(map[string]string)
+	return variables
+}
+
+// ensure SessionVariablesMiddleware implements Middleware.
+var _ Middleware = (*SessionVariablesMiddleware)(nil) // compile time check
+
+// SessionVariablesMiddleware is a middleware that sets the session variables from the context
+// before the statement is executed, so that the row level security policies and the audit triggers
+// of the database can see the identity of the application user.
+//
+// For example, with the postgres driver:
+//
+//	ctx = juice.ContextWithSessionVariables(ctx, map[string]string{"app.user_id": "42"})
+//	// SELECT set_config('app.user_id', '42', true) is executed before the statements in the transaction.
+//
+// The variables are only set when the statement is executed in a transaction,
+// since the statements outside a transaction may be executed on different connections of the pool.
+// The variables of the drivers implementing driver.SessionVariableResetter, like the user-defined
+// variables of MySQL, are reset before the transaction ends, so that they never leak to the next user
+// of the connection.
+type SessionVariablesMiddleware struct {
+	// Variables returns extra variables from the context.
+	// Variables returned by it are merged with the variables set by ContextWithSessionVariables.
+	Variables func(ctx context.Context) map[string]string
+
+	mu sync.Mutex
+	// scoped are the names of the variables to reset by the transactions.
+	scoped map[session.Session]map[string]struct{}
+}
+
+// QueryContext implements Middleware.
+func (m *SessionVariablesMiddleware) QueryContext(_ Statement, next QueryHandler) QueryHandler {
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		if err := m.set(ctx); err != nil {
+			return nil, err
+		}
+		return next(ctx, query, args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *SessionVariablesMiddleware) ExecContext(_ Statement, next ExecHandler) ExecHandler {
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		if err := m.set(ctx); err != nil {
+			return nil, err
+		}
+		return next(ctx, query, args...)
+	}
+}
+
+// set sets the session variables in the transaction of the context.
+func (m *SessionVariablesMiddleware) set(ctx context.Context) error {
+	variables := SessionVariablesFromContext(ctx)
+	if m.Variables != nil {
+		if extra := m.Variables(ctx); len(extra) > 0 {
+			merged := make(map[string]string, len(variables)+len(extra))
+			for name, value := range variables {
+				merged[name] = value
+			}
+			for name, value := range extra {
+				merged[name] = value
+			}
+			variables = merged
+		}
+	}
+	if len(variables) == 0 {
+		return nil
+	}
+	sess, err := session.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok := sess.(session.TransactionSession); !ok {
+		return nil
+	}
+	drv, err := driver.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if resetter, ok := drv.(driver.SessionVariableResetter); ok {
+		if err = m.scope(ctx, sess, resetter, names); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		query, args, err := driver.SessionVariable(drv, name, variables[name])
+		if err != nil {
+			return err
+		}
+		if _, err = sess.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errSessionVariablesNotScoped is the error that the variables of the transaction can not be reset before it ends.
+var errSessionVariablesNotScoped = errors.New("session variables can not be reset before the transaction ends")
+
+// scope registers the reset of the variables before the transaction ends, once for each transaction.
+func (m *SessionVariablesMiddleware) scope(ctx context.Context, sess session.Session, resetter driver.SessionVariableResetter, names []string) error {
+	ender, ok := sess.(session.BeforeEnder)
+	if !ok || !reflect.TypeOf(sess).Comparable() {
+		return fmt.Errorf("%w: %T", errSessionVariablesNotScoped, sess)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.scoped == nil {
+		m.scoped = make(map[session.Session]map[string]struct{})
+	}
+	scoped, exists := m.scoped[sess]
+	if !exists {
+		scoped = make(map[string]struct{}, len(names))
+		m.scoped[sess] = scoped
+		// the reset is executed even if the context of the statement is canceled.
+		ctx = context.WithoutCancel(ctx)
+		ender.BeforeEnd(func() error { return m.reset(ctx, sess, resetter) })
+	}
+	for _, name := range names {
+		scoped[name] = struct{}{}
+	}
+	return nil
+}
+
+// reset resets the variables set in the transaction.
+func (m *SessionVariablesMiddleware) reset(ctx context.Context, sess session.Session, resetter driver.SessionVariableResetter) error {
+	m.mu.Lock()
+	scoped := m.scoped[sess]
+	delete(m.scoped, sess)
+	m.mu.Unlock()
+	names := make([]string, 0, len(scoped))
+	for name := range scoped {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs []error
+	for _, name := range names {
+		query, args, err := resetter.ResetSessionVariable(name)
+		if err == nil {
+			_, err = sess.ExecContext(ctx, query, args...)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/session"
+)
+
+// recordingTx is the transaction session which records the executed queries.
+type recordingTx struct {
+	session.Session
+	queries []string
+}
+
+func (r *recordingTx) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	r.queries = append(r.queries, query)
+	return sqldriver.RowsAffected(0), nil
+}
+
+func (r *recordingTx) Commit() error   { r.queries = append(r.queries, "COMMIT"); return nil }
+func (r *recordingTx) Rollback() error { r.queries = append(r.queries, "ROLLBACK"); return nil }
+
+func TestSessionVariablesMiddleware_MySQLReset(t *testing.T) {
+	middleware := &SessionVariablesMiddleware{}
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Update, name: "main.User.Update"}
+	exec := middleware.ExecContext(stmt, func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return sqldriver.RowsAffected(1), nil
+	})
+
+	for _, end := range []string{"COMMIT", "ROLLBACK"} {
+		raw := &recordingTx{}
+		tx := session.WithAfterCommit(raw)
+		ctx := session.WithContext(driver.WithContext(context.Background(), driver.MySQLDriver{}), tx)
+		ctx = ContextWithSessionVariables(ctx, map[string]string{"app.user_id": "42"})
+		for range 2 {
+			if _, err := exec(ctx, "update user set name = ?", "eat"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var err error
+		if end == "COMMIT" {
+			err = tx.Commit()
+		} else {
+			err = tx.Rollback()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the user-defined variables are reset once before the transaction ends.
+		want := []string{"SET @`app.user_id` = ?", "SET @`app.user_id` = ?", "SET @`app.user_id` = NULL", end}
+		if !reflect.DeepEqual(raw.queries, want) {
+			t.Errorf("unexpected queries: %q", raw.queries)
+		}
+	}
+	if len(middleware.scoped) != 0 {
+		t.Errorf("expected the transactions to be released, got %v", middleware.scoped)
+	}
+}
+
+func TestSessionVariablesMiddleware_Postgres(t *testing.T) {
+	middleware := &SessionVariablesMiddleware{Variables: func(context.Context) map[string]string {
+		return map[string]string{"app.tenant": "7"}
+	}}
+	stmt := &xmlSQLStatement{mapper: &Mapper{}, action: Select, name: "main.User.List"}
+	exec := middleware.ExecContext(stmt, func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return sqldriver.RowsAffected(1), nil
+	})
+	raw := &recordingTx{}
+	tx := session.WithAfterCommit(raw)
+	ctx := session.WithContext(driver.WithContext(context.Background(), driver.PostgresDriver{}), tx)
+	if _, err := exec(ctx, "select 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	// the variables of postgres are local to the transaction, which need no reset.
+	if want := []string{"SELECT set_config($1, $2, true)", "COMMIT"}; !reflect.DeepEqual(raw.queries, want) {
+		t.Errorf("unexpected queries: %q", raw.queries)
+	}
+
+	// the statements outside a transaction do not set the variables.
+	direct := &recordingTx{}
+	ctx = session.WithContext(driver.WithContext(context.Background(), driver.PostgresDriver{}), struct{ session.Session }{direct})
+	if _, err := exec(ctx, "select 1"); err != nil || len(direct.queries) != 0 {
+		t.Errorf("unexpected queries: %q %v", direct.queries, err)
+	}
+}
+
+func TestWithAfterCommit_BeforeEndFailure(t *testing.T) {
+	raw := &recordingTx{}
+	tx := session.WithAfterCommit(raw)
+	resetErr := errors.New("reset failed")
+	tx.(session.BeforeEnder).BeforeEnd(func() error { return resetErr })
+	var committed, rolledBack bool
+	tx.(session.AfterCommitter).AfterCommit(func() { committed = true })
+	tx.(session.AfterRollbacker).AfterRollback(func() { rolledBack = true })
+
+	// the transaction is rolled back when the variables can not be reset, so that its connection is released.
+	if err := tx.Commit(); !errors.Is(err, resetErr) {
+		t.Fatalf("expected the reset error, got %v", err)
+	}
+	if want := []string{"ROLLBACK"}; !reflect.DeepEqual(raw.queries, want) {
+		t.Errorf("unexpected queries: %q", raw.queries)
+	}
+	if committed || !rolledBack {
+		t.Errorf("expected the rollback hooks to be called only, got committed %v, rolled back %v", committed, rolledBack)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// SourcePosition is the position in the mapper file where a statement or a dynamic node is declared.
+type SourcePosition struct {
+	File string
+	Line int
+}
+
+// IsValid reports whether the position is recorded.
+func (s SourcePosition) IsValid() bool {
+	return s.Line > 0
+}
+
+// String returns the position as file:line.
+func (s SourcePosition) String() string {
+	if s.File == "" {
+		return "line " + strconv.Itoa(s.Line)
+	}
+	return s.File + ":" + strconv.Itoa(s.Line)
+}
+
+// SourceError is an error that occurs when building the statement,
+// it reports the position in the mapper file where the failed node is declared.
+type SourceError struct {
+	Source SourcePosition
+	Err    error
+}
+
+// Error returns the error message, like "expr error at user_mapper.xml:142".
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%v at %s", e.Err, e.Source)
+}
+
+// Unwrap returns the underlying error.
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// withSource wraps the error with the position, unless the error is already wrapped by a deeper node.
+func withSource(err error, source SourcePosition) error {
+	if err == nil || !source.IsValid() {
+		return err
+	}
+	var sourceError *SourceError
+	if errors.As(err, &sourceError) {
+		return err
+	}
+	return &SourceError{Source: source, Err: err}
+}
+
+// SourceOf returns the position in the mapper file where the statement is declared.
+// It returns false if the statement does not record its position, like the raw sql statements.
+func SourceOf(statement Statement) (SourcePosition, bool) {
+	if s, ok := statement.(interface{ Source() SourcePosition }); ok {
+		source := s.Source()
+		return source, source.IsValid()
+	}
+	return SourcePosition{}, false
+}
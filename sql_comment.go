@@ -0,0 +1,165 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// sqlCommentTagsKey is the context key of the sql comment tags.
+type sqlCommentTagsKey struct{}
+
+// ContextWithSQLCommentTags returns a new context carrying the given tags.
+// The tags will be rendered into the comment prepended by SQLCommentMiddleware,
+// for example a trace id or the name of the request handler.
+// Tags already in the context are kept unless overwritten by the given ones.
+func ContextWithSQLCommentTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string, len(tags))
+	for key, value := range SQLCommentTagsFromContext(ctx) {
+		merged[key] = value
+	}
+	for key, value := range tags {
+		merged[key] = value
+	}
+	return context.WithValue(ctx, sqlCommentTagsKey{}, merged)
+}
+
+// SQLCommentTagsFromContext returns the sql comment tags from the context.
+func SQLCommentTagsFromContext(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(sqlCommentTagsKey{}).(map[string]string)
+	return tags
+}
+
+// ensure SQLCommentMiddleware implements Middleware.
+var _ Middleware = (*SQLCommentMiddleware)(nil) // compile time check
+
+// SQLCommentMiddleware is a middleware that prepends a comment to every built sql,
+// so that the statements can be traced back to the application from the database side.
+//
+// The comment looks like:
+//
+//	/* juice:main.UserRepository.FindByID traceparent='00-4bf9...-01' */ SELECT ...
+//
+// The tags are encoded in the sqlcommenter format: keys are sorted, keys and values are percent encoded
+// and the values are wrapped with single quotes.
+//
+// It can be turned off for a statement by setting the sqlComment attribute to false,
+// or for all statements by setting the sqlComment setting to false.
+type SQLCommentMiddleware struct {
+	// Tags returns extra tags from the context, like the trace id of the current span.
+	// Tags returned by it are merged with the tags set by ContextWithSQLCommentTags.
+	Tags func(ctx context.Context) map[string]string
+}
+
+// QueryContext implements Middleware.
+func (m *SQLCommentMiddleware) QueryContext(stmt Statement, next QueryHandler) QueryHandler {
+	if !m.enabled(stmt) {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+		return next(ctx, m.comment(ctx, stmt)+query, args...)
+	}
+}
+
+// ExecContext implements Middleware.
+func (m *SQLCommentMiddleware) ExecContext(stmt Statement, next ExecHandler) ExecHandler {
+	if !m.enabled(stmt) {
+		return next
+	}
+	return func(ctx context.Context, query string, args ...any) (sql.Result, error) {
+		return next(ctx, m.comment(ctx, stmt)+query, args...)
+	}
+}
+
+// enabled returns false if the sql comment is disabled by the statement or the configuration.
+func (m *SQLCommentMiddleware) enabled(stmt Statement) bool {
+	if stmt.Attribute("sqlComment") == "false" {
+		return false
+	}
+	if cfg := stmt.Configuration(); cfg != nil && cfg.Settings().Get("sqlComment") == "false" {
+		return false
+	}
+	return true
+}
+
+// comment builds the comment of the statement.
+func (m *SQLCommentMiddleware) comment(ctx context.Context, stmt Statement) string {
+	tags := SQLCommentTagsFromContext(ctx)
+	if m.Tags != nil {
+		if extra := m.Tags(ctx); len(extra) > 0 {
+			merged := make(map[string]string, len(tags)+len(extra))
+			for key, value := range tags {
+				merged[key] = value
+			}
+			for key, value := range extra {
+				merged[key] = value
+			}
+			tags = merged
+		}
+	}
+	builder := getStringBuilder()
+	defer putStringBuilder(builder)
+	builder.WriteString("/* juice:")
+	builder.WriteString(escapeSQLComment(stmt.Name()))
+	if len(tags) > 0 {
+		keys := make([]string, 0, len(tags))
+		for key := range tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		builder.WriteString(" ")
+		for i, key := range keys {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			// percent encoding also takes care of the quotes and the comment terminator.
+			builder.WriteString(percentEncode(key))
+			builder.WriteString("='")
+			builder.WriteString(percentEncode(tags[key]))
+			builder.WriteString("'")
+		}
+	}
+	builder.WriteString(" */ ")
+	return builder.String()
+}
+
+// percentEncode encodes the text like encodeURIComponent, which the sqlcommenter format requires,
+// every byte except the unreserved characters is encoded as %XX, so a space is %20 instead of +.
+func percentEncode(text string) string {
+	const hex = "0123456789ABCDEF"
+	var builder strings.Builder
+	builder.Grow(len(text))
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			builder.WriteByte(c)
+		default:
+			builder.WriteByte('%')
+			builder.WriteByte(hex[c>>4])
+			builder.WriteByte(hex[c&0x0F])
+		}
+	}
+	return builder.String()
+}
+
+// escapeSQLComment makes sure the given text can not close the sql comment.
+func escapeSQLComment(text string) string {
+	return strings.ReplaceAll(text, "*/", "* /")
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestSQLCommentMiddleware(t *testing.T) {
+	middleware := &SQLCommentMiddleware{Tags: func(context.Context) map[string]string {
+		return map[string]string{"traceparent": "00-4bf9-01", "route": "/users/{id}"}
+	}}
+	stmt := &xmlSQLStatement{
+		mapper: &Mapper{mappers: &Mappers{cfg: &Configuration{}}},
+		action: Select,
+		name:   "main.UserRepository.FindByID",
+	}
+	var built string
+	query := middleware.QueryContext(stmt, func(_ context.Context, query string, _ ...any) (*sql.Rows, error) {
+		built = query
+		return nil, nil
+	})
+	// the tags of the context are merged with the ones of Tags, which take precedence.
+	ctx := ContextWithSQLCommentTags(context.Background(), map[string]string{"route": "ignored", "app": "juice"})
+	ctx = ContextWithSQLCommentTags(ctx, map[string]string{"user": "O'Reilly */ drop"})
+	if _, err := query(ctx, "SELECT * FROM user WHERE id = ?", 1); err != nil {
+		t.Fatal(err)
+	}
+	want := "/* juice:main.UserRepository.FindByID app='juice',route='%2Fusers%2F%7Bid%7D',traceparent='00-4bf9-01',user='O%27Reilly%20%2A%2F%20drop' */ SELECT * FROM user WHERE id = ?"
+	if built != want {
+		t.Errorf("unexpected query:\n%s\nwant:\n%s", built, want)
+	}
+
+	// the statement name without tags.
+	exec := (&SQLCommentMiddleware{}).ExecContext(stmt, func(_ context.Context, query string, _ ...any) (sql.Result, error) {
+		built = query
+		return nil, nil
+	})
+	if _, err := exec(context.Background(), "UPDATE user SET name = ?", "juice"); err != nil {
+		t.Fatal(err)
+	}
+	if want = "/* juice:main.UserRepository.FindByID */ UPDATE user SET name = ?"; built != want {
+		t.Errorf("unexpected query: %s", built)
+	}
+}
+
+func TestSQLCommentMiddleware_Disabled(t *testing.T) {
+	for name, stmt := range map[string]*xmlSQLStatement{
+		"attribute": {
+			mapper: &Mapper{mappers: &Mappers{cfg: &Configuration{}}},
+			name:   "main.User.List",
+			attrs:  map[string]string{"sqlComment": "false"},
+		},
+		"setting": {
+			mapper: &Mapper{mappers: &Mappers{cfg: &Configuration{settings: keyValueSettingProvider{"sqlComment": "false"}}}},
+			name:   "main.User.List",
+		},
+	} {
+		var built string
+		query := (&SQLCommentMiddleware{}).QueryContext(stmt, func(_ context.Context, query string, _ ...any) (*sql.Rows, error) {
+			built = query
+			return nil, nil
+		})
+		if _, _ = query(context.Background(), "SELECT 1"); built != "SELECT 1" {
+			t.Errorf("%s: expected no comment, got %s", name, built)
+		}
+	}
+}
+
+func TestPercentEncode(t *testing.T) {
+	for text, want := range map[string]string{
+		"juice-1.0_~": "juice-1.0_~",
+		"a b":         "a%20b",
+		"a+b":         "a%2Bb",
+		"k=v,x":       "k%3Dv%2Cx",
+		"it's":        "it%27s",
+		"中":           "%E4%B8%AD",
+	} {
+		if got := percentEncode(text); got != want {
+			t.Errorf("%q: expected %q, got %q", text, want, got)
+		}
+	}
+}
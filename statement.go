@@ -17,12 +17,18 @@ limitations under the License.
 package juice
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"hash/fnv"
 	"strconv"
+	"time"
 
 	"github.com/go-juicedev/juice/driver"
+	"github.com/go-juicedev/juice/eval"
 )
 
+// Statement is a statement declared by the mappers, or a raw query.
 type Statement interface {
 	ID() string
 	Name() string
@@ -30,6 +36,8 @@ type Statement interface {
 	Action() Action
 	Configuration() IConfiguration
 	ResultMap() (ResultMap, error)
+	// Build builds the query and the arguments of the statement with the parameter, without executing it.
+	// It is safe to be called by the external tools, see BuildWithReport for the chosen branches.
 	Build(translator driver.Translator, param Param) (query string, args []any, err error)
 }
 
@@ -41,6 +49,17 @@ type xmlSQLStatement struct {
 	attrs  map[string]string
 	name   string
 	id     string
+	source SourcePosition
+	hash   string
+	// includes are the include nodes of the statement, whose fragments are hashed along with it.
+	includes []*IncludeNode
+	// rows is the moving average of the rows returned, which hints the capacity of the slice results.
+	rows rowsAverage
+}
+
+// Source returns the position in the mapper file where the statement is declared.
+func (s *xmlSQLStatement) Source() SourcePosition {
+	return s.source
 }
 
 // Attribute returns the value of the attribute with the given key.
@@ -105,18 +124,127 @@ func (s *xmlSQLStatement) ResultMap() (ResultMap, error) {
 }
 
 // Build builds the xmlSQLStatement with the given parameter.
+// If the statement declares the dialect attribute, like dialect="oracle",
+// the translator of the registered driver of the dialect is used instead of the given one,
+// for the statements targeting another database, like the queries through a linked server.
 func (s *xmlSQLStatement) Build(translator driver.Translator, param Param) (query string, args []any, err error) {
+	query, args, err = s.build(translator, param, buildOptions{})
+	args, _ = unredactArgs(args)
+	return query, args, err
+}
+
+// buildOptions are the options of the build resolved from the context, see buildStatement.
+type buildOptions struct {
+	// trace records the test expressions if it is not nil.
+	trace *ExprTrace
+	// schema is substituted for ${schema} if it is not empty.
+	schema string
+}
+
+// buildStatement builds the statement with the options of the context, like the expression trace and the schema.
+func buildStatement(ctx context.Context, statement Statement, translator driver.Translator, param Param) (string, []any, error) {
+	if xmlStatement, ok := statement.(*xmlSQLStatement); ok {
+		options := buildOptions{trace: ExprTraceFromContext(ctx), schema: statementSchema(ctx, statement)}
+		return xmlStatement.build(translator, param, options)
+	}
+	return statement.Build(translator, param)
+}
+
+// build builds the statement with the options.
+func (s *xmlSQLStatement) build(translator driver.Translator, param Param, options buildOptions) (query string, args []any, err error) {
+	if dialect := s.Attribute("dialect"); dialect != "" {
+		dialectDriver, err := driver.Get(dialect)
+		if err != nil {
+			return "", nil, withSource(fmt.Errorf("dialect of statement %s: %w", s.Name(), err), s.source)
+		}
+		translator = dialectDriver.Translator()
+	}
 	value := newGenericParam(param, s.Attribute("paramName"))
+	if generic, ok := value.(*eval.GenericParameter); ok && foldParamKeysEnabled(s) {
+		generic.FoldKeys = true
+	}
+	value = withRedactAttribute(value, s.Attribute("redact"))
+	if truthyEnabled(s) {
+		value = eval.WithTruthy(value)
+	}
+	if limits := expressionLimits(s); !limits.IsZero() {
+		value = eval.WithLimits(value, limits)
+	}
+	if value, err = withSchema(value, options.schema); err != nil {
+		return "", nil, withSource(fmt.Errorf("schema of statement %s: %w", s.Name(), err), s.source)
+	}
+	if options.trace != nil {
+		value = exprTraceParameter{Parameter: value, trace: options.trace, statement: s.Name()}
+	}
 	query, args, err = s.Nodes.Accept(translator, value)
 	if err != nil {
-		return "", nil, err
+		var exprError *eval.ExprError
+		if errors.As(err, &exprError) && exprError.Statement == "" {
+			exprError.Statement = s.Name()
+		}
+		return "", nil, withSource(err, s.source)
 	}
 	if len(query) == 0 {
 		return "", nil, ErrEmptyQuery
 	}
+	if err = checkPlaceholders(query, args, translator); err != nil {
+		return "", nil, withSource(fmt.Errorf("statement %s: %w", s.Name(), err), s.source)
+	}
 	return query, args, nil
 }
 
+// truthyEnabled reports whether the expressions of the statement are evaluated in truthy mode,
+// where the non-bool operands of and, or and not are coerced like OGNL does.
+// The statement attribute "truthy" takes precedence over the setting with the same name.
+func truthyEnabled(statement Statement) bool {
+	if attr := statement.Attribute("truthy"); attr != "" {
+		return StringValue(attr).Bool()
+	}
+	cfg := statement.Configuration()
+	return cfg != nil && cfg.Settings().Get("truthy").Bool()
+}
+
+// foldParamKeysEnabled reports whether the names of the statement are resolved against the keys of
+// the map parameters case-insensitively and by the naming conventions, like user_name for userName.
+// The statement attribute "foldParamKeys" takes precedence over the setting with the same name.
+func foldParamKeysEnabled(statement Statement) bool {
+	if attr := statement.Attribute("foldParamKeys"); attr != "" {
+		return StringValue(attr).Bool()
+	}
+	cfg := statement.Configuration()
+	return cfg != nil && cfg.Settings().Get("foldParamKeys").Bool()
+}
+
+// expressionLimits returns the limits of the expressions of the statement from the settings,
+// see settingsExpressionLimits.
+func expressionLimits(statement Statement) eval.Limits {
+	cfg := statement.Configuration()
+	if cfg == nil {
+		return eval.Limits{}
+	}
+	return settingsExpressionLimits(cfg.Settings())
+}
+
+// settingsExpressionLimits returns the limits of the expressions from the settings "exprMaxDepth",
+// "exprMaxNodes", "exprMaxCalls" and "exprTimeout", the timeout is a duration like 50ms.
+// The unset limits fall back to eval.DefaultLimits. The settings are validated when they are parsed.
+func settingsExpressionLimits(settings SettingProvider) eval.Limits {
+	limits := eval.DefaultLimits()
+	if value := settings.Get("exprMaxDepth"); value != "" {
+		limits.MaxDepth = int(value.Int64())
+	}
+	if value := settings.Get("exprMaxNodes"); value != "" {
+		limits.MaxNodes = int(value.Int64())
+	}
+	if value := settings.Get("exprMaxCalls"); value != "" {
+		limits.MaxCalls = int(value.Int64())
+	}
+	if value := settings.Get("exprTimeout"); value != "" {
+		limits.Timeout, _ = time.ParseDuration(value.String())
+	}
+	return limits
+}
+
 // rawSQLStatement represents a raw SQL query with its parameters and action type.
 // It implements the Statement interface and provides methods for query execution.
 type rawSQLStatement struct {
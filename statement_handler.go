@@ -27,7 +27,6 @@ import (
 	"github.com/go-juicedev/juice/driver"
 	"github.com/go-juicedev/juice/internal/ctxreducer"
 	"github.com/go-juicedev/juice/internal/reflectlite"
-	"github.com/go-juicedev/juice/internal/stmt"
 	"github.com/go-juicedev/juice/session"
 )
 
@@ -66,12 +65,15 @@ func (s *CompiledStatementHandler) QueryContext(ctx context.Context, statement S
 	contextReducer := ctxreducer.G{
 		ctxreducer.NewSessionContextReducer(s.session),
 		ctxreducer.NewParamContextReducer(param),
+		ctxreducer.NewDriverContextReducer(s.driver),
 	}
 	ctx = contextReducer.Reduce(ctx)
 	if s.queryHandler == nil {
 		s.queryHandler = SessionQueryHandler
 	}
-	return s.middlewares.QueryContext(statement, s.queryHandler)(ctx, s.query, s.args...)
+	// the drivers receive the values of the redacted arguments, which are masked by the logs.
+	args, redacted := unredactArgs(s.args)
+	return s.middlewares.QueryContext(statement, s.queryHandler)(withRedactedArgs(ctx, redacted), s.query, args...)
 }
 
 // ExecContext executes a non-query SQL statement (such as INSERT, UPDATE, DELETE)
@@ -81,73 +83,90 @@ func (s *CompiledStatementHandler) ExecContext(ctx context.Context, statement St
 	contextReducer := ctxreducer.G{
 		ctxreducer.NewSessionContextReducer(s.session),
 		ctxreducer.NewParamContextReducer(param),
+		ctxreducer.NewDriverContextReducer(s.driver),
 	}
 	ctx = contextReducer.Reduce(ctx)
 	if s.execHandler == nil {
 		s.execHandler = SessionExecHandler
 	}
-	return s.middlewares.ExecContext(statement, s.execHandler)(ctx, s.query, s.args...)
+	if s.queryHandler == nil {
+		s.queryHandler = SessionQueryHandler
+	}
+	// the inserts returning their generated keys are queried by the query handler, see useGeneratedKeysMiddleware.
+	ctx = context.WithValue(ctx, returningQueryHandlerKey{}, s.queryHandler)
+	// the drivers receive the values of the redacted arguments, which are masked by the logs.
+	args, redacted := unredactArgs(s.args)
+	return s.middlewares.ExecContext(statement, s.execHandler)(withRedactedArgs(ctx, redacted), s.query, args...)
 }
 
+// maxHandlerPreparedStatements is the max prepared statements kept by a PreparedStatementHandler.
+// The batch inserts only need two of them, one for the full batches and one for the final short batch,
+// the others are kept for the statements whose queries vary between the batches.
+const maxHandlerPreparedStatements = 8
+
 // PreparedStatementHandler implements the StatementHandler interface.
-// It maintains a single prepared statement that can be reused if the query is the same.
-// When a different query is encountered, it closes the existing statement and creates a new one.
+// It maintains the prepared statements by their queries, so that the same query is only prepared once,
+// like the chunks of the same size in batch inserts, which produce the identical query.
+// At most maxHandlerPreparedStatements are kept, the least recently used one is closed beyond them.
 type PreparedStatementHandler struct {
-	stmts       *sql.Stmt
+	stmts       lru[string, *sql.Stmt]
 	middlewares MiddlewareGroup
 	driver      driver.Driver
 	session     session.Session
 }
 
-// getOrPrepare retrieves an existing prepared statement if the query matches,
-// otherwise closes the current statement (if any) and creates a new one.
+// getOrPrepare retrieves an existing prepared statement of the query,
+// otherwise prepares a new one and keeps it until it is evicted or the handler is closed.
 func (s *PreparedStatementHandler) getOrPrepare(ctx context.Context, query string) (*sql.Stmt, error) {
-	if s.stmts != nil && stmt.Query(s.stmts) == query {
-		return s.stmts, nil
+	if preparedStmt, ok := s.stmts.get(query); ok {
+		return preparedStmt, nil
 	}
-	// it means the prepared statement is not what we want
-	if s.stmts != nil {
-		_ = s.stmts.Close()
-	}
-	var err error
-	s.stmts, err = s.session.PrepareContext(ctx, query)
+	preparedStmt, err := s.session.PrepareContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("prepare statement failed: %w", err)
 	}
-	return s.stmts, nil
+	if s.stmts.onEvict == nil {
+		s.stmts.max = maxHandlerPreparedStatements
+		// the rows queried by the evicted statement are still readable, sql.Stmt closes after them.
+		s.stmts.onEvict = func(_ string, evicted *sql.Stmt) { _ = evicted.Close() }
+	}
+	s.stmts.set(query, preparedStmt)
+	return preparedStmt, nil
 }
 
 // QueryContext executes a query that returns rows. It builds the query using
 // the provided Statement and Param, applies middlewares, and executes the
 // prepared statement with the given context.
 func (s *PreparedStatementHandler) QueryContext(ctx context.Context, statement Statement, param Param) (*sql.Rows, error) {
-	query, args, err := statement.Build(s.driver.Translator(), param)
+	query, args, err := buildStatement(ctx, statement, s.driver.Translator(), param)
 	if err != nil {
 		return nil, err
 	}
-	queryHandler := func(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-		preparedStmt, err := s.getOrPrepare(ctx, query)
-		if err != nil {
-			return nil, err
-		}
-		return preparedStmt.QueryContext(ctx, args...)
-	}
 	statementHandler := CompiledStatementHandler{
 		query:        query,
 		args:         args,
 		middlewares:  s.middlewares,
 		driver:       s.driver,
 		session:      s.session,
-		queryHandler: queryHandler,
+		queryHandler: s.queryHandler,
 	}
 	return statementHandler.QueryContext(ctx, statement, param)
 }
 
+// queryHandler queries the prepared statement of the query.
+func (s *PreparedStatementHandler) queryHandler(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	preparedStmt, err := s.getOrPrepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return preparedStmt.QueryContext(ctx, args...)
+}
+
 // ExecContext executes a query that doesn't return rows. It builds the query
 // using the provided Statement and Param, applies middlewares, and executes
 // the prepared statement with the given context.
 func (s *PreparedStatementHandler) ExecContext(ctx context.Context, statement Statement, param Param) (result sql.Result, err error) {
-	query, args, err := statement.Build(s.driver.Translator(), param)
+	query, args, err := buildStatement(ctx, statement, s.driver.Translator(), param)
 	if err != nil {
 		return nil, err
 	}
@@ -159,12 +178,13 @@ func (s *PreparedStatementHandler) ExecContext(ctx context.Context, statement St
 		return preparedStmt.ExecContext(ctx, args...)
 	}
 	statementHandler := CompiledStatementHandler{
-		query:       query,
-		args:        args,
-		middlewares: s.middlewares,
-		driver:      s.driver,
-		session:     s.session,
-		execHandler: execHandler,
+		query:        query,
+		args:         args,
+		middlewares:  s.middlewares,
+		driver:       s.driver,
+		session:      s.session,
+		execHandler:  execHandler,
+		queryHandler: s.queryHandler,
 	}
 	return statementHandler.ExecContext(ctx, statement, param)
 }
@@ -172,10 +192,13 @@ func (s *PreparedStatementHandler) ExecContext(ctx context.Context, statement St
 // Close closes all prepared statements in the pool and returns any error
 // that occurred during the process. Multiple errors are joined together.
 func (s *PreparedStatementHandler) Close() error {
-	if s.stmts != nil {
-		return s.stmts.Close()
+	var errs []error
+	for _, preparedStmt := range s.stmts.clear() {
+		if err := preparedStmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // QueryBuildStatementHandler handles the execution of SQL statements and returns
@@ -192,7 +215,7 @@ type QueryBuildStatementHandler struct {
 // processes the query through any configured middlewares, and then executes it using
 // the associated driver.
 func (s *QueryBuildStatementHandler) QueryContext(ctx context.Context, statement Statement, param Param) (*sql.Rows, error) {
-	query, args, err := statement.Build(s.driver.Translator(), param)
+	query, args, err := buildStatement(ctx, statement, s.driver.Translator(), param)
 	if err != nil {
 		return nil, err
 	}
@@ -210,7 +233,7 @@ func (s *QueryBuildStatementHandler) QueryContext(ctx context.Context, statement
 // within a context, and returns the result. Similar to QueryContext, it constructs
 // the SQL command, applies middlewares, and executes the command using the driver.
 func (s *QueryBuildStatementHandler) ExecContext(ctx context.Context, statement Statement, param Param) (sql.Result, error) {
-	query, args, err := statement.Build(s.driver.Translator(), param)
+	query, args, err := buildStatement(ctx, statement, s.driver.Translator(), param)
 	if err != nil {
 		return nil, err
 	}
@@ -256,52 +279,21 @@ func (s *sliceBatchStatementHandler) QueryContext(ctx context.Context, statement
 	return statementHandler.QueryContext(ctx, statement, param)
 }
 
-func (s *sliceBatchStatementHandler) execContext(ctx context.Context, statement Statement, param Param) (sql.Result, error) {
-	statementHandler := NewQueryBuildStatementHandler(s.driver, s.session, s.middlewares...)
-	return statementHandler.ExecContext(ctx, statement, param)
-}
-
 func (s *sliceBatchStatementHandler) ExecContext(ctx context.Context, statement Statement, param Param) (result sql.Result, err error) {
 	length := s.value.Len()
 	if length == 0 {
 		return nil, fmt.Errorf("%w: empty slice", errInvalidParamType)
 	}
-	times := (length + int(s.batchSize) - 1) / int(s.batchSize)
-
-	if times == 1 {
-		return s.execContext(ctx, statement, param)
-	}
-
-	// Create a PreparedStatementHandler for batch processing.
-	// We use PreparedStatementHandler here because:
-	// 1. For batch inserts with size N, we only need at most 2 prepared statements:
-	//    - One for full batch (N rows)
-	//    - One for remaining rows (< N rows)
-	// 2. These statements can be reused across multiple batches
-	// 3. This significantly reduces the overhead of preparing statements repeatedly
-	preparedStatementHandler := &PreparedStatementHandler{
+	executor := batchExecutor{
 		driver:      s.driver,
 		middlewares: s.middlewares,
 		session:     s.session,
+		batchSize:   s.batchSize,
 	}
-
-	// Ensure all prepared statements are properly closed after use
-	defer func() { _ = preparedStatementHandler.Close() }()
-
-	// execute the statement in batches.
-	for i := 0; i < times; i++ {
-		start := i * int(s.batchSize)
-		end := (i + 1) * int(s.batchSize)
-		if end > length {
-			end = length
-		}
-		batchParam := s.value.Slice(start, end).Interface()
-		result, err = preparedStatementHandler.ExecContext(ctx, statement, batchParam)
-		if err != nil {
-			return nil, err
-		}
+	chunk := func(start, end int) Param {
+		return s.value.Slice(start, end).Interface()
 	}
-	return result, nil
+	return executor.execContext(ctx, statement, param, length, chunk)
 }
 
 type mapBatchStatementHandler struct {
@@ -321,11 +313,6 @@ func (s *mapBatchStatementHandler) QueryContext(ctx context.Context, statement S
 	return statementHandler.QueryContext(ctx, statement, param)
 }
 
-func (s *mapBatchStatementHandler) execContext(ctx context.Context, statement Statement, param Param) (sql.Result, error) {
-	statementHandler := NewQueryBuildStatementHandler(s.driver, s.session, s.middlewares...)
-	return statementHandler.ExecContext(ctx, statement, param)
-}
-
 func (s *mapBatchStatementHandler) ExecContext(ctx context.Context, statement Statement, param Param) (result sql.Result, err error) {
 	mapKeys := s.value.MapKeys()
 	if len(mapKeys) != 1 {
@@ -346,46 +333,22 @@ func (s *mapBatchStatementHandler) ExecContext(ctx context.Context, statement St
 	if length == 0 {
 		return nil, fmt.Errorf("%w: empty slice", errInvalidParamType)
 	}
-	times := (length + int(s.batchSize) - 1) / int(s.batchSize)
-
-	if times == 1 {
-		return s.execContext(ctx, statement, param)
-	}
-
-	// Create a PreparedStatementHandler for batch processing.
-	// We use PreparedStatementHandler here because:
-	// 1. For batch inserts with size N, we only need at most 2 prepared statements:
-	//    - One for full batch (N rows)
-	//    - One for remaining rows (< N rows)
-	// 2. These statements can be reused across multiple batches
-	// 3. This significantly reduces the overhead of preparing statements repeatedly
-	preparedStatementHandler := &PreparedStatementHandler{
+	executor := batchExecutor{
 		driver:      s.driver,
 		middlewares: s.middlewares,
 		session:     s.session,
+		batchSize:   s.batchSize,
 	}
 
-	// Ensure all prepared statements are properly closed after use
-	defer func() { _ = preparedStatementHandler.Close() }()
-
 	batchParam := reflect.MakeMap(s.value.Type())
 
 	executionParam := batchParam.Interface()
 
-	// execute the statement in batches.
-	for i := 0; i < times; i++ {
-		start := i * int(s.batchSize)
-		end := (i + 1) * int(s.batchSize)
-		if end > length {
-			end = length
-		}
+	chunk := func(start, end int) Param {
 		batchParam.SetMapIndex(keyValue, value.Slice(start, end))
-		result, err = preparedStatementHandler.ExecContext(ctx, statement, executionParam)
-		if err != nil {
-			return nil, err
-		}
+		return executionParam
 	}
-	return result, nil
+	return executor.execContext(ctx, statement, param, length, chunk)
 }
 
 // BatchStatementHandler is a specialized SQL statement executor that provides optimized handling
@@ -0,0 +1,330 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"iter"
+	"os"
+	"slices"
+	"strings"
+)
+
+// endElementPrefix is the prefix of the end elements.
+var endElementPrefix = []byte("</")
+
+// statementHash is the content hash of the statement, which is written with the bytes of its body
+// as they are read by the parser, and the end element of the body is excluded.
+// The whitespaces of the body are collapsed into one space, and removed around the elements, which are
+// trimmed by the parser. The quoted literals are kept as they are, since their whitespaces are a part of the values.
+type statementHash struct {
+	hash hash.Hash
+	// tail is the bytes from the last "</", which are hashed unless they are the end element.
+	tail []byte
+	// canonical is the canonical body to be written to the hash.
+	canonical []byte
+	quote     byte
+	space     bool
+	started   bool
+	last      byte
+}
+
+// newStatementHash returns the hash of the statement which is declared by the action and the attributes.
+// The attributes are sorted, so only the changes of the sql template and its options are reported as drifts.
+func newStatementHash(action Action, attrs map[string]string) *statementHash {
+	h := &statementHash{hash: sha256.New()}
+	_, _ = io.WriteString(h.hash, action.String())
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		_, _ = fmt.Fprintf(h.hash, "\x00%s=%s", key, attrs[key])
+	}
+	_, _ = io.WriteString(h.hash, "\x00")
+	return h
+}
+
+// WriteByte implements io.ByteWriter.
+func (h *statementHash) WriteByte(c byte) error {
+	if c == '/' && len(h.tail) > 0 && h.tail[len(h.tail)-1] == '<' {
+		// the bytes before the "</" are not a part of the end element.
+		h.canonicalize(h.tail[:len(h.tail)-1])
+		h.tail = append(h.tail[:0], endElementPrefix...)
+		return nil
+	}
+	h.tail = append(h.tail, c)
+	// before the "</" is read, only the last byte may be the start of the end element.
+	if len(h.tail) > 1 && !bytes.HasPrefix(h.tail, endElementPrefix) {
+		h.canonicalize(h.tail[:len(h.tail)-1])
+		h.tail = append(h.tail[:0], c)
+	}
+	return nil
+}
+
+// canonicalize writes the body to the hash with the whitespaces collapsed.
+func (h *statementHash) canonicalize(body []byte) {
+	for _, c := range body {
+		if h.quote != 0 {
+			h.write(c)
+			if c == h.quote {
+				h.quote = 0
+			}
+			continue
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			h.space = true
+			continue
+		}
+		if h.space && h.started && c != '<' && h.last != '>' {
+			h.write(' ')
+		}
+		h.space = false
+		if c == '\'' || c == '"' || c == '`' {
+			h.quote = c
+		}
+		h.write(c)
+	}
+	if len(h.canonical) >= 4096 {
+		_, _ = h.hash.Write(h.canonical)
+		h.canonical = h.canonical[:0]
+	}
+}
+
+func (h *statementHash) write(c byte) {
+	h.canonical = append(h.canonical, c)
+	h.started, h.last = true, c
+}
+
+// Sum returns the hash in hex, within which the last "</" and the bytes after it are excluded as the end element.
+func (h *statementHash) Sum() string {
+	if !bytes.HasPrefix(h.tail, endElementPrefix) {
+		h.canonicalize(h.tail)
+	}
+	h.tail = h.tail[:0]
+	_, _ = h.hash.Write(h.canonical)
+	h.canonical = h.canonical[:0]
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
+
+// hashingReader is the reader of the mapper file, which writes the bytes read by the decoder
+// to the hash of the statement being parsed, so the statements are hashed without keeping the file.
+// It implements io.ByteReader, so the decoder reads the bytes one by one instead of reading ahead.
+type hashingReader struct {
+	reader *bufio.Reader
+	// offset is the number of the bytes read.
+	offset int64
+	// hash is the hash of the statement being parsed, or nil.
+	hash *statementHash
+}
+
+func newHashingReader(reader io.Reader) *hashingReader {
+	return &hashingReader{reader: bufio.NewReader(reader)}
+}
+
+// ReadByte implements io.ByteReader.
+func (r *hashingReader) ReadByte() (byte, error) {
+	c, err := r.reader.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	r.offset++
+	if r.hash != nil {
+		_ = r.hash.WriteByte(c)
+	}
+	return c, nil
+}
+
+// Read implements io.Reader.
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.offset += int64(n)
+	if r.hash != nil {
+		for _, c := range p[:n] {
+			_ = r.hash.WriteByte(c)
+		}
+	}
+	return n, err
+}
+
+// maxIncludeHashDepth limits the nested include fragments which are hashed, against the cyclic ones.
+const maxIncludeHashDepth = 32
+
+// hashIncludes returns the hash combined with the content hashes of the include fragments,
+// which are resolved when hashed, since they may be declared by the mappers parsed later.
+// The unresolved fragments are hashed by their ids.
+func hashIncludes(hash string, includes []*IncludeNode, depth int) string {
+	if len(includes) == 0 || depth > maxIncludeHashDepth {
+		return hash
+	}
+	combined := sha256.New()
+	_, _ = io.WriteString(combined, hash)
+	for _, include := range includes {
+		_, _ = fmt.Fprintf(combined, "\x00%s=", include.refId)
+		if node, err := include.mapper.GetSQLNodeByID(include.refId); err == nil {
+			if fragment, ok := node.(*SQLNode); ok {
+				_, _ = io.WriteString(combined, hashIncludes(fragment.hash, fragment.includes, depth+1))
+			}
+		}
+	}
+	return hex.EncodeToString(combined.Sum(nil))
+}
+
+// Hash returns the sha256 content hash of the statement in the mapper file, in hex.
+// The content of the include fragments is a part of the hash.
+func (s *xmlSQLStatement) Hash() string {
+	if s.hash == "" {
+		return ""
+	}
+	return hashIncludes(s.hash, s.includes, 0)
+}
+
+// StatementHash returns the content hash of the statement,
+// it returns false if the statement is not declared in a mapper file, like the raw sql statements.
+func StatementHash(statement Statement) (string, bool) {
+	if s, ok := statement.(interface{ Hash() string }); ok {
+		hash := s.Hash()
+		return hash, hash != ""
+	}
+	return "", false
+}
+
+// StatementHashes returns the content hashes of the statements keyed by their names, like main.User.List,
+// which is the manifest to be reviewed and committed along with the mapper files.
+// It is empty if the configuration does not provide its statements.
+func (e *Engine) StatementHashes() StatementManifest {
+	hashes := make(StatementManifest)
+	provider, ok := e.GetConfiguration().(interface{ Statements() iter.Seq[Statement] })
+	if !ok {
+		return hashes
+	}
+	for statement := range provider.Statements() {
+		if hash, ok := StatementHash(statement); ok {
+			hashes[statement.Name()] = hash
+		}
+	}
+	return hashes
+}
+
+// StatementManifest is the content hashes of the statements keyed by their names.
+// It is encoded as a json object, which can be generated by Engine.WriteStatementManifest.
+type StatementManifest map[string]string
+
+// ReadStatementManifest reads the json manifest.
+func ReadStatementManifest(reader io.Reader) (StatementManifest, error) {
+	var manifest StatementManifest
+	if err := json.NewDecoder(reader).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("juice: read statement manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// WriteStatementManifest writes the content hashes of the statements as the json manifest.
+func (e *Engine) WriteStatementManifest(writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(e.StatementHashes())
+}
+
+// StatementDriftKind is the kind of the difference between the statements and the manifest.
+type StatementDriftKind string
+
+const (
+	// StatementChanged means the statement differs from the manifest.
+	StatementChanged StatementDriftKind = "changed"
+	// StatementAdded means the statement is not in the manifest.
+	StatementAdded StatementDriftKind = "added"
+	// StatementRemoved means the statement of the manifest is not deployed.
+	StatementRemoved StatementDriftKind = "removed"
+)
+
+// StatementDrift is a statement which differs from the manifest.
+type StatementDrift struct {
+	// Name is the name of the statement, like main.User.List.
+	Name string
+	Kind StatementDriftKind
+	// Expected is the hash of the manifest, which is empty if the statement is added.
+	Expected string
+	// Actual is the hash of the deployed statement, which is empty if the statement is removed.
+	Actual string
+}
+
+// String returns the drift like "main.User.List changed: 3f2a... != 9c1b...".
+func (d StatementDrift) String() string {
+	switch d.Kind {
+	case StatementAdded:
+		return fmt.Sprintf("%s %s: not in the manifest", d.Name, d.Kind)
+	case StatementRemoved:
+		return fmt.Sprintf("%s %s: not deployed", d.Name, d.Kind)
+	default:
+		return fmt.Sprintf("%s %s: %s != %s", d.Name, d.Kind, d.Actual, d.Expected)
+	}
+}
+
+// VerifyStatementManifest compares the statements with the manifest, and returns the drifts sorted by name.
+// It returns nil if the deployed statements are the reviewed ones.
+func (e *Engine) VerifyStatementManifest(manifest StatementManifest) []StatementDrift {
+	hashes := e.StatementHashes()
+	var drifts []StatementDrift
+	for name, actual := range hashes {
+		expected, ok := manifest[name]
+		switch {
+		case !ok:
+			drifts = append(drifts, StatementDrift{Name: name, Kind: StatementAdded, Actual: actual})
+		case expected != actual:
+			drifts = append(drifts, StatementDrift{Name: name, Kind: StatementChanged, Expected: expected, Actual: actual})
+		}
+	}
+	for name, expected := range manifest {
+		if _, ok := hashes[name]; !ok {
+			drifts = append(drifts, StatementDrift{Name: name, Kind: StatementRemoved, Expected: expected})
+		}
+	}
+	slices.SortFunc(drifts, func(a, b StatementDrift) int { return strings.Compare(a.Name, b.Name) })
+	return drifts
+}
+
+// checkStatementManifest warns the drifts from the manifest file of the setting "statementManifest",
+// which is checked when the engine is created.
+func (e *Engine) checkStatementManifest() {
+	path := e.GetConfiguration().Settings().Get("statementManifest").String()
+	if path == "" {
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Printf("[juice]: failed to open the statement manifest: %v", err)
+		return
+	}
+	defer func() { _ = file.Close() }()
+	manifest, err := ReadStatementManifest(file)
+	if err != nil {
+		logger.Printf("[juice]: %v", err)
+		return
+	}
+	for _, drift := range e.VerifyStatementManifest(manifest) {
+		logger.Printf("[juice]: statement drift from the manifest %s: %s", path, drift)
+	}
+}
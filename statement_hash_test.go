@@ -0,0 +1,156 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func parseHashedMapper(t *testing.T, mapper string) (*Engine, *Mapper) {
+	t.Helper()
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(mapper))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mappers Mappers
+	if err = mappers.setMapper(m.namespace, m); err != nil {
+		t.Fatal(err)
+	}
+	return &Engine{configuration: Configuration{mappers: &mappers}, rw: &NoOpRWMutex{}}, m
+}
+
+func TestStatementHash(t *testing.T) {
+	const mapper = `<mapper namespace="main.User">
+    <select id="List" timeout="3">
+        select * from user
+        <where><if test="id > 0">id = #{id}</if></where>
+    </select>
+    <delete id="Delete">delete from user where id = #{id}</delete>
+</mapper>`
+	engine, m := parseHashedMapper(t, mapper)
+	hash, ok := StatementHash(m.statements["List"])
+	if !ok || len(hash) != 64 {
+		t.Fatalf("unexpected hash: %q", hash)
+	}
+	if _, ok = StatementHash(rawSQLStatement{query: "select 1"}); ok {
+		t.Error("expected no hash of the raw statement")
+	}
+
+	var manifest bytes.Buffer
+	if err := engine.WriteStatementManifest(&manifest); err != nil {
+		t.Fatal(err)
+	}
+	reviewed, err := ReadStatementManifest(&manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reviewed) != 2 || reviewed["main.User.List"] != hash {
+		t.Fatalf("unexpected manifest: %v", reviewed)
+	}
+
+	// the whitespaces and the order of the attributes are not drifts.
+	reformatted, _ := parseHashedMapper(t, `<mapper namespace="main.User">
+    <select timeout="3" id="List">select * from user <where>
+        <if test="id > 0">id = #{id}</if>
+    </where></select>
+    <delete id="Delete">delete   from user where id = #{id}</delete>
+</mapper>`)
+	if drifts := reformatted.VerifyStatementManifest(reviewed); len(drifts) != 0 {
+		t.Fatalf("unexpected drifts: %v", drifts)
+	}
+
+	changed, _ := parseHashedMapper(t, `<mapper namespace="main.User">
+    <select id="List" timeout="3">select * from user <where><if test="id >= 0">id = #{id}</if></where></select>
+    <update id="Update">update user set name = #{name}</update>
+</mapper>`)
+	drifts := changed.VerifyStatementManifest(reviewed)
+	if len(drifts) != 3 {
+		t.Fatalf("unexpected drifts: %v", drifts)
+	}
+	want := []StatementDrift{
+		{Name: "main.User.Delete", Kind: StatementRemoved},
+		{Name: "main.User.List", Kind: StatementChanged},
+		{Name: "main.User.Update", Kind: StatementAdded},
+	}
+	for i, drift := range drifts {
+		if drift.Name != want[i].Name || drift.Kind != want[i].Kind {
+			t.Errorf("unexpected drift %d: %v", i, drift)
+		}
+	}
+}
+
+func TestStatementHash_Content(t *testing.T) {
+	hashOf := func(mapper string) string {
+		t.Helper()
+		_, m := parseHashedMapper(t, mapper)
+		hash, _ := StatementHash(m.statements["List"])
+		return hash
+	}
+	base := hashOf(`<mapper namespace="main.User">
+    <sql id="columns">id, name</sql>
+    <select id="List">select <include refid="columns"/> from user where name = 'a  b'</select>
+</mapper>`)
+
+	// the fragment declared after the statement is resolved when hashed.
+	reordered := hashOf(`<mapper namespace="main.User">
+    <select id="List">select <include refid="columns"/> from user where name = 'a  b'</select>
+    <sql id="columns">id,   name</sql>
+</mapper>`)
+	if reordered != base {
+		t.Error("expected the same hash of the reordered fragment")
+	}
+	includeChanged := hashOf(`<mapper namespace="main.User">
+    <sql id="columns">id, name, password</sql>
+    <select id="List">select <include refid="columns"/> from user where name = 'a  b'</select>
+</mapper>`)
+	if includeChanged == base {
+		t.Error("expected the changed fragment to change the hash")
+	}
+	literalChanged := hashOf(`<mapper namespace="main.User">
+    <sql id="columns">id, name</sql>
+    <select id="List">select <include refid="columns"/> from user where name = 'a b'</select>
+</mapper>`)
+	if literalChanged == base {
+		t.Error("expected the whitespaces of the literal to change the hash")
+	}
+}
+
+func TestStatementHash_Streaming(t *testing.T) {
+	parser := &XMLMappersElementParser{parser: &XMLParser{}}
+	m, err := parser.parseMapperByReader("user.xml", strings.NewReader(`<mapper namespace="main.User">
+    <delete id="Delete">
+        delete from user
+        where id = #{id}
+    </delete>
+</mapper>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parser.source != nil {
+		t.Error("expected the source of the mapper file to be released")
+	}
+	// the hash is written as the body is read, the same as the sha256 of the canonical declaration.
+	sum := sha256.Sum256([]byte("delete\x00id=Delete\x00delete from user where id = #{id}"))
+	if hash := m.statements["Delete"].hash; hash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected hash: %s", hash)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxSuggestions is the max number of the suggestions of the not found errors.
+const maxSuggestions = 3
+
+// closestNames returns the candidates closest to the name by the edit distance, which are the did-you-mean suggestions.
+// Only the candidates within a third of the length of the name, at least two, are considered close.
+func closestNames(name string, candidates iter.Seq[string]) []string {
+	type suggestion struct {
+		name     string
+		distance int
+	}
+	threshold := max(2, len(name)/3)
+	var suggestions []suggestion
+	for candidate := range candidates {
+		if distance := editDistance(name, candidate); distance <= threshold && candidate != name {
+			suggestions = append(suggestions, suggestion{name: candidate, distance: distance})
+		}
+	}
+	slices.SortFunc(suggestions, func(a, b suggestion) int {
+		return cmp.Or(cmp.Compare(a.distance, b.distance), strings.Compare(a.name, b.name))
+	})
+	names := make([]string, 0, min(len(suggestions), maxSuggestions))
+	for _, s := range suggestions[:min(len(suggestions), maxSuggestions)] {
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(target)]
+}
+
+// didYouMean formats the suggestions, like ", did you mean "a" or "b"?".
+func didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	quoted := make([]string, 0, len(suggestions))
+	for _, suggestion := range suggestions {
+		quoted = append(quoted, strconv.Quote(suggestion))
+	}
+	return ", did you mean " + strings.Join(quoted, " or ") + "?"
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// defaultWherePrefixOverrides are removed from the start of the where clause.
+	defaultWherePrefixOverrides = []string{"AND", "OR"}
+
+	// defaultWhereSuffixOverrides are removed from the end of the where clause.
+	defaultWhereSuffixOverrides = []string{"AND", "OR"}
+
+	// defaultSetOverrides are removed from both ends of the set clause.
+	defaultSetOverrides = []string{","}
+)
+
+// parseOverrides splits the value of the prefixOverrides or suffixOverrides attribute
+// like "AND |OR " into tokens, empty tokens are dropped.
+func parseOverrides(value string) []string {
+	var overrides []string
+	for _, override := range strings.Split(value, "|") {
+		if override = strings.TrimSpace(override); override != "" {
+			overrides = append(overrides, override)
+		}
+	}
+	return overrides
+}
+
+// overridesOr returns the overrides, or the defaults if no override is declared.
+func overridesOr(overrides, defaults []string) []string {
+	if len(overrides) == 0 {
+		return defaults
+	}
+	return overrides
+}
+
+// trimPrefixOverrides removes the first override found at the start of the query.
+// Overrides are matched case-insensitively and only as whole words,
+// so that "OR" never cuts the head of "ORDER".
+func trimPrefixOverrides(query string, overrides []string) string {
+	query = strings.TrimLeftFunc(query, unicode.IsSpace)
+	for _, override := range overrides {
+		override = strings.TrimSpace(override)
+		if override == "" || len(query) < len(override) || !strings.EqualFold(query[:len(override)], override) {
+			continue
+		}
+		if isWordEnd(override) && isWordStart(query[len(override):]) {
+			continue
+		}
+		return strings.TrimLeftFunc(query[len(override):], unicode.IsSpace)
+	}
+	return query
+}
+
+// trimSuffixOverrides removes the first override found at the end of the query,
+// it follows the same matching rules as trimPrefixOverrides.
+func trimSuffixOverrides(query string, overrides []string) string {
+	query = strings.TrimRightFunc(query, unicode.IsSpace)
+	for _, override := range overrides {
+		override = strings.TrimSpace(override)
+		if override == "" || len(query) < len(override) || !strings.EqualFold(query[len(query)-len(override):], override) {
+			continue
+		}
+		if isWordStart(override) && isWordEnd(query[:len(query)-len(override)]) {
+			continue
+		}
+		return strings.TrimRightFunc(query[:len(query)-len(override)], unicode.IsSpace)
+	}
+	return query
+}
+
+// hasPrefixWord reports whether the query starts with the given keyword as a whole word.
+func hasPrefixWord(query, word string) bool {
+	return len(query) >= len(word) && strings.EqualFold(query[:len(word)], word) && !isWordStart(query[len(word):])
+}
+
+// joinTrimmed joins the parts of a trimmed clause, a space is inserted between them
+// only when they would be glued into one word, "WHERE" and "id" for example.
+func joinTrimmed(prefix, query, suffix string) string {
+	var builder = getStringBuilder()
+	defer putStringBuilder(builder)
+
+	builder.Grow(len(prefix) + len(query) + len(suffix) + 2)
+	builder.WriteString(prefix)
+	if isWordEnd(prefix) && isWordStart(query) {
+		builder.WriteByte(' ')
+	}
+	builder.WriteString(query)
+	if isWordEnd(query) && isWordStart(suffix) {
+		builder.WriteByte(' ')
+	}
+	builder.WriteString(suffix)
+	return builder.String()
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isWordStart(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return size > 0 && isWordRune(r)
+}
+
+func isWordEnd(s string) bool {
+	r, size := utf8.DecodeLastRuneInString(s)
+	return size > 0 && isWordRune(r)
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-juicedev/juice/session"
+)
+
+// WarmupStatement is a statement to prepare by Engine.Warmup, with the parameter to build it.
+type WarmupStatement struct {
+	// Statement is the id or the method reference of the statement.
+	Statement any
+	// Param is the parameter to build the statement.
+	Param Param
+}
+
+// WarmupOptions is the options of Engine.Warmup.
+type WarmupOptions struct {
+	// Connections is the number of the connections opened ahead, it must not be negative,
+	// nor greater than the MaxOpenConns of the database.
+	// Note that the idle connections are kept up to the MaxIdleConns of the database.
+	Connections int
+
+	// Statements are the statements prepared ahead, like the hottest ones.
+	// The prepared statements are kept by the engine, and the queries built the same as theirs
+	// are executed with them until the engine is closed, or they are evicted by the budget, see SetCacheBudget.
+	Statements []WarmupStatement
+
+	// Results are the values of the result types, like User{} or []*User{},
+	// whose column fields are resolved ahead for mapping the rows.
+	Results []any
+}
+
+// validate checks the options against the database.
+func (o WarmupOptions) validate(db *sql.DB) error {
+	if o.Connections < 0 {
+		return fmt.Errorf("warmup: invalid connections %d", o.Connections)
+	}
+	if maxOpen := db.Stats().MaxOpenConnections; maxOpen > 0 && o.Connections > maxOpen {
+		return fmt.Errorf("warmup: connections %d exceed the max open connections %d", o.Connections, maxOpen)
+	}
+	return nil
+}
+
+// Warmup reduces the latency of the first requests after the deploys.
+// It opens the connections ahead, prepares the given statements and resolves the column fields of the result types.
+// All the errors are joined and returned, the engine is still usable even if it fails.
+//
+// Note that the statements and their expressions are already compiled when the configuration is parsed.
+func (e *Engine) Warmup(ctx context.Context, options WarmupOptions) error {
+	if err := options.validate(e.DB()); err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, result := range options.Results {
+		if err := warmupResult(result); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := e.warmupConnections(ctx, options.Connections); err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, warmup := range options.Statements {
+		if err := e.prepare(ctx, warmup); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// warmupResult resolves the column fields of the struct type of the result.
+func warmupResult(result any) error {
+	tp := reflect.TypeOf(result)
+	for tp != nil && (tp.Kind() == reflect.Pointer || tp.Kind() == reflect.Slice || tp.Kind() == reflect.Array) {
+		tp = tp.Elem()
+	}
+	if tp == nil || tp.Kind() != reflect.Struct {
+		return fmt.Errorf("warmup result: expected struct, got %T", result)
+	}
+	columnFieldsOf(tp)
+	return nil
+}
+
+// warmupConnections opens n connections at the same time, and returns them to the pool.
+func (e *Engine) warmupConnections(ctx context.Context, n int) error {
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	}()
+	for i := 0; i < n; i++ {
+		conn, err := e.DB().Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("warmup connection: %w", err)
+		}
+		conns = append(conns, conn)
+		if err = conn.PingContext(ctx); err != nil {
+			return fmt.Errorf("warmup connection: %w", err)
+		}
+	}
+	return nil
+}
+
+// prepare builds the statement with its parameter, and keeps its prepared statement by the engine.
+func (e *Engine) prepare(ctx context.Context, warmup WarmupStatement) error {
+	if e.prepared == nil {
+		return errors.New("warmup: the engine is not created by New")
+	}
+	statement, err := e.StatementOf(warmup.Statement)
+	if err != nil {
+		return err
+	}
+	query, _, err := statement.Build(e.Driver().Translator(), warmup.Param)
+	if err != nil {
+		return fmt.Errorf("warmup statement %s: %w", statement.Name(), err)
+	}
+	if e.prepared.contains(e.DB(), query) {
+		return nil
+	}
+	preparedStmt, err := e.DB().PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("warmup statement %s: %w", statement.Name(), err)
+	}
+	e.prepared.store(e.DB(), query, preparedStmt)
+	return nil
+}
+
+// preparedKey is the key of the prepared statements, which are prepared by their databases.
+type preparedKey struct {
+	db    *sql.DB
+	query string
+}
+
+// preparedStatement is a statement prepared by Engine.Warmup, which counts its users,
+// so that the statement evicted by the budget is closed after they are done.
+type preparedStatement struct {
+	stmt    *sql.Stmt
+	users   int
+	evicted bool
+}
+
+// preparedStatements are the statements prepared by Engine.Warmup, it is shared by the cloned engines.
+// The least recently used ones are evicted once it exceeds the budget set by SetCacheBudget.
+type preparedStatements struct {
+	mu    sync.Mutex
+	stmts lru[preparedKey, *preparedStatement]
+}
+
+// get returns the prepared statement of the query on the database,
+// release must be called once the statement is executed.
+func (p *preparedStatements) get(db *sql.DB, query string) (stmt *sql.Stmt, release func(), ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	prepared, ok := p.stmts.get(preparedKey{db: db, query: query})
+	if !ok {
+		return nil, nil, false
+	}
+	prepared.users++
+	release = func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		prepared.users--
+		if prepared.evicted && prepared.users == 0 {
+			_ = prepared.stmt.Close()
+		}
+	}
+	return prepared.stmt, release, true
+}
+
+// contains reports whether the query on the database is prepared.
+func (p *preparedStatements) contains(db *sql.DB, query string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.stmts.get(preparedKey{db: db, query: query})
+	return ok
+}
+
+// store keeps the prepared statement of the query on the database,
+// the statement prepared by another warmup at the same time is closed.
+func (p *preparedStatements) store(db *sql.DB, query string, stmt *sql.Stmt) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := preparedKey{db: db, query: query}
+	if _, exists := p.stmts.get(key); exists {
+		_ = stmt.Close()
+		return
+	}
+	if p.stmts.onEvict == nil {
+		p.stmts.onEvict = func(_ preparedKey, prepared *preparedStatement) {
+			prepared.evicted = true
+			if prepared.users == 0 {
+				_ = prepared.stmt.Close()
+			}
+		}
+	}
+	p.stmts.max = currentCacheBudget().PreparedStatements
+	p.stmts.set(key, &preparedStatement{stmt: stmt})
+}
+
+// Len returns the number of the prepared statements.
+func (p *preparedStatements) Len() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stmts.len()
+}
+
+// Close closes all the prepared statements, multiple errors are joined together.
+// The statements being executed are closed after they are done, see sql.Stmt.Close.
+func (p *preparedStatements) Close() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	for p.stmts.len() > 0 {
+		element := p.stmts.recency.Back()
+		entry := element.Value.(*lruEntry[preparedKey, *preparedStatement])
+		if err := entry.value.stmt.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		p.stmts.delete(entry.key)
+	}
+	return errors.Join(errs...)
+}
+
+// preparedSession is the session of the database, which executes the queries prepared by Engine.Warmup
+// with their prepared statements.
+type preparedSession struct {
+	*sql.DB
+	prepared *preparedStatements
+}
+
+// QueryContext implements session.Session.
+func (s preparedSession) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	if stmt, release, ok := s.prepared.get(s.DB, query); ok {
+		defer release()
+		return stmt.QueryContext(ctx, args...)
+	}
+	return s.DB.QueryContext(ctx, query, args...)
+}
+
+// ExecContext implements session.Session.
+func (s preparedSession) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if stmt, release, ok := s.prepared.get(s.DB, query); ok {
+		defer release()
+		return stmt.ExecContext(ctx, args...)
+	}
+	return s.DB.ExecContext(ctx, query, args...)
+}
+
+var _ session.Session = preparedSession{}
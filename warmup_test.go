@@ -0,0 +1,113 @@
+/*
+Copyright 2025 eatmoreapple
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package juice
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func newWarmupEngine(t *testing.T, counter *batchCounter) *Engine {
+	cfg, err := NewXMLConfiguration("testdata/configuration/juice.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sql.OpenDB(batchConnector{counter: counter})
+	engine := &Engine{configuration: cfg, db: db, driver: bulkDriver{}, rw: &NoOpRWMutex{}, prepared: &preparedStatements{}}
+	t.Cleanup(func() {
+		_ = engine.prepared.Close()
+		_ = db.Close()
+	})
+	return engine
+}
+
+func TestEngine_Warmup(t *testing.T) {
+	var counter batchCounter
+	engine := newWarmupEngine(t, &counter)
+
+	const id = "main.Repository.BatchInsertValues"
+	param := H{"list": []batchUser{{Name: "juice"}}}
+	err := engine.Warmup(context.Background(), WarmupOptions{
+		Connections: 2,
+		Statements:  []WarmupStatement{{Statement: id, Param: param}, {Statement: id, Param: param}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counter.prepares.Load() != 1 || engine.prepared.Len() != 1 {
+		t.Fatalf("expected 1 prepared statement, got %d prepares and %d kept", counter.prepares.Load(), engine.prepared.Len())
+	}
+
+	// the queries built the same as the prepared one are executed with the prepared statement.
+	for i := 0; i < 2; i++ {
+		if _, err = engine.Object(id).ExecContext(context.Background(), param); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counter.prepares.Load() != 1 || counter.execs.Load() != 2 {
+		t.Fatalf("expected 1 prepare and 2 execs, got %d and %d", counter.prepares.Load(), counter.execs.Load())
+	}
+
+	// the other queries are prepared by the database each time.
+	param = H{"list": []batchUser{{Name: "juice"}, {Name: "pillow"}}}
+	if _, err = engine.Object(id).ExecContext(context.Background(), param); err != nil {
+		t.Fatal(err)
+	}
+	if counter.prepares.Load() != 2 {
+		t.Fatalf("expected 2 prepares, got %d", counter.prepares.Load())
+	}
+
+	if err = engine.prepared.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if engine.prepared.Len() != 0 {
+		t.Fatalf("expected the prepared statements to be closed, got %d", engine.prepared.Len())
+	}
+}
+
+func TestEngine_WarmupResults(t *testing.T) {
+	type warmupUser struct {
+		ID   int64  `column:"id"`
+		Name string `column:"name"`
+	}
+	engine := newWarmupEngine(t, &batchCounter{})
+	if err := engine.Warmup(context.Background(), WarmupOptions{Results: []any{[]*warmupUser{}}}); err != nil {
+		t.Fatal(err)
+	}
+	columnFieldsCache.mu.Lock()
+	fields, ok := columnFieldsCache.fields.get(reflect.TypeFor[warmupUser]())
+	columnFieldsCache.mu.Unlock()
+	if !ok || len(fields) != 2 {
+		t.Fatalf("expected the column fields to be cached, got %v", fields)
+	}
+	if err := engine.Warmup(context.Background(), WarmupOptions{Results: []any{1}}); err == nil {
+		t.Fatal("expected an error for the result which is not a struct")
+	}
+}
+
+func TestEngine_WarmupOptions(t *testing.T) {
+	engine := newWarmupEngine(t, &batchCounter{})
+	if err := engine.Warmup(context.Background(), WarmupOptions{Connections: -1}); err == nil {
+		t.Fatal("expected an error for the negative connections")
+	}
+	engine.DB().SetMaxOpenConns(1)
+	if err := engine.Warmup(context.Background(), WarmupOptions{Connections: 2}); err == nil {
+		t.Fatal("expected an error for the connections exceeding the max open connections")
+	}
+}